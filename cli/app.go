@@ -21,6 +21,7 @@
 	"github.com/kopia/kopia/internal/releasable"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/logging"
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/snapshot/restore"
@@ -130,6 +131,10 @@ type App struct {
 	keyRingEnabled                bool
 	persistCredentials            bool
 	disableInternalLog            bool
+	verifyAfterWriteRate          float64
+	disableBlobChecksumVerify     bool
+	indexLoading                  string
+	compressionEntropyThreshold   float64
 	dumpAllocatorStats            bool
 	AdvancedCommands              string
 	cliStorageProviders           []StorageProvider
@@ -151,6 +156,7 @@ type App struct {
 	diff        commandDiff
 	index       commandIndex
 	list        commandList
+	object      commandObject
 	server      commandServer
 	session     commandSession
 	policy      commandPolicy
@@ -268,6 +274,10 @@ func (c *App) setup(app *kingpin.Application) {
 	app.Flag("password", "Repository password.").Envar(c.EnvName("KOPIA_PASSWORD")).Short('p').StringVar(&c.password)
 	app.Flag("persist-credentials", "Persist credentials").Default("true").Envar(c.EnvName("KOPIA_PERSIST_CREDENTIALS_ON_CONNECT")).BoolVar(&c.persistCredentials)
 	app.Flag("disable-internal-log", "Disable internal log").Hidden().Envar(c.EnvName("KOPIA_DISABLE_INTERNAL_LOG")).BoolVar(&c.disableInternalLog)
+	app.Flag("index-loading", "When to load repository indexes: eager (when opening, predictable latency) or lazy (on first access, faster startup)").Default(string(content.IndexLoadingEager)).Envar(c.EnvName("KOPIA_INDEX_LOADING")).EnumVar(&c.indexLoading, content.SupportedIndexLoadingModes()...)
+	app.Flag("disable-blob-checksum-verification", "Do not verify blob checksums when blobs are read, use 'blob verify' to verify them explicitly").Hidden().Envar(c.EnvName("KOPIA_DISABLE_BLOB_CHECKSUM_VERIFICATION")).BoolVar(&c.disableBlobChecksumVerify)
+	app.Flag("verify-after-write-rate", "Fraction (0..1) of newly written pack blobs to read back and verify").Hidden().Envar(c.EnvName("KOPIA_VERIFY_AFTER_WRITE_RATE")).Float64Var(&c.verifyAfterWriteRate)
+	app.Flag("compression-entropy-threshold", "Skip compression of contents whose sampled entropy exceeds the provided number of bits per byte (0 always compresses)").Hidden().Envar(c.EnvName("KOPIA_COMPRESSION_ENTROPY_THRESHOLD")).Float64Var(&c.compressionEntropyThreshold)
 	app.Flag("advanced-commands", "Enable advanced (and potentially dangerous) commands.").Hidden().Envar(c.EnvName("KOPIA_ADVANCED_COMMANDS")).StringVar(&c.AdvancedCommands)
 	app.Flag("track-releasable", "Enable tracking of releasable resources.").Hidden().Envar(c.EnvName("KOPIA_TRACK_RELEASABLE")).StringsVar(&c.trackReleasable)
 	app.Flag("dump-allocator-stats", "Dump allocator stats at the end of execution.").Hidden().Envar(c.EnvName("KOPIA_DUMP_ALLOCATOR_STATS")).BoolVar(&c.dumpAllocatorStats)
@@ -305,6 +315,7 @@ func (c *App) setup(app *kingpin.Application) {
 	c.index.setup(c, app)
 	c.list.setup(c, app)
 	c.logs.setup(c, app)
+	c.object.setup(c, app)
 	c.server.setup(c, app)
 	c.session.setup(c, app)
 	c.restore.setup(c, app)
@@ -259,6 +259,29 @@ func (p *cliProgress) Finish() {
 	}
 }
 
+// uploadHeartbeatProgress is the progress of snapshot creation included in heartbeats.
+type uploadHeartbeatProgress struct {
+	HashedFiles       int32 `json:"hashedFiles"`
+	HashedBytes       int64 `json:"hashedBytes"`
+	CachedFiles       int32 `json:"cachedFiles"`
+	CachedBytes       int64 `json:"cachedBytes"`
+	UploadedBytes     int64 `json:"uploadedBytes"`
+	IgnoredErrorCount int32 `json:"ignoredErrors"`
+	FatalErrorCount   int32 `json:"fatalErrors"`
+}
+
+func (p *cliProgress) heartbeatProgress() any {
+	return uploadHeartbeatProgress{
+		HashedFiles:       p.hashedFiles.Load(),
+		HashedBytes:       p.hashedBytes.Load(),
+		CachedFiles:       p.cachedFiles.Load(),
+		CachedBytes:       p.cachedBytes.Load(),
+		UploadedBytes:     p.uploadedBytes.Load(),
+		IgnoredErrorCount: p.ignoredErrorCount.Load(),
+		FatalErrorCount:   p.fatalErrorCount.Load(),
+	}
+}
+
 type cliRestoreProgress struct {
 	restoredCount      atomic.Int32
 	enqueuedCount      atomic.Int32
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandBlobAffected struct {
+	blobID string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandBlobAffected) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("affected-snapshots", "List snapshot files and directories whose contents are stored in a BLOB, such as one that is known to be corrupt")
+	cmd.Arg("blobID", "Blob ID").Required().StringVar(&c.blobID)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+type affectedEntryJSON struct {
+	SnapshotID manifest.ID         `json:"snapshotID"`
+	Source     snapshot.SourceInfo `json:"source"`
+	StartTime  time.Time           `json:"startTime"`
+
+	*snapshotfs.AffectedEntry
+}
+
+func (c *commandBlobAffected) run(ctx context.Context, rep repo.DirectRepository) error {
+	ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshots")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		return errors.Wrap(err, "unable to load snapshots")
+	}
+
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	report, err := snapshotfs.AffectedSnapshots(ctx, rep, blob.ID(c.blobID), snapshot.SortByTime(manifests, false), func(e *snapshotfs.AffectedEntry) error {
+		if c.jo.jsonOutput {
+			jl.emit(affectedEntryJSON{e.Snapshot.ID, e.Snapshot.Source, e.Snapshot.StartTime.ToTime(), e})
+			return nil
+		}
+
+		suffix := ""
+		if e.Error != "" {
+			suffix = " (unable to read: " + e.Error + ")"
+		}
+
+		c.out.printStdout("%v %v %v %v%v\n", e.Snapshot.ID, e.Snapshot.Source, formatTimestamp(e.Snapshot.StartTime.ToTime()), e.Path, suffix)
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error finding affected snapshots")
+	}
+
+	if !c.jo.jsonOutput {
+		c.out.printStderr("Blob %v has %v contents referenced by %v entries in %v of %v snapshots.\n",
+			report.BlobID, report.ContentCount, report.AffectedEntryCount, len(report.AffectedSnapshots), len(manifests))
+
+		if len(report.IncompleteSnapshots) > 0 {
+			c.out.printStderr("Some entries could not be examined in %v snapshots: %v\n", len(report.IncompleteSnapshots), report.IncompleteSnapshots)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestBlobAffectedSnapshots(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1"), []byte(strings.Repeat("kopia", 1000)), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var packs []blob.Metadata
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "blob", "list", "--prefix=p", "--json"), &packs)
+	require.Len(t, packs, 1)
+
+	var entries []struct {
+		SnapshotID string              `json:"snapshotID"`
+		Source     snapshot.SourceInfo `json:"source"`
+		StartTime  time.Time           `json:"startTime"`
+		Path       string              `json:"path"`
+		ObjectID   string              `json:"objectID"`
+		ContentIDs []string            `json:"contentIDs"`
+	}
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "blob", "affected-snapshots", string(packs[0].BlobID), "--json"), &entries)
+	require.Len(t, entries, 1)
+	require.Equal(t, "file1", entries[0].Path)
+	require.Equal(t, dir, entries[0].Source.Path)
+	require.Len(t, entries[0].ContentIDs, 1)
+
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "blob", "affected-snapshots", string(packs[0].BlobID))
+	require.Contains(t, strings.Join(stderr, "\n"), "referenced by 1 entries in 1 of 1 snapshots")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "blob", "affected-snapshots", "pnosuchblob", "--json"), &entries)
+	require.Empty(t, entries)
+}
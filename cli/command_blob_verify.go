@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/checksum"
+)
+
+type commandBlobVerify struct {
+	blobVerifyPrefix   string
+	blobVerifyParallel int
+}
+
+func (c *commandBlobVerify) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("verify", "Verify checksums of BLOBs by reading them in their entirety")
+	cmd.Flag("prefix", "Blob ID prefix").StringVar(&c.blobVerifyPrefix)
+	cmd.Flag("parallel", "Parallelism").Default("16").IntVar(&c.blobVerifyParallel)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandBlobVerify) run(ctx context.Context, rep repo.DirectRepository) error {
+	blobcfg, err := rep.FormatManager().BlobCfgBlob(ctx)
+	if err != nil {
+		return errors.Wrap(err, "blob configuration")
+	}
+
+	if blobcfg.ChecksumAlgorithm == "" {
+		return errors.New("the repository does not store blob checksums")
+	}
+
+	// verify blobs even if verification on read is disabled.
+	ctx = checksum.WithVerification(ctx)
+
+	var verifiedCount, errorCount atomic.Int32
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(max(c.blobVerifyParallel, 1))
+
+	if err := rep.BlobReader().ListBlobs(ctx, blob.ID(c.blobVerifyPrefix), func(bm blob.Metadata) error {
+		if strings.HasPrefix(string(bm.BlobID), checksum.ExcludedBlobIDPrefix) {
+			return nil
+		}
+
+		eg.Go(func() error {
+			var tmp gather.WriteBuffer
+			defer tmp.Close()
+
+			if err := rep.BlobReader().GetBlob(ctx, bm.BlobID, 0, -1, &tmp); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err() //nolint:wrapcheck
+				}
+
+				log(ctx).Errorf("error verifying blob %v: %v", bm.BlobID, err)
+				errorCount.Add(1)
+			}
+
+			verifiedCount.Add(1)
+
+			return nil
+		})
+
+		return nil
+	}); err != nil {
+		eg.Wait() //nolint:errcheck
+
+		return errors.Wrap(err, "error listing blobs")
+	}
+
+	if err := eg.Wait(); err != nil {
+		return errors.Wrap(err, "error verifying blobs")
+	}
+
+	log(ctx).Infof("Finished verifying %v blobs, found %v errors.", verifiedCount.Load(), errorCount.Load())
+
+	if ec := errorCount.Load(); ec != 0 {
+		return errors.Errorf("encountered %v errors", ec)
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ type commandCache struct {
 	prefetch commandCachePrefetch
 	set      commandCacheSetParams
 	sync     commandCacheSync
+	warm     commandCacheWarm
 }
 
 func (c *commandCache) setup(svc appServices, parent commandParent) {
@@ -16,4 +17,5 @@ func (c *commandCache) setup(svc appServices, parent commandParent) {
 	c.prefetch.setup(svc, cmd)
 	c.set.setup(svc, cmd)
 	c.sync.setup(svc, cmd)
+	c.warm.setup(svc, cmd)
 }
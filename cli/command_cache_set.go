@@ -16,10 +16,15 @@ type cacheSizeFlags struct {
 	contentCacheSizeMB      int64
 	contentCacheSizeLimitMB int64
 	contentMinSweepAge      time.Duration
+	contentCacheTTL         time.Duration
 
 	metadataCacheSizeMB      int64
 	metadataCacheSizeLimitMB int64
 	metadataMinSweepAge      time.Duration
+	metadataCacheTTL         time.Duration
+
+	contentMemoryCacheSizeMB  int64
+	metadataMemoryCacheSizeMB int64
 
 	maxListCacheDuration time.Duration
 	indexMinSweepAge     time.Duration
@@ -31,9 +36,13 @@ func (c *cacheSizeFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("content-cache-size-mb", "Desired size of local content cache (soft limit)").PlaceHolder("MB").Int64Var(&c.contentCacheSizeMB)
 	cmd.Flag("content-cache-size-limit-mb", "Maximum size of local content cache (hard limit)").PlaceHolder("MB").Int64Var(&c.contentCacheSizeLimitMB)
 	cmd.Flag("content-min-sweep-age", "Minimal age of content cache item to be subject to sweeping").DurationVar(&c.contentMinSweepAge)
+	cmd.Flag("content-cache-ttl", "Remove content cache items which have not been used in this long").DurationVar(&c.contentCacheTTL)
 	cmd.Flag("metadata-cache-size-mb", "Desired size of local metadata cache (soft limit)").PlaceHolder("MB").Int64Var(&c.metadataCacheSizeMB)
 	cmd.Flag("metadata-cache-size-limit-mb", "Maximum size of local metadata cache (hard limit)").PlaceHolder("MB").Int64Var(&c.metadataCacheSizeLimitMB)
 	cmd.Flag("metadata-min-sweep-age", "Minimal age of metadata cache item to be subject to sweeping").DurationVar(&c.metadataMinSweepAge)
+	cmd.Flag("metadata-cache-ttl", "Remove metadata cache items which have not been used in this long").DurationVar(&c.metadataCacheTTL)
+	cmd.Flag("content-memory-cache-size-mb", "Size of in-memory content cache consulted before the local content cache").PlaceHolder("MB").Int64Var(&c.contentMemoryCacheSizeMB)
+	cmd.Flag("metadata-memory-cache-size-mb", "Size of in-memory metadata cache consulted before the local metadata cache").PlaceHolder("MB").Int64Var(&c.metadataMemoryCacheSizeMB)
 	cmd.Flag("index-min-sweep-age", "Minimal age of index cache item to be subject to sweeping").DurationVar(&c.indexMinSweepAge)
 	cmd.Flag("max-list-cache-duration", "Duration of index cache").DurationVar(&c.maxListCacheDuration)
 }
@@ -52,11 +61,15 @@ func (c *commandCacheSetParams) setup(svc appServices, parent commandParent) {
 	c.contentMinSweepAge = -1
 	c.metadataMinSweepAge = -1
 	c.indexMinSweepAge = -1
+	c.contentCacheTTL = -1
+	c.metadataCacheTTL = -1
 	c.maxListCacheDuration = -1
 	c.contentCacheSizeLimitMB = -1
 	c.contentCacheSizeMB = -1
 	c.metadataCacheSizeLimitMB = -1
 	c.metadataCacheSizeMB = -1
+	c.contentMemoryCacheSizeMB = -1
+	c.metadataMemoryCacheSizeMB = -1
 	c.cacheSizeFlags.setup(cmd)
 
 	cmd.Flag("cache-directory", "Directory where to store cache files").StringVar(&c.directory)
@@ -107,6 +120,20 @@ func (c *commandCacheSetParams) run(ctx context.Context, _ repo.RepositoryWriter
 		changed++
 	}
 
+	if v := c.contentMemoryCacheSizeMB; v != -1 {
+		v *= 1e6 // convert MB to bytes
+		log(ctx).Infof("changing content memory cache size to %v", units.BytesString(v))
+		opts.ContentMemoryCacheSizeBytes = v
+		changed++
+	}
+
+	if v := c.metadataMemoryCacheSizeMB; v != -1 {
+		v *= 1e6 // convert MB to bytes
+		log(ctx).Infof("changing metadata memory cache size to %v", units.BytesString(v))
+		opts.MetadataMemoryCacheSizeBytes = v
+		changed++
+	}
+
 	if v := c.maxListCacheDuration; v != -1 {
 		log(ctx).Infof("changing list cache duration to %v", v)
 		opts.MaxListCacheDuration = content.DurationSeconds(v.Seconds())
@@ -131,6 +158,18 @@ func (c *commandCacheSetParams) run(ctx context.Context, _ repo.RepositoryWriter
 		changed++
 	}
 
+	if v := c.contentCacheTTL; v != -1 {
+		log(ctx).Infof("changing content cache TTL to %v", v)
+		opts.ContentCacheTTL = content.DurationSeconds(v.Seconds())
+		changed++
+	}
+
+	if v := c.metadataCacheTTL; v != -1 {
+		log(ctx).Infof("changing metadata cache TTL to %v", v)
+		opts.MetadataCacheTTL = content.DurationSeconds(v.Seconds())
+		changed++
+	}
+
 	if changed == 0 {
 		return errors.Errorf("no changes")
 	}
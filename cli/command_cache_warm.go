@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandCacheWarm struct {
+	snapshotID string
+	pathPrefix string
+	maxBytes   int64
+	hint       string
+
+	svc appServices
+}
+
+func (c *commandCacheWarm) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("warm", "Fetches contents of files in a snapshot into cache ahead of a restore")
+	cmd.Arg("snapshot-id", "Snapshot ID").Required().StringVar(&c.snapshotID)
+	cmd.Arg("path-prefix", "Only warm files under the provided path").StringVar(&c.pathPrefix)
+	cmd.Flag("max-bytes", "Maximum total size of files to warm (defaults to the content cache size)").Int64Var(&c.maxBytes)
+	cmd.Flag("hint", "Prefetch hint").StringVar(&c.hint)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandCacheWarm) run(ctx context.Context, rep repo.Repository) error {
+	maxBytes := c.maxBytes
+
+	if maxBytes == 0 {
+		opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+		if err != nil {
+			return errors.Wrap(err, "error getting cache options")
+		}
+
+		maxBytes = opts.ContentCacheSizeBytes
+	}
+
+	st, err := snapshotfs.WarmCacheForSnapshot(ctx, rep, manifest.ID(c.snapshotID), snapshotfs.WarmCacheOptions{
+		PathPrefix: c.pathPrefix,
+		MaxBytes:   maxBytes,
+		Hint:       c.hint,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error warming cache")
+	}
+
+	log(ctx).Infof("Warmed %v contents of %v files (%v).", st.Contents, st.Files, units.BytesString(st.Bytes))
+
+	if st.BudgetExceeded {
+		log(ctx).Warnf("Stopped after reaching the cache budget of %v, remaining files were not warmed.", units.BytesString(maxBytes))
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestCacheWarm(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file1"), []byte("some contents"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcdir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "sub", "file2"), []byte("other contents"), 0o644))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	var man cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+
+	env.RunAndExpectSuccess(t, "cache", "clear")
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "cache", "warm", string(man.ID))
+	require.Contains(t, stderr, "Warmed 2 contents of 2 files (27 B).")
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "cache", "warm", string(man.ID), "sub")
+	require.Contains(t, stderr, "Warmed 1 contents of 1 files (14 B).")
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "cache", "warm", string(man.ID), "--max-bytes=20")
+	require.True(t, hasLinePrefix(stderr, "Stopped after reaching the cache budget"), stderr)
+}
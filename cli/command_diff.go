@@ -2,6 +2,8 @@
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -9,6 +11,9 @@
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/internal/diff"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
@@ -50,6 +55,9 @@ func (c *commandDiff) run(ctx context.Context, rep repo.Repository) error {
 		return errors.New("arguments do diff must both be directories or both non-directories")
 	}
 
+	c.describeSnapshot(ctx, rep, "old", c.diffFirstObjectPath)
+	c.describeSnapshot(ctx, rep, "new", c.diffSecondObjectPath)
+
 	d, err := diff.NewComparer(c.out.stdout())
 	if err != nil {
 		return errors.Wrap(err, "error creating comparer")
@@ -69,6 +77,34 @@ func (c *commandDiff) run(ctx context.Context, rep repo.Repository) error {
 	return errors.New("comparing files not implemented yet")
 }
 
+// describeSnapshot prints the snapshot the provided object path refers to, if it can be unambiguously determined,
+// which helps tell apart compared snapshots by their descriptions.
+func (c *commandDiff) describeSnapshot(ctx context.Context, rep repo.Repository, label, objectPath string) {
+	rootID := strings.Split(filepath.ToSlash(objectPath), "/")[0]
+
+	man, err := snapshot.LoadSnapshot(ctx, rep, manifest.ID(rootID))
+	if err != nil {
+		oid, perr := object.ParseID(rootID)
+		if perr != nil {
+			return
+		}
+
+		mans, ferr := snapshot.FindSnapshotsByRootObjectID(ctx, rep, oid)
+		if ferr != nil || len(mans) != 1 {
+			return
+		}
+
+		man = mans[0]
+	}
+
+	desc := ""
+	if man.Description != "" {
+		desc = fmt.Sprintf(" %q", man.Description)
+	}
+
+	c.out.printStderr("%v: snapshot of %v at %v%v\n", label, man.Source, formatTimestamp(man.StartTime.ToTime()), desc)
+}
+
 func defaultDiffCommand() string {
 	if isWindows() {
 		return "cmp"
@@ -1,15 +1,19 @@
 package cli
 
 type commandMaintenance struct {
-	info commandMaintenanceInfo
-	run  commandMaintenanceRun
-	set  commandMaintenanceSet
+	info      commandMaintenanceInfo
+	rebalance commandMaintenanceRebalance
+	run       commandMaintenanceRun
+	set       commandMaintenanceSet
+	stats     commandMaintenanceStats
 }
 
 func (c *commandMaintenance) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("maintenance", "Maintenance commands.").Hidden().Alias("gc")
 
 	c.info.setup(svc, cmd)
+	c.rebalance.setup(svc, cmd)
 	c.run.setup(svc, cmd)
 	c.set.setup(svc, cmd)
+	c.stats.setup(svc, cmd)
 }
@@ -66,12 +66,24 @@ func (c *commandMaintenanceInfo) run(ctx context.Context, rep repo.DirectReposit
 	c.out.printStdout("  max age of logs: %v\n", cl.MaxAge)
 	c.out.printStdout("  max total size:  %v\n", units.BytesString(cl.MaxTotalSize))
 
+	if sh := p.StatsHistory.OrDefault(); !sh.Enabled {
+		c.out.printStdout("Stats History: disabled\n")
+	} else {
+		c.out.printStdout("Stats History:\n")
+		c.out.printStdout("  max count:       %v\n", sh.MaxCount)
+		c.out.printStdout("  max age:         %v\n", sh.MaxAge)
+	}
+
 	if p.ExtendObjectLocks {
 		c.out.printStdout("Object Lock Extension: enabled\n")
 	} else {
 		c.out.printStdout("Object Lock Extension: disabled\n")
 	}
 
+	if p.UnsafeContentTTL > 0 {
+		c.out.printStdout("Content TTL: %v (UNSAFE, contents are deleted even if referenced)\n", p.UnsafeContentTTL)
+	}
+
 	c.out.printStdout("Recent Maintenance Runs:\n")
 
 	for run, timings := range s.Runs {
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
+)
+
+type commandMaintenanceRebalance struct {
+	maxPackSpread float64
+	dryRun        bool
+	force         bool
+
+	heartbeatFlags
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandMaintenanceRebalance) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("rebalance", "Rewrite contents of files in the same directory into shared pack blobs to improve restore locality")
+	cmd.Flag("max-pack-spread", "Rebalance directories whose files are spread across more than this many times the minimum number of pack blobs").Default("2").Float64Var(&c.maxPackSpread)
+	cmd.Flag("dry-run", "Only report locality, without rewriting contents").Short('n').BoolVar(&c.dryRun)
+	cmd.Flag("force", "Rebalance even if maintenance is not owned (unsafe)").Hidden().BoolVar(&c.force)
+	c.heartbeatFlags.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandMaintenanceRebalance) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	hb := c.startHeartbeat(ctx, rep, "maintenance rebalance", nil)
+	defer hb.Stop(ctx)
+
+	st, err := snapshotmaintenance.RebalanceForLocality(ctx, rep, snapshotmaintenance.RebalanceOptions{
+		MaxPackSpread: c.maxPackSpread,
+		DryRun:        c.dryRun,
+		Force:         c.force,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error rebalancing contents")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(st))
+		return nil
+	}
+
+	verb := "Rewrote"
+	if c.dryRun {
+		verb = "Would rewrite"
+	}
+
+	c.out.printStdout("%v %v contents (%v) in %v of %v directories.\n", verb, st.ContentsRewritten, units.BytesString(st.BytesRewritten), st.DirectoriesRebalanced, st.Directories)
+	c.out.printStdout("Pack blobs per directory: %.2f -> %.2f\n", st.PacksPerDirectoryBefore(), st.PacksPerDirectoryAfter())
+	c.out.printStdout("Pack switches when reading directories in order: %v -> %v\n", st.PackSwitchesBefore, st.PackSwitchesAfter)
+
+	return nil
+}
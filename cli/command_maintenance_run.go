@@ -2,9 +2,11 @@
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
@@ -13,14 +15,26 @@
 type commandMaintenanceRun struct {
 	maintenanceRunFull  bool
 	maintenanceRunForce bool
+	maintenanceDryRun   bool
+	maintenancePhase    string
 	safety              maintenance.SafetyParameters
+
+	heartbeatFlags
+
+	jo  jsonOutput
+	out textOutput
 }
 
 func (c *commandMaintenanceRun) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("run", "Run repository maintenance")
 	cmd.Flag("full", "Full maintenance").BoolVar(&c.maintenanceRunFull)
 	cmd.Flag("force", "Run maintenance even if not owned (unsafe)").Hidden().BoolVar(&c.maintenanceRunForce)
+	cmd.Flag("dry-run", "Display the maintenance plan without modifying the repository").BoolVar(&c.maintenanceDryRun)
+	cmd.Flag("phase", "Run a single maintenance phase").EnumVar(&c.maintenancePhase, maintenancePhaseNames()...)
 	safetyFlagVar(cmd, &c.safety)
+	c.heartbeatFlags.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
 }
@@ -37,6 +51,104 @@ func (c *commandMaintenanceRun) run(ctx context.Context, rep repo.DirectReposito
 		mode = maintenance.ModeFull
 	}
 
+	if c.maintenanceDryRun {
+		return c.dryRun(ctx, rep, mode)
+	}
+
+	hb := c.startHeartbeat(ctx, rep, "maintenance", nil)
+	defer hb.Stop(ctx)
+
+	if c.maintenancePhase != "" {
+		return c.runPhase(ctx, rep, mode)
+	}
+
 	//nolint:wrapcheck
 	return snapshotmaintenance.Run(ctx, rep, mode, c.maintenanceRunForce, c.safety)
 }
+
+func (c *commandMaintenanceRun) runPhase(ctx context.Context, rep repo.DirectRepositoryWriter, mode maintenance.Mode) error {
+	r, err := maintenance.RunPhase(ctx, rep, maintenance.Phase(c.maintenancePhase), maintenance.PhaseOptions{
+		Full:   mode == maintenance.ModeFull,
+		Safety: c.safety,
+		Force:  c.maintenanceRunForce,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error running maintenance phase %v", c.maintenancePhase)
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(r))
+		return nil
+	}
+
+	if r.Skipped() {
+		c.out.printStdout("%v: skipped - %v\n", r.Phase, r.SkipReason)
+		return nil
+	}
+
+	c.out.printStdout("%v: completed in %v", r.Phase, r.End.Sub(r.Start).Round(time.Second))
+
+	if r.DeletedBlobs > 0 {
+		c.out.printStdout(", deleted %v blobs", r.DeletedBlobs)
+	}
+
+	c.out.printStdout("\n")
+
+	return nil
+}
+
+func (c *commandMaintenanceRun) dryRun(ctx context.Context, rep repo.DirectRepositoryWriter, mode maintenance.Mode) error {
+	plan, err := maintenance.DryRun(ctx, rep, mode, c.safety)
+	if err != nil {
+		return errors.Wrap(err, "unable to compute maintenance plan")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(plan))
+		return nil
+	}
+
+	c.out.printStdout("Plan for %v maintenance (snapshot GC not included):\n", plan.Mode)
+
+	for _, t := range plan.Tasks {
+		if t.Skipped() {
+			c.out.printStdout("  %v: skipped - %v\n", t.Task, t.SkipReason)
+			continue
+		}
+
+		c.out.printStdout("  %v:", t.Task)
+
+		if n := len(t.IndexBlobs); n > 0 {
+			c.out.printStdout(" merge %v index blobs", n)
+		}
+
+		if n := t.ContentCount; n > 0 {
+			c.out.printStdout(" %v contents (%v)", n, units.BytesString(t.ContentBytes))
+		}
+
+		if n := len(t.Blobs); n > 0 {
+			c.out.printStdout(" delete %v blobs (%v)", n, units.BytesString(t.EstimatedBytesReclaimed))
+		}
+
+		if d := t.EstimatedDuration.Round(time.Second); d > 0 {
+			c.out.printStdout(" ~%v", d)
+		}
+
+		c.out.printStdout("\n")
+	}
+
+	c.out.printStdout("Estimated space reclaimed: %v\n", units.BytesString(plan.EstimatedBytesReclaimed))
+	c.out.printStdout("Estimated duration: %v\n", plan.EstimatedDuration.Round(time.Second))
+
+	return nil
+}
+
+func maintenancePhaseNames() []string {
+	var result []string
+
+	for _, p := range maintenance.SupportedPhases() {
+		result = append(result, string(p))
+	}
+
+	return result
+}
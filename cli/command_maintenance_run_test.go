@@ -0,0 +1,33 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestMaintenanceRunPhase(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	var r maintenance.PhaseReport
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "run", "--phase=rewrite-contents", "--full", "--json"), &r)
+	require.Equal(t, maintenance.PhaseRewriteContents, r.Phase)
+	require.False(t, r.Skipped())
+
+	// orphaned blobs are not deleted right after the rewrite, which has not been finalized yet.
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "run", "--phase=delete-orphaned-blobs", "--full", "--json"), &r)
+	require.True(t, r.Skipped())
+
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--phase=index-compaction")
+	e.RunAndExpectFailure(t, "maintenance", "run", "--phase=no-such-phase")
+}
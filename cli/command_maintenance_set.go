@@ -24,7 +24,16 @@ type commandMaintenanceSet struct {
 	maxRetainedLogAge         time.Duration
 	maxTotalRetainedLogSizeMB int64
 
+	enableStatsHistory   []bool // optional boolean
+	maxStatsHistoryCount int
+	maxStatsHistoryAge   time.Duration
+
 	extendObjectLocks []bool // optional boolean
+
+	unsafeContentTTL        time.Duration
+	confirmUnsafeContentTTL bool
+
+	svc appServices
 }
 
 func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
@@ -39,6 +48,11 @@ func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
 	c.maxRetainedLogAge = -1
 	c.maxTotalRetainedLogSizeMB = -1
 
+	c.maxStatsHistoryCount = -1
+	c.maxStatsHistoryAge = -1
+
+	c.unsafeContentTTL = -1
+
 	cmd.Flag("owner", "Set maintenance owner user@hostname").StringVar(&c.maintenanceSetOwner)
 
 	cmd.Flag("enable-quick", "Enable or disable quick maintenance").BoolListVar(&c.maintenanceSetEnableQuick)
@@ -53,7 +67,14 @@ func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
 	cmd.Flag("max-retained-log-count", "Set maximum number of log sessions to retain").IntVar(&c.maxRetainedLogCount)
 	cmd.Flag("max-retained-log-age", "Set maximum age of log sessions to retain").DurationVar(&c.maxRetainedLogAge)
 	cmd.Flag("max-retained-log-size-mb", "Set maximum total size of log sessions").Int64Var(&c.maxTotalRetainedLogSizeMB)
+	cmd.Flag("enable-stats-history", "Enable or disable recording of repository statistics during full maintenance").BoolListVar(&c.enableStatsHistory)
+	cmd.Flag("max-stats-history-count", "Set maximum number of repository statistics points to retain").IntVar(&c.maxStatsHistoryCount)
+	cmd.Flag("max-stats-history-age", "Set maximum age of repository statistics points to retain").DurationVar(&c.maxStatsHistoryAge)
 	cmd.Flag("extend-object-locks", "Extend retention period of locked objects as part of full maintenance.").BoolListVar(&c.extendObjectLocks)
+	cmd.Flag("unsafe-content-ttl", "UNSAFE: Delete contents older than the provided duration during full maintenance, even if they are still referenced by snapshots. Only for repositories used as caches, 0 disables. Requires advanced commands.").DurationVar(&c.unsafeContentTTL)
+	cmd.Flag("i-understand-contents-will-be-deleted", "Confirm that contents referenced by snapshots will be deleted when setting --unsafe-content-ttl").BoolVar(&c.confirmUnsafeContentTTL)
+
+	c.svc = svc
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
 }
@@ -87,6 +108,41 @@ func (c *commandMaintenanceSet) setLogCleanupParametersFromFlags(ctx context.Con
 	}
 }
 
+func (c *commandMaintenanceSet) setStatsHistoryParametersFromFlags(ctx context.Context, p *maintenance.Params, changed *bool) {
+	// we use lists to distinguish between flag not set
+	// Zero elements == not set, more than zero - flag set, in which case we pick the last value
+	if len(c.enableStatsHistory) > 0 {
+		sh := p.StatsHistory.OrDefault()
+		sh.Enabled = c.enableStatsHistory[len(c.enableStatsHistory)-1]
+		p.StatsHistory = sh
+		*changed = true
+
+		if sh.Enabled {
+			log(ctx).Info("Stats history enabled.")
+		} else {
+			log(ctx).Info("Stats history disabled.")
+		}
+	}
+
+	if v := c.maxStatsHistoryCount; v != -1 {
+		sh := p.StatsHistory.OrDefault()
+		sh.MaxCount = v
+		p.StatsHistory = sh
+		*changed = true
+
+		log(ctx).Infof("Setting max stats history count to %v.", sh.MaxCount)
+	}
+
+	if v := c.maxStatsHistoryAge; v != -1 {
+		sh := p.StatsHistory.OrDefault()
+		sh.MaxAge = v
+		p.StatsHistory = sh
+		*changed = true
+
+		log(ctx).Infof("Setting max stats history age to %v.", sh.MaxAge)
+	}
+}
+
 func (c *commandMaintenanceSet) setMaintenanceOwnerFromFlags(ctx context.Context, p *maintenance.Params, rep repo.DirectRepositoryWriter, changed *bool) {
 	if v := c.maintenanceSetOwner; v != "" {
 		if v == "me" {
@@ -140,6 +196,29 @@ func (c *commandMaintenanceSet) setMaintenanceObjectLockExtendFromFlags(ctx cont
 	}
 }
 
+func (c *commandMaintenanceSet) setUnsafeContentTTLFromFlags(ctx context.Context, p *maintenance.Params, changed *bool) error {
+	if v := c.unsafeContentTTL; v != -1 {
+		if v != 0 {
+			c.svc.advancedCommand(ctx)
+
+			if !c.confirmUnsafeContentTTL {
+				return errors.Errorf("setting content TTL deletes contents referenced by snapshots, pass --i-understand-contents-will-be-deleted to confirm")
+			}
+		}
+
+		p.UnsafeContentTTL = v
+		*changed = true
+
+		if v == 0 {
+			log(ctx).Info("Content TTL disabled.")
+		} else {
+			log(ctx).Warnf("Setting content TTL to %v. Full maintenance will delete older contents and all snapshots referencing them, this repository must not be used for backups.", v)
+		}
+	}
+
+	return nil
+}
+
 func (c *commandMaintenanceSet) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
 	p, err := maintenance.GetParams(ctx, rep)
 	if err != nil {
@@ -157,8 +236,13 @@ func (c *commandMaintenanceSet) run(ctx context.Context, rep repo.DirectReposito
 	c.setMaintenanceEnabledAndIntervalFromFlags(ctx, &p.QuickCycle, "quick", c.maintenanceSetEnableQuick, c.maintenanceSetQuickFrequency, &changedParams)
 	c.setMaintenanceEnabledAndIntervalFromFlags(ctx, &p.FullCycle, "full", c.maintenanceSetEnableFull, c.maintenanceSetFullFrequency, &changedParams)
 	c.setLogCleanupParametersFromFlags(ctx, p, &changedParams)
+	c.setStatsHistoryParametersFromFlags(ctx, p, &changedParams)
 	c.setMaintenanceObjectLockExtendFromFlags(ctx, p, &changedParams)
 
+	if err := c.setUnsafeContentTTLFromFlags(ctx, p, &changedParams); err != nil {
+		return err
+	}
+
 	if pauseDuration := c.maintenanceSetPauseQuick; pauseDuration != -1 {
 		s.NextQuickMaintenanceTime = rep.Time().Add(pauseDuration)
 		changedSchedule = true
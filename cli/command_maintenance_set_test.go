@@ -39,6 +39,37 @@ func TestMaintenanceSetExtendObjectLocks(t *testing.T) {
 	require.False(t, mi.ExtendObjectLocks, "ExtendOjectLocks should be disabled.")
 }
 
+func TestMaintenanceSetUnsafeContentTTL(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	var mi cli.MaintenanceInfo
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &mi)
+	require.Zero(t, mi.UnsafeContentTTL)
+
+	// setting the TTL requires explicit confirmation.
+	e.RunAndExpectFailure(t, "maintenance", "set", "--unsafe-content-ttl", "168h")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &mi)
+	require.Zero(t, mi.UnsafeContentTTL)
+
+	e.RunAndExpectSuccess(t, "maintenance", "set", "--unsafe-content-ttl", "168h", "--i-understand-contents-will-be-deleted")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &mi)
+	require.Equal(t, 168*time.Hour, mi.UnsafeContentTTL)
+
+	e.RunAndExpectSuccess(t, "maintenance", "set", "--unsafe-content-ttl", "0")
+
+	mi = cli.MaintenanceInfo{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &mi)
+	require.Zero(t, mi.UnsafeContentTTL)
+}
+
 func (s *formatSpecificTestSuite) TestInvalidExtendRetainOptions(t *testing.T) {
 	var mi cli.MaintenanceInfo
 
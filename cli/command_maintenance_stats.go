@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+type commandMaintenanceStats struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandMaintenanceStats) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("stats", "Display history of repository statistics recorded by maintenance")
+	c.jo.setup(svc, cmd)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+	c.out.setup(svc)
+}
+
+func (c *commandMaintenanceStats) run(ctx context.Context, rep repo.DirectRepository) error {
+	points, err := maintenance.GetStatsHistory(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to get stats history")
+	}
+
+	var jl jsonList
+
+	if c.jo.jsonOutput {
+		jl.begin(&c.jo)
+		defer jl.end()
+	}
+
+	for _, st := range points {
+		if c.jo.jsonOutput {
+			jl.emit(st)
+			continue
+		}
+
+		c.out.printStdout("%v %-5v blobs:%v (%v) contents:%v (%v) snapshots:%v fragmentation:%.1f%%\n",
+			formatTimestamp(st.Time),
+			st.Mode,
+			st.BlobCount,
+			units.BytesString(st.BlobBytes),
+			st.ContentCount,
+			units.BytesString(st.UniqueContentBytes),
+			st.SnapshotCount,
+			100*st.Fragmentation, //nolint:mnd
+		)
+	}
+
+	return nil
+}
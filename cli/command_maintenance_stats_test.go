@@ -0,0 +1,44 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestMaintenanceStats(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	var points []maintenance.StatsPoint
+
+	// stats history is disabled by default.
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full")
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "stats", "--json"), &points)
+	require.Empty(t, points)
+
+	e.RunAndExpectSuccess(t, "maintenance", "set", "--enable-stats-history=true")
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full")
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "stats", "--json"), &points)
+	require.Len(t, points, 2)
+	require.Equal(t, 1, points[len(points)-1].SnapshotCount)
+
+	e.RunAndExpectSuccess(t, "maintenance", "set", "--max-stats-history-count=1")
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "stats", "--json"), &points)
+	require.Len(t, points, 1)
+
+	e.RunAndExpectSuccess(t, "maintenance", "stats")
+}
@@ -0,0 +1,11 @@
+package cli
+
+type commandObject struct {
+	describe commandObjectDescribe
+}
+
+func (c *commandObject) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("object", "Commands to inspect objects in repository.").Alias("objects").Hidden()
+
+	c.describe.setup(svc, cmd)
+}
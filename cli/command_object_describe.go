@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandObjectDescribe struct {
+	path          string
+	fragmentation bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandObjectDescribe) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("describe", "Displays the layout of contents backing a repository object without reading its data.")
+	cmd.Arg("object-path", "Path").Required().StringVar(&c.path)
+	cmd.Flag("fragmentation", "Include fragmentation of contents across pack blobs").BoolVar(&c.fragmentation)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+func (c *commandObjectDescribe) run(ctx context.Context, rep repo.DirectRepository) error {
+	oid, err := snapshotfs.ParseObjectIDWithPath(ctx, rep, c.path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse ID: %v", c.path)
+	}
+
+	l, err := rep.DescribeObject(ctx, oid)
+	if err != nil {
+		return errors.Wrapf(err, "error describing object %v", oid)
+	}
+
+	var f *object.Fragmentation
+
+	if c.fragmentation {
+		mp, err := rep.FormatManager().GetMutableParameters(ctx)
+		if err != nil {
+			return errors.Wrap(err, "mutable parameters")
+		}
+
+		v := l.Fragmentation(mp.PackAlignment)
+		f = &v
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(struct {
+			*object.Layout
+			Fragmentation *object.Fragmentation `json:"fragmentation,omitempty"`
+		}{l, f}))
+
+		return nil
+	}
+
+	c.printLayout(l, "", 0)
+
+	if f != nil {
+		c.out.printStdout("fragmentation: contents:%v packs:%v runs:%v averageRunLength:%.2f scatterScore:%.2f\n",
+			f.Contents, f.PackBlobs, f.Runs, f.AverageRunLength, f.ScatterScore)
+	}
+
+	return nil
+}
+
+func (c *commandObjectDescribe) printLayout(l *object.Layout, label string, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	if l.Content == nil {
+		c.out.printStdout("%v%v%v offset:%v length:%v indirection:%v entries:%v\n", indent, label, l.ObjectID, l.Offset, l.Length, l.IndirectionLevel, len(l.Entries))
+
+		if l.Index != nil {
+			c.printLayout(l.Index, "index ", depth+1)
+		}
+
+		for i := range l.Entries {
+			c.printLayout(&l.Entries[i], "", depth+1)
+		}
+
+		return
+	}
+
+	ci := l.Content
+
+	c.out.printStdout("%v%v%v offset:%v length:%v content:%v pack:%v packOffset:%v packedLength:%v\n",
+		indent, label, l.ObjectID, l.Offset, l.Length, ci.ContentID, ci.PackBlobID, ci.PackOffset, ci.PackedLength)
+}
@@ -247,6 +247,36 @@ func applyOptionalInt64MiB(ctx context.Context, desc string, val **policy.Option
 	return nil
 }
 
+func applyOptionalInt64(ctx context.Context, desc string, val **policy.OptionalInt64, str string, changeCount *int) error {
+	if str == "" {
+		// not changed
+		return nil
+	}
+
+	if str == inheritPolicyString || str == defaultPolicyString {
+		*changeCount++
+
+		log(ctx).Infof(" - resetting %q to a default value inherited from parent.", desc)
+
+		*val = nil
+
+		return nil
+	}
+
+	v, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "can't parse the %v %q", desc, str)
+	}
+
+	i := policy.OptionalInt64(v)
+	*changeCount++
+
+	log(ctx).Infof(" - setting %q to %v.", desc, i)
+	*val = &i
+
+	return nil
+}
+
 func applyPolicyNumber64(ctx context.Context, desc string, val *int64, str string, changeCount *int) error {
 	if str == "" {
 		// not changed
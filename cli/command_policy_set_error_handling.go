@@ -13,12 +13,20 @@ type policyErrorFlags struct {
 	policyIgnoreFileErrors      string
 	policyIgnoreDirectoryErrors string
 	policyIgnoreUnknownTypes    string
+
+	policyIgnorePermissionDeniedFiles       string
+	policyIgnorePermissionDeniedDirectories string
+
+	policyZeroFillDeviceReadErrors string
 }
 
 func (c *policyErrorFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("ignore-file-errors", "Ignore errors reading files while traversing ('true', 'false', 'inherit')").EnumVar(&c.policyIgnoreFileErrors, booleanEnumValues...)
 	cmd.Flag("ignore-dir-errors", "Ignore errors reading directories while traversing ('true', 'false', 'inherit").EnumVar(&c.policyIgnoreDirectoryErrors, booleanEnumValues...)
 	cmd.Flag("ignore-unknown-types", "Ignore unknown entry types in directories ('true', 'false', 'inherit").EnumVar(&c.policyIgnoreUnknownTypes, booleanEnumValues...)
+	cmd.Flag("ignore-permission-denied-files", "Skip and record files that can't be read due to insufficient permissions ('true', 'false', 'inherit')").EnumVar(&c.policyIgnorePermissionDeniedFiles, booleanEnumValues...)
+	cmd.Flag("ignore-permission-denied-dirs", "Skip and record directories that can't be read due to insufficient permissions ('true', 'false', 'inherit')").EnumVar(&c.policyIgnorePermissionDeniedDirectories, booleanEnumValues...)
+	cmd.Flag("zero-fill-device-read-errors", "Replace unreadable regions of block devices with zeros ('true', 'false', 'inherit')").EnumVar(&c.policyZeroFillDeviceReadErrors, booleanEnumValues...)
 }
 
 func (c *policyErrorFlags) setErrorHandlingPolicyFromFlags(ctx context.Context, fp *policy.ErrorHandlingPolicy, changeCount *int) error {
@@ -34,5 +42,17 @@ func (c *policyErrorFlags) setErrorHandlingPolicyFromFlags(ctx context.Context,
 		return errors.Wrap(err, "ignore unknown types")
 	}
 
+	if err := applyPolicyBoolPtr(ctx, "ignore permission denied files", &fp.IgnorePermissionDeniedFiles, c.policyIgnorePermissionDeniedFiles, changeCount); err != nil {
+		return errors.Wrap(err, "ignore permission denied files")
+	}
+
+	if err := applyPolicyBoolPtr(ctx, "ignore permission denied directories", &fp.IgnorePermissionDeniedDirectories, c.policyIgnorePermissionDeniedDirectories, changeCount); err != nil {
+		return errors.Wrap(err, "ignore permission denied directories")
+	}
+
+	if err := applyPolicyBoolPtr(ctx, "zero-fill device read errors", &fp.ZeroFillDeviceReadErrors, c.policyZeroFillDeviceReadErrors, changeCount); err != nil {
+		return errors.Wrap(err, "zero-fill device read errors")
+	}
+
 	return nil
 }
@@ -24,6 +24,8 @@ type policyFilesFlags struct {
 	// Ignore other mounted filesystems.
 	policyOneFileSystem string
 
+	policyFollowSymlinks string
+
 	policyIgnoreCacheDirs string
 }
 
@@ -42,6 +44,8 @@ func (c *policyFilesFlags) setup(cmd *kingpin.CmdClause) {
 	// Ignore other mounted filesystems.
 	cmd.Flag("one-file-system", "Stay in parent filesystem when finding files ('true', 'false', 'inherit')").EnumVar(&c.policyOneFileSystem, booleanEnumValues...)
 
+	cmd.Flag("follow-symlinks", "Snapshot targets of symbolic links instead of the links themselves ('true', 'false', 'inherit')").EnumVar(&c.policyFollowSymlinks, booleanEnumValues...)
+
 	cmd.Flag("ignore-cache-dirs", "Ignore cache directories ('true', 'false', 'inherit')").EnumVar(&c.policyIgnoreCacheDirs, booleanEnumValues...)
 }
 
@@ -57,5 +61,9 @@ func (c *policyFilesFlags) setFilesPolicyFromFlags(ctx context.Context, fp *poli
 		return err
 	}
 
+	if err := applyPolicyBoolPtr(ctx, "follow symlinks", &fp.FollowSymlinks, c.policyFollowSymlinks, changeCount); err != nil {
+		return err
+	}
+
 	return applyPolicyBoolPtr(ctx, "one filesystem", &fp.OneFileSystem, c.policyOneFileSystem, changeCount)
 }
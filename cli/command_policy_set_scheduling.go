@@ -17,6 +17,7 @@ type policySchedulingFlags struct {
 	policySetCron       string
 	policySetManual     bool
 	policySetRunMissed  string
+	policySetMaxJitter  string
 }
 
 func (c *policySchedulingFlags) setup(cmd *kingpin.CmdClause) {
@@ -24,6 +25,7 @@ func (c *policySchedulingFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("snapshot-time", "Comma-separated times of day when to take snapshot (HH:mm,HH:mm,...) or 'inherit' to remove override").StringsVar(&c.policySetTimesOfDay)
 	cmd.Flag("snapshot-time-crontab", "Semicolon-separated crontab-compatible expressions (or 'inherit')").StringVar(&c.policySetCron)
 	cmd.Flag("run-missed", "Run missed time-of-day or cron snapshots ('true', 'false', 'inherit')").EnumVar(&c.policySetRunMissed, booleanEnumValues...)
+	cmd.Flag("snapshot-jitter", "Maximum random delay of scheduled snapshots (or 'inherit')").StringVar(&c.policySetMaxJitter)
 	cmd.Flag("manual", "Only create snapshots manually").BoolVar(&c.policySetManual)
 }
 
@@ -97,6 +99,10 @@ func (c *policySchedulingFlags) setScheduleFromFlags(ctx context.Context, sp *po
 		return errors.Wrap(err, "invalid run-missed value")
 	}
 
+	if err := c.setMaxJitterFromFlags(ctx, sp, changeCount); err != nil {
+		return errors.Wrap(err, "invalid snapshot-jitter value")
+	}
+
 	if sp.Manual {
 		*changeCount++
 
@@ -117,6 +123,43 @@ func (c *policySchedulingFlags) setRunMissedFromFlags(ctx context.Context, sp *p
 	return nil
 }
 
+// Update MaxJitterSeconds policy field if changed.
+func (c *policySchedulingFlags) setMaxJitterFromFlags(ctx context.Context, sp *policy.SchedulingPolicy, changeCount *int) error {
+	switch c.policySetMaxJitter {
+	case "":
+		// not changed
+		return nil
+
+	case inheritPolicyString, defaultPolicyString:
+		*changeCount++
+
+		log(ctx).Info(" - resetting max snapshot jitter to a default value inherited from parent.")
+
+		sp.MaxJitterSeconds = nil
+
+		return nil
+	}
+
+	d, err := time.ParseDuration(c.policySetMaxJitter)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse duration")
+	}
+
+	if d < 0 {
+		return errors.New("max jitter cannot be negative")
+	}
+
+	v := policy.OptionalInt64(d / time.Second)
+
+	*changeCount++
+
+	sp.MaxJitterSeconds = &v
+
+	log(ctx).Infof(" - setting max snapshot jitter to %v", sp.MaxJitter())
+
+	return nil
+}
+
 // splitCronExpressions splits the provided string into a list of cron expressions.
 // Individual items are separated by semi-colons. As a special case, the string "inherit"
 // returns a nil slice.
@@ -12,13 +12,14 @@
 
 type policySplitterFlags struct {
 	policySetSplitterAlgorithmOverride string
+	policySetMaxChunksPerFile          string
 }
 
 func (c *policySplitterFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("splitter", "Splitter algorithm override").EnumVar(&c.policySetSplitterAlgorithmOverride, supportedSplitterAlgorithms()...)
+	cmd.Flag("max-chunks-per-file", "Maximum number of chunks a file is split into before switching to larger chunks (0 = unlimited)").PlaceHolder("N").StringVar(&c.policySetMaxChunksPerFile)
 }
 
-//nolint:unparam
 func (c *policySplitterFlags) setSplitterPolicyFromFlags(ctx context.Context, p *policy.SplitterPolicy, changeCount *int) error {
 	if v := c.policySetSplitterAlgorithmOverride; v != "" {
 		if v == inheritPolicyString {
@@ -34,7 +35,7 @@ func (c *policySplitterFlags) setSplitterPolicyFromFlags(ctx context.Context, p
 		*changeCount++
 	}
 
-	return nil
+	return applyOptionalInt(ctx, "maximum number of chunks per file", &p.MaxChunksPerFile, c.policySetMaxChunksPerFile, changeCount)
 }
 
 func supportedSplitterAlgorithms() []string {
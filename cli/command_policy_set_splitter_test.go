@@ -39,4 +39,12 @@ func TestSetSplitterPolicy(t *testing.T) {
 	require.Contains(t, lines, " Algorithm override: (repository default) inherited from (global)")
 
 	e.RunAndExpectFailure(t, "policy", "set", td, "--splitter=NO-SUCH_SPLITTER")
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--max-chunks-per-file=1000")
+
+	lines = e.RunAndExpectSuccess(t, "policy", "show", td)
+	lines = compressSpaces(lines)
+	require.Contains(t, lines, " Max chunks per file: 1000 (defined for this target)")
+
+	e.RunAndExpectFailure(t, "policy", "set", td, "--max-chunks-per-file=many")
 }
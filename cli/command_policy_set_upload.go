@@ -12,12 +12,18 @@ type policyUploadFlags struct {
 	maxParallelUploads            string
 	maxParallelFileReads          string
 	parallelizeUploadAboveSizeMiB string
+	maxParallelChunkHashing       string
+	inlineFilesUpToSize           string
+	maxDirectoryManifestEntries   string
 }
 
 func (c *policyUploadFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("max-parallel-file-reads", "Maximum number of parallel file reads").StringVar(&c.maxParallelFileReads)
 	cmd.Flag("max-parallel-snapshots", "Maximum number of parallel snapshots (server, KopiaUI only)").StringVar(&c.maxParallelUploads)
 	cmd.Flag("parallel-upload-above-size-mib", "Use parallel uploads above size").StringVar(&c.parallelizeUploadAboveSizeMiB)
+	cmd.Flag("max-parallel-chunk-hashing", "Maximum number of chunks of a single file hashed in parallel (fixed-size splitters only)").StringVar(&c.maxParallelChunkHashing)
+	cmd.Flag("inline-files-up-to-size", "Store files up to the specified size in bytes in directory manifests (-1 disables)").StringVar(&c.inlineFilesUpToSize)
+	cmd.Flag("max-directory-manifest-entries", "Split manifests of directories with more entries into shards of the specified size (0 disables)").StringVar(&c.maxDirectoryManifestEntries)
 }
 
 func (c *policyUploadFlags) setUploadPolicyFromFlags(ctx context.Context, up *policy.UploadPolicy, changeCount *int) error {
@@ -29,5 +35,17 @@ func (c *policyUploadFlags) setUploadPolicyFromFlags(ctx context.Context, up *po
 		return err
 	}
 
-	return applyOptionalInt64MiB(ctx, "parallel upload above size", &up.ParallelUploadAboveSize, c.parallelizeUploadAboveSizeMiB, changeCount)
+	if err := applyOptionalInt64MiB(ctx, "parallel upload above size", &up.ParallelUploadAboveSize, c.parallelizeUploadAboveSizeMiB, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt(ctx, "max parallel chunk hashing", &up.MaxParallelChunkHashing, c.maxParallelChunkHashing, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt64(ctx, "inline files up to size", &up.InlineFilesUpToSize, c.inlineFilesUpToSize, changeCount); err != nil {
+		return err
+	}
+
+	return applyOptionalInt(ctx, "max directory manifest entries", &up.MaxDirectoryManifestEntries, c.maxDirectoryManifestEntries, changeCount)
 }
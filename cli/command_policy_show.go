@@ -205,12 +205,29 @@ func appendFilesPolicyValue(items []policyTableRow, p *policy.Policy, def *polic
 		"  Scan one filesystem only:",
 		boolToString(p.FilesPolicy.OneFileSystem.OrDefault(false)),
 		definitionPointToString(p.Target(), def.FilesPolicy.OneFileSystem),
+	}, policyTableRow{
+		"  Follow symbolic links:",
+		boolToString(p.FilesPolicy.FollowSymlinks.OrDefault(false)),
+		definitionPointToString(p.Target(), def.FilesPolicy.FollowSymlinks),
 	})
 
 	return items
 }
 
 func appendErrorHandlingPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
+	ehp := &p.ErrorHandlingPolicy
+
+	// permission denied errors are handled the same as other errors unless explicitly set.
+	permissionDeniedFilesDef := def.ErrorHandlingPolicy.IgnoreFileErrors
+	if ehp.IgnorePermissionDeniedFiles != nil {
+		permissionDeniedFilesDef = def.ErrorHandlingPolicy.IgnorePermissionDeniedFiles
+	}
+
+	permissionDeniedDirsDef := def.ErrorHandlingPolicy.IgnoreDirectoryErrors
+	if ehp.IgnorePermissionDeniedDirectories != nil {
+		permissionDeniedDirsDef = def.ErrorHandlingPolicy.IgnorePermissionDeniedDirectories
+	}
+
 	return append(rows,
 		policyTableRow{"Error handling policy:", "", ""},
 		policyTableRow{
@@ -228,6 +245,21 @@ func appendErrorHandlingPolicyRows(rows []policyTableRow, p *policy.Policy, def
 			boolToString(p.ErrorHandlingPolicy.IgnoreUnknownTypes.OrDefault(true)),
 			definitionPointToString(p.Target(), def.ErrorHandlingPolicy.IgnoreUnknownTypes),
 		},
+		policyTableRow{
+			"  Ignore permission denied files:",
+			boolToString(ehp.IgnorePermissionDeniedFiles.OrDefault(ehp.IgnoreFileErrors.OrDefault(false))),
+			definitionPointToString(p.Target(), permissionDeniedFilesDef),
+		},
+		policyTableRow{
+			"  Ignore permission denied dirs:",
+			boolToString(ehp.IgnorePermissionDeniedDirectories.OrDefault(ehp.IgnoreDirectoryErrors.OrDefault(false))),
+			definitionPointToString(p.Target(), permissionDeniedDirsDef),
+		},
+		policyTableRow{
+			"  Zero-fill device read errors:",
+			boolToString(p.ErrorHandlingPolicy.ZeroFillDeviceReadErrors.OrDefault(false)),
+			definitionPointToString(p.Target(), def.ErrorHandlingPolicy.ZeroFillDeviceReadErrors),
+		},
 	)
 }
 
@@ -275,6 +307,9 @@ func appendUploadPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy
 		policyTableRow{"  Max parallel snapshots (server/UI):", valueOrNotSet(p.UploadPolicy.MaxParallelSnapshots), definitionPointToString(p.Target(), def.UploadPolicy.MaxParallelSnapshots)},
 		policyTableRow{"  Max parallel file reads:", valueOrNotSet(p.UploadPolicy.MaxParallelFileReads), definitionPointToString(p.Target(), def.UploadPolicy.MaxParallelFileReads)},
 		policyTableRow{"  Parallel upload above size:", valueOrNotSetOptionalInt64Bytes(p.UploadPolicy.ParallelUploadAboveSize), definitionPointToString(p.Target(), def.UploadPolicy.ParallelUploadAboveSize)},
+		policyTableRow{"  Max parallel chunk hashing:", valueOrNotSet(p.UploadPolicy.MaxParallelChunkHashing), definitionPointToString(p.Target(), def.UploadPolicy.MaxParallelChunkHashing)},
+		policyTableRow{"  Inline files up to size:", inlineFilesUpToSizeString(p.UploadPolicy.InlineFilesUpToSize), definitionPointToString(p.Target(), def.UploadPolicy.InlineFilesUpToSize)},
+		policyTableRow{"  Max directory manifest entries:", valueOrNotSet(p.UploadPolicy.MaxDirectoryManifestEntries), definitionPointToString(p.Target(), def.UploadPolicy.MaxDirectoryManifestEntries)},
 	)
 }
 
@@ -329,6 +364,10 @@ func appendSchedulingPolicyRows(rows []policyTableRow, p *policy.Policy, def *po
 		rows = append(rows, policyTableRow{"    None.", "", ""})
 	}
 
+	if !p.SchedulingPolicy.Manual {
+		rows = append(rows, policyTableRow{"  Max jitter:", p.SchedulingPolicy.MaxJitter().String(), definitionPointToString(p.Target(), def.SchedulingPolicy.MaxJitterSeconds)})
+	}
+
 	rows = append(rows, policyTableRow{"  Manual snapshot:", boolToString(p.SchedulingPolicy.Manual), definitionPointToString(p.Target(), def.SchedulingPolicy.Manual)})
 
 	return rows
@@ -396,7 +435,8 @@ func appendSplitterPolicyRows(rows []policyTableRow, p *policy.Policy, def *poli
 
 	rows = append(rows,
 		policyTableRow{"Splitter:", "", ""},
-		policyTableRow{"  Algorithm override:", algorithm, definitionPointToString(p.Target(), def.SplitterPolicy.Algorithm)})
+		policyTableRow{"  Algorithm override:", algorithm, definitionPointToString(p.Target(), def.SplitterPolicy.Algorithm)},
+		policyTableRow{"  Max chunks per file:", valueOrNotSet(p.SplitterPolicy.MaxChunksPerFile), definitionPointToString(p.Target(), def.SplitterPolicy.MaxChunksPerFile)})
 
 	return rows
 }
@@ -483,6 +523,14 @@ func valueOrNotSet(p *policy.OptionalInt) string {
 	return fmt.Sprintf("%v", *p)
 }
 
+func inlineFilesUpToSizeString(p *policy.OptionalInt64) string {
+	if p != nil && *p < 0 {
+		return "disabled"
+	}
+
+	return valueOrNotSetOptionalInt64Bytes(p)
+}
+
 func valueOrNotSetOptionalInt64Bytes(p *policy.OptionalInt64) string {
 	if p == nil {
 		return "-"
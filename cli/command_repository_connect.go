@@ -92,15 +92,19 @@ func (c *connectOptions) getFormatBlobCacheDuration() time.Duration {
 func (c *connectOptions) toRepoConnectOptions() *repo.ConnectOptions {
 	return &repo.ConnectOptions{
 		CachingOptions: content.CachingOptions{
-			CacheDirectory:              c.connectCacheDirectory,
-			ContentCacheSizeBytes:       c.contentCacheSizeMB << 20,       //nolint:mnd
-			ContentCacheSizeLimitBytes:  c.contentCacheSizeLimitMB << 20,  //nolint:mnd
-			MetadataCacheSizeBytes:      c.metadataCacheSizeMB << 20,      //nolint:mnd
-			MetadataCacheSizeLimitBytes: c.metadataCacheSizeLimitMB << 20, //nolint:mnd
-			MaxListCacheDuration:        content.DurationSeconds(c.maxListCacheDuration.Seconds()),
-			MinContentSweepAge:          content.DurationSeconds(c.contentMinSweepAge.Seconds()),
-			MinMetadataSweepAge:         content.DurationSeconds(c.metadataMinSweepAge.Seconds()),
-			MinIndexSweepAge:            content.DurationSeconds(c.indexMinSweepAge.Seconds()),
+			CacheDirectory:               c.connectCacheDirectory,
+			ContentCacheSizeBytes:        c.contentCacheSizeMB << 20,       //nolint:mnd
+			ContentCacheSizeLimitBytes:   c.contentCacheSizeLimitMB << 20,  //nolint:mnd
+			MetadataCacheSizeBytes:       c.metadataCacheSizeMB << 20,      //nolint:mnd
+			MetadataCacheSizeLimitBytes:  c.metadataCacheSizeLimitMB << 20, //nolint:mnd
+			MaxListCacheDuration:         content.DurationSeconds(c.maxListCacheDuration.Seconds()),
+			MinContentSweepAge:           content.DurationSeconds(c.contentMinSweepAge.Seconds()),
+			MinMetadataSweepAge:          content.DurationSeconds(c.metadataMinSweepAge.Seconds()),
+			MinIndexSweepAge:             content.DurationSeconds(c.indexMinSweepAge.Seconds()),
+			ContentCacheTTL:              content.DurationSeconds(c.contentCacheTTL.Seconds()),
+			MetadataCacheTTL:             content.DurationSeconds(c.metadataCacheTTL.Seconds()),
+			ContentMemoryCacheSizeBytes:  c.contentMemoryCacheSizeMB << 20,  //nolint:mnd
+			MetadataMemoryCacheSizeBytes: c.metadataMemoryCacheSizeMB << 20, //nolint:mnd
 		},
 		ClientOptions: repo.ClientOptions{
 			Hostname:                c.connectHostname,
@@ -9,6 +9,8 @@
 
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/checksum"
+	"github.com/kopia/kopia/repo/content/index"
 	"github.com/kopia/kopia/repo/ecc"
 	"github.com/kopia/kopia/repo/encryption"
 	"github.com/kopia/kopia/repo/format"
@@ -32,8 +34,11 @@ type commandRepositoryCreate struct {
 	createSplitter                    string
 	createOnly                        bool
 	createFormatVersion               int
+	createIndexOrdering               string
+	createPackAlignment               int
 	retentionMode                     string
 	retentionPeriod                   time.Duration
+	blobChecksumAlgorithm             string
 
 	co  connectOptions
 	svc advancedAppServices
@@ -50,8 +55,11 @@ func (c *commandRepositoryCreate) setup(svc advancedAppServices, parent commandP
 	cmd.Flag("object-splitter", "The splitter to use for new objects in the repository").Default(splitter.DefaultAlgorithm).EnumVar(&c.createSplitter, splitter.SupportedAlgorithms()...)
 	cmd.Flag("create-only", "Create repository, but don't connect to it.").Short('c').BoolVar(&c.createOnly)
 	cmd.Flag("format-version", "Force a particular repository format version (1, 2 or 3, 0==default)").IntVar(&c.createFormatVersion)
+	cmd.Flag("index-ordering", "[EXPERIMENTAL] Ordering of entries in index blobs.").Hidden().EnumVar(&c.createIndexOrdering, index.SupportedOrderings()...)
+	cmd.Flag("pack-alignment", "[EXPERIMENTAL] Align contents within pack blobs to the provided number of bytes (power of two, 0==no alignment).").Hidden().IntVar(&c.createPackAlignment)
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
+	cmd.Flag("blob-checksum", "Store a checksum with each blob, which is verified when the blob is read in its entirety - use 'kopia blob verify' to verify pack blobs (CRC32C is faster, SHA256 interoperates with external tools).").PlaceHolder("ALGO").EnumVar(&c.blobChecksumAlgorithm, checksum.SupportedAlgorithms()...)
 	//nolint:lll
 	cmd.Flag("format-block-key-derivation-algorithm", "Algorithm to derive the encryption key for the format block from the repository password").Default(format.DefaultKeyDerivationAlgorithm).EnumVar(&c.createBlockKeyDerivationAlgorithm, format.SupportedFormatBlobKeyDerivationAlgorithms()...)
 
@@ -81,7 +89,9 @@ func (c *commandRepositoryCreate) newRepositoryOptionsFromFlags() *repo.NewRepos
 	return &repo.NewRepositoryOptions{
 		BlockFormat: format.ContentFormat{
 			MutableParameters: format.MutableParameters{
-				Version: format.Version(c.createFormatVersion),
+				Version:       format.Version(c.createFormatVersion),
+				IndexOrdering: c.createIndexOrdering,
+				PackAlignment: c.createPackAlignment,
 			},
 			Hash:               c.createBlockHashFormat,
 			Encryption:         c.createBlockEncryptionFormat,
@@ -96,6 +106,7 @@ func (c *commandRepositoryCreate) newRepositoryOptionsFromFlags() *repo.NewRepos
 		RetentionMode:                     blob.RetentionMode(c.retentionMode),
 		RetentionPeriod:                   c.retentionPeriod,
 		FormatBlockKeyDerivationAlgorithm: c.createBlockKeyDerivationAlgorithm,
+		BlobChecksumAlgorithm:             c.blobChecksumAlgorithm,
 	}
 }
 
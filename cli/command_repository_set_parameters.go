@@ -18,6 +18,8 @@
 type commandRepositorySetParameters struct {
 	maxPackSizeMB      int
 	indexFormatVersion int
+	maxIndexBlobs      int
+	dedupScope         string
 	retentionMode      string
 	retentionPeriod    time.Duration
 
@@ -43,6 +45,8 @@ func (c *commandRepositorySetParameters) setup(svc appServices, parent commandPa
 
 	cmd.Flag("max-pack-size-mb", "Set max pack file size").PlaceHolder("MB").IntVar(&c.maxPackSizeMB)
 	cmd.Flag("index-version", "Set version of index format used for writing").IntVar(&c.indexFormatVersion)
+	cmd.Flag("max-index-blobs", "Force index compaction when flushing if the number of index blobs exceeds given threshold (0=unlimited)").Default("-1").IntVar(&c.maxIndexBlobs)
+	cmd.Flag("dedup-scope", "Set the scope within which identical contents are deduplicated, applies to newly written contents").EnumVar(&c.dedupScope, string(format.DedupScopeGlobal), string(format.DedupScopePerSource))
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, "none", blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
 
@@ -207,6 +211,20 @@ func (c *commandRepositorySetParameters) run(ctx context.Context, rep repo.Direc
 		}
 	}
 
+	if c.maxIndexBlobs >= 0 && c.maxIndexBlobs != mp.MaxIndexBlobs {
+		mp.MaxIndexBlobs = c.maxIndexBlobs
+		anyChange = true
+
+		log(ctx).Infof(" - setting max index blobs to %v.\n", c.maxIndexBlobs)
+	}
+
+	if v := format.DedupScope(c.dedupScope); v != "" && v != mp.DedupScope {
+		mp.DedupScope = v
+		anyChange = true
+
+		log(ctx).Infof(" - setting dedup scope to %v.\n", v)
+	}
+
 	if c.retentionMode == "none" {
 		if blobcfg.IsRetentionEnabled() {
 			// disable blob retention if already enabled
@@ -193,6 +193,30 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	c.out.printStdout("Max pack length:     %v\n", units.BytesString(int64(mp.MaxPackSize)))
 	c.out.printStdout("Index Format:        v%v\n", mp.IndexVersion)
 
+	if mp.MaxIndexBlobs > 0 {
+		c.out.printStdout("Max index blobs:     %v\n", mp.MaxIndexBlobs)
+	}
+
+	if mp.IndexOrdering != "" {
+		c.out.printStdout("Index Ordering:      %v\n", mp.IndexOrdering)
+	}
+
+	if mp.PackAlignment > 0 {
+		c.out.printStdout("Pack alignment:      %v\n", units.BytesString(int64(mp.PackAlignment)))
+	}
+
+	if mp.DedupScope != "" {
+		c.out.printStdout("Dedup scope:         %v\n", mp.DedupScope)
+	}
+
+	if blobcfg, _ := dr.FormatManager().BlobCfgBlob(ctx); blobcfg.ChecksumAlgorithm != "" {
+		c.out.printStdout("Blob checksum:       %v\n", blobcfg.ChecksumAlgorithm)
+	}
+
+	if blobcfg, _ := dr.FormatManager().BlobCfgBlob(ctx); blobcfg.MaxObjectSize > 0 {
+		c.out.printStdout("Max object size:     %v\n", units.BytesString(blobcfg.MaxObjectSize))
+	}
+
 	emgr, epochMgrEnabled, emerr := dr.ContentReader().EpochManager(ctx)
 	if emerr != nil {
 		return errors.Wrap(emerr, "epoch manager")
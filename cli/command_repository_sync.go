@@ -18,6 +18,8 @@
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/checksum"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/format"
 )
 
@@ -72,6 +74,24 @@ func (c *commandRepositorySyncTo) setup(svc advancedAppServices, parent commandP
 					return errors.Errorf("sync only supports directly-connected repositories")
 				}
 
+				blobcfg, err := dr.FormatManager().BlobCfgBlob(ctx)
+				if err != nil {
+					return errors.Wrap(err, "blob configuration")
+				}
+
+				if blobcfg.MaxObjectSize > 0 {
+					// the destination uses the same layout of split blobs as the source.
+					st = splitting.NewWrapper(st, blobcfg.MaxObjectSize)
+				}
+
+				if blobcfg.ChecksumAlgorithm != "" {
+					// blobs read from the source repository have their checksums verified and removed.
+					st, err = checksum.NewWrapper(st, blobcfg.ChecksumAlgorithm)
+					if err != nil {
+						return errors.Wrap(err, "unable to add blob checksum wrapper")
+					}
+				}
+
 				return c.runSyncWithStorage(ctx, dr.BlobReader(), st)
 			})
 		})
@@ -11,6 +11,7 @@
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -20,6 +21,7 @@
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/restore"
@@ -115,14 +117,23 @@ type commandRestore struct {
 	restoreSkipTimes              bool
 	restoreSkipOwners             bool
 	restoreSkipPermissions        bool
+	restoreFileFlags              bool
+	restoreMetadataOnly           bool
 	restoreIncremental            bool
 	restoreIgnoreErrors           bool
+	restoreCheckpointFile         string
+	restoreVerifyCheckpointed     bool
+	restorePlanFile               string
+	restoreCaseCollisions         string
 	restoreShallowAtDepth         int32
 	minSizeForPlaceholder         int32
 	snapshotTime                  string
 
 	restores []restoreSourceTarget
 
+	heartbeatFlags
+	retryBudgetFlags
+
 	svc appServices
 }
 
@@ -142,13 +153,21 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	cmd.Flag("skip-owners", "Skip owners during restore").BoolVar(&c.restoreSkipOwners)
 	cmd.Flag("skip-permissions", "Skip permissions during restore").BoolVar(&c.restoreSkipPermissions)
 	cmd.Flag("skip-times", "Skip times during restore").BoolVar(&c.restoreSkipTimes)
+	cmd.Flag("restore-file-flags", "Restore file flags, such as immutable or append-only, captured in the snapshot").BoolVar(&c.restoreFileFlags)
+	cmd.Flag("metadata-only", "Only restore the directory tree and file attributes, creating sparse files of the correct size without their contents, existing files are never overwritten").BoolVar(&c.restoreMetadataOnly)
 	cmd.Flag("ignore-permission-errors", "Ignore permission errors").Default("true").BoolVar(&c.restoreIgnorePermissionErrors)
 	cmd.Flag("write-files-atomically", "Write files atomically to disk, ensuring they are either fully committed, or not written at all, preventing partially written files").Default("false").BoolVar(&c.restoreWriteFilesAtomically)
 	cmd.Flag("ignore-errors", "Ignore all errors").BoolVar(&c.restoreIgnoreErrors)
 	cmd.Flag("skip-existing", "Skip files and symlinks that exist in the output").BoolVar(&c.restoreIncremental)
+	cmd.Flag("checkpoint-file", "Record restored files in the provided file (outside of the target), allowing interrupted restore to be resumed").StringVar(&c.restoreCheckpointFile)
+	cmd.Flag("verify-checkpointed-files", "When resuming restore, compare contents of already-restored files with the snapshot").BoolVar(&c.restoreVerifyCheckpointed)
+	cmd.Flag("plan-file", "Save the resolved list of entries to restore in the provided file and reuse it in subsequent restores of the same snapshot").StringVar(&c.restorePlanFile)
+	cmd.Flag("case-collisions", "How entries whose names differ only by case from a sibling are restored").Default(string(restore.CaseCollisionKeep)).EnumVar(&c.restoreCaseCollisions, restore.SupportedCaseCollisionPolicies()...)
 	cmd.Flag("shallow", "Shallow restore the directory hierarchy starting at this level (default is to deep restore the entire hierarchy.)").Int32Var(&c.restoreShallowAtDepth)
 	cmd.Flag("shallow-minsize", "When doing a shallow restore, write actual files instead of placeholders smaller than this size.").Int32Var(&c.minSizeForPlaceholder)
 	cmd.Flag("snapshot-time", "When using a path as the source, use the latest snapshot available before this date. Default is latest").Default("latest").StringVar(&c.snapshotTime)
+	c.heartbeatFlags.setup(cmd)
+	c.retryBudgetFlags.setup(cmd)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 }
 
@@ -247,6 +266,10 @@ func (c *commandRestore) restoreOutput(ctx context.Context, rep repo.Repository)
 	targetpath := c.restores[0].target
 
 	m := c.detectRestoreMode(ctx, c.restoreMode, targetpath)
+	if c.restoreMetadataOnly && m != restoreModeLocal {
+		return nil, errors.Errorf("--metadata-only is only supported when restoring to local filesystem")
+	}
+
 	switch m {
 	case restoreModeLocal:
 		o := &restore.FilesystemOutput{
@@ -260,6 +283,12 @@ func (c *commandRestore) restoreOutput(ctx context.Context, rep repo.Repository)
 			SkipPermissions:        c.restoreSkipPermissions,
 			SkipTimes:              c.restoreSkipTimes,
 			WriteSparseFiles:       c.restoreWriteSparseFiles,
+			RestoreFileFlags:       c.restoreFileFlags,
+			MetadataOnly:           c.restoreMetadataOnly,
+		}
+
+		if c.restoreMetadataOnly {
+			log(ctx).Warnf("Restoring metadata only, files in %v will have the correct size and attributes, but their contents will be empty (all zeros).", targetpath)
 		}
 
 		if err := o.Init(ctx); err != nil {
@@ -372,8 +401,26 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 		return errors.Wrap(oerr, "unable to initialize output")
 	}
 
+	var (
+		statsMutex  sync.Mutex
+		latestStats restore.Stats
+	)
+
+	hb := c.startHeartbeat(ctx, rep, "restore", func() any {
+		statsMutex.Lock()
+		defer statsMutex.Unlock()
+
+		return latestStats
+	})
+	defer hb.Stop(ctx)
+
+	ctx = c.withRetryBudget(ctx, rep)
+
 	for _, rstp := range c.restores {
-		var rootEntry fs.Entry
+		var (
+			rootEntry fs.Entry
+			plan      *restore.Plan
+		)
 
 		if rstp.isplaceholder {
 			re, err := c.setupPlaceholderExpansion(ctx, rep, rstp, output)
@@ -394,10 +441,21 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 			}
 
 			rootEntry = re
+
+			if c.restorePlanFile != "" {
+				plan, err = c.loadOrBuildRestorePlan(ctx, rep, source, rootEntry)
+				if err != nil {
+					return err
+				}
+			}
 		}
 
 		restoreProgress := c.svc.getRestoreProgress()
 		progressCallback := func(ctx context.Context, stats restore.Stats) {
+			statsMutex.Lock()
+			latestStats = stats
+			statsMutex.Unlock()
+
 			restoreProgress.SetCounters(
 				stats.EnqueuedFileCount+stats.EnqueuedDirCount+stats.EnqueuedSymlinkCount,
 				stats.RestoredFileCount+stats.RestoredDirCount+stats.RestoredSymlinkCount,
@@ -410,12 +468,16 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 		}
 
 		st, err := restore.Entry(ctx, rep, output, rootEntry, restore.Options{
-			Parallel:               c.restoreParallel,
-			Incremental:            c.restoreIncremental,
-			IgnoreErrors:           c.restoreIgnoreErrors,
-			RestoreDirEntryAtDepth: c.restoreShallowAtDepth,
-			MinSizeForPlaceholder:  c.minSizeForPlaceholder,
-			ProgressCallback:       progressCallback,
+			Parallel:                c.restoreParallel,
+			Incremental:             c.restoreIncremental,
+			IgnoreErrors:            c.restoreIgnoreErrors,
+			RestoreDirEntryAtDepth:  c.restoreShallowAtDepth,
+			MinSizeForPlaceholder:   c.minSizeForPlaceholder,
+			CheckpointFile:          c.restoreCheckpointFile,
+			VerifyCheckpointedFiles: c.restoreVerifyCheckpointed,
+			Plan:                    plan,
+			CaseCollisions:          restore.CaseCollisionPolicy(c.restoreCaseCollisions),
+			ProgressCallback:        progressCallback,
 		})
 		if err != nil {
 			return errors.Wrap(err, "error restoring")
@@ -429,6 +491,45 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 	return nil
 }
 
+// loadOrBuildRestorePlan returns the restore plan stored in the plan file if it was built for the provided
+// source, otherwise it resolves the entries to restore and saves the new plan in the file.
+func (c *commandRestore) loadOrBuildRestorePlan(ctx context.Context, rep repo.Repository, source string, rootEntry fs.Entry) (*restore.Plan, error) {
+	var snapshotID manifest.ID
+
+	if m, err := snapshot.LoadSnapshot(ctx, rep, manifest.ID(strings.Split(filepath.ToSlash(source), "/")[0])); err == nil {
+		snapshotID = m.ID
+	}
+
+	plan, err := restore.LoadPlan(c.restorePlanFile)
+
+	switch {
+	case err == nil:
+		verr := plan.Validate(snapshotID, rootEntry)
+		if verr == nil {
+			log(ctx).Infof("Using restore plan with %v entries from %v", len(plan.Entries), c.restorePlanFile)
+			return plan, nil
+		}
+
+		log(ctx).Infof("Not using restore plan from %v: %v", c.restorePlanFile, verr)
+
+	case !os.IsNotExist(err):
+		return nil, errors.Wrap(err, "unable to load restore plan")
+	}
+
+	plan, err = restore.BuildPlan(ctx, snapshotID, rootEntry)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build restore plan")
+	}
+
+	if err := restore.SavePlan(c.restorePlanFile, plan); err != nil {
+		return nil, errors.Wrap(err, "unable to save restore plan")
+	}
+
+	log(ctx).Infof("Saved restore plan with %v entries to %v", len(plan.Entries), c.restorePlanFile)
+
+	return plan, nil
+}
+
 // tryToConvertPathToID checks if the source is a path and in this case returns the ID of the snapshot
 // containing the latest version available.
 func (c *commandRestore) tryToConvertPathToID(ctx context.Context, rep repo.Repository, source string) (string, error) {
@@ -20,6 +20,7 @@ type commandServer struct {
 	throttle commandServerThrottle
 	upload   commandServerUpload
 	shutdown commandServerShutdown
+	verify   commandServerVerification
 }
 
 type serverFlags struct {
@@ -72,6 +73,7 @@ func (c *commandServer) setup(svc advancedAppServices, parent commandParent) {
 	c.pause.setup(svc, cmd)
 	c.resume.setup(svc, cmd)
 	c.throttle.setup(svc, cmd)
+	c.verify.setup(svc, cmd)
 }
 
 func (c *serverClientFlags) serverAPIClientOptions() (apiclient.Options, error) {
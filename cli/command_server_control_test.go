@@ -2,6 +2,7 @@
 
 import (
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -94,6 +95,16 @@ func TestServerControl(t *testing.T) {
 	env.RunAndExpectSuccess(t, "server", "pause", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, dir1)
 	env.RunAndExpectSuccess(t, "server", "resume", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, dir1)
 
+	// pause and resume the scheduler
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "server", "pause", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "--scheduler")
+	require.True(t, hasLinePrefix(stderr, "Scheduler paused since"), stderr)
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "server", "resume", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "--scheduler")
+	require.Contains(t, stderr, "Scheduler running")
+
+	require.Equal(t, []string{"Background verification is not enabled."},
+		env.RunAndExpectSuccess(t, "server", "verification", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword))
+
 	env.RunAndExpectSuccess(t, "server", "throttle", "set", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword,
 		"--download-bytes-per-second=1000000000",
 		"--upload-bytes-per-second=2000000000",
@@ -218,6 +229,53 @@ func TestServerControlUDS(t *testing.T) {
 	}
 }
 
+func TestServerBackgroundVerification(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	env.RunAndExpectSuccess(t, "snap", "create", testutil.TempDirectory(t))
+
+	serverStarted := make(chan struct{})
+	serverStopped := make(chan struct{})
+
+	var sp testutil.ServerParameters
+
+	go func() {
+		wait, _ := env.RunAndProcessStderr(t, sp.ProcessOutput,
+			"server", "start", "--insecure", "--random-server-control-password", "--address=127.0.0.1:0",
+			"--background-verify", "--background-verify-max-rate=1000")
+
+		close(serverStarted)
+
+		wait()
+
+		close(serverStopped)
+	}()
+
+	select {
+	case <-serverStarted:
+		t.Logf("server started on %v", sp.BaseURL)
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server did not start in time")
+	}
+
+	require.Eventually(t, func() bool {
+		lines := env.RunAndExpectSuccess(t, "server", "verification", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
+		t.Logf("lines: %v", lines)
+
+		return hasLinePrefix(lines, "Verified contents:") && !hasLine(lines, "Verified contents:     0")
+	}, 15*time.Second, 100*time.Millisecond)
+
+	env.RunAndExpectSuccess(t, "server", "shutdown", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
+
+	select {
+	case <-serverStopped:
+	case <-time.After(15 * time.Second):
+		t.Fatalf("server did not shutdown in time")
+	}
+}
+
 func hasLine(lines []string, lookFor string) bool {
 	for _, l := range lines {
 		if l == lookFor {
@@ -227,3 +285,13 @@ func hasLine(lines []string, lookFor string) bool {
 
 	return false
 }
+
+func hasLinePrefix(lines []string, prefix string) bool {
+	for _, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
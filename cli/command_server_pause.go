@@ -8,14 +8,21 @@
 
 type commandServerPause struct {
 	commandServerSourceManagerAction
+
+	scheduler bool
 }
 
 func (c *commandServerPause) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("pause", "Pause the scheduled snapshots for one or more sources")
+	cmd.Flag("scheduler", "Pause the scheduler, which suspends all scheduled snapshots and maintenance").BoolVar(&c.scheduler)
 	c.commandServerSourceManagerAction.setup(svc, cmd)
 	cmd.Action(svc.serverAction(&c.sf, c.run))
 }
 
 func (c *commandServerPause) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	if c.scheduler {
+		return c.triggerSchedulerAction(ctx, cli, "control/pause-scheduler")
+	}
+
 	return c.triggerActionOnMatchingSources(ctx, cli, "control/pause-source")
 }
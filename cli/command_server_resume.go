@@ -8,14 +8,21 @@
 
 type commandServerResume struct {
 	commandServerSourceManagerAction
+
+	scheduler bool
 }
 
 func (c *commandServerResume) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("resume", "Resume the scheduled snapshots for one or more sources").Alias("unpause")
+	cmd.Flag("scheduler", "Resume the scheduler after it has been paused").BoolVar(&c.scheduler)
 	c.commandServerSourceManagerAction.setup(svc, cmd)
 	cmd.Action(svc.serverAction(&c.sf, c.run))
 }
 
 func (c *commandServerResume) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	if c.scheduler {
+		return c.triggerSchedulerAction(ctx, cli, "control/resume-scheduler")
+	}
+
 	return c.triggerActionOnMatchingSources(ctx, cli, "control/resume-source")
 }
@@ -64,3 +64,19 @@ func (c *commandServerSourceManagerAction) triggerActionOnMatchingSources(ctx co
 
 	return nil
 }
+
+func (c *commandServerSourceManagerAction) triggerSchedulerAction(ctx context.Context, cli *apiclient.KopiaAPIClient, path string) error {
+	var resp serverapi.SchedulerStatus
+
+	if err := cli.Post(ctx, path, &serverapi.Empty{}, &resp); err != nil {
+		return errors.Wrapf(err, "server returned error")
+	}
+
+	if resp.Paused && resp.PausedSince != nil {
+		log(ctx).Infof("Scheduler paused since %v, catch-up policy: %v", formatTimestamp(*resp.PausedSince), resp.CatchUp)
+	} else {
+		log(ctx).Info("Scheduler running")
+	}
+
+	return nil
+}
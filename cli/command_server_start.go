@@ -19,7 +19,9 @@
 	htpasswd "github.com/tg123/go-htpasswd"
 
 	"github.com/kopia/kopia/internal/auth"
+	"github.com/kopia/kopia/internal/bgverify"
 	"github.com/kopia/kopia/internal/ctxutil"
+	"github.com/kopia/kopia/internal/scheduler"
 	"github.com/kopia/kopia/internal/server"
 	"github.com/kopia/kopia/repo"
 )
@@ -64,6 +66,11 @@ type commandServerStart struct {
 	persistentLogs                      bool
 	debugScheduler                      bool
 	minMaintenanceInterval              time.Duration
+	schedulerCatchUp                    string
+	backgroundVerify                    bool
+	backgroundVerifyMinRate             float64
+	backgroundVerifyMaxRate             float64
+	backgroundVerifyBusyRate            float64
 
 	shutdownGracePeriod time.Duration
 
@@ -99,6 +106,12 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 	cmd.Flag("auth-cookie-signing-key", "Force particular auth cookie signing key").Envar(svc.EnvName("KOPIA_AUTH_COOKIE_SIGNING_KEY")).Hidden().StringVar(&c.serverAuthCookieSingingKey)
 	cmd.Flag("log-scheduler", "Enable logging of scheduler actions").Hidden().Default("true").BoolVar(&c.debugScheduler)
 	cmd.Flag("min-maintenance-interval", "Minimum maintenance interval").Hidden().Default("60s").DurationVar(&c.minMaintenanceInterval)
+	cmd.Flag("scheduler-catch-up", "How snapshots missed while the scheduler was paused are handled when it's resumed").Default(string(scheduler.CatchUpRunOnce)).EnumVar(&c.schedulerCatchUp, scheduler.SupportedCatchUpPolicies()...)
+
+	cmd.Flag("background-verify", "Continuously verify repository contents in the background").BoolVar(&c.backgroundVerify)
+	cmd.Flag("background-verify-min-rate", "Contents verified per second while the server is busy").Default("0.1").Float64Var(&c.backgroundVerifyMinRate)
+	cmd.Flag("background-verify-max-rate", "Contents verified per second while the server is idle").Default("20").Float64Var(&c.backgroundVerifyMaxRate)
+	cmd.Flag("background-verify-busy-rate", "Requests per second at which the server is considered busy").Default("10").Float64Var(&c.backgroundVerifyBusyRate)
 
 	cmd.Flag("shutdown-on-stdin", "Shut down the server when stdin handle has closed.").Hidden().BoolVar(&c.serverStartShutdownWhenStdinClosed)
 
@@ -139,6 +152,16 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 		uiPreferencesFile = filepath.Join(filepath.Dir(c.svc.repositoryConfigFileName()), "ui-preferences.json")
 	}
 
+	var bgv *bgverify.Options
+
+	if c.backgroundVerify {
+		bgv = &bgverify.Options{
+			MinRate:            c.backgroundVerifyMinRate,
+			MaxRate:            c.backgroundVerifyMaxRate,
+			BusyForegroundRate: c.backgroundVerifyBusyRate,
+		}
+	}
+
 	return &server.Options{
 		ConfigFile:           c.svc.repositoryConfigFileName(),
 		ConnectOptions:       c.co.toRepoConnectOptions(),
@@ -157,6 +180,8 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 
 		DebugScheduler:         c.debugScheduler,
 		MinMaintenanceInterval: c.minMaintenanceInterval,
+		SchedulerCatchUp:       scheduler.CatchUpPolicy(c.schedulerCatchUp),
+		BackgroundVerification: bgv,
 		DisableCSRFTokenChecks: c.disableCSRFTokenChecks,
 	}, nil
 }
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+)
+
+type commandServerVerification struct {
+	sf serverClientFlags
+
+	out textOutput
+}
+
+func (c *commandServerVerification) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("verification", "Show status of background verification of repository contents")
+
+	c.sf.setup(svc, cmd)
+	c.out.setup(svc)
+
+	cmd.Action(svc.serverAction(&c.sf, c.run))
+}
+
+func (c *commandServerVerification) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	var resp serverapi.BackgroundVerificationStatus
+
+	if err := cli.Get(ctx, "control/verification", nil, &resp); err != nil {
+		return errors.Wrap(err, "unable to get background verification status")
+	}
+
+	if !resp.Enabled || resp.Status == nil {
+		c.out.printStdout("Background verification is not enabled.\n")
+		return nil
+	}
+
+	st := resp.Status
+
+	c.out.printStdout("Verification rate:     %.2f contents/s\n", st.Rate)
+	c.out.printStdout("Foreground rate:       %.2f requests/s\n", st.ForegroundRate)
+	c.out.printStdout("Verified contents:     %v\n", st.VerifiedContents)
+	c.out.printStdout("Failed contents:       %v\n", st.FailedContents)
+	c.out.printStdout("Coverage:              %.1f%% of %v contents in the last %v\n", 100*st.Coverage, st.TotalContents, st.CoverageWindow)
+
+	if st.LastError != "" {
+		c.out.printStdout("Last error:            %v\n", st.LastError)
+	}
+
+	return nil
+}
@@ -9,9 +9,11 @@ type commandSnapshot struct {
 	expire      commandSnapshotExpire
 	fix         commandSnapshotFix
 	list        commandSnapshotList
+	listFiles   commandSnapshotListFiles
 	migrate     commandSnapshotMigrate
 	pin         commandSnapshotPin
 	restore     commandSnapshotRestore
+	retention   commandSnapshotRetentionLock
 	verify      commandSnapshotVerify
 }
 
@@ -25,8 +27,10 @@ func (c *commandSnapshot) setup(svc advancedAppServices, parent commandParent) {
 	c.expire.setup(svc, cmd)
 	c.fix.setup(svc, cmd)
 	c.list.setup(svc, cmd)
+	c.listFiles.setup(svc, cmd)
 	c.migrate.setup(svc, cmd)
 	c.pin.setup(svc, cmd)
 	c.restore.setup(svc, cmd)
+	c.retention.setup(svc, cmd)
 	c.verify.setup(svc, cmd)
 }
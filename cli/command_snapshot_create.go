@@ -18,10 +18,7 @@
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
-const (
-	maxSnapshotDescriptionLength = 1024
-	timeFormat                   = "2006-01-02 15:04:05 MST"
-)
+const timeFormat = "2006-01-02 15:04:05 MST"
 
 type commandSnapshotCreate struct {
 	snapshotCreateSources                 []string
@@ -38,6 +35,8 @@ type commandSnapshotCreate struct {
 	snapshotCreateStdinFileName           string
 	snapshotCreateCheckpointUploadLimitMB int64
 	snapshotCreateTags                    []string
+	snapshotCreateCaptureFileFlags        bool
+	snapshotCreateReproducible            bool
 	flushPerSource                        bool
 	sourceOverride                        string
 
@@ -46,6 +45,9 @@ type commandSnapshotCreate struct {
 	logDirDetail   int
 	logEntryDetail int
 
+	heartbeatFlags
+	retryBudgetFlags
+
 	jo  jsonOutput
 	svc appServices
 	out textOutput
@@ -71,6 +73,8 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	cmd.Flag("pin", "Create a pinned snapshot that will not expire automatically").StringsVar(&c.pins)
 	cmd.Flag("flush-per-source", "Flush writes at the end of each source").Hidden().BoolVar(&c.flushPerSource)
 	cmd.Flag("override-source", "Override the source of the snapshot.").StringVar(&c.sourceOverride)
+	cmd.Flag("capture-file-flags", "Capture file flags, such as immutable or append-only").BoolVar(&c.snapshotCreateCaptureFileFlags)
+	cmd.Flag("reproducible", "Replace wall-clock derived metadata, such as the modification time of --stdin-file, so that identical inputs produce identical root object IDs").BoolVar(&c.snapshotCreateReproducible)
 
 	c.logDirDetail = -1
 	c.logEntryDetail = -1
@@ -78,6 +82,8 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	cmd.Flag("log-dir-detail", "Override log level for directories").IntVar(&c.logDirDetail)
 	cmd.Flag("log-entry-detail", "Override log level for entries").IntVar(&c.logEntryDetail)
 
+	c.heartbeatFlags.setup(cmd)
+	c.retryBudgetFlags.setup(cmd)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 
@@ -114,12 +120,17 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 		return err
 	}
 
-	if len(c.snapshotCreateDescription) > maxSnapshotDescriptionLength {
-		return errors.New("description too long")
+	if err := snapshot.ValidateDescription(c.snapshotCreateDescription); err != nil {
+		return errors.Wrap(err, "invalid description")
 	}
 
 	u := c.setupUploader(rep)
 
+	hb := c.startHeartbeat(ctx, rep, "snapshot-create", c.svc.getProgress().heartbeatProgress)
+	defer hb.Stop(ctx)
+
+	ctx = c.withRetryBudget(ctx, rep)
+
 	var finalErrors []string
 
 	tags, err := getTags(c.snapshotCreateTags)
@@ -239,6 +250,8 @@ func (c *commandSnapshotCreate) setupUploader(rep repo.RepositoryWriter) *snapsh
 	u.ParallelUploads = c.snapshotCreateParallelUploads
 
 	u.FailFast = c.snapshotCreateFailFast
+	u.CaptureFileFlags = c.snapshotCreateCaptureFileFlags
+	u.Reproducible = c.snapshotCreateReproducible
 	u.Progress = c.svc.getProgress()
 
 	return u
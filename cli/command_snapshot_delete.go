@@ -10,6 +10,7 @@
 	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
 )
 
 type commandSnapshotDelete struct {
@@ -76,6 +77,27 @@ func (c *commandSnapshotDelete) snapshotDeleteSources(ctx context.Context, rep r
 				return errors.Wrap(err, "error deleting")
 			}
 		}
+
+		if err := reportRetentionLocksOfSource(ctx, rep, si); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportRetentionLocksOfSource informs about files of the source which are kept by retention locks
+// after all its snapshots have been deleted.
+func reportRetentionLocksOfSource(ctx context.Context, rep repo.Repository, si snapshot.SourceInfo) error {
+	locks, err := retentionlock.ListActive(ctx, rep, rep.Time())
+	if err != nil {
+		return errors.Wrap(err, "error listing retention locks")
+	}
+
+	for _, l := range locks {
+		if l.Source == si {
+			log(ctx).Infof("File %v of %v is kept by retention lock %v until %v.", l.Path, si, l.ID, formatTimestamp(l.RetainUntil))
+		}
 	}
 
 	return nil
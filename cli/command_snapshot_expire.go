@@ -2,11 +2,13 @@
 
 import (
 	"context"
+	"fmt"
 	"sort"
 
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
 )
@@ -71,8 +73,26 @@ func (c *commandSnapshotExpire) run(ctx context.Context, rep repo.RepositoryWrit
 			log(ctx).Infof("Deleted %v snapshots of %v...", len(deleted), src)
 		} else {
 			log(ctx).Infof("%v snapshot(s) of %v would be deleted. Pass --delete to do it.", len(deleted), src)
+			c.logSnapshots(ctx, rep, deleted)
 		}
 	}
 
 	return nil
 }
+
+func (c *commandSnapshotExpire) logSnapshots(ctx context.Context, rep repo.Repository, ids []manifest.ID) {
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		log(ctx).Errorf("unable to load snapshots: %v", err)
+		return
+	}
+
+	for _, m := range snapshot.SortByTime(manifests, false) {
+		desc := ""
+		if m.Description != "" {
+			desc = fmt.Sprintf(" %q", m.Description)
+		}
+
+		log(ctx).Infof("  %v %v%v", formatTimestamp(m.StartTime.ToTime()), m.ID, desc)
+	}
+}
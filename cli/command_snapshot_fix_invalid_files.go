@@ -35,7 +35,7 @@ func (c *commandSnapshotFixInvalidFiles) setup(svc appServices, parent commandPa
 func (c *commandSnapshotFixInvalidFiles) rewriteEntry(ctx context.Context, dirRelativePath string, ent *snapshot.DirEntry) (*snapshot.DirEntry, error) {
 	fname := dirRelativePath + "/" + ent.Name
 
-	if ent.Type != snapshot.EntryTypeDirectory {
+	if ent.Type != snapshot.EntryTypeDirectory && !ent.IsInline() {
 		if err := c.verifier.VerifyFile(ctx, ent.ObjectID, fname); err != nil {
 			log(ctx).Warnf("removing invalid file %v due to: %v", fname, err)
 
@@ -34,6 +34,7 @@ type commandSnapshotList struct {
 	snapshotListShowAll              bool
 	maxResultsPerPath                int
 	snapshotListTags                 []string
+	snapshotListDescription          string
 	storageStats                     bool
 	reverseSort                      bool
 
@@ -57,6 +58,7 @@ func (c *commandSnapshotList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("all", "Show all snapshots (not just current username/host)").Short('a').BoolVar(&c.snapshotListShowAll)
 	cmd.Flag("max-results", "Maximum number of entries per source.").Short('n').IntVar(&c.maxResultsPerPath)
 	cmd.Flag("tags", "Tag filters to apply on the list items. Must be provided in the <key>:<value> format.").StringsVar(&c.snapshotListTags)
+	cmd.Flag("description", "Only list snapshots whose description contains the provided text (case-insensitive).").StringVar(&c.snapshotListDescription)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -135,6 +137,10 @@ func (c *commandSnapshotList) run(ctx context.Context, rep repo.Repository) erro
 		return errors.Wrap(err, "unable to load snapshots")
 	}
 
+	if c.snapshotListDescription != "" {
+		manifests = snapshot.FilterByDescription(manifests, c.snapshotListDescription)
+	}
+
 	if c.jo.jsonOutput {
 		return c.outputJSON(ctx, rep, manifests)
 	}
@@ -438,6 +444,10 @@ func (c *commandSnapshotList) entryBits(ctx context.Context, m *snapshot.Manifes
 		}
 	}
 
+	if m.Description != "" {
+		bits = append(bits, fmt.Sprintf("description:%q", m.Description))
+	}
+
 	if u := m.StorageStats; u != nil {
 		bits = append(bits,
 			fmt.Sprintf("new-data:%v", units.BytesString(atomic.LoadInt64(&u.NewData.PackedContentBytes))),
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandSnapshotListFiles struct {
+	snapshotID string
+	pathPrefix string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotListFiles) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("list-files", "List all files and directories in a snapshot without restoring it")
+	cmd.Arg("snapshot-id", "Snapshot ID").Required().StringVar(&c.snapshotID)
+	cmd.Arg("path-prefix", "Only list entries under the provided path").StringVar(&c.pathPrefix)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotListFiles) run(ctx context.Context, rep repo.Repository) error {
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	//nolint:wrapcheck
+	return snapshotfs.ListFiles(ctx, rep, manifest.ID(c.snapshotID), c.pathPrefix, func(e *snapshotfs.FileListEntry) error {
+		if c.jo.jsonOutput {
+			jl.emit(e)
+		} else {
+			c.out.printStdout("%v %12d %v %-34v %v\n", e.Mode, e.Size, formatTimestamp(e.ModTime.Local()), e.ObjectID, e.Path)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotListFiles(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "top"), []byte{1, 2, 3}, 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcdir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "sub", "nested"), []byte{1, 2, 3, 4, 5}, 0o644))
+
+	var man cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+
+	var entries []snapshotfs.FileListEntry
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list-files", string(man.ID), "--json"), &entries)
+	require.Len(t, entries, 3)
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "list-files", string(man.ID), "sub")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], " 5 ")
+	require.Contains(t, lines[1], " sub/nested")
+
+	e.RunAndExpectFailure(t, "snapshot", "list-files", "no-such-snapshot")
+}
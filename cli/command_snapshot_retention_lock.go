@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+)
+
+type commandSnapshotRetentionLock struct {
+	add    commandSnapshotRetentionLockAdd
+	delete commandSnapshotRetentionLockDelete
+	extend commandSnapshotRetentionLockExtend
+	list   commandSnapshotRetentionLockList
+}
+
+func (c *commandSnapshotRetentionLock) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("retention-lock", "Manage retention locks keeping individual files until the specified time regardless of snapshot retention")
+
+	c.add.setup(svc, cmd)
+	c.delete.setup(svc, cmd)
+	c.extend.setup(svc, cmd)
+	c.list.setup(svc, cmd)
+}
+
+type retainUntilFlags struct {
+	until     string
+	retainFor time.Duration
+}
+
+func (c *retainUntilFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("until", "Retain until the provided time (RFC 3339 or YYYY-MM-DD)").StringVar(&c.until)
+	cmd.Flag("retain-for", "Retain for the provided duration").DurationVar(&c.retainFor)
+}
+
+func (c *retainUntilFlags) retainUntil(now time.Time) (time.Time, error) {
+	switch {
+	case c.until != "" && c.retainFor != 0:
+		return time.Time{}, errors.New("--until and --retain-for are mutually exclusive")
+
+	case c.retainFor > 0:
+		return now.Add(c.retainFor), nil
+
+	case c.until != "":
+		if t, err := time.Parse(time.RFC3339, c.until); err == nil {
+			return t, nil
+		}
+
+		t, err := time.ParseInLocation("2006-01-02", c.until, time.Local)
+
+		return t, errors.Wrap(err, "invalid --until")
+
+	default:
+		return time.Time{}, errors.New("must specify --until or --retain-for")
+	}
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+)
+
+type commandSnapshotRetentionLockAdd struct {
+	snapshotID  string
+	path        string
+	objectID    string
+	description string
+
+	retainUntilFlags
+}
+
+func (c *commandSnapshotRetentionLockAdd) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("add", "Lock a file in a snapshot or an object until the provided time")
+	cmd.Arg("snapshot-id", "Snapshot ID").StringVar(&c.snapshotID)
+	cmd.Arg("path", "Path of the file within the snapshot").StringVar(&c.path)
+	cmd.Flag("object-id", "Lock the object with the provided ID instead of a file in a snapshot").StringVar(&c.objectID)
+	cmd.Flag("description", "Lock description").StringVar(&c.description)
+	c.retainUntilFlags.setup(cmd)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandSnapshotRetentionLockAdd) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	until, err := c.retainUntil(rep.Time())
+	if err != nil {
+		return err
+	}
+
+	var l *retentionlock.Lock
+
+	switch {
+	case c.objectID != "" && c.snapshotID != "":
+		return errors.New("--object-id can't be combined with a snapshot ID")
+
+	case c.objectID != "":
+		oid, err := object.ParseID(c.objectID)
+		if err != nil {
+			return errors.Wrap(err, "unable to parse object ID")
+		}
+
+		l = &retentionlock.Lock{ObjectID: oid, RetainUntil: until, Description: c.description}
+
+		if _, err := retentionlock.Create(ctx, rep, l); err != nil {
+			return errors.Wrap(err, "unable to create retention lock")
+		}
+
+	case c.snapshotID != "" && c.path != "":
+		m, err := snapshot.LoadSnapshot(ctx, rep, manifest.ID(c.snapshotID))
+		if err != nil {
+			return errors.Wrapf(err, "error loading snapshot %v", c.snapshotID)
+		}
+
+		l, err = retentionlock.LockFile(ctx, rep, m, c.path, until, c.description)
+		if err != nil {
+			return errors.Wrap(err, "unable to create retention lock")
+		}
+
+	default:
+		return errors.New("must specify a snapshot ID and path or --object-id")
+	}
+
+	log(ctx).Infof("Created retention lock %v for %v until %v.", l.ID, l.ObjectID, formatTimestamp(l.RetainUntil))
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+)
+
+type commandSnapshotRetentionLockDelete struct {
+	ids []string
+}
+
+func (c *commandSnapshotRetentionLockDelete) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("delete", "Delete expired retention locks").Alias("remove").Alias("rm")
+	cmd.Arg("id", "Retention lock ID").Required().StringsVar(&c.ids)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandSnapshotRetentionLockDelete) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	for _, id := range c.ids {
+		if err := retentionlock.Delete(ctx, rep, manifest.ID(id)); err != nil {
+			return errors.Wrapf(err, "error deleting retention lock %v", id)
+		}
+
+		log(ctx).Infof("Deleted retention lock %v.", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+)
+
+type commandSnapshotRetentionLockExtend struct {
+	ids []string
+
+	retainUntilFlags
+}
+
+func (c *commandSnapshotRetentionLockExtend) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("extend", "Extend retention locks, which can't be shortened")
+	cmd.Arg("id", "Retention lock ID").Required().StringsVar(&c.ids)
+	c.retainUntilFlags.setup(cmd)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandSnapshotRetentionLockExtend) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	until, err := c.retainUntil(rep.Time())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range c.ids {
+		l, err := retentionlock.Extend(ctx, rep, manifest.ID(id), until)
+		if err != nil {
+			return errors.Wrapf(err, "error extending retention lock %v", id)
+		}
+
+		log(ctx).Infof("Extended retention lock %v for %v until %v.", l.ID, l.ObjectID, formatTimestamp(l.RetainUntil))
+	}
+
+	return nil
+}
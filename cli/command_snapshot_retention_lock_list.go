@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+)
+
+type commandSnapshotRetentionLockList struct {
+	all bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotRetentionLockList) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("list", "List retention locks").Alias("ls")
+	cmd.Flag("all", "Include expired locks").BoolVar(&c.all)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotRetentionLockList) run(ctx context.Context, rep repo.Repository) error {
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	locks, err := retentionlock.List(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "error listing retention locks")
+	}
+
+	now := rep.Time()
+
+	for _, l := range locks {
+		expired := l.Expired(now)
+		if expired && !c.all {
+			continue
+		}
+
+		if c.jo.jsonOutput {
+			jl.emit(retentionLockListItem{l.ID, expired, l})
+			continue
+		}
+
+		status := "until"
+		if expired {
+			status = "expired"
+		}
+
+		c.out.printStdout("%v %v %v %v %v %v\n", l.ID, status, formatTimestamp(l.RetainUntil), l.ObjectID, l.Source, l.Path)
+	}
+
+	return nil
+}
+
+type retentionLockListItem struct {
+	ID      manifest.ID `json:"id"`
+	Expired bool        `json:"expired,omitempty"`
+	*retentionlock.Lock
+}
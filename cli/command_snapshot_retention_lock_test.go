@@ -0,0 +1,62 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotRetentionLock(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcdir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "sub", "locked.txt"), []byte("locked contents"), 0o644))
+
+	var man cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+
+	e.RunAndExpectFailure(t, "snapshot", "retention-lock", "add", string(man.ID), "sub/locked.txt")
+	e.RunAndExpectFailure(t, "snapshot", "retention-lock", "add", string(man.ID), "sub", "--retain-for=24h")
+	e.RunAndExpectSuccess(t, "snapshot", "retention-lock", "add", string(man.ID), "sub/locked.txt", "--retain-for=24h", "--description=legal hold")
+
+	var locks []map[string]any
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "retention-lock", "list", "--json"), &locks)
+	require.Len(t, locks, 1)
+	require.Equal(t, "sub/locked.txt", locks[0]["path"])
+	require.Equal(t, "legal hold", locks[0]["description"])
+
+	lockID := locks[0]["id"].(string)
+	oid := locks[0]["object"].(string)
+
+	// active locks can't be deleted or shortened.
+	e.RunAndExpectFailure(t, "snapshot", "retention-lock", "delete", lockID)
+	e.RunAndExpectFailure(t, "snapshot", "retention-lock", "extend", lockID, "--retain-for=1h")
+	e.RunAndExpectSuccess(t, "snapshot", "retention-lock", "extend", lockID, "--retain-for=48h")
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "retention-lock", "list")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], "sub/locked.txt")
+
+	// the locked file is kept after the source has been removed and its contents garbage-collected.
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "delete", srcdir, "--all-snapshots-for-source", "--delete")
+	require.True(t, hasLinePrefix(stderr, "File sub/locked.txt of "), strings.Join(stderr, "\n"))
+
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+
+	require.Equal(t, []string{"locked contents"}, e.RunAndExpectSuccess(t, "show", oid))
+}
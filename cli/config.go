@@ -17,6 +17,8 @@
 	"github.com/kopia/kopia/fs/localfs"
 	"github.com/kopia/kopia/internal/ospath"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/content"
 )
 
 func deprecatedFlag(w io.Writer, help string) func(_ *kingpin.ParseContext) error {
@@ -73,12 +75,23 @@ func (c *App) openRepository(ctx context.Context, required bool) (repo.Repositor
 }
 
 func (c *App) optionsFromFlags(ctx context.Context) *repo.Options {
+	var predictor compression.Predictor
+	if c.compressionEntropyThreshold > 0 {
+		predictor = compression.EntropyPredictor(c.compressionEntropyThreshold)
+	}
+
 	return &repo.Options{
 		TraceStorage:        c.traceStorage,
 		DisableInternalLog:  c.disableInternalLog,
 		UpgradeOwnerID:      c.upgradeOwnerID,
 		DoNotWaitForUpgrade: c.doNotWaitForUpgrade,
 
+		VerifyAfterWriteRate: c.verifyAfterWriteRate,
+		CompressionPredictor: predictor,
+		IndexLoading:         content.IndexLoadingMode(c.indexLoading),
+
+		DisableBlobChecksumVerification: c.disableBlobChecksumVerify,
+
 		// when a fatal error is encountered in the repository, run all registered callbacks
 		// and exit the program.
 		OnFatalError: func(err error) {
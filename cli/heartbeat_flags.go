@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/internal/heartbeat"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+type heartbeatFlags struct {
+	heartbeatInterval time.Duration
+	heartbeatFile     string
+}
+
+func (c *heartbeatFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("heartbeat-interval", "Periodically write the status of the operation to a heartbeat blob in the repository (0 = disabled)").DurationVar(&c.heartbeatInterval)
+	cmd.Flag("heartbeat-file", "Write heartbeats to the provided local file instead of the repository").StringVar(&c.heartbeatFile)
+}
+
+// startHeartbeat starts writing heartbeats for the provided operation if enabled, the result must be stopped when the
+// operation completes, which is a no-op when heartbeats are disabled.
+func (c *heartbeatFlags) startHeartbeat(ctx context.Context, rep repo.Repository, operation string, progress func() any) *heartbeat.Heartbeat {
+	if c.heartbeatInterval <= 0 {
+		return nil
+	}
+
+	sink := heartbeat.FileSink(c.heartbeatFile)
+
+	if c.heartbeatFile == "" {
+		dr, ok := rep.(interface{ BlobStorage() blob.Storage })
+		if !ok || rep.ClientOptions().ReadOnly {
+			log(ctx).Warn("Heartbeats can only be written to the repository when connected directly to storage in read-write mode, use --heartbeat-file instead.")
+			return nil
+		}
+
+		sink = heartbeat.BlobSink(dr.BlobStorage(), heartbeatBlobID(rep.ClientOptions(), operation))
+	}
+
+	return heartbeat.Start(ctx, heartbeat.Options{
+		Operation: operation,
+		Hostname:  rep.ClientOptions().Hostname,
+		Username:  rep.ClientOptions().Username,
+		Interval:  c.heartbeatInterval,
+		Sink:      sink,
+		Progress:  progress,
+		TimeNow:   rep.Time,
+	})
+}
+
+// heartbeatBlobID returns the ID of the heartbeat blob which is unique for the operation and user@hostname.
+func heartbeatBlobID(co repo.ClientOptions, operation string) blob.ID {
+	h := sha256.Sum256([]byte(co.UsernameAtHost()))
+
+	return heartbeat.BlobIDPrefix + blob.ID(operation+"_"+hex.EncodeToString(h[:8]))
+}
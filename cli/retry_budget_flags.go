@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/internal/metrics"
+	"github.com/kopia/kopia/internal/retry"
+	"github.com/kopia/kopia/repo"
+)
+
+type retryBudgetFlags struct {
+	retryBudgetCount int
+	retryBudgetTime  time.Duration
+}
+
+func (c *retryBudgetFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("retry-budget-count", "Maximum total number of storage retries for the entire operation (0 = unlimited)").IntVar(&c.retryBudgetCount)
+	cmd.Flag("retry-budget-time", "Maximum total time spent retrying storage operations for the entire operation (0 = unlimited)").DurationVar(&c.retryBudgetTime)
+}
+
+// withRetryBudget returns a context sharing a single retry budget between all storage operations performed
+// using it, when enabled.
+func (c *retryBudgetFlags) withRetryBudget(ctx context.Context, rep repo.Repository) context.Context {
+	if c.retryBudgetCount <= 0 && c.retryBudgetTime <= 0 {
+		return ctx
+	}
+
+	var mr *metrics.Registry
+
+	if mrep, ok := rep.(interface{ Metrics() *metrics.Registry }); ok {
+		mr = mrep.Metrics()
+	}
+
+	return retry.WithBudget(ctx, retry.NewBudget(retry.BudgetOptions{
+		MaxRetries:   c.retryBudgetCount,
+		MaxRetryTime: c.retryBudgetTime,
+	}, mr))
+}
@@ -27,6 +27,7 @@ func (c *storageAzureFlags) Setup(svc StorageProviderServices, cmd *kingpin.CmdC
 	cmd.Flag("client-secret", "Azure service principle client secret (overrides AZURE_CLIENT_SECRET environment variable)").Envar(svc.EnvName("AZURE_CLIENT_SECRET")).StringVar(&c.azOptions.ClientSecret)
 
 	commonThrottlingFlags(cmd, &c.azOptions.Limits)
+	commonObjectSizeFlags(cmd, &c.azOptions.ObjectSizeLimit)
 
 	var pointInTimeStr string
 
@@ -38,6 +38,7 @@ func (c *storageFilesystemFlags) Setup(_ StorageProviderServices, cmd *kingpin.C
 	cmd.Flag("list-parallelism", "Set list parallelism").Hidden().IntVar(&c.options.ListParallelism)
 
 	commonThrottlingFlags(cmd, &c.options.Limits)
+	commonObjectSizeFlags(cmd, &c.options.ObjectSizeLimit)
 }
 
 func (c *storageFilesystemFlags) Connect(ctx context.Context, isCreate bool, formatVersion int) (blob.Storage, error) {
@@ -7,6 +7,7 @@
 	"github.com/alecthomas/kingpin/v2"
 
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
@@ -40,6 +41,10 @@ func commonThrottlingFlags(cmd *kingpin.CmdClause, limits *throttling.Limits) {
 	cmd.Flag("max-upload-speed", "Limit the upload speed.").PlaceHolder("BYTES_PER_SEC").FloatVar(&limits.UploadBytesPerSecond)
 }
 
+func commonObjectSizeFlags(cmd *kingpin.CmdClause, l *splitting.ObjectSizeLimit) {
+	cmd.Flag("max-object-size", "Maximum size of a single object supported by the storage, larger blobs are split into multiple objects. Only used when creating a repository, the limit is stored in the repository.").PlaceHolder("BYTES").Int64Var(&l.MaxObjectSize)
+}
+
 // AddStorageProvider adds a new StorageProvider at runtime after the App has
 // been initialized with the default providers. This is used in tests which
 // require custom storage providers to simulate various edge cases.
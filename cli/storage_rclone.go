@@ -30,6 +30,7 @@ func (c *storageRcloneFlags) Setup(_ StorageProviderServices, cmd *kingpin.CmdCl
 	cmd.Flag("atomic-writes", "Assume provider writes are atomic").Default("true").BoolVar(&c.opt.AtomicWrites)
 
 	commonThrottlingFlags(cmd, &c.opt.Limits)
+	commonObjectSizeFlags(cmd, &c.opt.ObjectSizeLimit)
 }
 
 func (c *storageRcloneFlags) Connect(ctx context.Context, isCreate bool, formatVersion int) (blob.Storage, error) {
@@ -31,6 +31,7 @@ func (c *storageS3Flags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClau
 	cmd.Flag("disable-tls-verification", "Disable TLS (HTTPS) certificate verification").BoolVar(&c.s3options.DoNotVerifyTLS)
 
 	commonThrottlingFlags(cmd, &c.s3options.Limits)
+	commonObjectSizeFlags(cmd, &c.s3options.ObjectSizeLimit)
 
 	var pointInTimeStr string
 
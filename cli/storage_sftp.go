@@ -43,6 +43,7 @@ func (c *storageSFTPFlags) Setup(_ StorageProviderServices, cmd *kingpin.CmdClau
 	cmd.Flag("list-parallelism", "Set list parallelism").Hidden().IntVar(&c.options.ListParallelism)
 
 	commonThrottlingFlags(cmd, &c.options.Limits)
+	commonObjectSizeFlags(cmd, &c.options.ObjectSizeLimit)
 }
 
 func (c *storageSFTPFlags) getOptions(formatVersion int) (*sftp.Options, error) {
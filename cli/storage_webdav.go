@@ -24,6 +24,7 @@ func (c *storageWebDAVFlags) Setup(svc StorageProviderServices, cmd *kingpin.Cmd
 	cmd.Flag("atomic-writes", "Assume WebDAV provider implements atomic writes").BoolVar(&c.options.AtomicWrites)
 
 	commonThrottlingFlags(cmd, &c.options.Limits)
+	commonObjectSizeFlags(cmd, &c.options.ObjectSizeLimit)
 }
 
 func (c *storageWebDAVFlags) Connect(ctx context.Context, isCreate bool, formatVersion int) (blob.Storage, error) {
@@ -33,6 +33,16 @@ func (c *commonThrottleGet) output(limits *throttling.Limits) error {
 	c.printValueOrUnlimited("Max Concurrent Reads:", float64(limits.ConcurrentReads), c.floatToString)
 	c.printValueOrUnlimited("Max Concurrent Writes:", float64(limits.ConcurrentWrites), c.floatToString)
 
+	if limits.ConcurrentWritesRampUpStart != 0 {
+		c.out.printStdout("%-30v %v\n", "Write Ramp-Up Start:", limits.ConcurrentWritesRampUpStart)
+
+		if limits.ConcurrentWritesRampUpSeconds != 0 {
+			c.out.printStdout("%-30v %vs\n", "Write Ramp-Up Time:", c.floatToString(limits.ConcurrentWritesRampUpSeconds))
+		} else {
+			c.out.printStdout("%-30v (default)\n", "Write Ramp-Up Time:")
+		}
+	}
+
 	return nil
 }
 
@@ -19,6 +19,8 @@ type commonThrottleSet struct {
 	setListsPerSecond         string
 	setConcurrentReads        string
 	setConcurrentWrites       string
+	setWritesRampUpStart      string
+	setWritesRampUpSeconds    string
 }
 
 func (c *commonThrottleSet) setup(cmd *kingpin.CmdClause) {
@@ -29,6 +31,8 @@ func (c *commonThrottleSet) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("list-requests-per-second", "Set max lists per second").StringVar(&c.setListsPerSecond)
 	cmd.Flag("concurrent-reads", "Set max concurrent reads").StringVar(&c.setConcurrentReads)
 	cmd.Flag("concurrent-writes", "Set max concurrent writes").StringVar(&c.setConcurrentWrites)
+	cmd.Flag("concurrent-writes-ramp-up-start", "Set initial concurrent writes, which will gradually ramp up to max concurrent writes").StringVar(&c.setWritesRampUpStart)
+	cmd.Flag("concurrent-writes-ramp-up-seconds", "Set the time (in seconds) to ramp up concurrent writes").StringVar(&c.setWritesRampUpSeconds)
 }
 
 func (c *commonThrottleSet) apply(ctx context.Context, limits *throttling.Limits, changeCount *int) error {
@@ -56,7 +60,15 @@ func (c *commonThrottleSet) apply(ctx context.Context, limits *throttling.Limits
 		return err
 	}
 
-	return c.setThrottleInt(ctx, "concurrent writes", &limits.ConcurrentWrites, c.setConcurrentWrites, changeCount)
+	if err := c.setThrottleInt(ctx, "concurrent writes", &limits.ConcurrentWrites, c.setConcurrentWrites, changeCount); err != nil {
+		return err
+	}
+
+	if err := c.setThrottleInt(ctx, "concurrent writes ramp-up start", &limits.ConcurrentWritesRampUpStart, c.setWritesRampUpStart, changeCount); err != nil {
+		return err
+	}
+
+	return c.setThrottleFloat64(ctx, "concurrent writes ramp-up seconds", false, &limits.ConcurrentWritesRampUpSeconds, c.setWritesRampUpSeconds, changeCount)
 }
 
 func (c *commonThrottleSet) setThrottleFloat64(ctx context.Context, desc string, bps bool, val *float64, str string, changeCount *int) error {
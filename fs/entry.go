@@ -36,6 +36,20 @@ type DeviceInfo struct {
 	Rdev uint64 `json:"rdev"`
 }
 
+// FileFlags is a platform-independent bitmask of file flags, such as immutable or append-only.
+type FileFlags uint32
+
+// Supported file flags.
+const (
+	FileFlagImmutable  FileFlags = 1 << iota // file can't be modified, renamed or deleted
+	FileFlagAppendOnly                       // file can only be opened for appending
+)
+
+// HasFileFlags is implemented by entries that are able to report their file flags.
+type HasFileFlags interface {
+	FileFlags() (FileFlags, error)
+}
+
 // Reader allows reading from a file and retrieving its up-to-date file info.
 type Reader interface {
 	io.ReadCloser
@@ -179,6 +193,12 @@ type EntryWithError struct {
 	Error     string `json:"error"`
 }
 
+// PermissionDeniedEntry describes an entry that was skipped because it could not be read due to insufficient permissions.
+type PermissionDeniedEntry struct {
+	EntryPath   string `json:"path"`
+	IsDirectory bool   `json:"dir,omitempty"`
+}
+
 // DirectorySummary represents summary information about a directory.
 type DirectorySummary struct {
 	TotalFileSize     int64        `json:"size"`
@@ -194,6 +214,10 @@ type DirectorySummary struct {
 
 	// first 10 failed entries
 	FailedEntries []*EntryWithError `json:"errors,omitempty"`
+
+	// number of entries skipped due to insufficient permissions and first 10 of them
+	PermissionDeniedCount   int                      `json:"numPermissionDenied,omitempty"`
+	PermissionDeniedEntries []*PermissionDeniedEntry `json:"permissionDenied,omitempty"`
 }
 
 // Clone clones given directory summary.
@@ -201,6 +225,7 @@ func (s *DirectorySummary) Clone() DirectorySummary {
 	res := *s
 
 	res.FailedEntries = append([]*EntryWithError(nil), s.FailedEntries...)
+	res.PermissionDeniedEntries = append([]*PermissionDeniedEntry(nil), s.PermissionDeniedEntries...)
 
 	return res
 }
@@ -211,6 +236,26 @@ type Symlink interface {
 	Readlink(ctx context.Context) (string, error)
 }
 
+// ResolvableSymlink is implemented by symlinks that can be followed to the entry they point to.
+type ResolvableSymlink interface {
+	Symlink
+
+	// Resolve returns the entry the symlink points to, which retains the name of the symlink.
+	Resolve(ctx context.Context) (Entry, error)
+}
+
+// UnfollowedSymlink is a symlink which is kept as-is even though following symlinks was requested,
+// because following it would cause a directory cycle.
+type UnfollowedSymlink interface {
+	Symlink
+
+	// FollowError returns the reason why the symlink was not followed.
+	FollowError() error
+}
+
+// ErrSymlinkCycle is returned by ResolvableSymlink.Resolve() when following the symlink would cause a directory cycle.
+var ErrSymlinkCycle = errors.New("symbolic link cycle")
+
 // FindByName returns an entry with a given name, or nil if not found. Assumes
 // the given slice of fs.Entry is sorted.
 func FindByName(entries []Entry, n string) Entry {
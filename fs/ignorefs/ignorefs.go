@@ -31,7 +31,8 @@ type ignoreContext struct {
 	matchers       []wcmatch.WildcardMatcher // current set of rules to ignore files
 	maxFileSize    int64                     // maximum size of file allowed
 
-	oneFileSystem bool // should we enter other mounted filesystems
+	oneFileSystem  bool // should we enter other mounted filesystems
+	followSymlinks bool // should we follow symbolic links
 }
 
 func (c *ignoreContext) shouldIncludeByName(ctx context.Context, path string, e fs.Entry, policyTree *policy.Tree) bool {
@@ -222,6 +223,10 @@ func (d *ignoreDirectory) Close() {
 func (d *ignoreDirectory) maybeWrappedChildEntry(ctx context.Context, ic *ignoreContext, e fs.Entry) (fs.Entry, bool) {
 	s := d.relativePath + "/" + e.Name()
 
+	if sl, ok := e.(fs.ResolvableSymlink); ok && ic.followSymlinks {
+		e = followSymlink(ctx, s, sl)
+	}
+
 	if !ic.shouldIncludeByName(ctx, s, e, d.policyTree) {
 		return nil, false
 	}
@@ -248,6 +253,36 @@ func (d *ignoreDirectory) maybeWrappedChildEntry(ctx context.Context, ic *ignore
 	return e, true
 }
 
+// unfollowedSymlink is a symlink which can't be followed because it leads to a directory cycle.
+type unfollowedSymlink struct {
+	fs.ResolvableSymlink
+
+	err error
+}
+
+func (s unfollowedSymlink) FollowError() error {
+	return s.err
+}
+
+// followSymlink returns the entry the provided symlink points to, or the symlink itself if it can't be followed.
+// Symlinks leading to directory cycles are returned as fs.UnfollowedSymlink, so that they can be reported.
+func followSymlink(ctx context.Context, relativePath string, sl fs.ResolvableSymlink) fs.Entry {
+	target, err := sl.Resolve(ctx)
+	if err != nil {
+		if errors.Is(err, fs.ErrSymlinkCycle) {
+			return unfollowedSymlink{sl, err}
+		}
+
+		log(ctx).Debugw("unable to follow symbolic link", "path", relativePath, "error", err)
+
+		return sl
+	}
+
+	sl.Close()
+
+	return target
+}
+
 func (d *ignoreDirectory) Child(ctx context.Context, name string) (fs.Entry, error) {
 	if d.skipCacheDirectory(ctx, d.relativePath, d.policyTree) {
 		return nil, fs.ErrEntryNotFound
@@ -300,6 +335,7 @@ func (d *ignoreDirectory) buildContext(ctx context.Context) (*ignoreContext, err
 		dotIgnoreFiles: effectiveDotIgnoreFiles,
 		maxFileSize:    d.parentContext.maxFileSize,
 		oneFileSystem:  d.parentContext.oneFileSystem,
+		followSymlinks: d.parentContext.followSymlinks,
 	}
 
 	if pol != nil {
@@ -330,6 +366,7 @@ func (c *ignoreContext) overrideFromPolicy(fp *policy.FilesPolicy, dirPath strin
 	}
 
 	c.oneFileSystem = fp.OneFileSystem.OrDefault(false)
+	c.followSymlinks = fp.FollowSymlinks.OrDefault(false)
 
 	// append policy-level rules
 	for _, rule := range fp.IgnoreRules {
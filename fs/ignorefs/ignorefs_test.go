@@ -3,15 +3,21 @@
 import (
 	"bytes"
 	"context"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
+	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/ignorefs"
+	"github.com/kopia/kopia/fs/localfs"
 	"github.com/kopia/kopia/internal/mockfs"
 	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/snapshot/policy"
 )
 
@@ -579,3 +585,65 @@ func verifyDirectoryTree(t *testing.T, dir fs.Directory, expected []string) {
 		t.Errorf("unexpected directory tree, diff(-got,+want): %v\n", diff)
 	}
 }
+
+func TestIgnoreFSFollowSymlinksCycle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not supported on Windows without elevated privileges")
+	}
+
+	tmp := testutil.TempDirectory(t)
+
+	// A/linkToB -> B, B/linkToA -> A
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "A"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "B"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "A", "fa"), []byte{1}, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "B", "fb"), []byte{2}, 0o644))
+	require.NoError(t, os.Symlink(filepath.Join("..", "B"), filepath.Join(tmp, "A", "linkToB")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "A"), filepath.Join(tmp, "B", "linkToA")))
+
+	root, err := localfs.Directory(tmp)
+	require.NoError(t, err)
+
+	// without following, symlinks are kept as-is.
+	require.Equal(t, []string{
+		"./",
+		"./A/",
+		"./A/fa",
+		"./A/linkToB",
+		"./B/",
+		"./B/fb",
+		"./B/linkToA",
+	}, sortedTree(t, ignorefs.New(root, defaultPolicy)))
+
+	followSymlinksPolicy := policy.BuildTree(map[string]*policy.Policy{
+		".": {
+			FilesPolicy: policy.FilesPolicy{
+				FollowSymlinks: &trueValue,
+			},
+		},
+	}, policy.DefaultPolicy)
+
+	// when following, the cycle is broken by keeping the symlink that leads back to its parent.
+	require.Equal(t, []string{
+		"./",
+		"./A/",
+		"./A/fa",
+		"./A/linkToB/",
+		"./A/linkToB/fb",
+		"./A/linkToB/linkToA",
+		"./B/",
+		"./B/fb",
+		"./B/linkToA/",
+		"./B/linkToA/fa",
+		"./B/linkToA/linkToB",
+	}, sortedTree(t, ignorefs.New(root, followSymlinksPolicy)))
+}
+
+func sortedTree(t *testing.T, dir fs.Directory) []string {
+	t.Helper()
+
+	output := walkTree(t, dir)
+	sort.Strings(output)
+
+	return output
+}
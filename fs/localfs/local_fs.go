@@ -64,6 +64,23 @@ func (e *filesystemEntry) LocalFilesystemPath() string {
 	return e.fullPath()
 }
 
+// FileFlags implements fs.HasFileFlags. Flags are not collected when the entry is read
+// since on some platforms it requires opening the file.
+func (e *filesystemEntry) FileFlags() (fs.FileFlags, error) {
+	if !e.mode.IsRegular() && !e.mode.IsDir() {
+		return 0, nil
+	}
+
+	return platformSpecificGetFileFlags(e.fullPath())
+}
+
+// SetFileFlags replaces the supported flags of the file at the given path with the provided ones,
+// leaving any other platform-specific flags unchanged.
+// Returns ErrFileFlagsNotSupported if the platform or filesystem does not support file flags.
+func SetFileFlags(path string, flags fs.FileFlags) error {
+	return platformSpecificSetFileFlags(path, flags)
+}
+
 type filesystemDirectory struct {
 	filesystemEntry
 }
@@ -117,6 +134,32 @@ func (fsl *filesystemSymlink) Readlink(ctx context.Context) (string, error) {
 	return os.Readlink(fsl.fullPath())
 }
 
+// Resolve returns the entry the symbolic link points to. The entry retains the name and path of the link,
+// so linked directories are traversed through it. To prevent infinite recursion fs.ErrSymlinkCycle is returned
+// if the link points to one of the directories containing it (compared by device and inode).
+func (fsl *filesystemSymlink) Resolve(ctx context.Context) (fs.Entry, error) {
+	linkPath := fsl.fullPath()
+
+	fi, err := os.Stat(linkPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to resolve symlink")
+	}
+
+	if fi.IsDir() {
+		for dir := filepath.Dir(linkPath); ; dir = filepath.Dir(dir) {
+			if dfi, err := os.Stat(dir); err == nil && os.SameFile(fi, dfi) {
+				return nil, errors.Wrapf(fs.ErrSymlinkCycle, "%v points to its parent %v", linkPath, dir)
+			}
+
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+
+	return entryFromDirEntry(fi, fsl.prefix), nil
+}
+
 func (e *filesystemErrorEntry) ErrorInfo() error {
 	return e.err
 }
@@ -155,8 +198,11 @@ func Directory(path string) (fs.Directory, error) {
 }
 
 var (
-	_ fs.Directory  = (*filesystemDirectory)(nil)
-	_ fs.File       = (*filesystemFile)(nil)
-	_ fs.Symlink    = (*filesystemSymlink)(nil)
-	_ fs.ErrorEntry = (*filesystemErrorEntry)(nil)
+	_ fs.Directory = (*filesystemDirectory)(nil)
+	_ fs.File      = (*filesystemFile)(nil)
+	_ fs.Symlink   = (*filesystemSymlink)(nil)
+
+	_ fs.ResolvableSymlink = (*filesystemSymlink)(nil)
+	_ fs.ErrorEntry        = (*filesystemErrorEntry)(nil)
+	_ fs.HasFileFlags      = (*filesystemFile)(nil)
 )
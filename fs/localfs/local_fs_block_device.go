@@ -0,0 +1,81 @@
+package localfs
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// filesystemBlockDevice represents a raw block device (such as /dev/sdb), which is exposed
+// as a regular file whose size is the size of the device.
+type filesystemBlockDevice struct {
+	filesystemEntry
+}
+
+func (d *filesystemBlockDevice) Close() {
+}
+
+func (d *filesystemBlockDevice) Open(ctx context.Context) (fs.Reader, error) {
+	f, err := os.Open(d.fullPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open block device")
+	}
+
+	return &blockDeviceReader{f, d}, nil
+}
+
+type blockDeviceReader struct {
+	*os.File
+
+	entry *filesystemBlockDevice
+}
+
+func (r *blockDeviceReader) Entry() (fs.Entry, error) {
+	return r.entry, nil
+}
+
+// IsBlockDevice returns true if the provided file mode describes a block device.
+func IsBlockDevice(mode os.FileMode) bool {
+	return mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0
+}
+
+// BlockDevice returns fs.File for the block device at the specified path.
+func BlockDevice(path string) (fs.File, error) {
+	e, err := NewEntry(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := e.(*filesystemBlockDevice)
+	if !ok {
+		return nil, errors.Errorf("not a block device: %v (was %T)", path, e)
+	}
+
+	return d, nil
+}
+
+// newFilesystemBlockDevice returns a block device entry, the size of stat() result for block devices
+// is not meaningful, so it is determined by seeking to the end of the device.
+func newFilesystemBlockDevice(e filesystemEntry) (*filesystemBlockDevice, error) {
+	f, err := os.Open(e.fullPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open block device")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to determine block device size")
+	}
+
+	e.size = size
+
+	return &filesystemBlockDevice{e}, nil
+}
+
+var _ fs.File = (*filesystemBlockDevice)(nil)
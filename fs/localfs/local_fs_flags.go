@@ -0,0 +1,8 @@
+package localfs
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrFileFlagsNotSupported is returned when file flags are not supported by the platform or filesystem.
+var ErrFileFlagsNotSupported = errors.New("file flags are not supported")
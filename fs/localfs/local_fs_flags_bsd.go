@@ -0,0 +1,69 @@
+//go:build darwin || freebsd || netbsd || openbsd
+// +build darwin freebsd netbsd openbsd
+
+package localfs
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// flags from sys/stat.h, which are the same on all BSD-derived platforms.
+const (
+	bsdUserImmutableFlag    = 0x00000002
+	bsdUserAppendOnlyFlag   = 0x00000004
+	bsdSystemImmutableFlag  = 0x00020000
+	bsdSystemAppendOnlyFlag = 0x00040000
+)
+
+func platformSpecificGetFileFlags(path string) (fs.FileFlags, error) {
+	var st unix.Stat_t
+
+	if err := unix.Lstat(path, &st); err != nil {
+		return 0, errors.Wrap(err, "unable to stat file")
+	}
+
+	var result fs.FileFlags
+
+	if st.Flags&(bsdUserImmutableFlag|bsdSystemImmutableFlag) != 0 {
+		result |= fs.FileFlagImmutable
+	}
+
+	if st.Flags&(bsdUserAppendOnlyFlag|bsdSystemAppendOnlyFlag) != 0 {
+		result |= fs.FileFlagAppendOnly
+	}
+
+	return result, nil
+}
+
+// platformSpecificSetFileFlags sets the user variants of the flags, since system flags can't be
+// set by regular users and in some cases can't be cleared at all when the system is running.
+func platformSpecificSetFileFlags(path string, flags fs.FileFlags) error {
+	var st unix.Stat_t
+
+	if err := unix.Lstat(path, &st); err != nil {
+		return errors.Wrap(err, "unable to stat file")
+	}
+
+	v := st.Flags &^ (bsdUserImmutableFlag | bsdUserAppendOnlyFlag)
+
+	if flags&fs.FileFlagImmutable != 0 {
+		v |= bsdUserImmutableFlag
+	}
+
+	if flags&fs.FileFlagAppendOnly != 0 {
+		v |= bsdUserAppendOnlyFlag
+	}
+
+	if err := unix.Chflags(path, int(v)); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			return errors.Wrapf(ErrFileFlagsNotSupported, "unable to set file flags: %v", err)
+		}
+
+		return errors.Wrap(err, "unable to set file flags")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package localfs
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// inode flags from linux/fs.h, not exported by x/sys/unix.
+const (
+	linuxImmutableFlag  = 0x00000010
+	linuxAppendOnlyFlag = 0x00000020
+)
+
+func platformSpecificGetFileFlags(path string) (fs.FileFlags, error) {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to open file")
+	}
+
+	defer unix.Close(fd) //nolint:errcheck
+
+	v, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		if isFileFlagsNotSupportedError(err) {
+			// filesystem does not support flags, which is the same as not having any.
+			return 0, nil
+		}
+
+		return 0, errors.Wrap(err, "unable to get file flags")
+	}
+
+	var result fs.FileFlags
+
+	if v&linuxImmutableFlag != 0 {
+		result |= fs.FileFlagImmutable
+	}
+
+	if v&linuxAppendOnlyFlag != 0 {
+		result |= fs.FileFlagAppendOnly
+	}
+
+	return result, nil
+}
+
+func platformSpecificSetFileFlags(path string, flags fs.FileFlags) error {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return errors.Wrap(err, "unable to open file")
+	}
+
+	defer unix.Close(fd) //nolint:errcheck
+
+	v, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return wrapFileFlagsError(err, "unable to get file flags")
+	}
+
+	v &^= linuxImmutableFlag | linuxAppendOnlyFlag
+
+	if flags&fs.FileFlagImmutable != 0 {
+		v |= linuxImmutableFlag
+	}
+
+	if flags&fs.FileFlagAppendOnly != 0 {
+		v |= linuxAppendOnlyFlag
+	}
+
+	if err := unix.IoctlSetPointerInt(fd, unix.FS_IOC_SETFLAGS, int(v)); err != nil {
+		return wrapFileFlagsError(err, "unable to set file flags")
+	}
+
+	return nil
+}
+
+func isFileFlagsNotSupportedError(err error) bool {
+	return errors.Is(err, unix.ENOTTY) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL)
+}
+
+func wrapFileFlagsError(err error, msg string) error {
+	if isFileFlagsNotSupportedError(err) {
+		return errors.Wrapf(ErrFileFlagsNotSupported, "%v: %v", msg, err)
+	}
+
+	return errors.Wrap(err, msg)
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package localfs
+
+import (
+	"github.com/kopia/kopia/fs"
+)
+
+//nolint:revive
+func platformSpecificGetFileFlags(path string) (fs.FileFlags, error) {
+	return 0, nil
+}
+
+//nolint:revive
+func platformSpecificSetFileFlags(path string, flags fs.FileFlags) error {
+	return ErrFileFlagsNotSupported
+}
@@ -105,7 +105,7 @@ func toDirEntryOrNil(dirEntry os.DirEntry, prefix string) (fs.Entry, error) {
 }
 
 // NewEntry returns fs.Entry for the specified path, the result will be one of supported entry types: fs.File, fs.Directory, fs.Symlink
-// or fs.UnsupportedEntry.
+// or fs.UnsupportedEntry. Block devices are returned as fs.File whose size is the size of the device.
 func NewEntry(path string) (fs.Entry, error) {
 	path = filepath.Clean(path)
 
@@ -131,6 +131,12 @@ func NewEntry(path string) (fs.Entry, error) {
 		return entryFromDirEntry(fi, ""), nil
 	}
 
+	// block devices are only supported when explicitly specified as a snapshot source,
+	// when encountered during directory traversal they are treated as unknown entries.
+	if IsBlockDevice(fi.Mode()) {
+		return newFilesystemBlockDevice(newEntry(fi, dirPrefix(path)))
+	}
+
 	return entryFromDirEntry(fi, dirPrefix(path)), nil
 }
 
@@ -301,3 +301,53 @@ func assertNoError(t *testing.T, err error) {
 		t.Errorf("err: %v", err)
 	}
 }
+
+func TestSymlinkResolveCycle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not supported on Windows without elevated privileges")
+	}
+
+	ctx := testlogging.Context(t)
+	tmp := testutil.TempDirectory(t)
+
+	// A/linkToB -> B, B/linkToA -> A
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "A"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "B"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "B", "f1"), []byte{1, 2, 3}, 0o644))
+	require.NoError(t, os.Symlink(filepath.Join("..", "B"), filepath.Join(tmp, "A", "linkToB")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "A"), filepath.Join(tmp, "B", "linkToA")))
+
+	dirA, err := Directory(filepath.Join(tmp, "A"))
+	require.NoError(t, err)
+
+	e, err := dirA.Child(ctx, "linkToB")
+	require.NoError(t, err)
+
+	// following A/linkToB is fine, the resolved entry keeps the name and path of the link.
+	resolved, err := e.(fs.ResolvableSymlink).Resolve(ctx)
+	require.NoError(t, err)
+	require.True(t, resolved.IsDir())
+	require.Equal(t, "linkToB", resolved.Name())
+	require.Equal(t, filepath.Join(tmp, "A", "linkToB"), resolved.LocalFilesystemPath())
+
+	f1, err := resolved.(fs.Directory).Child(ctx, "f1")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), f1.Size())
+
+	// following A/linkToB/linkToA leads back to A.
+	e2, err := resolved.(fs.Directory).Child(ctx, "linkToA")
+	require.NoError(t, err)
+
+	_, err = e2.(fs.ResolvableSymlink).Resolve(ctx)
+	require.ErrorIs(t, err, fs.ErrSymlinkCycle)
+
+	// dangling symlink can't be resolved.
+	require.NoError(t, os.Symlink(filepath.Join(tmp, "no-such-dir"), filepath.Join(tmp, "dangling")))
+
+	e3, err := NewEntry(filepath.Join(tmp, "dangling"))
+	require.NoError(t, err)
+
+	_, err = e3.(fs.ResolvableSymlink).Resolve(ctx)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, fs.ErrSymlinkCycle)
+}
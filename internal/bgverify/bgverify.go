@@ -0,0 +1,334 @@
+// Package bgverify implements continuous low-priority verification of repository contents,
+// whose rate adapts to the rate of foreground operations.
+package bgverify
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/sleepable"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+	"github.com/kopia/kopia/repo/logging"
+)
+
+var log = logging.Module("bgverify")
+
+// Default values of Options.
+const (
+	DefaultMinRate            = 0.1
+	DefaultMaxRate            = 20
+	DefaultBusyForegroundRate = 10
+	DefaultCoverageWindow     = 7 * 24 * time.Hour
+)
+
+const (
+	// weight of the most recent sample of the foreground rate.
+	foregroundRateSmoothing = 0.3
+
+	coverageBuckets = 24
+
+	hexDigits = "0123456789abcdef"
+)
+
+// Options provides options for the background verifier.
+type Options struct {
+	// MinRate is the number of contents verified per second when the rate of foreground operations
+	// is at or above BusyForegroundRate.
+	MinRate float64
+
+	// MaxRate is the number of contents verified per second when there are no foreground operations.
+	MaxRate float64
+
+	// BusyForegroundRate is the number of foreground operations per second at which
+	// verification slows down to MinRate.
+	BusyForegroundRate float64
+
+	// CoverageWindow is the period over which coverage is reported.
+	CoverageWindow time.Duration
+
+	TimeNow func() time.Time
+}
+
+func (o *Options) applyDefaults() {
+	if o.MinRate <= 0 {
+		o.MinRate = DefaultMinRate
+	}
+
+	if o.MaxRate <= 0 {
+		o.MaxRate = DefaultMaxRate
+	}
+
+	o.MaxRate = max(o.MaxRate, o.MinRate)
+
+	if o.BusyForegroundRate <= 0 {
+		o.BusyForegroundRate = DefaultBusyForegroundRate
+	}
+
+	if o.CoverageWindow <= 0 {
+		o.CoverageWindow = DefaultCoverageWindow
+	}
+
+	if o.TimeNow == nil {
+		o.TimeNow = clock.Now
+	}
+}
+
+// Status describes the state of the background verifier.
+type Status struct {
+	// Rate is the current number of contents verified per second.
+	Rate float64 `json:"rate"`
+
+	// ForegroundRate is the recent number of foreground operations per second.
+	ForegroundRate float64 `json:"foregroundRate"`
+
+	VerifiedContents int64  `json:"verifiedContents"`
+	FailedContents   int64  `json:"failedContents"`
+	LastError        string `json:"lastError,omitempty"`
+
+	// TotalContents is the number of contents in the repository when it was last listed.
+	TotalContents int `json:"totalContents"`
+
+	// Coverage is the fraction of contents verified within the coverage window.
+	Coverage       float64       `json:"coverage"`
+	CoverageWindow time.Duration `json:"coverageWindow"`
+}
+
+type contentReader interface {
+	VerifyContentInStorage(ctx context.Context, id content.ID) error
+	IterateContents(ctx context.Context, opts content.IterateOptions, callback content.IterateCallback) error
+}
+
+type coverageBucket struct {
+	start time.Time
+	count int
+}
+
+// Verifier continuously verifies contents of a repository by reading them from the storage, pacing itself to leave
+// CPU and storage capacity to foreground operations, which must be reported by calling ForegroundOperation().
+type Verifier struct {
+	cr  contentReader
+	opt Options
+
+	foregroundOps atomic.Int64
+
+	mu sync.Mutex
+	// +checklocks:mu
+	lastRateUpdate time.Time
+	// +checklocks:mu
+	foregroundRate float64
+	// +checklocks:mu
+	verified int64
+	// +checklocks:mu
+	failed int64
+	// +checklocks:mu
+	lastError string
+	// +checklocks:mu
+	totalContents int
+	// +checklocks:mu
+	buckets []coverageBucket
+}
+
+// New creates a background verifier of contents returned by the provided reader.
+func New(cr contentReader, opt Options) *Verifier {
+	opt.applyDefaults()
+
+	return &Verifier{
+		cr:             cr,
+		opt:            opt,
+		lastRateUpdate: opt.TimeNow(),
+	}
+}
+
+// ForegroundOperation notes that a foreground operation has been performed.
+func (v *Verifier) ForegroundOperation() {
+	v.foregroundOps.Add(1)
+}
+
+// Run verifies all contents repeatedly until the context is canceled.
+func (v *Verifier) Run(ctx context.Context) error {
+	for {
+		total := 0
+
+		for _, prefix := range batchPrefixes() {
+			ids, err := v.listContents(ctx, prefix)
+			if err != nil {
+				return err
+			}
+
+			total += len(ids)
+
+			for _, id := range ids {
+				if err := v.wait(ctx); err != nil {
+					return err
+				}
+
+				v.verify(ctx, id)
+			}
+		}
+
+		v.mu.Lock()
+		v.totalContents = total
+		v.mu.Unlock()
+
+		if total == 0 {
+			// nothing to verify yet, wait before listing contents again.
+			if err := v.wait(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// batchPrefixes returns prefixes of content IDs verified together, so that contents are listed in batches.
+func batchPrefixes() []index.IDPrefix {
+	var result []index.IDPrefix
+
+	for _, c := range hexDigits {
+		for _, d := range hexDigits {
+			result = append(result, index.IDPrefix(string(c)+string(d)))
+		}
+	}
+
+	for c := 'g'; c <= 'z'; c++ {
+		result = append(result, index.IDPrefix(string(c)))
+	}
+
+	return result
+}
+
+func (v *Verifier) listContents(ctx context.Context, prefix index.IDPrefix) ([]content.ID, error) {
+	var ids []content.ID
+
+	if err := v.cr.IterateContents(ctx, content.IterateOptions{Range: index.PrefixRange(prefix)}, func(ci content.Info) error {
+		ids = append(ids, ci.ContentID)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error listing contents")
+	}
+
+	return ids, nil
+}
+
+// wait sleeps for the interval between verifications at the current rate.
+func (v *Verifier) wait(ctx context.Context) error {
+	now := v.opt.TimeNow()
+	interval := time.Duration(float64(time.Second) / v.rate(now))
+
+	t := sleepable.NewTimer(v.opt.TimeNow, now.Add(interval))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+
+	case <-t.C:
+		// yield to foreground work before reading the content.
+		runtime.Gosched()
+
+		return nil
+	}
+}
+
+// rate updates the foreground rate with operations reported since the last update and returns
+// the number of contents to verify per second.
+func (v *Verifier) rate(now time.Time) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if elapsed := now.Sub(v.lastRateUpdate).Seconds(); elapsed > 0 {
+		sample := float64(v.foregroundOps.Swap(0)) / elapsed
+
+		v.foregroundRate += foregroundRateSmoothing * (sample - v.foregroundRate)
+		v.lastRateUpdate = now
+	}
+
+	return v.rateLocked()
+}
+
+// +checklocks:v.mu
+func (v *Verifier) rateLocked() float64 {
+	load := min(v.foregroundRate/v.opt.BusyForegroundRate, 1)
+
+	return v.opt.MaxRate - load*(v.opt.MaxRate-v.opt.MinRate)
+}
+
+func (v *Verifier) verify(ctx context.Context, id content.ID) {
+	// contents are read from the storage, since cached copies say nothing about the storage and
+	// caching them would evict contents used by foreground operations.
+	err := v.cr.VerifyContentInStorage(ctx, id)
+
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err != nil {
+		log(ctx).Errorf("background verification of content %v failed: %v", id, err)
+
+		v.failed++
+		v.lastError = errors.Wrapf(err, "content %v", id).Error()
+
+		return
+	}
+
+	v.verified++
+	v.recordVerifiedLocked(v.opt.TimeNow())
+}
+
+// +checklocks:v.mu
+func (v *Verifier) recordVerifiedLocked(now time.Time) {
+	bucketSize := v.opt.CoverageWindow / coverageBuckets
+	start := now.Truncate(bucketSize)
+
+	if n := len(v.buckets); n > 0 && v.buckets[n-1].start.Equal(start) {
+		v.buckets[n-1].count++
+		return
+	}
+
+	v.buckets = append(v.buckets, coverageBucket{start, 1})
+
+	// drop buckets which have fallen out of the window.
+	for len(v.buckets) > 0 && !v.buckets[0].start.After(now.Add(-v.opt.CoverageWindow)) {
+		v.buckets = v.buckets[1:]
+	}
+}
+
+// Status returns the current status of the verifier.
+func (v *Verifier) Status() Status {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	st := Status{
+		Rate:             v.rateLocked(),
+		ForegroundRate:   v.foregroundRate,
+		VerifiedContents: v.verified,
+		FailedContents:   v.failed,
+		LastError:        v.lastError,
+		TotalContents:    v.totalContents,
+		CoverageWindow:   v.opt.CoverageWindow,
+	}
+
+	cutoff := v.opt.TimeNow().Add(-v.opt.CoverageWindow)
+	inWindow := 0
+
+	for _, b := range v.buckets {
+		if b.start.After(cutoff) {
+			inWindow += b.count
+		}
+	}
+
+	if v.totalContents > 0 {
+		st.Coverage = min(float64(inWindow)/float64(v.totalContents), 1)
+	}
+
+	return st
+}
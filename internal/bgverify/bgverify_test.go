@@ -0,0 +1,163 @@
+package bgverify
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+)
+
+type fakeContentReader struct {
+	ids    []content.ID
+	broken content.ID
+}
+
+func (r *fakeContentReader) VerifyContentInStorage(_ context.Context, id content.ID) error {
+	if id == r.broken {
+		return errors.New("checksum mismatch")
+	}
+
+	return nil
+}
+
+func (r *fakeContentReader) IterateContents(_ context.Context, opts content.IterateOptions, cb content.IterateCallback) error {
+	for _, id := range r.ids {
+		if !opts.Range.Contains(id) {
+			continue
+		}
+
+		if err := cb(content.Info{ContentID: id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestRateAdaptsToForegroundOperations(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	v := New(&fakeContentReader{}, Options{
+		MinRate:            1,
+		MaxRate:            100,
+		BusyForegroundRate: 10,
+		TimeNow:            func() time.Time { return now },
+	})
+
+	// idle server verifies at full speed.
+	now = now.Add(time.Second)
+	require.InDelta(t, 100, v.rate(now), 0.001)
+
+	// sustained load above the busy rate slows verification to the minimum.
+	for range 30 {
+		for range 50 {
+			v.ForegroundOperation()
+		}
+
+		now = now.Add(time.Second)
+		v.rate(now)
+	}
+
+	require.InDelta(t, 1, v.rate(now), 0.001)
+	require.Greater(t, v.Status().ForegroundRate, 10.0)
+
+	// once load goes away, verification speeds up again.
+	for range 30 {
+		now = now.Add(time.Second)
+		v.rate(now)
+	}
+
+	require.Greater(t, v.rate(now), 99.0)
+}
+
+func TestCoverage(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	v := New(&fakeContentReader{}, Options{
+		CoverageWindow: 24 * time.Hour,
+		TimeNow:        func() time.Time { return now },
+	})
+
+	require.Zero(t, v.Status().Coverage)
+
+	v.mu.Lock()
+	v.totalContents = 10
+
+	for range 5 {
+		v.recordVerifiedLocked(now)
+	}
+	v.mu.Unlock()
+
+	require.InDelta(t, 0.5, v.Status().Coverage, 0.001)
+
+	now = now.Add(12 * time.Hour)
+
+	v.mu.Lock()
+	for range 5 {
+		v.recordVerifiedLocked(now)
+	}
+	v.mu.Unlock()
+
+	require.InDelta(t, 1, v.Status().Coverage, 0.001)
+
+	// verifications older than the window no longer count.
+	now = now.Add(13 * time.Hour)
+	require.InDelta(t, 0.5, v.Status().Coverage, 0.001)
+
+	now = now.Add(24 * time.Hour)
+	require.Zero(t, v.Status().Coverage)
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(testlogging.Context(t))
+	defer cancel()
+
+	r := &fakeContentReader{}
+
+	for i := range 100 {
+		h := sha256.Sum256([]byte(fmt.Sprintf("content-%v", i)))
+
+		prefix := index.IDPrefix("")
+		if i%2 == 0 {
+			prefix = "k"
+		}
+
+		id, err := index.IDFromHash(prefix, h[:])
+		require.NoError(t, err)
+
+		r.ids = append(r.ids, id)
+	}
+
+	r.broken = r.ids[7]
+
+	v := New(r, Options{
+		MinRate: 10000,
+		MaxRate: 10000,
+	})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- v.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		st := v.Status()
+		return st.TotalContents == 100 && st.VerifiedContents >= 99 && st.FailedContents >= 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	st := v.Status()
+	require.GreaterOrEqual(t, st.Coverage, 0.99)
+	require.Contains(t, st.LastError, "checksum mismatch")
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
@@ -30,9 +30,13 @@ type Options struct {
 	FetchFullBlobs     bool
 	Sweep              SweepSettings
 	TimeNow            func() time.Time
+
+	// MemoryCacheSizeBytes is the size of the in-memory tier consulted before the persistent cache, 0 disables it.
+	MemoryCacheSizeBytes int64
 }
 
 type contentCacheImpl struct {
+	mem            *memoryCache
 	pc             *PersistentCache
 	st             blob.Storage
 	fetchFullBlobs bool
@@ -53,12 +57,26 @@ func BlobIDCacheKey(id blob.ID) string {
 	return string(id[1:] + id[0:1])
 }
 
+// GetContent consults the memory tier, then the persistent cache and finally the underlying storage.
+// Contents found in the persistent cache or fetched from the storage are promoted to the memory tier.
 func (c *contentCacheImpl) GetContent(ctx context.Context, contentID string, blobID blob.ID, offset, length int64, output *gather.WriteBuffer) error {
+	if length >= 0 && c.mem.get(contentID, length, output) {
+		return nil
+	}
+
+	var err error
+
 	if c.fetchFullBlobs {
-		return c.getContentFromFullBlob(ctx, blobID, offset, length, output)
+		err = c.getContentFromFullBlob(ctx, blobID, offset, length, output)
+	} else {
+		err = c.getContentFromFullOrPartialBlob(ctx, contentID, blobID, offset, length, output)
+	}
+
+	if err == nil && length >= 0 {
+		c.mem.put(contentID, output.Bytes())
 	}
 
-	return c.getContentFromFullOrPartialBlob(ctx, contentID, blobID, offset, length, output)
+	return err
 }
 
 func (c *contentCacheImpl) getContentFromFullBlob(ctx context.Context, blobID blob.ID, offset, length int64, output *gather.WriteBuffer) error {
@@ -194,6 +212,7 @@ func NewContentCache(ctx context.Context, st blob.Storage, opt Options, mr *metr
 
 	return &contentCacheImpl{
 		st:             st,
+		mem:            newMemoryCache(opt.MemoryCacheSizeBytes, mr, opt.CacheSubDir),
 		pc:             pc,
 		fetchFullBlobs: opt.FetchFullBlobs,
 	}, nil
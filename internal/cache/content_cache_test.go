@@ -17,6 +17,7 @@
 	"github.com/kopia/kopia/internal/cache"
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/internal/testlogging"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo/blob"
@@ -239,6 +240,66 @@ func verifyContentCache(t *testing.T, cc cache.ContentCache, cacheStorage blob.S
 	})
 }
 
+func TestTwoTierContentCache(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	mr := metrics.NewRegistry()
+	cacheData := blobtesting.DataMap{}
+	cacheStorage := blobtesting.NewMapStorage(cacheData, nil, nil)
+
+	newCache := func() cache.ContentCache {
+		cc, err := cache.NewContentCache(ctx, newUnderlyingStorageForContentCacheTesting(t), cache.Options{
+			Storage:              withoutTouchBlob{cacheStorage},
+			CacheSubDir:          "contents",
+			Sweep:                cache.SweepSettings{MaxSizeBytes: 10000},
+			MemoryCacheSizeBytes: 1000,
+		}, mr)
+		require.NoError(t, err)
+
+		return cc
+	}
+
+	counter := func(name string) int64 {
+		return mr.Snapshot(false).Counters[name+"[cache:contents]"]
+	}
+
+	getContent := func(cc cache.ContentCache) {
+		t.Helper()
+
+		var v gather.WriteBuffer
+		defer v.Close()
+
+		require.NoError(t, cc.GetContent(ctx, "xf0f0f1", "content-1", 1, 5, &v))
+		require.Equal(t, []byte{2, 3, 4, 5, 6}, v.ToByteSlice())
+	}
+
+	cc := newCache()
+
+	// first read comes from the underlying storage and populates both tiers.
+	getContent(cc)
+	require.EqualValues(t, 1, counter("cache_memory_miss"))
+	require.EqualValues(t, 0, counter("cache_hit"))
+
+	// second read is served from memory.
+	getContent(cc)
+	require.EqualValues(t, 1, counter("cache_memory_hit"))
+	require.EqualValues(t, 0, counter("cache_hit"))
+
+	cc.Close(ctx)
+
+	// after restart, the disk tier still has the content and promotes it to memory.
+	cc = newCache()
+	defer cc.Close(ctx)
+
+	getContent(cc)
+	require.EqualValues(t, 2, counter("cache_memory_miss"))
+	require.EqualValues(t, 1, counter("cache_hit"))
+
+	getContent(cc)
+	require.EqualValues(t, 2, counter("cache_memory_hit"))
+	require.EqualValues(t, 1, counter("cache_hit"))
+}
+
 func TestCacheFailureToOpen(t *testing.T) {
 	someError := errors.New("some error")
 
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"container/list"
+	"hash/crc32"
+	"sync"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/metrics"
+)
+
+// memoryCacheEntry is a single item held in memory along with the checksum of its data.
+type memoryCacheEntry struct {
+	key      string
+	data     []byte
+	checksum uint32
+}
+
+// memoryCache is a small in-memory LRU cache of items held in front of the persistent cache.
+// Items are verified against their checksums when read and evicted when corrupted.
+type memoryCache struct {
+	maxSizeBytes int64
+
+	mu sync.Mutex
+	// +checklocks:mu
+	totalBytes int64
+	// +checklocks:mu
+	lru *list.List // of *memoryCacheEntry, most recently used first
+	// +checklocks:mu
+	entries map[string]*list.Element
+
+	metricHitCount       *metrics.Counter
+	metricHitBytes       *metrics.Counter
+	metricMissCount      *metrics.Counter
+	metricEvictedCount   *metrics.Counter
+	metricMalformedCount *metrics.Counter
+}
+
+// get replaces the contents of the output with the cached item and returns true if it's found,
+// has the expected length (unless length < 0) and matches its checksum.
+func (c *memoryCache) get(key string, length int64, output *gather.WriteBuffer) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.metricMissCount.Add(1)
+		return false
+	}
+
+	e := el.Value.(*memoryCacheEntry) //nolint:forcetypeassert
+
+	if length >= 0 && int64(len(e.data)) != length {
+		c.metricMissCount.Add(1)
+		c.removeLocked(el)
+
+		return false
+	}
+
+	if crc32.ChecksumIEEE(e.data) != e.checksum {
+		c.metricMalformedCount.Add(1)
+		c.removeLocked(el)
+
+		return false
+	}
+
+	c.lru.MoveToFront(el)
+
+	output.Reset()
+	output.Append(e.data)
+
+	c.metricHitCount.Add(1)
+	c.metricHitBytes.Add(int64(len(e.data)))
+
+	return true
+}
+
+// put adds a copy of the provided data to the cache, evicting least recently used items as needed.
+func (c *memoryCache) put(key string, data gather.Bytes) {
+	if c == nil || int64(data.Length()) > c.maxSizeBytes {
+		return
+	}
+
+	b := data.ToByteSlice()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryCacheEntry{key, b, crc32.ChecksumIEEE(b)})
+	c.totalBytes += int64(len(b))
+
+	for c.totalBytes > c.maxSizeBytes {
+		c.removeLocked(c.lru.Back())
+		c.metricEvictedCount.Add(1)
+	}
+}
+
+// +checklocks:c.mu
+func (c *memoryCache) removeLocked(el *list.Element) {
+	e := c.lru.Remove(el).(*memoryCacheEntry) //nolint:forcetypeassert
+
+	delete(c.entries, e.key)
+	c.totalBytes -= int64(len(e.data))
+}
+
+// newMemoryCache returns a new memory cache of the provided size or nil if the size is not positive.
+func newMemoryCache(maxSizeBytes int64, mr *metrics.Registry, cacheID string) *memoryCache {
+	if maxSizeBytes <= 0 {
+		return nil
+	}
+
+	labels := map[string]string{
+		"cache": cacheID,
+	}
+
+	return &memoryCache{
+		maxSizeBytes: maxSizeBytes,
+		lru:          list.New(),
+		entries:      map[string]*list.Element{},
+
+		metricHitCount: mr.CounterInt64(
+			"cache_memory_hit",
+			"Number of time content was retrieved from the memory cache", labels),
+
+		metricHitBytes: mr.CounterInt64(
+			"cache_memory_hit_bytes",
+			"Number of bytes retrieved from the memory cache", labels),
+
+		metricMissCount: mr.CounterInt64(
+			"cache_memory_miss",
+			"Number of time content was not found in the memory cache", labels),
+
+		metricEvictedCount: mr.CounterInt64(
+			"cache_memory_evicted",
+			"Number of items evicted from the memory cache to make room for new items", labels),
+
+		metricMalformedCount: mr.CounterInt64(
+			"cache_memory_malformed",
+			"Number of times malformed content was found in the memory cache", labels),
+	}
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/gather"
+)
+
+func TestMemoryCache_LRU(t *testing.T) {
+	mc := newMemoryCache(10, nil, "test")
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	mc.put("a", gather.FromSlice([]byte{1, 2, 3, 4}))
+	mc.put("b", gather.FromSlice([]byte{5, 6, 7, 8}))
+
+	// touch "a", so that "b" becomes least recently used.
+	require.True(t, mc.get("a", 4, &tmp))
+	require.Equal(t, []byte{1, 2, 3, 4}, tmp.ToByteSlice())
+
+	mc.put("c", gather.FromSlice([]byte{9, 10, 11, 12}))
+
+	require.True(t, mc.get("a", -1, &tmp))
+	require.False(t, mc.get("b", -1, &tmp))
+	require.True(t, mc.get("c", -1, &tmp))
+	require.Equal(t, []byte{9, 10, 11, 12}, tmp.ToByteSlice())
+
+	// items larger than the cache are not stored.
+	mc.put("d", gather.FromSlice(make([]byte, 11)))
+	require.False(t, mc.get("d", -1, &tmp))
+	require.True(t, mc.get("a", -1, &tmp))
+
+	// length mismatch is a miss.
+	require.False(t, mc.get("a", 3, &tmp))
+	require.False(t, mc.get("a", -1, &tmp))
+}
+
+func TestMemoryCache_Corruption(t *testing.T) {
+	mc := newMemoryCache(100, nil, "test")
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	mc.put("a", gather.FromSlice([]byte{1, 2, 3, 4}))
+
+	mc.mu.Lock()
+	mc.entries["a"].Value.(*memoryCacheEntry).data[0] ^= 1 //nolint:forcetypeassert
+	mc.mu.Unlock()
+
+	require.False(t, mc.get("a", 4, &tmp))
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	require.Empty(t, mc.entries)
+	require.Zero(t, mc.totalBytes)
+}
+
+func TestMemoryCache_Disabled(t *testing.T) {
+	mc := newMemoryCache(0, nil, "test")
+	require.Nil(t, mc)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	mc.put("a", gather.FromSlice([]byte{1, 2, 3, 4}))
+	require.False(t, mc.get("a", 4, &tmp))
+}
@@ -14,6 +14,7 @@
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/internal/releasable"
+	"github.com/kopia/kopia/internal/sleepable"
 	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/logging"
@@ -25,6 +26,9 @@
 	// DefaultTouchThreshold specifies the resolution of timestamps used to determine which cache items
 	// to expire. This helps cache storage writes on frequently accessed items.
 	DefaultTouchThreshold = 10 * time.Minute
+
+	// maxExpirationCheckInterval is the maximum time between checks for items older than TTL.
+	maxExpirationCheckInterval = time.Hour
 )
 
 // PersistentCache provides persistent on-disk cache.
@@ -36,6 +40,8 @@ type PersistentCache struct {
 	listCache contentMetadataHeap
 	// +checklocks:listCacheMutex
 	pendingWriteBytes int64
+	// +checklocks:listCacheMutex
+	pinned map[blob.ID]int
 
 	cacheStorage      Storage
 	storageProtection cacheprot.StorageProtection
@@ -47,6 +53,9 @@ type PersistentCache struct {
 
 	description string
 
+	stopExpiration context.CancelFunc
+	expirationWG   sync.WaitGroup
+
 	metricsStruct
 }
 
@@ -210,15 +219,149 @@ func (c *PersistentCache) Put(ctx context.Context, key string, data gather.Bytes
 	})
 }
 
+// Pin prevents the item with the provided key from being expired based on TTL until the matching Unpin().
+// Pins are counted, so each call to Pin must be matched by a call to Unpin.
+func (c *PersistentCache) Pin(key string) {
+	if c == nil {
+		return
+	}
+
+	c.listCacheMutex.Lock()
+	defer c.listCacheMutex.Unlock()
+
+	c.pinned[blob.ID(key)]++
+}
+
+// Unpin releases the pin acquired with Pin().
+func (c *PersistentCache) Unpin(key string) {
+	if c == nil {
+		return
+	}
+
+	c.listCacheMutex.Lock()
+	defer c.listCacheMutex.Unlock()
+
+	if c.pinned[blob.ID(key)]--; c.pinned[blob.ID(key)] <= 0 {
+		delete(c.pinned, blob.ID(key))
+	}
+}
+
 // Close closes the instance of persistent cache possibly waiting for at least one sweep to complete.
 func (c *PersistentCache) Close(ctx context.Context) {
 	if c == nil {
 		return
 	}
 
+	if c.stopExpiration != nil {
+		c.stopExpiration()
+		c.expirationWG.Wait()
+	}
+
 	releasable.Released("persistent-cache", c)
 }
 
+// expirationLoop periodically removes items older than TTL until the provided context is canceled.
+func (c *PersistentCache) expirationLoop(ctx context.Context) {
+	defer c.expirationWG.Done()
+
+	var notBefore time.Time
+
+	for {
+		t := sleepable.NewTimer(c.timeNow, c.nextExpirationTime(notBefore))
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+
+		case <-t.C:
+		}
+
+		notBefore = time.Time{}
+
+		if !c.expireOldItems(ctx) {
+			// don't retry failed deletions immediately.
+			notBefore = c.timeNow().Add(min(c.sweep.TTL, maxExpirationCheckInterval) / 10) //nolint:mnd
+		}
+	}
+}
+
+// nextExpirationTime returns the time when the oldest item in the cache that is not pinned will become
+// older than TTL, but not earlier than notBefore.
+func (c *PersistentCache) nextExpirationTime(notBefore time.Time) time.Time {
+	c.listCacheMutex.Lock()
+	defer c.listCacheMutex.Unlock()
+
+	next := c.timeNow().Add(min(c.sweep.TTL, maxExpirationCheckInterval))
+
+	for _, it := range c.listCache.data {
+		if c.pinned[it.BlobID] > 0 {
+			continue
+		}
+
+		if e := it.Timestamp.Add(c.sweep.TTL); e.Before(next) {
+			next = e
+		}
+	}
+
+	if next.Before(notBefore) {
+		return notBefore
+	}
+
+	return next
+}
+
+// expireOldItems removes all items older than TTL except pinned ones and returns false if any
+// of the items could not be removed.
+func (c *PersistentCache) expireOldItems(ctx context.Context) bool {
+	c.listCacheMutex.Lock()
+	defer c.listCacheMutex.Unlock()
+
+	var (
+		retained     []blob.Metadata
+		expiredCount int
+		expiredBytes int64
+		success      = true
+		cutoff       = c.timeNow().Add(-c.sweep.TTL)
+	)
+
+	for len(c.listCache.data) > 0 && ctx.Err() == nil {
+		oldest := c.listCache.data[0]
+		if !oldest.Timestamp.Before(cutoff) {
+			break
+		}
+
+		heap.Pop(&c.listCache)
+
+		if c.pinned[oldest.BlobID] > 0 {
+			retained = append(retained, oldest)
+			continue
+		}
+
+		if delerr := c.cacheStorage.DeleteBlob(ctx, oldest.BlobID); delerr != nil && !errors.Is(delerr, blob.ErrBlobNotFound) {
+			log(ctx).Warnw("unable to remove expired cache item", "cache", c.description, "item", oldest.BlobID, "err", delerr)
+
+			retained = append(retained, oldest)
+			success = false
+
+			continue
+		}
+
+		expiredCount++
+		expiredBytes += oldest.Length
+	}
+
+	for _, m := range retained {
+		heap.Push(&c.listCache, m)
+	}
+
+	if expiredCount > 0 {
+		log(ctx).Debugw("expired cache items", "cache", c.description, "count", expiredCount, "bytes", expiredBytes)
+	}
+
+	return success
+}
+
 // A contentMetadataHeap implements heap.Interface and holds blob.Metadata.
 type contentMetadataHeap struct {
 	data           []blob.Metadata
@@ -420,6 +563,10 @@ type SweepSettings struct {
 
 	// on each use, items will be touched if they have not been touched in this long.
 	TouchThreshold time.Duration
+
+	// if non-zero, items which have not been touched in this long will be removed from the cache
+	// in the background regardless of its size, except for items which are pinned.
+	TTL time.Duration
 }
 
 func (s SweepSettings) applyDefaults() SweepSettings {
@@ -449,6 +596,7 @@ func NewPersistentCache(ctx context.Context, description string, cacheStorage St
 		storageProtection: storageProtection,
 		metricsStruct:     initMetricsStruct(mr, description),
 		listCache:         newContentMetadataHeap(),
+		pinned:            map[blob.ID]int{},
 		timeNow:           timeNow,
 		lastCacheWarning:  time.Time{},
 	}
@@ -468,5 +616,15 @@ func NewPersistentCache(ctx context.Context, description string, cacheStorage St
 		return nil, errors.Wrapf(err, "error during initial scan of %s", c.description)
 	}
 
+	if sweep.TTL > 0 {
+		// the expiration runs in the background until Close(), independently of the provided context.
+		ectx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+		c.stopExpiration = cancel
+		c.expirationWG.Add(1)
+
+		go c.expirationLoop(ectx)
+	}
+
 	return c, nil
 }
@@ -13,8 +13,10 @@
 	"github.com/kopia/kopia/internal/cache"
 	"github.com/kopia/kopia/internal/cacheprot"
 	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/faketime"
 	"github.com/kopia/kopia/internal/fault"
 	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/sleepable"
 	"github.com/kopia/kopia/internal/testlogging"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo/blob"
@@ -290,6 +292,84 @@ func TestPersistentLRUCache_Sweep1(t *testing.T) {
 	pc.Close(ctx)
 }
 
+func TestPersistentLRUCache_TTL(t *testing.T) {
+	// not parallel since it modifies sleepable.MaxSleepTime.
+	oldMaxSleepTime := sleepable.MaxSleepTime
+	sleepable.MaxSleepTime = 10 * time.Millisecond
+
+	t.Cleanup(func() { sleepable.MaxSleepTime = oldMaxSleepTime })
+
+	ctx := testlogging.ContextWithLevel(t, testlogging.LevelInfo)
+
+	ft := faketime.NewClockTimeWithOffset(0)
+	cs := blobtesting.NewMapStorageWithLimit(blobtesting.DataMap{}, nil, ft.NowFunc(), 1e6).(cache.Storage)
+
+	pc, err := cache.NewPersistentCache(ctx, "testing", cs, cacheprot.ChecksumProtection([]byte{1, 2, 3}), cache.SweepSettings{
+		MaxSizeBytes:   1e6,
+		TouchThreshold: time.Minute,
+		TTL:            time.Hour,
+	}, nil, ft.NowFunc())
+	require.NoError(t, err)
+
+	defer pc.Close(ctx)
+
+	someData := []byte{1, 2, 3}
+
+	pc.Put(ctx, "old", gather.FromSlice(someData))
+	pc.Put(ctx, "pinned", gather.FromSlice(someData))
+	pc.Pin("pinned")
+
+	ft.Advance(50 * time.Minute)
+	pc.Put(ctx, "recent", gather.FromSlice(someData))
+
+	ft.Advance(20 * time.Minute)
+
+	require.Eventually(t, func() bool {
+		_, err := cs.GetMetadata(ctx, "old")
+		return errors.Is(err, blob.ErrBlobNotFound)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	verifyBlobExists(ctx, t, cs, "pinned")
+	verifyBlobExists(ctx, t, cs, "recent")
+
+	// once unpinned, the item is expired as well.
+	pc.Unpin("pinned")
+	ft.Advance(time.Hour)
+
+	require.Eventually(t, func() bool {
+		_, err := cs.GetMetadata(ctx, "pinned")
+		return errors.Is(err, blob.ErrBlobNotFound)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	verifyBlobDoesNotExist(ctx, t, cs, "recent")
+}
+
+func TestPersistentLRUCache_TTLStopsOnClose(t *testing.T) {
+	ctx := testlogging.ContextWithLevel(t, testlogging.LevelInfo)
+
+	cs := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil).(cache.Storage)
+
+	pc, err := cache.NewPersistentCache(ctx, "testing", cs, nil, cache.SweepSettings{
+		MaxSizeBytes: 1e6,
+		TTL:          time.Hour,
+	}, nil, clock.Now)
+	require.NoError(t, err)
+
+	// Close() must not wait for the timer to fire.
+	done := make(chan struct{})
+
+	go func() {
+		pc.Close(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not stop background expiration")
+	}
+}
+
 func TestPersistentLRUCacheNil(t *testing.T) {
 	ctx := testlogging.ContextWithLevel(t, testlogging.LevelInfo)
 
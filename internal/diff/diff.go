@@ -2,6 +2,7 @@
 package diff
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@
 	"github.com/kopia/kopia/internal/iocopy"
 	"github.com/kopia/kopia/repo/logging"
 	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
 )
 
 const dirMode = 0o700
@@ -49,6 +51,14 @@ func maybeOID(e fs.Entry) string {
 	return ""
 }
 
+func inlineData(e fs.Entry) ([]byte, bool) {
+	if h, ok := e.(snapshot.HasDirEntry); ok && h.DirEntry().IsInline() {
+		return h.DirEntry().InlineData, true
+	}
+
+	return nil, false
+}
+
 func (c *Comparer) compareDirectories(ctx context.Context, dir1, dir2 fs.Directory, parent string) error {
 	log(ctx).Debugf("comparing directories %v (%v and %v)", parent, maybeOID(dir1), maybeOID(dir2))
 
@@ -78,13 +88,21 @@ func (c *Comparer) compareEntry(ctx context.Context, e1, e2 fs.Entry, path strin
 	// see if we have the same object IDs, which implies identical objects, thanks to content-addressable-storage
 	if h1, ok := e1.(object.HasObjectID); ok {
 		if h2, ok := e2.(object.HasObjectID); ok {
-			if h1.ObjectID() == h2.ObjectID() {
+			if h1.ObjectID() == h2.ObjectID() && h1.ObjectID() != object.EmptyID {
 				log(ctx).Debugf("unchanged %v", path)
 				return nil
 			}
 		}
 	}
 
+	// files stored inline have no object IDs, compare their data instead.
+	if d1, ok := inlineData(e1); ok {
+		if d2, ok := inlineData(e2); ok && bytes.Equal(d1, d2) {
+			log(ctx).Debugf("unchanged %v", path)
+			return nil
+		}
+	}
+
 	if e1 == nil {
 		if dir2, isDir2 := e2.(fs.Directory); isDir2 {
 			c.output("added directory %v\n", path)
@@ -0,0 +1,187 @@
+// Package heartbeat implements periodic writing of status of long-running operations,
+// which allows external watchers to detect stalled operations.
+package heartbeat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/sleepable"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/logging"
+)
+
+var log = logging.Module("heartbeat")
+
+// BlobIDPrefix is the prefix of heartbeat blobs written to the repository.
+const BlobIDPrefix blob.ID = "_heartbeat_"
+
+// DefaultInterval is the default interval between heartbeats.
+const DefaultInterval = time.Minute
+
+// Status is the contents of a single heartbeat. A heartbeat whose Time is older than a few intervals
+// indicates that the operation is hung or the process is gone.
+type Status struct {
+	Operation string        `json:"operation"`
+	Hostname  string        `json:"hostname,omitempty"`
+	Username  string        `json:"username,omitempty"`
+	PID       int           `json:"pid"`
+	StartTime time.Time     `json:"startTime"`
+	Time      time.Time     `json:"time"`
+	Interval  time.Duration `json:"interval"`
+	Sequence  int           `json:"sequence"`
+	Finished  bool          `json:"finished,omitempty"`
+	Progress  any           `json:"progress,omitempty"`
+}
+
+// Sink persists heartbeats.
+type Sink interface {
+	WriteHeartbeat(ctx context.Context, data []byte) error
+}
+
+type blobSink struct {
+	st     blob.Storage
+	blobID blob.ID
+}
+
+func (s blobSink) WriteHeartbeat(ctx context.Context, data []byte) error {
+	//nolint:wrapcheck
+	return s.st.PutBlob(ctx, s.blobID, gather.FromSlice(data), blob.PutOptions{})
+}
+
+// BlobSink returns a Sink which writes heartbeats to the provided blob, overwriting it each time.
+func BlobSink(st blob.Storage, blobID blob.ID) Sink {
+	return blobSink{st, blobID}
+}
+
+type fileSink string
+
+func (s fileSink) WriteHeartbeat(_ context.Context, data []byte) error {
+	//nolint:wrapcheck
+	return atomicfile.Write(string(s), bytes.NewReader(data))
+}
+
+// FileSink returns a Sink which atomically replaces the provided local file with each heartbeat.
+func FileSink(filename string) Sink {
+	return fileSink(filename)
+}
+
+// Options provides options for heartbeats.
+type Options struct {
+	Operation string
+	Hostname  string
+	Username  string
+	Interval  time.Duration
+	Sink      Sink
+
+	// Progress, when not nil, returns the current progress of the operation to be included in heartbeats.
+	Progress func() any
+
+	TimeNow func() time.Time
+}
+
+// Heartbeat periodically writes the status of an operation until stopped.
+type Heartbeat struct {
+	opt    Options
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// only accessed by the heartbeat goroutine and after it has stopped.
+	status Status
+}
+
+// Start starts writing heartbeats in the background until Stop() is called.
+// Failures to write heartbeats are logged but otherwise ignored, so that they never affect the operation.
+func Start(ctx context.Context, opt Options) *Heartbeat {
+	if opt.Interval <= 0 {
+		opt.Interval = DefaultInterval
+	}
+
+	if opt.TimeNow == nil {
+		opt.TimeNow = clock.Now
+	}
+
+	h := &Heartbeat{
+		opt: opt,
+		status: Status{
+			Operation: opt.Operation,
+			Hostname:  opt.Hostname,
+			Username:  opt.Username,
+			PID:       os.Getpid(),
+			StartTime: opt.TimeNow(),
+			Interval:  opt.Interval,
+		},
+	}
+
+	// heartbeats must continue until Stop(), even if the provided context is canceled.
+	ctx, h.cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+	h.wg.Add(1)
+
+	go h.run(ctx)
+
+	return h
+}
+
+func (h *Heartbeat) run(ctx context.Context) {
+	defer h.wg.Done()
+
+	h.write(ctx)
+
+	for {
+		t := sleepable.NewTimer(h.opt.TimeNow, h.opt.TimeNow().Add(h.opt.Interval))
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+
+		case <-t.C:
+			h.write(ctx)
+		}
+	}
+}
+
+func (h *Heartbeat) write(ctx context.Context) {
+	h.status.Time = h.opt.TimeNow()
+
+	if h.opt.Progress != nil {
+		h.status.Progress = h.opt.Progress()
+	}
+
+	data, err := json.Marshal(h.status)
+	if err != nil {
+		log(ctx).Warnf("unable to serialize heartbeat: %v", err)
+		return
+	}
+
+	// don't let a hung write delay subsequent heartbeats or the end of the operation.
+	ctx, cancel := context.WithTimeout(ctx, h.opt.Interval)
+	defer cancel()
+
+	if err := h.opt.Sink.WriteHeartbeat(ctx, data); err != nil {
+		log(ctx).Warnf("unable to write heartbeat for %v: %v", h.opt.Operation, err)
+	}
+
+	h.status.Sequence++
+}
+
+// Stop stops writing heartbeats and writes the final heartbeat marking the operation as finished.
+func (h *Heartbeat) Stop(ctx context.Context) {
+	if h == nil {
+		return
+	}
+
+	h.cancel()
+	h.wg.Wait()
+
+	h.status.Finished = true
+	h.write(context.WithoutCancel(ctx))
+}
@@ -0,0 +1,125 @@
+package heartbeat_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/heartbeat"
+	"github.com/kopia/kopia/internal/sleepable"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func TestHeartbeat(t *testing.T) {
+	oldMaxSleepTime := sleepable.MaxSleepTime
+	sleepable.MaxSleepTime = 10 * time.Millisecond
+
+	t.Cleanup(func() { sleepable.MaxSleepTime = oldMaxSleepTime })
+
+	ctx := testlogging.Context(t)
+	ft := faketime.NewClockTimeWithOffset(0)
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+
+	const blobID = heartbeat.BlobIDPrefix + "test"
+
+	progress := 0
+
+	hb := heartbeat.Start(ctx, heartbeat.Options{
+		Operation: "test-op",
+		Hostname:  "some-host",
+		Interval:  time.Minute,
+		Sink:      heartbeat.BlobSink(st, blobID),
+		Progress: func() any {
+			progress++
+			return progress
+		},
+		TimeNow: ft.NowFunc(),
+	})
+
+	require.Eventually(t, func() bool {
+		return readStatus(ctx, t, st, blobID).Sequence == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ft.Advance(2 * time.Minute)
+
+	require.Eventually(t, func() bool {
+		return readStatus(ctx, t, st, blobID).Sequence == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	hb.Stop(ctx)
+
+	s := readStatus(ctx, t, st, blobID)
+	require.True(t, s.Finished)
+	require.Equal(t, "test-op", s.Operation)
+	require.Equal(t, "some-host", s.Hostname)
+	require.Equal(t, time.Minute, s.Interval)
+	require.Equal(t, 2, s.Sequence)
+	require.EqualValues(t, 3, s.Progress)
+	require.True(t, s.Time.After(s.StartTime))
+
+	// stopping nil heartbeat is a no-op.
+	var nilHeartbeat *heartbeat.Heartbeat
+
+	nilHeartbeat.Stop(ctx)
+}
+
+func TestHeartbeat_WriteFailuresIgnored(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	fs := blobtesting.NewFaultyStorage(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil))
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(errors.New("some error")).Repeat(100)
+
+	hb := heartbeat.Start(ctx, heartbeat.Options{
+		Operation: "test-op",
+		Sink:      heartbeat.BlobSink(fs, heartbeat.BlobIDPrefix+"test"),
+	})
+
+	hb.Stop(ctx)
+}
+
+func TestHeartbeat_FileSink(t *testing.T) {
+	ctx := testlogging.Context(t)
+	fname := t.TempDir() + "/heartbeat.json"
+
+	heartbeat.Start(ctx, heartbeat.Options{
+		Operation: "test-op",
+		Sink:      heartbeat.FileSink(fname),
+	}).Stop(ctx)
+
+	b, err := os.ReadFile(fname)
+	require.NoError(t, err)
+
+	var hs heartbeat.Status
+
+	require.NoError(t, json.Unmarshal(b, &hs))
+	require.True(t, hs.Finished)
+	require.Equal(t, "test-op", hs.Operation)
+}
+
+func readStatus(ctx context.Context, t *testing.T, st blob.Storage, blobID blob.ID) heartbeat.Status {
+	t.Helper()
+
+	var (
+		tmp gather.WriteBuffer
+		s   heartbeat.Status
+	)
+
+	defer tmp.Close()
+
+	if err := st.GetBlob(ctx, blobID, 0, -1, &tmp); err != nil {
+		return heartbeat.Status{Sequence: -1}
+	}
+
+	require.NoError(t, json.Unmarshal(tmp.ToByteSlice(), &s))
+
+	return s
+}
@@ -59,6 +59,10 @@ func InfoDiff(i1, i2 index.Info, ignore ...string) []string {
 		diffs = append(diffs, fmt.Sprintf("GetEncryptionKeyID %v != %v", l, r))
 	}
 
+	if l, r := i1.FeatureFlags, i2.FeatureFlags; l != r {
+		diffs = append(diffs, fmt.Sprintf("GetFeatureFlags %v != %v", l, r))
+	}
+
 	// dear future reader, if this fails because the number of methods has changed,
 	// you need to add additional verification above.
 	if cnt := reflect.TypeOf(index.Info{}).NumMethod(); cnt != 1 {
@@ -40,6 +40,8 @@
 	"content_uploaded_bytes":                       33,
 	"content_write_bytes":                          34,
 	"content_write_duration_nanos":                 35,
+	"blob_write_throttled":                         36,
+	"content_predicted_non_compressible_bytes":     37,
 	// add new items here, use consecutive values
 })
 
@@ -61,7 +63,8 @@
 
 // SizeDistributions provides mapping between SizeDistribution metric names to IDs.
 //
-//nolint:gochecknoglobals
+//nolint:gochecknoglobals,mnd
 var SizeDistributions = NewMapping(map[string]int{
+	"blob_write_effective_concurrency": 1,
 	// add new items here, use consecutive values
 })
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Gauge represents an int64 value which can go up and down.
+// Unlike counters, gauges are only exported to Prometheus and are not included in snapshots.
+type Gauge struct {
+	state atomic.Int64
+
+	prom prometheus.Gauge
+}
+
+// Set sets the value of a gauge.
+func (g *Gauge) Set(v int64) {
+	if g == nil {
+		return
+	}
+
+	g.prom.Set(float64(v))
+	g.state.Store(v)
+}
+
+// Value returns the current value of a gauge.
+func (g *Gauge) Value() int64 {
+	if g == nil {
+		return 0
+	}
+
+	return g.state.Load()
+}
+
+// GaugeInt64 gets a persistent int64 gauge with the provided name.
+func (r *Registry) GaugeInt64(name, help string, labels map[string]string) *Gauge {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fullName := name + labelsSuffix(labels)
+
+	g := r.allGauges[fullName]
+	if g == nil {
+		g = &Gauge{
+			prom: getPrometheusGauge(prometheus.GaugeOpts{
+				Name: prometheusPrefix + name,
+				Help: help,
+			}, labels),
+		}
+
+		r.allGauges[fullName] = g
+	}
+
+	return g
+}
@@ -0,0 +1,39 @@
+package metrics_test
+
+import (
+	"testing"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/metrics"
+)
+
+func TestGauge_Nil(t *testing.T) {
+	var e *metrics.Registry
+	g := e.GaugeInt64("aaa", "bbb", nil)
+	require.Nil(t, g)
+	g.Set(33)
+	require.Equal(t, int64(0), g.Value())
+}
+
+func TestGauge(t *testing.T) {
+	e := metrics.NewRegistry()
+	g := e.GaugeInt64("some_real_gauge", "some-help", map[string]string{"key1": "label1"})
+
+	require.Same(t, g, e.GaugeInt64("some_real_gauge", "some-help", map[string]string{"key1": "label1"}))
+
+	g.Set(33)
+	require.Equal(t, 33.0,
+		mustFindMetric(t, "kopia_some_real_gauge", prommodel.MetricType_GAUGE, map[string]string{"key1": "label1"}).
+			GetGauge().GetValue())
+
+	g.Set(10)
+	require.Equal(t, 10.0,
+		mustFindMetric(t, "kopia_some_real_gauge", prommodel.MetricType_GAUGE, map[string]string{"key1": "label1"}).
+			GetGauge().GetValue())
+	require.Equal(t, int64(10), g.Value())
+
+	// gauges are not included in snapshots.
+	require.Empty(t, e.Snapshot(false).Counters)
+}
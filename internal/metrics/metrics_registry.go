@@ -22,6 +22,7 @@ type Registry struct {
 	startTime time.Time
 
 	allCounters              map[string]*Counter
+	allGauges                map[string]*Gauge
 	allThroughput            map[string]*Throughput
 	allDurationDistributions map[string]*Distribution[time.Duration]
 	allSizeDistributions     map[string]*Distribution[int64]
@@ -142,6 +143,7 @@ func NewRegistry() *Registry {
 		startTime: clock.Now(),
 
 		allCounters:              map[string]*Counter{},
+		allGauges:                map[string]*Gauge{},
 		allDurationDistributions: map[string]*Distribution[time.Duration]{},
 		allSizeDistributions:     map[string]*Distribution[int64]{},
 		allThroughput:            map[string]*Throughput{},
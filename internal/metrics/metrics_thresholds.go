@@ -44,6 +44,27 @@ type Thresholds[T constraints.Float | constraints.Integer] struct {
 	"",
 }
 
+// ConcurrencyThresholds is a set of thresholds for concurrency levels.
+//
+//nolint:gochecknoglobals
+var ConcurrencyThresholds = &Thresholds[int64]{
+	[]int64{
+		1,
+		2,
+		4,
+		8,
+		16,
+		32,
+		64,
+		128,
+		256,
+		512,
+		1024,
+	},
+	1,
+	"",
+}
+
 // IOLatencyThresholds is a set of thresholds that can represent IO latencies from 500us to 50s.
 //
 //nolint:gochecknoglobals
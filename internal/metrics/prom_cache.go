@@ -19,6 +19,8 @@
 	// +checklocks:promCacheMutex
 	promCounters = map[string]*prometheus.CounterVec{}
 	// +checklocks:promCacheMutex
+	promGauges = map[string]*prometheus.GaugeVec{}
+	// +checklocks:promCacheMutex
 	promHistograms = map[string]*prometheus.HistogramVec{}
 )
 
@@ -36,6 +38,20 @@ func getPrometheusCounter(opts prometheus.CounterOpts, labels map[string]string)
 	return prom.WithLabelValues(maps.Values(labels)...)
 }
 
+func getPrometheusGauge(opts prometheus.GaugeOpts, labels map[string]string) prometheus.Gauge {
+	promCacheMutex.Lock()
+	defer promCacheMutex.Unlock()
+
+	prom := promGauges[opts.Name]
+	if prom == nil {
+		prom = promauto.NewGaugeVec(opts, maps.Keys(labels))
+
+		promGauges[opts.Name] = prom
+	}
+
+	return prom.WithLabelValues(maps.Values(labels)...)
+}
+
 func getPrometheusHistogram(opts prometheus.HistogramOpts, labels map[string]string) prometheus.Observer {
 	promCacheMutex.Lock()
 	defer promCacheMutex.Unlock()
@@ -0,0 +1,119 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/metrics"
+)
+
+// ErrBudgetExhausted is returned instead of retrying when the retry budget of the operation has been exhausted.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+type budgetContextKey struct{}
+
+// BudgetOptions specifies the limits of a retry budget, zero values mean no limit.
+type BudgetOptions struct {
+	// MaxRetries is the total number of retries.
+	MaxRetries int
+
+	// MaxRetryTime is the total time spent sleeping between attempts and on attempts which failed.
+	MaxRetryTime time.Duration
+}
+
+// Budget limits retries performed by all retried calls of a single operation, such as a snapshot or a restore,
+// so that a degraded backend causes the operation to fail fast instead of each call retrying independently.
+type Budget struct {
+	opt BudgetOptions
+
+	mu sync.Mutex
+	// +checklocks:mu
+	retries int
+	// +checklocks:mu
+	retryTime time.Duration
+
+	remainingRetries *metrics.Gauge
+	remainingTime    *metrics.Gauge
+}
+
+// NewBudget creates a new retry budget which reports the remaining budget to the provided metrics registry.
+func NewBudget(opt BudgetOptions, mr *metrics.Registry) *Budget {
+	b := &Budget{
+		opt:              opt,
+		remainingRetries: mr.GaugeInt64("retry_budget_remaining_retries", "Number of retries remaining in the retry budget", nil),
+		remainingTime:    mr.GaugeInt64("retry_budget_remaining_time_nanos", "Time remaining in the retry budget", nil),
+	}
+
+	b.mu.Lock()
+	b.reportLocked()
+	b.mu.Unlock()
+
+	return b
+}
+
+// Remaining returns the number of retries and retry time remaining, negative values mean no limit.
+func (b *Budget) Remaining() (retries int, retryTime time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.remainingLocked()
+}
+
+// +checklocks:b.mu
+func (b *Budget) remainingLocked() (retries int, retryTime time.Duration) {
+	retries, retryTime = -1, -1
+
+	if b.opt.MaxRetries > 0 {
+		retries = max(b.opt.MaxRetries-b.retries, 0)
+	}
+
+	if b.opt.MaxRetryTime > 0 {
+		retryTime = max(b.opt.MaxRetryTime-b.retryTime, 0)
+	}
+
+	return retries, retryTime
+}
+
+// +checklocks:b.mu
+func (b *Budget) reportLocked() {
+	retries, retryTime := b.remainingLocked()
+
+	b.remainingRetries.Set(int64(retries))
+	b.remainingTime.Set(retryTime.Nanoseconds())
+}
+
+// consume accounts for a retry which is expected to take the provided amount of time and returns false
+// if the budget does not allow it.
+func (b *Budget) consume(d time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.opt.MaxRetries > 0 && b.retries >= b.opt.MaxRetries {
+		return false
+	}
+
+	if b.opt.MaxRetryTime > 0 && b.retryTime+d > b.opt.MaxRetryTime {
+		return false
+	}
+
+	b.retries++
+	b.retryTime += d
+
+	b.reportLocked()
+
+	return true
+}
+
+// WithBudget returns a context which causes all retries performed using it to be accounted against the provided budget.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetContextKey{}, b)
+}
+
+func budgetFromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetContextKey{}).(*Budget)
+
+	return b
+}
@@ -3,10 +3,12 @@
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/repo/logging"
 )
 
@@ -55,6 +57,13 @@ func PeriodicallyNoValue(ctx context.Context, interval time.Duration, count int,
 // internalRetry runs the provided attempt until it succeeds, retrying on all errors that are
 // deemed retriable by the provided function. The delay between retries grows exponentially up to
 // a certain limit.
+//
+// When the context has a deadline, the time spent on attempts and sleeps is accounted against it
+// and retrying stops early when the remaining time is not sufficient to sleep and complete another
+// attempt taking as long as the previous one.
+//
+// When the context has a retry budget, each retry is accounted against it and ErrBudgetExhausted
+// is returned once the budget does not allow another one.
 func internalRetry[T any](ctx context.Context, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc, initial, maxSleep time.Duration, count int, factor float64) (T, error) {
 	sleepAmount := initial
 
@@ -71,6 +80,8 @@ func internalRetry[T any](ctx context.Context, desc string, attempt func() (T, e
 			return defaultT, cerr
 		}
 
+		attemptStart := clock.Now()
+
 		v, err := attempt()
 		if err == nil {
 			return v, nil
@@ -82,8 +93,23 @@ func internalRetry[T any](ctx context.Context, desc string, attempt func() (T, e
 			return v, err
 		}
 
+		if deadline, ok := ctx.Deadline(); ok {
+			if remaining, needed := deadline.Sub(clock.Now()), sleepAmount+clock.Now().Sub(attemptStart); remaining < needed {
+				return defaultT, errors.Wrapf(lastError, "unable to complete %v after %v retries, remaining time until deadline (%v) is insufficient to retry", desc, i, remaining)
+			}
+		}
+
+		if b := budgetFromContext(ctx); b != nil && !b.consume(sleepAmount+clock.Now().Sub(attemptStart)) {
+			// wrap both errors, so that callers can match the budget exhaustion as well as the underlying cause.
+			return defaultT, fmt.Errorf("unable to complete %v after %v retries: %w, last error: %w", desc, i, ErrBudgetExhausted, lastError)
+		}
+
 		log(ctx).Debugf("got error %v when %v (#%v), sleeping for %v before retrying", err, desc, i, sleepAmount)
-		time.Sleep(sleepAmount)
+
+		if !clock.SleepInterruptibly(ctx, sleepAmount) {
+			return defaultT, ctx.Err() //nolint:wrapcheck
+		}
+
 		sleepAmount = time.Duration(float64(sleepAmount) * factor)
 
 		if sleepAmount > maxSleep {
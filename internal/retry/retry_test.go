@@ -8,6 +8,7 @@
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/internal/testlogging"
 )
 
@@ -72,3 +73,111 @@ func TestRetryContextCancel(t *testing.T) {
 		return errRetriable
 	}, isRetriable))
 }
+
+func TestRetryDoesNotExceedDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(testlogging.Context(t), 2*time.Second)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+
+	// each attempt takes 300ms + 1s sleep between attempts, so the second attempt ends at 1.6s and
+	// the deadline check fails because there's not enough time left for a third one.
+	err := PeriodicallyNoValue(ctx, time.Second, 10, "deadline", func() error {
+		attempts++
+
+		time.Sleep(300 * time.Millisecond)
+
+		return errRetriable
+	}, isRetriable)
+
+	require.ErrorIs(t, err, errRetriable)
+	require.ErrorContains(t, err, "insufficient to retry")
+	require.Equal(t, 2, attempts)
+	require.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestRetrySleepIsInterruptedByCancellation(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(testlogging.Context(t))
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+
+	require.ErrorIs(t, PeriodicallyNoValue(ctx, time.Hour, 10, "canceled", func() error {
+		return errRetriable
+	}, isRetriable), context.Canceled)
+
+	require.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	mr := metrics.NewRegistry()
+	b := NewBudget(BudgetOptions{MaxRetries: 3}, mr)
+	ctx := WithBudget(testlogging.Context(t), b)
+
+	attempts := 0
+
+	// the first call consumes 2 retries.
+	require.NoError(t, PeriodicallyNoValue(ctx, time.Millisecond, 100, "first", func() error {
+		attempts++
+		if attempts < 3 {
+			return errRetriable
+		}
+
+		return nil
+	}, isRetriable))
+
+	retries, retryTime := b.Remaining()
+	require.Equal(t, 1, retries)
+	require.Equal(t, time.Duration(-1), retryTime)
+	require.EqualValues(t, 1, mr.GaugeInt64("retry_budget_remaining_retries", "", nil).Value())
+
+	// the second call is left with a single retry.
+	attempts = 0
+
+	err := PeriodicallyNoValue(ctx, time.Millisecond, 100, "second", func() error {
+		attempts++
+		return errRetriable
+	}, isRetriable)
+	require.ErrorIs(t, err, ErrBudgetExhausted)
+	require.ErrorIs(t, err, errRetriable)
+	require.Equal(t, 2, attempts)
+
+	retries, _ = b.Remaining()
+	require.Zero(t, retries)
+
+	// calls without the budget are not affected.
+	attempts = 0
+
+	require.ErrorContains(t, PeriodicallyNoValue(testlogging.Context(t), time.Millisecond, 5, "third", func() error {
+		attempts++
+		return errRetriable
+	}, isRetriable), "despite 5 retries")
+	require.Equal(t, 5, attempts)
+}
+
+func TestRetryBudget_Time(t *testing.T) {
+	t.Parallel()
+
+	b := NewBudget(BudgetOptions{MaxRetryTime: 50 * time.Millisecond}, nil)
+	ctx := WithBudget(testlogging.Context(t), b)
+
+	attempts := 0
+
+	err := PeriodicallyNoValue(ctx, 20*time.Millisecond, -1, "timed", func() error {
+		attempts++
+		return errRetriable
+	}, isRetriable)
+	require.ErrorIs(t, err, ErrBudgetExhausted)
+	require.Equal(t, 3, attempts)
+
+	_, retryTime := b.Remaining()
+	require.Less(t, retryTime, 20*time.Millisecond)
+}
@@ -24,6 +24,28 @@ type Item struct {
 	Description string
 	NextTime    time.Time
 	Trigger     func()
+
+	// Skip is invoked instead of Trigger when the item became due while the scheduler was paused
+	// and the catch-up policy is CatchUpSkip. It must reschedule the item to a time in the future.
+	// Items without Skip are triggered regardless of the catch-up policy.
+	Skip func()
+}
+
+// CatchUpPolicy determines how items which became due while the scheduler was paused are handled when it's resumed.
+type CatchUpPolicy string
+
+// Supported catch-up policies.
+const (
+	// CatchUpRunOnce triggers each missed item once after resuming, regardless of how many times it was missed.
+	CatchUpRunOnce CatchUpPolicy = "run-once"
+
+	// CatchUpSkip skips missed items, which are next triggered at their following scheduled time.
+	CatchUpSkip CatchUpPolicy = "skip"
+)
+
+// SupportedCatchUpPolicies returns the list of supported catch-up policies.
+func SupportedCatchUpPolicies() []string {
+	return []string{string(CatchUpRunOnce), string(CatchUpSkip)}
 }
 
 // Scheduler manages triggering of arbitrary events by periodically determining the first
@@ -36,6 +58,16 @@ type Scheduler struct {
 	getItems         GetItemsFunc
 	closed           chan struct{}
 	wg               sync.WaitGroup
+	catchUp          CatchUpPolicy
+
+	// pauseChanged wakes up the scheduler loop when the scheduler is paused or resumed.
+	pauseChanged chan struct{}
+
+	mu sync.Mutex
+	// +checklocks:mu
+	pausedSince time.Time
+	// +checklocks:mu
+	resumedAt time.Time
 }
 
 // Options the scheduler.
@@ -43,6 +75,12 @@ type Options struct {
 	TimeNow        func() time.Time
 	Debug          bool
 	RefreshChannel chan string
+
+	// CatchUp determines how items missed while the scheduler was paused are handled, CatchUpRunOnce by default.
+	CatchUp CatchUpPolicy
+
+	// StartPaused causes the scheduler to start in the paused state.
+	StartPaused bool
 }
 
 // Start runs a new scheduler that will call getItems() to get the list of items to schedule.
@@ -53,12 +91,23 @@ func Start(ctx context.Context, getItems GetItemsFunc, opts Options) *Scheduler
 		timeNow = clock.Now
 	}
 
+	catchUp := opts.CatchUp
+	if catchUp == "" {
+		catchUp = CatchUpRunOnce
+	}
+
 	s := &Scheduler{
 		TimeNow:          timeNow,
 		refreshRequested: opts.RefreshChannel,
 		closed:           make(chan struct{}),
 		getItems:         getItems,
 		Debug:            opts.Debug,
+		catchUp:          catchUp,
+		pauseChanged:     make(chan struct{}, 1),
+	}
+
+	if opts.StartPaused {
+		s.pausedSince = timeNow()
 	}
 
 	s.wg.Add(1)
@@ -114,11 +163,113 @@ func (s *Scheduler) Stop() {
 	s.wg.Wait()
 }
 
+// Pause suspends triggering of items until Resume is called. Items which have already been triggered
+// are not affected. Pausing a paused scheduler has no effect.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.pausedSince.IsZero() {
+		return
+	}
+
+	s.pausedSince = s.TimeNow()
+	s.notifyPauseChanged()
+}
+
+// Resume resumes triggering of items, items which became due while the scheduler was paused are handled
+// according to the catch-up policy. Resuming a scheduler which is not paused has no effect.
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pausedSince.IsZero() {
+		return
+	}
+
+	s.pausedSince = time.Time{}
+	s.resumedAt = s.TimeNow()
+	s.notifyPauseChanged()
+}
+
+// Paused returns true and the time when the scheduler was paused, if it's paused.
+func (s *Scheduler) Paused() (since time.Time, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pausedSince, !s.pausedSince.IsZero()
+}
+
+func (s *Scheduler) notifyPauseChanged() {
+	select {
+	case s.pauseChanged <- struct{}{}:
+	default:
+	}
+}
+
+// takeResumedAt returns the time when the scheduler was last resumed, if missed items have not been handled since.
+func (s *Scheduler) takeResumedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.resumedAt
+	s.resumedAt = time.Time{}
+
+	return t
+}
+
+// skipMissedItems invokes Skip for items which were due before the scheduler was resumed.
+func skipMissedItems(ctx context.Context, items []Item, resumedAt time.Time) {
+	for _, it := range items {
+		if it.Skip != nil && !it.NextTime.After(resumedAt) {
+			log(ctx).Debugf("skipping %v missed while paused", it.Description)
+
+			it.Skip()
+		}
+	}
+}
+
+// waitWhilePaused waits until the scheduler is resumed and returns false if it was stopped in the meantime.
+func (s *Scheduler) waitWhilePaused(ctx context.Context) bool {
+	for {
+		since, paused := s.Paused()
+		if !paused {
+			return true
+		}
+
+		if s.Debug {
+			log(ctx).Debugf("paused since %v", since.Format(time.RFC3339))
+		}
+
+		select {
+		case <-s.closed:
+			return false
+
+		case <-s.pauseChanged:
+
+		case reason := <-s.refreshRequested:
+			if s.Debug {
+				log(ctx).Debugw("schedule re-evaluation requested while paused", "reason", reason)
+			}
+		}
+	}
+}
+
 func (s *Scheduler) run(ctx context.Context) {
 	var timer *time.Timer
 
 	for {
+		if !s.waitWhilePaused(ctx) {
+			return
+		}
+
 		now := s.TimeNow()
+
+		if resumedAt := s.takeResumedAt(); !resumedAt.IsZero() && s.catchUp == CatchUpSkip {
+			// skipped items are rescheduled, so they must be fetched again.
+			skipMissedItems(ctx, s.getItems(ctx, now), resumedAt)
+		}
+
 		nextTriggerTime, toTrigger := s.upcomingItems(ctx, now)
 
 		sleepTimeUntilNextTrigger := sleepTimeOrDefault(now, nextTriggerTime, sleepTimeWhenNoUpcomingSnapshots)
@@ -146,7 +297,14 @@ func (s *Scheduler) run(ctx context.Context) {
 			// stopping, just exit
 			return
 
+		case <-s.pauseChanged:
+			// re-evaluate the pause state
+
 		case <-timer.C:
+			if _, paused := s.Paused(); paused {
+				continue
+			}
+
 			for _, sm := range toTrigger {
 				log(ctx).Debugf("triggering %v", sm.Description)
 
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -21,12 +22,12 @@ func TestScheduler(t *testing.T) {
 	ch := make(chan string, 1000)
 
 	// times of upcoming events
-	it1 := scheduler.Item{"it1", baseTime.Add(100 * time.Millisecond), reportTriggered(t, ch, "it1")}
-	it2a := scheduler.Item{"it2", baseTime.Add(200 * time.Millisecond), reportTriggered(t, ch, "it2")}
-	it2b := scheduler.Item{"it2", baseTime.Add(200 * time.Millisecond), reportTriggered(t, ch, "it2")}
-	it3a := scheduler.Item{"it3", baseTime.Add(300 * time.Millisecond), reportTriggered(t, ch, "it3")}
-	it3b := scheduler.Item{"it3", baseTime.Add(300 * time.Millisecond), reportTriggered(t, ch, "it3")}
-	it4 := scheduler.Item{"it4", baseTime.Add(30 * time.Hour), reportTriggered(t, ch, "it4")}
+	it1 := scheduler.Item{Description: "it1", NextTime: baseTime.Add(100 * time.Millisecond), Trigger: reportTriggered(t, ch, "it1")}
+	it2a := scheduler.Item{Description: "it2", NextTime: baseTime.Add(200 * time.Millisecond), Trigger: reportTriggered(t, ch, "it2")}
+	it2b := scheduler.Item{Description: "it2", NextTime: baseTime.Add(200 * time.Millisecond), Trigger: reportTriggered(t, ch, "it2")}
+	it3a := scheduler.Item{Description: "it3", NextTime: baseTime.Add(300 * time.Millisecond), Trigger: reportTriggered(t, ch, "it3")}
+	it3b := scheduler.Item{Description: "it3", NextTime: baseTime.Add(300 * time.Millisecond), Trigger: reportTriggered(t, ch, "it3")}
+	it4 := scheduler.Item{Description: "it4", NextTime: baseTime.Add(30 * time.Hour), Trigger: reportTriggered(t, ch, "it4")}
 
 	items := []scheduler.Item{it1, it2a, it2b, it3a, it3b, it4}
 
@@ -72,7 +73,7 @@ func TestScheduler(t *testing.T) {
 	}
 
 	// now change the set of items returned by adding it5 which comes before it4
-	it5 := scheduler.Item{"it5", ft.NowFunc()().Add(time.Second), reportTriggered(t, ch, "it5")}
+	it5 := scheduler.Item{Description: "it5", NextTime: ft.NowFunc()().Add(time.Second), Trigger: reportTriggered(t, ch, "it5")}
 	items = []scheduler.Item{it1, it2a, it2b, it3a, it3b, it4, it5}
 
 	refresh <- "x"
@@ -97,9 +98,9 @@ func TestSchedulerWillTriggerItemsInThePast(t *testing.T) {
 	s := scheduler.Start(ctx, func(ctx context.Context, now time.Time) []scheduler.Item {
 		if v := cnt.Add(1); v <= 3 {
 			return []scheduler.Item{{
-				"it1",
-				now.Add(-100 * time.Millisecond),
-				func() {
+				Description: "it1",
+				NextTime:    now.Add(-100 * time.Millisecond),
+				Trigger: func() {
 					t.Logf("zzz %v", v)
 				},
 			}}
@@ -128,17 +129,17 @@ func TestSchedulerRefresh(t *testing.T) {
 		switch cnt.Add(1) {
 		case 1:
 			return []scheduler.Item{{
-				"it1",
-				now.Add(time.Hour),
-				func() {
+				Description: "it1",
+				NextTime:    now.Add(time.Hour),
+				Trigger: func() {
 					t.Error("this should not happen")
 				},
 			}}
 		case 2:
 			return []scheduler.Item{{
-				"it1",
-				now,
-				func() {
+				Description: "it1",
+				NextTime:    now,
+				Trigger: func() {
 					close(triggered)
 				},
 			}}
@@ -167,6 +168,117 @@ func() {
 	}
 }
 
+func TestSchedulerPauseResume(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var triggerCount atomic.Int32
+
+	start := clock.Now()
+
+	s := scheduler.Start(ctx, func(ctx context.Context, now time.Time) []scheduler.Item {
+		if triggerCount.Load() > 0 {
+			return nil
+		}
+
+		return []scheduler.Item{{
+			Description: "it1",
+			NextTime:    start.Add(100 * time.Millisecond),
+			Trigger: func() {
+				triggerCount.Add(1)
+			},
+		}}
+	}, scheduler.Options{StartPaused: true})
+
+	defer s.Stop()
+
+	since, paused := s.Paused()
+	require.True(t, paused)
+	require.False(t, since.IsZero())
+
+	// the item is not triggered while paused.
+	time.Sleep(500 * time.Millisecond)
+	require.EqualValues(t, 0, triggerCount.Load())
+
+	// pausing again does not change the pause time.
+	s.Pause()
+
+	since2, paused := s.Paused()
+	require.True(t, paused)
+	require.Equal(t, since, since2)
+
+	// the missed item is triggered once after resuming.
+	s.Resume()
+
+	_, paused = s.Paused()
+	require.False(t, paused)
+
+	require.Eventually(t, func() bool { return triggerCount.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(200 * time.Millisecond)
+	require.EqualValues(t, 1, triggerCount.Load())
+}
+
+func TestSchedulerCatchUpSkip(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var (
+		mu          sync.Mutex
+		nextTime    = clock.Now().Add(100 * time.Millisecond)
+		triggeredAt time.Time
+		skipCount   atomic.Int32
+	)
+
+	s := scheduler.Start(ctx, func(ctx context.Context, now time.Time) []scheduler.Item {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !triggeredAt.IsZero() {
+			return nil
+		}
+
+		return []scheduler.Item{{
+			Description: "it1",
+			NextTime:    nextTime,
+			Trigger: func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				triggeredAt = clock.Now()
+			},
+			Skip: func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				skipCount.Add(1)
+				nextTime = clock.Now().Add(300 * time.Millisecond)
+			},
+		}}
+	}, scheduler.Options{StartPaused: true, CatchUp: scheduler.CatchUpSkip})
+
+	defer s.Stop()
+
+	time.Sleep(300 * time.Millisecond)
+
+	resumedAt := clock.Now()
+
+	s.Resume()
+
+	// the missed item is skipped and triggered at its following scheduled time.
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return !triggeredAt.IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.EqualValues(t, 1, skipCount.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.GreaterOrEqual(t, triggeredAt.Sub(resumedAt), 300*time.Millisecond)
+}
+
 func TestTriggerNames(t *testing.T) {
 	cases := []struct {
 		items []scheduler.Item
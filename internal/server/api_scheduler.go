@@ -0,0 +1,25 @@
+package server
+
+import (
+	"context"
+)
+
+func handleSchedulerStatus(_ context.Context, rc requestContext) (interface{}, *apiError) {
+	return rc.srv.SchedulerStatus(), nil
+}
+
+func handleSchedulerPause(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	rc.srv.PauseScheduler(ctx)
+
+	return rc.srv.SchedulerStatus(), nil
+}
+
+func handleSchedulerResume(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	rc.srv.ResumeScheduler(ctx)
+
+	return rc.srv.SchedulerStatus(), nil
+}
+
+func handleBackgroundVerificationStatus(_ context.Context, rc requestContext) (interface{}, *apiError) {
+	return rc.srv.BackgroundVerificationStatus(), nil
+}
@@ -130,6 +130,12 @@ func handleEditSnapshots(ctx context.Context, rc requestContext) (interface{}, *
 		return nil, requestError(serverapi.ErrorMalformedRequest, "malformed request")
 	}
 
+	if req.NewDescription != nil {
+		if err := snapshot.ValidateDescription(*req.NewDescription); err != nil {
+			return nil, requestError(serverapi.ErrorMalformedRequest, "invalid description: "+err.Error())
+		}
+	}
+
 	var snaps []*serverapi.Snapshot
 
 	if err := repo.WriteSession(ctx, rc.rep, repo.WriteSessionOptions{
@@ -252,6 +252,13 @@ func TestEditSnapshots(t *testing.T) {
 		RemovePins: []string{"pin3"},
 	}, &updated))
 
+	invalidDesc := "multi\nline"
+
+	require.Error(t, cli.Post(ctx, "snapshots/edit", &serverapi.EditSnapshotsRequest{
+		Snapshots:      []manifest.ID{updated[0].ID},
+		NewDescription: &invalidDesc,
+	}, &updated))
+
 	require.Len(t, updated, 1)
 	require.EqualValues(t, []string{"pin2"}, updated[0].Pins)
 	require.EqualValues(t, newDesc2, updated[0].Description)
@@ -9,6 +9,7 @@
 
 	"github.com/kopia/kopia/internal/auth"
 	"github.com/kopia/kopia/internal/mount"
+	"github.com/kopia/kopia/internal/serverapi"
 	"github.com/kopia/kopia/internal/uitask"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/object"
@@ -36,6 +37,10 @@ type serverInterface interface {
 	getConnectOptions(cliOpts repo.ClientOptions) *repo.ConnectOptions
 	SetRepository(ctx context.Context, rep repo.Repository) error
 	InitRepositoryAsync(ctx context.Context, mode string, initializer InitRepositoryFunc, wait bool) (string, error)
+	PauseScheduler(ctx context.Context)
+	ResumeScheduler(ctx context.Context)
+	SchedulerStatus() *serverapi.SchedulerStatus
+	BackgroundVerificationStatus() *serverapi.BackgroundVerificationStatus
 }
 
 type requestContext struct {
@@ -12,6 +12,7 @@
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -20,6 +21,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/auth"
+	"github.com/kopia/kopia/internal/bgverify"
 	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/ctxutil"
 	"github.com/kopia/kopia/internal/mount"
@@ -103,6 +105,13 @@ type Server struct {
 	// +checklocks:serverMutex
 	sched *scheduler.Scheduler
 
+	// time when the scheduler was paused, preserved when the scheduler is restarted after reconnecting.
+	// +checklocks:serverMutex
+	schedulerPausedSince time.Time
+
+	// background verification of the current repository, nil when not enabled.
+	verification atomic.Pointer[srvVerification]
+
 	nextRefreshTimeLock sync.Mutex
 
 	// +checklocks:nextRefreshTimeLock
@@ -174,6 +183,10 @@ func (s *Server) SetupControlAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/control/pause-source", s.handleServerControlAPI(handlePause)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/resume-source", s.handleServerControlAPI(handleResume)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/throttle", s.handleServerControlAPI(handleRepoGetThrottle)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/scheduler", s.handleServerControlAPIPossiblyNotConnected(handleSchedulerStatus)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/control/pause-scheduler", s.handleServerControlAPIPossiblyNotConnected(handleSchedulerPause)).Methods(http.MethodPost)
+	m.HandleFunc("/api/v1/control/resume-scheduler", s.handleServerControlAPIPossiblyNotConnected(handleSchedulerResume)).Methods(http.MethodPost)
+	m.HandleFunc("/api/v1/control/verification", s.handleServerControlAPIPossiblyNotConnected(handleBackgroundVerificationStatus)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/control/throttle", s.handleServerControlAPI(handleRepoSetThrottle)).Methods(http.MethodPut)
 }
 
@@ -345,6 +358,8 @@ func (s *Server) handleRequestPossiblyNotConnected(isAuthorized isAuthorizedFunc
 
 		rc.body = body
 
+		s.noteForegroundOperation()
+
 		if s.options.LogRequests {
 			log(ctx).Debugf("request %v (%v bytes)", rc.req.URL, len(body))
 		}
@@ -565,6 +580,10 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 			s.maint.stop(ctx)
 			s.maint = nil
 		}
+
+		if v := s.verification.Swap(nil); v != nil {
+			v.stop(ctx)
+		}
 	}
 
 	s.rep = rep
@@ -581,6 +600,10 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 
 	if dr, ok := s.rep.(repo.DirectRepository); ok {
 		s.maint = startMaintenanceManager(ctx, dr, s, s.options.MinMaintenanceInterval)
+
+		if s.options.BackgroundVerification != nil {
+			s.verification.Store(startBackgroundVerification(ctx, dr, *s.options.BackgroundVerification))
+		}
 	} else {
 		s.maint = nil
 	}
@@ -589,11 +612,88 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 		TimeNow:        clock.Now,
 		Debug:          s.options.DebugScheduler,
 		RefreshChannel: s.schedulerRefresh,
+		CatchUp:        s.options.SchedulerCatchUp,
+		StartPaused:    !s.schedulerPausedSince.IsZero(),
 	})
 
 	return nil
 }
 
+// PauseScheduler suspends scheduled snapshots and maintenance until ResumeScheduler is called.
+// Snapshots which are already running are allowed to finish.
+func (s *Server) PauseScheduler(ctx context.Context) {
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+
+	if !s.schedulerPausedSince.IsZero() {
+		return
+	}
+
+	log(ctx).Info("pausing scheduler")
+
+	s.schedulerPausedSince = clock.Now()
+
+	if s.sched != nil {
+		s.sched.Pause()
+	}
+}
+
+// ResumeScheduler resumes scheduled snapshots and maintenance, snapshots missed while the scheduler was paused
+// are handled according to the catch-up policy.
+func (s *Server) ResumeScheduler(ctx context.Context) {
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+
+	if s.schedulerPausedSince.IsZero() {
+		return
+	}
+
+	log(ctx).Info("resuming scheduler")
+
+	s.schedulerPausedSince = time.Time{}
+
+	if s.sched != nil {
+		s.sched.Resume()
+	}
+}
+
+// SchedulerStatus returns the status of the scheduler.
+func (s *Server) SchedulerStatus() *serverapi.SchedulerStatus {
+	s.serverMutex.RLock()
+	defer s.serverMutex.RUnlock()
+
+	st := &serverapi.SchedulerStatus{
+		Paused:  !s.schedulerPausedSince.IsZero(),
+		CatchUp: string(s.options.SchedulerCatchUp),
+	}
+
+	if st.CatchUp == "" {
+		st.CatchUp = string(scheduler.CatchUpRunOnce)
+	}
+
+	if st.Paused {
+		t := s.schedulerPausedSince
+		st.PausedSince = &t
+	}
+
+	return st
+}
+
+// BackgroundVerificationStatus returns the status of background verification of repository contents.
+func (s *Server) BackgroundVerificationStatus() *serverapi.BackgroundVerificationStatus {
+	v := s.verification.Load()
+	if v == nil {
+		return &serverapi.BackgroundVerificationStatus{}
+	}
+
+	st := v.v.Status()
+
+	return &serverapi.BackgroundVerificationStatus{
+		Enabled: true,
+		Status:  &st,
+	}
+}
+
 // +checklocks:s.serverMutex
 func (s *Server) stopAllSourceManagersLocked(ctx context.Context) {
 	for _, sm := range s.sourceManagers {
@@ -798,6 +898,8 @@ type Options struct {
 	UITitlePrefix          string
 	DebugScheduler         bool
 	MinMaintenanceInterval time.Duration
+	SchedulerCatchUp       scheduler.CatchUpPolicy // handling of snapshots missed while the scheduler was paused
+	BackgroundVerification *bgverify.Options       // when set, contents of direct repositories are continuously verified
 }
 
 // InitRepositoryFunc is a function that attempts to connect to/open repository.
@@ -1023,6 +1125,7 @@ func (s *Server) getSchedulerItems(ctx context.Context, now time.Time) []schedul
 			result = append(result, scheduler.Item{
 				Description: fmt.Sprintf("snapshot %q", sm.src.Path),
 				Trigger:     sm.scheduleSnapshotNow,
+				Skip:        sm.skipMissedSnapshot,
 				NextTime:    nst,
 			})
 		} else {
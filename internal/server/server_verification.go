@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kopia/kopia/internal/bgverify"
+	"github.com/kopia/kopia/internal/ctxutil"
+	"github.com/kopia/kopia/repo"
+)
+
+// srvVerification runs background verification of contents of a direct repository.
+type srvVerification struct {
+	v         *bgverify.Verifier
+	cancelCtx context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func startBackgroundVerification(ctx context.Context, dr repo.DirectRepository, opt bgverify.Options) *srvVerification {
+	ctx, cancel := context.WithCancel(ctxutil.Detach(ctx))
+
+	s := &srvVerification{
+		v:         bgverify.New(dr.ContentReader(), opt),
+		cancelCtx: cancel,
+	}
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		log(ctx).Debug("background verification started")
+
+		if err := s.v.Run(ctx); err != nil && ctx.Err() == nil {
+			log(ctx).Errorf("background verification stopped: %v", err)
+		}
+	}()
+
+	return s
+}
+
+func (s *srvVerification) stop(ctx context.Context) {
+	s.cancelCtx()
+	s.wg.Wait()
+
+	log(ctx).Debug("background verification stopped")
+}
+
+// noteForegroundOperation slows down background verification while the server is busy.
+func (s *Server) noteForegroundOperation() {
+	if v := s.verification.Load(); v != nil {
+		v.v.ForegroundOperation()
+	}
+}
@@ -239,6 +239,16 @@ func (s *sourceManager) scheduleSnapshotNow() {
 	}
 }
 
+// skipMissedSnapshot reschedules a snapshot which was missed while the scheduler was paused to the following
+// scheduled time, as if the missed one had been attempted.
+func (s *sourceManager) skipMissedSnapshot() {
+	s.sourceMutex.Lock()
+	defer s.sourceMutex.Unlock()
+
+	s.lastAttemptedSnapshotTime = fs.UTCTimestampFromTime(clock.Now())
+	s.nextSnapshotTime = s.findClosestNextSnapshotTimeReadLocked()
+}
+
 func (s *sourceManager) upload(ctx context.Context) serverapi.SourceActionResponse {
 	log(ctx).Infof("upload triggered via API: %v", s.src)
 	s.scheduleSnapshotNow()
@@ -403,7 +413,7 @@ func (s *sourceManager) findClosestNextSnapshotTimeReadLocked() *time.Time {
 		previousSnapshotTime = s.lastAttemptedSnapshotTime
 	}
 
-	t, ok := s.pol.NextSnapshotTime(previousSnapshotTime.ToTime(), clock.Now())
+	t, ok := s.pol.NextSnapshotTimeForSource(previousSnapshotTime.ToTime(), clock.Now(), s.src)
 	if !ok {
 		return nil
 	}
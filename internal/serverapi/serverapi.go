@@ -6,6 +6,7 @@
 	"time"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bgverify"
 	"github.com/kopia/kopia/internal/uitask"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
@@ -106,6 +107,19 @@ type SourceActionResponse struct {
 	Success bool `json:"success"`
 }
 
+// SchedulerStatus describes the state of the server scheduler.
+type SchedulerStatus struct {
+	Paused      bool       `json:"paused"`
+	PausedSince *time.Time `json:"pausedSince,omitempty"`
+	CatchUp     string     `json:"catchUp"`
+}
+
+// BackgroundVerificationStatus describes the state of background verification of repository contents.
+type BackgroundVerificationStatus struct {
+	Enabled bool             `json:"enabled"`
+	Status  *bgverify.Status `json:"status,omitempty"`
+}
+
 // MultipleSourceActionResponse contains per-source responses for all sources targeted by API command.
 type MultipleSourceActionResponse struct {
 	Sources map[string]SourceActionResponse `json:"sources"`
@@ -0,0 +1,114 @@
+package sleepable
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker is similar to time.Ticker but ticks based on the provided wall-clock function, so that it keeps
+// ticking at the right times after the computer wakes up from sleep.
+type Ticker struct {
+	// C receives the current time on each tick.
+	C <-chan time.Time
+
+	c            chan time.Time
+	nowFunc      func() time.Time
+	maxSleepTime time.Duration
+
+	mu sync.Mutex
+	// +checklocks:mu
+	closed chan struct{}
+}
+
+// NewTicker creates a new ticker which ticks every interval of time returned by nowFunc(), starting one
+// interval from now. Ticks missed because the computer was asleep or the receiver was slow are coalesced
+// into a single tick and the following tick is scheduled one interval after it.
+func NewTicker(nowFunc func() time.Time, interval time.Duration) *Ticker {
+	return NewTickerWithMaxSleep(nowFunc, interval, MaxSleepTime)
+}
+
+// NewTickerWithMaxSleep creates a new ticker like NewTicker(), which re-evaluates the time at least every maxSleepTime,
+// MaxSleepTime if non-positive.
+func NewTickerWithMaxSleep(nowFunc func() time.Time, interval, maxSleepTime time.Duration) *Ticker {
+	if interval <= 0 {
+		panic("non-positive interval for sleepable.NewTicker")
+	}
+
+	if maxSleepTime <= 0 {
+		maxSleepTime = MaxSleepTime
+	}
+
+	c := make(chan time.Time, 1)
+
+	t := &Ticker{
+		C:            c,
+		c:            c,
+		nowFunc:      nowFunc,
+		maxSleepTime: maxSleepTime,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.startLocked(interval)
+
+	return t
+}
+
+// Stop stops the ticker, it is safe to call it multiple times. C is not closed.
+func (t *Ticker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopLocked()
+}
+
+// Reset stops the ticker and restarts it with the provided interval, starting one interval from now.
+// A tick already delivered to C but not yet received is not discarded.
+func (t *Ticker) Reset(interval time.Duration) {
+	if interval <= 0 {
+		panic("non-positive interval for sleepable.Ticker.Reset")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopLocked()
+	t.startLocked(interval)
+}
+
+// +checklocks:t.mu
+func (t *Ticker) stopLocked() {
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+}
+
+// +checklocks:t.mu
+func (t *Ticker) startLocked(interval time.Duration) {
+	t.closed = make(chan struct{})
+
+	go t.run(t.closed, interval)
+}
+
+func (t *Ticker) run(closed chan struct{}, interval time.Duration) {
+	next := t.nowFunc().Add(interval)
+
+	for sleepUntil(closed, nil, t.nowFunc, next, t.maxSleepTime, clockJumpDetector{}) == wakeDeadline {
+		now := t.nowFunc()
+
+		select {
+		case t.c <- now:
+		default:
+			// previous tick has not been received yet.
+		}
+
+		next = next.Add(interval)
+		if !next.After(now) {
+			// skip ticks missed while asleep.
+			next = now.Add(interval)
+		}
+	}
+}
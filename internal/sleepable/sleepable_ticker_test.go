@@ -0,0 +1,102 @@
+package sleepable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kopia/kopia/internal/faketime"
+)
+
+func TestTicker(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tk := NewTickerWithMaxSleep(ft.NowFunc(), time.Hour, testMaxSleepTime)
+	defer tk.Stop()
+
+	expectNoTick(t, tk)
+
+	ft.Advance(time.Hour)
+	expectTick(t, tk)
+	expectNoTick(t, tk)
+
+	// simulate the computer waking up from sleep after several intervals, only a single tick is delivered.
+	ft.Advance(5*time.Hour + 30*time.Minute)
+	expectTick(t, tk)
+	expectNoTick(t, tk)
+
+	// next tick is one interval after the catch-up tick.
+	ft.Advance(59 * time.Minute)
+	expectNoTick(t, tk)
+
+	ft.Advance(time.Minute)
+	expectTick(t, tk)
+}
+
+func TestTicker_SlowReceiver(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tk := NewTickerWithMaxSleep(ft.NowFunc(), time.Hour, testMaxSleepTime)
+	defer tk.Stop()
+
+	for range 3 {
+		ft.Advance(time.Hour)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// ticks not received in time are dropped.
+	expectTick(t, tk)
+	expectNoTick(t, tk)
+}
+
+func TestTicker_StopAndReset(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tk := NewTickerWithMaxSleep(ft.NowFunc(), time.Hour, testMaxSleepTime)
+	tk.Stop()
+	tk.Stop()
+
+	ft.Advance(2 * time.Hour)
+	expectNoTick(t, tk)
+
+	tk.Reset(time.Minute)
+	defer tk.Stop()
+
+	expectNoTick(t, tk)
+
+	ft.Advance(time.Minute)
+	expectTick(t, tk)
+
+	ft.Advance(time.Minute)
+	expectTick(t, tk)
+}
+
+func TestTicker_NonPositiveMaxSleep(t *testing.T) {
+	for _, maxSleep := range []time.Duration{0, -time.Second} {
+		tk := NewTickerWithMaxSleep(time.Now, time.Hour, maxSleep)
+		tk.Stop()
+
+		if tk.maxSleepTime != MaxSleepTime {
+			t.Fatalf("unexpected max sleep time %v for %v", tk.maxSleepTime, maxSleep)
+		}
+	}
+}
+
+func expectTick(t *testing.T, tk *Ticker) {
+	t.Helper()
+
+	select {
+	case <-tk.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker did not tick")
+	}
+}
+
+func expectNoTick(t *testing.T, tk *Ticker) {
+	t.Helper()
+
+	select {
+	case <-tk.C:
+		t.Fatal("unexpected tick")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,328 @@
+// Package sleepable implements a timer that is robust against the computer going to sleep.
+package sleepable
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// MaxSleepTime is the default maximum duration of a single sleep, which ensures that the deadline is re-evaluated
+// periodically and timers don't fire late after the computer wakes up from sleep. It is read when timers are
+// created, use NewTimerWithMaxSleep() or Options.MaxSleepTime instead of modifying it.
+//
+//nolint:gochecknoglobals
+var MaxSleepTime = 15 * time.Second
+
+// Timer is similar to time.Timer but fires based on the provided wall-clock function instead of monotonic time,
+// which does not advance while the computer is asleep.
+type Timer struct {
+	// C is closed when the timer fires.
+	C <-chan struct{}
+
+	ctx          context.Context //nolint:containedctx
+	nowFunc      func() time.Time
+	maxSleepTime time.Duration
+	jitter       float64
+	clockJump    clockJumpDetector
+	fn           func()
+
+	mu sync.Mutex
+	// +checklocks:mu
+	closed chan struct{} // closed when the current run of the timer is stopped
+	// +checklocks:mu
+	state timerState // state of the current run of the timer
+	// +checklocks:mu
+	until time.Time // deadline of the current run of the timer, including jitter
+	// +checklocks:mu
+	err error
+}
+
+// Options provides optional parameters of a Timer.
+type Options struct {
+	// Jitter randomizes the deadline by up to ±Jitter times the duration until the deadline, which prevents
+	// timers created at the same time from firing at the same time. It must be between 0 and 1.
+	// The deadline is randomized once, when the timer is created or reset.
+	Jitter float64
+
+	// MaxSleepTime overrides the default maximum duration of a single sleep when non-zero.
+	MaxSleepTime time.Duration
+
+	// OnClockJump is invoked in a separate goroutine when the wall clock is found to have moved backward or
+	// forward by more than ClockJumpThreshold relative to monotonic time between two consecutive reads.
+	// The delta is the difference between the elapsed wall-clock and monotonic time, negative for backward jumps.
+	OnClockJump func(delta time.Duration)
+
+	// ClockJumpThreshold is the minimum forward jump reported to OnClockJump, DefaultClockJumpThreshold if zero.
+	// Any backward movement of the clock is reported.
+	ClockJumpThreshold time.Duration
+}
+
+// DefaultClockJumpThreshold is the default minimum forward jump of the wall clock reported to Options.OnClockJump.
+const DefaultClockJumpThreshold = 10 * time.Second
+
+// Stop stops the timer, it is safe to call it multiple times. It returns true if the call stopped the timer
+// and false if the timer has already fired or been stopped. For timers created with AfterFunc(), true means
+// that the function will not run.
+func (t *Timer) Stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.stopLocked()
+}
+
+// +checklocks:t.mu
+func (t *Timer) stopLocked() bool {
+	wasPending := t.state == timerPending
+	if wasPending {
+		t.state = timerStopped
+	}
+
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+
+	return wasPending
+}
+
+// Fired returns true if the timer has fired, which includes being woken up by cancellation of its context.
+// It returns false while the timer is pending and after it has been stopped before firing.
+func (t *Timer) Fired() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state == timerFired
+}
+
+// Stopped returns true if the timer has been stopped before firing.
+func (t *Timer) Stopped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state == timerStopped
+}
+
+// Reset reschedules the timer to fire at the provided time, whether it has already fired, was stopped or is
+// still pending. Because C is closed when the timer fires, Reset replaces it with a new channel, so the caller
+// must be done receiving from C before calling Reset and must use the new C afterwards, similar to draining
+// the channel before resetting time.Timer.
+func (t *Timer) Reset(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopLocked()
+	t.startLocked(until)
+}
+
+// Deadline returns the time when the timer fires or has fired, as provided when it was created or last reset,
+// adjusted by jitter if requested.
+func (t *Timer) Deadline() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.until
+}
+
+// Remaining returns the time left until the deadline according to nowFunc(), or zero if the deadline has passed.
+func (t *Timer) Remaining() time.Duration {
+	return max(t.Deadline().Sub(t.nowFunc()), 0)
+}
+
+// Err returns the error of the context the timer was created with if C was closed because
+// the context was canceled, and nil otherwise.
+func (t *Timer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.err
+}
+
+// NewTimer creates a new timer which will fire when nowFunc() reaches the provided time.
+func NewTimer(nowFunc func() time.Time, until time.Time) *Timer {
+	return newTimer(context.Background(), nowFunc, until, Options{}, nil)
+}
+
+// NewTimerWithOptions creates a new timer which will fire when nowFunc() reaches the provided time,
+// adjusted according to the provided options.
+func NewTimerWithOptions(nowFunc func() time.Time, until time.Time, opt Options) *Timer {
+	return newTimer(context.Background(), nowFunc, until, opt, nil)
+}
+
+// NewTimerWithMaxSleep creates a new timer which will fire when nowFunc() reaches the provided time,
+// re-evaluating the time at least every maxSleepTime.
+func NewTimerWithMaxSleep(nowFunc func() time.Time, until time.Time, maxSleepTime time.Duration) *Timer {
+	return newTimer(context.Background(), nowFunc, until, Options{MaxSleepTime: maxSleepTime}, nil)
+}
+
+// NewTimerContext creates a new timer which will fire when nowFunc() reaches the provided time
+// or when the provided context is canceled, whichever happens first. Err() can be used to tell them apart.
+func NewTimerContext(ctx context.Context, nowFunc func() time.Time, until time.Time) *Timer {
+	return newTimer(ctx, nowFunc, until, Options{}, nil)
+}
+
+// AfterFunc creates a new timer which will run the provided function in its own goroutine when nowFunc()
+// reaches the provided time, and close C at the same time. Stop() can be used to prevent the function from running.
+func AfterFunc(nowFunc func() time.Time, until time.Time, fn func()) *Timer {
+	return newTimer(context.Background(), nowFunc, until, Options{}, fn)
+}
+
+func newTimer(ctx context.Context, nowFunc func() time.Time, until time.Time, opt Options, fn func()) *Timer {
+	if opt.Jitter < 0 || opt.Jitter > 1 {
+		panic("sleepable timer jitter must be between 0 and 1")
+	}
+
+	maxSleepTime := opt.MaxSleepTime
+	if maxSleepTime <= 0 {
+		maxSleepTime = MaxSleepTime
+	}
+
+	t := &Timer{
+		ctx:          ctx,
+		nowFunc:      nowFunc,
+		maxSleepTime: maxSleepTime,
+		jitter:       opt.Jitter,
+		clockJump:    clockJumpDetector{callback: opt.OnClockJump, threshold: opt.ClockJumpThreshold},
+		fn:           fn,
+	}
+
+	if t.clockJump.threshold <= 0 {
+		t.clockJump.threshold = DefaultClockJumpThreshold
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.startLocked(until)
+
+	return t
+}
+
+// +checklocks:t.mu
+func (t *Timer) startLocked(until time.Time) {
+	fired := make(chan struct{})
+	closed := make(chan struct{})
+
+	if t.jitter > 0 {
+		if d := until.Sub(t.nowFunc()); d > 0 {
+			until = until.Add(time.Duration((2*rand.Float64() - 1) * t.jitter * float64(d))) //nolint:gosec
+		}
+	}
+
+	t.C = fired
+	t.closed = closed
+	t.state = timerPending
+	t.until = until
+	t.err = nil
+
+	go t.run(fired, closed, until)
+}
+
+func (t *Timer) run(fired, closed chan struct{}, until time.Time) {
+	switch sleepUntil(closed, t.ctx.Done(), t.nowFunc, until, t.maxSleepTime, t.clockJump) {
+	case wakeDeadline:
+		t.fire(fired, closed, nil)
+
+	case wakeCanceled:
+		t.fire(fired, closed, t.ctx.Err())
+
+	case wakeStopped:
+	}
+}
+
+type timerState int
+
+const (
+	timerPending timerState = iota
+	timerFired
+	timerStopped
+)
+
+type wakeReason int
+
+const (
+	wakeDeadline wakeReason = iota
+	wakeStopped
+	wakeCanceled
+)
+
+// clockJumpDetector reports changes of the wall clock which don't match elapsed monotonic time.
+type clockJumpDetector struct {
+	callback  func(delta time.Duration)
+	threshold time.Duration
+}
+
+// sleepUntil waits until nowFunc() reaches the provided time, re-evaluating it at least every maxSleepTime,
+// or until one of the provided channels is closed, and returns the reason for waking up.
+func sleepUntil(stopped, canceled <-chan struct{}, nowFunc func() time.Time, until time.Time, maxSleepTime time.Duration, cj clockJumpDetector) wakeReason {
+	var prevWall, prevMono time.Time
+
+	for {
+		select {
+		case <-stopped:
+			return wakeStopped
+
+		case <-canceled:
+			return wakeCanceled
+
+		default:
+		}
+
+		now := nowFunc()
+
+		if cj.callback != nil {
+			// strip monotonic readings, so that wall-clock times are compared.
+			wall, mono := now.Round(0), time.Now()
+
+			if !prevWall.IsZero() {
+				if delta := wall.Sub(prevWall) - mono.Sub(prevMono); wall.Before(prevWall) || delta > cj.threshold {
+					go cj.callback(delta)
+				}
+			}
+
+			prevWall, prevMono = wall, mono
+		}
+
+		if !now.Before(until) {
+			return wakeDeadline
+		}
+
+		nextSleepTime := until.Sub(now)
+		if nextSleepTime > maxSleepTime {
+			nextSleepTime = maxSleepTime
+		}
+
+		select {
+		case <-stopped:
+			return wakeStopped
+
+		case <-canceled:
+			return wakeCanceled
+
+		case <-time.After(nextSleepTime):
+		}
+	}
+}
+
+// fire closes the channel of the current run of the timer unless it has been stopped or reset in the meantime.
+func (t *Timer) fire(fired, closed chan struct{}, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-closed:
+		return
+	default:
+	}
+
+	t.state = timerFired
+	t.err = err
+
+	close(fired)
+
+	if t.fn != nil {
+		go t.fn()
+	}
+}
@@ -0,0 +1,540 @@
+package sleepable
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/faketime"
+)
+
+// testMaxSleepTime makes timers notice changes of fake time quickly.
+const testMaxSleepTime = 10 * time.Millisecond
+
+func TestTimer(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tm := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), testMaxSleepTime)
+	defer tm.Stop()
+
+	select {
+	case <-tm.C:
+		t.Fatal("timer fired too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// simulate the computer waking up from sleep after the deadline.
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+}
+
+func TestTimer_Past(t *testing.T) {
+	tm := NewTimer(clock.Now, clock.Now().Add(-time.Second))
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+
+	// stopping fired timer is a no-op.
+	require.False(t, tm.Stop())
+	require.False(t, tm.Stop())
+}
+
+func TestTimer_Stop(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tm := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), testMaxSleepTime)
+	require.True(t, tm.Stop())
+
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case <-tm.C:
+		t.Fatal("stopped timer fired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.False(t, tm.Stop())
+}
+
+func TestTimerContext_CancelBeforeFire(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tm := NewTimerContext(ctx, clock.Now, clock.Now().Add(time.Hour))
+	defer tm.Stop()
+
+	require.NoError(t, tm.Err())
+
+	cancel()
+
+	// cancellation is honored without waiting for the current sleep, which is longer than the timeout below.
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire after cancellation")
+	}
+
+	require.ErrorIs(t, tm.Err(), context.Canceled)
+}
+
+func TestTimerContext_CancelAfterFire(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tm := NewTimerContext(ctx, clock.Now, clock.Now().Add(-time.Second))
+	defer tm.Stop()
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+
+	cancel()
+
+	require.NoError(t, tm.Err())
+}
+
+func TestTimerContext_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	tm := NewTimerContext(ctx, clock.Now, clock.Now().Add(time.Hour))
+	defer tm.Stop()
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire after context deadline")
+	}
+
+	require.ErrorIs(t, tm.Err(), context.DeadlineExceeded)
+}
+
+func TestTimer_ResetFired(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tm := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(-time.Second), testMaxSleepTime)
+	defer tm.Stop()
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+
+	tm.Reset(ft.NowFunc()().Add(time.Hour))
+
+	select {
+	case <-tm.C:
+		t.Fatal("reset timer fired too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reset timer did not fire")
+	}
+}
+
+func TestTimer_ResetStopped(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tm := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), testMaxSleepTime)
+	tm.Stop()
+
+	tm.Reset(ft.NowFunc()().Add(3 * time.Hour))
+	defer tm.Stop()
+
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case <-tm.C:
+		t.Fatal("timer fired at the original time")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("re-armed timer did not fire")
+	}
+}
+
+func TestTimer_ResetPending(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	tm := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), testMaxSleepTime)
+	defer tm.Stop()
+
+	oldC := tm.C
+
+	// move the deadline to the past.
+	tm.Reset(ft.NowFunc()().Add(-time.Second))
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reset timer did not fire")
+	}
+
+	ft.Advance(2 * time.Hour)
+
+	// the channel of the previous deadline is never closed.
+	select {
+	case <-oldC:
+		t.Fatal("channel of the previous deadline was closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAfterFunc_StopWins(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	var ran atomic.Bool
+
+	tm := AfterFunc(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), func() { ran.Store(true) })
+	require.True(t, tm.Stop())
+
+	ft.Advance(2 * time.Hour)
+	time.Sleep(50 * time.Millisecond)
+
+	require.False(t, ran.Load())
+	require.False(t, tm.Stop())
+}
+
+func TestAfterFunc_DeadlineWins(t *testing.T) {
+	ran := make(chan struct{})
+
+	tm := AfterFunc(clock.Now, clock.Now().Add(-time.Second), func() { close(ran) })
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("function did not run")
+	}
+
+	<-tm.C
+
+	require.False(t, tm.Stop())
+}
+
+func TestAfterFunc_StopRace(t *testing.T) {
+	for range 100 {
+		ran := make(chan struct{})
+
+		tm := AfterFunc(clock.Now, clock.Now().Add(time.Millisecond), func() { close(ran) })
+
+		time.Sleep(time.Millisecond)
+
+		if tm.Stop() {
+			select {
+			case <-ran:
+				t.Fatal("function ran even though Stop() returned true")
+			case <-time.After(5 * time.Millisecond):
+			}
+		} else {
+			select {
+			case <-ran:
+			case <-time.After(5 * time.Second):
+				t.Fatal("function did not run even though Stop() returned false")
+			}
+		}
+	}
+}
+
+func TestTimerWithOptions_Jitter(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+	now := ft.NowFunc()()
+	until := now.Add(time.Hour)
+
+	distinct := map[time.Time]bool{}
+
+	for range 100 {
+		tm := NewTimerWithOptions(ft.NowFunc(), until, Options{Jitter: 0.1, MaxSleepTime: testMaxSleepTime})
+
+		actual := tm.Deadline()
+
+		tm.Stop()
+
+		require.False(t, actual.Before(now.Add(54*time.Minute)), actual.Sub(now))
+		require.False(t, actual.After(now.Add(66*time.Minute)), actual.Sub(now))
+
+		distinct[actual] = true
+	}
+
+	require.Greater(t, len(distinct), 1)
+
+	// the deadline is randomized once and does not change while the timer is sleeping.
+	tm := NewTimerWithOptions(ft.NowFunc(), until, Options{Jitter: 0.5, MaxSleepTime: testMaxSleepTime})
+	defer tm.Stop()
+
+	actual := tm.Deadline()
+
+	ft.Advance(actual.Sub(now) - time.Second)
+
+	select {
+	case <-tm.C:
+		t.Fatal("timer fired before the randomized deadline")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ft.Advance(time.Second)
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire at the randomized deadline")
+	}
+}
+
+func TestTimerWithOptions_NoJitter(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+	until := ft.NowFunc()().Add(time.Hour)
+
+	tm := NewTimerWithOptions(ft.NowFunc(), until, Options{MaxSleepTime: testMaxSleepTime})
+	defer tm.Stop()
+
+	require.Equal(t, until, tm.Deadline())
+
+	ft.Advance(time.Hour - time.Second)
+
+	select {
+	case <-tm.C:
+		t.Fatal("timer fired too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ft.Advance(time.Second)
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+
+	require.Panics(t, func() { NewTimerWithOptions(ft.NowFunc(), until, Options{Jitter: 1.5}) })
+	require.Panics(t, func() { NewTimerWithOptions(ft.NowFunc(), until, Options{Jitter: -0.1}) })
+}
+
+func TestTimer_DifferentMaxSleepTimes(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	fast := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), testMaxSleepTime)
+	defer fast.Stop()
+
+	slow := NewTimerWithMaxSleep(ft.NowFunc(), ft.NowFunc()().Add(time.Hour), time.Hour)
+	defer slow.Stop()
+
+	// give both timers a chance to start sleeping.
+	time.Sleep(50 * time.Millisecond)
+	ft.Advance(2 * time.Hour)
+
+	select {
+	case <-fast.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer with short max sleep time did not fire")
+	}
+
+	// the other timer is still in its first sleep and won't notice the time change for an hour.
+	select {
+	case <-slow.C:
+		t.Fatal("timer with long max sleep time fired too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTimer_DeadlineAndRemaining(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+	until := ft.NowFunc()().Add(time.Hour)
+
+	tm := NewTimerWithMaxSleep(ft.NowFunc(), until, testMaxSleepTime)
+	defer tm.Stop()
+
+	require.Equal(t, until, tm.Deadline())
+	require.LessOrEqual(t, tm.Remaining(), time.Hour)
+	require.Greater(t, tm.Remaining(), 59*time.Minute)
+
+	ft.Advance(30 * time.Minute)
+	require.LessOrEqual(t, tm.Remaining(), 30*time.Minute)
+	require.Greater(t, tm.Remaining(), 29*time.Minute)
+
+	ft.Advance(time.Hour)
+
+	select {
+	case <-tm.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire")
+	}
+
+	require.Equal(t, until, tm.Deadline())
+	require.Zero(t, tm.Remaining())
+
+	// reset changes the deadline, stopping does not.
+	until2 := ft.NowFunc()().Add(time.Hour)
+
+	tm.Reset(until2)
+	require.Equal(t, until2, tm.Deadline())
+
+	tm.Stop()
+	require.Equal(t, until2, tm.Deadline())
+	require.Positive(t, tm.Remaining())
+}
+
+func TestTimer_OnClockJump(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	jumps := make(chan time.Duration, 10)
+
+	tm := NewTimerWithOptions(ft.NowFunc(), ft.NowFunc()().Add(10*time.Hour), Options{
+		MaxSleepTime:       testMaxSleepTime,
+		OnClockJump:        func(delta time.Duration) { jumps <- delta },
+		ClockJumpThreshold: time.Minute,
+	})
+	defer tm.Stop()
+
+	expectNoJump := func() {
+		t.Helper()
+
+		select {
+		case d := <-jumps:
+			t.Fatalf("unexpected clock jump: %v", d)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	expectJump := func() time.Duration {
+		t.Helper()
+
+		select {
+		case d := <-jumps:
+			return d
+		case <-time.After(5 * time.Second):
+			t.Fatal("clock jump not reported")
+			return 0
+		}
+	}
+
+	// steady clock is not reported.
+	expectNoJump()
+
+	ft.Advance(-time.Hour)
+
+	d := expectJump()
+	require.Less(t, d, -59*time.Minute)
+	require.Greater(t, d, -61*time.Minute)
+	expectNoJump()
+
+	// small forward jumps are not reported.
+	ft.Advance(30 * time.Second)
+	expectNoJump()
+
+	ft.Advance(2 * time.Hour)
+
+	d = expectJump()
+	require.Greater(t, d, 119*time.Minute)
+	require.Less(t, d, 121*time.Minute)
+	expectNoJump()
+
+	select {
+	case <-tm.C:
+		t.Fatal("timer fired too early")
+	default:
+	}
+}
+
+func TestTimer_FiredAndStopped(t *testing.T) {
+	tm := NewTimer(clock.Now, clock.Now().Add(-time.Second))
+
+	<-tm.C
+
+	require.True(t, tm.Fired())
+	require.False(t, tm.Stopped())
+
+	// stopping a fired timer does not change its state.
+	require.False(t, tm.Stop())
+	require.True(t, tm.Fired())
+	require.False(t, tm.Stopped())
+
+	tm = NewTimer(clock.Now, clock.Now().Add(time.Hour))
+	require.False(t, tm.Fired())
+	require.False(t, tm.Stopped())
+
+	require.True(t, tm.Stop())
+	require.False(t, tm.Fired())
+	require.True(t, tm.Stopped())
+
+	// reset re-arms a stopped timer.
+	tm.Reset(clock.Now().Add(-time.Second))
+	<-tm.C
+
+	require.True(t, tm.Fired())
+	require.False(t, tm.Stopped())
+}
+
+func TestTimerConcurrentStop(t *testing.T) {
+	const numStoppers = 10
+
+	for range 100 {
+		tm := NewTimer(clock.Now, clock.Now().Add(time.Millisecond))
+
+		var (
+			wg      sync.WaitGroup
+			stopped atomic.Int32
+		)
+
+		for range numStoppers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				time.Sleep(time.Millisecond)
+
+				if tm.Stop() {
+					stopped.Add(1)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		// exactly one outcome is observed: either one of the calls stopped the timer, or it fired.
+		switch stopped.Load() {
+		case 0:
+			<-tm.C
+			require.True(t, tm.Fired())
+			require.False(t, tm.Stopped())
+
+		case 1:
+			require.True(t, tm.Stopped())
+			require.False(t, tm.Fired())
+
+			select {
+			case <-tm.C:
+				t.Fatal("stopped timer fired")
+			default:
+			}
+
+		default:
+			t.Fatalf("timer stopped by %v calls", stopped.Load())
+		}
+	}
+}
@@ -3,6 +3,7 @@
 import (
 	"time"
 
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
@@ -31,6 +32,7 @@ type Options struct {
 	StorageDomain string `json:"storageDomain,omitempty"`
 
 	throttling.Limits
+	splitting.ObjectSizeLimit
 
 	// PointInTime specifies a view of the (versioned) store at that time
 	PointInTime *time.Time `json:"pointInTime,omitempty"`
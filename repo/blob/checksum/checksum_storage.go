@@ -0,0 +1,238 @@
+// Package checksum implements a storage wrapper which protects blobs with a checksum stored with their data.
+package checksum
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"hash"
+	"hash/crc32"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// Supported checksum algorithms.
+const (
+	CRC32C = "CRC32C"
+	SHA256 = "SHA256"
+)
+
+// ExcludedBlobIDPrefix is the prefix of IDs of blobs which are stored without a checksum, which are the format
+// blobs and other repository-wide blobs which must be readable before the algorithm is known.
+const ExcludedBlobIDPrefix = "kopia."
+
+// ErrChecksumMismatch is returned when the data of a blob does not match its checksum.
+var ErrChecksumMismatch = errors.New("blob checksum mismatch")
+
+//nolint:gochecknoglobals
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+//nolint:gochecknoglobals
+var algorithms = map[string]struct {
+	size    int
+	newHash func() hash.Hash
+}{
+	CRC32C: {crc32.Size, func() hash.Hash { return crc32.New(castagnoliTable) }},
+	SHA256: {sha256.Size, sha256.New},
+}
+
+// SupportedAlgorithms returns the names of supported checksum algorithms.
+func SupportedAlgorithms() []string {
+	var result []string
+
+	for k := range algorithms {
+		result = append(result, k)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Options provides options for the checksum wrapper.
+type Options struct {
+	// DisableVerifyOnRead disables verification of blobs when they are read in their entirety,
+	// blobs are then only verified when the context was returned by WithVerification().
+	DisableVerifyOnRead bool
+}
+
+type forceVerificationKey struct{}
+
+// WithVerification returns a context in which blobs read in their entirety are always verified, even if
+// verification on read has been disabled. This is used by explicit verification of blobs.
+func WithVerification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceVerificationKey{}, true)
+}
+
+func verificationForced(ctx context.Context) bool {
+	v, _ := ctx.Value(forceVerificationKey{}).(bool)
+	return v
+}
+
+// checksumStorage appends a checksum of the data to each blob it writes, the checksum is verified and removed
+// when the entire blob is read.
+//
+// Partial reads are never verified, since the checksum covers the entire blob. In particular contents are
+// read from pack blobs using partial reads, so only blobs read in full, such as index blobs, are verified
+// during regular operation and pack blobs must be verified explicitly by reading them in their entirety.
+type checksumStorage struct {
+	blob.Storage
+
+	algorithm    string
+	size         int
+	newHash      func() hash.Hash
+	verifyOnRead bool
+}
+
+func isExcluded(id blob.ID) bool {
+	return strings.HasPrefix(string(id), ExcludedBlobIDPrefix)
+}
+
+func (s *checksumStorage) checksum(data blob.Bytes) ([]byte, error) {
+	h := s.newHash()
+
+	if _, err := data.WriteTo(h); err != nil {
+		return nil, errors.Wrap(err, "error computing checksum")
+	}
+
+	return h.Sum(nil), nil
+}
+
+func (s *checksumStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	if isExcluded(id) {
+		//nolint:wrapcheck
+		return s.Storage.GetBlob(ctx, id, offset, length, output)
+	}
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	if length >= 0 {
+		// include the checksum in the requested range, so that ranges extending past
+		// the end of the data are rejected by the underlying storage.
+		if err := s.Storage.GetBlob(ctx, id, offset, length+int64(s.size), &tmp); err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+
+		output.Reset()
+
+		//nolint:wrapcheck
+		return tmp.AppendSectionTo(output, 0, int(length))
+	}
+
+	if err := s.Storage.GetBlob(ctx, id, 0, -1, &tmp); err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	dataLength := tmp.Length() - s.size
+	if dataLength < 0 {
+		return errors.Wrapf(ErrChecksumMismatch, "blob %v is too short to hold a %v checksum", id, s.algorithm)
+	}
+
+	if !s.verifyOnRead && !verificationForced(ctx) {
+		output.Reset()
+
+		//nolint:wrapcheck
+		return tmp.AppendSectionTo(output, 0, dataLength)
+	}
+
+	b := tmp.Bytes()
+
+	var stored bytes.Buffer
+	if err := b.AppendSectionTo(&stored, dataLength, s.size); err != nil {
+		return errors.Wrap(err, "error reading checksum")
+	}
+
+	h := s.newHash()
+	if err := b.AppendSectionTo(h, 0, dataLength); err != nil {
+		return errors.Wrap(err, "error computing checksum")
+	}
+
+	if !bytes.Equal(h.Sum(nil), stored.Bytes()) {
+		return errors.Wrapf(ErrChecksumMismatch, "blob %v", id)
+	}
+
+	output.Reset()
+
+	//nolint:wrapcheck
+	return tmp.AppendSectionTo(output, 0, dataLength)
+}
+
+func (s *checksumStorage) dataMetadata(bm blob.Metadata) blob.Metadata {
+	if !isExcluded(bm.BlobID) && bm.Length >= int64(s.size) {
+		bm.Length -= int64(s.size)
+	}
+
+	return bm
+}
+
+func (s *checksumStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	bm, err := s.Storage.GetMetadata(ctx, id)
+	if err != nil {
+		//nolint:wrapcheck
+		return blob.Metadata{}, err
+	}
+
+	return s.dataMetadata(bm), nil
+}
+
+func (s *checksumStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	//nolint:wrapcheck
+	return s.Storage.ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+		return callback(s.dataMetadata(bm))
+	})
+}
+
+func (s *checksumStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if isExcluded(id) {
+		//nolint:wrapcheck
+		return s.Storage.PutBlob(ctx, id, data, opts)
+	}
+
+	sum, err := s.checksum(data)
+	if err != nil {
+		return err
+	}
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	if _, err := data.WriteTo(&tmp); err != nil {
+		return errors.Wrap(err, "error copying data")
+	}
+
+	tmp.Append(sum)
+
+	//nolint:wrapcheck
+	return s.Storage.PutBlob(ctx, id, tmp.Bytes(), opts)
+}
+
+// NewWrapper returns a Storage wrapper that stores a checksum computed using the provided algorithm
+// with each blob and verifies it when blobs are read in their entirety.
+func NewWrapper(wrapped blob.Storage, algorithm string) (blob.Storage, error) {
+	return NewWrapperWithOptions(wrapped, algorithm, Options{})
+}
+
+// NewWrapperWithOptions returns a Storage wrapper that stores a checksum computed using the provided algorithm
+// with each blob, using the provided options.
+func NewWrapperWithOptions(wrapped blob.Storage, algorithm string, opt Options) (blob.Storage, error) {
+	alg, ok := algorithms[algorithm]
+	if !ok {
+		return nil, errors.Errorf("unsupported blob checksum algorithm: %q", algorithm)
+	}
+
+	return &checksumStorage{
+		Storage:      wrapped,
+		algorithm:    algorithm,
+		size:         alg.size,
+		newHash:      alg.newHash,
+		verifyOnRead: !opt.DisableVerifyOnRead,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package checksum_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/checksum"
+)
+
+func TestChecksumStorage_VerifyStorage(t *testing.T) {
+	for _, alg := range checksum.SupportedAlgorithms() {
+		t.Run(alg, func(t *testing.T) {
+			ctx := testlogging.Context(t)
+
+			st, err := checksum.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), alg)
+			require.NoError(t, err)
+
+			blobtesting.VerifyStorage(ctx, t, st, blob.PutOptions{})
+		})
+	}
+}
+
+func TestChecksumStorage(t *testing.T) {
+	for alg, checksumSize := range map[string]int{
+		checksum.CRC32C: 4,
+		checksum.SHA256: 32,
+	} {
+		t.Run(alg, func(t *testing.T) {
+			ctx := testlogging.Context(t)
+
+			data := blobtesting.DataMap{}
+			st, err := checksum.NewWrapper(blobtesting.NewMapStorage(data, nil, nil), alg)
+			require.NoError(t, err)
+
+			payload := []byte("some blob data")
+
+			require.NoError(t, st.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{}))
+			require.NoError(t, st.PutBlob(ctx, "kopia.repository", gather.FromSlice(payload), blob.PutOptions{}))
+
+			// the checksum is stored after the data, except for the excluded blobs.
+			require.Len(t, data["blob1"], len(payload)+checksumSize)
+			require.Equal(t, payload, data["blob1"][:len(payload)])
+			require.Equal(t, payload, data["kopia.repository"])
+
+			bm, err := st.GetMetadata(ctx, "blob1")
+			require.NoError(t, err)
+			require.EqualValues(t, len(payload), bm.Length)
+
+			blobs, err := blob.ListAllBlobs(ctx, st, "")
+			require.NoError(t, err)
+			require.Len(t, blobs, 2)
+
+			for _, bm := range blobs {
+				require.EqualValues(t, len(payload), bm.Length, bm.BlobID)
+			}
+
+			var tmp gather.WriteBuffer
+			defer tmp.Close()
+
+			require.NoError(t, st.GetBlob(ctx, "blob1", 0, -1, &tmp))
+			require.Equal(t, payload, tmp.ToByteSlice())
+
+			require.NoError(t, st.GetBlob(ctx, "blob1", 5, 4, &tmp))
+			require.Equal(t, payload[5:9], tmp.ToByteSlice())
+
+			require.ErrorIs(t, st.GetBlob(ctx, "blob1", 5, int64(len(payload)), &tmp), blob.ErrInvalidRange)
+
+			// corrupt the data, which is detected when the blob is read in its entirety.
+			data["blob1"][0] ^= 1
+
+			require.ErrorIs(t, st.GetBlob(ctx, "blob1", 0, -1, &tmp), checksum.ErrChecksumMismatch)
+
+			// corrupt the checksum.
+			data["blob1"][0] ^= 1
+			data["blob1"][len(payload)] ^= 1
+
+			require.ErrorIs(t, st.GetBlob(ctx, "blob1", 0, -1, &tmp), checksum.ErrChecksumMismatch)
+
+			// blobs which are too short to hold a checksum are corrupted.
+			data["blob2"] = []byte{1, 2}
+
+			require.ErrorIs(t, st.GetBlob(ctx, "blob2", 0, -1, &tmp), checksum.ErrChecksumMismatch)
+		})
+	}
+}
+
+func TestChecksumStorage_DisableVerifyOnRead(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st, err := checksum.NewWrapperWithOptions(blobtesting.NewMapStorage(data, nil, nil), checksum.SHA256, checksum.Options{
+		DisableVerifyOnRead: true,
+	})
+	require.NoError(t, err)
+
+	payload := []byte("some blob data")
+
+	require.NoError(t, st.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{}))
+
+	data["blob1"][0] ^= 1
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	// corruption is not detected, but the checksum is still removed.
+	require.NoError(t, st.GetBlob(ctx, "blob1", 0, -1, &tmp))
+	require.Len(t, tmp.ToByteSlice(), len(payload))
+
+	// explicit verification.
+	require.ErrorIs(t, st.GetBlob(checksum.WithVerification(ctx), "blob1", 0, -1, &tmp), checksum.ErrChecksumMismatch)
+
+	// partial reads are never verified.
+	require.NoError(t, st.GetBlob(checksum.WithVerification(ctx), "blob1", 0, 4, &tmp))
+}
+
+func TestChecksumStorage_UnsupportedAlgorithm(t *testing.T) {
+	_, err := checksum.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), "MD5")
+	require.ErrorContains(t, err, "unsupported blob checksum algorithm")
+}
@@ -4,6 +4,7 @@
 	"os"
 
 	"github.com/kopia/kopia/repo/blob/sharded"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
@@ -19,6 +20,7 @@ type Options struct {
 
 	sharded.Options
 	throttling.Limits
+	splitting.ObjectSizeLimit
 
 	osInterfaceOverride osInterface
 }
@@ -2,6 +2,7 @@
 
 import (
 	"github.com/kopia/kopia/repo/blob/sharded"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
@@ -19,4 +20,5 @@ type Options struct {
 
 	sharded.Options
 	throttling.Limits
+	splitting.ObjectSizeLimit
 }
@@ -9,11 +9,17 @@
 	"github.com/kopia/kopia/internal/retry"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/logging"
 )
 
+var log = logging.Module("retrying")
+
 // retryingStorage adds retry loop around all operations of the underlying storage.
 type retryingStorage struct {
 	blob.Storage
+
+	// when not nil, used to refresh credentials once per operation after ErrInvalidCredentials.
+	refresher blob.CredentialsRefresher
 }
 
 func (s retryingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
@@ -21,30 +27,67 @@ func (s retryingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length
 		output.Reset()
 
 		return s.Storage.GetBlob(ctx, id, offset, length, output)
-	}, isRetriable)
+	}, s.isRetriable(ctx))
 }
 
 func (s retryingStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
 	return retry.WithExponentialBackoff(ctx, "GetMetadata("+string(id)+")", func() (blob.Metadata, error) {
 		return s.Storage.GetMetadata(ctx, id)
-	}, isRetriable)
+	}, s.isRetriable(ctx))
 }
 
 func (s retryingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
 	return retry.WithExponentialBackoffNoValue(ctx, "PutBlob("+string(id)+")", func() error {
 		return s.Storage.PutBlob(ctx, id, data, opts)
-	}, isRetriable)
+	}, s.isRetriable(ctx))
 }
 
 func (s retryingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
 	return retry.WithExponentialBackoffNoValue(ctx, "DeleteBlob("+string(id)+")", func() error {
 		return s.Storage.DeleteBlob(ctx, id)
-	}, isRetriable)
+	}, s.isRetriable(ctx))
 }
 
 // NewWrapper returns a Storage wrapper that adds retry loop around all operations of the underlying storage.
+//
+// If the underlying storage implements blob.CredentialsRefresher, operations failing with blob.ErrInvalidCredentials
+// are retried once after refreshing the credentials instead of failing immediately.
 func NewWrapper(wrapped blob.Storage) blob.Storage {
-	return &retryingStorage{Storage: wrapped}
+	r, _ := wrapped.(blob.CredentialsRefresher)
+
+	return &retryingStorage{Storage: wrapped, refresher: r}
+}
+
+// isRetriable returns the retry classifier for a single operation, which refreshes credentials
+// at most once and retries the operation with them.
+func (s retryingStorage) isRetriable(ctx context.Context) retry.IsRetriableFunc {
+	classifier := isRetriableReportingThrottling(ctx)
+	refreshed := false
+
+	return func(err error) bool {
+		if s.refresher == nil || refreshed || !errors.Is(err, blob.ErrInvalidCredentials) {
+			return classifier(err)
+		}
+
+		refreshed = true
+
+		if rerr := s.refresher.RefreshCredentials(ctx); rerr != nil {
+			log(ctx).Errorf("unable to refresh credentials: %v", rerr)
+			return false
+		}
+
+		return true
+	}
+}
+
+// isRetriableReportingThrottling returns a retry classifier which additionally notifies the throttle
+// observer associated with the context about every throttled attempt.
+func isRetriableReportingThrottling(ctx context.Context) retry.IsRetriableFunc {
+	return func(err error) bool {
+		blob.ReportThrottled(ctx, err)
+
+		return isRetriable(err)
+	}
 }
 
 func isRetriable(err error) bool {
@@ -67,6 +110,10 @@ func isRetriable(err error) bool {
 	case errors.Is(err, blob.ErrBlobAlreadyExists):
 		return false
 
+	case errors.Is(err, blob.ErrThrottled):
+		// throttled requests should be retried after backing off
+		return true
+
 	case errors.Is(err, repo.ErrRepositoryUnavailableDueToUpgradeInProgress):
 		// hard-fail when upgrade is in progress
 		return false
@@ -1,6 +1,7 @@
 package retrying_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -59,3 +60,101 @@ func TestRetrying(t *testing.T) {
 
 	fs.VerifyAllFaultsExercised(t)
 }
+
+// tokenStorage rejects all requests unless the current token is valid.
+type tokenStorage struct {
+	blob.Storage
+
+	provider func() string
+	token    string
+	requests int
+}
+
+func (s *tokenStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	s.requests++
+
+	if s.token != "valid" {
+		return blob.ErrInvalidCredentials
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.GetBlob(ctx, id, offset, length, output)
+}
+
+func (s *tokenStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	s.requests++
+
+	if s.token != "valid" {
+		return blob.ErrInvalidCredentials
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.PutBlob(ctx, id, data, opts)
+}
+
+func (s *tokenStorage) RefreshCredentials(ctx context.Context) error {
+	s.token = s.provider()
+
+	return nil
+}
+
+func TestRetrying_RefreshesCredentials(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	tokens := []string{"expired", "valid"}
+	providerCalls := 0
+
+	ts := &tokenStorage{
+		Storage: blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil),
+		provider: func() string {
+			providerCalls++
+			return tokens[min(providerCalls-1, len(tokens)-1)]
+		},
+	}
+
+	// the initial credentials have already expired.
+	require.NoError(t, ts.RefreshCredentials(ctx))
+
+	rs := retrying.NewWrapper(ts)
+
+	require.NoError(t, rs.PutBlob(ctx, "blob1", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	require.Equal(t, 2, providerCalls)
+	require.Equal(t, 2, ts.requests)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, rs.GetBlob(ctx, "blob1", 0, -1, &tmp))
+	require.Equal(t, 2, providerCalls)
+	require.Equal(t, 3, ts.requests)
+
+	// credentials are only refreshed once per request.
+	tokens = []string{"expired"}
+
+	require.NoError(t, ts.RefreshCredentials(ctx))
+	require.ErrorIs(t, rs.GetBlob(ctx, "blob1", 0, -1, &tmp), blob.ErrInvalidCredentials)
+	require.Equal(t, 4, providerCalls)
+	require.Equal(t, 5, ts.requests)
+}
+
+func TestRetrying_InvalidCredentialsWithoutRefresher(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	ms := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+	fs := blobtesting.NewFaultyStorage(ms)
+	fs.AddFault(blobtesting.MethodGetBlob).ErrorInstead(blob.ErrInvalidCredentials)
+
+	rs := retrying.NewWrapper(fs)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.ErrorIs(t, rs.GetBlob(ctx, "blob1", 0, -1, &tmp), blob.ErrInvalidCredentials)
+
+	// not retried, so the next request reaches the underlying storage.
+	require.ErrorIs(t, rs.GetBlob(ctx, "blob1", 0, -1, &tmp), blob.ErrBlobNotFound)
+}
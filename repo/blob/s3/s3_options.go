@@ -1,11 +1,23 @@
 package s3
 
 import (
+	"context"
 	"time"
 
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
+// Credentials are S3 credentials returned by Options.CredentialsProvider.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expiration is the time when the credentials expire, if known.
+	Expiration time.Time
+}
+
 // Options defines options for S3-based storage.
 type Options struct {
 	// BucketName is the name of the bucket where data is stored.
@@ -26,7 +38,13 @@ type Options struct {
 	// Region is an optional region to pass in authorization header.
 	Region string `json:"region,omitempty"`
 
+	// CredentialsProvider, when set, is invoked to obtain credentials when the storage is opened and again
+	// whenever a request is rejected because the credentials have expired, after which the request is retried once.
+	// It takes precedence over the static credentials above and is not persisted in the configuration.
+	CredentialsProvider func(ctx context.Context) (Credentials, error) `json:"-"`
+
 	throttling.Limits
+	splitting.ObjectSizeLimit
 
 	// PointInTime specifies a view of the (versioned) store at that time
 	PointInTime *time.Time `json:"pointInTime,omitempty"`
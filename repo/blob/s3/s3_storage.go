@@ -10,6 +10,7 @@
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -35,6 +36,9 @@ type s3Storage struct {
 	cli *minio.Client
 
 	storageConfig *StorageConfig
+
+	creds       *credentials.Credentials
+	refreshable *refreshableCredentials // nil unless CredentialsProvider is set
 }
 
 func (s *s3Storage) GetBlob(ctx context.Context, b blob.ID, offset, length int64, output blob.OutputBuffer) error {
@@ -105,6 +109,9 @@ func translateError(err error) error {
 
 		case http.StatusRequestedRangeNotSatisfiable:
 			return blob.ErrInvalidRange
+
+		case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+			return blob.MarkThrottled(err)
 		}
 	}
 
@@ -205,7 +212,7 @@ func (s *s3Storage) putBlob(ctx context.Context, b blob.ID, data blob.Bytes, opt
 	}
 
 	if err != nil {
-		return versionMetadata{}, err //nolint:wrapcheck
+		return versionMetadata{}, translateError(err)
 	}
 
 	return versionMetadata{
@@ -337,10 +344,32 @@ func New(ctx context.Context, opt *Options, isCreate bool) (blob.Storage, error)
 		return nil, err
 	}
 
+	// only expose blob.CredentialsRefresher when there is a provider of fresh credentials.
+	if rs, ok := s.(*s3Storage); ok && rs.refreshable != nil {
+		s = &refreshingS3Storage{rs}
+	}
+
 	return retrying.NewWrapper(s), nil
 }
 
 func newStorage(ctx context.Context, opt *Options) (*s3Storage, error) {
+	if opt.CredentialsProvider != nil {
+		rc := &refreshableCredentials{provider: opt.CredentialsProvider}
+
+		if err := rc.refresh(ctx); err != nil {
+			return nil, err
+		}
+
+		s, err := newStorageWithCredentials(ctx, credentials.New(rc), opt)
+		if err != nil {
+			return nil, err
+		}
+
+		s.refreshable = rc
+
+		return s, nil
+	}
+
 	creds := credentials.NewChainCredentials(
 		[]credentials.Provider{
 			&credentials.Static{
@@ -390,6 +419,7 @@ func newStorageWithCredentials(ctx context.Context, creds *credentials.Credentia
 		Options:       *opt,
 		cli:           cli,
 		storageConfig: &StorageConfig{},
+		creds:         creds,
 	}
 
 	var scOutput gather.WriteBuffer
@@ -405,6 +435,73 @@ func newStorageWithCredentials(ctx context.Context, creds *credentials.Credentia
 	return &s, nil
 }
 
+// refreshingS3Storage is an s3Storage with a CredentialsProvider, which implements blob.CredentialsRefresher.
+type refreshingS3Storage struct {
+	*s3Storage
+}
+
+// RefreshCredentials implements blob.CredentialsRefresher.
+func (s *refreshingS3Storage) RefreshCredentials(ctx context.Context) error {
+	if err := s.refreshable.refresh(ctx); err != nil {
+		return err
+	}
+
+	// force the client to retrieve new credentials on next request.
+	s.creds.Expire()
+
+	return nil
+}
+
+// refreshableCredentials is a credentials.Provider returning the credentials most recently
+// obtained from Options.CredentialsProvider.
+type refreshableCredentials struct {
+	provider func(ctx context.Context) (Credentials, error)
+
+	mu sync.Mutex
+	// +checklocks:mu
+	current Credentials
+}
+
+func (p *refreshableCredentials) refresh(ctx context.Context) error {
+	c, err := p.provider(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to obtain credentials")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.current = c
+
+	return nil
+}
+
+func (p *refreshableCredentials) Retrieve() (credentials.Value, error) {
+	// proactively refresh credentials known to have expired, the client does not provide the context here.
+	if p.IsExpired() {
+		if err := p.refresh(context.Background()); err != nil {
+			return credentials.Value{}, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return credentials.Value{
+		AccessKeyID:     p.current.AccessKeyID,
+		SecretAccessKey: p.current.SecretAccessKey,
+		SessionToken:    p.current.SessionToken,
+		SignerType:      credentials.SignatureV4,
+	}, nil
+}
+
+func (p *refreshableCredentials) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return !p.current.Expiration.IsZero() && !clock.Now().Before(p.current.Expiration)
+}
+
 func init() {
 	blob.AddSupportedStorage(s3storageType, Options{}, New)
 }
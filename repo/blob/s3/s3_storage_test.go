@@ -340,6 +340,50 @@ func TestTokenExpiration(t *testing.T) {
 	verifyBlobNotFoundForGetBlob(ctx, t, rst)
 }
 
+func TestRefreshableCredentials(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	var calls int
+
+	rc := &refreshableCredentials{
+		provider: func(ctx context.Context) (Credentials, error) {
+			calls++
+
+			if calls == 1 {
+				return Credentials{AccessKeyID: "expired", Expiration: time.Now().Add(-time.Minute)}, nil
+			}
+
+			return Credentials{AccessKeyID: fmt.Sprintf("valid-%v", calls), SecretAccessKey: "secret"}, nil
+		},
+	}
+
+	require.NoError(t, rc.refresh(ctx))
+
+	creds := credentials.New(rc)
+
+	// expired credentials are refreshed before being used.
+	v, err := creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "valid-2", v.AccessKeyID)
+	require.Equal(t, 2, calls)
+
+	// credentials rejected by the server are refreshed on request.
+	s := &refreshingS3Storage{&s3Storage{creds: creds, refreshable: rc}}
+	require.NoError(t, s.RefreshCredentials(ctx))
+
+	v, err = creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "valid-3", v.AccessKeyID)
+
+	// storage without a credentials provider can't refresh credentials.
+	var st blob.Storage = &s3Storage{}
+
+	_, ok := st.(blob.CredentialsRefresher)
+	require.False(t, ok)
+}
+
 func TestS3StorageMinio(t *testing.T) {
 	t.Parallel()
 	testutil.ProviderTest(t)
@@ -828,3 +872,20 @@ func customCredentialsAndProvider(accessKey, secretKey, roleARN, region string)
 
 	return credentials.New(cp), cp
 }
+
+func TestTranslateErrorThrottled(t *testing.T) {
+	t.Parallel()
+
+	for _, code := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		orig := fmt.Errorf("wrapped: %w", minio.ErrorResponse{StatusCode: code, Code: "SlowDown"})
+		err := translateError(orig)
+
+		require.ErrorIs(t, err, blob.ErrThrottled)
+		require.ErrorIs(t, err, orig)
+
+		var me minio.ErrorResponse
+
+		require.ErrorAs(t, err, &me)
+		require.Equal(t, code, me.StatusCode)
+	}
+}
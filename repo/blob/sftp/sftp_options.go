@@ -5,6 +5,7 @@
 	"path/filepath"
 
 	"github.com/kopia/kopia/repo/blob/sharded"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
@@ -28,6 +29,7 @@ type Options struct {
 
 	sharded.Options
 	throttling.Limits
+	splitting.ObjectSizeLimit
 }
 
 func (sftpo *Options) knownHostsFile() string {
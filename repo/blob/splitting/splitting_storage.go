@@ -0,0 +1,354 @@
+// Package splitting implements a storage wrapper which transparently splits blobs exceeding the maximum
+// object size supported by the underlying storage into multiple objects.
+package splitting
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// partSeparator separates the ID of a split blob from the index of its continuation part.
+const partSeparator = ".part"
+
+// splittingStorage stores blobs longer than maxObjectSize as multiple objects of the underlying storage.
+//
+// The first maxObjectSize bytes are stored under the original blob ID (the head) and the remaining data is
+// stored in continuation parts named '<blobID>.part<N>' (N=1,2,...), all of which except the last one are
+// exactly maxObjectSize long. Continuation parts are written before the head and deleted after it, so the
+// blob is never observable in part and a head shorter than maxObjectSize never has continuation parts.
+// When writing any of the objects fails, continuation parts already written are deleted.
+//
+// Blobs are not expected to be overwritten with shorter contents, which is consistent with how repository
+// blobs are written - only small blobs which are never split are ever overwritten.
+type splittingStorage struct {
+	blob.Storage
+
+	maxObjectSize int64
+}
+
+func partID(id blob.ID, n int) blob.ID {
+	if n == 0 {
+		return id
+	}
+
+	return blob.ID(fmt.Sprintf("%v%v%v", id, partSeparator, n))
+}
+
+// parsePartID returns the ID of the split blob and the index of the part if the provided ID is a continuation part.
+func parsePartID(id blob.ID) (blob.ID, int, bool) {
+	p := strings.LastIndex(string(id), partSeparator)
+	if p < 0 {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(string(id[p+len(partSeparator):]))
+	if err != nil || n <= 0 {
+		return "", 0, false
+	}
+
+	return id[0:p], n, true
+}
+
+// IsPartID returns true if the provided blob ID is a continuation part of a split blob, such objects
+// must never be treated as standalone blobs.
+func IsPartID(id blob.ID) bool {
+	_, _, ok := parsePartID(id)
+	return ok
+}
+
+// PartOf returns the ID of the split blob the provided continuation part belongs to.
+func PartOf(id blob.ID) (blob.ID, bool) {
+	base, _, ok := parsePartID(id)
+	return base, ok
+}
+
+func (s *splittingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	if length >= 0 && offset+length <= s.maxObjectSize {
+		//nolint:wrapcheck
+		return s.Storage.GetBlob(ctx, id, offset, length, output)
+	}
+
+	output.Reset()
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	if length < 0 {
+		return s.getEntireBlob(ctx, id, &tmp, output)
+	}
+
+	if offset < 0 {
+		return errors.Wrapf(blob.ErrInvalidRange, "invalid offset: %v", offset)
+	}
+
+	for pos, end := offset, offset+length; pos < end; {
+		n := int(pos / s.maxObjectSize)
+		partOffset := pos - int64(n)*s.maxObjectSize
+		partLength := min(s.maxObjectSize-partOffset, end-pos)
+
+		tmp.Reset()
+
+		if err := s.Storage.GetBlob(ctx, partID(id, n), partOffset, partLength, &tmp); err != nil {
+			if n > 0 && errors.Is(err, blob.ErrBlobNotFound) {
+				return errors.Wrapf(blob.ErrInvalidRange, "invalid length: %v", length)
+			}
+
+			//nolint:wrapcheck
+			return err
+		}
+
+		if _, err := tmp.Bytes().WriteTo(output); err != nil {
+			return errors.Wrap(err, "error writing data to output")
+		}
+
+		pos += partLength
+	}
+
+	return nil
+}
+
+func (s *splittingStorage) getEntireBlob(ctx context.Context, id blob.ID, tmp *gather.WriteBuffer, output blob.OutputBuffer) error {
+	for n := 0; ; n++ {
+		tmp.Reset()
+
+		if err := s.Storage.GetBlob(ctx, partID(id, n), 0, -1, tmp); err != nil {
+			if n > 0 && errors.Is(err, blob.ErrBlobNotFound) {
+				return nil
+			}
+
+			//nolint:wrapcheck
+			return err
+		}
+
+		if _, err := tmp.Bytes().WriteTo(output); err != nil {
+			return errors.Wrap(err, "error writing data to output")
+		}
+
+		if int64(tmp.Length()) < s.maxObjectSize {
+			return nil
+		}
+	}
+}
+
+// parts returns the metadata of all objects making up the provided blob, starting with the head.
+func (s *splittingStorage) parts(ctx context.Context, id blob.ID) ([]blob.Metadata, error) {
+	bm, err := s.Storage.GetMetadata(ctx, id)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+
+	result := []blob.Metadata{bm}
+
+	for n := 1; bm.Length >= s.maxObjectSize; n++ {
+		bm, err = s.Storage.GetMetadata(ctx, partID(id, n))
+		if errors.Is(err, blob.ErrBlobNotFound) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting metadata of part %v of %v", n, id)
+		}
+
+		result = append(result, bm)
+	}
+
+	return result, nil
+}
+
+func (s *splittingStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	parts, err := s.parts(ctx, id)
+	if err != nil {
+		return blob.Metadata{}, err
+	}
+
+	result := parts[0]
+
+	for _, p := range parts[1:] {
+		result.Length += p.Length
+	}
+
+	return result, nil
+}
+
+func (s *splittingStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	var (
+		// heads which may have continuation parts are reported after all parts have been seen.
+		heads       []blob.Metadata
+		partLengths = map[blob.ID]map[int]int64{}
+	)
+
+	if err := s.Storage.ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+		if base, n, ok := parsePartID(bm.BlobID); ok {
+			if partLengths[base] == nil {
+				partLengths[base] = map[int]int64{}
+			}
+
+			partLengths[base][n] = bm.Length
+
+			return nil
+		}
+
+		if bm.Length >= s.maxObjectSize {
+			heads = append(heads, bm)
+			return nil
+		}
+
+		return callback(bm)
+	}); err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	for _, bm := range heads {
+		pl := partLengths[bm.BlobID]
+
+		for n := 1; ; n++ {
+			l, ok := pl[n]
+			if !ok {
+				break
+			}
+
+			bm.Length += l
+
+			if l < s.maxObjectSize {
+				break
+			}
+		}
+
+		if err := callback(bm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *splittingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	length := int64(data.Length())
+	if length <= s.maxObjectSize {
+		//nolint:wrapcheck
+		return s.Storage.PutBlob(ctx, id, data, opts)
+	}
+
+	if opts.DoNotRecreate {
+		if _, err := s.Storage.GetMetadata(ctx, id); err == nil {
+			return blob.ErrBlobAlreadyExists
+		}
+	}
+
+	r := data.Reader()
+	defer r.Close() //nolint:errcheck
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	partOpts := opts
+	partOpts.DoNotRecreate = false
+	partOpts.GetModTime = nil
+
+	numParts := int((length + s.maxObjectSize - 1) / s.maxObjectSize)
+
+	// write continuation parts first followed by the head, which makes the blob visible.
+	for n := 1; n <= numParts; n++ {
+		pn, po := n, partOpts
+		if n == numParts {
+			pn, po = 0, opts
+		}
+
+		if err := s.putPart(ctx, id, pn, r, &tmp, po); err != nil {
+			// parts written so far would never be observed without the head, unless it has been
+			// created concurrently, in which case they belong to it.
+			if !errors.Is(err, blob.ErrBlobAlreadyExists) {
+				s.deleteContinuationParts(ctx, id, n-1)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *splittingStorage) putPart(ctx context.Context, id blob.ID, n int, r io.ReadSeeker, tmp *gather.WriteBuffer, opts blob.PutOptions) error {
+	if _, err := r.Seek(int64(n)*s.maxObjectSize, io.SeekStart); err != nil {
+		return errors.Wrap(err, "seek error")
+	}
+
+	tmp.Reset()
+
+	if _, err := io.CopyN(tmp, r, s.maxObjectSize); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrap(err, "error reading data")
+	}
+
+	if err := s.Storage.PutBlob(ctx, partID(id, n), tmp.Bytes(), opts); err != nil {
+		return errors.Wrapf(err, "error writing part %v of %v", n, id)
+	}
+
+	return nil
+}
+
+// deleteContinuationParts deletes the first count continuation parts of the provided blob on a best-effort
+// basis, parts which can't be deleted are left for blob garbage collection.
+func (s *splittingStorage) deleteContinuationParts(ctx context.Context, id blob.ID, count int) {
+	for n := 1; n <= count; n++ {
+		s.Storage.DeleteBlob(ctx, partID(id, n)) //nolint:errcheck
+	}
+}
+
+func (s *splittingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	parts, err := s.parts(ctx, id)
+	if errors.Is(err, blob.ErrBlobNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// parts are deleted in order, starting with the head which makes the blob disappear.
+	for _, p := range parts {
+		if err := s.Storage.DeleteBlob(ctx, p.BlobID); err != nil && !errors.Is(err, blob.ErrBlobNotFound) {
+			return errors.Wrapf(err, "error deleting %v", p.BlobID)
+		}
+	}
+
+	return nil
+}
+
+func (s *splittingStorage) ExtendBlobRetention(ctx context.Context, id blob.ID, opts blob.ExtendOptions) error {
+	parts, err := s.parts(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range parts {
+		if err := s.Storage.ExtendBlobRetention(ctx, p.BlobID, opts); err != nil {
+			//nolint:wrapcheck
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewWrapper returns a Storage wrapper that splits blobs longer than maxObjectSize into multiple
+// objects of the underlying storage and reassembles them on reads.
+func NewWrapper(wrapped blob.Storage, maxObjectSize int64) blob.Storage {
+	return &splittingStorage{Storage: wrapped, maxObjectSize: maxObjectSize}
+}
+
+// ObjectSizeLimit is embedded in options of storage providers to specify the maximum size of a single
+// object supported by the backend, larger blobs are split into multiple objects. The limit is only used
+// when a repository is created, after that the one stored in the repository applies to all clients.
+type ObjectSizeLimit struct {
+	MaxObjectSize int64 `json:"maxObjectSize,omitempty"`
+}
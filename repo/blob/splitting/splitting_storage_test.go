@@ -0,0 +1,148 @@
+package splitting_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/splitting"
+)
+
+const testMaxObjectSize = 300
+
+// cappedStorage rejects writes of blobs longer than the maximum object size.
+type cappedStorage struct {
+	blob.Storage
+}
+
+func (s cappedStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if data.Length() > testMaxObjectSize {
+		return errors.Errorf("object %v too large: %v", id, data.Length())
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.PutBlob(ctx, id, data, opts)
+}
+
+func TestSplittingStorage_VerifyStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st := splitting.NewWrapper(cappedStorage{blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)}, testMaxObjectSize)
+
+	blobtesting.VerifyStorage(ctx, t, st, blob.PutOptions{})
+}
+
+func TestSplittingStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := splitting.NewWrapper(cappedStorage{blobtesting.NewMapStorage(data, nil, nil)}, testMaxObjectSize)
+
+	payload := make([]byte, 1000)
+	for i := range payload {
+		payload[i] = byte(i % 251)
+	}
+
+	// the baseline storage rejects the oversized blob.
+	require.Error(t, cappedStorage{blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)}.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{}))
+
+	require.NoError(t, st.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{}))
+	require.NoError(t, st.PutBlob(ctx, "blob2", gather.FromSlice(payload[:testMaxObjectSize]), blob.PutOptions{}))
+	require.NoError(t, st.PutBlob(ctx, "blob3", gather.FromSlice(payload[:10]), blob.PutOptions{}))
+
+	// blob1 is stored as 4 objects, the others as one.
+	require.Len(t, data, 6)
+	require.Len(t, data["blob1"], testMaxObjectSize)
+	require.Len(t, data["blob1.part3"], 100)
+
+	blobtesting.AssertGetBlob(ctx, t, st, "blob1", payload)
+	blobtesting.AssertGetBlob(ctx, t, st, "blob2", payload[:testMaxObjectSize])
+	blobtesting.AssertGetBlob(ctx, t, st, "blob3", payload[:10])
+
+	bm, err := st.GetMetadata(ctx, "blob1")
+	require.NoError(t, err)
+	require.Equal(t, int64(len(payload)), bm.Length)
+
+	bm, err = st.GetMetadata(ctx, "blob2")
+	require.NoError(t, err)
+	require.Equal(t, int64(testMaxObjectSize), bm.Length)
+
+	all, err := blob.ListAllBlobs(ctx, st, "")
+	require.NoError(t, err)
+
+	lengths := map[blob.ID]int64{}
+	for _, bm := range all {
+		lengths[bm.BlobID] = bm.Length
+	}
+
+	require.Equal(t, map[blob.ID]int64{"blob1": 1000, "blob2": testMaxObjectSize, "blob3": 10}, lengths)
+
+	// ranged reads spanning multiple parts.
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	for _, r := range []struct{ offset, length int64 }{
+		{0, 300}, {250, 100}, {299, 2}, {100, 800}, {600, 400}, {999, 1}, {1000, 0},
+	} {
+		require.NoError(t, st.GetBlob(ctx, "blob1", r.offset, r.length, &tmp), r)
+		require.True(t, bytes.Equal(payload[r.offset:r.offset+r.length], tmp.ToByteSlice()), r)
+	}
+
+	require.ErrorIs(t, st.GetBlob(ctx, "blob1", 900, 101, &tmp), blob.ErrInvalidRange)
+	require.ErrorIs(t, st.GetBlob(ctx, "blob2", 200, 101, &tmp), blob.ErrInvalidRange)
+	require.ErrorIs(t, st.GetBlob(ctx, "no-such-blob", 200, 500, &tmp), blob.ErrBlobNotFound)
+
+	require.ErrorIs(t, st.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{DoNotRecreate: true}), blob.ErrBlobAlreadyExists)
+
+	// deleting removes all parts.
+	require.NoError(t, st.DeleteBlob(ctx, "blob1"))
+	require.Len(t, data, 2)
+
+	blobtesting.AssertGetBlobNotFound(ctx, t, st, "blob1")
+}
+
+// failingPutStorage fails writes of the provided blob.
+type failingPutStorage struct {
+	blob.Storage
+
+	failID  blob.ID
+	failErr error
+}
+
+func (s failingPutStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if id == s.failID {
+		return s.failErr
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.PutBlob(ctx, id, data, opts)
+}
+
+func TestSplittingStorage_FailedPutDeletesParts(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	payload := bytes.Repeat([]byte{1, 2, 3}, 400)
+	errFailed := errors.New("put failed")
+
+	for _, failID := range []blob.ID{"blob1.part1", "blob1.part2", "blob1"} {
+		data := blobtesting.DataMap{}
+		st := splitting.NewWrapper(failingPutStorage{blobtesting.NewMapStorage(data, nil, nil), failID, errFailed}, testMaxObjectSize)
+
+		require.ErrorIs(t, st.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{}), errFailed, failID)
+		require.Empty(t, data, failID)
+	}
+
+	// parts are kept when the head has been created concurrently, since they belong to it.
+	data := blobtesting.DataMap{}
+	st := splitting.NewWrapper(failingPutStorage{blobtesting.NewMapStorage(data, nil, nil), "blob1", blob.ErrBlobAlreadyExists}, testMaxObjectSize)
+
+	require.ErrorIs(t, st.PutBlob(ctx, "blob1", gather.FromSlice(payload), blob.PutOptions{}), blob.ErrBlobAlreadyExists)
+	require.Len(t, data, 3)
+}
@@ -30,6 +30,12 @@
 // authenticating with a storage provider has expired.
 var ErrInvalidCredentials = errors.Errorf(InvalidCredentialsErrStr)
 
+// CredentialsRefresher is implemented by storage providers which can obtain fresh credentials,
+// such as temporary tokens, after a request fails with ErrInvalidCredentials.
+type CredentialsRefresher interface {
+	RefreshCredentials(ctx context.Context) error
+}
+
 // ErrBlobAlreadyExists is returned when attempting to put a blob that already exists.
 var ErrBlobAlreadyExists = errors.New("blob already exists")
 
@@ -89,6 +95,7 @@ type Reader interface {
 
 	// ListBlobs invokes the provided callback for each blob in the storage.
 	// Iteration continues until the callback returns an error or until all matching blobs have been reported.
+	// The order of blobs is unspecified and differs between storage providers.
 	ListBlobs(ctx context.Context, blobIDPrefix ID, cb func(bm Metadata) error) error
 
 	// ConnectionInfo returns JSON-serializable data structure containing information required to
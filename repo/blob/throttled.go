@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrThrottled is returned (possibly wrapped) when the storage provider asks the client to slow down,
+// for example by responding with HTTP 503 (Service Unavailable) or 429 (Too Many Requests).
+var ErrThrottled = errors.New("request throttled by storage provider")
+
+type throttleObserverKey struct{}
+
+// WithThrottleObserver returns a context that invokes the provided function for every attempt of a storage
+// operation using that context that was throttled by the provider, including attempts that were later
+// retried successfully.
+func WithThrottleObserver(ctx context.Context, observer func(err error)) context.Context {
+	return context.WithValue(ctx, throttleObserverKey{}, observer)
+}
+
+// ReportThrottled notifies the throttle observer associated with the context if the provided error
+// indicates that the operation was throttled.
+func ReportThrottled(ctx context.Context, err error) {
+	if !errors.Is(err, ErrThrottled) {
+		return
+	}
+
+	if observer, ok := ctx.Value(throttleObserverKey{}).(func(err error)); ok {
+		observer(err)
+	}
+}
+
+// throttledError marks a provider error as throttled while preserving it in the error chain.
+type throttledError struct {
+	err error
+}
+
+func (e throttledError) Error() string {
+	return ErrThrottled.Error() + ": " + e.err.Error()
+}
+
+func (e throttledError) Unwrap() error {
+	return e.err
+}
+
+func (e throttledError) Is(target error) bool {
+	return target == ErrThrottled //nolint:errorlint
+}
+
+// MarkThrottled returns an error that wraps the provided provider error and also matches ErrThrottled.
+func MarkThrottled(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return throttledError{err}
+}
@@ -0,0 +1,149 @@
+package throttling
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/metrics"
+)
+
+// rampingSemaphore limits concurrency similarly to semaphore, but the effective limit starts low
+// and grows linearly to the configured maximum over the ramp-up window (similar to TCP slow-start).
+// When the storage provider throttles an operation, the effective limit is halved and ramp-up
+// starts again from the reduced value.
+type rampingSemaphore struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// +checklocks:mu
+	maxLimit int // 0 == unlimited
+	// +checklocks:mu
+	rampFrom float64
+	// +checklocks:mu
+	rampStart time.Time
+	// +checklocks:mu
+	rampWindow time.Duration // 0 == no ramp-up
+	// +checklocks:mu
+	inUse int
+
+	timeNow func() time.Time
+
+	effectiveLimit *metrics.Distribution[int64]
+	throttled      *metrics.Counter
+}
+
+// +checklocks:s.mu
+func (s *rampingSemaphore) effectiveLimitLocked(now time.Time) int {
+	if s.maxLimit <= 0 || s.rampWindow <= 0 {
+		return s.maxLimit
+	}
+
+	elapsed := now.Sub(s.rampStart)
+	if elapsed >= s.rampWindow {
+		return s.maxLimit
+	}
+
+	v := s.rampFrom + (float64(s.maxLimit)-s.rampFrom)*float64(elapsed)/float64(s.rampWindow)
+
+	return max(1, min(s.maxLimit, int(v)))
+}
+
+// EffectiveLimit returns the current concurrency limit or 0 if unlimited.
+func (s *rampingSemaphore) EffectiveLimit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.effectiveLimitLocked(s.timeNow())
+}
+
+func (s *rampingSemaphore) Acquire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		limit := s.effectiveLimitLocked(s.timeNow())
+		if limit <= 0 || s.inUse < limit {
+			s.inUse++
+			s.effectiveLimit.Observe(int64(limit))
+
+			return
+		}
+
+		s.cond.Wait()
+	}
+}
+
+func (s *rampingSemaphore) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inUse > 0 {
+		s.inUse--
+	}
+
+	// effective limit may have grown since the last release, wake up all waiters.
+	s.cond.Broadcast()
+}
+
+// Backoff halves the effective limit and restarts ramp-up from the reduced value.
+func (s *rampingSemaphore) Backoff() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttled.Add(1)
+
+	if s.maxLimit <= 0 || s.rampWindow <= 0 {
+		return
+	}
+
+	now := s.timeNow()
+
+	s.rampFrom = float64(max(1, s.effectiveLimitLocked(now)/2)) //nolint:mnd
+	s.rampStart = now
+}
+
+// SetLimit sets the maximum concurrency, which will be reached after ramping up from the provided
+// start value over the provided window. Ramp-up is disabled when start is zero or not less than limit.
+func (s *rampingSemaphore) SetLimit(limit, start int, window time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit < 0 || start < 0 || window < 0 {
+		return errors.Errorf("invalid limit")
+	}
+
+	s.maxLimit = limit
+	s.rampWindow = 0
+
+	if limit > 0 && start > 0 && start < limit {
+		s.rampFrom = float64(start)
+		s.rampStart = s.timeNow()
+		s.rampWindow = window
+	}
+
+	s.cond.Broadcast()
+
+	return nil
+}
+
+func newRampingSemaphore(mr *metrics.Registry, name string) *rampingSemaphore {
+	s := &rampingSemaphore{
+		timeNow: clock.Now,
+		effectiveLimit: mr.SizeDistribution(
+			"blob_"+name+"_effective_concurrency",
+			"Effective concurrency limit observed when starting an operation",
+			metrics.ConcurrencyThresholds,
+			nil),
+		throttled: mr.CounterInt64(
+			"blob_"+name+"_throttled",
+			"Number of operations throttled by the storage provider",
+			nil),
+	}
+
+	s.cond = sync.NewCond(&s.mu)
+
+	return s
+}
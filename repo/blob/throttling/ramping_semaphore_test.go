@@ -0,0 +1,90 @@
+package throttling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/faketime"
+)
+
+func TestRampingSemaphore(t *testing.T) {
+	ft := faketime.NewTimeAdvance(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	s := newRampingSemaphore(nil, "test")
+	s.timeNow = ft.NowFunc()
+
+	// default is unlimited
+	require.Equal(t, 0, s.EffectiveLimit())
+	s.Acquire()
+	s.Release()
+
+	require.Error(t, s.SetLimit(-1, 0, 0))
+	require.Error(t, s.SetLimit(10, -1, 0))
+
+	// no ramp-up
+	require.NoError(t, s.SetLimit(10, 0, 10*time.Second))
+	require.Equal(t, 10, s.EffectiveLimit())
+
+	// backing off without ramp-up has no effect
+	s.Backoff()
+	require.Equal(t, 10, s.EffectiveLimit())
+
+	require.NoError(t, s.SetLimit(10, 2, 10*time.Second))
+	require.Equal(t, 2, s.EffectiveLimit())
+
+	ft.Advance(5 * time.Second)
+	require.Equal(t, 6, s.EffectiveLimit())
+
+	ft.Advance(5 * time.Second)
+	require.Equal(t, 10, s.EffectiveLimit())
+
+	ft.Advance(time.Hour)
+	require.Equal(t, 10, s.EffectiveLimit())
+
+	// throttling halves the limit and restarts the ramp-up from there.
+	s.Backoff()
+	require.Equal(t, 5, s.EffectiveLimit())
+
+	s.Backoff()
+	require.Equal(t, 2, s.EffectiveLimit())
+
+	s.Backoff()
+	s.Backoff()
+	require.Equal(t, 1, s.EffectiveLimit())
+
+	ft.Advance(5 * time.Second)
+	require.Equal(t, 5, s.EffectiveLimit())
+
+	ft.Advance(5 * time.Second)
+	require.Equal(t, 10, s.EffectiveLimit())
+}
+
+func TestRampingSemaphore_BlocksAboveEffectiveLimit(t *testing.T) {
+	ft := faketime.NewTimeAdvance(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	s := newRampingSemaphore(nil, "test")
+	s.timeNow = ft.NowFunc()
+
+	require.NoError(t, s.SetLimit(10, 1, 10*time.Second))
+
+	s.Acquire()
+
+	acquired := make(chan struct{})
+
+	go func() {
+		s.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired above the effective limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.Release()
+	<-acquired
+	s.Release()
+}
@@ -6,8 +6,14 @@
 	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/metrics"
 )
 
+// defaultConcurrentWritesRampUpWindow is the time it takes to ramp up concurrent writes to the limit
+// when ramp-up is enabled without explicitly specifying the window.
+const defaultConcurrentWritesRampUpWindow = 30 * time.Second
+
 // SettableThrottler exposes methods to set throttling limits.
 type SettableThrottler interface {
 	Throttler
@@ -32,7 +38,7 @@ type tokenBucketBasedThrottler struct {
 	download *tokenBucket
 
 	concurrentReads  *semaphore
-	concurrentWrites *semaphore
+	concurrentWrites *rampingSemaphore
 
 	window time.Duration // +checklocksignore
 
@@ -62,6 +68,20 @@ func (t *tokenBucketBasedThrottler) AfterOperation(ctx context.Context, op strin
 	}
 }
 
+// OnThrottled implements ThrottleObserver, throttled writes cause the effective write concurrency to back off.
+func (t *tokenBucketBasedThrottler) OnThrottled(ctx context.Context, op string) {
+	switch op {
+	case operationPutBlob, operationDeleteBlob:
+		t.concurrentWrites.Backoff()
+	}
+}
+
+// EffectiveConcurrentWrites returns the current limit of concurrent writes, which may be lower than
+// the configured limit during ramp-up or after backing off, or 0 if unlimited.
+func (t *tokenBucketBasedThrottler) EffectiveConcurrentWrites() int {
+	return t.concurrentWrites.EffectiveLimit()
+}
+
 func (t *tokenBucketBasedThrottler) BeforeDownload(ctx context.Context, numBytes int64) {
 	t.download.Take(ctx, float64(numBytes))
 }
@@ -127,7 +147,12 @@ func (t *tokenBucketBasedThrottler) setLimits(limits Limits) error {
 		return errors.Wrap(err, "ConcurrentReads")
 	}
 
-	if err := t.concurrentWrites.SetLimit(limits.ConcurrentWrites); err != nil {
+	rampUpWindow := time.Duration(limits.ConcurrentWritesRampUpSeconds * float64(time.Second))
+	if rampUpWindow == 0 {
+		rampUpWindow = defaultConcurrentWritesRampUpWindow
+	}
+
+	if err := t.concurrentWrites.SetLimit(limits.ConcurrentWrites, limits.ConcurrentWritesRampUpStart, rampUpWindow); err != nil {
 		return errors.Wrap(err, "ConcurrentWrites")
 	}
 
@@ -147,12 +172,19 @@ type Limits struct {
 	DownloadBytesPerSecond float64 `json:"maxDownloadSpeedBytesPerSecond,omitempty"`
 	ConcurrentReads        int     `json:"concurrentReads,omitempty"`
 	ConcurrentWrites       int     `json:"concurrentWrites,omitempty"`
+
+	// when set, concurrent writes start at this value and gradually ramp up to ConcurrentWrites.
+	ConcurrentWritesRampUpStart   int     `json:"concurrentWritesRampUpStart,omitempty"`
+	ConcurrentWritesRampUpSeconds float64 `json:"concurrentWritesRampUpSeconds,omitempty"`
 }
 
-var _ Throttler = (*tokenBucketBasedThrottler)(nil)
+var (
+	_ Throttler        = (*tokenBucketBasedThrottler)(nil)
+	_ ThrottleObserver = (*tokenBucketBasedThrottler)(nil)
+)
 
-// NewThrottler returns a Throttler with provided limits.
-func NewThrottler(limits Limits, window time.Duration, initialFillRatio float64) (SettableThrottler, error) {
+// NewThrottler returns a Throttler with provided limits, reporting effective write concurrency to the provided registry.
+func NewThrottler(limits Limits, window time.Duration, initialFillRatio float64, mr *metrics.Registry) (SettableThrottler, error) {
 	t := &tokenBucketBasedThrottler{
 		readOps:          newTokenBucket("read-ops", initialFillRatio*limits.ReadsPerSecond*window.Seconds(), 0, window),
 		writeOps:         newTokenBucket("write-ops", initialFillRatio*limits.WritesPerSecond*window.Seconds(), 0, window),
@@ -160,7 +192,7 @@ func NewThrottler(limits Limits, window time.Duration, initialFillRatio float64)
 		upload:           newTokenBucket("upload-bytes", initialFillRatio*limits.UploadBytesPerSecond*window.Seconds(), 0, window),
 		download:         newTokenBucket("download-bytes", initialFillRatio*limits.DownloadBytesPerSecond*window.Seconds(), 0, window),
 		concurrentReads:  newSemaphore(),
-		concurrentWrites: newSemaphore(),
+		concurrentWrites: newRampingSemaphore(mr, "write"),
 		window:           window,
 	}
 
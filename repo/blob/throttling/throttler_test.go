@@ -26,7 +26,7 @@ func TestThrottler(t *testing.T) {
 	const window = time.Second
 
 	ctx := context.Background()
-	th, err := NewThrottler(limits, window, 0.0 /* start empty */)
+	th, err := NewThrottler(limits, window, 0.0 /* start empty */, nil)
 	require.NoError(t, err)
 	require.Equal(t, limits, th.Limits())
 
@@ -38,7 +38,7 @@ func TestThrottler(t *testing.T) {
 		atomic.AddInt64(total, numBytes)
 	})
 
-	th, err = NewThrottler(limits, window, 0.0 /* start empty */)
+	th, err = NewThrottler(limits, window, 0.0 /* start empty */, nil)
 	require.NoError(t, err)
 	testRateLimiting(t, "UploadBytesPerSecond", limits.UploadBytesPerSecond, func(total *int64) {
 		numBytes := rand.Int63n(1500)
@@ -46,21 +46,21 @@ func TestThrottler(t *testing.T) {
 		atomic.AddInt64(total, numBytes)
 	})
 
-	th, err = NewThrottler(limits, window, 0.0 /* start empty */)
+	th, err = NewThrottler(limits, window, 0.0 /* start empty */, nil)
 	require.NoError(t, err)
 	testRateLimiting(t, "ReadsPerSecond", limits.ReadsPerSecond, func(total *int64) {
 		th.BeforeOperation(ctx, "GetBlob")
 		atomic.AddInt64(total, 1)
 	})
 
-	th, err = NewThrottler(limits, window, 0.0 /* start empty */)
+	th, err = NewThrottler(limits, window, 0.0 /* start empty */, nil)
 	require.NoError(t, err)
 	testRateLimiting(t, "WritesPerSecond", limits.WritesPerSecond, func(total *int64) {
 		th.BeforeOperation(ctx, "PutBlob")
 		atomic.AddInt64(total, 1)
 	})
 
-	th, err = NewThrottler(limits, window, 0.0 /* start empty */)
+	th, err = NewThrottler(limits, window, 0.0 /* start empty */, nil)
 	require.NoError(t, err)
 	testRateLimiting(t, "ListsPerSecond", limits.ListsPerSecond, func(total *int64) {
 		th.BeforeOperation(ctx, "ListBlobs")
@@ -78,7 +78,7 @@ func TestThrottlerLargeWindow(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	th, err := NewThrottler(limits, time.Minute, 1.0 /* start full */)
+	th, err := NewThrottler(limits, time.Minute, 1.0 /* start full */, nil)
 	require.NoError(t, err)
 
 	// make sure we can consume 60x worth the quota without
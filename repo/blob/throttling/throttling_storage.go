@@ -4,6 +4,9 @@
 
 import (
 	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo/blob"
 )
@@ -40,6 +43,12 @@ type Throttler interface {
 	ReturnUnusedDownloadBytes(ctx context.Context, numBytes int64)
 }
 
+// ThrottleObserver is optionally implemented by Throttler to be notified when the storage provider
+// throttles an operation, which allows it to back off.
+type ThrottleObserver interface {
+	OnThrottled(ctx context.Context, op string)
+}
+
 // throttlingStorage.
 type throttlingStorage struct {
 	blob.Storage
@@ -95,14 +104,22 @@ func (s *throttlingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.B
 
 	s.throttler.BeforeUpload(ctx, int64(data.Length()))
 
-	return s.Storage.PutBlob(ctx, id, data, opts) //nolint:wrapcheck
+	ctx, done := s.observeThrottling(ctx, operationPutBlob)
+	err := s.Storage.PutBlob(ctx, id, data, opts)
+	done(err)
+
+	return err //nolint:wrapcheck
 }
 
 func (s *throttlingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
 	s.throttler.BeforeOperation(ctx, operationDeleteBlob)
 	defer s.throttler.AfterOperation(ctx, operationDeleteBlob)
 
-	return s.Storage.DeleteBlob(ctx, id) //nolint:wrapcheck
+	ctx, done := s.observeThrottling(ctx, operationDeleteBlob)
+	err := s.Storage.DeleteBlob(ctx, id)
+	done(err)
+
+	return err //nolint:wrapcheck
 }
 
 func (s *throttlingStorage) ExtendBlobRetention(ctx context.Context, id blob.ID, opts blob.ExtendOptions) error {
@@ -112,6 +129,29 @@ func (s *throttlingStorage) ExtendBlobRetention(ctx context.Context, id blob.ID,
 	return s.Storage.ExtendBlobRetention(ctx, id, opts) //nolint:wrapcheck
 }
 
+// observeThrottling returns a context which notifies the throttler about throttled attempts of the operation
+// (typically reported by the retry loop) and a function to be invoked with the final result, which reports
+// throttling errors that were not observed through the context.
+func (s *throttlingStorage) observeThrottling(ctx context.Context, op string) (context.Context, func(err error)) {
+	o, ok := s.throttler.(ThrottleObserver)
+	if !ok {
+		return ctx, func(error) {}
+	}
+
+	var observed atomic.Bool
+
+	octx := blob.WithThrottleObserver(ctx, func(_ error) {
+		observed.Store(true)
+		o.OnThrottled(ctx, op)
+	})
+
+	return octx, func(err error) {
+		if !observed.Load() && errors.Is(err, blob.ErrThrottled) {
+			o.OnThrottled(ctx, op)
+		}
+	}
+}
+
 // NewWrapper returns a Storage wrapper that adds retry loop around all operations of the underlying storage.
 func NewWrapper(wrapped blob.Storage, throttler Throttler) blob.Storage {
 	return &throttlingStorage{wrapped, throttler}
@@ -6,14 +6,20 @@
 	"fmt"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/fault"
 	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/internal/testlogging"
 	"github.com/kopia/kopia/repo/blob"
 	bloblogging "github.com/kopia/kopia/repo/blob/logging"
+	"github.com/kopia/kopia/repo/blob/retrying"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
@@ -148,3 +154,50 @@ func TestThrottling(t *testing.T) {
 		"AfterOperation(ListBlobs)",
 	}, m.activity)
 }
+
+func TestThrottlingBacksOffOnThrottledWrites(t *testing.T) {
+	ctx := testlogging.Context(t)
+	mr := metrics.NewRegistry()
+
+	th, err := throttling.NewThrottler(throttling.Limits{
+		ConcurrentWrites:            16,
+		ConcurrentWritesRampUpStart: 8,
+	}, time.Second, 1.0, mr)
+	require.NoError(t, err)
+
+	effectiveConcurrency := th.(interface{ EffectiveConcurrentWrites() int }).EffectiveConcurrentWrites
+
+	require.Equal(t, 8, effectiveConcurrency())
+
+	fs := blobtesting.NewFaultyStorage(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil))
+	err503 := errors.Wrap(blob.ErrThrottled, "503 Service Unavailable")
+
+	// first attempts are throttled and successfully retried.
+	fs.AddFaults(blobtesting.MethodPutBlob,
+		fault.New().ErrorInstead(err503),
+		fault.New().ErrorInstead(err503))
+
+	wrapped := throttling.NewWrapper(retrying.NewWrapper(fs), th)
+
+	require.NoError(t, wrapped.PutBlob(ctx, "blob1", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	require.Equal(t, 2, effectiveConcurrency())
+
+	// non-retriable throttling error reported directly to the throttling wrapper.
+	direct := throttling.NewWrapper(fs, th)
+
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(err503)
+	require.ErrorIs(t, direct.PutBlob(ctx, "blob2", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}), blob.ErrThrottled)
+	require.Equal(t, 1, effectiveConcurrency())
+
+	// other errors don't cause back-off.
+	fs.AddFault(blobtesting.MethodPutBlob).ErrorInstead(blob.ErrBlobAlreadyExists)
+	require.ErrorIs(t, direct.PutBlob(ctx, "blob3", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}), blob.ErrBlobAlreadyExists)
+	require.Equal(t, 1, effectiveConcurrency())
+
+	fs.VerifyAllFaultsExercised(t)
+
+	snap := mr.Snapshot(false)
+	require.Equal(t, int64(3), snap.Counters["blob_write_throttled"])
+	require.Equal(t, int64(8), snap.SizeDistributions["blob_write_effective_concurrency"].Max)
+	require.Equal(t, int64(1), snap.SizeDistributions["blob_write_effective_concurrency"].Min)
+}
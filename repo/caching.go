@@ -75,6 +75,8 @@ func setupCachingOptionsWithDefaults(ctx context.Context, configPath string, lc
 	lc.Caching.MinContentSweepAge = opt.MinContentSweepAge
 	lc.Caching.MinMetadataSweepAge = opt.MinMetadataSweepAge
 	lc.Caching.MinIndexSweepAge = opt.MinIndexSweepAge
+	lc.Caching.ContentCacheTTL = opt.ContentCacheTTL
+	lc.Caching.MetadataCacheTTL = opt.MetadataCacheTTL
 
 	log(ctx).Debugf("Creating cache directory '%v' with max size %v", lc.Caching.CacheDirectory, lc.Caching.ContentCacheSizeBytes)
 
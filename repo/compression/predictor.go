@@ -0,0 +1,77 @@
+package compression
+
+import (
+	"io"
+	"math"
+)
+
+// Predictor is invoked with a sample of data before it is compressed and returns false if the data
+// is predicted to be incompressible, in which case compression is not attempted.
+type Predictor func(sample []byte) bool
+
+// AlwaysCompress is a Predictor which always attempts compression.
+func AlwaysCompress([]byte) bool {
+	return true
+}
+
+// DefaultEntropyThreshold is the entropy in bits per byte above which EntropyPredictor
+// considers the data incompressible.
+const DefaultEntropyThreshold = 7.5
+
+// EntropyPredictor returns a Predictor which estimates the Shannon entropy of the distribution of bytes in the
+// sample and predicts data whose entropy exceeds the provided number of bits per byte to be incompressible.
+// This detects data which is already compressed or encrypted, but not data whose redundancy spans
+// longer sequences of bytes, which compressors can exploit regardless of the distribution of bytes.
+func EntropyPredictor(maxBitsPerByte float64) Predictor {
+	return func(sample []byte) bool {
+		return len(sample) == 0 || Entropy(sample) <= maxBitsPerByte
+	}
+}
+
+// Entropy returns the Shannon entropy of the distribution of bytes in the provided data in bits per byte.
+func Entropy(data []byte) float64 {
+	var counts [256]int
+
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var (
+		result float64
+		n      = float64(len(data))
+	)
+
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+
+		p := float64(c) / n
+		result -= p * math.Log2(p)
+	}
+
+	return result
+}
+
+const predictorSampleChunks = 4
+
+// Sample fills the provided buffer with a sample of the data of the provided length, made of
+// chunks taken at evenly spaced offsets, and returns the filled part of the buffer.
+func Sample(buf []byte, data io.ReaderAt, length int) []byte {
+	if length <= len(buf) {
+		n, _ := data.ReadAt(buf[:length], 0)
+
+		return buf[:n]
+	}
+
+	chunkSize := len(buf) / predictorSampleChunks
+	stride := (length - chunkSize) / (predictorSampleChunks - 1)
+	result := buf[:0]
+
+	for i := range predictorSampleChunks {
+		n, _ := data.ReadAt(buf[len(result):len(result)+chunkSize], int64(i*stride))
+		result = buf[:len(result)+n]
+	}
+
+	return result
+}
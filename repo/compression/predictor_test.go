@@ -0,0 +1,105 @@
+package compression
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntropy(t *testing.T) {
+	require.InDelta(t, 0.0, Entropy(bytes.Repeat([]byte{7}, 1000)), 0.001)
+	require.InDelta(t, 2.0, Entropy(bytes.Repeat([]byte{1, 2, 3, 4}, 1000)), 0.001)
+
+	random := make([]byte, 100000)
+	rand.Read(random)
+
+	require.Greater(t, Entropy(random), 7.9)
+}
+
+func TestEntropyPredictor(t *testing.T) {
+	p := EntropyPredictor(DefaultEntropyThreshold)
+
+	random := make([]byte, 4096)
+	rand.Read(random)
+
+	require.False(t, p(random))
+	require.True(t, p(bytes.Repeat([]byte("some text "), 400)))
+	require.True(t, p(nil))
+	require.True(t, AlwaysCompress(random))
+}
+
+func TestSample(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i / 100)
+	}
+
+	var buf [100]byte
+
+	// short data is sampled in its entirety.
+	require.Equal(t, data[0:50], Sample(buf[:], bytes.NewReader(data[0:50]), 50))
+
+	// longer data is sampled in evenly spaced chunks, including the beginning and the end.
+	s := Sample(buf[:], bytes.NewReader(data), len(data))
+	require.Len(t, s, 100)
+	require.Equal(t, data[0:25], s[0:25])
+	require.Equal(t, data[975:1000], s[75:100])
+	require.Equal(t, data[325:350], s[25:50])
+}
+
+// BenchmarkPredictor compares compressing mixed data, of which half is incompressible, with and
+// without the entropy predictor.
+func BenchmarkPredictor(b *testing.B) {
+	const blockSize = 1 << 20
+
+	var blocks [][]byte
+
+	for i := range 8 {
+		blk := make([]byte, blockSize)
+
+		if i%2 == 0 {
+			rand.Read(blk)
+		} else {
+			for j := range blk {
+				blk[j] = "the quick brown fox jumps over the lazy dog "[j%44]
+			}
+		}
+
+		blocks = append(blocks, blk)
+	}
+
+	comp := ByName["zstd"]
+
+	for _, tc := range []struct {
+		name      string
+		predictor Predictor
+	}{
+		{"always", AlwaysCompress},
+		{"entropy", EntropyPredictor(DefaultEntropyThreshold)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			var (
+				out    bytes.Buffer
+				sample [4096]byte
+			)
+
+			b.SetBytes(int64(len(blocks) * blockSize))
+
+			for range b.N {
+				for _, blk := range blocks {
+					if !tc.predictor(Sample(sample[:], bytes.NewReader(blk), len(blk))) {
+						continue
+					}
+
+					out.Reset()
+
+					if err := comp.Compress(&out, bytes.NewReader(blk)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
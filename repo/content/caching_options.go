@@ -19,16 +19,20 @@ func (s DurationSeconds) DurationOrDefault(def time.Duration) time.Duration {
 
 // CachingOptions specifies configuration of local cache.
 type CachingOptions struct {
-	CacheDirectory              string          `json:"cacheDirectory,omitempty"`
-	ContentCacheSizeBytes       int64           `json:"maxCacheSize,omitempty"`
-	ContentCacheSizeLimitBytes  int64           `json:"contentCacheSizeLimitBytes,omitempty"`
-	MetadataCacheSizeBytes      int64           `json:"maxMetadataCacheSize,omitempty"`
-	MetadataCacheSizeLimitBytes int64           `json:"metadataCacheSizeLimitBytes,omitempty"`
-	MaxListCacheDuration        DurationSeconds `json:"maxListCacheDuration,omitempty"`
-	MinMetadataSweepAge         DurationSeconds `json:"minMetadataSweepAge,omitempty"`
-	MinContentSweepAge          DurationSeconds `json:"minContentSweepAge,omitempty"`
-	MinIndexSweepAge            DurationSeconds `json:"minIndexSweepAge,omitempty"`
-	HMACSecret                  []byte          `json:"-"`
+	CacheDirectory               string          `json:"cacheDirectory,omitempty"`
+	ContentCacheSizeBytes        int64           `json:"maxCacheSize,omitempty"`
+	ContentCacheSizeLimitBytes   int64           `json:"contentCacheSizeLimitBytes,omitempty"`
+	MetadataCacheSizeBytes       int64           `json:"maxMetadataCacheSize,omitempty"`
+	MetadataCacheSizeLimitBytes  int64           `json:"metadataCacheSizeLimitBytes,omitempty"`
+	MaxListCacheDuration         DurationSeconds `json:"maxListCacheDuration,omitempty"`
+	MinMetadataSweepAge          DurationSeconds `json:"minMetadataSweepAge,omitempty"`
+	MinContentSweepAge           DurationSeconds `json:"minContentSweepAge,omitempty"`
+	MinIndexSweepAge             DurationSeconds `json:"minIndexSweepAge,omitempty"`
+	ContentCacheTTL              DurationSeconds `json:"contentCacheTTL,omitempty"`
+	MetadataCacheTTL             DurationSeconds `json:"metadataCacheTTL,omitempty"`
+	ContentMemoryCacheSizeBytes  int64           `json:"contentMemoryCacheSizeBytes,omitempty"`
+	MetadataMemoryCacheSizeBytes int64           `json:"metadataMemoryCacheSizeBytes,omitempty"`
+	HMACSecret                   []byte          `json:"-"`
 }
 
 // EffectiveMetadataCacheSizeBytes returns the effective metadata cache size.
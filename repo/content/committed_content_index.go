@@ -38,6 +38,12 @@ type committedContentIndex struct {
 	inUse map[blob.ID]index.Index
 	// +checklocks:mu
 	merged index.Merged
+	// +checklocks:mu
+	scope func(ID) bool // when set, only contents for which scope returns true are visible
+	// +checklocks:mu
+	outOfScope map[blob.ID]bool // index blobs without any contents in scope, which are not opened again
+	// +checklocks:mu
+	imported *importedIndex // when set, replaces the index blobs it was exported from
 
 	v1PerContentOverhead func() int
 	formatProvider       format.Provider
@@ -48,6 +54,28 @@ type committedContentIndex struct {
 	log logging.Logger
 }
 
+// importedIndex is a merged index loaded from an export, which is used instead of
+// the index blobs it was exported from as long as all of them remain active.
+// The index is held in memory, so it does not need to be closed.
+type importedIndex struct {
+	indexBlobs map[blob.ID]bool
+	ndx        index.Index
+	merged     bool // whether the index has been merged into committedContentIndex.merged
+}
+
+// coveredBy returns true if all index blobs the import was exported from are in the provided list.
+func (ii *importedIndex) coveredBy(indexFiles []blob.ID) bool {
+	n := 0
+
+	for _, e := range indexFiles {
+		if ii.indexBlobs[e] {
+			n++
+		}
+	}
+
+	return n == len(ii.indexBlobs)
+}
+
 type committedContentIndexCache interface {
 	hasIndexBlobID(ctx context.Context, indexBlob blob.ID) (bool, error)
 	addContentToCache(ctx context.Context, indexBlob blob.ID, data gather.Bytes) error
@@ -59,6 +87,14 @@ func (c *committedContentIndex) revision() int64 {
 	return c.rev.Load()
 }
 
+// indexBlobCount returns the number of index blobs currently in use.
+func (c *committedContentIndex) indexBlobCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.inUse)
+}
+
 func (c *committedContentIndex) getContent(contentID ID) (Info, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -120,6 +156,8 @@ func (c *committedContentIndex) addIndexBlob(ctx context.Context, indexBlobID bl
 		return errors.Wrapf(err, "unable to open pack index %q", indexBlobID)
 	}
 
+	ndx = index.WithBloomFilter(ndx)
+
 	c.inUse[indexBlobID] = ndx
 	c.merged = append(c.merged, ndx)
 
@@ -144,14 +182,38 @@ func (c *committedContentIndex) listContents(r IDRange, cb func(i Info) error) e
 
 // +checklocks:c.mu
 func (c *committedContentIndex) indexFilesChanged(indexFiles []blob.ID) bool {
-	if len(indexFiles) != len(c.inUse) {
+	cnt := len(c.inUse)
+	if c.imported != nil {
+		cnt += len(c.imported.indexBlobs)
+	}
+
+	for _, ndx := range indexFiles {
+		if c.outOfScope[ndx] {
+			cnt++
+		}
+	}
+
+	if len(indexFiles) != cnt {
 		return true
 	}
 
 	for _, ndx := range indexFiles {
-		if c.inUse[ndx] == nil {
+		if c.inUse[ndx] == nil && (c.imported == nil || !c.imported.indexBlobs[ndx]) && !c.outOfScope[ndx] {
+			return true
+		}
+	}
+
+	// index blobs opened before the import need to be replaced by it.
+	if c.imported != nil {
+		if !c.imported.merged {
 			return true
 		}
+
+		for ndx := range c.inUse {
+			if c.imported.indexBlobs[ndx] {
+				return true
+			}
+		}
 	}
 
 	return false
@@ -165,7 +227,29 @@ func (c *committedContentIndex) merge(ctx context.Context, indexFiles []blob.ID)
 		newUsedMap  = map[blob.ID]index.Index{}
 	)
 
+	mp, err := c.formatProvider.GetMutableParameters(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting mutable parameters")
+	}
+
+	ordering, err := mp.GetIndexOrdering()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting index ordering")
+	}
+
+	if c.imported != nil {
+		newMerged = append(newMerged, c.imported.ndx)
+	}
+
 	for _, e := range indexFiles {
+		if c.imported != nil && c.imported.indexBlobs[e] {
+			continue
+		}
+
+		if c.outOfScope[e] {
+			continue
+		}
+
 		ndx := c.inUse[e]
 		if ndx == nil {
 			var err error
@@ -181,18 +265,59 @@ func (c *committedContentIndex) merge(ctx context.Context, indexFiles []blob.ID)
 				return nil, nil, errors.Wrapf(err, "unable to open pack index %q", e)
 			}
 
+			if c.scope != nil {
+				inScope, serr := hasContentsInScope(ndx, c.scope)
+				if serr != nil || !inScope {
+					ndx.Close() //nolint:errcheck
+				}
+
+				if serr != nil {
+					newlyOpened.Close() //nolint:errcheck
+
+					return nil, nil, errors.Wrapf(serr, "unable to read pack index %q", e)
+				}
+
+				if !inScope {
+					// index blobs never change, so this one is not needed for as long as the scope is in effect.
+					c.outOfScope[e] = true
+					continue
+				}
+			}
+
+			ndx = index.WithBloomFilter(ndx)
 			newlyOpened = append(newlyOpened, ndx)
+
+			// indexes using different orderings can't be merged.
+			if o := ndx.Ordering(); o.ID != ordering.ID {
+				newlyOpened.Close() //nolint:errcheck
+
+				return nil, nil, errors.Errorf("pack index %q uses ordering %q, but repository uses %q", e, o.Name, ordering.Name)
+			}
 		}
 
 		newMerged = append(newMerged, ndx)
 		newUsedMap[e] = ndx
 	}
 
-	mergedAndCombined, err := c.combineSmallIndexes(ctx, newMerged)
-	if err != nil {
-		newlyOpened.Close() //nolint:errcheck
+	var mergedAndCombined index.Merged
+
+	if c.scope != nil {
+		// the scoped index replaces all others, so small indexes don't need to be combined first.
+		scoped, serr := c.buildInMemoryIndex(ctx, newMerged, c.scope)
+		if serr != nil {
+			newlyOpened.Close() //nolint:errcheck
+
+			return nil, nil, errors.Wrap(serr, "unable to build scoped index")
+		}
 
-		return nil, nil, errors.Wrap(err, "unable to combine small indexes")
+		mergedAndCombined = index.Merged{scoped}
+	} else {
+		mergedAndCombined, err = c.combineSmallIndexes(ctx, newMerged)
+		if err != nil {
+			newlyOpened.Close() //nolint:errcheck
+
+			return nil, nil, errors.Wrap(err, "unable to combine small indexes")
+		}
 	}
 
 	c.log.Debugw("combined index segments", "original", len(newMerged), "merged", len(mergedAndCombined))
@@ -208,6 +333,15 @@ func (c *committedContentIndex) use(ctx context.Context, indexFiles []blob.ID, i
 
 	c.deletionWatermark = ignoreDeletedBefore
 
+	// the imported index is discarded once any of the index blobs it was exported from
+	// is no longer active, which happens after compaction or other maintenance.
+	oldImported := c.imported
+	if oldImported != nil && !oldImported.coveredBy(indexFiles) {
+		c.log.Infof("imported index is no longer up-to-date, using index blobs")
+
+		c.imported = nil
+	}
+
 	if !c.indexFilesChanged(indexFiles) {
 		return nil
 	}
@@ -216,11 +350,30 @@ func (c *committedContentIndex) use(ctx context.Context, indexFiles []blob.ID, i
 
 	mergedAndCombined, newInUse, err := c.merge(ctx, indexFiles)
 	if err != nil {
+		c.imported = oldImported
+
 		return err
 	}
 
+	c.replaceMerged(mergedAndCombined, newInUse)
+
+	if err := c.cache.expireUnused(ctx, c.cachedIndexBlobs(indexFiles)); err != nil {
+		c.log.Errorf("unable to expire unused index files: %v", err)
+	}
+
+	return nil
+}
+
+// replaceMerged makes the provided merged index current and closes indexes that are no longer in use.
+//
+// +checklocks:c.mu
+func (c *committedContentIndex) replaceMerged(merged index.Merged, newInUse map[blob.ID]index.Index) {
 	c.rev.Add(1)
-	c.merged = mergedAndCombined
+	c.merged = merged
+
+	if c.imported != nil {
+		c.imported.merged = true
+	}
 
 	oldInUse := c.inUse
 	c.inUse = newInUse
@@ -233,12 +386,68 @@ func (c *committedContentIndex) use(ctx context.Context, indexFiles []blob.ID, i
 			}
 		}
 	}
+}
 
-	if err := c.cache.expireUnused(ctx, indexFiles); err != nil {
-		c.log.Errorf("unable to expire unused index files: %v", err)
+// cachedIndexBlobs returns the subset of provided index blobs which are not covered by the imported index.
+//
+// +checklocks:c.mu
+func (c *committedContentIndex) cachedIndexBlobs(indexFiles []blob.ID) []blob.ID {
+	if c.imported == nil {
+		return indexFiles
 	}
 
-	return nil
+	var result []blob.ID
+
+	for _, e := range indexFiles {
+		if !c.imported.indexBlobs[e] {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// importIndex makes the provided index replace the index blobs it was exported from, the change becomes
+// effective with the next call to use().
+func (c *committedContentIndex) importIndex(ii *importedIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.imported = ii
+}
+
+// hasImportedIndex returns true if an imported index is currently in use.
+func (c *committedContentIndex) hasImportedIndex() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.imported != nil
+}
+
+// exportState returns the IDs of all index blobs currently in use and their merged index, regardless of scope.
+func (c *committedContentIndex) exportState() ([]blob.ID, index.Merged) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		ids []blob.ID
+		m   index.Merged
+	)
+
+	if c.imported != nil {
+		for id := range c.imported.indexBlobs {
+			ids = append(ids, id)
+		}
+
+		m = append(m, c.imported.ndx)
+	}
+
+	for id, ndx := range c.inUse {
+		ids = append(ids, id)
+		m = append(m, ndx)
+	}
+
+	return ids, m
 }
 
 func (c *committedContentIndex) combineSmallIndexes(ctx context.Context, m index.Merged) (index.Merged, error) {
@@ -256,11 +465,25 @@ func (c *committedContentIndex) combineSmallIndexes(ctx context.Context, m index
 		return m, nil
 	}
 
+	combined, err := c.buildInMemoryIndex(ctx, toMerge, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(toKeep, index.WithBloomFilter(combined)), nil
+}
+
+// buildInMemoryIndex builds a single in-memory index containing entries from all provided indexes,
+// optionally limited to contents for which keep() returns true.
+func (c *committedContentIndex) buildInMemoryIndex(ctx context.Context, m index.Merged, keep func(ID) bool) (index.Index, error) {
 	b := index.Builder{}
 
-	for _, ndx := range toMerge {
+	for _, ndx := range m {
 		if err := ndx.Iterate(index.AllIDs, func(i index.Info) error {
-			b.Add(i)
+			if keep == nil || keep(i.ContentID) {
+				b.Add(i)
+			}
+
 			return nil
 		}); err != nil {
 			return nil, errors.Wrap(err, "unable to iterate index entries")
@@ -272,9 +495,14 @@ func (c *committedContentIndex) combineSmallIndexes(ctx context.Context, m index
 		return nil, errors.Wrap(mperr, "error getting mutable parameters")
 	}
 
+	ordering, err := mp.GetIndexOrdering()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting index ordering")
+	}
+
 	var buf bytes.Buffer
 
-	if err := b.Build(&buf, mp.IndexVersion); err != nil {
+	if err := b.BuildWithOrdering(&buf, mp.IndexVersion, ordering); err != nil {
 		return nil, errors.Wrap(err, "error building combined in-memory index")
 	}
 
@@ -283,9 +511,77 @@ func (c *committedContentIndex) combineSmallIndexes(ctx context.Context, m index
 		return nil, errors.Wrap(err, "error opening combined in-memory index")
 	}
 
-	return append(toKeep, combined), nil
+	return combined, nil
+}
+
+// restrictTo limits the set of visible committed contents to those for which keep() returns true.
+// Index blobs without any contents in scope are closed and are not opened again when indexes are
+// refreshed, so only index blobs added since the restriction need to be loaded to find out whether
+// they are needed. The restriction is not applied to index blobs added locally via addIndexBlob(),
+// so that contents written by this process remain visible.
+func (c *committedContentIndex) restrictTo(ctx context.Context, keep func(ID) bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var indexFiles []blob.ID
+
+	outOfScope := map[blob.ID]bool{}
+
+	for id, ndx := range c.inUse {
+		inScope, err := hasContentsInScope(ndx, keep)
+		if err != nil {
+			return errors.Wrapf(err, "unable to read pack index %q", id)
+		}
+
+		if !inScope {
+			outOfScope[id] = true
+		}
+
+		indexFiles = append(indexFiles, id)
+	}
+
+	// index blobs excluded by the previous scope may be needed by the new one.
+	for id := range c.outOfScope {
+		indexFiles = append(indexFiles, id)
+	}
+
+	oldScope, oldOutOfScope := c.scope, c.outOfScope
+	c.scope, c.outOfScope = keep, outOfScope
+
+	merged, newInUse, err := c.merge(ctx, indexFiles)
+	if err != nil {
+		c.scope, c.outOfScope = oldScope, oldOutOfScope
+
+		return err
+	}
+
+	c.replaceMerged(merged, newInUse)
+
+	return nil
+}
+
+// hasContentsInScope returns true if the provided index has any entries for which keep() returns true.
+func hasContentsInScope(ndx index.Index, keep func(ID) bool) (bool, error) {
+	found := false
+
+	err := ndx.Iterate(index.AllIDs, func(i index.Info) error {
+		if keep(i.ContentID) {
+			found = true
+			return errStopIteration
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return false, errors.Wrap(err, "error iterating index entries")
+	}
+
+	return found, nil
 }
 
+// errStopIteration is returned from Iterate() callbacks to stop once the result is known.
+var errStopIteration = errors.New("stop iteration")
+
 func (c *committedContentIndex) close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -350,6 +646,12 @@ func (c *committedContentIndex) fetchIndexBlobs(ctx context.Context, isPermissiv
 
 // missingIndexBlobs returns a closed channel filled with blob IDs that are not in committedContents cache.
 func (c *committedContentIndex) missingIndexBlobs(ctx context.Context, blobs []blob.ID) (<-chan blob.ID, error) {
+	c.mu.RLock()
+	if c.imported != nil && c.imported.coveredBy(blobs) {
+		blobs = c.cachedIndexBlobs(blobs)
+	}
+	c.mu.RUnlock()
+
 	ch := make(chan blob.ID, len(blobs))
 	defer close(ch)
 
@@ -94,6 +94,10 @@ type SharedManager struct {
 	// +checklocks:indexesLock
 	refreshIndexesAfter time.Time
 
+	// whether committed indexes have been loaded at least once, only false with lazy index loading.
+	// +checklocks:indexesLock
+	indexesLoaded bool
+
 	format format.Provider
 
 	checkInvariantsOnUnlock bool
@@ -101,6 +105,10 @@ type SharedManager struct {
 	maxPreambleLength       int
 	paddingUnit             int
 
+	verifyAfterWrite *verifyAfterWriteSampler
+
+	compressionPredictor compression.Predictor
+
 	// logger where logs should be written
 	log logging.Logger
 
@@ -247,6 +255,7 @@ func (sm *SharedManager) loadPackIndexesLocked(ctx context.Context) error {
 			}
 
 			sm.refreshIndexesAfter = sm.timeNow().Add(indexRefreshFrequency)
+			sm.indexesLoaded = true
 
 			return nil
 		}
@@ -425,6 +434,7 @@ func contentCacheSweepSettings(caching *CachingOptions) cache.SweepSettings {
 		MaxSizeBytes: caching.ContentCacheSizeBytes,
 		LimitBytes:   caching.ContentCacheSizeLimitBytes,
 		MinSweepAge:  caching.MinContentSweepAge.DurationOrDefault(DefaultDataCacheSweepAge),
+		TTL:          caching.ContentCacheTTL.DurationOrDefault(0),
 	}
 }
 
@@ -433,6 +443,7 @@ func metadataCacheSizeSweepSettings(caching *CachingOptions) cache.SweepSettings
 		MaxSizeBytes: caching.EffectiveMetadataCacheSizeBytes(),
 		LimitBytes:   caching.MetadataCacheSizeLimitBytes,
 		MinSweepAge:  caching.MinMetadataSweepAge.DurationOrDefault(DefaultMetadataCacheSweepAge),
+		TTL:          caching.MetadataCacheTTL.DurationOrDefault(0),
 	}
 }
 
@@ -445,21 +456,23 @@ func indexBlobCacheSweepSettings(caching *CachingOptions) cache.SweepSettings {
 
 func (sm *SharedManager) setupCachesAndIndexManagers(ctx context.Context, caching *CachingOptions, mr *metrics.Registry) error {
 	dataCache, err := cache.NewContentCache(ctx, sm.st, cache.Options{
-		BaseCacheDirectory: caching.CacheDirectory,
-		CacheSubDir:        "contents",
-		HMACSecret:         caching.HMACSecret,
-		Sweep:              contentCacheSweepSettings(caching),
+		BaseCacheDirectory:   caching.CacheDirectory,
+		CacheSubDir:          "contents",
+		HMACSecret:           caching.HMACSecret,
+		Sweep:                contentCacheSweepSettings(caching),
+		MemoryCacheSizeBytes: caching.ContentMemoryCacheSizeBytes,
 	}, mr)
 	if err != nil {
 		return errors.Wrap(err, "unable to initialize content cache")
 	}
 
 	metadataCache, err := cache.NewContentCache(ctx, sm.st, cache.Options{
-		BaseCacheDirectory: caching.CacheDirectory,
-		CacheSubDir:        "metadata",
-		HMACSecret:         caching.HMACSecret,
-		FetchFullBlobs:     true,
-		Sweep:              metadataCacheSizeSweepSettings(caching),
+		BaseCacheDirectory:   caching.CacheDirectory,
+		CacheSubDir:          "metadata",
+		HMACSecret:           caching.HMACSecret,
+		FetchFullBlobs:       true,
+		Sweep:                metadataCacheSizeSweepSettings(caching),
+		MemoryCacheSizeBytes: caching.MetadataMemoryCacheSizeBytes,
 	}, mr)
 	if err != nil {
 		return errors.Wrap(err, "unable to initialize metadata cache")
@@ -592,6 +605,34 @@ func (sm *SharedManager) AlsoLogToContentLog(ctx context.Context) context.Contex
 	})
 }
 
+// ensureIndexesLoaded loads committed indexes if that has been deferred by lazy index loading.
+func (sm *SharedManager) ensureIndexesLoaded(ctx context.Context) error {
+	sm.indexesLock.RLock()
+	loaded := sm.indexesLoaded
+	sm.indexesLock.RUnlock()
+
+	if loaded {
+		return nil
+	}
+
+	sm.indexesLock.Lock()
+	defer sm.indexesLock.Unlock()
+
+	if sm.indexesLoaded {
+		return nil
+	}
+
+	timer := timetrack.StartTimer()
+
+	if err := sm.loadPackIndexesLocked(ctx); err != nil {
+		return errors.Wrap(err, "error loading indexes")
+	}
+
+	sm.log.Debugf("Lazily loaded indexes in %v", timer.Elapsed())
+
+	return nil
+}
+
 func (sm *SharedManager) shouldRefreshIndexes() bool {
 	sm.indexesLock.RLock()
 	defer sm.indexesLock.RUnlock()
@@ -624,6 +665,8 @@ func NewSharedManager(ctx context.Context, st blob.Storage, prov format.Provider
 		checkInvariantsOnUnlock: os.Getenv("KOPIA_VERIFY_INVARIANTS") != "",
 		repoLogManager:          repoLogManager,
 		contextLogger:           logging.Module(FormatLogModule)(ctx),
+		verifyAfterWrite:        newVerifyAfterWriteSampler(opts.VerifyAfterWriteRate, opts.VerifyAfterWriteSeed),
+		compressionPredictor:    opts.CompressionPredictor,
 
 		metricsStruct: initMetricsStruct(mr),
 	}
@@ -640,12 +683,30 @@ func NewSharedManager(ctx context.Context, st blob.Storage, prov format.Provider
 		return nil, errors.Wrap(err, "error setting up read manager caches")
 	}
 
+	if len(opts.ImportedIndex) > 0 {
+		ii, err := sm.parseIndexExport(opts.ImportedIndex)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading imported index")
+		}
+
+		sm.committedContents.importIndex(ii)
+	}
+
 	sm.indexesLock.Lock()
 	defer sm.indexesLock.Unlock()
 
+	if opts.IndexLoading == IndexLoadingLazy {
+		// indexes will be loaded by ensureIndexesLoaded() on first access.
+		return sm, nil
+	}
+
 	if err := sm.loadPackIndexesLocked(ctx); err != nil {
 		return nil, errors.Wrap(err, "error loading indexes")
 	}
 
+	if len(opts.ImportedIndex) > 0 && !sm.committedContents.hasImportedIndex() {
+		sm.log.Infof("imported index is stale, loaded index blobs instead")
+	}
+
 	return sm, nil
 }
@@ -0,0 +1,230 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/crypto"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content/index"
+)
+
+// Index export format:
+//
+//	magic           [8]byte  "KOPIAIDX"
+//	version         uint16   (big endian)
+//	header length   uint32   (big endian)
+//	header          JSON-encoded indexExportHeader
+//	index length    uint64   (big endian)
+//	index           merged content index in the repository index format
+//	checksum        [32]byte HMAC-SHA256 of all preceding bytes keyed by a key derived from the master key
+const (
+	indexExportMagic   = "KOPIAIDX"
+	indexExportVersion = 1
+
+	indexExportChecksumSize = sha256.Size
+	indexExportKeyLength    = 32
+
+	maxIndexExportHeaderLength = 64 << 20
+)
+
+//nolint:gochecknoglobals
+var indexExportKeyPurpose = []byte("index-export")
+
+// ErrIndexExportStale is returned by ImportIndex when the imported index was made obsolete by changes
+// to the repository since it was exported.
+var ErrIndexExportStale = errors.New("index export is stale")
+
+type indexExportHeader struct {
+	CreatedAt    time.Time `json:"createdAt"`
+	IndexVersion int       `json:"indexVersion"`
+	EntryCount   int       `json:"entryCount"`
+
+	// IndexBlobs are the index blobs the export was produced from, sorted.
+	IndexBlobs []blob.ID `json:"indexBlobs"`
+}
+
+func (sm *SharedManager) indexExportChecksum(data []byte) []byte {
+	var key []byte
+
+	if mk := sm.format.GetMasterKey(); len(mk) > 0 {
+		key = crypto.DeriveKeyFromMasterKey(mk, nil, indexExportKeyPurpose, indexExportKeyLength)
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write(data) //nolint:errcheck
+
+	return h.Sum(nil)
+}
+
+// ExportIndex writes a single compact binary dump of the merged committed content index to the provided writer,
+// which can be loaded by ImportIndex() into a manager for the same repository, typically a read-only replica,
+// instead of fetching and merging all index blobs.
+//
+// The export records the set of index blobs it was produced from. The imported index is used as long as all
+// of them remain active, index blobs written afterwards are fetched and merged on top of it as usual. Once any
+// of the source index blobs is no longer active (after index compaction performed by maintenance), the imported
+// index is discarded and all index blobs are loaded instead, so a fresh export should be produced after maintenance.
+func (sm *SharedManager) ExportIndex(ctx context.Context, w io.Writer) error {
+	if err := sm.ensureIndexesLoaded(ctx); err != nil {
+		return err
+	}
+
+	mp, err := sm.format.GetMutableParameters(ctx)
+	if err != nil {
+		return errors.Wrap(err, "mutable parameters")
+	}
+
+	ordering, err := mp.GetIndexOrdering()
+	if err != nil {
+		return errors.Wrap(err, "error getting index ordering")
+	}
+
+	sm.indexesLock.RLock()
+	ids, merged := sm.committedContents.exportState()
+
+	b := index.Builder{}
+
+	err = merged.Iterate(index.AllIDs, func(i index.Info) error {
+		b.Add(i)
+		return nil
+	})
+	sm.indexesLock.RUnlock()
+
+	if err != nil {
+		return errors.Wrap(err, "error iterating index entries")
+	}
+
+	slices.Sort(ids)
+
+	hdr, err := json.Marshal(indexExportHeader{
+		CreatedAt:    sm.timeNow(),
+		IndexVersion: mp.IndexVersion,
+		EntryCount:   len(b),
+		IndexBlobs:   ids,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error serializing header")
+	}
+
+	var ndx bytes.Buffer
+
+	if err := b.BuildStableWithOrdering(&ndx, mp.IndexVersion, ordering); err != nil {
+		return errors.Wrap(err, "error building index")
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(indexExportMagic)
+	binary.Write(&buf, binary.BigEndian, uint16(indexExportVersion)) //nolint:errcheck
+	binary.Write(&buf, binary.BigEndian, uint32(len(hdr)))           //nolint:errcheck,gosec
+	buf.Write(hdr)
+	binary.Write(&buf, binary.BigEndian, uint64(ndx.Len())) //nolint:errcheck
+	buf.Write(ndx.Bytes())
+	buf.Write(sm.indexExportChecksum(buf.Bytes()))
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, "error writing index export")
+	}
+
+	return nil
+}
+
+// ImportIndex loads the index export produced by ExportIndex() and uses it instead of the index blobs it was
+// produced from, after which indexes are refreshed, so the resulting view of the repository is equivalent
+// to merging all active index blobs.
+//
+// ErrIndexExportStale is returned when the export was made obsolete by the repository changes since it was
+// produced, in which case index blobs are used as if the import did not happen.
+func (sm *SharedManager) ImportIndex(ctx context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "error reading index export")
+	}
+
+	ii, err := sm.parseIndexExport(data)
+	if err != nil {
+		return err
+	}
+
+	sm.indexesLock.Lock()
+	defer sm.indexesLock.Unlock()
+
+	sm.committedContents.importIndex(ii)
+
+	if err := sm.loadPackIndexesLocked(ctx); err != nil {
+		return errors.Wrap(err, "error loading indexes")
+	}
+
+	if !sm.committedContents.hasImportedIndex() {
+		return ErrIndexExportStale
+	}
+
+	return nil
+}
+
+func (sm *SharedManager) parseIndexExport(data []byte) (*importedIndex, error) {
+	const fixedHeaderLength = len(indexExportMagic) + 2 + 4
+
+	if len(data) < fixedHeaderLength+indexExportChecksumSize {
+		return nil, errors.New("index export too short")
+	}
+
+	if string(data[0:len(indexExportMagic)]) != indexExportMagic {
+		return nil, errors.New("not an index export")
+	}
+
+	payload, checksum := data[0:len(data)-indexExportChecksumSize], data[len(data)-indexExportChecksumSize:]
+	if !hmac.Equal(checksum, sm.indexExportChecksum(payload)) {
+		return nil, errors.New("invalid index export checksum, the export is corrupted or belongs to a different repository")
+	}
+
+	if v := binary.BigEndian.Uint16(payload[len(indexExportMagic):]); v != indexExportVersion {
+		return nil, errors.Errorf("unsupported index export version %v", v)
+	}
+
+	rest := payload[fixedHeaderLength:]
+
+	hdrLen := int64(binary.BigEndian.Uint32(payload[fixedHeaderLength-4:]))
+	if hdrLen > maxIndexExportHeaderLength || hdrLen+8 > int64(len(rest)) {
+		return nil, errors.New("invalid index export header length")
+	}
+
+	var hdr indexExportHeader
+
+	if err := json.Unmarshal(rest[0:hdrLen], &hdr); err != nil {
+		return nil, errors.Wrap(err, "invalid index export header")
+	}
+
+	rest = rest[hdrLen:]
+
+	ndxLen := binary.BigEndian.Uint64(rest)
+	if ndxLen != uint64(len(rest)-8) { //nolint:gosec
+		return nil, errors.New("invalid index export length")
+	}
+
+	ndx, err := index.Open(rest[8:], nil, sm.format.Encryptor().Overhead)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open exported index")
+	}
+
+	ii := &importedIndex{
+		indexBlobs: map[blob.ID]bool{},
+		ndx:        ndx,
+	}
+
+	for _, id := range hdr.IndexBlobs {
+		ii.indexBlobs[id] = true
+	}
+
+	return ii, nil
+}
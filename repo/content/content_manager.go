@@ -101,6 +101,10 @@ type WriteManager struct {
 	log logging.Logger
 }
 
+// pendingPackInfo describes a pack which is being built in memory. Contents are appended to it until it
+// reaches the maximum pack size or the manager is flushed, only then is it finalized and written.
+// Finalized packs are never reopened: packs are immutable once written and a finalized pack which
+// failed to upload is retried as-is, so appending to it would invalidate the local index.
 type pendingPackInfo struct {
 	prefix           blob.ID
 	packBlobID       blob.ID
@@ -161,6 +165,10 @@ func (bm *WriteManager) DeleteContent(ctx context.Context, contentID ID) error {
 }
 
 func (bm *WriteManager) maybeRefreshIndexes(ctx context.Context) error {
+	if err := bm.ensureIndexesLoaded(ctx); err != nil {
+		return err
+	}
+
 	if bm.permissiveCacheLoading {
 		return nil
 	}
@@ -305,6 +313,11 @@ func (bm *WriteManager) addToPackUnlocked(ctx context.Context, contentID ID, dat
 		return errors.Wrap(err, "unable to create pending pack")
 	}
 
+	if err := alignPackData(pp.currentPackData, mp.PackAlignment); err != nil {
+		bm.unlock(ctx)
+		return errors.Wrap(err, "unable to align pack data")
+	}
+
 	info := Info{
 		Deleted:          isDeleted,
 		ContentID:        contentID,
@@ -449,8 +462,13 @@ func (bm *WriteManager) flushPackIndexesLocked(ctx context.Context, mp format.Mu
 	}
 
 	if len(bm.packIndexBuilder) > 0 {
+		ordering, err := mp.GetIndexOrdering()
+		if err != nil {
+			return errors.Wrap(err, "unable to get index ordering")
+		}
+
 		_, span2 := tracer.Start(ctx, "BuildShards")
-		dataShards, closeShards, err := bm.packIndexBuilder.BuildShards(mp.IndexVersion, true, indexblob.DefaultIndexShardSize)
+		dataShards, closeShards, err := bm.packIndexBuilder.BuildShards(mp.IndexVersion, ordering, true, indexblob.DefaultIndexShardSize)
 
 		span2.End()
 
@@ -604,6 +622,18 @@ func (bm *WriteManager) Flush(ctx context.Context) error {
 		return errors.Wrap(mperr, "mutable parameters")
 	}
 
+	if err := bm.flushPendingWrites(ctx, mp); err != nil {
+		return err
+	}
+
+	// compaction may take a while, so it runs after the write lock is released to avoid blocking writers.
+	bm.maybeForceIndexCompaction(ctx, mp)
+
+	return nil
+}
+
+// flushPendingWrites writes pending and previously failed packs followed by pack indexes while holding the write lock.
+func (bm *WriteManager) flushPendingWrites(ctx context.Context, mp format.MutableParameters) error {
 	bm.lock()
 	defer bm.unlock(ctx)
 
@@ -679,6 +709,36 @@ func (bm *WriteManager) getContentDataAndInfo(ctx context.Context, contentID ID,
 	return bi, nil
 }
 
+// VerifyContentInStorage reads the given content directly from the storage and verifies its integrity.
+// Unlike GetContent() the local content cache is neither consulted nor populated, so the copy in the
+// storage is always checked and cached contents used by other operations are not evicted.
+// Contents which have not been written to the storage yet are verified in memory.
+func (bm *WriteManager) VerifyContentInStorage(ctx context.Context, contentID ID) error {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	pp, bi, err := bm.getContentInfoReadLocked(ctx, contentID)
+	if err != nil {
+		return err
+	}
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	if pp != nil {
+		return bm.getContentDataReadLocked(ctx, pp, bi, &tmp)
+	}
+
+	var payload gather.WriteBuffer
+	defer payload.Close()
+
+	if err := bm.st.GetBlob(ctx, bi.PackBlobID, int64(bi.PackOffset), int64(bi.PackedLength), &payload); err != nil {
+		return errors.Wrapf(err, "error reading content from blob %q", bi.PackBlobID)
+	}
+
+	return bm.decryptContentAndVerify(payload.Bytes(), bi, &tmp)
+}
+
 // UndeleteContent rewrites the content with the given ID if the content exists
 // and is mark deleted. If the content exists and is not marked deleted, this
 // operation is a no-op.
@@ -778,6 +838,23 @@ func (bm *WriteManager) SupportsContentCompression() bool {
 	return mp.IndexVersion >= index.Version2
 }
 
+// ComputeContentID returns the content ID which WriteContent would assign to the provided data and prefix,
+// without writing it. In per-source deduplication scope the ID depends on the namespace associated with the context.
+func (bm *WriteManager) ComputeContentID(ctx context.Context, data gather.Bytes, prefix index.IDPrefix) (ID, error) {
+	mp, mperr := bm.format.GetMutableParameters(ctx)
+	if mperr != nil {
+		return EmptyID, errors.Wrap(mperr, "mutable parameters")
+	}
+
+	if err := prefix.ValidateSingle(); err != nil {
+		return EmptyID, errors.Wrap(err, "invalid prefix")
+	}
+
+	var hashOutput [hashing.MaxHashSize]byte
+
+	return IDFromHash(prefix, bm.format.HashFunc()(hashOutput[:0], namespacedHashInput(ctx, mp, data)))
+}
+
 // WriteContent saves a given content of data to a pack group with a provided name and returns a contentID
 // that's based on the contents of data written.
 func (bm *WriteManager) WriteContent(ctx context.Context, data gather.Bytes, prefix index.IDPrefix, comp compression.HeaderID) (ID, error) {
@@ -801,7 +878,7 @@ func (bm *WriteManager) WriteContent(ctx context.Context, data gather.Bytes, pre
 
 	var hashOutput [hashing.MaxHashSize]byte
 
-	contentID, err := IDFromHash(prefix, bm.hashData(hashOutput[:0], data))
+	contentID, err := IDFromHash(prefix, bm.hashData(hashOutput[:0], namespacedHashInput(ctx, mp, data)))
 	if err != nil {
 		return EmptyID, errors.Wrap(err, "invalid hash")
 	}
@@ -951,6 +1028,45 @@ type ManagerOptions struct {
 	TimeNow                func() time.Time // Time provider
 	DisableInternalLog     bool
 	PermissiveCacheLoading bool
+
+	// VerifyAfterWriteRate is the fraction (0..1) of newly written pack blobs that are read back
+	// from the storage and compared with the data that was written, zero disables verification.
+	VerifyAfterWriteRate float64
+	// VerifyAfterWriteSeed seeds the selection of blobs to verify, zero uses a random seed.
+	VerifyAfterWriteSeed int64
+
+	// ImportedIndex, when set, is an index export produced by ExportIndex() which is used instead of
+	// the index blobs it was produced from, so that they don't need to be fetched when opening.
+	ImportedIndex []byte
+
+	// CompressionPredictor is invoked with a sample of each content before it is compressed and compression
+	// is not attempted if it returns false. When nil, compression is always attempted.
+	CompressionPredictor compression.Predictor
+
+	// IndexLoading determines whether committed indexes are loaded when the manager is created or
+	// on first access, empty value is the same as IndexLoadingEager.
+	IndexLoading IndexLoadingMode
+}
+
+// IndexLoadingMode determines when committed indexes are loaded.
+type IndexLoadingMode string
+
+// Supported index loading modes.
+const (
+	// IndexLoadingEager downloads, opens and merges all index blobs when the manager is created,
+	// so opening the repository takes longer, but all lookups have predictable latency.
+	IndexLoadingEager IndexLoadingMode = "eager"
+
+	// IndexLoadingLazy defers loading of index blobs until the first content lookup or iteration,
+	// which makes opening fast (useful for commands which may not need indexes at all), at the cost
+	// of the first lookup paying the full loading latency. Errors loading indexes are reported by
+	// that lookup instead of when opening.
+	IndexLoadingLazy IndexLoadingMode = "lazy"
+)
+
+// SupportedIndexLoadingModes returns the list of supported index loading modes.
+func SupportedIndexLoadingModes() []string {
+	return []string{string(IndexLoadingEager), string(IndexLoadingLazy)}
 }
 
 // CloneOrDefault returns a clone of provided ManagerOptions or default empty struct if nil.
@@ -11,6 +11,7 @@
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content/index"
 	"github.com/kopia/kopia/repo/content/indexblob"
+	"github.com/kopia/kopia/repo/format"
 )
 
 // Refresh reloads the committed content indexes.
@@ -61,6 +62,59 @@ func (sm *SharedManager) CompactIndexes(ctx context.Context, opt indexblob.Compa
 	return nil
 }
 
+// PlanIndexCompaction returns the index blobs that would be merged by CompactIndexes() with the same options,
+// without modifying the repository.
+func (sm *SharedManager) PlanIndexCompaction(ctx context.Context, opt indexblob.CompactOptions) ([]indexblob.Metadata, error) {
+	ibm, err := sm.indexBlobManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:wrapcheck
+	return ibm.PlanCompaction(ctx, opt)
+}
+
+// maybeForceIndexCompaction compacts index blobs when their number exceeds mp.MaxIndexBlobs, which
+// prevents unbounded growth when index blobs are written faster than maintenance compacts them.
+//
+// Compaction is crash-safe since compacted index blobs are written before the inputs are marked as
+// superseded, so it simply gets retried on the next flush if it fails or gets canceled.
+func (sm *SharedManager) maybeForceIndexCompaction(ctx context.Context, mp format.MutableParameters) {
+	if mp.MaxIndexBlobs <= 0 {
+		return
+	}
+
+	cnt := sm.committedContents.indexBlobCount()
+	if cnt <= mp.MaxIndexBlobs || ctx.Err() != nil {
+		return
+	}
+
+	sm.log.Infof("number of index blobs (%v) exceeds %v, forcing index compaction", cnt, mp.MaxIndexBlobs)
+
+	if err := sm.CompactIndexes(ctx, indexblob.CompactOptions{
+		MaxSmallBlobs: 1,
+		Forced:        true,
+	}); err != nil {
+		sm.log.Errorf("forced index compaction failed, will retry on next flush: %v", err)
+	}
+}
+
+// RestrictIndex limits the committed contents visible through this manager to those for which keep() returns true,
+// which reduces the memory used by the index when only a small subset of the repository is needed,
+// such as when restoring a single source. Lookups of contents outside of the scope will fail with
+// ErrContentNotFound and IterateContents() will not return them.
+//
+// Index blobs still need to be downloaded and opened once in order to find the ones with contents in scope,
+// the others are closed and are not opened again when indexes are refreshed, which only loads index blobs
+// added since. The scoped index is re-built from index blobs with contents in scope (regardless of how
+// contents are sharded across them). Contents written through this manager are not subject to the restriction.
+func (sm *SharedManager) RestrictIndex(ctx context.Context, keep func(ID) bool) error {
+	sm.indexesLock.Lock()
+	defer sm.indexesLock.Unlock()
+
+	return sm.committedContents.restrictTo(ctx, keep)
+}
+
 // ParseIndexBlob loads entries in a given index blob and returns them.
 func ParseIndexBlob(blobID blob.ID, encrypted gather.Bytes, crypter blobcrypto.Crypter) ([]Info, error) {
 	var data gather.WriteBuffer
@@ -11,6 +11,7 @@
 
 	"github.com/kopia/kopia/internal/bigmap"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/content/index"
 )
 
@@ -276,7 +277,16 @@ func(pi PackInfo) error {
 
 	if err := blob.IterateAllPrefixesInParallel(ctx, parallellism, bm.st, prefixes,
 		func(bm blob.Metadata) error {
-			if usedPacks.Contains([]byte(bm.BlobID)) {
+			id := bm.BlobID
+
+			// parts of split blobs are only visible if the storage is not wrapped, they are in use
+			// as long as the blob they belong to is, which also covers parts left behind by
+			// interrupted writes.
+			if base, ok := splitting.PartOf(id); ok {
+				id = base
+			}
+
+			if usedPacks.Contains([]byte(id)) {
 				return nil
 			}
 
@@ -24,6 +24,20 @@
 
 const indexBlobCompactionWarningThreshold = 1000
 
+// compressionPredictorSampleSize is the size of the sample of content data passed to the compression predictor.
+const compressionPredictorSampleSize = 4096
+
+// predictCompressible returns false if the compression predictor expects the data to be incompressible.
+func (sm *SharedManager) predictCompressible(data gather.Bytes) bool {
+	if sm.compressionPredictor == nil {
+		return true
+	}
+
+	var buf [compressionPredictorSampleSize]byte
+
+	return sm.compressionPredictor(compression.Sample(buf[:], data, data.Length()))
+}
+
 func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes, contentID ID, comp compression.HeaderID, output *gather.WriteBuffer, mp format.MutableParameters) (compression.HeaderID, error) {
 	var hashOutput [hashing.MaxHashSize]byte
 
@@ -36,12 +50,18 @@ func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes
 		comp = compression.HeaderZstdFastest
 	}
 
-	//nolint:nestif
-	if comp != NoCompression {
-		if mp.IndexVersion < index.Version2 {
-			return NoCompression, errors.Errorf("compression is not enabled for this repository")
-		}
+	if comp != NoCompression && mp.IndexVersion < index.Version2 {
+		return NoCompression, errors.Errorf("compression is not enabled for this repository")
+	}
 
+	if comp != NoCompression && !sm.predictCompressible(data) {
+		// the header ID recorded in the index indicates the content is stored uncompressed.
+		comp = NoCompression
+
+		sm.predictedNonCompressibleBytes.Add(int64(data.Length()))
+	}
+
+	if comp != NoCompression {
 		var tmp gather.WriteBuffer
 		defer tmp.Close()
 
@@ -89,11 +109,29 @@ func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes
 func writeRandomBytesToBuffer(b *gather.WriteBuffer, count int) error {
 	var rnd [defaultPaddingUnit]byte
 
-	if _, err := io.ReadFull(cryptorand.Reader, rnd[0:count]); err != nil {
-		return errors.Wrap(err, "error getting random bytes")
+	for count > 0 {
+		n := min(count, len(rnd))
+
+		if _, err := io.ReadFull(cryptorand.Reader, rnd[0:n]); err != nil {
+			return errors.Wrap(err, "error getting random bytes")
+		}
+
+		b.Append(rnd[0:n])
+		count -= n
 	}
 
-	b.Append(rnd[0:count])
+	return nil
+}
+
+// alignPackData pads the pack data with random bytes so that its length is a multiple of the alignment.
+func alignPackData(b *gather.WriteBuffer, alignment int) error {
+	if alignment <= 1 {
+		return nil
+	}
+
+	if r := b.Length() % alignment; r != 0 {
+		return writeRandomBytesToBuffer(b, alignment-r)
+	}
 
 	return nil
 }
@@ -191,7 +229,15 @@ func (sm *SharedManager) writePackFileNotLocked(ctx context.Context, packFile bl
 	sm.Stats.wroteContent(data.Length())
 	onUpload(int64(data.Length()))
 
-	return errors.Wrap(sm.st.PutBlob(ctx, packFile, data, blob.PutOptions{}), "error writing pack file")
+	if err := sm.st.PutBlob(ctx, packFile, data, blob.PutOptions{}); err != nil {
+		return errors.Wrap(err, "error writing pack file")
+	}
+
+	if sm.verifyAfterWrite.shouldVerify() {
+		return sm.verifyWrittenBlob(ctx, packFile, data)
+	}
+
+	return nil
 }
 
 func (sm *SharedManager) hashData(output []byte, data gather.Bytes) []byte {
@@ -19,6 +19,9 @@ type metricsStruct struct {
 
 	nonCompressibleBytes *metrics.Counter
 
+	// number of bytes not compressed because the compression predictor expected them to be incompressible.
+	predictedNonCompressibleBytes *metrics.Counter
+
 	compressibleBytes  *metrics.Counter
 	compressionSavings *metrics.Counter
 
@@ -43,10 +46,11 @@ func initMetricsStruct(mr *metrics.Registry) metricsStruct {
 		writeContentBytes: mr.Throughput("content_write", "WriteContent throughput (before deduplication)", nil),
 		hashedBytes:       mr.Throughput("content_hashed", "Hashing throughput.", nil),
 
-		afterCompressionBytes: mr.CounterInt64("content_after_compression_bytes", "Number of bytes after deduplication and compression", nil),
-		nonCompressibleBytes:  mr.CounterInt64("content_non_compressible_bytes", "Number of bytes that were found to be non-compressible stage.", nil),
-		compressibleBytes:     mr.CounterInt64("content_compressible_bytes", "Number of bytes that were found to be compressible.", nil),
-		compressionSavings:    mr.CounterInt64("content_compression_savings_bytes", "Number of bytes that were saved due to compression.", nil),
+		afterCompressionBytes:         mr.CounterInt64("content_after_compression_bytes", "Number of bytes after deduplication and compression", nil),
+		nonCompressibleBytes:          mr.CounterInt64("content_non_compressible_bytes", "Number of bytes that were found to be non-compressible stage.", nil),
+		predictedNonCompressibleBytes: mr.CounterInt64("content_predicted_non_compressible_bytes", "Number of bytes that were predicted to be non-compressible and not compressed.", nil),
+		compressibleBytes:             mr.CounterInt64("content_compressible_bytes", "Number of bytes that were found to be compressible.", nil),
+		compressionSavings:            mr.CounterInt64("content_compression_savings_bytes", "Number of bytes that were saved due to compression.", nil),
 
 		encryptedBytes:            mr.Throughput("content_encrypted", "Encryption throughput.", nil),
 		compressionAttemptedBytes: mr.Throughput("content_compression_attempted", "Compression throughput.", nil),
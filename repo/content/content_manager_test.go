@@ -410,6 +410,69 @@ func (s *contentManagerSuite) TestContentManagerFailedToWritePack(t *testing.T)
 	faulty.VerifyAllFaultsExercised(t)
 }
 
+// packDroppingStorage acknowledges writes of pack blobs without storing them.
+type packDroppingStorage struct {
+	blob.Storage
+}
+
+func (s packDroppingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if strings.HasPrefix(string(id), string(PackBlobIDPrefixRegular)) {
+		return nil
+	}
+
+	return s.Storage.PutBlob(ctx, id, data, opts)
+}
+
+func (s *contentManagerSuite) TestContentManagerVerifyAfterWrite(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	bm := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{VerifyAfterWriteRate: 1},
+	})
+
+	writeContentAndVerify(ctx, t, bm, seededRandomData(1, 100))
+	require.NoError(t, bm.Flush(ctx))
+
+	bm = s.newTestContentManagerWithTweaks(t, packDroppingStorage{st}, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{VerifyAfterWriteRate: 1},
+	})
+
+	_, err := bm.WriteContent(ctx, gather.FromSlice(seededRandomData(2, 100)), "", NoCompression)
+	require.NoError(t, err)
+	require.ErrorIs(t, bm.Flush(ctx), ErrVerifyAfterWriteFailed)
+}
+
+func TestVerifyAfterWriteSampler(t *testing.T) {
+	require.Nil(t, newVerifyAfterWriteSampler(0, 1))
+	require.False(t, newVerifyAfterWriteSampler(0, 1).shouldVerify())
+
+	sample := func(s *verifyAfterWriteSampler) []bool {
+		var result []bool
+
+		for range 1000 {
+			result = append(result, s.shouldVerify())
+		}
+
+		return result
+	}
+
+	// same seed produces the same selection.
+	s1 := sample(newVerifyAfterWriteSampler(0.1, 123))
+	require.Equal(t, s1, sample(newVerifyAfterWriteSampler(0.1, 123)))
+
+	cnt := 0
+
+	for _, v := range s1 {
+		if v {
+			cnt++
+		}
+	}
+
+	require.InDelta(t, 100, cnt, 50)
+}
+
 func (s *contentManagerSuite) TestIndexCompactionDropsContent(t *testing.T) {
 	if s.mutableParameters.EpochParameters.Enabled {
 		t.Skip("dropping index entries not implemented")
@@ -455,6 +518,355 @@ func (s *contentManagerSuite) TestIndexCompactionDropsContent(t *testing.T) {
 	verifyContentNotFound(ctx, t, bm, content1)
 }
 
+func (s *contentManagerSuite) TestForcedIndexCompaction(t *testing.T) {
+	if s.mutableParameters.EpochParameters.Enabled {
+		t.Skip("epoch-based index blobs are compacted when epochs advance")
+	}
+
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	const maxIndexBlobs = 3
+
+	bm := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		maxIndexBlobs: maxIndexBlobs,
+	})
+
+	var contentIDs []ID
+
+	for i := range 10 {
+		contentIDs = append(contentIDs, writeContentAndVerify(ctx, t, bm, seededRandomData(i, 100)))
+		require.NoError(t, bm.Flush(ctx))
+		require.LessOrEqual(t, bm.committedContents.indexBlobCount(), maxIndexBlobs)
+	}
+
+	// compaction has been forced, so there are fewer index blobs than flushes.
+	ibm, err := bm.indexBlobManager(ctx)
+	require.NoError(t, err)
+
+	active, _, err := ibm.ListActiveIndexBlobs(ctx)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(active), maxIndexBlobs)
+
+	bm2 := s.newTestContentManager(t, st)
+	for i, cid := range contentIDs {
+		verifyContent(ctx, t, bm2, cid, seededRandomData(i, 100))
+	}
+}
+
+func (s *contentManagerSuite) TestPackAlignment(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	const alignment = 512
+
+	bm := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		packAlignment: alignment,
+	})
+
+	var contentIDs []ID
+
+	for i := range 10 {
+		contentIDs = append(contentIDs, writeContentAndVerify(ctx, t, bm, seededRandomData(i, 100*i+1)))
+	}
+
+	require.NoError(t, bm.Flush(ctx))
+
+	bm2 := s.newTestContentManager(t, st)
+
+	for i, cid := range contentIDs {
+		verifyContent(ctx, t, bm2, cid, seededRandomData(i, 100*i+1))
+
+		ci, err := bm2.ContentInfo(ctx, cid)
+		require.NoError(t, err)
+		require.Zero(t, ci.PackOffset%alignment, "offset %v is not aligned", ci.PackOffset)
+	}
+}
+
+func (s *contentManagerSuite) TestDedupScope(t *testing.T) {
+	ctx := testlogging.Context(t)
+	st := blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil)
+
+	ctxA := WithDedupNamespace(ctx, "source-a")
+	ctxB := WithDedupNamespace(ctx, "source-b")
+	d := seededRandomData(1, 100)
+
+	write := func(ctx context.Context, bm *WriteManager) ID {
+		t.Helper()
+
+		cid, err := bm.WriteContent(ctx, gather.FromSlice(d), "", NoCompression)
+		require.NoError(t, err)
+
+		verifyContent(ctx, t, bm, cid, d)
+
+		return cid
+	}
+
+	// in global scope the namespace is ignored.
+	bm := s.newTestContentManagerWithTweaks(t, st, nil)
+
+	globalID := write(ctx, bm)
+	require.Equal(t, globalID, write(ctxA, bm))
+	require.Equal(t, globalID, write(ctxB, bm))
+	require.NoError(t, bm.Flush(ctx))
+
+	// in per-source scope identical contents in different namespaces are stored separately.
+	bm = s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		dedupScope: format.DedupScopePerSource,
+	})
+
+	idA := write(ctxA, bm)
+	idB := write(ctxB, bm)
+
+	require.NotEqual(t, idA, idB)
+	require.NotEqual(t, globalID, idA)
+	require.Equal(t, idA, write(ctxA, bm))
+	require.Equal(t, globalID, write(ctx, bm))
+	require.NoError(t, bm.Flush(ctx))
+
+	// all contents, including those written before switching the scope remain readable.
+	bm2 := s.newTestContentManager(t, st)
+
+	for _, cid := range []ID{globalID, idA, idB} {
+		verifyContent(ctx, t, bm2, cid, d)
+	}
+}
+
+func (s *contentManagerSuite) TestExportImportIndex(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	bm := s.newTestContentManager(t, st)
+	defer bm.CloseShared(ctx)
+
+	var ids []ID
+
+	for i := range 4 {
+		ids = append(ids, writeContentAndVerify(ctx, t, bm, seededRandomData(i, 100)))
+		require.NoError(t, bm.Flush(ctx))
+	}
+
+	require.NoError(t, bm.DeleteContent(ctx, ids[1]))
+	require.NoError(t, bm.Flush(ctx))
+
+	var exported bytes.Buffer
+
+	require.NoError(t, bm.ExportIndex(ctx, &exported))
+
+	sourceIndexBlobs, _ := bm.committedContents.exportState()
+	require.NotEmpty(t, sourceIndexBlobs)
+
+	// the replica opened with the export does not need to fetch any index blobs.
+	cst := &indexReadCountingStorage{Storage: st}
+
+	replica := s.newTestContentManagerWithTweaks(t, cst, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{ImportedIndex: exported.Bytes()},
+	})
+	defer replica.CloseShared(ctx)
+
+	require.Zero(t, cst.indexReads.Load())
+	require.True(t, replica.committedContents.hasImportedIndex())
+	require.Equal(t, allContentInfos(ctx, t, bm), allContentInfos(ctx, t, replica))
+
+	verifyContent(ctx, t, replica, ids[0], seededRandomData(0, 100))
+	verifyDeletedContentRead(ctx, t, replica, ids[1], seededRandomData(1, 100))
+
+	// index blobs written after the export are merged on top of the imported index.
+	ids = append(ids, writeContentAndVerify(ctx, t, bm, seededRandomData(4, 100)))
+	require.NoError(t, bm.Flush(ctx))
+	require.NoError(t, replica.Refresh(ctx))
+
+	require.Positive(t, cst.indexReads.Load())
+	require.True(t, replica.committedContents.hasImportedIndex())
+	require.Equal(t, allContentInfos(ctx, t, bm), allContentInfos(ctx, t, replica))
+
+	// once any of the source index blobs is gone (as it would be after compaction), the imported index is discarded.
+	require.NoError(t, st.DeleteBlob(ctx, sourceIndexBlobs[0]))
+	require.NoError(t, bm.Refresh(ctx))
+	require.NoError(t, replica.Refresh(ctx))
+
+	require.False(t, replica.committedContents.hasImportedIndex())
+	require.Equal(t, allContentInfos(ctx, t, bm), allContentInfos(ctx, t, replica))
+	verifyContent(ctx, t, replica, ids[4], seededRandomData(4, 100))
+
+	require.ErrorIs(t, replica.ImportIndex(ctx, bytes.NewReader(exported.Bytes())), ErrIndexExportStale)
+	require.False(t, replica.committedContents.hasImportedIndex())
+
+	// a fresh export can be imported again.
+	exported.Reset()
+	require.NoError(t, bm.ExportIndex(ctx, &exported))
+	require.NoError(t, replica.ImportIndex(ctx, bytes.NewReader(exported.Bytes())))
+	require.True(t, replica.committedContents.hasImportedIndex())
+	require.Equal(t, allContentInfos(ctx, t, bm), allContentInfos(ctx, t, replica))
+
+	// corrupted exports are rejected.
+	corrupted := bytes.Clone(exported.Bytes())
+	corrupted[len(corrupted)/2] ^= 1
+
+	require.ErrorContains(t, replica.ImportIndex(ctx, bytes.NewReader(corrupted)), "checksum")
+	require.Error(t, replica.ImportIndex(ctx, bytes.NewReader(corrupted[0:10])))
+}
+
+func (s *contentManagerSuite) TestRestrictIndex(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	bm := s.newTestContentManager(t, st)
+	defer bm.CloseShared(ctx)
+
+	var ids []ID
+
+	for i := range 4 {
+		ids = append(ids, writeContentAndVerify(ctx, t, bm, seededRandomData(i, 100)))
+		require.NoError(t, bm.Flush(ctx))
+	}
+
+	reader := s.newTestContentManager(t, st)
+	defer reader.CloseShared(ctx)
+
+	require.Equal(t, 4, reader.committedContents.indexBlobCount())
+
+	// index blobs without contents in scope are closed.
+	require.NoError(t, reader.RestrictIndex(ctx, func(id ID) bool { return id == ids[0] || id == ids[2] }))
+	require.Equal(t, 2, reader.committedContents.indexBlobCount())
+
+	verifyContent(ctx, t, reader, ids[0], seededRandomData(0, 100))
+	verifyContent(ctx, t, reader, ids[2], seededRandomData(2, 100))
+	verifyContentNotFound(ctx, t, reader, ids[1])
+
+	// index blobs added later are opened but only kept if they have contents in scope.
+	ids = append(ids, writeContentAndVerify(ctx, t, bm, seededRandomData(4, 100)))
+	require.NoError(t, bm.Flush(ctx))
+	require.NoError(t, reader.Refresh(ctx))
+	require.Equal(t, 2, reader.committedContents.indexBlobCount())
+	verifyContentNotFound(ctx, t, reader, ids[4])
+
+	// index blobs excluded by the previous scope are opened again when the scope changes.
+	require.NoError(t, reader.RestrictIndex(ctx, func(id ID) bool { return id == ids[1] || id == ids[4] }))
+	require.Equal(t, 2, reader.committedContents.indexBlobCount())
+
+	verifyContent(ctx, t, reader, ids[1], seededRandomData(1, 100))
+	verifyContent(ctx, t, reader, ids[4], seededRandomData(4, 100))
+	verifyContentNotFound(ctx, t, reader, ids[0])
+}
+
+func (s *contentManagerSuite) TestLazyIndexLoading(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	bm := s.newTestContentManager(t, st)
+	defer bm.CloseShared(ctx)
+
+	var ids []ID
+
+	for i := range 4 {
+		ids = append(ids, writeContentAndVerify(ctx, t, bm, seededRandomData(i, 100)))
+		require.NoError(t, bm.Flush(ctx))
+	}
+
+	require.NoError(t, bm.DeleteContent(ctx, ids[1]))
+	require.NoError(t, bm.Flush(ctx))
+
+	eagerStorage := &indexReadCountingStorage{Storage: st}
+
+	eager := s.newTestContentManagerWithTweaks(t, eagerStorage, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{IndexLoading: IndexLoadingEager},
+	})
+	defer eager.CloseShared(ctx)
+
+	require.Positive(t, eagerStorage.indexReads.Load())
+
+	lazyStorage := &indexReadCountingStorage{Storage: st}
+
+	lazy := s.newTestContentManagerWithTweaks(t, lazyStorage, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{IndexLoading: IndexLoadingLazy},
+	})
+	defer lazy.CloseShared(ctx)
+
+	// nothing is loaded until the first lookup.
+	require.Zero(t, lazyStorage.indexReads.Load())
+	require.Zero(t, lazy.committedContents.indexBlobCount())
+
+	for _, id := range ids {
+		want, err := eager.ContentInfo(ctx, id)
+		require.NoError(t, err)
+
+		got, err := lazy.ContentInfo(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	require.Positive(t, lazyStorage.indexReads.Load())
+
+	missingID := hashValue(t, []byte("foo"))
+
+	_, err := eager.ContentInfo(ctx, missingID)
+	require.ErrorIs(t, err, ErrContentNotFound)
+
+	_, err = lazy.ContentInfo(ctx, missingID)
+	require.ErrorIs(t, err, ErrContentNotFound)
+
+	require.Equal(t, allContentInfos(ctx, t, eager), allContentInfos(ctx, t, lazy))
+
+	// iteration alone also loads indexes.
+	lazy2 := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{IndexLoading: IndexLoadingLazy},
+	})
+	defer lazy2.CloseShared(ctx)
+
+	require.Equal(t, allContentInfos(ctx, t, eager), allContentInfos(ctx, t, lazy2))
+
+	// writes of existing contents are deduplicated against lazily loaded indexes.
+	lazy3 := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		ManagerOptions: ManagerOptions{IndexLoading: IndexLoadingLazy},
+	})
+	defer lazy3.CloseShared(ctx)
+
+	blobCount := len(data)
+
+	id, err := lazy3.WriteContent(ctx, gather.FromSlice(seededRandomData(0, 100)), "", NoCompression)
+	require.NoError(t, err)
+	require.Equal(t, ids[0], id)
+	require.NoError(t, lazy3.Flush(ctx))
+	require.Len(t, data, blobCount)
+}
+
+// indexReadCountingStorage counts reads of index blobs.
+type indexReadCountingStorage struct {
+	blob.Storage
+
+	indexReads atomic.Int32
+}
+
+func (s *indexReadCountingStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	for _, prefix := range allIndexBlobPrefixes {
+		if strings.HasPrefix(string(id), string(prefix)) {
+			s.indexReads.Add(1)
+		}
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.GetBlob(ctx, id, offset, length, output)
+}
+
+func allContentInfos(ctx context.Context, t *testing.T, bm *WriteManager) map[ID]Info {
+	t.Helper()
+
+	result := map[ID]Info{}
+
+	require.NoError(t, bm.IterateContents(ctx, IterateOptions{IncludeDeleted: true}, func(ci Info) error {
+		result[ci.ContentID] = ci
+		return nil
+	}))
+
+	return result
+}
+
 func (s *contentManagerSuite) TestContentManagerConcurrency(t *testing.T) {
 	ctx := testlogging.Context(t)
 	data := blobtesting.DataMap{}
@@ -1735,6 +2147,135 @@ func (s *contentManagerSuite) TestFindUnreferencedBlobs2(t *testing.T) {
 	verifyUnreferencedBlobsCount(ctx, t, bm, 0)
 }
 
+// reversedListingStorage lists blobs of the underlying storage in reverse order.
+type reversedListingStorage struct {
+	blob.Storage
+}
+
+func (s reversedListingStorage) ListBlobs(ctx context.Context, prefix blob.ID, cb func(bm blob.Metadata) error) error {
+	all, err := blob.ListAllBlobs(ctx, s.Storage, prefix)
+	if err != nil {
+		return err
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		if err := cb(all[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *contentManagerSuite) TestIterateUnreferencedBlobs_UnsortedListing(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := reversedListingStorage{blobtesting.NewMapStorage(data, nil, nil)}
+
+	bm := s.newTestContentManager(t, st)
+
+	for i := range 10 {
+		writeContentAndVerify(ctx, t, bm, seededRandomData(i, 100))
+		require.NoError(t, bm.Flush(ctx))
+	}
+
+	var usedPacks []blob.ID
+
+	require.NoError(t, bm.IteratePacks(ctx, IteratePackOptions{}, func(pi PackInfo) error {
+		usedPacks = append(usedPacks, pi.PackID)
+		return nil
+	}))
+	require.Len(t, usedPacks, 10)
+
+	// unreferenced blobs sorting before, between and after the packs in use.
+	var want []blob.ID
+
+	for _, id := range append([]blob.ID{"p0", "p" + blob.ID(strings.Repeat("f", 40))}, usedPacks...) {
+		id += "-unreferenced"
+		require.NoError(t, st.PutBlob(ctx, id, gather.FromSlice([]byte{1}), blob.PutOptions{}))
+
+		want = append(want, id)
+	}
+
+	// parts of split blobs in use are not reported, parts without the blob they belong to are.
+	for _, id := range usedPacks {
+		require.NoError(t, st.PutBlob(ctx, id+".part1", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+	}
+
+	require.NoError(t, st.PutBlob(ctx, "p0-headless.part1", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+
+	want = append(want, "p0-headless.part1")
+
+	for _, parallel := range []int{1, 8, 32} {
+		var (
+			mu  sync.Mutex
+			got []blob.ID
+		)
+
+		require.NoError(t, bm.IterateUnreferencedBlobs(ctx, nil, parallel, func(bm blob.Metadata) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			got = append(got, bm.BlobID)
+
+			return nil
+		}))
+
+		require.ElementsMatch(t, want, got, "parallel=%v", parallel)
+	}
+}
+
+func (s *contentManagerSuite) TestVerifyContentInStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+	bm := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		CachingOptions: CachingOptions{
+			CacheDirectory:         testutil.TempDirectory(t),
+			ContentCacheSizeBytes:  100e6,
+			MetadataCacheSizeBytes: 100e6,
+		},
+	})
+
+	defer bm.CloseShared(ctx)
+
+	// pending contents are verified in memory.
+	id1 := writeContentAndVerify(ctx, t, bm, seededRandomData(1, 1000))
+	require.NoError(t, bm.VerifyContentInStorage(ctx, id1))
+
+	require.NoError(t, bm.Flush(ctx))
+
+	id2 := writeContentAndVerify(ctx, t, bm, seededRandomData(2, 1000))
+	require.NoError(t, bm.Flush(ctx))
+
+	require.NoError(t, bm.VerifyContentInStorage(ctx, id1))
+	require.NoError(t, bm.VerifyContentInStorage(ctx, id2))
+	require.ErrorIs(t, bm.VerifyContentInStorage(ctx, hashValue(t, []byte("foo"))), ErrContentNotFound)
+
+	corrupt := func(id ID) {
+		t.Helper()
+
+		bi := getContentInfo(t, bm, id)
+		data[bi.PackBlobID][bi.PackOffset+bi.PackedLength/2] ^= 1
+	}
+
+	// the first content is in the cache, which hides the corruption from GetContent().
+	_, err := bm.GetContent(ctx, id1)
+	require.NoError(t, err)
+
+	corrupt(id1)
+
+	_, err = bm.GetContent(ctx, id1)
+	require.NoError(t, err)
+	require.Error(t, bm.VerifyContentInStorage(ctx, id1))
+
+	// verification does not populate the cache.
+	corrupt(id2)
+
+	_, err = bm.GetContent(ctx, id2)
+	require.Error(t, err)
+}
+
 func dumpContents(ctx context.Context, t *testing.T, bm *WriteManager, caption string) {
 	t.Helper()
 
@@ -2061,6 +2602,61 @@ func (s *contentManagerSuite) TestCompression_CompressibleData(t *testing.T) {
 	verifyContent(ctx, t, bm2, cid, compressibleData)
 }
 
+func (s *contentManagerSuite) TestCompression_Predictor(t *testing.T) {
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	var samples [][]byte
+
+	tweaks := &contentManagerTestTweaks{
+		indexVersion: index.Version2,
+	}
+
+	tweaks.CompressionPredictor = func(sample []byte) bool {
+		samples = append(samples, bytes.Clone(sample))
+
+		// predict data starting with zero to be incompressible.
+		return sample[0] != 0
+	}
+
+	bm := s.newTestContentManagerWithTweaks(t, st, tweaks)
+
+	ctx := testlogging.Context(t)
+	headerID := compression.ByName["gzip"].HeaderID()
+
+	compressibleData := bytes.Repeat([]byte{1, 2, 3, 4}, 10000)
+	predictedIncompressible := bytes.Repeat([]byte{0, 1, 2, 3}, 10000)
+
+	cid1, err := bm.WriteContent(ctx, gather.FromSlice(compressibleData), "", headerID)
+	require.NoError(t, err)
+
+	cid2, err := bm.WriteContent(ctx, gather.FromSlice(predictedIncompressible), "", headerID)
+	require.NoError(t, err)
+
+	// the predictor is invoked with samples of the data.
+	require.Len(t, samples, 2)
+	require.LessOrEqual(t, len(samples[0]), compressionPredictorSampleSize)
+	require.Equal(t, compressibleData[0:10], samples[0][0:10])
+
+	ci1, err := bm.ContentInfo(ctx, cid1)
+	require.NoError(t, err)
+	require.Equal(t, headerID, ci1.CompressionHeaderID)
+
+	// the decision is recorded in the index.
+	ci2, err := bm.ContentInfo(ctx, cid2)
+	require.NoError(t, err)
+	require.Equal(t, NoCompression, ci2.CompressionHeaderID)
+	require.Greater(t, ci2.PackedLength, ci2.OriginalLength)
+
+	require.NoError(t, bm.Flush(ctx))
+
+	bm2 := s.newTestContentManagerWithTweaks(t, st, &contentManagerTestTweaks{
+		indexVersion: index.Version2,
+	})
+	verifyContent(ctx, t, bm2, cid1, compressibleData)
+	verifyContent(ctx, t, bm2, cid2, predictedIncompressible)
+}
+
 func (s *contentManagerSuite) TestCompression_NonCompressibleData(t *testing.T) {
 	data := blobtesting.DataMap{}
 	st := blobtesting.NewMapStorage(data, nil, nil)
@@ -2419,6 +3015,9 @@ type contentManagerTestTweaks struct {
 
 	indexVersion  int
 	maxPackSize   int
+	maxIndexBlobs int
+	packAlignment int
+	dedupScope    format.DedupScope
 	formatVersion format.Version
 }
 
@@ -2448,6 +3047,10 @@ func (s *contentManagerSuite) newTestContentManagerWithTweaks(t *testing.T, st b
 		mp.Version = tweaks.formatVersion
 	}
 
+	mp.MaxIndexBlobs = tweaks.maxIndexBlobs
+	mp.PackAlignment = tweaks.packAlignment
+	mp.DedupScope = tweaks.dedupScope
+
 	ctx := testlogging.Context(t)
 	fo := mustCreateFormatProvider(t, &format.ContentFormat{
 		Hash:              "HMAC-SHA256",
@@ -4,6 +4,8 @@
 	"context"
 
 	"github.com/kopia/kopia/internal/epoch"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content/indexblob"
 	"github.com/kopia/kopia/repo/format"
 )
 
@@ -15,9 +17,12 @@ type Reader interface {
 	SupportsContentCompression() bool
 	ContentFormat() format.Provider
 	GetContent(ctx context.Context, id ID) ([]byte, error)
+	VerifyContentInStorage(ctx context.Context, id ID) error
 	ContentInfo(ctx context.Context, id ID) (Info, error)
 	IterateContents(ctx context.Context, opts IterateOptions, callback IterateCallback) error
 	IteratePacks(ctx context.Context, opts IteratePackOptions, callback IteratePacksCallback) error
 	ListActiveSessions(ctx context.Context) (map[SessionID]*SessionInfo, error)
+	IterateUnreferencedBlobs(ctx context.Context, blobPrefixes []blob.ID, parallellism int, callback func(blob.Metadata) error) error
+	PlanIndexCompaction(ctx context.Context, opt indexblob.CompactOptions) ([]indexblob.Metadata, error)
 	EpochManager(ctx context.Context) (*epoch.Manager, bool, error)
 }
@@ -0,0 +1,79 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"math/rand"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// ErrVerifyAfterWriteFailed is returned when the blob read back from the storage after writing
+// does not match the data that was written.
+var ErrVerifyAfterWriteFailed = errors.New("verify-after-write failed")
+
+// verifyAfterWriteSampler decides which newly written blobs should be read back and verified.
+type verifyAfterWriteSampler struct {
+	rate float64
+
+	mu sync.Mutex
+	// +checklocks:mu
+	rnd *rand.Rand
+}
+
+func newVerifyAfterWriteSampler(rate float64, seed int64) *verifyAfterWriteSampler {
+	if rate <= 0 {
+		return nil
+	}
+
+	if seed == 0 {
+		seed = clock.Now().UnixNano()
+	}
+
+	return &verifyAfterWriteSampler{
+		rate: rate,
+		rnd:  rand.New(rand.NewSource(seed)), //nolint:gosec
+	}
+}
+
+func (s *verifyAfterWriteSampler) shouldVerify() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rnd.Float64() < s.rate
+}
+
+// verifyWrittenBlob reads back the blob from the storage and ensures its hash matches the data that was written,
+// to detect storage backends that acknowledge writes without durably storing them.
+func (sm *SharedManager) verifyWrittenBlob(ctx context.Context, blobID blob.ID, data gather.Bytes) error {
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	if err := sm.st.GetBlob(ctx, blobID, 0, -1, &tmp); err != nil {
+		return errors.Wrapf(ErrVerifyAfterWriteFailed, "unable to read back %v: %v", blobID, err)
+	}
+
+	want := sha256.New()
+	data.WriteTo(want) //nolint:errcheck
+
+	got := sha256.New()
+	tmp.Bytes().WriteTo(got) //nolint:errcheck
+
+	if !bytes.Equal(want.Sum(nil), got.Sum(nil)) {
+		return errors.Wrapf(ErrVerifyAfterWriteFailed, "%v does not match written data (length %v, read back %v)", blobID, data.Length(), tmp.Length())
+	}
+
+	sm.log.Debugf("verified-after-write %v", blobID)
+
+	return nil
+}
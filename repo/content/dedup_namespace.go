@@ -0,0 +1,46 @@
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/format"
+)
+
+type dedupNamespaceKey struct{}
+
+// WithDedupNamespace returns a context which causes contents written using it to be deduplicated only with other
+// contents written in the same namespace, when the repository uses format.DedupScopePerSource.
+// In the default global scope the namespace is ignored.
+//
+// Identical data written in different namespaces gets different content IDs, so existing contents are never
+// affected by changing the scope, they simply stop being deduplicated against new writes.
+// The namespace is not propagated to remote repository servers.
+func WithDedupNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, dedupNamespaceKey{}, namespace)
+}
+
+// dedupNamespaceFromContext returns the deduplication namespace associated with the context or an empty string.
+func dedupNamespaceFromContext(ctx context.Context) string {
+	ns, _ := ctx.Value(dedupNamespaceKey{}).(string)
+
+	return ns
+}
+
+// namespacedHashInput returns the data to be hashed to compute the content ID, which in per-source scope
+// is prefixed with a fixed-length salt derived from the namespace.
+func namespacedHashInput(ctx context.Context, mp format.MutableParameters, data gather.Bytes) gather.Bytes {
+	if !mp.DedupScope.IsPerSource() {
+		return data
+	}
+
+	ns := dedupNamespaceFromContext(ctx)
+	if ns == "" {
+		return data
+	}
+
+	salt := sha256.Sum256([]byte("kopia-dedup-namespace:" + ns))
+
+	return gather.Bytes{Slices: append([][]byte{salt[:]}, data.Slices...)}
+}
@@ -0,0 +1,187 @@
+package index
+
+import (
+	"hash/maphash"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// bloomFilterMinEntries is the minimum number of entries in an index for which a bloom filter is built,
+	// smaller indexes are cheap enough to search directly.
+	bloomFilterMinEntries = 256
+
+	// bloomFilterBuildLookupDivisor determines when the bloom filter is built: after the index has received
+	// 1/bloomFilterBuildLookupDivisor lookups per entry, so that the cost of building it is amortized.
+	bloomFilterBuildLookupDivisor = 8
+
+	bloomFilterMinBitsPerKey = 6
+	bloomFilterMaxBitsPerKey = 12
+)
+
+//nolint:gochecknoglobals
+var bloomFilterSeed = maphash.MakeSeed()
+
+// BloomFilter is a probabilistic set of content IDs, which may report false positives but never false negatives.
+type BloomFilter struct {
+	bits      []uint64
+	hashCount uint32
+}
+
+// NewBloomFilter returns a bloom filter sized for the provided number of content IDs.
+//
+// Larger filters use more bits per key, since the lookups they avoid are more expensive, and the
+// number of hash functions is chosen to minimize the false positive rate for that size.
+func NewBloomFilter(entryCount int) *BloomFilter {
+	bitsPerKey := bloomFilterMinBitsPerKey
+	for n := entryCount; n >= bloomFilterMinEntries && bitsPerKey < bloomFilterMaxBitsPerKey; n >>= 4 {
+		bitsPerKey++
+	}
+
+	words := max((entryCount*bitsPerKey+63)/64, 1) //nolint:mnd
+
+	return &BloomFilter{
+		bits:      make([]uint64, words),
+		hashCount: max(uint32(math.Round(float64(bitsPerKey)*math.Ln2)), 1),
+	}
+}
+
+// bloomHashes returns two independent hashes of the content ID, which are combined to simulate more.
+func bloomHashes(id ID) (h1, h2 uint32) {
+	var h maphash.Hash
+
+	h.SetSeed(bloomFilterSeed)
+	h.WriteByte(id.prefix) //nolint:errcheck
+	h.Write(id.Hash())     //nolint:errcheck
+
+	v := h.Sum64()
+
+	return uint32(v), uint32(v>>32) | 1 //nolint:mnd
+}
+
+// Add adds the provided content ID to the filter.
+func (f *BloomFilter) Add(id ID) {
+	h1, h2 := bloomHashes(id)
+	n := uint32(len(f.bits) * 64) //nolint:mnd
+
+	for i := range f.hashCount {
+		bit := (h1 + i*h2) % n
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MightContain returns false if the provided content ID has definitely not been added to the filter.
+func (f *BloomFilter) MightContain(id ID) bool {
+	h1, h2 := bloomHashes(id)
+	n := uint32(len(f.bits) * 64) //nolint:mnd
+
+	for i := range f.hashCount {
+		bit := (h1 + i*h2) % n
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bloomFilteredIndex skips lookups of contents not present in the underlying index using a bloom filter
+// built once the index has been queried enough times.
+type bloomFilteredIndex struct {
+	Index
+
+	lookups    atomic.Int64
+	buildAfter int64
+	buildOnce  sync.Once
+	filter     atomic.Pointer[BloomFilter]
+}
+
+// WithBloomFilter returns an index which answers GetInfo() for contents not present in the provided index
+// without searching it. The bloom filter is built lazily from the index entries after the index has been
+// queried a number of times proportional to its size, small indexes are returned unchanged.
+func WithBloomFilter(ndx Index) Index {
+	if _, ok := ndx.(*bloomFilteredIndex); ok || ndx.ApproximateCount() < bloomFilterMinEntries {
+		return ndx
+	}
+
+	return &bloomFilteredIndex{
+		Index:      ndx,
+		buildAfter: int64(ndx.ApproximateCount() / bloomFilterBuildLookupDivisor),
+	}
+}
+
+// GetInfo implements Index interface.
+func (b *bloomFilteredIndex) GetInfo(contentID ID, result *Info) (bool, error) {
+	if !b.MightContain(contentID) {
+		return false, nil
+	}
+
+	//nolint:wrapcheck
+	return b.Index.GetInfo(contentID, result)
+}
+
+// Contains implements Index interface.
+func (b *bloomFilteredIndex) Contains(contentID ID) (found, deleted bool, err error) {
+	if !b.MightContain(contentID) {
+		return false, false, nil
+	}
+
+	//nolint:wrapcheck
+	return b.Index.Contains(contentID)
+}
+
+// GetInfos implements Index interface.
+func (b *bloomFilteredIndex) GetInfos(contentIDs []ID) (map[ID]Info, []ID, error) {
+	var candidates, missing []ID
+
+	for _, id := range contentIDs {
+		if b.MightContain(id) {
+			candidates = append(candidates, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	slices.SortFunc(missing, ID.compare)
+	missing = slices.Compact(missing)
+
+	found, notFound, err := b.Index.GetInfos(candidates)
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck
+	}
+
+	return found, append(missing, notFound...), nil
+}
+
+// MightContain implements Index interface.
+func (b *bloomFilteredIndex) MightContain(contentID ID) bool {
+	f := b.filter.Load()
+
+	if f == nil && b.lookups.Add(1) >= b.buildAfter {
+		b.buildOnce.Do(b.buildFilter)
+
+		f = b.filter.Load()
+	}
+
+	if f == nil {
+		return true
+	}
+
+	return f.MightContain(contentID)
+}
+
+func (b *bloomFilteredIndex) buildFilter() {
+	f := NewBloomFilter(b.Index.ApproximateCount())
+
+	if err := b.Index.Iterate(AllIDs, func(i Info) error {
+		f.Add(i.ContentID)
+		return nil
+	}); err != nil {
+		// keep searching the index directly.
+		return
+	}
+
+	b.filter.Store(f)
+}
@@ -0,0 +1,159 @@
+package index
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func bloomTestContentID(tb testing.TB, n int) ID {
+	tb.Helper()
+
+	var b [8]byte
+
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+
+	h := sha256.Sum256(b[:])
+
+	cid, err := IDFromHash("", h[:])
+	require.NoError(tb, err)
+
+	return cid
+}
+
+func bloomTestIndex(tb testing.TB, first, count int) Index {
+	tb.Helper()
+
+	b := Builder{}
+
+	for i := range count {
+		cid := bloomTestContentID(tb, first+i)
+		b[cid] = Info{ContentID: cid, PackBlobID: blob.ID(fmt.Sprintf("p%v", first)), PackOffset: uint32(i)}
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(tb, b.Build(&buf, Version2))
+
+	ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(tb, err)
+
+	return ndx
+}
+
+func TestBloomFilter(t *testing.T) {
+	for _, n := range []int{1, 100, 10000, 200000} {
+		f := NewBloomFilter(n)
+
+		for i := range n {
+			f.Add(bloomTestContentID(t, i))
+		}
+
+		// no false negatives.
+		for i := range n {
+			require.True(t, f.MightContain(bloomTestContentID(t, i)))
+		}
+
+		const probes = 10000
+
+		falsePositives := 0
+
+		for i := range probes {
+			if f.MightContain(bloomTestContentID(t, n+i)) {
+				falsePositives++
+			}
+		}
+
+		// compare with the theoretical false positive rate, leaving some margin for randomness.
+		k := float64(f.hashCount)
+		expected := math.Pow(1-math.Exp(-k*float64(n)/float64(len(f.bits)*64)), k)
+
+		require.Less(t, float64(falsePositives)/probes, 1.5*expected+0.005, "too many false positives for %v entries", n)
+	}
+}
+
+func TestWithBloomFilter(t *testing.T) {
+	small := bloomTestIndex(t, 0, bloomFilterMinEntries-1)
+	require.Equal(t, small, WithBloomFilter(small))
+
+	const count = 10000
+
+	ndx := WithBloomFilter(bloomTestIndex(t, 0, count))
+	require.Equal(t, ndx, WithBloomFilter(ndx))
+
+	bfi, ok := ndx.(*bloomFilteredIndex)
+	require.True(t, ok)
+
+	var info Info
+
+	// lookups work before and after the filter has been built.
+	for i := range 2 * count / bloomFilterBuildLookupDivisor {
+		found, err := ndx.GetInfo(bloomTestContentID(t, i), &info)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, uint32(i), info.PackOffset)
+
+		found, err = ndx.GetInfo(bloomTestContentID(t, count+i), &info)
+		require.NoError(t, err)
+		require.False(t, found)
+	}
+
+	require.NotNil(t, bfi.filter.Load())
+
+	m := Merged{bloomTestIndex(t, 0, 10), ndx}
+	require.True(t, m.MightContain(bloomTestContentID(t, 5)))
+	require.True(t, m.MightContain(bloomTestContentID(t, count-1)))
+}
+
+func BenchmarkMergedGetInfoMiss(b *testing.B) {
+	const (
+		indexCount        = 1000
+		entriesPerIndex   = 1000
+		lookupIDsPerRound = 1000
+	)
+
+	var raw Merged
+
+	for i := range indexCount {
+		raw = append(raw, bloomTestIndex(b, i*entriesPerIndex, entriesPerIndex))
+	}
+
+	var filtered Merged
+
+	for _, ndx := range raw {
+		bfi := WithBloomFilter(ndx).(*bloomFilteredIndex) //nolint:forcetypeassert
+		bfi.buildOnce.Do(bfi.buildFilter)
+		filtered = append(filtered, bfi)
+	}
+
+	var missing []ID
+
+	for i := range lookupIDsPerRound {
+		missing = append(missing, bloomTestContentID(b, indexCount*entriesPerIndex+i))
+	}
+
+	for _, tc := range []struct {
+		name string
+		m    Merged
+	}{
+		{"no-filter", raw},
+		{"bloom-filter", filtered},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			var info Info
+
+			for i := range b.N {
+				if found, _ := tc.m.GetInfo(missing[i%len(missing)], &info); found {
+					b.Fatal("unexpected content found")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package index
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Serialized indexes end with a checksum trailer consisting of indexChecksumMagic followed by
+// the truncated SHA-256 of all preceding bytes. The trailer is written after the index payload
+// and before the optional random suffix, so readers which don't know about it ignore it.
+const (
+	indexChecksumMagic       = "~kidxcs~"
+	indexChecksumSize        = 8
+	indexChecksumTrailerSize = len(indexChecksumMagic) + indexChecksumSize
+)
+
+// ErrChecksumNotAvailable is returned by VerifyChecksum() for indexes written without a checksum.
+var ErrChecksumNotAvailable = errors.New("index checksum not available")
+
+// checksumWriter computes the checksum of the index payload written through it.
+type checksumWriter struct {
+	io.Writer
+	h io.Writer
+}
+
+func (w checksumWriter) Write(p []byte) (int, error) {
+	w.h.Write(p) //nolint:errcheck
+
+	//nolint:wrapcheck
+	return w.Writer.Write(p)
+}
+
+// buildWithChecksum invokes the provided build function and writes the checksum trailer
+// covering everything it has written.
+func buildWithChecksum(output io.Writer, build func(w io.Writer) error) error {
+	h := sha256.New()
+
+	if err := build(checksumWriter{output, h}); err != nil {
+		return err
+	}
+
+	trailer := append([]byte(indexChecksumMagic), h.Sum(nil)[:indexChecksumSize]...)
+
+	if _, err := output.Write(trailer); err != nil {
+		return errors.Wrap(err, "error writing index checksum")
+	}
+
+	return nil
+}
+
+// verifyChecksum verifies the checksum trailer of serialized index data, which may be followed by the random suffix.
+// Indexes written by older versions or truncated ones, including those whose trailer itself is lost or damaged,
+// return ErrChecksumNotAvailable.
+func verifyChecksum(data []byte) error {
+	for _, suffixLength := range []int{0, randomSuffixSize} {
+		end := len(data) - suffixLength
+		if end < indexChecksumTrailerSize {
+			continue
+		}
+
+		trailer := data[end-indexChecksumTrailerSize : end]
+		if string(trailer[:len(indexChecksumMagic)]) != indexChecksumMagic {
+			continue
+		}
+
+		sum := sha256.Sum256(data[:end-indexChecksumTrailerSize])
+		if !bytes.Equal(sum[:indexChecksumSize], trailer[len(indexChecksumMagic):]) {
+			return errors.Errorf("index checksum mismatch")
+		}
+
+		return nil
+	}
+
+	return ErrChecksumNotAvailable
+}
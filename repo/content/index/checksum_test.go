@@ -0,0 +1,100 @@
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	b := Builder{}
+
+	for i := range 100 {
+		b.Add(Info{ContentID: deterministicContentID(t, "checksum", i), PackBlobID: deterministicPackBlobID(i % 7), PackOffset: uint32(i)})
+	}
+
+	for _, version := range []int{Version1, Version2} {
+		var stable, unique bytes.Buffer
+
+		require.NoError(t, b.BuildStable(&stable, version))
+		require.NoError(t, b.Build(&unique, version))
+
+		shards, closeShards, err := b.BuildShards(version, DefaultOrdering, false, 30)
+		require.NoError(t, err)
+
+		defer closeShards()
+
+		all := [][]byte{stable.Bytes(), unique.Bytes()}
+		for _, s := range shards {
+			all = append(all, s.ToByteSlice())
+		}
+
+		for _, data := range all {
+			ndx, err := Open(data, nil, func() int { return fakeEncryptionOverhead })
+			require.NoError(t, err)
+			require.NoError(t, ndx.VerifyChecksum())
+		}
+
+		// corrupt each byte of the payload and the checksum in turn.
+		data := stable.Bytes()
+
+		for p := range data[:len(data)-indexChecksumTrailerSize] {
+			corrupted := bytes.Clone(data)
+			corrupted[p] ^= 1
+
+			require.Error(t, verifyChecksum(corrupted), "corruption at %v not detected", p)
+		}
+
+		corrupted := bytes.Clone(unique.Bytes())
+		corrupted[len(corrupted)-randomSuffixSize-1] ^= 0x80
+		require.Error(t, verifyChecksum(corrupted))
+		require.NotErrorIs(t, verifyChecksum(corrupted), ErrChecksumNotAvailable)
+
+		// random suffix is not covered by the checksum.
+		corrupted = bytes.Clone(unique.Bytes())
+		corrupted[len(corrupted)-1] ^= 1
+		require.NoError(t, verifyChecksum(corrupted))
+	}
+}
+
+func TestVerifyChecksumNotAvailable(t *testing.T) {
+	b := Builder{}
+	b.Add(Info{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "xx"})
+
+	// indexes written before checksums were introduced, with and without the random suffix.
+	var legacy bytes.Buffer
+
+	require.NoError(t, b.buildV2(&legacy, DefaultOrdering))
+
+	legacyStable := bytes.Clone(legacy.Bytes())
+	legacy.Write(bytes.Repeat([]byte{0xaa}, randomSuffixSize))
+
+	var v1 bytes.Buffer
+
+	require.NoError(t, b.buildV1(&v1))
+
+	for _, data := range [][]byte{legacyStable, legacy.Bytes(), v1.Bytes()} {
+		ndx, err := Open(data, nil, func() int { return fakeEncryptionOverhead })
+		require.NoError(t, err)
+
+		var info Info
+
+		ok, err := ndx.GetInfo(mustParseID(t, "aabbcc"), &info)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.ErrorIs(t, ndx.VerifyChecksum(), ErrChecksumNotAvailable)
+	}
+
+	require.ErrorIs(t, verifyChecksum(nil), ErrChecksumNotAvailable)
+
+	withChecksum := orderedIndexWithItems(t, DefaultOrdering, Info{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "yy"})
+
+	legacyNdx, err := Open(legacyStable, nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	require.NoError(t, Merged{withChecksum}.VerifyChecksum())
+	require.ErrorIs(t, Merged{withChecksum, legacyNdx}.VerifyChecksum(), ErrChecksumNotAvailable)
+	require.ErrorIs(t, WithBloomFilter(legacyNdx).VerifyChecksum(), ErrChecksumNotAvailable)
+}
@@ -0,0 +1,60 @@
+package index
+
+import "github.com/pkg/errors"
+
+// countEntries implements Count() and CountLive() for indexes which don't know the number of their entries
+// by visiting all of them.
+func countEntries(ndx Index, includeDeleted bool) (int, error) {
+	cnt := 0
+
+	if err := ndx.Iterate(AllIDs, func(i Info) error {
+		if includeDeleted || !i.Deleted {
+			cnt++
+		}
+
+		return nil
+	}); err != nil {
+		return 0, errors.Wrap(err, "error counting index entries")
+	}
+
+	return cnt, nil
+}
+
+// IsEmpty returns true if the index has no entries, including deleted ones.
+func IsEmpty(ndx Index) (bool, error) {
+	cnt, err := ndx.Count()
+
+	return cnt == 0, err
+}
+
+// Stats contains aggregate statistics about index entries.
+type Stats struct {
+	LiveCount    int
+	DeletedCount int
+
+	// PackedBytes is the total packed length of all entries, LivePackedBytes only includes entries which are not deleted.
+	PackedBytes     int64
+	LivePackedBytes int64
+}
+
+// ComputeStats computes aggregate statistics about the provided index in a single pass over its entries.
+func ComputeStats(ndx Index) (Stats, error) {
+	var s Stats
+
+	if err := ndx.Iterate(AllIDs, func(i Info) error {
+		s.PackedBytes += int64(i.PackedLength)
+
+		if i.Deleted {
+			s.DeletedCount++
+		} else {
+			s.LiveCount++
+			s.LivePackedBytes += int64(i.PackedLength)
+		}
+
+		return nil
+	}); err != nil {
+		return Stats{}, errors.Wrap(err, "error computing index stats")
+	}
+
+	return s, nil
+}
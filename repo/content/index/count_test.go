@@ -0,0 +1,98 @@
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount(t *testing.T) {
+	live := Info{ContentID: mustParseID(t, "aabbcc"), TimestampSeconds: 1, PackBlobID: "xx"}
+	deleted1 := Info{ContentID: mustParseID(t, "ddeeff"), TimestampSeconds: 1, PackBlobID: "xx", Deleted: true}
+	deleted2 := Info{ContentID: mustParseID(t, "k010203"), TimestampSeconds: 1, PackBlobID: "xx", Deleted: true}
+
+	v1 := make(Builder)
+	v1.Add(deleted1)
+	v1.Add(deleted2)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, v1.Build(&buf, Version1))
+
+	v1ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	// newer deletion of the live content hides it in the merged index.
+	deletedLater := live
+	deletedLater.TimestampSeconds = 2
+	deletedLater.Deleted = true
+
+	cases := []struct {
+		name      string
+		ndx       Index
+		wantCount int
+		wantLive  int
+	}{
+		{"v1-all-deleted", v1ndx, 2, 0},
+		{"v2-all-deleted", orderedIndexWithItems(t, DefaultOrdering, deleted1, deleted2), 2, 0},
+		{"reverse-all-deleted", orderedIndexWithItems(t, reverseOrdering, deleted1, deleted2), 2, 0},
+		{"v2-mixed", orderedIndexWithItems(t, DefaultOrdering, live, deleted1), 2, 1},
+		{"bloom-all-deleted", WithBloomFilter(orderedIndexWithItems(t, DefaultOrdering, deleted1, deleted2)), 2, 0},
+		{"merged-empty", Merged{}, 0, 0},
+		{"merged-single", Merged{orderedIndexWithItems(t, DefaultOrdering, live, deleted1)}, 2, 1},
+		{"merged-duplicates", Merged{
+			orderedIndexWithItems(t, DefaultOrdering, live, deleted1),
+			orderedIndexWithItems(t, DefaultOrdering, live, deleted2),
+		}, 3, 1},
+		{"merged-all-deleted", Merged{
+			orderedIndexWithItems(t, DefaultOrdering, live, deleted1),
+			orderedIndexWithItems(t, DefaultOrdering, deletedLater, deleted2),
+		}, 3, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cnt, err := tc.ndx.Count()
+			require.NoError(t, err)
+			require.Equal(t, tc.wantCount, cnt)
+
+			cnt, err = tc.ndx.CountLive()
+			require.NoError(t, err)
+			require.Equal(t, tc.wantLive, cnt)
+
+			empty, err := IsEmpty(tc.ndx)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantCount == 0, empty)
+		})
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	items := []Info{
+		{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "p1", PackedLength: 100, TimestampSeconds: 1},
+		{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "p1", PackedLength: 200, TimestampSeconds: 1},
+		{ContentID: mustParseID(t, "k010203"), PackBlobID: "p2", PackedLength: 50, TimestampSeconds: 1, Deleted: true},
+		{ContentID: mustParseID(t, "k020304"), PackBlobID: "p2", PackedLength: 7, TimestampSeconds: 1},
+	}
+
+	s, err := ComputeStats(orderedIndexWithItems(t, DefaultOrdering, items...))
+	require.NoError(t, err)
+	require.Equal(t, Stats{LiveCount: 3, DeletedCount: 1, PackedBytes: 357, LivePackedBytes: 307}, s)
+
+	// contents present in multiple indexes are counted once, using the newest entry.
+	newer := items[1]
+	newer.TimestampSeconds = 2
+	newer.Deleted = true
+
+	s, err = ComputeStats(Merged{
+		orderedIndexWithItems(t, DefaultOrdering, items...),
+		orderedIndexWithItems(t, DefaultOrdering, newer),
+	})
+	require.NoError(t, err)
+	require.Equal(t, Stats{LiveCount: 2, DeletedCount: 2, PackedBytes: 357, LivePackedBytes: 107}, s)
+
+	s, err = ComputeStats(Merged{})
+	require.NoError(t, err)
+	require.Equal(t, Stats{}, s)
+}
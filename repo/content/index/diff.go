@@ -0,0 +1,133 @@
+package index
+
+import (
+	"github.com/pkg/errors"
+)
+
+// IndexDiff describes differences between two indexes, with content IDs in ascending order.
+type IndexDiff struct {
+	OnlyInA  []Info         `json:"onlyInA,omitempty"`
+	OnlyInB  []Info         `json:"onlyInB,omitempty"`
+	Modified []ModifiedInfo `json:"modified,omitempty"`
+}
+
+// ModifiedInfo describes a content present in both indexes with different information.
+type ModifiedInfo struct {
+	A Info `json:"a"`
+	B Info `json:"b"`
+}
+
+// IsEmpty returns true if the indexes are equivalent.
+func (d *IndexDiff) IsEmpty() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 && len(d.Modified) == 0
+}
+
+// Diff compares entries of two indexes regardless of their orderings and formats and returns contents
+// present in only one of them and contents whose information, including location, lengths, timestamp
+// and deletion status, is different.
+//
+// Both indexes are streamed in ascending content ID order and merge-joined, so neither of them is
+// loaded into memory, except for indexes using non-default orderings which IterateSorted() must sort.
+func Diff(a, b Index) (*IndexDiff, error) {
+	sa := streamSorted(a)
+
+	d := &IndexDiff{}
+
+	if err := IterateSorted(b, func(ib Info) error {
+		for sa.ok && sa.cur.ContentID.compare(ib.ContentID) < 0 {
+			d.OnlyInA = append(d.OnlyInA, sa.cur)
+			sa.next()
+		}
+
+		if sa.ok && sa.cur.ContentID == ib.ContentID {
+			if sa.cur != ib {
+				d.Modified = append(d.Modified, ModifiedInfo{sa.cur, ib})
+			}
+
+			sa.next()
+
+			return nil
+		}
+
+		d.OnlyInB = append(d.OnlyInB, ib)
+
+		return nil
+	}); err != nil {
+		sa.stop() //nolint:errcheck
+
+		return nil, errors.Wrap(err, "error iterating second index")
+	}
+
+	for sa.ok {
+		d.OnlyInA = append(d.OnlyInA, sa.cur)
+		sa.next()
+	}
+
+	if err := sa.stop(); err != nil {
+		return nil, errors.Wrap(err, "error iterating first index")
+	}
+
+	return d, nil
+}
+
+const sortedStreamBufferSize = 256
+
+var errStreamStopped = errors.New("stream stopped")
+
+// sortedStream pulls entries of an index in ascending content ID order from a goroutine running
+// IterateSorted(), which allows merge-joining it with another index iterated by the caller.
+type sortedStream struct {
+	entries chan Info
+	done    chan struct{}
+
+	// err is the result of IterateSorted(), only valid after entries has been closed.
+	err error
+
+	cur Info
+	ok  bool
+}
+
+// streamSorted starts streaming entries of the provided index, the first entry is available in cur.
+func streamSorted(ndx Index) *sortedStream {
+	s := &sortedStream{
+		entries: make(chan Info, sortedStreamBufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.entries)
+
+		s.err = IterateSorted(ndx, func(i Info) error {
+			select {
+			case s.entries <- i:
+				return nil
+			case <-s.done:
+				return errStreamStopped
+			}
+		})
+	}()
+
+	s.next()
+
+	return s
+}
+
+// next advances to the next entry, ok is false when there are no more entries.
+func (s *sortedStream) next() {
+	s.cur, s.ok = <-s.entries
+}
+
+// stop stops streaming and returns the error encountered when iterating the index, if any.
+func (s *sortedStream) stop() error {
+	close(s.done)
+
+	for range s.entries {
+		// drain remaining entries until the goroutine exits.
+	}
+
+	if errors.Is(s.err, errStreamStopped) {
+		return nil
+	}
+
+	return s.err
+}
@@ -0,0 +1,86 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	var items []Info
+
+	for i := range 20 {
+		items = append(items, Info{ContentID: deterministicContentID(t, "diff", i), TimestampSeconds: 1000, PackBlobID: deterministicPackBlobID(i % 3), PackOffset: uint32(i * 100), PackedLength: 100})
+	}
+
+	base := orderedIndexWithItems(t, DefaultOrdering, items...)
+
+	// equal indexes, regardless of ordering and layout.
+	for name, other := range map[string]Index{
+		"same":    base,
+		"reverse": orderedIndexWithItems(t, reverseOrdering, items...),
+		"merged":  Merged{orderedIndexWithItems(t, DefaultOrdering, items[:7]...), orderedIndexWithItems(t, DefaultOrdering, items[7:]...)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d, err := Diff(base, other)
+			require.NoError(t, err)
+			require.True(t, d.IsEmpty())
+			require.Equal(t, &IndexDiff{}, d)
+		})
+	}
+
+	// remove first and last entry, add a new one and modify some.
+	modified := append([]Info(nil), items[1:len(items)-1]...)
+
+	added := Info{ContentID: deterministicContentID(t, "diff", 100), TimestampSeconds: 1000, PackBlobID: "new"}
+	modified = append(modified, added)
+
+	changes := map[int]func(i *Info){
+		3:  func(i *Info) { i.PackBlobID = "other" },
+		5:  func(i *Info) { i.PackOffset++ },
+		7:  func(i *Info) { i.PackedLength++ },
+		9:  func(i *Info) { i.Deleted = true },
+		11: func(i *Info) { i.TimestampSeconds++ },
+	}
+
+	wantModified := map[ID]ModifiedInfo{}
+
+	for n, change := range changes {
+		// items[n] is at position n-1 after removal of the first one.
+		change(&modified[n-1])
+		wantModified[items[n].ContentID] = ModifiedInfo{items[n], modified[n-1]}
+	}
+
+	other := orderedIndexWithItems(t, reverseOrdering, modified...)
+
+	d, err := Diff(base, other)
+	require.NoError(t, err)
+	require.False(t, d.IsEmpty())
+	require.ElementsMatch(t, []Info{items[0], items[len(items)-1]}, d.OnlyInA)
+	require.Equal(t, []Info{added}, d.OnlyInB)
+	require.Len(t, d.Modified, len(changes))
+
+	for i, m := range d.Modified {
+		require.Equal(t, wantModified[m.A.ContentID], m)
+
+		if i > 0 {
+			require.Negative(t, d.Modified[i-1].A.ContentID.compare(m.A.ContentID))
+		}
+	}
+
+	// swapping arguments swaps the result.
+	rev, err := Diff(other, base)
+	require.NoError(t, err)
+	require.Equal(t, d.OnlyInA, rev.OnlyInB)
+	require.Equal(t, d.OnlyInB, rev.OnlyInA)
+
+	for i, m := range rev.Modified {
+		require.Equal(t, ModifiedInfo{d.Modified[i].B, d.Modified[i].A}, m)
+	}
+
+	// diff against an empty index.
+	d, err = Diff(base, Merged{})
+	require.NoError(t, err)
+	require.Len(t, d.OnlyInA, len(items))
+	require.Empty(t, d.OnlyInB)
+}
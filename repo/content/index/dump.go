@@ -0,0 +1,35 @@
+package index
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// DumpHeader is the first object written by DumpJSON().
+type DumpHeader struct {
+	Ordering string `json:"ordering"`
+	Count    int    `json:"count"`
+}
+
+// DumpJSON writes the contents of the index as a stream of JSON objects, one per line, for diagnostics.
+// The first object is a DumpHeader, followed by one Info for each entry in the order of the index.
+// Entries are written as they are visited, so the index is never loaded into memory.
+func DumpJSON(ndx Index, w io.Writer) error {
+	cnt, err := ndx.Count()
+	if err != nil {
+		return errors.Wrap(err, "unable to count index entries")
+	}
+
+	enc := json.NewEncoder(w)
+
+	if err := enc.Encode(DumpHeader{Ordering: ndx.Ordering().Name, Count: cnt}); err != nil {
+		return errors.Wrap(err, "error writing index header")
+	}
+
+	//nolint:wrapcheck
+	return ndx.Iterate(AllIDs, func(i Info) error {
+		return errors.Wrapf(enc.Encode(i), "error writing index entry %v", i.ContentID)
+	})
+}
@@ -0,0 +1,56 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpJSON(t *testing.T) {
+	items := []Info{
+		{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "p1", PackOffset: 10, PackedLength: 100, OriginalLength: 200, TimestampSeconds: 1000, FormatVersion: 2, CompressionHeaderID: 0x1100},
+		{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "p1", PackOffset: 110, PackedLength: 50, OriginalLength: 50, TimestampSeconds: 1001, FormatVersion: 2},
+		{ContentID: mustParseID(t, "k010203"), PackBlobID: "q2", PackOffset: 0, PackedLength: 7, OriginalLength: 7, TimestampSeconds: 1002, FormatVersion: 2, Deleted: true},
+	}
+
+	for _, o := range []*Ordering{DefaultOrdering, reverseOrdering} {
+		t.Run(o.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, DumpJSON(orderedIndexWithItems(t, o, items...), &buf))
+
+			// one object per line.
+			require.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), len(items)+1)
+
+			dec := json.NewDecoder(&buf)
+			dec.DisallowUnknownFields()
+
+			var hdr DumpHeader
+
+			require.NoError(t, dec.Decode(&hdr))
+			require.Equal(t, DumpHeader{Ordering: o.Name, Count: len(items)}, hdr)
+
+			var got []Info
+
+			for {
+				var i Info
+
+				err := dec.Decode(&i)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+
+				got = append(got, i)
+			}
+
+			require.ElementsMatch(t, items, got)
+		})
+	}
+}
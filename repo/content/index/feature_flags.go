@@ -0,0 +1,49 @@
+package index
+
+import (
+	"github.com/pkg/errors"
+)
+
+// FeatureFlags is a bitmask of features used by an index entry, which allows future writers to change
+// how contents are encoded without older readers silently misinterpreting them.
+//
+// Flags in the RequiredFeatureFlags range change how the content must be interpreted, so readers must
+// reject entries that use required flags they don't understand. Flags in the OptionalFeatureFlags range
+// are hints that can be safely ignored by readers that don't understand them.
+//
+// Pre-existing per-entry data is understood by all readers that support index v2 and is therefore
+// outside of this bitmask:
+//
+//   - the deleted marker (required) - stored in the pack offset bits of each entry,
+//   - custom ordering (required) - recorded in the index header and rejected by readers that don't know the ordering ID,
+//   - format ID, extended pack ID and high length bits (required) - signaled by the entry size of the index.
+//
+// There are currently no optional features.
+type FeatureFlags byte
+
+const (
+	// RequiredFeatureFlags is the range of feature flags that readers must understand.
+	RequiredFeatureFlags FeatureFlags = 0xF0
+
+	// OptionalFeatureFlags is the range of feature flags that readers may ignore.
+	OptionalFeatureFlags FeatureFlags = 0x0F
+
+	// SupportedRequiredFeatureFlags is the set of required feature flags understood by this version.
+	SupportedRequiredFeatureFlags FeatureFlags = 0
+)
+
+// ErrUnsupportedFeature is returned when an index entry uses a required feature that is not supported.
+var ErrUnsupportedFeature = errors.New("unsupported index entry feature")
+
+// Unsupported returns the required feature flags which aren't understood by this version.
+func (f FeatureFlags) Unsupported() FeatureFlags {
+	return f & RequiredFeatureFlags &^ SupportedRequiredFeatureFlags
+}
+
+func validateFeatureFlags(contentID ID, f FeatureFlags) error {
+	if u := f.Unsupported(); u != 0 {
+		return errors.Wrapf(ErrUnsupportedFeature, "content %v uses feature flags %#x, possibly written by a newer version of Kopia", contentID, byte(u))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func buildIndexWithFeatureFlags(t *testing.T, flags FeatureFlags) []byte {
+	t.Helper()
+
+	b := Builder{}
+	b.Add(Info{ContentID: mustParseID(t, "aabbcc"), TimestampSeconds: 1, PackBlobID: "xx", PackOffset: 1})
+	b.Add(Info{ContentID: mustParseID(t, "ddeeff"), TimestampSeconds: 1, PackBlobID: "xx", PackOffset: 2, FeatureFlags: flags})
+
+	var buf bytes.Buffer
+
+	require.NoError(t, b.Build(&buf, Version2))
+
+	return buf.Bytes()
+}
+
+func TestFeatureFlags_NotStoredWhenUnused(t *testing.T) {
+	data := buildIndexWithFeatureFlags(t, 0)
+
+	require.EqualValues(t, v2EntryMinLength, binary.BigEndian.Uint16(data[2:4]))
+}
+
+func TestFeatureFlags_UnknownOptionalFlagIgnored(t *testing.T) {
+	flags := FeatureFlags(0x04)
+	require.Equal(t, flags, flags&OptionalFeatureFlags)
+
+	data := buildIndexWithFeatureFlags(t, flags)
+
+	require.EqualValues(t, v2EntryMaxLength, binary.BigEndian.Uint16(data[2:4]))
+
+	ndx, err := Open(data, nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	var info Info
+
+	ok, err := ndx.GetInfo(mustParseID(t, "ddeeff"), &info)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, flags, info.FeatureFlags)
+	require.EqualValues(t, 2, info.PackOffset)
+
+	ok, err = ndx.GetInfo(mustParseID(t, "aabbcc"), &info)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, FeatureFlags(0), info.FeatureFlags)
+
+	require.NoError(t, ndx.Iterate(AllIDs, func(Info) error { return nil }))
+}
+
+func TestFeatureFlags_UnknownRequiredFlagRejected(t *testing.T) {
+	flags := FeatureFlags(0x40)
+	require.Equal(t, flags, flags.Unsupported())
+
+	data := buildIndexWithFeatureFlags(t, flags)
+
+	ndx, err := Open(data, nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	var info Info
+
+	_, err = ndx.GetInfo(mustParseID(t, "ddeeff"), &info)
+	require.ErrorIs(t, err, ErrUnsupportedFeature)
+
+	// entries not using the feature can still be read.
+	ok, err := ndx.GetInfo(mustParseID(t, "aabbcc"), &info)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	err = ndx.Iterate(AllIDs, func(Info) error { return nil })
+	require.ErrorIs(t, err, ErrUnsupportedFeature)
+}
+
+func TestFeatureFlags_NotSupportedInV1(t *testing.T) {
+	b := Builder{}
+	b.Add(Info{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "xx", FeatureFlags: 0x01})
+
+	var buf bytes.Buffer
+
+	require.ErrorContains(t, b.Build(&buf, Version1), "feature flags not supported")
+}
@@ -0,0 +1,64 @@
+package index
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// getInfos implements GetInfos() for any index by walking its entries once, in the index ordering,
+// and matching them against a sorted copy of the provided content IDs.
+func getInfos(ndx Index, contentIDs []ID) (map[ID]Info, []ID, error) {
+	ordering := ndx.Ordering()
+
+	sorted := slices.Clone(contentIDs)
+	slices.SortFunc(sorted, ordering.compare)
+	sorted = slices.Compact(sorted)
+
+	found := map[ID]Info{}
+
+	if len(sorted) == 0 {
+		return found, nil, nil
+	}
+
+	r := AllIDs
+	if ordering.IsDefault() {
+		// with the default ordering entries are sorted by ID, so only the range between
+		// the first and last requested ID needs to be visited.
+		r = IDRange{
+			StartID: IDPrefix(sorted[0].String()),
+			EndID:   IDPrefix(sorted[len(sorted)-1].String() + "\x00"),
+		}
+	}
+
+	var missing []ID
+
+	next := 0
+
+	if err := ndx.Iterate(r, func(i Info) error {
+		for next < len(sorted) && ordering.compare(sorted[next], i.ContentID) < 0 {
+			missing = append(missing, sorted[next])
+			next++
+		}
+
+		if next < len(sorted) && sorted[next] == i.ContentID {
+			found[i.ContentID] = i
+			next++
+		}
+
+		if next >= len(sorted) {
+			return errStopIteration
+		}
+
+		return nil
+	}); err != nil && !errors.Is(err, errStopIteration) {
+		return nil, nil, errors.Wrap(err, "error iterating index")
+	}
+
+	missing = append(missing, sorted[next:]...)
+
+	return found, missing, nil
+}
+
+// errStopIteration is returned from Iterate() callbacks to stop once all requested entries have been seen.
+var errStopIteration = errors.New("stop iteration")
@@ -0,0 +1,111 @@
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInfos(t *testing.T) {
+	var items []Info
+
+	for i, id := range []string{"aabbcc", "ddeeff", "k010203", "k020304", "z010203"} {
+		items = append(items, Info{ContentID: mustParseID(t, id), TimestampSeconds: 1, PackBlobID: "xx", PackOffset: uint32(i)})
+	}
+
+	v1 := make(Builder)
+	for _, it := range items {
+		v1.Add(it)
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, v1.Build(&buf, Version1))
+
+	v1ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	cases := map[string]Index{
+		"v1":      v1ndx,
+		"v2":      orderedIndexWithItems(t, DefaultOrdering, items...),
+		"reverse": orderedIndexWithItems(t, reverseOrdering, items...),
+		"merged":  Merged{orderedIndexWithItems(t, DefaultOrdering, items[:2]...), orderedIndexWithItems(t, DefaultOrdering, items[2:]...)},
+	}
+
+	for name, ndx := range cases {
+		t.Run(name, func(t *testing.T) {
+			found, missing, err := ndx.GetInfos(nil)
+			require.NoError(t, err)
+			require.Empty(t, found)
+			require.Empty(t, missing)
+
+			found, missing, err = ndx.GetInfos(parseIDs(t, "a0", "k030405", "a0", "zffff"))
+			require.NoError(t, err)
+			require.Empty(t, found)
+			require.ElementsMatch(t, parseIDs(t, "a0", "k030405", "zffff"), missing)
+
+			found, missing, err = ndx.GetInfos(parseIDs(t, "z010203", "aabbcc", "k030405", "aabbcc", "ff", "k010203"))
+			require.NoError(t, err)
+			require.ElementsMatch(t, parseIDs(t, "k030405", "ff"), missing)
+			require.Len(t, found, 3)
+
+			for id, info := range found {
+				var want Info
+
+				ok, err := ndx.GetInfo(id, &want)
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, want, info)
+			}
+		})
+	}
+}
+
+func TestGetInfosMergedPrefersNewest(t *testing.T) {
+	id := mustParseID(t, "aabbcc")
+
+	m := Merged{
+		orderedIndexWithItems(t, DefaultOrdering, Info{ContentID: id, TimestampSeconds: 1, PackBlobID: "old"}),
+		orderedIndexWithItems(t, DefaultOrdering, Info{ContentID: id, TimestampSeconds: 2, PackBlobID: "new"}),
+	}
+
+	found, missing, err := m.GetInfos([]ID{id})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+	require.Equal(t, "new", string(found[id].PackBlobID))
+}
+
+func TestGetInfosWithBloomFilter(t *testing.T) {
+	ndx := WithBloomFilter(bloomTestIndex(t, 0, 1000))
+
+	var ids []ID
+
+	for i := 500; i < 1500; i += 7 {
+		ids = append(ids, bloomTestContentID(t, i))
+	}
+
+	// query enough times to build the bloom filter.
+	for range 3 {
+		found, missing, err := ndx.GetInfos(ids)
+		require.NoError(t, err)
+		require.Len(t, found, 72)
+		require.Len(t, missing, len(ids)-72)
+
+		for _, id := range missing {
+			require.NotContains(t, found, id)
+		}
+	}
+}
+
+func parseIDs(t *testing.T, ids ...string) []ID {
+	t.Helper()
+
+	var result []ID
+
+	for _, id := range ids {
+		result = append(result, mustParseID(t, id))
+	}
+
+	return result
+}
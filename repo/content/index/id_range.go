@@ -1,5 +1,7 @@
 package index
 
+import "time"
+
 // IDRange represents a range of IDs.
 type IDRange struct {
 	StartID IDPrefix // inclusive
@@ -18,6 +20,31 @@ func PrefixRange(prefix IDPrefix) IDRange {
 	return IDRange{prefix, prefix + maxIDCharacterPlus1}
 }
 
+// IteratePrefix invokes the provided callback for all entries of the index whose IDs start with the provided prefix,
+// in the order of the index. With the default ordering, the first matching entry is found using binary search and
+// iteration stops at the first entry that does not match, otherwise all entries are visited and filtered.
+// An empty prefix matches all entries, the callback is not invoked if no entries match.
+func IteratePrefix(ndx Index, prefix IDPrefix, cb func(Info) error) error {
+	//nolint:wrapcheck
+	return ndx.Iterate(PrefixRange(prefix), cb)
+}
+
+// IterateCreatedBetween invokes the provided callback for all entries of the index whose timestamp is in the
+// half-open interval [start, end), in the order of the index. Zero end time means no upper bound.
+// Timestamps are stored with one-second precision, so entries are compared using their Timestamp().
+func IterateCreatedBetween(ndx Index, start, end time.Time, cb func(Info) error) error {
+	//nolint:wrapcheck
+	return ndx.Iterate(AllIDs, func(i Info) error {
+		ts := i.Timestamp()
+
+		if ts.Before(start) || (!end.IsZero() && !ts.Before(end)) {
+			return nil
+		}
+
+		return cb(i)
+	})
+}
+
 // AllIDs is an IDRange that contains all valid IDs.
 //
 //nolint:gochecknoglobals
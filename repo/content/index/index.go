@@ -2,28 +2,174 @@
 package index
 
 import (
+	"context"
 	"io"
+	"slices"
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/hashing"
 )
 
 const (
 	maxContentIDSize = hashing.MaxHashSize + 1
 	unknownKeySize   = 255
+
+	// iterateContextCheckInterval is the number of entries visited by IterateContext() between checks
+	// for context cancellation.
+	iterateContextCheckInterval = 4096
 )
 
 // Index is a read-only index of packed contents.
 type Index interface {
 	io.Closer
 	ApproximateCount() int
+
+	// Count returns the number of entries in the index, including deleted ones. It takes constant time
+	// for individual indexes, but Merged must visit all entries to avoid counting duplicates.
+	Count() (int, error)
+
+	// CountLive returns the number of entries in the index which are not deleted, which always
+	// requires visiting all entries.
+	CountLive() (int, error)
+
 	GetInfo(contentID ID, result *Info) (bool, error)
 
-	// invoked the provided callback for all entries such that entry.ID >= startID and entry.ID < endID
+	// MightContain returns false if the index definitely does not contain the provided content,
+	// so that callers can skip it. GetInfo() must be used to find out if it does.
+	MightContain(contentID ID) bool
+
+	// Contains returns whether the index has an entry for the provided content and whether it is deleted,
+	// without decoding the rest of the entry.
+	Contains(contentID ID) (found, deleted bool, err error)
+
+	// GetInfos returns information about all provided contents found in the index, and the IDs
+	// of contents not present in it. The order of provided IDs does not matter and duplicates are ignored.
+	GetInfos(contentIDs []ID) (map[ID]Info, []ID, error)
+
+	// VerifyChecksum verifies the checksum of the serialized index, which detects corruption before any lookups
+	// are trusted. ErrChecksumNotAvailable is returned for indexes written without a checksum.
+	VerifyChecksum() error
+
+	// Ordering returns the ordering of entries in the index.
+	Ordering() *Ordering
+
+	// invoked the provided callback for all entries such that entry.ID >= startID and entry.ID < endID,
+	// in the order of the index, which is ascending content ID order only when Ordering().IsDefault().
+	// Use IterateSorted() when ascending order is required regardless of the ordering.
 	Iterate(r IDRange, cb func(Info) error) error
 }
 
+// IterateSorted invokes the provided callback for all entries of the index in ascending content ID order,
+// which allows linear merge-joins between indexes. Indexes using the default ordering are iterated
+// directly, entries of indexes using other orderings are loaded into memory and sorted first.
+func IterateSorted(ndx Index, cb func(Info) error) error {
+	if ndx.Ordering().IsDefault() {
+		//nolint:wrapcheck
+		return ndx.Iterate(AllIDs, cb)
+	}
+
+	var entries []Info
+
+	if err := ndx.Iterate(AllIDs, func(i Info) error {
+		entries = append(entries, i)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error iterating index")
+	}
+
+	slices.SortFunc(entries, func(a, b Info) int {
+		return a.ContentID.compare(b.ContentID)
+	})
+
+	for _, e := range entries {
+		if err := cb(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IterateContext invokes the provided callback for all entries of the index in the provided range, like Iterate(),
+// but stops when the context is canceled, checking it every few thousand entries. The context error is returned
+// in that case, errors returned by the callback are returned unchanged.
+func IterateContext(ctx context.Context, ndx Index, r IDRange, cb func(Info) error) error {
+	if err := ctx.Err(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var (
+		visited  int
+		canceled error
+	)
+
+	err := ndx.Iterate(r, func(i Info) error {
+		visited++
+		if visited%iterateContextCheckInterval == 0 {
+			if canceled = ctx.Err(); canceled != nil {
+				return errStopIteration
+			}
+		}
+
+		return cb(i)
+	})
+
+	if canceled != nil {
+		return canceled //nolint:wrapcheck
+	}
+
+	return err //nolint:wrapcheck
+}
+
+// ContentsInPack invokes the provided callback for all entries of the index stored in the provided pack blob,
+// in the order of the index. Nothing is visited for an empty pack blob ID.
+func ContentsInPack(ndx Index, packBlobID blob.ID, cb func(Info) error) error {
+	if packBlobID == "" {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return ndx.Iterate(AllIDs, func(i Info) error {
+		if i.PackBlobID != packBlobID {
+			return nil
+		}
+
+		return cb(i)
+	})
+}
+
+// FormatVersion returns the version the provided index was written with, for merged indexes the highest
+// version of the underlying indexes, or zero if unknown.
+func FormatVersion(ndx Index) int {
+	switch ndx := ndx.(type) {
+	case *indexV1:
+		return Version1
+
+	case *indexV2:
+		return Version2
+
+	case *bloomFilteredIndex:
+		return FormatVersion(ndx.Index)
+
+	case *observedIndex:
+		return FormatVersion(ndx.Index)
+
+	case Merged:
+		v := 0
+
+		for _, n := range ndx {
+			v = max(v, FormatVersion(n))
+		}
+
+		return v
+
+	default:
+		return 0
+	}
+}
+
 // Open reads an Index from a given reader. The caller must call Close() when the index is no longer used.
 func Open(data []byte, closer func() error, v1PerContentOverhead func() int) (Index, error) {
 	h, err := v1ReadHeader(data)
@@ -35,7 +181,7 @@ func Open(data []byte, closer func() error, v1PerContentOverhead func() int) (In
 	case Version1:
 		return openV1PackIndex(h, data, closer, uint32(v1PerContentOverhead()))
 
-	case Version2:
+	case Version2, Version2 | v2CustomOrderingFlag:
 		return openV2PackIndex(data, closer)
 
 	default:
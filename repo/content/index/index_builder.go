@@ -7,6 +7,7 @@
 	"runtime"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -43,6 +44,115 @@ func (b Builder) Add(i Info) {
 	}
 }
 
+// ErrConflictingDuplicate is returned by AddStrict() when a content which is already present and not deleted
+// is added again with a different location or data.
+var ErrConflictingDuplicate = errors.New("conflicting duplicate content")
+
+// AddStrict is like Add() but returns ErrConflictingDuplicate instead of silently picking a winner when
+// a content is added while a non-deleted entry for it with a different location or data is present.
+// Adding an entry which only differs in the timestamp behaves like Add(), deleting the content and adding it
+// again after deletion are allowed.
+func (b Builder) AddStrict(i Info) error {
+	if old, found := b[i.ContentID]; found && !old.Deleted && !i.Deleted && !sameContentData(old, i) {
+		return errors.Wrapf(ErrConflictingDuplicate, "content %v in %v at %v, previously in %v at %v",
+			i.ContentID, i.PackBlobID, i.PackOffset, old.PackBlobID, old.PackOffset)
+	}
+
+	b.Add(i)
+
+	return nil
+}
+
+// sameContentData returns true if both entries refer to the same stored data, regardless of their timestamps.
+func sameContentData(a, b Info) bool {
+	a.TimestampSeconds = b.TimestampSeconds
+
+	return a == b
+}
+
+// AddIndex adds all entries of the provided index to the builder, using the same rules as Add() for
+// contents which are already present.
+func (b Builder) AddIndex(ndx Index) error {
+	//nolint:wrapcheck
+	return ndx.Iterate(AllIDs, func(i Info) error {
+		b.Add(i)
+		return nil
+	})
+}
+
+// PurgeDeletedBefore removes deleted entries whose timestamp is before the provided cutoff time and returns
+// the number of entries removed. Entries which are not deleted are never removed.
+//
+// Purging is only safe when the cutoff is old enough that no index which is still in use may contain
+// older entries for the same contents, otherwise they could become visible again after the purge.
+func (b Builder) PurgeDeletedBefore(cutoff time.Time) int {
+	purged := 0
+
+	for id, i := range b {
+		if i.Deleted && i.Timestamp().Before(cutoff) {
+			delete(b, id)
+
+			purged++
+		}
+	}
+
+	return purged
+}
+
+// MergeIndexes returns a builder containing entries from all provided indexes, which can be used to
+// compact them into a single index.
+//
+// When a content is present in multiple indexes, the entry with the newest timestamp wins, so newer
+// deletions and relocations of contents override older entries and deleted contents stay deleted
+// unless they have been re-added later. For the same timestamp, non-deleted entries win. The result
+// does not depend on the order of indexes.
+func MergeIndexes(indexes ...Index) (Builder, error) {
+	b := Builder{}
+
+	for _, ndx := range indexes {
+		if err := b.AddIndex(ndx); err != nil {
+			return nil, errors.Wrap(err, "error merging index")
+		}
+	}
+
+	return b, nil
+}
+
+// BuildMerged merges the provided indexes using the same rules as MergeIndexes() and writes the result
+// using the provided index version, converting entries of indexes written with older versions.
+// Indexes written with versions newer than the target version are rejected, because converting
+// them could lose information.
+func BuildMerged(output io.Writer, targetVersion int, indexes ...Index) error {
+	for _, ndx := range indexes {
+		if v := FormatVersion(ndx); v > targetVersion {
+			return errors.Errorf("unable to merge index version %v into version %v", v, targetVersion)
+		}
+	}
+
+	ordering, err := commonOrdering(indexes)
+	if err != nil {
+		return err
+	}
+
+	b, err := MergeIndexes(indexes...)
+	if err != nil {
+		return err
+	}
+
+	return b.BuildWithOrdering(output, targetVersion, ordering)
+}
+
+// IterateSorted invokes the provided callback for all entries of the builder in ascending content ID order.
+func (b Builder) IterateSorted(cb func(Info) error) error {
+	for _, i := range b.sortedContents(DefaultOrdering) {
+		if err := cb(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // base36Value stores a base-36 reverse lookup such that ASCII character corresponds to its
 // base-36 value ('0'=0..'9'=9, 'a'=10, 'b'=11, .., 'z'=35).
 //
@@ -60,10 +170,29 @@ func init() {
 	}
 }
 
-// sortedContents returns the list of []Info sorted lexicographically using bucket sort
+// sortedContents returns the list of []Info sorted using the provided ordering.
+func (b Builder) sortedContents(o *Ordering) []Info {
+	if o.IsDefault() {
+		return b.sortedContentsLexicographically()
+	}
+
+	result := make([]Info, 0, len(b))
+
+	for _, v := range b {
+		result = append(result, v)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return o.Compare(result[i].ContentID, result[j].ContentID) < 0
+	})
+
+	return result
+}
+
+// sortedContentsLexicographically returns the list of []Info sorted lexicographically using bucket sort
 // sorting is optimized based on the format of content IDs (optional single-character
 // alphanumeric prefix (0-9a-z), followed by hexadecimal digits (0-9a-f).
-func (b Builder) sortedContents() []Info {
+func (b Builder) sortedContentsLexicographically() []Info {
 	var buckets [36 * 16][]Info
 
 	// phase 1 - bucketize into 576 (36 *16) separate lists
@@ -115,7 +244,12 @@ func (b Builder) sortedContents() []Info {
 
 // Build writes the pack index to the provided output.
 func (b Builder) Build(output io.Writer, version int) error {
-	if err := b.BuildStable(output, version); err != nil {
+	return b.BuildWithOrdering(output, version, DefaultOrdering)
+}
+
+// BuildWithOrdering writes the pack index using the provided ordering of entries to the provided output.
+func (b Builder) BuildWithOrdering(output io.Writer, version int, o *Ordering) error {
+	if err := b.BuildStableWithOrdering(output, version, o); err != nil {
 		return err
 	}
 
@@ -134,12 +268,23 @@ func (b Builder) Build(output io.Writer, version int) error {
 
 // BuildStable writes the pack index to the provided output.
 func (b Builder) BuildStable(output io.Writer, version int) error {
+	return b.BuildStableWithOrdering(output, version, DefaultOrdering)
+}
+
+// BuildStableWithOrdering writes the pack index using the provided ordering of entries to the provided output.
+func (b Builder) BuildStableWithOrdering(output io.Writer, version int, o *Ordering) error {
 	switch version {
 	case Version1:
-		return b.buildV1(output)
+		if !o.IsDefault() {
+			return errors.Errorf("index ordering %q requires index version %v", o.Name, Version2)
+		}
+
+		return buildWithChecksum(output, b.buildV1)
 
 	case Version2:
-		return b.buildV2(output)
+		return buildWithChecksum(output, func(w io.Writer) error {
+			return b.buildV2(w, o)
+		})
 
 	default:
 		return errors.Errorf("unsupported index version: %v", version)
@@ -183,7 +328,7 @@ func (b Builder) shard(maxShardSize int) []Builder {
 
 // BuildShards builds the set of index shards ensuring no more than the provided number of contents are in each index.
 // Returns shard bytes and function to clean up after the shards have been written.
-func (b Builder) BuildShards(indexVersion int, stable bool, shardSize int) ([]gather.Bytes, func(), error) {
+func (b Builder) BuildShards(indexVersion int, ordering *Ordering, stable bool, shardSize int) ([]gather.Bytes, func(), error) {
 	if shardSize == 0 {
 		return nil, nil, errors.Errorf("invalid shard size")
 	}
@@ -206,7 +351,7 @@ func (b Builder) BuildShards(indexVersion int, stable bool, shardSize int) ([]ga
 
 		dataShardsBuf = append(dataShardsBuf, buf)
 
-		if err := s.BuildStable(buf, indexVersion); err != nil {
+		if err := s.BuildStableWithOrdering(buf, indexVersion, ordering); err != nil {
 			closeShards()
 
 			return nil, nil, errors.Wrap(err, "error building index shard")
@@ -105,6 +105,52 @@ func (b *indexV1) ApproximateCount() int {
 	return b.hdr.entryCount
 }
 
+// Count implements Index interface, the number of entries is stored in the header.
+func (b *indexV1) Count() (int, error) {
+	return b.hdr.entryCount, nil
+}
+
+// CountLive implements Index interface.
+func (b *indexV1) CountLive() (int, error) {
+	return countEntries(b, false)
+}
+
+// VerifyChecksum implements Index interface.
+func (b *indexV1) VerifyChecksum() error {
+	return verifyChecksum(b.data)
+}
+
+// MightContain implements Index interface, the index must be searched to determine whether it has the content.
+func (b *indexV1) MightContain(_ ID) bool {
+	return true
+}
+
+// Contains implements Index interface.
+func (b *indexV1) Contains(contentID ID) (found, deleted bool, err error) {
+	var entryBuf [v1MaxEntrySize]byte
+
+	e, err := b.findEntry(entryBuf[:0], contentID)
+	if err != nil || e == nil {
+		return false, false, err
+	}
+
+	if len(e) != v1EntryLength {
+		return false, false, errors.Errorf("invalid entry length: %v", len(e))
+	}
+
+	return true, e[12]&0x80 != 0, nil //nolint:mnd
+}
+
+// GetInfos implements Index.
+func (b *indexV1) GetInfos(contentIDs []ID) (map[ID]Info, []ID, error) {
+	return getInfos(b, contentIDs)
+}
+
+// Ordering implements Index. Version 1 indexes always use the default ordering.
+func (b *indexV1) Ordering() *Ordering {
+	return DefaultOrdering
+}
+
 // Iterate invokes the provided callback function for a range of contents in the index, sorted alphabetically.
 // The iteration ends when the callback returns an error, which is propagated to the caller or when
 // all contents have been visited.
@@ -267,7 +313,7 @@ type indexBuilderV1 struct {
 
 // buildV1 writes the pack index to the provided output.
 func (b Builder) buildV1(output io.Writer) error {
-	allContents := b.sortedContents()
+	allContents := b.sortedContents(DefaultOrdering)
 	b1 := &indexBuilderV1{
 		packBlobIDOffsets: map[blob.ID]uint32{},
 		keyLength:         -1,
@@ -347,6 +393,10 @@ func (b *indexBuilderV1) writeEntry(w io.Writer, it Info, entry []byte) error {
 		return errors.Errorf("encryption key ID not supported in index v1")
 	}
 
+	if it.FeatureFlags != 0 {
+		return errors.Errorf("feature flags not supported in index v1")
+	}
+
 	if err := b.formatEntry(entry, it); err != nil {
 		return errors.Wrap(err, "unable to format entry")
 	}
@@ -374,6 +424,11 @@ func (b *indexBuilderV1) formatEntry(entry []byte, it Info) error {
 		return errors.Errorf("empty pack content ID for %v", it.ContentID)
 	}
 
+	// the highest bit of the offset is used as a deleted marker.
+	if it.PackOffset&v1DeletedMarker != 0 {
+		return errors.Errorf("pack offset %v of %v is too high", it.PackOffset, it.ContentID)
+	}
+
 	binary.BigEndian.PutUint32(entryPackFileOffset, b.extraDataOffset+b.packBlobIDOffsets[packBlobID])
 
 	if it.Deleted {
@@ -410,7 +465,7 @@ func v1ReadHeader(data []byte) (v1HeaderInfo, error) {
 		entryCount: int(binary.BigEndian.Uint32(header[4:8])),
 	}
 
-	if hi.keySize <= 1 || hi.valueSize < 0 || hi.entryCount < 0 {
+	if hi.keySize <= 1 || hi.keySize > maxContentIDSize || hi.valueSize < 0 || hi.entryCount < 0 {
 		return v1HeaderInfo{}, errors.Errorf("invalid header")
 	}
 
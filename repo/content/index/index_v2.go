@@ -25,8 +25,10 @@
 	// Version2 identifies version 2 of the index, supporting content-level compression.
 	Version2 = 2
 
-	v2IndexHeaderSize       = 17 // size of fixed header at the beginning of index
-	v2PackInfoSize          = 5  // size of each pack information blob
+	v2IndexHeaderSize       = 17   // size of fixed header at the beginning of index
+	v2CustomOrderingFlag    = 0x80 // set in version byte when the index uses non-default ordering
+	v2OrderingIDSize        = 1    // size of ordering ID following the header when v2CustomOrderingFlag is set
+	v2PackInfoSize          = 5    // size of each pack information blob
 	v2MaxFormatCount        = invalidFormatVersion
 	v2MaxUniquePackIDCount  = 1 << 24 // max number of packs that can be stored
 	v2MaxShortPackIDCount   = 1 << 16 // max number that can be represented using 2 bytes
@@ -55,6 +57,9 @@
 //
 //	original length bits 24..27  (4 hi bits)
 //	packed length bits 24..27    (4 lo bits)
+//
+// 19: feature flags (see FeatureFlags) - present if any entry uses feature flags,
+// readers that predate it reject such indexes because of their entry size.
 const (
 	v2EntryOffsetTimestampSeconds      = 0
 	v2EntryOffsetPackOffsetAndFlags    = 4
@@ -67,8 +72,10 @@
 	v2EntryOffsetExtendedPackBlobID    = 17 // optional
 	v2EntryOffsetExtendedPackBlobIDEnd = v2EntryOffsetHighLengthBits
 	v2EntryOffsetHighLengthBits        = 18 // optional
-	v2EntryOffsetHighLengthBitsEnd     = v2EntryMaxLength
-	v2EntryMaxLength                   = 19
+	v2EntryOffsetHighLengthBitsEnd     = v2EntryOffsetFeatureFlags
+	v2EntryOffsetFeatureFlags          = 19 // optional, assumed zero if missing
+	v2EntryOffsetFeatureFlagsEnd       = v2EntryMaxLength
+	v2EntryMaxLength                   = 20
 
 	// flags (at offset v2EntryOffsetPackOffsetAndFlags).
 	v2EntryDeletedFlag    = 0x80
@@ -111,6 +118,9 @@ type FormatV2 struct {
 		BaseTimestamp     uint32 // base timestamp in unix seconds
 	}
 
+	// present only if Version has v2CustomOrderingFlag set.
+	OrderingID byte
+
 	Entries []struct {
 		Key   []byte // key bytes (KeySize)
 		Entry []byte // entry bytes (EntrySize)
@@ -141,6 +151,7 @@ type indexV2 struct {
 	closer      func() error
 	formats     []indexV2FormatInfo
 	packBlobIDs []blob.ID
+	ordering    *Ordering
 }
 
 func (b *indexV2) entryToInfoStruct(contentID ID, data []byte, result *Info) error {
@@ -148,6 +159,15 @@ func (b *indexV2) entryToInfoStruct(contentID ID, data []byte, result *Info) err
 		return errors.Errorf("invalid entry length: %v", len(data))
 	}
 
+	result.FeatureFlags = 0
+	if len(data) > v2EntryOffsetFeatureFlags {
+		result.FeatureFlags = FeatureFlags(data[v2EntryOffsetFeatureFlags])
+	}
+
+	if err := validateFeatureFlags(contentID, result.FeatureFlags); err != nil {
+		return err
+	}
+
 	result.ContentID = contentID
 	result.TimestampSeconds = int64(decodeBigEndianUint32(data[v2EntryOffsetTimestampSeconds:])) + int64(b.hdr.baseTimestamp)
 	result.Deleted = data[v2EntryOffsetPackOffsetAndFlags]&v2EntryDeletedFlag != 0
@@ -220,10 +240,58 @@ func (b *indexV2) ApproximateCount() int {
 	return b.hdr.entryCount
 }
 
-// Iterate invokes the provided callback function for a range of contents in the index, sorted alphabetically.
-// The iteration ends when the callback returns an error, which is propagated to the caller or when
-// all contents have been visited.
+// Count implements Index interface, the number of entries is stored in the header.
+func (b *indexV2) Count() (int, error) {
+	return b.hdr.entryCount, nil
+}
+
+// CountLive implements Index interface.
+func (b *indexV2) CountLive() (int, error) {
+	return countEntries(b, false)
+}
+
+// VerifyChecksum implements Index interface.
+func (b *indexV2) VerifyChecksum() error {
+	return verifyChecksum(b.data)
+}
+
+// MightContain implements Index interface, the index must be searched to determine whether it has the content.
+func (b *indexV2) MightContain(_ ID) bool {
+	return true
+}
+
+// Contains implements Index interface.
+func (b *indexV2) Contains(contentID ID) (found, deleted bool, err error) {
+	e, err := b.findEntry(contentID)
+	if err != nil || e == nil {
+		return false, false, err
+	}
+
+	if len(e) < v2EntryMinLength {
+		return false, false, errors.Errorf("invalid entry length: %v", len(e))
+	}
+
+	return true, e[v2EntryOffsetPackOffsetAndFlags]&v2EntryDeletedFlag != 0, nil
+}
+
+// GetInfos implements Index.
+func (b *indexV2) GetInfos(contentIDs []ID) (map[ID]Info, []ID, error) {
+	return getInfos(b, contentIDs)
+}
+
+// Ordering implements Index.
+func (b *indexV2) Ordering() *Ordering {
+	return b.ordering
+}
+
+// Iterate invokes the provided callback function for a range of contents in the index, sorted according
+// to the index ordering. The iteration ends when the callback returns an error, which is propagated to
+// the caller or when all contents have been visited.
 func (b *indexV2) Iterate(r IDRange, cb func(Info) error) error {
+	if !b.ordering.IsDefault() {
+		return b.iterateUnordered(r, cb)
+	}
+
 	startPos, err := b.findEntryPosition(r.StartID)
 	if err != nil {
 		return errors.Wrap(err, "could not find starting position")
@@ -256,6 +324,34 @@ func (b *indexV2) Iterate(r IDRange, cb func(Info) error) error {
 	return nil
 }
 
+// iterateUnordered iterates over entries of an index whose ordering is unrelated to ID ranges,
+// so all entries must be visited.
+func (b *indexV2) iterateUnordered(r IDRange, cb func(Info) error) error {
+	var tmp Info
+
+	for i := range b.hdr.entryCount {
+		entry, err := safeSlice(b.data, b.entryOffset(i), int(b.hdr.entryStride))
+		if err != nil {
+			return errors.Wrap(err, "unable to read from index")
+		}
+
+		contentID := bytesToContentID(entry[0:b.hdr.keySize])
+		if !r.Contains(contentID) {
+			continue
+		}
+
+		if err := b.entryToInfoStruct(contentID, entry[b.hdr.keySize:], &tmp); err != nil {
+			return errors.Wrap(err, "invalid index data")
+		}
+
+		if err := cb(tmp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (b *indexV2) entryOffset(p int) int64 {
 	return b.hdr.entriesOffset + b.hdr.entryStride*int64(p)
 }
@@ -280,9 +376,27 @@ func (b *indexV2) findEntryPosition(contentID IDPrefix) (int, error) {
 	return pos, readErr
 }
 
-func (b *indexV2) findEntryPositionExact(idBytes []byte) (int, error) {
+func (b *indexV2) findEntryPositionExact(contentID ID, idBytes []byte) (int, error) {
 	var readErr error
 
+	if !b.ordering.IsDefault() {
+		pos := sort.Search(b.hdr.entryCount, func(p int) bool {
+			if readErr != nil {
+				return false
+			}
+
+			entryBuf, err := safeSlice(b.data, b.entryOffset(p), b.hdr.keySize)
+			if err != nil {
+				readErr = err
+				return false
+			}
+
+			return b.ordering.Compare(bytesToContentID(entryBuf), contentID) >= 0
+		})
+
+		return pos, readErr
+	}
+
 	pos := sort.Search(b.hdr.entryCount, func(p int) bool {
 		if readErr != nil {
 			return false
@@ -314,7 +428,7 @@ func (b *indexV2) findEntry(contentID ID) ([]byte, error) {
 		return nil, errors.Errorf("invalid content ID: %q (%v vs %v)", contentID, len(key), b.hdr.keySize)
 	}
 
-	position, err := b.findEntryPositionExact(key)
+	position, err := b.findEntryPositionExact(contentID, key)
 	if err != nil {
 		return nil, err
 	}
@@ -364,6 +478,7 @@ func (b *indexV2) Close() error {
 
 type indexBuilderV2 struct {
 	packBlobIDOffsets      map[blob.ID]uint32
+	headerSize             int
 	entryCount             int
 	keyLength              int
 	entrySize              int
@@ -409,6 +524,17 @@ func buildPackIDToIndexMap(sortedInfos []Info) map[blob.ID]int {
 	return result
 }
 
+// hasFeatureFlags returns true if any of the infos uses feature flags.
+func hasFeatureFlags(sortedInfos []Info) bool {
+	for _, v := range sortedInfos {
+		if v.FeatureFlags != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
 // maxContentLengths computes max content lengths in the builder.
 func maxContentLengths(sortedInfos []Info) (maxPackedLength, maxOriginalLength, maxPackOffset uint32) {
 	for _, v := range sortedInfos {
@@ -468,6 +594,10 @@ func newIndexBuilderV2(sortedInfos []Info) (*indexBuilderV2, error) {
 		return nil, errors.Errorf("pack offset %v is too high", maxPackOffset)
 	}
 
+	if hasFeatureFlags(sortedInfos) {
+		entrySize = max(entrySize, v2EntryOffsetFeatureFlagsEnd)
+	}
+
 	keyLength := -1
 
 	if len(sortedInfos) > 0 {
@@ -478,6 +608,7 @@ func newIndexBuilderV2(sortedInfos []Info) (*indexBuilderV2, error) {
 
 	return &indexBuilderV2{
 		packBlobIDOffsets:      map[blob.ID]uint32{},
+		headerSize:             v2IndexHeaderSize,
 		keyLength:              keyLength,
 		entrySize:              entrySize,
 		entryCount:             len(sortedInfos),
@@ -487,14 +618,18 @@ func newIndexBuilderV2(sortedInfos []Info) (*indexBuilderV2, error) {
 }
 
 // buildV2 writes the pack index to the provided output.
-func (b Builder) buildV2(output io.Writer) error {
-	sortedInfos := b.sortedContents()
+func (b Builder) buildV2(output io.Writer, o *Ordering) error {
+	sortedInfos := b.sortedContents(o)
 
 	b2, err := newIndexBuilderV2(sortedInfos)
 	if err != nil {
 		return err
 	}
 
+	if !o.IsDefault() {
+		b2.headerSize += v2OrderingIDSize
+	}
+
 	w := bufio.NewWriter(output)
 
 	// prepare extra data to be appended at the end of an index.
@@ -505,7 +640,7 @@ func (b Builder) buildV2(output io.Writer) error {
 	}
 
 	// write header
-	header := make([]byte, v2IndexHeaderSize)
+	header := make([]byte, b2.headerSize)
 	header[0] = Version2 // version
 	header[1] = byte(b2.keyLength)
 	binary.BigEndian.PutUint16(header[2:4], uint16(b2.entrySize))
@@ -514,6 +649,11 @@ func (b Builder) buildV2(output io.Writer) error {
 	header[12] = byte(len(b2.uniqueFormatInfo2Index))
 	binary.BigEndian.PutUint32(header[13:17], uint32(b2.baseTimestamp))
 
+	if !o.IsDefault() {
+		header[0] |= v2CustomOrderingFlag
+		header[v2IndexHeaderSize] = o.ID
+	}
+
 	if _, err := w.Write(header); err != nil {
 		return errors.Wrap(err, "unable to write header")
 	}
@@ -570,7 +710,7 @@ func (b *indexBuilderV2) prepareExtraData(sortedInfos []Info) []byte {
 		}
 	}
 
-	b.extraDataOffset = v2IndexHeaderSize                                         // fixed header
+	b.extraDataOffset = uint32(b.headerSize)                                      // fixed header
 	b.extraDataOffset += uint32(b.entryCount * (b.keyLength + b.entrySize))       // entries index
 	b.extraDataOffset += uint32(len(b.packID2Index) * v2PackInfoSize)             // pack information
 	b.extraDataOffset += uint32(len(b.uniqueFormatInfo2Index) * v2FormatInfoSize) // formats
@@ -666,6 +806,9 @@ func (b *indexBuilderV2) writeIndexValueEntry(w io.Writer, it Info) error {
 	//            packed length bits 24..27    (4 lo bits)
 	buf[v2EntryOffsetHighLengthBits] = byte(it.PackedLength>>v2EntryHighLengthShift) | byte((it.OriginalLength>>v2EntryHighLengthShift)<<v2EntryHighLengthBitsOriginalLengthShift)
 
+	//     19: feature flags - present if any entry uses feature flags
+	buf[v2EntryOffsetFeatureFlags] = byte(it.FeatureFlags)
+
 	for i := b.entrySize; i < v2EntryMaxLength; i++ {
 		if buf[i] != 0 {
 			panic(fmt.Sprintf("encoding bug %x (entrySize=%v)", buf, b.entrySize))
@@ -693,7 +836,7 @@ func openV2PackIndex(data []byte, closer func() error) (Index, error) {
 		baseTimestamp: binary.BigEndian.Uint32(header[13:17]),
 	}
 
-	if hi.keySize <= 1 || hi.entrySize < v2EntryMinLength || hi.entrySize > v2EntryMaxLength || hi.entryCount < 0 || hi.formatCount > v2MaxFormatCount {
+	if hi.keySize <= 1 || hi.keySize > maxContentIDSize || hi.entrySize < v2EntryMinLength || hi.entrySize > v2EntryMaxLength || hi.entryCount < 0 || hi.formatCount > v2MaxFormatCount {
 		return nil, errors.Errorf("invalid header")
 	}
 
@@ -703,6 +846,22 @@ func openV2PackIndex(data []byte, closer func() error) (Index, error) {
 	}
 
 	hi.entriesOffset = v2IndexHeaderSize
+
+	ordering := DefaultOrdering
+
+	if hi.version&v2CustomOrderingFlag != 0 {
+		ob, err := safeSlice(data, v2IndexHeaderSize, v2OrderingIDSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid header")
+		}
+
+		ordering, err = orderingByID(ob[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid header")
+		}
+
+		hi.entriesOffset += v2OrderingIDSize
+	}
 	hi.packsOffset = hi.entriesOffset + int64(hi.entryCount)*hi.entryStride
 	hi.formatsOffset = hi.packsOffset + int64(hi.packCount*v2PackInfoSize)
 
@@ -737,6 +896,7 @@ func openV2PackIndex(data []byte, closer func() error) (Index, error) {
 		closer:      closer,
 		formats:     parseFormatsBuffer(formatsBuf, int(hi.formatCount)),
 		packBlobIDs: packIDs,
+		ordering:    ordering,
 	}, nil
 }
 
@@ -19,6 +19,7 @@ type Info struct {
 	Deleted             bool                 `json:"deleted"`
 	FormatVersion       byte                 `json:"formatVersion"`
 	EncryptionKeyID     byte                 `json:"encryptionKeyID,omitempty"`
+	FeatureFlags        FeatureFlags         `json:"featureFlags,omitempty"`
 }
 
 // Timestamp implements the Info interface.
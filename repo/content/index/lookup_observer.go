@@ -0,0 +1,57 @@
+package index
+
+import (
+	"time"
+
+	"github.com/kopia/kopia/internal/timetrack"
+)
+
+// LookupObserver is invoked after a content lookup in an index has been resolved, with the content ID,
+// whether it was found and how long the lookup took.
+type LookupObserver func(contentID ID, found bool, dur time.Duration)
+
+// observedIndex reports GetInfo() and Contains() lookups of the underlying index to an observer.
+type observedIndex struct {
+	Index
+
+	observer LookupObserver
+}
+
+// WithLookupObserver returns an index which reports single-content lookups to the provided observer,
+// which is useful for collecting metrics. The observer only receives the content ID, so it can't
+// modify the returned information. When the observer is nil the index is returned unchanged.
+func WithLookupObserver(ndx Index, observer LookupObserver) Index {
+	if observer == nil {
+		return ndx
+	}
+
+	return &observedIndex{Index: ndx, observer: observer}
+}
+
+// GetInfo implements Index interface.
+func (o *observedIndex) GetInfo(contentID ID, result *Info) (bool, error) {
+	t0 := timetrack.StartTimer()
+
+	found, err := o.Index.GetInfo(contentID, result)
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+
+	o.observer(contentID, found, t0.Elapsed())
+
+	return found, nil
+}
+
+// Contains implements Index interface.
+func (o *observedIndex) Contains(contentID ID) (found, deleted bool, err error) {
+	t0 := timetrack.StartTimer()
+
+	found, deleted, err = o.Index.Contains(contentID)
+	if err != nil {
+		return false, false, err //nolint:wrapcheck
+	}
+
+	o.observer(contentID, found, t0.Elapsed())
+
+	return found, deleted, nil
+}
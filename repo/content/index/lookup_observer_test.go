@@ -0,0 +1,55 @@
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLookupObserver(t *testing.T) {
+	base := orderedIndexWithItems(t, DefaultOrdering,
+		Info{ContentID: mustParseID(t, "aabbcc"), TimestampSeconds: 1, PackBlobID: "p1"},
+		Info{ContentID: mustParseID(t, "ddeeff"), TimestampSeconds: 1, PackBlobID: "p1", Deleted: true},
+	)
+
+	require.Equal(t, base, WithLookupObserver(base, nil))
+
+	type lookup struct {
+		id    ID
+		found bool
+	}
+
+	var lookups []lookup
+
+	ndx := WithLookupObserver(base, func(contentID ID, found bool, dur time.Duration) {
+		require.GreaterOrEqual(t, dur, time.Duration(0))
+		require.Less(t, dur, time.Minute)
+
+		lookups = append(lookups, lookup{contentID, found})
+	})
+
+	var info Info
+
+	found, err := ndx.GetInfo(mustParseID(t, "aabbcc"), &info)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "p1", string(info.PackBlobID))
+
+	found, err = ndx.GetInfo(mustParseID(t, "a0a0a0"), &info)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	found, deleted, err := ndx.Contains(mustParseID(t, "ddeeff"))
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, deleted)
+
+	require.Equal(t, []lookup{
+		{mustParseID(t, "aabbcc"), true},
+		{mustParseID(t, "a0a0a0"), false},
+		{mustParseID(t, "ddeeff"), true},
+	}, lookups)
+
+	require.Equal(t, Version2, FormatVersion(ndx))
+}
@@ -22,6 +22,43 @@ func (m Merged) ApproximateCount() int {
 	return c
 }
 
+// Count implements Index interface. Contents present in multiple indexes are counted once, which
+// requires visiting all entries unless there's at most one underlying index.
+func (m Merged) Count() (int, error) {
+	switch len(m) {
+	case 0:
+		return 0, nil
+	case 1:
+		return m[0].Count() //nolint:wrapcheck
+	default:
+		return countEntries(m, true)
+	}
+}
+
+// CountLive implements Index interface.
+func (m Merged) CountLive() (int, error) {
+	return countEntries(m, false)
+}
+
+// VerifyChecksum implements Index interface. ErrChecksumNotAvailable is returned if none of the underlying
+// indexes is corrupt, but some of them don't have a checksum.
+func (m Merged) VerifyChecksum() error {
+	var result error
+
+	for _, ndx := range m {
+		err := ndx.VerifyChecksum()
+
+		switch {
+		case errors.Is(err, ErrChecksumNotAvailable):
+			result = err
+		case err != nil:
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return result
+}
+
 // Close closes all underlying indexes.
 func (m Merged) Close() error {
 	var err error
@@ -33,6 +70,15 @@ func (m Merged) Close() error {
 	return errors.Wrap(err, "closing index shards")
 }
 
+// Ordering implements Index interface.
+func (m Merged) Ordering() *Ordering {
+	if len(m) == 0 {
+		return DefaultOrdering
+	}
+
+	return m[0].Ordering()
+}
+
 func contentInfoGreaterThanStruct(a, b Info) bool {
 	if l, r := a.TimestampSeconds, b.TimestampSeconds; l != r {
 		// different timestamps, higher one wins
@@ -49,6 +95,55 @@ func contentInfoGreaterThanStruct(a, b Info) bool {
 	return a.PackBlobID > b.PackBlobID
 }
 
+// MightContain implements Index interface.
+func (m Merged) MightContain(id ID) bool {
+	for _, ndx := range m {
+		if ndx.MightContain(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetInfos implements Index interface.
+func (m Merged) GetInfos(contentIDs []ID) (map[ID]Info, []ID, error) {
+	return getInfos(m, contentIDs)
+}
+
+// Contains implements Index interface. When underlying indexes disagree on whether the content is deleted,
+// the newest entry is looked up to resolve it.
+func (m Merged) Contains(id ID) (found, deleted bool, err error) {
+	var haveLive, haveDeleted bool
+
+	for _, ndx := range m {
+		ok, del, err := ndx.Contains(id)
+		if err != nil {
+			return false, false, errors.Wrapf(err, "error getting id %v from index shard", id)
+		}
+
+		if !ok {
+			continue
+		}
+
+		if del {
+			haveDeleted = true
+		} else {
+			haveLive = true
+		}
+	}
+
+	if !haveLive || !haveDeleted {
+		return haveLive || haveDeleted, haveDeleted, nil
+	}
+
+	var info Info
+
+	found, err = m.GetInfo(id, &info)
+
+	return found, info.Deleted, err
+}
+
 // GetInfo returns information about a single content. If a content is not found, returns (false,nil).
 func (m Merged) GetInfo(id ID, result *Info) (bool, error) {
 	var (
@@ -80,27 +175,30 @@ type nextInfo struct {
 	ch <-chan Info
 }
 
-type nextInfoHeap []*nextInfo
+type nextInfoHeap struct {
+	items    []*nextInfo
+	ordering *Ordering
+}
 
-func (h nextInfoHeap) Len() int { return len(h) }
+func (h nextInfoHeap) Len() int { return len(h.items) }
 func (h nextInfoHeap) Less(i, j int) bool {
-	if a, b := h[i].it.ContentID, h[j].it.ContentID; a != b {
-		return a.less(b)
+	if a, b := h.items[i].it.ContentID, h.items[j].it.ContentID; a != b {
+		return h.ordering.compare(a, b) < 0
 	}
 
-	return !contentInfoGreaterThanStruct(h[i].it, h[j].it)
+	return !contentInfoGreaterThanStruct(h.items[i].it, h.items[j].it)
 }
 
-func (h nextInfoHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h nextInfoHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
 func (h *nextInfoHeap) Push(x interface{}) {
-	*h = append(*h, x.(*nextInfo)) //nolint:forcetypeassert
+	h.items = append(h.items, x.(*nextInfo)) //nolint:forcetypeassert
 }
 
 func (h *nextInfoHeap) Pop() interface{} {
-	old := *h
+	old := h.items
 	n := len(old)
 	x := old[n-1]
-	*h = old[0 : n-1]
+	h.items = old[0 : n-1]
 
 	return x
 }
@@ -127,8 +225,14 @@ func iterateChan(r IDRange, ndx Index, done chan bool, wg *sync.WaitGroup) <-cha
 
 // Iterate invokes the provided callback for all unique content IDs in the underlying sources until either
 // all contents have been visited or until an error is returned by the callback.
+// All underlying indexes must use the same ordering.
 func (m Merged) Iterate(r IDRange, cb func(i Info) error) error {
-	var minHeap nextInfoHeap
+	ordering, err := commonOrdering(m)
+	if err != nil {
+		return err
+	}
+
+	minHeap := nextInfoHeap{ordering: ordering}
 
 	done := make(chan bool)
 
@@ -155,7 +259,7 @@ func (m Merged) Iterate(r IDRange, cb func(i Info) error) error {
 		pendingItem     Info
 	)
 
-	for len(minHeap) > 0 {
+	for minHeap.Len() > 0 {
 		//nolint:forcetypeassert
 		minNextInfo := heap.Pop(&minHeap).(*nextInfo)
 		if !havePendingItem || pendingItem.ContentID != minNextInfo.it.ContentID {
@@ -3,6 +3,7 @@
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"reflect"
 	"testing"
 
@@ -257,3 +258,161 @@ func indexWithItems(items ...Info) (Index, error) {
 
 	return Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
 }
+
+func TestMergeIndexes(t *testing.T) {
+	var (
+		relocated   = mustParseID(t, "aabbcc")
+		tombstoned  = mustParseID(t, "ddeeff")
+		readded     = mustParseID(t, "de1e1e")
+		staleDelete = mustParseID(t, "k010203")
+		onlyOld     = mustParseID(t, "z010203")
+		sameTime    = mustParseID(t, "xaabbcc")
+	)
+
+	i1, err := indexWithItems(
+		Info{ContentID: relocated, TimestampSeconds: 1, PackBlobID: "p1", PackOffset: 1},
+		Info{ContentID: tombstoned, TimestampSeconds: 1, PackBlobID: "p1", PackOffset: 2},
+		Info{ContentID: readded, TimestampSeconds: 1, PackBlobID: "p1", PackOffset: 3},
+		Info{ContentID: staleDelete, TimestampSeconds: 5, PackBlobID: "q1", PackOffset: 4},
+		Info{ContentID: onlyOld, TimestampSeconds: 1, PackBlobID: "q1", PackOffset: 5},
+	)
+	require.NoError(t, err)
+
+	i2, err := indexWithItems(
+		Info{ContentID: relocated, TimestampSeconds: 2, PackBlobID: "p2", PackOffset: 10},
+		Info{ContentID: tombstoned, TimestampSeconds: 2, PackBlobID: "p1", PackOffset: 2, Deleted: true},
+		Info{ContentID: readded, TimestampSeconds: 2, PackBlobID: "p1", PackOffset: 3, Deleted: true},
+		Info{ContentID: staleDelete, TimestampSeconds: 3, PackBlobID: "q1", PackOffset: 4, Deleted: true},
+		Info{ContentID: sameTime, TimestampSeconds: 7, PackBlobID: "q2", PackOffset: 11, Deleted: true},
+	)
+	require.NoError(t, err)
+
+	i3, err := indexWithItems(
+		Info{ContentID: relocated, TimestampSeconds: 3, PackBlobID: "p3", PackOffset: 20},
+		Info{ContentID: readded, TimestampSeconds: 3, PackBlobID: "p3", PackOffset: 21},
+		Info{ContentID: sameTime, TimestampSeconds: 7, PackBlobID: "q3", PackOffset: 22},
+	)
+	require.NoError(t, err)
+
+	want := map[ID]Info{
+		relocated:   {ContentID: relocated, TimestampSeconds: 3, PackBlobID: "p3", PackOffset: 20},
+		tombstoned:  {ContentID: tombstoned, TimestampSeconds: 2, PackBlobID: "p1", PackOffset: 2, Deleted: true},
+		readded:     {ContentID: readded, TimestampSeconds: 3, PackBlobID: "p3", PackOffset: 21},
+		staleDelete: {ContentID: staleDelete, TimestampSeconds: 5, PackBlobID: "q1", PackOffset: 4},
+		onlyOld:     {ContentID: onlyOld, TimestampSeconds: 1, PackBlobID: "q1", PackOffset: 5},
+		sameTime:    {ContentID: sameTime, TimestampSeconds: 7, PackBlobID: "q3", PackOffset: 22},
+	}
+
+	// the result does not depend on the order of indexes.
+	for _, indexes := range [][]Index{{i1, i2, i3}, {i3, i2, i1}, {i2, i3, i1}} {
+		b, err := MergeIndexes(indexes...)
+		require.NoError(t, err)
+		require.Len(t, b, len(want))
+
+		for id, w := range want {
+			got := b[id]
+			require.Equal(t, w.PackBlobID, got.PackBlobID, id)
+			require.Equal(t, w.PackOffset, got.PackOffset, id)
+			require.Equal(t, w.TimestampSeconds, got.TimestampSeconds, id)
+			require.Equal(t, w.Deleted, got.Deleted, id)
+		}
+
+		// merged builder produces the same view as the merged index.
+		var buf bytes.Buffer
+
+		require.NoError(t, b.Build(&buf, Version2))
+
+		compacted, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+		require.NoError(t, err)
+
+		for id := range want {
+			var fromMerged, fromCompacted Info
+
+			ok, err := Merged(indexes).GetInfo(id, &fromMerged)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			ok, err = compacted.GetInfo(id, &fromCompacted)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, fromMerged, fromCompacted)
+		}
+	}
+
+	b, err := MergeIndexes()
+	require.NoError(t, err)
+	require.Empty(t, b)
+}
+
+func TestBuildMergedUpgradesVersion(t *testing.T) {
+	v1Items := []Info{
+		{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "p1", PackOffset: 10, PackedLength: 100, TimestampSeconds: 1000, FormatVersion: 1},
+		{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "p1", PackOffset: 110, PackedLength: 50, TimestampSeconds: 1001, FormatVersion: 1, Deleted: true},
+	}
+	v2Items := []Info{
+		{ContentID: mustParseID(t, "k010203"), PackBlobID: "p2", PackOffset: 0, PackedLength: 70, OriginalLength: 300, TimestampSeconds: 1002, FormatVersion: 2, CompressionHeaderID: 0x1100},
+		// newer deletion of a content from the v1 index.
+		{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "p2", PackOffset: 70, PackedLength: 100, OriginalLength: 73, TimestampSeconds: 2000, FormatVersion: 2, Deleted: true},
+	}
+
+	v1 := Builder{}
+	for _, it := range v1Items {
+		v1.Add(it)
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, v1.Build(&buf, Version1))
+
+	v1ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	v2ndx := orderedIndexWithItems(t, DefaultOrdering, v2Items...)
+
+	require.Equal(t, Version1, FormatVersion(v1ndx))
+	require.Equal(t, Version2, FormatVersion(v2ndx))
+	require.Equal(t, Version2, FormatVersion(Merged{v1ndx, WithBloomFilter(v2ndx)}))
+
+	// v2 entries can't be written to v1.
+	require.ErrorContains(t, BuildMerged(io.Discard, Version1, v1ndx, v2ndx), "unable to merge index version 2 into version 1")
+
+	var out bytes.Buffer
+
+	require.NoError(t, BuildMerged(&out, Version2, v1ndx, v2ndx))
+
+	merged, err := Open(out.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+	require.Equal(t, Version2, FormatVersion(merged))
+
+	want, err := MergeIndexes(v1ndx, v2ndx)
+	require.NoError(t, err)
+
+	cnt, err := merged.Count()
+	require.NoError(t, err)
+	require.Len(t, want, cnt)
+
+	for id, wantInfo := range want {
+		var got Info
+
+		found, err := merged.GetInfo(id, &got)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, wantInfo, got)
+	}
+
+	// timestamps, deletions and the derived original length of v1 entries survive the upgrade.
+	var got Info
+
+	found, err := merged.GetInfo(mustParseID(t, "ddeeff"), &got)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, got.Deleted)
+	require.Equal(t, int64(1001), got.TimestampSeconds)
+	require.Equal(t, uint32(50-fakeEncryptionOverhead), got.OriginalLength)
+
+	found, err = merged.GetInfo(mustParseID(t, "aabbcc"), &got)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, got.Deleted)
+	require.Equal(t, int64(2000), got.TimestampSeconds)
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"bytes"
+	"cmp"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultOrderingName is the name of the default ordering of index entries (by content ID bytes).
+const DefaultOrderingName = "bytes"
+
+// Ordering determines the order of entries within an index.
+//
+// The ordering is chosen when the repository is created and is recorded in the repository format.
+// Indexes written using orderings other than the default also record the ordering ID in their header,
+// so that readers can use the same ordering when seeking and prevent indexes using different orderings
+// from being mixed.
+type Ordering struct {
+	ID      byte   // persisted in the header of each index, 0 == default
+	Name    string // persisted in the repository format
+	Compare func(a, b ID) int
+}
+
+// IsDefault returns true if the ordering is the default byte ordering.
+func (o *Ordering) IsDefault() bool {
+	return o == nil || o.ID == DefaultOrdering.ID
+}
+
+func (o *Ordering) compare(a, b ID) int {
+	if o.IsDefault() {
+		return a.compare(b)
+	}
+
+	return o.Compare(a, b)
+}
+
+// DefaultOrdering orders index entries by the bytes of content IDs.
+//
+//nolint:gochecknoglobals
+var DefaultOrdering = &Ordering{
+	ID:      0,
+	Name:    DefaultOrderingName,
+	Compare: ID.compare,
+}
+
+//nolint:gochecknoglobals
+var (
+	orderingsMu     sync.RWMutex
+	orderingsByName = map[string]*Ordering{DefaultOrderingName: DefaultOrdering}
+	orderingsByID   = map[byte]*Ordering{DefaultOrdering.ID: DefaultOrdering}
+)
+
+// RegisterOrdering registers a custom ordering of index entries. It is typically called from init().
+func RegisterOrdering(o *Ordering) {
+	orderingsMu.Lock()
+	defer orderingsMu.Unlock()
+
+	if o.Compare == nil {
+		panic("ordering comparator not provided: " + o.Name)
+	}
+
+	if orderingsByName[o.Name] != nil {
+		panic("ordering name already registered: " + o.Name)
+	}
+
+	if orderingsByID[o.ID] != nil {
+		panic("ordering ID already registered: " + o.Name)
+	}
+
+	orderingsByName[o.Name] = o
+	orderingsByID[o.ID] = o
+}
+
+// OrderingByName returns the ordering with the provided name, empty name returns the default ordering.
+func OrderingByName(name string) (*Ordering, error) {
+	if name == "" {
+		return DefaultOrdering, nil
+	}
+
+	orderingsMu.RLock()
+	defer orderingsMu.RUnlock()
+
+	o := orderingsByName[name]
+	if o == nil {
+		return nil, errors.Errorf("unsupported index ordering: %q", name)
+	}
+
+	return o, nil
+}
+
+func orderingByID(id byte) (*Ordering, error) {
+	orderingsMu.RLock()
+	defer orderingsMu.RUnlock()
+
+	o := orderingsByID[id]
+	if o == nil {
+		return nil, errors.Errorf("unsupported index ordering ID: %v", id)
+	}
+
+	return o, nil
+}
+
+// SupportedOrderings returns the names of registered index orderings.
+func SupportedOrderings() []string {
+	orderingsMu.RLock()
+	defer orderingsMu.RUnlock()
+
+	var result []string
+
+	for k := range orderingsByName {
+		result = append(result, k)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// commonOrdering returns the ordering shared by all provided indexes or an error if they are different.
+func commonOrdering(m []Index) (*Ordering, error) {
+	result := DefaultOrdering
+
+	for i, ndx := range m {
+		o := ndx.Ordering()
+
+		if i == 0 {
+			result = o
+			continue
+		}
+
+		if o.ID != result.ID {
+			return nil, errors.Errorf("can't mix indexes using different orderings: %q and %q", result.Name, o.Name)
+		}
+	}
+
+	return result, nil
+}
+
+func (i ID) compare(other ID) int {
+	if c := cmp.Compare(i.prefix, other.prefix); c != 0 {
+		return c
+	}
+
+	return bytes.Compare(i.data[:i.idLen], other.data[:other.idLen])
+}
@@ -0,0 +1,212 @@
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// reverseOrdering orders entries in reverse byte order, which makes it easy to tell apart from the default.
+//
+//nolint:gochecknoglobals
+var reverseOrdering = &Ordering{
+	ID:   200,
+	Name: "test-reverse",
+	Compare: func(a, b ID) int {
+		return b.compare(a)
+	},
+}
+
+func init() {
+	RegisterOrdering(reverseOrdering)
+}
+
+func orderedIndexWithItems(t *testing.T, o *Ordering, items ...Info) Index {
+	t.Helper()
+
+	b := make(Builder)
+
+	for _, it := range items {
+		b.Add(it)
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, b.BuildWithOrdering(&buf, Version2, o))
+
+	ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	return ndx
+}
+
+func TestCustomOrdering(t *testing.T) {
+	ids := []string{"aabbcc", "ddeeff", "k010203", "k020304", "z010203"}
+
+	var items []Info
+
+	for i, id := range ids {
+		items = append(items, Info{ContentID: mustParseID(t, id), TimestampSeconds: 1, PackBlobID: "xx", PackOffset: uint32(i)})
+	}
+
+	ndx := orderedIndexWithItems(t, reverseOrdering, items...)
+	require.Equal(t, reverseOrdering, ndx.Ordering())
+
+	for i, id := range ids {
+		var info Info
+
+		ok, err := ndx.GetInfo(mustParseID(t, id), &info)
+		require.NoError(t, err)
+		require.True(t, ok, id)
+		require.EqualValues(t, i, info.PackOffset)
+	}
+
+	var missing Info
+
+	ok, err := ndx.GetInfo(mustParseID(t, "k030405"), &missing)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	var got []string
+
+	require.NoError(t, ndx.Iterate(AllIDs, func(i Info) error {
+		got = append(got, i.ContentID.String())
+		return nil
+	}))
+
+	require.Equal(t, []string{"z010203", "k020304", "k010203", "ddeeff", "aabbcc"}, got)
+
+	// ranges are honored even though they can't be used to seek.
+	got = nil
+
+	require.NoError(t, ndx.Iterate(PrefixRange("k"), func(i Info) error {
+		got = append(got, i.ContentID.String())
+		return nil
+	}))
+
+	require.Equal(t, []string{"k020304", "k010203"}, got)
+
+	// merged indexes using the same ordering are iterated in that order.
+	ndx2 := orderedIndexWithItems(t, reverseOrdering,
+		Info{ContentID: mustParseID(t, "k015555"), TimestampSeconds: 1, PackBlobID: "yy"})
+
+	got = nil
+
+	require.NoError(t, Merged{ndx, ndx2}.Iterate(PrefixRange("k"), func(i Info) error {
+		got = append(got, i.ContentID.String())
+		return nil
+	}))
+
+	require.Equal(t, []string{"k020304", "k015555", "k010203"}, got)
+}
+
+func TestMixedOrderingsCantBeMerged(t *testing.T) {
+	i1 := orderedIndexWithItems(t, DefaultOrdering, Info{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "xx"})
+	i2 := orderedIndexWithItems(t, reverseOrdering, Info{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "yy"})
+
+	require.Equal(t, DefaultOrdering, i1.Ordering())
+
+	err := Merged{i1, i2}.Iterate(AllIDs, func(i Info) error { return nil })
+	require.ErrorContains(t, err, "can't mix indexes using different orderings")
+}
+
+func TestOrderingRequiresV2(t *testing.T) {
+	b := Builder{}
+	b.Add(Info{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "xx"})
+
+	var buf bytes.Buffer
+
+	require.ErrorContains(t, b.BuildWithOrdering(&buf, Version1, reverseOrdering), "requires index version")
+}
+
+func TestOrderingByName(t *testing.T) {
+	o, err := OrderingByName("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultOrdering, o)
+
+	o, err = OrderingByName(DefaultOrderingName)
+	require.NoError(t, err)
+	require.Equal(t, DefaultOrdering, o)
+
+	o, err = OrderingByName(reverseOrdering.Name)
+	require.NoError(t, err)
+	require.Equal(t, reverseOrdering, o)
+
+	_, err = OrderingByName("no-such-ordering")
+	require.Error(t, err)
+
+	require.Contains(t, SupportedOrderings(), DefaultOrderingName)
+}
+
+func TestIterateSorted(t *testing.T) {
+	var items []Info
+
+	// added out of order.
+	for _, id := range []string{"z010203", "k020304", "ddeeff", "k010203", "123456", "aabbcc", "g010203"} {
+		items = append(items, Info{ContentID: mustParseID(t, id), PackBlobID: "xx"})
+	}
+
+	want := []ID{
+		mustParseID(t, "123456"),
+		mustParseID(t, "aabbcc"),
+		mustParseID(t, "ddeeff"),
+		mustParseID(t, "g010203"),
+		mustParseID(t, "k010203"),
+		mustParseID(t, "k020304"),
+		mustParseID(t, "z010203"),
+	}
+
+	b := Builder{}
+	for _, it := range items {
+		b.Add(it)
+	}
+
+	collect := func(iterate func(cb func(Info) error) error) []ID {
+		t.Helper()
+
+		var got []ID
+
+		require.NoError(t, iterate(func(i Info) error {
+			got = append(got, i.ContentID)
+			return nil
+		}))
+
+		return got
+	}
+
+	require.Equal(t, want, collect(b.IterateSorted))
+
+	for _, o := range []*Ordering{DefaultOrdering, reverseOrdering} {
+		t.Run(o.Name, func(t *testing.T) {
+			ndx := orderedIndexWithItems(t, o, items...)
+
+			require.Equal(t, want, collect(func(cb func(Info) error) error {
+				return IterateSorted(ndx, cb)
+			}))
+
+			merged := Merged{
+				orderedIndexWithItems(t, o, items[:3]...),
+				orderedIndexWithItems(t, o, items[3:]...),
+			}
+
+			require.Equal(t, want, collect(func(cb func(Info) error) error {
+				return IterateSorted(merged, cb)
+			}))
+		})
+	}
+
+	// errors from the callback stop iteration.
+	errStop := errors.New("stop")
+
+	for _, ndx := range []Index{orderedIndexWithItems(t, DefaultOrdering, items...), orderedIndexWithItems(t, reverseOrdering, items...)} {
+		cnt := 0
+
+		require.ErrorIs(t, IterateSorted(ndx, func(Info) error {
+			cnt++
+			return errStop
+		}), errStop)
+		require.Equal(t, 1, cnt)
+	}
+}
@@ -2,6 +2,7 @@
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
@@ -9,7 +10,9 @@
 	"math/rand"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/repo/blob"
@@ -276,7 +279,7 @@ func TestPackIndexPerContentLimits(t *testing.T) {
 		var result bytes.Buffer
 
 		if tc.errMsg == "" {
-			require.NoError(t, b.buildV2(&result))
+			require.NoError(t, b.buildV2(&result, DefaultOrdering))
 
 			pi, err := Open(result.Bytes(), nil, func() int { return fakeEncryptionOverhead })
 			require.NoError(t, err)
@@ -289,13 +292,43 @@ func TestPackIndexPerContentLimits(t *testing.T) {
 
 			require.Equal(t, got, tc.info)
 		} else {
-			err := b.buildV2(&result)
+			err := b.buildV2(&result, DefaultOrdering)
 			require.Error(t, err)
 			require.Contains(t, err.Error(), tc.errMsg)
 		}
 	}
 }
 
+func TestPackIndexV1PackOffsetLimit(t *testing.T) {
+	for _, packOffset := range []uint32{v1DeletedMarker - 1, v1DeletedMarker, 1<<32 - 1} {
+		cid := deterministicContentID(t, "hello-world", 1)
+		info := Info{ContentID: cid, PackBlobID: "p1234", PackOffset: packOffset, Deleted: true}
+
+		b := Builder{cid: info}
+
+		var result bytes.Buffer
+
+		if packOffset >= v1DeletedMarker {
+			// offsets overlapping the deleted marker must not be silently truncated.
+			require.ErrorContains(t, b.buildV1(&result), "is too high")
+			continue
+		}
+
+		require.NoError(t, b.buildV1(&result))
+
+		pi, err := Open(result.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+		require.NoError(t, err)
+
+		var got Info
+
+		ok, err := pi.GetInfo(cid, &got)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, packOffset, got.PackOffset)
+		require.True(t, got.Deleted)
+	}
+}
+
 func TestSortedContents(t *testing.T) {
 	b := Builder{}
 
@@ -307,7 +340,7 @@ func TestSortedContents(t *testing.T) {
 		})
 	}
 
-	got := b.sortedContents()
+	got := b.sortedContents(DefaultOrdering)
 
 	var last ID
 	for _, info := range got {
@@ -353,7 +386,7 @@ func TestSortedContents2(t *testing.T) {
 		ContentID: mustParseID(t, "h1023"),
 	})
 
-	got := b.sortedContents()
+	got := b.sortedContents(DefaultOrdering)
 
 	var last ID
 
@@ -380,7 +413,7 @@ func TestPackIndexV2TooManyUniqueFormats(t *testing.T) {
 		})
 	}
 
-	require.NoError(t, b.buildV2(io.Discard))
+	require.NoError(t, b.buildV2(io.Discard, DefaultOrdering))
 
 	// add one more to push it over the edge
 	b.Add(Info{
@@ -389,7 +422,7 @@ func TestPackIndexV2TooManyUniqueFormats(t *testing.T) {
 		CompressionHeaderID: compression.HeaderID(5000),
 	})
 
-	err := b.buildV2(io.Discard)
+	err := b.buildV2(io.Discard, DefaultOrdering)
 	require.Error(t, err)
 	require.Equal(t, "unsupported - too many unique formats 256 (max 255)", err.Error())
 }
@@ -533,3 +566,570 @@ func mustParseID(t *testing.T, s string) ID {
 
 	return id
 }
+
+func TestIteratePrefix(t *testing.T) {
+	var infos []Info
+
+	for i := range 200 {
+		infos = append(infos, Info{ContentID: deterministicContentID(t, "prefix", i), PackBlobID: deterministicPackBlobID(i)})
+	}
+
+	v1 := make(Builder)
+	for _, it := range infos {
+		v1.Add(it)
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, v1.Build(&buf, Version1))
+
+	v1ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	for name, ndx := range map[string]Index{
+		"v1":      v1ndx,
+		"v2":      orderedIndexWithItems(t, DefaultOrdering, infos...),
+		"reverse": orderedIndexWithItems(t, reverseOrdering, infos...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var all []ID
+
+			require.NoError(t, ndx.Iterate(AllIDs, func(i Info) error {
+				all = append(all, i.ContentID)
+				return nil
+			}))
+
+			require.Len(t, all, len(infos))
+
+			for _, prefix := range []IDPrefix{"", "x", "m", "y", "x0", "a", "3f", "q", "xq", "z"} {
+				var want, got []ID
+
+				for _, id := range all {
+					if strings.HasPrefix(id.String(), string(prefix)) {
+						want = append(want, id)
+					}
+				}
+
+				require.NoError(t, IteratePrefix(ndx, prefix, func(i Info) error {
+					got = append(got, i.ContentID)
+					return nil
+				}))
+
+				require.Equal(t, want, got, "prefix %q", prefix)
+
+				switch prefix {
+				case "":
+					// empty prefix matches the entire index, in the same order as Iterate().
+					require.Equal(t, all, got)
+				case "q", "xq", "z":
+					require.Empty(t, got)
+				}
+			}
+		})
+	}
+}
+
+func TestIterateCreatedBetween(t *testing.T) {
+	var infos []Info
+
+	// several entries share each timestamp.
+	for i := range 30 {
+		infos = append(infos, Info{ContentID: deterministicContentID(t, "created", i), PackBlobID: deterministicPackBlobID(i), TimestampSeconds: int64(100 + i/3)})
+	}
+
+	for name, ndx := range map[string]Index{
+		"v2":      orderedIndexWithItems(t, DefaultOrdering, infos...),
+		"reverse": orderedIndexWithItems(t, reverseOrdering, infos...),
+		"merged":  Merged{orderedIndexWithItems(t, DefaultOrdering, infos[:15]...), orderedIndexWithItems(t, DefaultOrdering, infos[15:]...)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			countBetween := func(start, end time.Time) int {
+				t.Helper()
+
+				cnt := 0
+
+				require.NoError(t, IterateCreatedBetween(ndx, start, end, func(i Info) error {
+					require.False(t, i.Timestamp().Before(start))
+
+					if !end.IsZero() {
+						require.True(t, i.Timestamp().Before(end))
+					}
+
+					cnt++
+
+					return nil
+				}))
+
+				return cnt
+			}
+
+			// start is inclusive, end is exclusive.
+			require.Equal(t, 3, countBetween(time.Unix(100, 0), time.Unix(101, 0)))
+			require.Equal(t, 6, countBetween(time.Unix(101, 0), time.Unix(103, 0)))
+			require.Equal(t, 0, countBetween(time.Unix(101, 0), time.Unix(101, 0)))
+			require.Equal(t, 30, countBetween(time.Unix(100, 0), time.Unix(110, 0)))
+			require.Equal(t, 27, countBetween(time.Unix(100, 0), time.Unix(109, 0)))
+
+			// sub-second bounds.
+			require.Equal(t, 3, countBetween(time.Unix(100, 1), time.Unix(102, 0)))
+			require.Equal(t, 6, countBetween(time.Unix(100, 0), time.Unix(101, 1)))
+
+			// zero end time is unbounded.
+			require.Equal(t, 30, countBetween(time.Time{}, time.Time{}))
+			require.Equal(t, 12, countBetween(time.Unix(106, 0), time.Time{}))
+			require.Equal(t, 0, countBetween(time.Unix(110, 0), time.Time{}))
+		})
+	}
+}
+
+func TestPurgeDeletedBefore(t *testing.T) {
+	cutoff := time.Unix(1000, 0)
+
+	b := Builder{}
+
+	oldDeleted := Info{ContentID: mustParseID(t, "aabbcc"), TimestampSeconds: 999, PackBlobID: "xx", Deleted: true}
+	newDeleted := Info{ContentID: mustParseID(t, "ddeeff"), TimestampSeconds: 1001, PackBlobID: "xx", Deleted: true}
+	atCutoff := Info{ContentID: mustParseID(t, "k010203"), TimestampSeconds: 1000, PackBlobID: "xx", Deleted: true}
+	oldLive := Info{ContentID: mustParseID(t, "k020304"), TimestampSeconds: 1, PackBlobID: "xx"}
+
+	for _, it := range []Info{oldDeleted, newDeleted, atCutoff, oldLive} {
+		b.Add(it)
+	}
+
+	require.Equal(t, 1, b.PurgeDeletedBefore(cutoff))
+	require.NotContains(t, b, oldDeleted.ContentID)
+	require.Equal(t, Builder{
+		newDeleted.ContentID: newDeleted,
+		atCutoff.ContentID:   atCutoff,
+		oldLive.ContentID:    oldLive,
+	}, b)
+
+	// purging again is a no-op.
+	require.Equal(t, 0, b.PurgeDeletedBefore(cutoff))
+	require.Len(t, b, 3)
+
+	// builder without deleted entries is not modified.
+	live := Builder{oldLive.ContentID: oldLive}
+
+	require.Equal(t, 0, live.PurgeDeletedBefore(time.Unix(1e9, 0)))
+	require.Equal(t, Builder{oldLive.ContentID: oldLive}, live)
+}
+
+func TestAddStrict(t *testing.T) {
+	id := mustParseID(t, "aabbcc")
+	orig := Info{ContentID: id, TimestampSeconds: 1000, PackBlobID: "p1", PackOffset: 10, PackedLength: 100}
+
+	b := Builder{}
+	require.NoError(t, b.AddStrict(orig))
+
+	// identical entry is a no-op.
+	require.NoError(t, b.AddStrict(orig))
+	require.Equal(t, Builder{id: orig}, b)
+
+	// same data written again later.
+	later := orig
+	later.TimestampSeconds = 1001
+	require.NoError(t, b.AddStrict(later))
+	require.Equal(t, Builder{id: later}, b)
+
+	// same content in a different pack or location.
+	for _, conflicting := range []Info{
+		{ContentID: id, TimestampSeconds: 1002, PackBlobID: "p2", PackOffset: 10, PackedLength: 100},
+		{ContentID: id, TimestampSeconds: 1002, PackBlobID: "p1", PackOffset: 20, PackedLength: 100},
+		{ContentID: id, TimestampSeconds: 1002, PackBlobID: "p1", PackOffset: 10, PackedLength: 101},
+	} {
+		require.ErrorIs(t, b.AddStrict(conflicting), ErrConflictingDuplicate)
+		require.Equal(t, Builder{id: later}, b)
+	}
+
+	// deleting and adding again in a different pack is allowed.
+	deleted := later
+	deleted.TimestampSeconds = 1003
+	deleted.Deleted = true
+	require.NoError(t, b.AddStrict(deleted))
+	require.True(t, b[id].Deleted)
+
+	readded := Info{ContentID: id, TimestampSeconds: 1004, PackBlobID: "p3", PackOffset: 0, PackedLength: 100}
+	require.NoError(t, b.AddStrict(readded))
+	require.Equal(t, Builder{id: readded}, b)
+}
+
+func TestContentsInPack(t *testing.T) {
+	var infos []Info
+
+	// contents of 3 packs interleaved in ID order.
+	for i := range 30 {
+		infos = append(infos, Info{ContentID: deterministicContentID(t, "inpack", i), PackBlobID: deterministicPackBlobID(i % 3)})
+	}
+
+	for name, ndx := range map[string]Index{
+		"v2":      orderedIndexWithItems(t, DefaultOrdering, infos...),
+		"reverse": orderedIndexWithItems(t, reverseOrdering, infos...),
+		"merged":  Merged{orderedIndexWithItems(t, DefaultOrdering, infos[:10]...), orderedIndexWithItems(t, DefaultOrdering, infos[10:]...)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for p := range 3 {
+				var want, got []ID
+
+				for _, it := range infos {
+					if it.PackBlobID == deterministicPackBlobID(p) {
+						want = append(want, it.ContentID)
+					}
+				}
+
+				require.NoError(t, ContentsInPack(ndx, deterministicPackBlobID(p), func(i Info) error {
+					require.Equal(t, deterministicPackBlobID(p), i.PackBlobID)
+					got = append(got, i.ContentID)
+
+					return nil
+				}))
+
+				require.Len(t, got, 10)
+				require.ElementsMatch(t, want, got)
+			}
+
+			for _, packID := range []blob.ID{"", "no-such-pack"} {
+				require.NoError(t, ContentsInPack(ndx, packID, func(i Info) error {
+					t.Fatalf("unexpected entry in pack %q: %v", packID, i.ContentID)
+					return nil
+				}))
+			}
+		})
+	}
+}
+
+func TestIterateContext(t *testing.T) {
+	const entryCount = 5 * iterateContextCheckInterval
+
+	b := Builder{}
+	for i := range entryCount {
+		b.Add(Info{ContentID: deterministicContentID(t, "iterctx", i), PackBlobID: deterministicPackBlobID(i % 5)})
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, b.Build(&buf, Version2))
+
+	ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	visited := 0
+
+	require.NoError(t, IterateContext(context.Background(), ndx, AllIDs, func(i Info) error {
+		visited++
+		return nil
+	}))
+	require.Equal(t, entryCount, visited)
+
+	// cancel in the middle of iteration.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	visited = 0
+
+	err = IterateContext(ctx, ndx, AllIDs, func(i Info) error {
+		visited++
+		if visited == 1000 {
+			cancel()
+		}
+
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, visited, 1000+iterateContextCheckInterval)
+
+	// already canceled context visits nothing.
+	visited = 0
+
+	require.ErrorIs(t, IterateContext(ctx, ndx, AllIDs, func(i Info) error {
+		visited++
+		return nil
+	}), context.Canceled)
+	require.Zero(t, visited)
+
+	// callback errors are returned as is.
+	errCallback := errors.New("callback error")
+
+	err = IterateContext(context.Background(), ndx, AllIDs, func(i Info) error {
+		return errCallback
+	})
+	require.ErrorIs(t, err, errCallback)
+	require.NotErrorIs(t, err, context.Canceled)
+}
+
+func TestPackIndexMixedCompression(t *testing.T) {
+	want := map[ID]compression.HeaderID{}
+
+	b := Builder{}
+
+	for i := range 20 {
+		id := deterministicContentID(t, "compressed", i)
+
+		// every third content is not compressed.
+		want[id] = compression.HeaderID((i % 3) * 0x1100)
+
+		b.Add(Info{
+			ContentID:           id,
+			PackBlobID:          deterministicPackBlobID(i % 2),
+			PackedLength:        100,
+			OriginalLength:      200,
+			FormatVersion:       2,
+			CompressionHeaderID: want[id],
+		})
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, b.Build(&buf, Version2))
+
+	ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	for id, comp := range want {
+		var info Info
+
+		ok, err := ndx.GetInfo(id, &info)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, comp, info.CompressionHeaderID, id)
+	}
+
+	// v1 indexes have no compression information, all contents are read as uncompressed.
+	v1 := Builder{}
+	v1.Add(Info{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "xx", PackedLength: 100})
+
+	buf.Reset()
+	require.NoError(t, v1.Build(&buf, Version1))
+
+	v1ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	var info Info
+
+	ok, err := v1ndx.GetInfo(mustParseID(t, "aabbcc"), &info)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, compression.HeaderID(0), info.CompressionHeaderID)
+
+	v1.Add(Info{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "xx", CompressionHeaderID: 0x1100})
+	require.Error(t, v1.Build(io.Discard, Version1))
+}
+
+func TestGetInfoRandomLookups(t *testing.T) {
+	const count = 5000
+
+	b := Builder{}
+
+	for i := range count {
+		cid := bloomTestContentID(t, 2*i)
+		b.Add(Info{ContentID: cid, PackBlobID: deterministicPackBlobID(i % 13), PackOffset: uint32(i), PackedLength: uint32(i + 1), Deleted: i%7 == 0})
+	}
+
+	var v1buf bytes.Buffer
+
+	require.NoError(t, b.Build(&v1buf, Version1))
+
+	v1ndx, err := Open(v1buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	indexes := map[string]Index{
+		"v1":      v1ndx,
+		"v2":      orderedIndexWithItems(t, DefaultOrdering, b.sortedContents(DefaultOrdering)...),
+		"reverse": orderedIndexWithItems(t, reverseOrdering, b.sortedContents(DefaultOrdering)...),
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+
+	for name, ndx := range indexes {
+		t.Run(name, func(t *testing.T) {
+			for range 2000 {
+				// odd numbers are never in the index.
+				cid := bloomTestContentID(t, rnd.Intn(2*count+10))
+				want, wantFound := b[cid]
+
+				var got Info
+
+				found, err := ndx.GetInfo(cid, &got)
+				require.NoError(t, err)
+				require.Equal(t, wantFound, found, cid)
+
+				if wantFound {
+					if name == "v1" {
+						// v1 index does not store original length.
+						want.OriginalLength = got.OriginalLength
+					}
+
+					require.Equal(t, want, got)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetInfo(b *testing.B) {
+	const count = 1_000_000
+
+	bld := Builder{}
+
+	for i := range count {
+		cid := bloomTestContentID(b, 2*i)
+		bld.Add(Info{ContentID: cid, PackBlobID: deterministicPackBlobID(i % 1000), PackOffset: uint32(i)})
+	}
+
+	indexes := map[string]Index{}
+
+	for _, o := range []*Ordering{DefaultOrdering, reverseOrdering} {
+		var buf bytes.Buffer
+
+		require.NoError(b, bld.BuildWithOrdering(&buf, Version2, o))
+
+		ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+		require.NoError(b, err)
+
+		indexes[o.Name] = ndx
+	}
+
+	hits := make([]ID, 1000)
+	misses := make([]ID, 1000)
+
+	for i := range hits {
+		hits[i] = bloomTestContentID(b, 2*(i*997%count))
+		misses[i] = bloomTestContentID(b, 2*(i*997%count)+1)
+	}
+
+	for name, ndx := range indexes {
+		for kind, ids := range map[string][]ID{"hit": hits, "miss": misses} {
+			b.Run(name+"-"+kind, func(b *testing.B) {
+				var info Info
+
+				for i := range b.N {
+					if _, err := ndx.GetInfo(ids[i%len(ids)], &info); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+
+	// lookups in an in-memory map, for comparison.
+	for kind, ids := range map[string][]ID{"hit": hits, "miss": misses} {
+		b.Run("builder-"+kind, func(b *testing.B) {
+			for i := range b.N {
+				_ = bld[ids[i%len(ids)]]
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	live := Info{ContentID: mustParseID(t, "aabbcc"), PackBlobID: "xx", TimestampSeconds: 1}
+	deleted := Info{ContentID: mustParseID(t, "ddeeff"), PackBlobID: "xx", TimestampSeconds: 1, Deleted: true}
+	absent := mustParseID(t, "k010203")
+
+	v1 := Builder{}
+	v1.Add(live)
+	v1.Add(deleted)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, v1.Build(&buf, Version1))
+
+	v1ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+	require.NoError(t, err)
+
+	for name, ndx := range map[string]Index{
+		"v1":      v1ndx,
+		"v2":      orderedIndexWithItems(t, DefaultOrdering, live, deleted),
+		"reverse": orderedIndexWithItems(t, reverseOrdering, live, deleted),
+		"bloom":   WithBloomFilter(orderedIndexWithItems(t, DefaultOrdering, live, deleted)),
+		"merged":  Merged{orderedIndexWithItems(t, DefaultOrdering, live), orderedIndexWithItems(t, DefaultOrdering, deleted)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			found, del, err := ndx.Contains(live.ContentID)
+			require.NoError(t, err)
+			require.True(t, found)
+			require.False(t, del)
+
+			found, del, err = ndx.Contains(deleted.ContentID)
+			require.NoError(t, err)
+			require.True(t, found)
+			require.True(t, del)
+
+			found, del, err = ndx.Contains(absent)
+			require.NoError(t, err)
+			require.False(t, found)
+			require.False(t, del)
+		})
+	}
+
+	// when indexes disagree, the newest entry wins.
+	deletedLater := live
+	deletedLater.TimestampSeconds = 2
+	deletedLater.Deleted = true
+
+	recreated := deleted
+	recreated.TimestampSeconds = 2
+	recreated.Deleted = false
+
+	m := Merged{
+		orderedIndexWithItems(t, DefaultOrdering, live, deleted),
+		orderedIndexWithItems(t, DefaultOrdering, deletedLater, recreated),
+	}
+
+	found, del, err := m.Contains(live.ContentID)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, del)
+
+	found, del, err = m.Contains(deleted.ContentID)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, del)
+}
+
+func BenchmarkContains(b *testing.B) {
+	const count = 100_000
+
+	bld := Builder{}
+
+	for i := range count {
+		cid := bloomTestContentID(b, i)
+		bld.Add(Info{ContentID: cid, PackBlobID: deterministicPackBlobID(i % 100), PackOffset: uint32(i), Deleted: i%2 == 0})
+	}
+
+	for _, version := range []int{Version1, Version2} {
+		var buf bytes.Buffer
+
+		require.NoError(b, bld.Build(&buf, version))
+
+		ndx, err := Open(buf.Bytes(), nil, func() int { return fakeEncryptionOverhead })
+		require.NoError(b, err)
+
+		ids := make([]ID, 1000)
+		for i := range ids {
+			ids[i] = bloomTestContentID(b, i*97%count)
+		}
+
+		b.Run(fmt.Sprintf("v%v-GetInfo", version), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := range b.N {
+				var info Info
+
+				if _, err := ndx.GetInfo(ids[i%len(ids)], &info); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("v%v-Contains", version), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := range b.N {
+				if _, _, err := ndx.Contains(ids[i%len(ids)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
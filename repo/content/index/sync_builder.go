@@ -0,0 +1,65 @@
+package index
+
+import (
+	"sync"
+)
+
+// SyncBuilder is a Builder which can be safely used from multiple goroutines.
+type SyncBuilder struct {
+	mu sync.RWMutex
+	// +checklocks:mu
+	b Builder
+}
+
+// NewSyncBuilder returns a new empty SyncBuilder.
+func NewSyncBuilder() *SyncBuilder {
+	return &SyncBuilder{b: Builder{}}
+}
+
+// Add adds a new entry to the builder or conditionally replaces it, see Builder.Add().
+func (s *SyncBuilder) Add(i Info) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.b.Add(i)
+}
+
+// AddIndex adds all entries of the provided index to the builder, see Builder.AddIndex().
+func (s *SyncBuilder) AddIndex(ndx Index) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.b.AddIndex(ndx)
+}
+
+// Len returns the number of entries in the builder.
+func (s *SyncBuilder) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.b)
+}
+
+// Snapshot returns a copy of the current entries, which is not affected by subsequent changes.
+func (s *SyncBuilder) Snapshot() Builder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.b.Clone()
+}
+
+// IterateSorted invokes the provided callback for a snapshot of the current entries in ascending content ID order.
+// The callback is invoked without holding the lock, so it can modify the builder.
+func (s *SyncBuilder) IterateSorted(cb func(Info) error) error {
+	s.mu.RLock()
+	sorted := s.b.sortedContents(DefaultOrdering)
+	s.mu.RUnlock()
+
+	for _, i := range sorted {
+		if err := cb(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestSyncBuilder(t *testing.T) {
+	const (
+		numWriters        = 8
+		entriesPerWriter  = 200
+		numSnapshotChecks = 50
+	)
+
+	s := NewSyncBuilder()
+
+	var eg errgroup.Group
+
+	for w := range numWriters {
+		eg.Go(func() error {
+			for i := range entriesPerWriter {
+				s.Add(Info{ContentID: deterministicContentID(t, "sync", w*entriesPerWriter+i), PackBlobID: deterministicPackBlobID(w)})
+			}
+
+			return nil
+		})
+	}
+
+	eg.Go(func() error {
+		for range numSnapshotChecks {
+			var last Info
+
+			cnt := 0
+
+			// entries must be sorted and consistent even while being added.
+			if err := s.IterateSorted(func(i Info) error {
+				if cnt > 0 && last.ContentID.compare(i.ContentID) >= 0 {
+					return errors.Errorf("entries out of order: %v, %v", last.ContentID, i.ContentID)
+				}
+
+				last = i
+				cnt++
+
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			if cnt > s.Len() {
+				return errors.Errorf("iterated more entries than present: %v", cnt)
+			}
+		}
+
+		return nil
+	})
+
+	require.NoError(t, eg.Wait())
+	require.Equal(t, numWriters*entriesPerWriter, s.Len())
+
+	snap := s.Snapshot()
+	require.Len(t, snap, numWriters*entriesPerWriter)
+
+	// snapshot is not affected by subsequent changes.
+	s.Add(Info{ContentID: deterministicContentID(t, "sync", -1), PackBlobID: "xx"})
+	require.Len(t, snap, numWriters*entriesPerWriter)
+	require.Equal(t, numWriters*entriesPerWriter+1, s.Len())
+
+	require.NoError(t, s.AddIndex(orderedIndexWithItems(t, DefaultOrdering, Info{ContentID: deterministicContentID(t, "sync", -2), PackBlobID: "yy"})))
+	require.Equal(t, numWriters*entriesPerWriter+2, s.Len())
+}
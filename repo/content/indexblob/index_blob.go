@@ -15,6 +15,7 @@ type Manager interface {
 	WriteIndexBlobs(ctx context.Context, data []gather.Bytes, suffix blob.ID) ([]blob.Metadata, error)
 	ListActiveIndexBlobs(ctx context.Context) ([]Metadata, time.Time, error)
 	Compact(ctx context.Context, opts CompactOptions) error
+	PlanCompaction(ctx context.Context, opts CompactOptions) ([]Metadata, error)
 	Invalidate()
 }
 
@@ -25,6 +26,10 @@ type CompactOptions struct {
 	DropDeletedBefore                time.Time
 	DropContents                     []index.ID
 	DisableEventualConsistencySafety bool
+
+	// Forced indicates compaction triggered because too many index blobs have accumulated,
+	// in which case epoch-based managers will also try to advance and compact epochs.
+	Forced bool
 }
 
 func (co *CompactOptions) maxEventualConsistencySettleTime() time.Duration {
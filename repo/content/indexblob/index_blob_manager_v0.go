@@ -173,6 +173,27 @@ func (m *ManagerV0) Compact(ctx context.Context, opt CompactOptions) error {
 	return nil
 }
 
+// PlanCompaction returns the index blobs that would be merged by Compact() with the same options,
+// without writing anything.
+func (m *ManagerV0) PlanCompaction(ctx context.Context, opt CompactOptions) ([]Metadata, error) {
+	indexBlobs, _, err := m.ListActiveIndexBlobs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing active index blobs")
+	}
+
+	mp, mperr := m.formattingOptions.GetMutableParameters(ctx)
+	if mperr != nil {
+		return nil, errors.Wrap(mperr, "mutable parameters")
+	}
+
+	blobsToCompact := m.getBlobsToCompact(indexBlobs, opt, mp)
+	if !m.shouldCompactIndexBlobs(blobsToCompact, opt) {
+		return nil, nil
+	}
+
+	return blobsToCompact, nil
+}
+
 func (m *ManagerV0) registerCompaction(ctx context.Context, inputs, outputs []blob.Metadata, maxEventualConsistencySettleTime time.Duration) error {
 	logEntryBytes, err := json.Marshal(&compactionLogEntry{
 		InputMetadata:  inputs,
@@ -479,8 +500,12 @@ func (m *ManagerV0) getBlobsToCompact(indexBlobs []Metadata, opt CompactOptions,
 	return nonCompactedBlobs
 }
 
+func (m *ManagerV0) shouldCompactIndexBlobs(indexBlobs []Metadata, opt CompactOptions) bool {
+	return len(indexBlobs) > 1 || !opt.DropDeletedBefore.IsZero() || len(opt.DropContents) > 0
+}
+
 func (m *ManagerV0) compactIndexBlobs(ctx context.Context, indexBlobs []Metadata, opt CompactOptions) error {
-	if len(indexBlobs) <= 1 && opt.DropDeletedBefore.IsZero() && len(opt.DropContents) == 0 {
+	if !m.shouldCompactIndexBlobs(indexBlobs, opt) {
 		return nil
 	}
 
@@ -507,7 +532,12 @@ func (m *ManagerV0) compactIndexBlobs(ctx context.Context, indexBlobs []Metadata
 	// we must do it after all input blobs have been merged, otherwise we may resurrect contents.
 	m.dropContentsFromBuilder(bld, opt)
 
-	dataShards, cleanupShards, err := bld.BuildShards(mp.IndexVersion, false, DefaultIndexShardSize)
+	ordering, err := mp.GetIndexOrdering()
+	if err != nil {
+		return errors.Wrap(err, "index ordering")
+	}
+
+	dataShards, cleanupShards, err := bld.BuildShards(mp.IndexVersion, ordering, false, DefaultIndexShardSize)
 	if err != nil {
 		return errors.Wrap(err, "unable to build an index")
 	}
@@ -539,14 +569,9 @@ func (m *ManagerV0) dropContentsFromBuilder(bld index.Builder, opt CompactOption
 	if !opt.DropDeletedBefore.IsZero() {
 		m.log.Debugf("drop-content-deleted-before %v", opt.DropDeletedBefore)
 
-		for _, i := range bld {
-			if i.Deleted && i.Timestamp().Before(opt.DropDeletedBefore) {
-				m.log.Debugf("drop-from-index-old-deleted %v %v", i.ContentID, i.Timestamp())
-				delete(bld, i.ContentID)
-			}
-		}
+		purged := bld.PurgeDeletedBefore(opt.DropDeletedBefore)
 
-		m.log.Debugf("finished drop-content-deleted-before %v", opt.DropDeletedBefore)
+		m.log.Debugf("finished drop-content-deleted-before %v, dropped %v", opt.DropDeletedBefore, purged)
 	}
 }
 
@@ -564,12 +589,7 @@ func addIndexBlobsToBuilder(ctx context.Context, enc *EncryptionManager, bld ind
 		return errors.Wrapf(err, "unable to open index blob %q", indexBlobID)
 	}
 
-	_ = ndx.Iterate(index.AllIDs, func(i index.Info) error {
-		bld.Add(i)
-		return nil
-	})
-
-	return nil
+	return errors.Wrapf(bld.AddIndex(ndx), "unable to read index blob %q", indexBlobID)
 }
 
 func blobsOlderThan(m []blob.Metadata, cutoffTime time.Time) []blob.Metadata {
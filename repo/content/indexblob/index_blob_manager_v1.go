@@ -57,8 +57,19 @@ func (m *ManagerV1) Invalidate() {
 	m.epochMgr.Invalidate()
 }
 
-// Compact advances the deletion watermark.
+// Compact advances the deletion watermark. When compaction is forced, it also advances the write epoch
+// (if permitted by epoch parameters) and compacts the oldest uncompacted closed epoch.
 func (m *ManagerV1) Compact(ctx context.Context, opt CompactOptions) error {
+	if opt.Forced {
+		if err := m.epochMgr.MaybeAdvanceWriteEpoch(ctx); err != nil {
+			return errors.Wrap(err, "error advancing epoch")
+		}
+
+		if err := m.epochMgr.MaybeCompactSingleEpoch(ctx); err != nil {
+			return errors.Wrap(err, "error compacting single epoch")
+		}
+	}
+
 	if opt.DropDeletedBefore.IsZero() {
 		return nil
 	}
@@ -66,6 +77,12 @@ func (m *ManagerV1) Compact(ctx context.Context, opt CompactOptions) error {
 	return errors.Wrap(m.epochMgr.AdvanceDeletionWatermark(ctx, opt.DropDeletedBefore), "error advancing deletion watermark")
 }
 
+// PlanCompaction implements Manager. Index blobs are merged by the epoch manager as epochs
+// are closed rather than by Compact(), so there are never any index blobs to merge.
+func (m *ManagerV1) PlanCompaction(ctx context.Context, opt CompactOptions) ([]Metadata, error) {
+	return nil, nil
+}
+
 // CompactEpoch compacts the provided index blobs and writes a new set of blobs.
 func (m *ManagerV1) CompactEpoch(ctx context.Context, blobIDs []blob.ID, outputPrefix blob.ID) error {
 	tmpbld := make(index.Builder)
@@ -81,7 +98,12 @@ func (m *ManagerV1) CompactEpoch(ctx context.Context, blobIDs []blob.ID, outputP
 		return errors.Wrap(mperr, "mutable parameters")
 	}
 
-	dataShards, cleanupShards, err := tmpbld.BuildShards(mp.IndexVersion, true, DefaultIndexShardSize)
+	ordering, err := mp.GetIndexOrdering()
+	if err != nil {
+		return errors.Wrap(err, "index ordering")
+	}
+
+	dataShards, cleanupShards, err := tmpbld.BuildShards(mp.IndexVersion, ordering, true, DefaultIndexShardSize)
 	if err != nil {
 		return errors.Wrap(err, "unable to build index dataShards")
 	}
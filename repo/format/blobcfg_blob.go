@@ -3,12 +3,14 @@
 import (
 	"context"
 	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/checksum"
 )
 
 // KopiaBlobCfgBlobID is the identifier of a BLOB that describes BLOB retention
@@ -20,6 +22,15 @@
 type BlobStorageConfiguration struct {
 	RetentionMode   blob.RetentionMode `json:"retentionMode,omitempty"`
 	RetentionPeriod time.Duration      `json:"retentionPeriod,omitempty"`
+
+	// ChecksumAlgorithm is the algorithm of checksums stored with repository blobs, none when empty.
+	// It is selected when the repository is created and can't be changed afterwards.
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
+
+	// MaxObjectSize is the maximum size of a single object in the storage, larger blobs are split into
+	// multiple objects. It is selected when the repository is created and can't be changed afterwards,
+	// since all clients must agree on it to read split blobs and to recognize their parts.
+	MaxObjectSize int64 `json:"maxObjectSize,omitempty"`
 }
 
 // IsRetentionEnabled returns true if retention is enabled on the blob-config
@@ -38,6 +49,14 @@ func (r *BlobStorageConfiguration) Validate() error {
 		return errors.Errorf("invalid retention-period, the minimum required is 1-day and there is no maximum limit")
 	}
 
+	if r.ChecksumAlgorithm != "" && !slices.Contains(checksum.SupportedAlgorithms(), r.ChecksumAlgorithm) {
+		return errors.Errorf("unsupported blob checksum algorithm: %q", r.ChecksumAlgorithm)
+	}
+
+	if r.MaxObjectSize < 0 {
+		return errors.Errorf("invalid maximum object size: %v", r.MaxObjectSize)
+	}
+
 	return nil
 }
 
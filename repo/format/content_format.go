@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"slices"
 
 	"github.com/pkg/errors"
 
@@ -67,6 +68,49 @@ type MutableParameters struct {
 	MaxPackSize     int              `json:"maxPackSize,omitempty"`     // maximum size of a pack object
 	IndexVersion    int              `json:"indexVersion,omitempty"`    // force particular index format version (1,2,..)
 	EpochParameters epoch.Parameters `json:"epochParameters,omitempty"` // epoch manager parameters
+
+	// MaxIndexBlobs is the maximum number of active index blobs, above which index compaction is forced
+	// when flushing indexes regardless of maintenance schedule, 0 == unlimited.
+	MaxIndexBlobs int `json:"maxIndexBlobs,omitempty"`
+
+	// IndexOrdering is the name of the ordering of entries in index blobs, chosen when the repository is created.
+	IndexOrdering string `json:"indexOrdering,omitempty"`
+
+	// PackAlignment is the boundary (power of two) to which the offsets of contents within pack blobs are aligned
+	// by padding, which benefits storage that performs better with aligned reads, 0 == no alignment.
+	PackAlignment int `json:"packAlignment,omitempty"`
+
+	// DedupScope determines the scope within which identical contents are deduplicated, empty == DedupScopeGlobal.
+	DedupScope DedupScope `json:"dedupScope,omitempty"`
+}
+
+// DedupScope determines the scope within which identical contents are deduplicated.
+type DedupScope string
+
+// Supported deduplication scopes.
+const (
+	// DedupScopeGlobal deduplicates identical contents across the entire repository.
+	DedupScopeGlobal DedupScope = "global"
+
+	// DedupScopePerSource deduplicates identical contents only within the same snapshot source,
+	// which trades storage for isolation between sources.
+	DedupScopePerSource DedupScope = "per-source"
+)
+
+// SupportedDedupScopes lists supported deduplication scopes.
+//
+//nolint:gochecknoglobals
+var SupportedDedupScopes = []DedupScope{DedupScopeGlobal, DedupScopePerSource}
+
+// IsPerSource returns true if contents are deduplicated only within the same snapshot source.
+func (s DedupScope) IsPerSource() bool {
+	return s == DedupScopePerSource
+}
+
+// GetIndexOrdering returns the ordering of entries in index blobs.
+func (v *MutableParameters) GetIndexOrdering() (*index.Ordering, error) {
+	//nolint:wrapcheck
+	return index.OrderingByName(v.IndexOrdering)
 }
 
 // Validate validates the parameters.
@@ -83,6 +127,27 @@ func (v *MutableParameters) Validate() error {
 		return errors.Errorf("invalid index version, supported versions are 1 & 2")
 	}
 
+	if v.MaxIndexBlobs < 0 {
+		return errors.Errorf("invalid max index blobs")
+	}
+
+	if a := v.PackAlignment; a < 0 || a > maxValidPackAlignment || a&(a-1) != 0 {
+		return errors.Errorf("invalid pack alignment, must be a power of two <= %v", units.BytesString(maxValidPackAlignment))
+	}
+
+	if v.DedupScope != "" && !slices.Contains(SupportedDedupScopes, v.DedupScope) {
+		return errors.Errorf("invalid dedup scope %q", v.DedupScope)
+	}
+
+	o, err := v.GetIndexOrdering()
+	if err != nil {
+		return errors.Wrap(err, "invalid index ordering")
+	}
+
+	if !o.IsDefault() && v.IndexVersion < index.Version2 {
+		return errors.Errorf("index ordering %q requires index version %v", o.Name, index.Version2)
+	}
+
 	if err := v.EpochParameters.Validate(); err != nil {
 		return errors.Wrap(err, "invalid epoch parameters")
 	}
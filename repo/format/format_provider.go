@@ -16,6 +16,8 @@
 	minValidPackSize = 10 << 20
 	maxValidPackSize = 120 << 20
 
+	maxValidPackAlignment = 1 << 20
+
 	// CurrentWriteVersion is the version of the repository applied to new repositories.
 	CurrentWriteVersion = FormatVersion3
 
@@ -97,6 +99,10 @@ func NewFormattingOptionsProvider(f0 *ContentFormat, formatBytes []byte) (Provid
 		return nil, errors.Errorf("index version %v is not supported", f.IndexVersion)
 	}
 
+	if _, err := f.GetIndexOrdering(); err != nil {
+		return nil, errors.Wrap(err, "unable to determine index ordering")
+	}
+
 	// apply default
 	if f.MaxPackSize == 0 {
 		// legacy only, apply default
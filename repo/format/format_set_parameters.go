@@ -23,10 +23,22 @@ func (m *Manager) SetParameters(
 		return errors.Wrap(err, "invalid parameters")
 	}
 
+	if mp.IndexOrdering != m.repoConfig.ContentFormat.IndexOrdering {
+		return errors.Errorf("index ordering can't be changed after the repository has been created")
+	}
+
 	if err := blobcfg.Validate(); err != nil {
 		return errors.Wrap(err, "invalid blob-config options")
 	}
 
+	if blobcfg.ChecksumAlgorithm != m.blobCfgBlob.ChecksumAlgorithm {
+		return errors.Errorf("blob checksum algorithm can't be changed after the repository has been created")
+	}
+
+	if blobcfg.MaxObjectSize != m.blobCfgBlob.MaxObjectSize {
+		return errors.Errorf("maximum object size can't be changed after the repository has been created")
+	}
+
 	m.repoConfig.ContentFormat.MutableParameters = mp
 	m.repoConfig.RequiredFeatures = requiredFeatures
 
@@ -9,6 +9,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/ecc"
@@ -42,6 +43,8 @@ type NewRepositoryOptions struct {
 	RetentionMode                     blob.RetentionMode   `json:"retentionMode,omitempty"`
 	RetentionPeriod                   time.Duration        `json:"retentionPeriod,omitempty"`
 	FormatBlockKeyDerivationAlgorithm string               `json:"formatBlockKeyDerivationAlgorithm,omitempty"`
+	BlobChecksumAlgorithm             string               `json:"blobChecksumAlgorithm,omitempty"` // checksum stored with each blob, none when empty
+	MaxObjectSize                     int64                `json:"maxObjectSize,omitempty"`         // split blobs larger than this, defaults to the storage option
 }
 
 // Initialize creates initial repository data structures in the specified storage with given credentials.
@@ -50,6 +53,13 @@ func Initialize(ctx context.Context, st blob.Storage, opt *NewRepositoryOptions,
 		opt = &NewRepositoryOptions{}
 	}
 
+	if opt.MaxObjectSize == 0 {
+		// the limit of the storage used to create the repository applies to all clients.
+		o := *opt
+		o.MaxObjectSize = maxObjectSizeFromConnectionInfo(st.ConnectionInfo())
+		opt = &o
+	}
+
 	formatBlob := formatBlobFromOptions(opt)
 	blobcfg := blobCfgBlobFromOptions(opt)
 
@@ -75,8 +85,10 @@ func formatBlobFromOptions(opt *NewRepositoryOptions) *format.KopiaRepositoryJSO
 
 func blobCfgBlobFromOptions(opt *NewRepositoryOptions) format.BlobStorageConfiguration {
 	return format.BlobStorageConfiguration{
-		RetentionMode:   opt.RetentionMode,
-		RetentionPeriod: opt.RetentionPeriod,
+		RetentionMode:     opt.RetentionMode,
+		RetentionPeriod:   opt.RetentionPeriod,
+		ChecksumAlgorithm: opt.BlobChecksumAlgorithm,
+		MaxObjectSize:     opt.MaxObjectSize,
 	}
 }
 
@@ -108,6 +120,8 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 				MaxPackSize:     applyDefaultInt(opt.BlockFormat.MaxPackSize, 20<<20), //nolint:mnd
 				IndexVersion:    applyDefaultInt(opt.BlockFormat.IndexVersion, content.DefaultIndexVersion),
 				EpochParameters: opt.BlockFormat.EpochParameters,
+				IndexOrdering:   opt.BlockFormat.IndexOrdering,
+				PackAlignment:   opt.BlockFormat.PackAlignment,
 			},
 			EnablePasswordChange: opt.BlockFormat.EnablePasswordChange,
 		},
@@ -120,6 +134,16 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 		f.HMACSecret = nil
 	}
 
+	if opt.BlobChecksumAlgorithm != "" {
+		// blobs can't be read by versions which don't strip the checksums.
+		f.RequiredFeatures = append(f.RequiredFeatures, feature.Required{Feature: featureBlobChecksum})
+	}
+
+	if opt.MaxObjectSize > 0 {
+		// versions which don't reassemble split blobs would treat their parts as unreferenced.
+		f.RequiredFeatures = append(f.RequiredFeatures, feature.Required{Feature: featureSplitBlobs})
+	}
+
 	if fv == format.FormatVersion1 || f.ContentFormat.ECCOverheadPercent == 0 {
 		f.ContentFormat.ECC = ""
 		f.ContentFormat.ECCOverheadPercent = 0
@@ -61,6 +61,46 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 	// iterate unreferenced blobs and count them + optionally send to the channel to be deleted
 	log(ctx).Info("Looking for unreferenced blobs...")
 
+	if err := iterateBlobsToDelete(ctx, rep, opt, safety, func(bm blob.Metadata) {
+		unreferenced.Add(bm.Length)
+
+		if !opt.DryRun {
+			unused <- bm
+		}
+	}); err != nil {
+		close(unused)
+
+		return 0, err
+	}
+
+	close(unused)
+
+	unreferencedCount, unreferencedSize := unreferenced.Approximate()
+	log(ctx).Debugf("Found %v blobs to delete (%v)", unreferencedCount, units.BytesString(unreferencedSize))
+
+	// wait for all delete workers to finish.
+	if err := eg.Wait(); err != nil {
+		return 0, errors.Wrap(err, "worker error")
+	}
+
+	if opt.DryRun {
+		return int(unreferencedCount), nil
+	}
+
+	del, cnt := deleted.Approximate()
+
+	log(ctx).Infof("Deleted total %v unreferenced blobs (%v)", del, units.BytesString(cnt))
+
+	return int(del), nil
+}
+
+// iterateBlobsToDelete invokes the provided callback for each unreferenced blob that is old enough
+// to be safely deleted according to the options and safety parameters. The callback may be invoked concurrently.
+func iterateBlobsToDelete(ctx context.Context, rep repo.DirectRepository, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters, cb func(bm blob.Metadata)) error {
+	if opt.Parallel == 0 {
+		opt.Parallel = 16
+	}
+
 	var prefixes []blob.ID
 	if p := opt.Prefix; p != "" {
 		prefixes = append(prefixes, p)
@@ -68,9 +108,32 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 		prefixes = append(prefixes, content.PackBlobIDPrefixRegular, content.PackBlobIDPrefixSpecial, content.BlobIDPrefixSession)
 	}
 
-	activeSessions, err := rep.ContentManager().ListActiveSessions(ctx)
+	canDelete, err := blobDeletionFilter(ctx, rep, opt, safety)
 	if err != nil {
-		return 0, errors.Wrap(err, "unable to load active sessions")
+		return err
+	}
+
+	// iterate all pack blobs + session blobs and keep ones that are too young or
+	// belong to alive sessions.
+	if err := rep.ContentReader().IterateUnreferencedBlobs(ctx, prefixes, opt.Parallel, func(bm blob.Metadata) error {
+		if canDelete(ctx, bm) {
+			cb(bm)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error looking for unreferenced blobs")
+	}
+
+	return nil
+}
+
+// blobDeletionFilter returns a function which determines whether an unreferenced blob is old enough
+// to be safely deleted according to the options and safety parameters.
+func blobDeletionFilter(ctx context.Context, rep repo.DirectRepository, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (func(ctx context.Context, bm blob.Metadata) bool, error) {
+	activeSessions, err := rep.ContentReader().ListActiveSessions(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load active sessions")
 	}
 
 	cutoffTime := opt.NotAfterTime
@@ -85,55 +148,25 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 
 	cutoffTime = cutoffTime.Add(cutoffTimeSlack)
 
-	// iterate all pack blobs + session blobs and keep ones that are too young or
-	// belong to alive sessions.
-	if err := rep.ContentManager().IterateUnreferencedBlobs(ctx, prefixes, opt.Parallel, func(bm blob.Metadata) error {
+	return func(ctx context.Context, bm blob.Metadata) bool {
 		if bm.Timestamp.After(cutoffTime) {
 			log(ctx).Debugf("  preserving %v because it was created after maintenance started", bm.BlobID)
-			return nil
+			return false
 		}
 
 		if age := cutoffTime.Sub(bm.Timestamp); age < safety.BlobDeleteMinAge {
 			log(ctx).Debugf("  preserving %v because it's too new (age: %v<%v)", bm.BlobID, age, safety.BlobDeleteMinAge)
-			return nil
+			return false
 		}
 
 		sid := content.SessionIDFromBlobID(bm.BlobID)
 		if s, ok := activeSessions[sid]; ok {
 			if age := cutoffTime.Sub(s.CheckpointTime); age < safety.SessionExpirationAge {
 				log(ctx).Debugf("  preserving %v because it's part of an active session (%v)", bm.BlobID, sid)
-				return nil
+				return false
 			}
 		}
 
-		unreferenced.Add(bm.Length)
-
-		if !opt.DryRun {
-			unused <- bm
-		}
-
-		return nil
-	}); err != nil {
-		return 0, errors.Wrap(err, "error looking for unreferenced blobs")
-	}
-
-	close(unused)
-
-	unreferencedCount, unreferencedSize := unreferenced.Approximate()
-	log(ctx).Debugf("Found %v blobs to delete (%v)", unreferencedCount, units.BytesString(unreferencedSize))
-
-	// wait for all delete workers to finish.
-	if err := eg.Wait(); err != nil {
-		return 0, errors.Wrap(err, "worker error")
-	}
-
-	if opt.DryRun {
-		return int(unreferencedCount), nil
-	}
-
-	del, cnt := deleted.Approximate()
-
-	log(ctx).Infof("Deleted total %v unreferenced blobs (%v)", del, units.BytesString(cnt))
-
-	return int(del), nil
+		return true
+	}, nil
 }
@@ -43,11 +43,29 @@ func defaultLogRetention() LogRetentionOptions {
 
 // CleanupLogs deletes old logs blobs beyond certain age, total size or count.
 func CleanupLogs(ctx context.Context, rep repo.DirectRepositoryWriter, opt LogRetentionOptions) ([]blob.Metadata, error) {
+	toDelete, err := logsToCleanup(ctx, rep, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opt.DryRun {
+		for _, bm := range toDelete {
+			if err := rep.BlobStorage().DeleteBlob(ctx, bm.BlobID); err != nil {
+				return nil, errors.Wrapf(err, "error deleting log %v", bm.BlobID)
+			}
+		}
+	}
+
+	return toDelete, nil
+}
+
+// logsToCleanup returns the log blobs beyond certain age, total size or count.
+func logsToCleanup(ctx context.Context, rep repo.DirectRepository, opt LogRetentionOptions) ([]blob.Metadata, error) {
 	if opt.TimeFunc == nil {
 		opt.TimeFunc = clock.Now
 	}
 
-	allLogBlobs, err := blob.ListAllBlobs(ctx, rep.BlobStorage(), "_")
+	allLogBlobs, err := blob.ListAllBlobs(ctx, rep.BlobReader(), "_")
 	if err != nil {
 		return nil, errors.Wrap(err, "error listing logs")
 	}
@@ -84,13 +102,5 @@ func CleanupLogs(ctx context.Context, rep repo.DirectRepositoryWriter, opt LogRe
 
 	log(ctx).Debugf("Keeping %v logs of total size %v", deletePosition, units.BytesString(totalSize))
 
-	if !opt.DryRun {
-		for _, bm := range toDelete {
-			if err := rep.BlobStorage().DeleteBlob(ctx, bm.BlobID); err != nil {
-				return nil, errors.Wrapf(err, "error deleting log %v", bm.BlobID)
-			}
-		}
-	}
-
 	return toDelete, nil
 }
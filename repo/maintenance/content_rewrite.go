@@ -83,7 +83,7 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 				}
 
 				age := rep.Time().Sub(c.Timestamp())
-				if age < safety.RewriteMinAge {
+				if tooNewToRewrite(rep, c.Info, safety) {
 					log(ctx).Debugf("Not rewriting content %v (%v bytes) from pack %v%v %v, because it's too new.", c.ContentID, c.PackedLength, c.PackBlobID, optDeleted, age)
 					continue
 				}
@@ -125,6 +125,11 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 	return errors.Errorf("failed to rewrite %v contents", failedCount)
 }
 
+// tooNewToRewrite returns true if the content is too recent to be safely rewritten.
+func tooNewToRewrite(rep repo.DirectRepository, ci content.Info, safety SafetyParameters) bool {
+	return rep.Time().Sub(ci.Timestamp()) < safety.RewriteMinAge
+}
+
 func getContentToRewrite(ctx context.Context, rep repo.DirectRepository, opt *RewriteContentsOptions) <-chan contentInfoOrError {
 	ch := make(chan contentInfoOrError)
 
@@ -13,9 +13,13 @@ func DropDeletedContents(ctx context.Context, rep repo.DirectRepositoryWriter, d
 	log(ctx).Infof("Dropping contents deleted before %v", dropDeletedBefore)
 
 	//nolint:wrapcheck
-	return rep.ContentManager().CompactIndexes(ctx, indexblob.CompactOptions{
+	return rep.ContentManager().CompactIndexes(ctx, dropDeletedContentsOptions(dropDeletedBefore, safety))
+}
+
+func dropDeletedContentsOptions(dropDeletedBefore time.Time, safety SafetyParameters) indexblob.CompactOptions {
+	return indexblob.CompactOptions{
 		AllIndexes:                       true,
 		DropDeletedBefore:                dropDeletedBefore,
 		DisableEventualConsistencySafety: safety.DisableEventualConsistencySafety,
-	})
+	}
 }
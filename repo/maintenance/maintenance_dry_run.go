@@ -0,0 +1,455 @@
+package maintenance
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/indexblob"
+)
+
+// Plan describes the tasks that would be performed by maintenance in a given mode.
+type Plan struct {
+	Mode  Mode          `json:"mode"`
+	Time  time.Time     `json:"time"`
+	Tasks []PlannedTask `json:"tasks"`
+
+	// EstimatedBytesReclaimed is the total number of bytes of blobs that would be deleted.
+	EstimatedBytesReclaimed int64 `json:"estimatedBytesReclaimed"`
+
+	// EstimatedDuration is the sum of estimated durations of all tasks that would run.
+	EstimatedDuration time.Duration `json:"estimatedDuration"`
+}
+
+// PlannedTask describes what a single maintenance task would do.
+type PlannedTask struct {
+	Task TaskType `json:"task"`
+
+	// SkipReason is set when the task would not run.
+	SkipReason string `json:"skipReason,omitempty"`
+
+	// IndexBlobs are index blobs that would be merged.
+	IndexBlobs []blob.ID `json:"indexBlobs,omitempty"`
+
+	// Blobs are blobs that would be deleted.
+	Blobs []blob.ID `json:"blobs,omitempty"`
+
+	// ContentCount and ContentBytes describe contents that would be rewritten or dropped from the index.
+	ContentCount int   `json:"contentCount,omitempty"`
+	ContentBytes int64 `json:"contentBytes,omitempty"`
+
+	EstimatedBytesReclaimed int64 `json:"estimatedBytesReclaimed,omitempty"`
+
+	// EstimatedDuration is the average duration of previous successful runs of the task, zero if unknown.
+	EstimatedDuration time.Duration `json:"estimatedDuration,omitempty"`
+}
+
+// Skipped returns true if the task would not run.
+func (t *PlannedTask) Skipped() bool {
+	return t.SkipReason != ""
+}
+
+// DryRun returns the plan of maintenance in the provided mode without modifying the repository.
+//
+// The plan is computed from the same phases, criteria and options as Run(), but unlike RunExclusive() it
+// does not acquire the maintenance lock, update the schedule or check ownership, so it is safe to
+// call while maintenance is running elsewhere. Each planned task is recorded in a copy of the schedule,
+// so that later phases are planned the way they would run after it. Snapshot garbage collection is not included.
+func DryRun(ctx context.Context, rep repo.DirectRepository, mode Mode, safety SafetyParameters) (*Plan, error) {
+	p, err := GetParams(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get maintenance params")
+	}
+
+	if mode == ModeAuto {
+		mode, err = shouldRun(ctx, rep, p)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to determine if maintenance is required")
+		}
+	}
+
+	s, err := GetSchedule(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get schedule")
+	}
+
+	pl := &planner{
+		rep:    rep,
+		params: p,
+		s:      cloneSchedule(s),
+		safety: safety,
+		plan:   &Plan{Mode: mode, Time: rep.Time()},
+
+		rewrittenPacks: map[blob.ID]bool{},
+		keptPacks:      map[blob.ID]bool{},
+	}
+
+	switch mode {
+	case ModeNone:
+		return pl.plan, nil
+
+	case ModeQuick:
+		err = pl.planQuick(ctx)
+
+	case ModeFull:
+		err = pl.planFull(ctx)
+
+	default:
+		return nil, errors.Errorf("unknown mode %q", mode)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range pl.plan.Tasks {
+		pl.plan.EstimatedBytesReclaimed += t.EstimatedBytesReclaimed
+		pl.plan.EstimatedDuration += t.EstimatedDuration
+	}
+
+	return pl.plan, nil
+}
+
+type planner struct {
+	rep    repo.DirectRepository
+	params *Params
+	s      *Schedule // copy of the schedule with planned tasks recorded in it
+	safety SafetyParameters
+	plan   *Plan
+
+	// packs with contents that would be rewritten and packs in which some contents would be kept,
+	// packs which are only in the former would be orphaned by the content rewrite.
+	rewrittenPacks map[blob.ID]bool
+	keptPacks      map[blob.ID]bool
+}
+
+// cloneSchedule returns a copy of the schedule that can be modified without affecting the original.
+func cloneSchedule(s *Schedule) *Schedule {
+	c := *s
+	c.Runs = map[TaskType][]RunInfo{}
+
+	for k, v := range s.Runs {
+		c.Runs[k] = slices.Clone(v)
+	}
+
+	return &c
+}
+
+// add appends the task to the plan. Unless it's skipped its duration is estimated based on previous runs
+// and it's recorded as a successful run in the schedule, like ReportRun() would.
+func (pl *planner) add(t PlannedTask) {
+	if !t.Skipped() {
+		t.EstimatedDuration = averageRunDuration(pl.s.Runs[t.Task])
+
+		pl.s.ReportRun(t.Task, RunInfo{Start: pl.plan.Time, End: pl.plan.Time, Success: true})
+	}
+
+	pl.plan.Tasks = append(pl.plan.Tasks, t)
+}
+
+func (pl *planner) skip(task TaskType, reason string) {
+	pl.add(PlannedTask{Task: task, SkipReason: reason})
+}
+
+// planQuick mirrors runQuickMaintenance().
+func (pl *planner) planQuick(ctx context.Context) error {
+	_, ok, emerr := pl.rep.ContentReader().EpochManager(ctx)
+	if ok {
+		return nil
+	}
+
+	if emerr != nil {
+		return errors.Wrap(emerr, "epoch manager")
+	}
+
+	if err := pl.planPhases(ctx, quickMaintenancePhases(), false); err != nil {
+		return err
+	}
+
+	return pl.planCleanupLogs(ctx)
+}
+
+// planFull mirrors runFullMaintenance().
+func (pl *planner) planFull(ctx context.Context) error {
+	if err := pl.planPhases(ctx, fullMaintenancePhases(), true); err != nil {
+		return err
+	}
+
+	if pl.params.ExtendObjectLocks {
+		pl.add(PlannedTask{Task: TaskExtendBlobRetentionTimeFull})
+	}
+
+	if err := pl.planEpochMaintenanceFull(ctx); err != nil {
+		return err
+	}
+
+	return pl.planCleanupLogs(ctx)
+}
+
+// planPhases mirrors runPhase() for each of the provided phases.
+func (pl *planner) planPhases(ctx context.Context, phases []Phase, full bool) error {
+	for _, phase := range phases {
+		var err error
+
+		switch phase {
+		case PhaseRewriteContents:
+			err = pl.planRewriteContents(ctx, full)
+
+		case PhaseDeleteOrphanedBlobs:
+			err = pl.planDeleteOrphanedBlobs(ctx, full)
+
+		case PhaseIndexCompaction:
+			err = pl.planIndexCompactionPhase(ctx, full)
+
+		case PhaseDropDeletedContents:
+			err = pl.planDropDeletedContents(ctx)
+
+		default:
+			return errors.Errorf("unknown maintenance phase %q", phase)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pl *planner) planRewriteContents(ctx context.Context, full bool) error {
+	task, skipReason := rewriteContentsTask(pl.s, full, pl.safety)
+	if skipReason != "" {
+		pl.skip(task, skipReason)
+		return nil
+	}
+
+	opt := rewriteContentsQuickOptions()
+	if task == TaskRewriteContentsFull {
+		opt = rewriteContentsFullOptions()
+	}
+
+	t := PlannedTask{Task: task}
+
+	for c := range getContentToRewrite(ctx, pl.rep, opt) {
+		if c.err != nil {
+			return errors.Wrap(c.err, "unable to find contents to rewrite")
+		}
+
+		if tooNewToRewrite(pl.rep, c.Info, pl.safety) {
+			pl.keptPacks[c.PackBlobID] = true
+			continue
+		}
+
+		pl.rewrittenPacks[c.PackBlobID] = true
+
+		t.ContentCount++
+		t.ContentBytes += int64(c.PackedLength)
+	}
+
+	pl.add(t)
+
+	return nil
+}
+
+func (pl *planner) planDeleteOrphanedBlobs(ctx context.Context, full bool) error {
+	task, skipReason := deleteOrphanedBlobsTask(pl.rep.Time(), pl.s, full, pl.safety)
+	if skipReason != "" {
+		left := nextBlobDeleteTime(pl.s, pl.safety).Sub(pl.rep.Time()).Truncate(time.Second)
+
+		pl.skip(task, skipReason+" ("+left.String()+" left)")
+
+		return nil
+	}
+
+	opt := deleteOrphanedBlobsQuickOptions(pl.rep.Time())
+	if task == TaskDeleteOrphanedBlobsFull {
+		opt = deleteOrphanedBlobsFullOptions(pl.rep.Time())
+	}
+
+	var (
+		mu sync.Mutex
+		t  = PlannedTask{Task: task}
+	)
+
+	if err := iterateBlobsToDelete(ctx, pl.rep, opt, pl.safety, func(bm blob.Metadata) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		t.Blobs = append(t.Blobs, bm.BlobID)
+		t.EstimatedBytesReclaimed += bm.Length
+	}); err != nil {
+		return err
+	}
+
+	if err := pl.planDeletePacksOrphanedByRewrite(ctx, opt, &t); err != nil {
+		return err
+	}
+
+	pl.add(t)
+
+	return nil
+}
+
+// planDeletePacksOrphanedByRewrite adds packs which would be orphaned by the planned content rewrite,
+// which are still referenced by the index, but would be deleted by a real run if the safety
+// parameters allow it.
+func (pl *planner) planDeletePacksOrphanedByRewrite(ctx context.Context, opt DeleteUnreferencedBlobsOptions, t *PlannedTask) error {
+	canDelete, err := blobDeletionFilter(ctx, pl.rep, opt, pl.safety)
+	if err != nil {
+		return err
+	}
+
+	for packID := range pl.rewrittenPacks {
+		if pl.keptPacks[packID] || !strings.HasPrefix(string(packID), string(opt.Prefix)) {
+			continue
+		}
+
+		bm, err := pl.rep.BlobReader().GetMetadata(ctx, packID)
+		if errors.Is(err, blob.ErrBlobNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "error getting metadata of %v", packID)
+		}
+
+		if canDelete(ctx, bm) {
+			t.Blobs = append(t.Blobs, bm.BlobID)
+			t.EstimatedBytesReclaimed += bm.Length
+		}
+	}
+
+	return nil
+}
+
+// planIndexCompactionPhase mirrors runPhaseIndexCompaction().
+func (pl *planner) planIndexCompactionPhase(ctx context.Context, full bool) error {
+	_, hasEpochManager, emerr := pl.rep.ContentReader().EpochManager(ctx)
+	if emerr != nil {
+		return errors.Wrap(emerr, "epoch manager")
+	}
+
+	if !hasEpochManager {
+		return pl.planIndexCompaction(ctx, TaskIndexCompaction, indexCompactionQuickOptions(pl.safety))
+	}
+
+	if full {
+		return pl.planEpochMaintenanceFull(ctx)
+	}
+
+	pl.add(PlannedTask{Task: TaskEpochCompactSingle})
+	pl.add(PlannedTask{Task: TaskEpochAdvance})
+
+	return nil
+}
+
+func (pl *planner) planIndexCompaction(ctx context.Context, task TaskType, opt indexblob.CompactOptions) error {
+	blobs, err := pl.rep.ContentReader().PlanIndexCompaction(ctx, opt)
+	if err != nil {
+		return errors.Wrap(err, "unable to plan index compaction")
+	}
+
+	t := PlannedTask{Task: task}
+
+	for _, bm := range blobs {
+		t.IndexBlobs = append(t.IndexBlobs, bm.BlobID)
+	}
+
+	pl.add(t)
+
+	return nil
+}
+
+func (pl *planner) planDropDeletedContents(ctx context.Context) error {
+	safeDropTime := dropDeletedContentsTime(pl.rep, pl.s, pl.safety)
+	if safeDropTime.IsZero() {
+		pl.skip(TaskDropDeletedContentsFull, skipReasonRecentGC)
+		return nil
+	}
+
+	if err := pl.planIndexCompaction(ctx, TaskDropDeletedContentsFull, dropDeletedContentsOptions(safeDropTime, pl.safety)); err != nil {
+		return err
+	}
+
+	t := &pl.plan.Tasks[len(pl.plan.Tasks)-1]
+
+	//nolint:wrapcheck
+	return pl.rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		if ci.Deleted && ci.Timestamp().Before(safeDropTime) {
+			t.ContentCount++
+			t.ContentBytes += int64(ci.PackedLength)
+		}
+
+		return nil
+	})
+}
+
+// planEpochMaintenanceFull lists the epoch manager tasks, each of which decides on its own what to do when it runs.
+func (pl *planner) planEpochMaintenanceFull(ctx context.Context) error {
+	_, hasEpochManager, emerr := pl.rep.ContentReader().EpochManager(ctx)
+	if emerr != nil {
+		return errors.Wrap(emerr, "epoch manager")
+	}
+
+	if !hasEpochManager {
+		return nil
+	}
+
+	for _, task := range []TaskType{
+		TaskEpochCompactSingle,
+		TaskEpochAdvance,
+		TaskEpochGenerateRange,
+		TaskEpochCleanupMarkers,
+		TaskEpochDeleteSupersededIndexes,
+	} {
+		pl.add(PlannedTask{Task: task})
+	}
+
+	return nil
+}
+
+func (pl *planner) planCleanupLogs(ctx context.Context) error {
+	toDelete, err := logsToCleanup(ctx, pl.rep, pl.params.LogRetention.OrDefault())
+	if err != nil {
+		return errors.Wrap(err, "unable to find logs to clean up")
+	}
+
+	t := PlannedTask{Task: TaskCleanupLogs}
+
+	for _, bm := range toDelete {
+		t.Blobs = append(t.Blobs, bm.BlobID)
+		t.EstimatedBytesReclaimed += bm.Length
+	}
+
+	pl.add(t)
+
+	return nil
+}
+
+// averageRunDuration returns the average duration of successful runs or zero if there were none.
+func averageRunDuration(runs []RunInfo) time.Duration {
+	var (
+		total time.Duration
+		cnt   int
+	)
+
+	for _, r := range runs {
+		if r.Success && r.End.After(r.Start) {
+			total += r.End.Sub(r.Start)
+			cnt++
+		}
+	}
+
+	if cnt == 0 {
+		return 0
+	}
+
+	return total / time.Duration(cnt)
+}
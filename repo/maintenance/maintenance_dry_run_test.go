@@ -0,0 +1,237 @@
+package maintenance_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func (s *formatSpecificTestSuite) TestDryRun(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion)
+
+	w := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{})
+	io.WriteString(w, "hello world!")
+	w.Result()
+	w.Close()
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	const (
+		extraBlobID1 blob.ID = "pdeadbeef1"
+		extraBlobID2 blob.ID = "pdeadbeef2"
+	)
+
+	mustPutDummyBlob(t, env.RepositoryWriter.BlobStorage(), extraBlobID1)
+	mustPutDummyBlob(t, env.RepositoryWriter.BlobStorage(), extraBlobID2)
+
+	blobsBefore, err := blob.ListAllBlobs(ctx, env.RepositoryWriter.BlobStorage(), "")
+	require.NoError(t, err)
+
+	scheduleBefore, err := maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+
+	plan, err := maintenance.DryRun(ctx, env.RepositoryWriter, maintenance.ModeFull, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Equal(t, maintenance.ModeFull, plan.Mode)
+
+	var deleteTask *maintenance.PlannedTask
+
+	for i := range plan.Tasks {
+		if plan.Tasks[i].Task == maintenance.TaskDeleteOrphanedBlobsFull {
+			deleteTask = &plan.Tasks[i]
+		}
+	}
+
+	require.NotNil(t, deleteTask)
+	require.False(t, deleteTask.Skipped())
+	require.ElementsMatch(t, []blob.ID{extraBlobID1, extraBlobID2}, deleteTask.Blobs)
+	require.EqualValues(t, 6, deleteTask.EstimatedBytesReclaimed)
+	require.GreaterOrEqual(t, plan.EstimatedBytesReclaimed, deleteTask.EstimatedBytesReclaimed)
+
+	// tasks are planned in the order of full maintenance phases.
+	require.Equal(t, []maintenance.TaskType{
+		maintenance.TaskRewriteContentsFull,
+		maintenance.TaskDropDeletedContentsFull,
+		maintenance.TaskDeleteOrphanedBlobsFull,
+	}, []maintenance.TaskType{plan.Tasks[0].Task, plan.Tasks[1].Task, plan.Tasks[2].Task})
+
+	// the plan only requires read access to the repository.
+	dr, ok := env.Repository.(repo.DirectRepository)
+	require.True(t, ok)
+
+	roPlan, err := maintenance.DryRun(ctx, dr, maintenance.ModeFull, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Len(t, roPlan.Tasks, len(plan.Tasks))
+
+	// the plan is serializable.
+	j, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	var plan2 maintenance.Plan
+
+	require.NoError(t, json.Unmarshal(j, &plan2))
+	require.Equal(t, plan.Tasks, plan2.Tasks)
+
+	// nothing has been modified.
+	blobsAfter, err := blob.ListAllBlobs(ctx, env.RepositoryWriter.BlobStorage(), "")
+	require.NoError(t, err)
+	require.Equal(t, blobsBefore, blobsAfter)
+
+	scheduleAfter, err := maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Equal(t, scheduleBefore, scheduleAfter)
+
+	// the real run deletes the blobs found by the dry run.
+	_, err = maintenance.DeleteUnreferencedBlobs(ctx, env.RepositoryWriter, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone)
+	require.NoError(t, err)
+
+	verifyBlobNotFound(t, env.RepositoryWriter.BlobStorage(), extraBlobID1)
+	verifyBlobNotFound(t, env.RepositoryWriter.BlobStorage(), extraBlobID2)
+}
+
+func (s *formatSpecificTestSuite) TestDryRun_SafetyFull(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ft.NowFunc()
+		},
+	})
+
+	mustWriteShortPacks(t, env, 2)
+
+	const extraBlobID blob.ID = "pdeadbeef1"
+
+	mustPutDummyBlob(t, env.RepositoryWriter.BlobStorage(), extraBlobID)
+
+	// make contents and blobs old enough to be rewritten and deleted.
+	ft.Advance(48 * time.Hour)
+
+	plan, err := maintenance.DryRun(ctx, env.RepositoryWriter, maintenance.ModeFull, maintenance.SafetyFull)
+	require.NoError(t, err)
+
+	rewriteTask := findPlannedTask(t, plan, maintenance.TaskRewriteContentsFull)
+	require.False(t, rewriteTask.Skipped())
+	require.NotZero(t, rewriteTask.ContentCount)
+
+	// the planned rewrite postpones deletion of orphaned blobs, like it does in a real run.
+	deleteTask := findPlannedTask(t, plan, maintenance.TaskDeleteOrphanedBlobsFull)
+	require.True(t, deleteTask.Skipped())
+	require.Contains(t, deleteTask.SkipReason, "not enough time has passed since the last content rewrite")
+	require.Empty(t, deleteTask.Blobs)
+
+	mustRunMaintenance(t, env, maintenance.ModeFull, maintenance.SafetyFull)
+
+	verifyBlobExists(t, env.RepositoryWriter.BlobStorage(), extraBlobID)
+}
+
+func (s *formatSpecificTestSuite) TestDryRun_PacksOrphanedByRewrite(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion)
+
+	shortPacks := mustWriteShortPacks(t, env, 2)
+
+	plan, err := maintenance.DryRun(ctx, env.RepositoryWriter, maintenance.ModeFull, maintenance.SafetyNone)
+	require.NoError(t, err)
+
+	// packs orphaned by the planned rewrite are deleted in the same run.
+	deleteTask := findPlannedTask(t, plan, maintenance.TaskDeleteOrphanedBlobsFull)
+	require.False(t, deleteTask.Skipped())
+	require.ElementsMatch(t, shortPacks, deleteTask.Blobs)
+
+	before := mustListBlobIDs(t, env, "p")
+
+	mustRunMaintenance(t, env, maintenance.ModeFull, maintenance.SafetyNone)
+
+	after := mustListBlobIDs(t, env, "p")
+
+	var deleted []blob.ID
+
+	for _, id := range before {
+		if !slices.Contains(after, id) {
+			deleted = append(deleted, id)
+		}
+	}
+
+	require.ElementsMatch(t, deleteTask.Blobs, deleted)
+}
+
+// mustWriteShortPacks writes the provided number of small objects, each in a separate pack blob and returns IDs of the packs.
+func mustWriteShortPacks(t *testing.T, env *repotesting.Environment, n int) []blob.ID {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+	before := mustListBlobIDs(t, env, "p")
+
+	for i := range n {
+		w := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{})
+		fmt.Fprintf(w, "short pack %v", i)
+
+		_, err := w.Result()
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.NoError(t, env.RepositoryWriter.Flush(ctx))
+	}
+
+	var packs []blob.ID
+
+	for _, id := range mustListBlobIDs(t, env, "p") {
+		if !slices.Contains(before, id) {
+			packs = append(packs, id)
+		}
+	}
+
+	require.Len(t, packs, n)
+
+	return packs
+}
+
+func mustListBlobIDs(t *testing.T, env *repotesting.Environment, prefix blob.ID) []blob.ID {
+	t.Helper()
+
+	bms, err := blob.ListAllBlobs(testlogging.Context(t), env.RepositoryWriter.BlobStorage(), prefix)
+	require.NoError(t, err)
+
+	var ids []blob.ID
+
+	for _, bm := range bms {
+		ids = append(ids, bm.BlobID)
+	}
+
+	return ids
+}
+
+func mustRunMaintenance(t *testing.T, env *repotesting.Environment, mode maintenance.Mode, safety maintenance.SafetyParameters) {
+	t.Helper()
+
+	require.NoError(t, maintenance.RunExclusive(testlogging.Context(t), env.RepositoryWriter, mode, true, func(ctx context.Context, runParams maintenance.RunParameters) error {
+		return maintenance.Run(ctx, runParams, safety)
+	}))
+}
+
+func findPlannedTask(t *testing.T, plan *maintenance.Plan, task maintenance.TaskType) *maintenance.PlannedTask {
+	t.Helper()
+
+	for i := range plan.Tasks {
+		if plan.Tasks[i].Task == task {
+			return &plan.Tasks[i]
+		}
+	}
+
+	t.Fatalf("task %v not found in plan", task)
+
+	return nil
+}
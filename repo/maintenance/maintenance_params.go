@@ -24,7 +24,15 @@ type Params struct {
 
 	LogRetention LogRetentionOptions `json:"logRetention"`
 
+	// StatsHistory controls recording and retention of repository statistics during full maintenance.
+	StatsHistory StatsHistoryOptions `json:"statsHistory"`
+
 	ExtendObjectLocks bool `json:"extendObjectLocks"`
+
+	// UnsafeContentTTL, when set, causes full maintenance to delete contents older than the TTL, even if they
+	// are still referenced, and to delete snapshots referencing them.
+	// This is only suitable for repositories used as caches and must never be used for backups.
+	UnsafeContentTTL time.Duration `json:"unsafeContentTTL,omitempty"`
 }
 
 // isOwnedByByThisUser determines whether current user is the maintenance owner.
@@ -44,6 +52,7 @@ func DefaultParams() Params {
 			Interval: 1 * time.Hour,
 		},
 		LogRetention: defaultLogRetention(),
+		StatsHistory: defaultStatsHistory(),
 		// Don't attempt to extend object locks by default. This option may not be
 		// supported by all storage providers or blob implementations (currently
 		// supported by S3 backend) and may cause data to be kept longer than
@@ -0,0 +1,281 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+// Phase identifies a group of maintenance tasks which can be run individually.
+type Phase string
+
+// Supported maintenance phases.
+const (
+	// PhaseIndexCompaction merges index blobs, for repositories with epoch manager it compacts epochs
+	// and in full mode also generates range indexes and deletes superseded index blobs.
+	PhaseIndexCompaction Phase = "index-compaction"
+
+	// PhaseDeleteOrphanedBlobs deletes pack blobs no longer referenced by any index.
+	// In quick mode only metadata (q) pack blobs are considered.
+	PhaseDeleteOrphanedBlobs Phase = "delete-orphaned-blobs"
+
+	// PhaseRewriteContents repacks contents of under-filled packs into new packs, orphaning the old packs.
+	// In quick mode only metadata (q) packs are repacked.
+	PhaseRewriteContents Phase = "rewrite-contents"
+
+	// PhaseDropDeletedContents drops contents which have been deleted (quarantined) for long enough
+	// that no snapshot can reference them anymore from indexes.
+	PhaseDropDeletedContents Phase = "drop-deleted-contents"
+)
+
+// SupportedPhases returns the list of phases which can be run by RunPhase.
+func SupportedPhases() []Phase {
+	return []Phase{
+		PhaseIndexCompaction,
+		PhaseDeleteOrphanedBlobs,
+		PhaseRewriteContents,
+		PhaseDropDeletedContents,
+	}
+}
+
+// PhaseOptions provides options shared by all maintenance phases.
+type PhaseOptions struct {
+	// Full selects the full variant of phases which have quick and full variants.
+	Full bool
+
+	Safety SafetyParameters
+
+	// Force runs the phase even if maintenance is not owned by the local user.
+	Force bool
+}
+
+// PhaseReport describes the results of running a single maintenance phase.
+type PhaseReport struct {
+	Phase Phase     `json:"phase"`
+	Full  bool      `json:"full,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// SkipReason is set when the phase did not run because doing so now would not be safe.
+	SkipReason string `json:"skipReason,omitempty"`
+
+	DeletedBlobs int `json:"deletedBlobs,omitempty"`
+}
+
+// Skipped returns true if the phase did not run.
+func (r *PhaseReport) Skipped() bool {
+	return r.SkipReason != ""
+}
+
+// reasons for skipping phases, shared by maintenance runs and dry runs.
+const (
+	skipReasonRewriteNotFinalized = "previous content rewrite has not been finalized yet"
+	skipReasonRecentRewrite       = "not enough time has passed since the last content rewrite"
+	skipReasonRecentGC            = "not enough time has passed since previous successful snapshot GC"
+)
+
+// quickMaintenancePhases returns the phases run by quick maintenance, in order.
+func quickMaintenancePhases() []Phase {
+	return []Phase{
+		PhaseRewriteContents,
+		PhaseDeleteOrphanedBlobs,
+		PhaseIndexCompaction,
+	}
+}
+
+// fullMaintenancePhases returns the phases run by full maintenance, in order.
+func fullMaintenancePhases() []Phase {
+	return []Phase{
+		PhaseRewriteContents,
+		// rewrite indexes by dropping content entries that have been marked
+		// as deleted for a long time
+		PhaseDropDeletedContents,
+		// delete orphaned packs after some time.
+		PhaseDeleteOrphanedBlobs,
+	}
+}
+
+// rewriteContentsTask returns the task run by the content rewrite phase and the reason for skipping it,
+// if it's currently not safe to run.
+func rewriteContentsTask(s *Schedule, full bool, safety SafetyParameters) (task TaskType, skipReason string) {
+	if full {
+		if !shouldFullRewriteContents(s, safety) {
+			return TaskRewriteContentsFull, skipReasonRewriteNotFinalized
+		}
+
+		return TaskRewriteContentsFull, ""
+	}
+
+	if !shouldQuickRewriteContents(s, safety) {
+		return TaskRewriteContentsQuick, skipReasonRewriteNotFinalized
+	}
+
+	return TaskRewriteContentsQuick, ""
+}
+
+// deleteOrphanedBlobsTask returns the task run by the orphaned blob deletion phase and the reason for skipping it,
+// if it's currently not safe to run.
+func deleteOrphanedBlobsTask(now time.Time, s *Schedule, full bool, safety SafetyParameters) (task TaskType, skipReason string) {
+	task = TaskDeleteOrphanedBlobsQuick
+
+	// if the last rewrite was full (started as part of full maintenance) we must complete it by
+	// running full orphaned blob deletion, otherwise next quick maintenance will start a quick rewrite
+	// and we'd never delete blobs orphaned by full rewrite.
+	if full || hadRecentFullRewrite(s) {
+		task = TaskDeleteOrphanedBlobsFull
+	}
+
+	if !shouldDeleteOrphanedPacks(now, s, safety) {
+		return task, skipReasonRecentRewrite
+	}
+
+	return task, ""
+}
+
+// ErrMaintenanceInProgress is returned by RunPhase when maintenance is already running locally.
+var ErrMaintenanceInProgress = errors.New("maintenance is already in progress")
+
+// RunPhase runs a single maintenance phase under the maintenance lock.
+//
+// Each phase applies the same safety checks that full or quick maintenance would and records its
+// tasks in the maintenance schedule, so subsequent phases or maintenance runs observe it. Phases which
+// would not be safe to run yet, such as deleting blobs orphaned by a recent content rewrite, are
+// skipped and the reason is returned in the report. Unlike Run() this does not move the time of
+// the next scheduled maintenance.
+func RunPhase(ctx context.Context, rep repo.DirectRepositoryWriter, phase Phase, opt PhaseOptions) (*PhaseReport, error) {
+	mode := ModeQuick
+	if opt.Full {
+		mode = ModeFull
+	}
+
+	var report *PhaseReport
+
+	err := runExclusive(ctx, rep, mode, opt.Force, false, func(ctx context.Context, runParams RunParameters) error {
+		s, err := GetSchedule(ctx, runParams.rep)
+		if err != nil {
+			return errors.Wrap(err, "unable to get schedule")
+		}
+
+		report, err = runPhase(ctx, runParams, s, phase, opt)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if report == nil {
+		return nil, ErrMaintenanceInProgress
+	}
+
+	return report, nil
+}
+
+func runPhase(ctx context.Context, runParams RunParameters, s *Schedule, phase Phase, opt PhaseOptions) (*PhaseReport, error) {
+	r := &PhaseReport{
+		Phase: phase,
+		Full:  opt.Full,
+		Start: runParams.rep.Time(),
+	}
+
+	var err error
+
+	switch phase {
+	case PhaseIndexCompaction:
+		err = runPhaseIndexCompaction(ctx, runParams, s, opt)
+
+	case PhaseDeleteOrphanedBlobs:
+		err = runPhaseDeleteOrphanedBlobs(ctx, runParams, s, opt, r)
+
+	case PhaseRewriteContents:
+		err = runPhaseRewriteContents(ctx, runParams, s, opt, r)
+
+	case PhaseDropDeletedContents:
+		err = runPhaseDropDeletedContents(ctx, runParams, s, opt, r)
+
+	default:
+		return nil, errors.Errorf("unknown maintenance phase %q", phase)
+	}
+
+	r.End = runParams.rep.Time()
+
+	return r, err
+}
+
+func runPhaseIndexCompaction(ctx context.Context, runParams RunParameters, s *Schedule, opt PhaseOptions) error {
+	_, hasEpochManager, emerr := runParams.rep.ContentManager().EpochManager(ctx)
+	if emerr != nil {
+		return errors.Wrap(emerr, "epoch manager")
+	}
+
+	if hasEpochManager {
+		if opt.Full {
+			return errors.Wrap(runTaskEpochMaintenanceFull(ctx, runParams, s), "error cleaning up epoch manager")
+		}
+
+		return errors.Wrap(runTaskEpochMaintenanceQuick(ctx, runParams, s), "error running quick epoch maintenance tasks")
+	}
+
+	// consolidate many smaller indexes into fewer larger ones.
+	return errors.Wrap(runTaskIndexCompactionQuick(ctx, runParams, s, opt.Safety), "error performing index compaction")
+}
+
+func runPhaseRewriteContents(ctx context.Context, runParams RunParameters, s *Schedule, opt PhaseOptions, r *PhaseReport) error {
+	task, skipReason := rewriteContentsTask(s, opt.Full, opt.Safety)
+	if skipReason != "" {
+		notRewritingContents(ctx)
+
+		r.SkipReason = skipReason
+
+		return nil
+	}
+
+	if task == TaskRewriteContentsFull {
+		// find packs that are less than 80% full and rewrite contents in them into
+		// new consolidated packs, orphaning old packs in the process.
+		return errors.Wrap(runTaskRewriteContentsFull(ctx, runParams, s, opt.Safety), "error rewriting contents in short packs")
+	}
+
+	// find 'q' packs that are less than 80% full and rewrite contents in them into
+	// new consolidated packs, orphaning old packs in the process.
+	return errors.Wrap(runTaskRewriteContentsQuick(ctx, runParams, s, opt.Safety), "error rewriting metadata contents")
+}
+
+func runPhaseDeleteOrphanedBlobs(ctx context.Context, runParams RunParameters, s *Schedule, opt PhaseOptions, r *PhaseReport) error {
+	task, skipReason := deleteOrphanedBlobsTask(runParams.rep.Time(), s, opt.Full, opt.Safety)
+	if skipReason != "" {
+		notDeletingOrphanedBlobs(ctx, s, opt.Safety)
+
+		r.SkipReason = skipReason
+
+		return nil
+	}
+
+	var err error
+
+	if task == TaskDeleteOrphanedBlobsFull {
+		log(ctx).Debug("Performing full blob deletion.")
+		r.DeletedBlobs, err = runTaskDeleteOrphanedBlobsFull(ctx, runParams, s, opt.Safety)
+	} else {
+		log(ctx).Debug("Performing quick blob deletion.")
+		r.DeletedBlobs, err = runTaskDeleteOrphanedBlobsQuick(ctx, runParams, s, opt.Safety)
+	}
+
+	return errors.Wrap(err, "error deleting unreferenced blobs")
+}
+
+func runPhaseDropDeletedContents(ctx context.Context, runParams RunParameters, s *Schedule, opt PhaseOptions, r *PhaseReport) error {
+	dropped, err := runTaskDropDeletedContentsFull(ctx, runParams, s, opt.Safety)
+	if err != nil {
+		return errors.Wrap(err, "error dropping deleted contents")
+	}
+
+	if !dropped {
+		r.SkipReason = skipReasonRecentGC
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package maintenance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+func (s *formatSpecificTestSuite) TestRunPhase(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ft.NowFunc()
+		},
+	})
+
+	const orphanedBlobID blob.ID = "pdeadbeef1"
+
+	mustPutDummyBlob(t, env.RepositoryWriter.BlobStorage(), orphanedBlobID)
+
+	scheduleBefore, err := maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+
+	opt := maintenance.PhaseOptions{
+		Full:   true,
+		Safety: maintenance.SafetyFull,
+		Force:  true,
+	}
+
+	r, err := maintenance.RunPhase(ctx, env.RepositoryWriter, maintenance.PhaseRewriteContents, opt)
+	require.NoError(t, err)
+	require.Equal(t, maintenance.PhaseRewriteContents, r.Phase)
+	require.False(t, r.Skipped())
+
+	// orphaned blobs can't be deleted right after a rewrite.
+	r, err = maintenance.RunPhase(ctx, env.RepositoryWriter, maintenance.PhaseDeleteOrphanedBlobs, opt)
+	require.NoError(t, err)
+	require.True(t, r.Skipped())
+	verifyBlobExists(t, env.RepositoryWriter.BlobStorage(), orphanedBlobID)
+
+	ft.Advance(25 * time.Hour)
+
+	r, err = maintenance.RunPhase(ctx, env.RepositoryWriter, maintenance.PhaseDeleteOrphanedBlobs, opt)
+	require.NoError(t, err)
+	require.False(t, r.Skipped())
+	require.Equal(t, 1, r.DeletedBlobs)
+	verifyBlobNotFound(t, env.RepositoryWriter.BlobStorage(), orphanedBlobID)
+
+	// there was no snapshot GC yet.
+	r, err = maintenance.RunPhase(ctx, env.RepositoryWriter, maintenance.PhaseDropDeletedContents, opt)
+	require.NoError(t, err)
+	require.True(t, r.Skipped())
+
+	r, err = maintenance.RunPhase(ctx, env.RepositoryWriter, maintenance.PhaseIndexCompaction, opt)
+	require.NoError(t, err)
+	require.False(t, r.Skipped())
+
+	_, err = maintenance.RunPhase(ctx, env.RepositoryWriter, "no-such-phase", opt)
+	require.ErrorContains(t, err, "unknown maintenance phase")
+
+	// phases are recorded in the schedule, but don't move the next maintenance.
+	scheduleAfter, err := maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Equal(t, scheduleBefore.NextFullMaintenanceTime, scheduleAfter.NextFullMaintenanceTime)
+	require.Equal(t, scheduleBefore.NextQuickMaintenanceTime, scheduleAfter.NextQuickMaintenanceTime)
+	require.Len(t, scheduleAfter.Runs[maintenance.TaskRewriteContentsFull], 1)
+	require.Len(t, scheduleAfter.Runs[maintenance.TaskDeleteOrphanedBlobsFull], 1)
+}
@@ -46,6 +46,8 @@
 	TaskIndexCompaction              = "index-compaction"
 	TaskExtendBlobRetentionTimeFull  = "extend-blob-retention-time"
 	TaskCleanupLogs                  = "cleanup-logs"
+	TaskExpireContents               = "expire-contents"
+	TaskRecordStats                  = "record-stats"
 	TaskEpochAdvance                 = "advance-epoch"
 	TaskEpochDeleteSupersededIndexes = "delete-superseded-epoch-indexes"
 	TaskEpochCleanupMarkers          = "cleanup-epoch-markers"
@@ -92,7 +94,7 @@ func shouldRun(ctx context.Context, rep repo.DirectRepository, p *Params) (Mode,
 	return ModeNone, nil
 }
 
-func updateSchedule(ctx context.Context, runParams RunParameters) error {
+func updateSchedule(ctx context.Context, runParams RunParameters, reschedule bool) error {
 	rep := runParams.rep
 	p := runParams.Params
 
@@ -101,6 +103,11 @@ func updateSchedule(ctx context.Context, runParams RunParameters) error {
 		return errors.Wrap(err, "error getting schedule")
 	}
 
+	if !reschedule {
+		// rewrite the schedule as-is, so that its timestamp can be used as the maintenance start time.
+		return SetSchedule(ctx, rep, s)
+	}
+
 	switch runParams.Mode {
 	case ModeFull:
 		// on full cycle, also update the quick cycle
@@ -149,6 +156,17 @@ func (e NotOwnedError) Error() string {
 // lock can be acquired. Lock is passed to the function, which ensures that every call to Run()
 // is within the exclusive context.
 func RunExclusive(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mode, force bool, cb func(ctx context.Context, runParams RunParameters) error) error {
+	return runExclusive(ctx, rep, mode, force, true, cb)
+}
+
+// RunExclusiveWithoutRescheduling is like RunExclusive but does not move the time of the next scheduled
+// maintenance, it is used to run individual maintenance tasks on demand.
+func RunExclusiveWithoutRescheduling(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mode, force bool, cb func(ctx context.Context, runParams RunParameters) error) error {
+	return runExclusive(ctx, rep, mode, force, false, cb)
+}
+
+// runExclusive implements RunExclusive, when reschedule is false the time of the next maintenance is not updated.
+func runExclusive(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mode, force, reschedule bool, cb func(ctx context.Context, runParams RunParameters) error) error {
 	rep.DisableIndexRefresh()
 
 	ctx = rep.AlsoLogToContentLog(ctx)
@@ -196,7 +214,7 @@ func RunExclusive(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mod
 
 	// update schedule so that we don't run the maintenance again immediately if
 	// this process crashes.
-	if err = updateSchedule(ctx, runParams); err != nil {
+	if err = updateSchedule(ctx, runParams, reschedule); err != nil {
 		return errors.Wrap(err, "error updating maintenance schedule")
 	}
 
@@ -267,45 +285,12 @@ func runQuickMaintenance(ctx context.Context, runParams RunParameters, safety Sa
 		return errors.Wrap(err, "unable to get schedule")
 	}
 
-	if shouldQuickRewriteContents(s, safety) {
-		// find 'q' packs that are less than 80% full and rewrite contents in them into
-		// new consolidated packs, orphaning old packs in the process.
-		if err := runTaskRewriteContentsQuick(ctx, runParams, s, safety); err != nil {
-			return errors.Wrap(err, "error rewriting metadata contents")
-		}
-	} else {
-		notRewritingContents(ctx)
-	}
-
-	if shouldDeleteOrphanedPacks(runParams.rep.Time(), s, safety) {
-		var err error
-
-		// time to delete orphaned blobs after last rewrite,
-		// if the last rewrite was full (started as part of full maintenance) we must complete it by
-		// running full orphaned blob deletion, otherwise next quick maintenance will start a quick rewrite
-		// and we'd never delete blobs orphaned by full rewrite.
-		if hadRecentFullRewrite(s) {
-			log(ctx).Debug("Had recent full rewrite - performing full blob deletion.")
-			err = runTaskDeleteOrphanedBlobsFull(ctx, runParams, s, safety)
-		} else {
-			log(ctx).Debug("Performing quick blob deletion.")
-			err = runTaskDeleteOrphanedBlobsQuick(ctx, runParams, s, safety)
-		}
+	opt := PhaseOptions{Safety: safety}
 
-		if err != nil {
-			return errors.Wrap(err, "error deleting unreferenced metadata blobs")
+	for _, phase := range quickMaintenancePhases() {
+		if _, err := runPhase(ctx, runParams, s, phase, opt); err != nil {
+			return err
 		}
-	} else {
-		notDeletingOrphanedBlobs(ctx, s, safety)
-	}
-
-	if err := runTaskEpochMaintenanceQuick(ctx, runParams, s); err != nil {
-		return errors.Wrap(err, "error running quick epoch maintenance tasks")
-	}
-
-	// consolidate many smaller indexes into fewer larger ones.
-	if err := runTaskIndexCompactionQuick(ctx, runParams, s, safety); err != nil {
-		return errors.Wrap(err, "error performing index compaction")
 	}
 
 	// clean up logs last
@@ -411,65 +396,98 @@ func runTaskEpochMaintenanceFull(ctx context.Context, runParams RunParameters, s
 	})
 }
 
-func runTaskDropDeletedContentsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	var safeDropTime time.Time
-
+// dropDeletedContentsTime returns the time before which deleted contents can be dropped from
+// the index or zero time if it's not safe to drop any contents yet.
+func dropDeletedContentsTime(rep repo.DirectRepository, s *Schedule, safety SafetyParameters) time.Time {
 	if safety.RequireTwoGCCycles {
-		safeDropTime = findSafeDropTime(s.Runs[TaskSnapshotGarbageCollection], safety)
-	} else {
-		safeDropTime = runParams.rep.Time()
+		return findSafeDropTime(s.Runs[TaskSnapshotGarbageCollection], safety)
 	}
 
+	return rep.Time()
+}
+
+// runTaskDropDeletedContentsFull drops deleted contents, if it's safe to do so, and returns true if it did.
+func runTaskDropDeletedContentsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) (bool, error) {
+	safeDropTime := dropDeletedContentsTime(runParams.rep, s, safety)
+
 	if safeDropTime.IsZero() {
 		log(ctx).Info("Not enough time has passed since previous successful Snapshot GC. Will try again next time.")
-		return nil
+		return false, nil
 	}
 
 	log(ctx).Infof("Found safe time to drop indexes: %v", safeDropTime)
 
-	return ReportRun(ctx, runParams.rep, TaskDropDeletedContentsFull, s, func() error {
+	return true, ReportRun(ctx, runParams.rep, TaskDropDeletedContentsFull, s, func() error {
 		return DropDeletedContents(ctx, runParams.rep, safeDropTime, safety)
 	})
 }
 
+func rewriteContentsQuickOptions() *RewriteContentsOptions {
+	return &RewriteContentsOptions{
+		ContentIDRange: index.AllPrefixedIDs,
+		PackPrefix:     content.PackBlobIDPrefixSpecial,
+		ShortPacks:     true,
+	}
+}
+
+func rewriteContentsFullOptions() *RewriteContentsOptions {
+	return &RewriteContentsOptions{
+		ContentIDRange: index.AllIDs,
+		ShortPacks:     true,
+	}
+}
+
 func runTaskRewriteContentsQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
 	return ReportRun(ctx, runParams.rep, TaskRewriteContentsQuick, s, func() error {
-		return RewriteContents(ctx, runParams.rep, &RewriteContentsOptions{
-			ContentIDRange: index.AllPrefixedIDs,
-			PackPrefix:     content.PackBlobIDPrefixSpecial,
-			ShortPacks:     true,
-		}, safety)
+		return RewriteContents(ctx, runParams.rep, rewriteContentsQuickOptions(), safety)
 	})
 }
 
 func runTaskRewriteContentsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
 	return ReportRun(ctx, runParams.rep, TaskRewriteContentsFull, s, func() error {
-		return RewriteContents(ctx, runParams.rep, &RewriteContentsOptions{
-			ContentIDRange: index.AllIDs,
-			ShortPacks:     true,
-		}, safety)
+		return RewriteContents(ctx, runParams.rep, rewriteContentsFullOptions(), safety)
 	})
 }
 
-func runTaskDeleteOrphanedBlobsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	return ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsFull, s, func() error {
-		_, err := DeleteUnreferencedBlobs(ctx, runParams.rep, DeleteUnreferencedBlobsOptions{
-			NotAfterTime: runParams.MaintenanceStartTime,
-		}, safety)
+func deleteOrphanedBlobsFullOptions(maintenanceStartTime time.Time) DeleteUnreferencedBlobsOptions {
+	return DeleteUnreferencedBlobsOptions{
+		NotAfterTime: maintenanceStartTime,
+	}
+}
+
+func deleteOrphanedBlobsQuickOptions(maintenanceStartTime time.Time) DeleteUnreferencedBlobsOptions {
+	return DeleteUnreferencedBlobsOptions{
+		NotAfterTime: maintenanceStartTime,
+		Prefix:       content.PackBlobIDPrefixSpecial,
+	}
+}
+
+func runTaskDeleteOrphanedBlobsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) (int, error) {
+	var deleted int
+
+	err := ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsFull, s, func() error {
+		var err error
+
+		deleted, err = DeleteUnreferencedBlobs(ctx, runParams.rep, deleteOrphanedBlobsFullOptions(runParams.MaintenanceStartTime), safety)
 
 		return err
 	})
+
+	return deleted, err
 }
 
-func runTaskDeleteOrphanedBlobsQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	return ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsQuick, s, func() error {
-		_, err := DeleteUnreferencedBlobs(ctx, runParams.rep, DeleteUnreferencedBlobsOptions{
-			NotAfterTime: runParams.MaintenanceStartTime,
-			Prefix:       content.PackBlobIDPrefixSpecial,
-		}, safety)
+func runTaskDeleteOrphanedBlobsQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) (int, error) {
+	var deleted int
+
+	err := ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsQuick, s, func() error {
+		var err error
+
+		deleted, err = DeleteUnreferencedBlobs(ctx, runParams.rep, deleteOrphanedBlobsQuickOptions(runParams.MaintenanceStartTime), safety)
 
 		return err
 	})
+
+	return deleted, err
 }
 
 func runTaskExtendBlobRetentionTimeFull(ctx context.Context, runParams RunParameters, s *Schedule) error {
@@ -485,29 +503,12 @@ func runFullMaintenance(ctx context.Context, runParams RunParameters, safety Saf
 		return errors.Wrap(err, "unable to get schedule")
 	}
 
-	if shouldFullRewriteContents(s, safety) {
-		// find packs that are less than 80% full and rewrite contents in them into
-		// new consolidated packs, orphaning old packs in the process.
-		if err := runTaskRewriteContentsFull(ctx, runParams, s, safety); err != nil {
-			return errors.Wrap(err, "error rewriting contents in short packs")
-		}
-	} else {
-		notRewritingContents(ctx)
-	}
+	opt := PhaseOptions{Full: true, Safety: safety}
 
-	// rewrite indexes by dropping content entries that have been marked
-	// as deleted for a long time
-	if err := runTaskDropDeletedContentsFull(ctx, runParams, s, safety); err != nil {
-		return errors.Wrap(err, "error dropping deleted contents")
-	}
-
-	if shouldDeleteOrphanedPacks(runParams.rep.Time(), s, safety) {
-		// delete orphaned packs after some time.
-		if err := runTaskDeleteOrphanedBlobsFull(ctx, runParams, s, safety); err != nil {
-			return errors.Wrap(err, "error deleting unreferenced blobs")
+	for _, phase := range fullMaintenancePhases() {
+		if _, err := runPhase(ctx, runParams, s, phase, opt); err != nil {
+			return err
 		}
-	} else {
-		notDeletingOrphanedBlobs(ctx, s, safety)
 	}
 
 	// extend retention-time on supported storage.
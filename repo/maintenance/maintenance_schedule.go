@@ -113,49 +113,64 @@ func TimeToAttemptNextMaintenance(ctx context.Context, rep repo.DirectRepository
 
 // GetSchedule gets the scheduled maintenance times.
 func GetSchedule(ctx context.Context, rep repo.DirectRepository) (*Schedule, error) {
+	s := &Schedule{}
+
+	if err := readEncryptedJSONBlob(ctx, rep, maintenanceScheduleBlobID, maintenanceScheduleAEADExtraData, s); err != nil {
+		return nil, errors.Wrap(err, "schedule")
+	}
+
+	return s, nil
+}
+
+// SetSchedule updates scheduled maintenance times.
+func SetSchedule(ctx context.Context, rep repo.DirectRepositoryWriter, s *Schedule) error {
+	return writeEncryptedJSONBlob(ctx, rep, maintenanceScheduleBlobID, maintenanceScheduleAEADExtraData, s)
+}
+
+// readEncryptedJSONBlob reads the provided blob written by writeEncryptedJSONBlob, leaving v unchanged if the blob does not exist.
+func readEncryptedJSONBlob(ctx context.Context, rep repo.DirectRepository, blobID blob.ID, extraData []byte, v any) error {
 	var tmp gather.WriteBuffer
 	defer tmp.Close()
 
 	// read
-	err := rep.BlobReader().GetBlob(ctx, maintenanceScheduleBlobID, 0, -1, &tmp)
+	err := rep.BlobReader().GetBlob(ctx, blobID, 0, -1, &tmp)
 	if errors.Is(err, blob.ErrBlobNotFound) {
-		return &Schedule{}, nil
+		return nil
 	}
 
 	if err != nil {
-		return nil, errors.Wrap(err, "error reading schedule blob")
+		return errors.Wrap(err, "error reading blob")
 	}
 
 	// decrypt
 	c, err := getAES256GCM(rep)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to get cipher")
+		return errors.Wrap(err, "unable to get cipher")
 	}
 
-	v := tmp.ToByteSlice()
+	b := tmp.ToByteSlice()
 
-	if len(v) < c.NonceSize() {
-		return nil, errors.Errorf("invalid schedule blob")
+	if len(b) < c.NonceSize() {
+		return errors.Errorf("invalid blob")
 	}
 
-	j, err := c.Open(nil, v[0:c.NonceSize()], v[c.NonceSize():], maintenanceScheduleAEADExtraData)
+	j, err := c.Open(nil, b[0:c.NonceSize()], b[c.NonceSize():], extraData)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to decrypt schedule blob")
+		return errors.Wrap(err, "unable to decrypt blob")
 	}
 
 	// parse JSON
-	s := &Schedule{}
-	if err := json.Unmarshal(j, s); err != nil {
-		return nil, errors.Wrap(err, "malformed schedule blob")
+	if err := json.Unmarshal(j, v); err != nil {
+		return errors.Wrap(err, "malformed blob")
 	}
 
-	return s, nil
+	return nil
 }
 
-// SetSchedule updates scheduled maintenance times.
-func SetSchedule(ctx context.Context, rep repo.DirectRepositoryWriter, s *Schedule) error {
+// writeEncryptedJSONBlob writes the provided value as JSON encrypted with AES-256-GCM to the provided blob.
+func writeEncryptedJSONBlob(ctx context.Context, rep repo.DirectRepositoryWriter, blobID blob.ID, extraData []byte, v any) error {
 	// encode JSON
-	v, err := json.Marshal(s)
+	j, err := json.Marshal(v)
 	if err != nil {
 		return errors.Wrap(err, "unable to serialize JSON")
 	}
@@ -173,10 +188,10 @@ func SetSchedule(ctx context.Context, rep repo.DirectRepositoryWriter, s *Schedu
 	}
 
 	result := append([]byte(nil), nonce...)
-	ciphertext := c.Seal(result, nonce, v, maintenanceScheduleAEADExtraData)
+	ciphertext := c.Seal(result, nonce, j, extraData)
 
 	//nolint:wrapcheck
-	return rep.BlobStorage().PutBlob(ctx, maintenanceScheduleBlobID, gather.FromSlice(ciphertext), blob.PutOptions{})
+	return rep.BlobStorage().PutBlob(ctx, blobID, gather.FromSlice(ciphertext), blob.PutOptions{})
 }
 
 // ReportRun reports timing of a maintenance run and persists it in repository.
@@ -0,0 +1,145 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+const statsHistoryBlobID = "kopia.maintenance.stats"
+
+//nolint:gochecknoglobals
+var statsHistoryAEADExtraData = []byte("maintenance stats")
+
+// StatsPoint is a compact record of repository statistics at a point in time.
+type StatsPoint struct {
+	Time time.Time `json:"time"`
+	Mode Mode      `json:"mode,omitempty"`
+
+	// BlobCount and BlobBytes describe all blobs in the repository.
+	BlobCount int   `json:"blobs"`
+	BlobBytes int64 `json:"blobBytes"`
+
+	// PackBytes is the total size of pack blobs.
+	PackBytes int64 `json:"packBytes"`
+
+	// ContentCount and UniqueContentBytes describe contents which are not deleted, after deduplication
+	// and compression.
+	ContentCount       int   `json:"contents"`
+	UniqueContentBytes int64 `json:"uniqueContentBytes"`
+
+	SnapshotCount int `json:"snapshots"`
+
+	// Fragmentation is the fraction of pack bytes not used by contents which are not deleted.
+	Fragmentation float64 `json:"fragmentation"`
+}
+
+// StatsHistoryOptions specifies whether stats are recorded and how many stats points to keep.
+// Collecting stats requires listing all blobs and contents, so it is only done during full maintenance
+// when explicitly enabled.
+type StatsHistoryOptions struct {
+	Enabled  bool          `json:"enabled,omitempty"`
+	MaxCount int           `json:"maxCount"`
+	MaxAge   time.Duration `json:"maxAge"`
+}
+
+// OrDefault returns default StatsHistoryOptions.
+func (o StatsHistoryOptions) OrDefault() StatsHistoryOptions {
+	if o.MaxCount == 0 && o.MaxAge == 0 {
+		d := defaultStatsHistory()
+		d.Enabled = o.Enabled
+
+		return d
+	}
+
+	return o
+}
+
+// defaultStatsHistory returns StatsHistoryOptions applied by default once stats history is enabled.
+func defaultStatsHistory() StatsHistoryOptions {
+	//nolint:mnd
+	return StatsHistoryOptions{
+		MaxCount: 1000,                 // no more than 1000 points, ~3 years of daily runs
+		MaxAge:   365 * 24 * time.Hour, // no more than a year of data
+	}
+}
+
+// CollectStats computes repository statistics, except for the snapshot count which is not known at this level.
+func CollectStats(ctx context.Context, rep repo.DirectRepository) (*StatsPoint, error) {
+	st := &StatsPoint{
+		Time: rep.Time(),
+	}
+
+	packPrefixes := map[blob.ID]bool{}
+	for _, prefix := range content.PackBlobIDPrefixes {
+		packPrefixes[prefix] = true
+	}
+
+	if err := rep.BlobReader().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		st.BlobCount++
+		st.BlobBytes += bm.Length
+
+		if len(bm.BlobID) > 0 && packPrefixes[bm.BlobID[0:1]] {
+			st.PackBytes += bm.Length
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error listing blobs")
+	}
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		st.ContentCount++
+		st.UniqueContentBytes += int64(ci.PackedLength)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	if st.PackBytes > 0 {
+		st.Fragmentation = max(0, 1-float64(st.UniqueContentBytes)/float64(st.PackBytes))
+	}
+
+	return st, nil
+}
+
+// GetStatsHistory returns the recorded stats points, oldest first.
+func GetStatsHistory(ctx context.Context, rep repo.DirectRepository) ([]StatsPoint, error) {
+	var points []StatsPoint
+
+	if err := readEncryptedJSONBlob(ctx, rep, statsHistoryBlobID, statsHistoryAEADExtraData, &points); err != nil {
+		return nil, errors.Wrap(err, "stats history")
+	}
+
+	return points, nil
+}
+
+// RecordStats appends the provided stats point to the history and discards points beyond the retention.
+func RecordStats(ctx context.Context, rep repo.DirectRepositoryWriter, st StatsPoint, opt StatsHistoryOptions) error {
+	points, err := GetStatsHistory(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	points = append(points, st)
+
+	if opt.MaxAge > 0 {
+		cutoff := st.Time.Add(-opt.MaxAge)
+
+		for len(points) > 0 && points[0].Time.Before(cutoff) {
+			points = points[1:]
+		}
+	}
+
+	if opt.MaxCount > 0 && len(points) > opt.MaxCount {
+		points = points[len(points)-opt.MaxCount:]
+	}
+
+	return writeEncryptedJSONBlob(ctx, rep, statsHistoryBlobID, statsHistoryAEADExtraData, points)
+}
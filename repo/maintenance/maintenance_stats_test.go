@@ -0,0 +1,76 @@
+package maintenance_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func (s *formatSpecificTestSuite) TestStatsHistory(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ft.NowFunc()
+		},
+	})
+
+	w := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{})
+	io.WriteString(w, "hello world!")
+	_, err := w.Result()
+	require.NoError(t, err)
+	w.Close()
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	st, err := maintenance.CollectStats(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Positive(t, st.BlobCount)
+	require.Positive(t, st.PackBytes)
+	require.GreaterOrEqual(t, st.BlobBytes, st.PackBytes)
+	require.Equal(t, 1, st.ContentCount)
+	require.Positive(t, st.UniqueContentBytes)
+	require.InDelta(t, 1-float64(st.UniqueContentBytes)/float64(st.PackBytes), st.Fragmentation, 1e-9)
+
+	points, err := maintenance.GetStatsHistory(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Empty(t, points)
+
+	opt := maintenance.StatsHistoryOptions{MaxCount: 3, MaxAge: 10 * time.Hour}
+
+	for i := range 5 {
+		st.SnapshotCount = i
+		st.Time = env.RepositoryWriter.Time()
+
+		require.NoError(t, maintenance.RecordStats(ctx, env.RepositoryWriter, *st, opt))
+		ft.Advance(time.Hour)
+	}
+
+	// only the most recent points are retained, oldest first.
+	points, err = maintenance.GetStatsHistory(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+
+	for i, p := range points {
+		require.Equal(t, i+2, p.SnapshotCount)
+	}
+
+	// points older than the max age are discarded.
+	ft.Advance(8*time.Hour + 30*time.Minute)
+
+	st.Time = env.RepositoryWriter.Time()
+	require.NoError(t, maintenance.RecordStats(ctx, env.RepositoryWriter, *st, opt))
+
+	points, err = maintenance.GetStatsHistory(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	require.Equal(t, 4, points[0].SnapshotCount)
+}
@@ -0,0 +1,190 @@
+package object
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// Layout describes how an object (or a range of a larger object) is stored in the repository.
+//
+// Objects stored directly in a single content have IndirectionLevel == 0 and Content describing
+// where the content lives. Indirect objects have IndirectionLevel > 0, the layout of the object
+// holding the list of entries in Index and the layouts of their entries (in order) in Entries.
+type Layout struct {
+	ObjectID ID `json:"objectID"`
+
+	// Offset and Length of the range within the top-level object described by this layout.
+	// For objects stored directly in a content, Length is the original (uncompressed) length of the content,
+	// the number of bytes it occupies in the pack blob is Content.PackedLength.
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+
+	IndirectionLevel int `json:"indirectionLevel"`
+
+	Content *content.Info `json:"content,omitempty"`
+
+	Index   *Layout  `json:"index,omitempty"`
+	Entries []Layout `json:"entries,omitempty"`
+}
+
+// DescribeObject returns the layout of the provided object without reading contents holding its data.
+// Only the index objects (lists of entries of indirect objects) are read. EmptyID, which is used by files
+// stored inline in directory manifests, is described as an empty object without contents.
+func DescribeObject(ctx context.Context, cr contentReader, oid ID) (*Layout, error) {
+	return describeObject(ctx, cr, oid, 0, -1)
+}
+
+func describeObject(ctx context.Context, cr contentReader, oid ID, offset, length int64) (*Layout, error) {
+	if oid == EmptyID {
+		return &Layout{ObjectID: oid, Offset: offset, Length: max(length, 0)}, nil
+	}
+
+	if indexObjectID, ok := oid.IndexObjectID(); ok {
+		return describeIndirectObject(ctx, cr, oid, indexObjectID, offset)
+	}
+
+	contentID, _, ok := oid.ContentID()
+	if !ok {
+		return nil, errors.Errorf("unrecognized object type: %v", oid)
+	}
+
+	ci, err := cr.ContentInfo(ctx, contentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting content info for %v", contentID)
+	}
+
+	if length < 0 {
+		length = int64(ci.OriginalLength)
+	}
+
+	return &Layout{
+		ObjectID: oid,
+		Offset:   offset,
+		Length:   length,
+		Content:  &ci,
+	}, nil
+}
+
+func describeIndirectObject(ctx context.Context, cr contentReader, oid, indexObjectID ID, offset int64) (*Layout, error) {
+	index, err := describeObject(ctx, cr, indexObjectID, 0, -1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to describe index object %v", indexObjectID)
+	}
+
+	entries, err := LoadIndexObject(ctx, cr, indexObjectID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load index object %v", indexObjectID)
+	}
+
+	l := &Layout{
+		ObjectID:         oid,
+		Offset:           offset,
+		IndirectionLevel: 1,
+		Index:            index,
+	}
+
+	for _, e := range entries {
+		el, err := describeObject(ctx, cr, e.Object, offset+e.Start, e.Length)
+		if err != nil {
+			return nil, err
+		}
+
+		l.IndirectionLevel = max(l.IndirectionLevel, el.IndirectionLevel+1)
+		l.Length += e.Length
+		l.Entries = append(l.Entries, *el)
+	}
+
+	return l, nil
+}
+
+// Fragmentation describes how the contents holding the data of an object are scattered across pack blobs.
+type Fragmentation struct {
+	Contents  int `json:"contents"`
+	PackBlobs int `json:"packBlobs"`
+
+	// Runs is the number of sequences of contents which are adjacent in object order and stored
+	// contiguously in the same pack blob (apart from alignment padding), each of which can be fetched
+	// with a single read.
+	Runs int `json:"runs"`
+
+	// AverageRunLength is the average number of contents in a run.
+	AverageRunLength float64 `json:"averageRunLength"`
+
+	// ScatterScore ranges from 0 when all contents are stored in a single run to 1 when
+	// no two contents adjacent in object order are stored contiguously.
+	ScatterScore float64 `json:"scatterScore"`
+}
+
+// Fragmentation returns the fragmentation of contents holding the data of the object, excluding index objects.
+// The packAlignment is the boundary to which contents are aligned within pack blobs, padding inserted
+// before aligned contents does not break runs.
+func (l *Layout) Fragmentation(packAlignment int) Fragmentation {
+	var (
+		f     Fragmentation
+		packs = map[blob.ID]bool{}
+		prev  *content.Info
+	)
+
+	l.visitDataContents(func(ci *content.Info) {
+		f.Contents++
+
+		if !packs[ci.PackBlobID] {
+			packs[ci.PackBlobID] = true
+			f.PackBlobs++
+		}
+
+		if prev == nil || prev.PackBlobID != ci.PackBlobID || alignOffset(int64(prev.PackOffset)+int64(prev.PackedLength), packAlignment) != int64(ci.PackOffset) {
+			f.Runs++
+		}
+
+		prev = ci
+	})
+
+	if f.Runs > 0 {
+		f.AverageRunLength = float64(f.Contents) / float64(f.Runs)
+	}
+
+	if f.Contents > 1 {
+		f.ScatterScore = float64(f.Runs-1) / float64(f.Contents-1)
+	}
+
+	return f
+}
+
+// alignOffset rounds the offset up to the provided alignment.
+func alignOffset(offset int64, alignment int) int64 {
+	if alignment <= 1 {
+		return offset
+	}
+
+	a := int64(alignment)
+
+	return (offset + a - 1) / a * a
+}
+
+func (l *Layout) visitDataContents(cb func(ci *content.Info)) {
+	if l.Content != nil {
+		cb(l.Content)
+	}
+
+	for i := range l.Entries {
+		l.Entries[i].visitDataContents(cb)
+	}
+}
+
+// FragmentationReport returns the fragmentation of the provided object, computed from its layout
+// without reading contents holding its data.
+func FragmentationReport(ctx context.Context, cr contentReader, oid ID, packAlignment int) (*Fragmentation, error) {
+	l, err := DescribeObject(ctx, cr, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	f := l.Fragmentation(packAlignment)
+
+	return &f, nil
+}
@@ -0,0 +1,217 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/splitter"
+)
+
+type countingContentReader struct {
+	contentReader
+
+	getContentCalls int
+}
+
+func (r *countingContentReader) GetContent(ctx context.Context, contentID content.ID) ([]byte, error) {
+	r.getContentCalls++
+
+	return r.contentReader.GetContent(ctx, contentID)
+}
+
+func TestDescribeObject(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, fcm, om := setupTest(t, nil)
+
+	writer := om.NewWriter(ctx, WriterOptions{})
+	writer.(*objectWriter).splitter = splitter.Fixed(1000)()
+
+	_, err := writer.Write(makeMaybeCompressibleData(2500, false))
+	require.NoError(t, err)
+
+	oid, err := writer.Result()
+	require.NoError(t, err)
+
+	cr := &countingContentReader{contentReader: fcm}
+
+	l, err := DescribeObject(ctx, cr, oid)
+	require.NoError(t, err)
+
+	// only the index object has been read.
+	require.Equal(t, 1, cr.getContentCalls)
+
+	require.Equal(t, oid, l.ObjectID)
+	require.Equal(t, 1, l.IndirectionLevel)
+	require.Equal(t, int64(2500), l.Length)
+	require.Nil(t, l.Content)
+
+	indexObjectID, _ := oid.IndexObjectID()
+	require.Equal(t, indexObjectID, l.Index.ObjectID)
+	require.Equal(t, 0, l.Index.IndirectionLevel)
+	require.NotNil(t, l.Index.Content)
+
+	require.Len(t, l.Entries, 3)
+
+	for i, want := range [][2]int64{{0, 1000}, {1000, 1000}, {2000, 500}} {
+		e := l.Entries[i]
+
+		require.Equal(t, want[0], e.Offset)
+		require.Equal(t, want[1], e.Length)
+		require.Equal(t, 0, e.IndirectionLevel)
+		require.NotNil(t, e.Content)
+
+		cid, _, ok := e.ObjectID.ContentID()
+		require.True(t, ok)
+		require.Equal(t, cid, e.Content.ContentID)
+	}
+
+	// direct objects are described by a single content.
+	l, err = DescribeObject(ctx, cr, l.Entries[0].ObjectID)
+	require.NoError(t, err)
+	require.Equal(t, 0, l.IndirectionLevel)
+	require.NotNil(t, l.Content)
+	require.Empty(t, l.Entries)
+
+	missing, err := content.ParseID("deadbeefdeadbeefdeadbeefdeadbeef")
+	require.NoError(t, err)
+
+	_, err = DescribeObject(ctx, cr, DirectObjectID(missing))
+	require.Error(t, err)
+}
+
+// packLayoutContentReader places contents in pack blobs according to the provided function.
+type packLayoutContentReader struct {
+	contentReader
+
+	place func(cid content.ID) (blob.ID, uint32)
+}
+
+func (r *packLayoutContentReader) ContentInfo(ctx context.Context, contentID content.ID) (content.Info, error) {
+	ci, err := r.contentReader.ContentInfo(ctx, contentID)
+	if err != nil {
+		return ci, err
+	}
+
+	ci.PackBlobID, ci.PackOffset = r.place(contentID)
+
+	return ci, nil
+}
+
+func TestDescribeObject_EmptyID(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, fcm, _ := setupTest(t, nil)
+
+	cr := &countingContentReader{contentReader: fcm}
+
+	// files stored inline in directory manifests have no object ID and no backing contents.
+	l, err := DescribeObject(ctx, cr, EmptyID)
+	require.NoError(t, err)
+	require.Equal(t, Layout{ObjectID: EmptyID}, *l)
+	require.Zero(t, cr.getContentCalls)
+	require.Zero(t, l.Fragmentation(0).Contents)
+}
+
+func TestFragmentationReport(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, fcm, om := setupTest(t, nil)
+
+	writer := om.NewWriter(ctx, WriterOptions{})
+	writer.(*objectWriter).splitter = splitter.Fixed(1000)()
+
+	_, err := writer.Write(makeMaybeCompressibleData(4000, false))
+	require.NoError(t, err)
+
+	oid, err := writer.Result()
+	require.NoError(t, err)
+
+	l, err := DescribeObject(ctx, fcm, oid)
+	require.NoError(t, err)
+	require.Len(t, l.Entries, 4)
+
+	chunkIndex := map[content.ID]int{}
+
+	for i, e := range l.Entries {
+		chunkIndex[e.Content.ContentID] = i
+	}
+
+	type packPlacement struct {
+		pack   blob.ID
+		offset uint32
+	}
+
+	cases := []struct {
+		name      string
+		placement [4]packPlacement
+		alignment int
+		want      Fragmentation
+	}{
+		{
+			name:      "contiguous",
+			placement: [4]packPlacement{{"p1", 0}, {"p1", 1000}, {"p1", 2000}, {"p1", 3000}},
+			want:      Fragmentation{Contents: 4, PackBlobs: 1, Runs: 1, AverageRunLength: 4, ScatterScore: 0},
+		},
+		{
+			name:      "two-packs",
+			placement: [4]packPlacement{{"p1", 0}, {"p1", 1000}, {"p2", 0}, {"p2", 1000}},
+			want:      Fragmentation{Contents: 4, PackBlobs: 2, Runs: 2, AverageRunLength: 2, ScatterScore: 1.0 / 3},
+		},
+		{
+			name:      "scattered",
+			placement: [4]packPlacement{{"p1", 3000}, {"p1", 0}, {"p2", 0}, {"p1", 1000}},
+			want:      Fragmentation{Contents: 4, PackBlobs: 2, Runs: 4, AverageRunLength: 1, ScatterScore: 1},
+		},
+		{
+			name:      "aligned",
+			placement: [4]packPlacement{{"p1", 0}, {"p1", 1024}, {"p1", 2048}, {"p1", 3072}},
+			alignment: 512,
+			want:      Fragmentation{Contents: 4, PackBlobs: 1, Runs: 1, AverageRunLength: 4, ScatterScore: 0},
+		},
+		{
+			name:      "aligned-with-gap",
+			placement: [4]packPlacement{{"p1", 0}, {"p1", 1024}, {"p1", 2560}, {"p1", 3584}},
+			alignment: 512,
+			want:      Fragmentation{Contents: 4, PackBlobs: 1, Runs: 2, AverageRunLength: 2, ScatterScore: 1.0 / 3},
+		},
+		{
+			name:      "padding-without-alignment",
+			placement: [4]packPlacement{{"p1", 0}, {"p1", 1024}, {"p1", 2048}, {"p1", 3072}},
+			want:      Fragmentation{Contents: 4, PackBlobs: 1, Runs: 4, AverageRunLength: 1, ScatterScore: 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cr := &countingContentReader{contentReader: &packLayoutContentReader{
+				contentReader: fcm,
+				place: func(cid content.ID) (blob.ID, uint32) {
+					i, ok := chunkIndex[cid]
+					if !ok {
+						// index object.
+						return "q1", 0
+					}
+
+					return tc.placement[i].pack, tc.placement[i].offset
+				},
+			}}
+
+			f, err := FragmentationReport(ctx, cr, oid, tc.alignment)
+			require.NoError(t, err)
+			require.InDelta(t, tc.want.ScatterScore, f.ScatterScore, 1e-9)
+
+			f.ScatterScore = tc.want.ScatterScore
+			require.Equal(t, tc.want, *f)
+
+			// only the index object has been read.
+			require.Equal(t, 1, cr.getContentCalls)
+		})
+	}
+
+	// objects stored in a single content are not fragmented.
+	f := l.Entries[0].Fragmentation(0)
+	require.Equal(t, Fragmentation{Contents: 1, PackBlobs: 1, Runs: 1, AverageRunLength: 1}, f)
+}
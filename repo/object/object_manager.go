@@ -44,9 +44,10 @@ type contentManager interface {
 type Manager struct {
 	Format format.ObjectFormat
 
-	contentMgr         contentManager
-	newDefaultSplitter splitter.Factory
-	writerPool         sync.Pool
+	contentMgr          contentManager
+	newDefaultSplitter  splitter.Factory
+	defaultSplitterName string
+	writerPool          sync.Pool
 }
 
 // NewWriter creates an ObjectWriter for writing to the repository.
@@ -57,17 +58,23 @@ func (om *Manager) NewWriter(ctx context.Context, opt WriterOptions) Writer {
 
 	var splitFactory splitter.Factory
 
+	splitterName := opt.Splitter
+
 	if opt.Splitter != "" {
 		splitFactory = splitter.GetFactory(opt.Splitter)
 	}
 
 	if splitFactory == nil {
 		splitFactory = om.newDefaultSplitter
+		splitterName = om.defaultSplitterName
 	}
 
 	w.splitter = splitFactory()
 
 	w.description = opt.Description
+	w.maxChunks = opt.MaxChunks
+	w.onChunkLimitExceeded = opt.OnChunkLimitExceeded
+	w.chunkLimitExceeded = false
 	w.prefix = opt.Prefix
 	w.compressor = compression.ByName[opt.Compressor]
 	w.totalLength = 0
@@ -76,9 +83,17 @@ func (om *Manager) NewWriter(ctx context.Context, opt WriterOptions) Writer {
 	// point the slice at the embedded array, so that we avoid allocations most of the time
 	w.indirectIndex = w.indirectIndexBuf[:0]
 
-	if opt.AsyncWrites > 0 {
-		if len(w.asyncWritesSemaphore) != 0 || cap(w.asyncWritesSemaphore) != opt.AsyncWrites {
-			w.asyncWritesSemaphore = make(chan struct{}, opt.AsyncWrites)
+	asyncWrites := opt.AsyncWrites
+
+	if opt.ParallelHashing > asyncWrites && splitter.IsFixedSize(splitterName) {
+		// chunk boundaries of fixed-size splitters don't depend on the data, so there's
+		// nothing to wait for before hashing and writing the next chunk.
+		asyncWrites = opt.ParallelHashing
+	}
+
+	if asyncWrites > 0 {
+		if len(w.asyncWritesSemaphore) != 0 || cap(w.asyncWritesSemaphore) != asyncWrites {
+			w.asyncWritesSemaphore = make(chan struct{}, asyncWrites)
 		}
 	} else {
 		w.asyncWritesSemaphore = nil
@@ -236,6 +251,7 @@ func NewObjectManager(ctx context.Context, bm contentManager, f format.ObjectFor
 	}
 
 	om.newDefaultSplitter = os
+	om.defaultSplitterName = splitterID
 
 	return om, nil
 }
@@ -63,9 +63,7 @@ func (f *fakeContentManager) WriteContent(ctx context.Context, data gather.Bytes
 		return content.EmptyID, f.writeContentError
 	}
 
-	h := sha256.New()
-	data.WriteTo(h)
-	contentID, err := content.IDFromHash(prefix, h.Sum(nil))
+	contentID, err := f.ComputeContentID(ctx, data, prefix)
 	impossible.PanicOnError(err)
 
 	f.mu.Lock()
@@ -79,6 +77,13 @@ func (f *fakeContentManager) WriteContent(ctx context.Context, data gather.Bytes
 	return contentID, nil
 }
 
+func (f *fakeContentManager) ComputeContentID(ctx context.Context, data gather.Bytes, prefix content.IDPrefix) (content.ID, error) {
+	h := sha256.New()
+	data.WriteTo(h)
+
+	return content.IDFromHash(prefix, h.Sum(nil))
+}
+
 func (f *fakeContentManager) SupportsContentCompression() bool {
 	return f.supportsContentCompression
 }
@@ -237,6 +242,88 @@ func TestCompression_CustomSplitters(t *testing.T) {
 	}
 }
 
+func TestWriterMaxChunks(t *testing.T) {
+	cases := []struct {
+		splitter        string
+		maxChunks       int
+		dataLength      int
+		wantLengths     []int64
+		wantNewSplitter string
+		wantExceeded    bool
+	}{
+		{
+			splitter:    "FIXED-128K",
+			maxChunks:   0,
+			dataLength:  4 << 17,
+			wantLengths: []int64{131072, 131072, 131072, 131072},
+		},
+		{
+			splitter:    "FIXED-128K",
+			maxChunks:   5,
+			dataLength:  4 << 17,
+			wantLengths: []int64{131072, 131072, 131072, 131072},
+		},
+		{
+			splitter:        "FIXED-128K",
+			maxChunks:       3,
+			dataLength:      20 << 20,
+			wantLengths:     []int64{131072, 131072, 131072, 8388608, 8388608, 3801088},
+			wantNewSplitter: ChunkLimitFallbackSplitter,
+			wantExceeded:    true,
+		},
+		{
+			splitter:        "FIXED-8M",
+			maxChunks:       1,
+			dataLength:      9 << 20,
+			wantLengths:     []int64{8388608, 1048576},
+			wantNewSplitter: "",
+			wantExceeded:    true,
+		},
+	}
+
+	ctx := testlogging.Context(t)
+
+	for _, tc := range cases {
+		_, fcm, om := setupTest(t, nil)
+
+		var (
+			exceeded    bool
+			newSplitter string
+		)
+
+		w := om.NewWriter(ctx, WriterOptions{
+			Splitter:  tc.splitter,
+			MaxChunks: tc.maxChunks,
+			OnChunkLimitExceeded: func(chunkCount int, ns string) {
+				require.Equal(t, tc.maxChunks, chunkCount)
+
+				exceeded = true
+				newSplitter = ns
+			},
+		})
+
+		w.Write(bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8}, tc.dataLength/8))
+		oid, err := w.Result()
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		ndx, ok := oid.IndexObjectID()
+		require.True(t, ok)
+
+		entries, err := LoadIndexObject(ctx, fcm, ndx)
+		require.NoError(t, err)
+
+		var gotLengths []int64
+		for _, e := range entries {
+			gotLengths = append(gotLengths, e.Length)
+		}
+
+		require.Equal(t, tc.wantLengths, gotLengths)
+		require.Equal(t, tc.wantExceeded, exceeded)
+		require.Equal(t, tc.wantNewSplitter, newSplitter)
+	}
+}
+
 func TestCompression_ContentCompressionDisabled(t *testing.T) {
 	ctx := testlogging.Context(t)
 
@@ -1001,3 +1088,191 @@ func TestWriterFailure_OnCompression(t *testing.T) {
 	_, err := w.Write(bytes.Repeat([]byte{1, 2, 3, 4}, 1e6))
 	require.ErrorIs(t, err, errSomeError)
 }
+
+func TestVerifyingReader(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data, fcm, om := setupTest(t, nil)
+
+	payload := make([]byte, 3<<20+100)
+	cryptorand.Read(payload)
+
+	oid := mustWriteObject(t, om, payload, "gzip")
+	require.Equal(t, 1, indirectionLevel(oid))
+
+	r, err := OpenVerifying(ctx, fcm, oid)
+	require.NoError(t, err)
+
+	var (
+		blocks []VerifiedBlock
+		got    []byte
+	)
+
+	for {
+		b, err := r.NextBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+		require.EqualValues(t, len(got), b.Offset)
+
+		blocks = append(blocks, b)
+		got = append(got, b.Data...)
+	}
+
+	require.Len(t, blocks, 4)
+	require.Equal(t, payload, got)
+
+	// io.Reader returns the same data.
+	r, err = OpenVerifying(ctx, fcm, oid)
+	require.NoError(t, err)
+
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, payload, got)
+	require.NoError(t, r.Close())
+
+	// tamper with the third content.
+	fcm.mu.Lock()
+	corrupted := append([]byte(nil), data[blocks[2].ContentID]...)
+	corrupted[0] ^= 1
+	data[blocks[2].ContentID] = corrupted
+	fcm.mu.Unlock()
+
+	r, err = OpenVerifying(ctx, fcm, oid)
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = r.NextBlock()
+		require.NoError(t, err)
+	}
+
+	_, err = r.NextBlock()
+
+	var me *ContentMismatchError
+
+	require.ErrorAs(t, err, &me)
+	require.Equal(t, blocks[2].ContentID, me.ContentID)
+	require.Equal(t, blocks[2].Offset, me.Offset)
+	require.NotEqual(t, me.ContentID, me.ComputedID)
+
+	// tampering with the index is detected too.
+	indexObjectID, ok := oid.IndexObjectID()
+	require.True(t, ok)
+
+	indexContentID, _, ok := indexObjectID.ContentID()
+	require.True(t, ok)
+
+	fcm.mu.Lock()
+	data[indexContentID] = append([]byte(" "), data[indexContentID]...)
+	fcm.mu.Unlock()
+
+	r, err = OpenVerifying(ctx, fcm, oid)
+	require.NoError(t, err)
+
+	_, err = r.NextBlock()
+	require.ErrorAs(t, err, &me)
+	require.Equal(t, indexContentID, me.ContentID)
+	require.EqualValues(t, -1, me.Offset)
+}
+
+func TestVerifyingReader_NotSupported(t *testing.T) {
+	_, err := OpenVerifying(testlogging.Context(t), struct{ contentReader }{}, EmptyID)
+	require.ErrorIs(t, err, ErrVerificationNotSupported)
+}
+
+func TestParallelHashing(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, _, om := setupTest(t, nil)
+
+	randomData := make([]byte, 10<<20+12345)
+	cryptorand.Read(randomData)
+
+	writeObject := func(opt WriterOptions) ID {
+		t.Helper()
+
+		w := om.NewWriter(ctx, opt)
+		defer w.Close()
+
+		// the number of concurrent writes is only raised for fixed-size splitters.
+		wantAsync := max(opt.AsyncWrites, opt.ParallelHashing)
+		if opt.Splitter != "" && !splitter.IsFixedSize(opt.Splitter) {
+			wantAsync = opt.AsyncWrites
+		}
+
+		require.Equal(t, wantAsync, cap(w.(*objectWriter).asyncWritesSemaphore))
+
+		for b := randomData; len(b) > 0; {
+			n := min(len(b), 77777)
+
+			_, err := w.Write(b[:n])
+			require.NoError(t, err)
+
+			b = b[n:]
+		}
+
+		oid, err := w.Result()
+		require.NoError(t, err)
+
+		return oid
+	}
+
+	for _, splitterName := range []string{"", "FIXED-128K", "DYNAMIC-128K-BUZHASH"} {
+		sequential := writeObject(WriterOptions{Splitter: splitterName})
+
+		for _, n := range []int{2, 8} {
+			parallel := writeObject(WriterOptions{Splitter: splitterName, AsyncWrites: 1, ParallelHashing: n})
+			require.Equal(t, sequential, parallel, "splitter %q, parallelism %v", splitterName, n)
+		}
+
+		verify(ctx, t, om.contentMgr, sequential, randomData, splitterName)
+	}
+}
+
+// hashingContentManager only computes content IDs, so that benchmarks measure hashing and not storage.
+type hashingContentManager struct {
+	fakeContentManager
+}
+
+func (f *hashingContentManager) WriteContent(ctx context.Context, data gather.Bytes, prefix content.IDPrefix, comp compression.HeaderID) (content.ID, error) {
+	return f.ComputeContentID(ctx, data, prefix)
+}
+
+func BenchmarkParallelHashing(b *testing.B) {
+	const fileSize = 50 << 30
+
+	ctx := testlogging.Context(b)
+
+	om, err := NewObjectManager(ctx, &hashingContentManager{}, format.ObjectFormat{
+		Splitter: "FIXED-4M",
+	}, nil)
+	require.NoError(b, err)
+
+	buf := make([]byte, 1<<20)
+	cryptorand.Read(buf)
+
+	for _, parallelism := range []int{0, 2, 4, runtime.NumCPU()} {
+		b.Run(fmt.Sprintf("parallel-%v", parallelism), func(b *testing.B) {
+			b.SetBytes(fileSize)
+
+			for range b.N {
+				w := om.NewWriter(ctx, WriterOptions{AsyncWrites: 1, ParallelHashing: parallelism})
+
+				for i := range fileSize / len(buf) {
+					// vary the data, so that chunks are not all identical.
+					buf[0], buf[1] = byte(i), byte(i>>8)
+
+					if _, err := w.Write(buf); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				if _, err := w.Result(); err != nil {
+					b.Fatal(err)
+				}
+
+				w.Close()
+			}
+		})
+	}
+}
@@ -282,6 +282,16 @@ func LoadIndexObject(ctx context.Context, cr contentReader, indexObjectID ID) ([
 }
 
 func newRawReader(ctx context.Context, cr contentReader, objectID ID, assertLength int64) (Reader, error) {
+	payload, err := readRawObject(ctx, cr, objectID, assertLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return newObjectReaderWithData(payload), nil
+}
+
+// readRawObject returns the data of an object stored in a single content.
+func readRawObject(ctx context.Context, cr contentReader, objectID ID, assertLength int64) ([]byte, error) {
 	contentID, compressed, ok := objectID.ContentID()
 	if !ok {
 		return nil, errors.Errorf("unsupported object ID: %v", objectID)
@@ -310,7 +320,7 @@ func newRawReader(ctx context.Context, cr contentReader, objectID ID, assertLeng
 		return nil, errors.Errorf("unexpected chunk length %v, expected %v", len(payload), assertLength)
 	}
 
-	return newObjectReaderWithData(payload), nil
+	return payload, nil
 }
 
 type readerWithData struct {
@@ -19,6 +19,10 @@
 
 const indirectContentPrefix = "x"
 
+// ChunkLimitFallbackSplitter is the splitter used for the remainder of an object after it has been
+// split into more than WriterOptions.MaxChunks chunks.
+const ChunkLimitFallbackSplitter = "FIXED-8M"
+
 // Writer allows writing content to the storage and supports automatic deduplication and encryption
 // of written data.
 type Writer interface {
@@ -84,6 +88,10 @@ type objectWriter struct {
 
 	splitter splitter.Splitter
 
+	maxChunks            int
+	chunkLimitExceeded   bool
+	onChunkLimitExceeded func(chunkCount int, newSplitter string)
+
 	// provides mutual exclusion of all public APIs (Write, Result, Checkpoint)
 	mu sync.Mutex
 
@@ -131,12 +139,43 @@ func (w *objectWriter) Write(data []byte) (n int, err error) {
 			return 0, err
 		}
 
+		w.maybeSwitchToFallbackSplitter()
+
 		data = data[n:]
 	}
 
 	return dataLen, nil
 }
 
+// maybeSwitchToFallbackSplitter switches the remainder of the object to a splitter producing larger chunks
+// once the object has been split into too many chunks, to bound the size of the index and the indirect object.
+// Must be called when the buffer is empty, so that the new splitter sees all remaining data.
+func (w *objectWriter) maybeSwitchToFallbackSplitter() {
+	if w.maxChunks <= 0 || w.chunkLimitExceeded || len(w.indirectIndex) < w.maxChunks {
+		return
+	}
+
+	w.chunkLimitExceeded = true
+
+	newSplitter := ""
+
+	if fallback := splitter.GetFactory(ChunkLimitFallbackSplitter)(); fallback.MaxSegmentSize() > w.splitter.MaxSegmentSize() {
+		w.splitter.Close()
+		w.splitter = fallback
+		newSplitter = ChunkLimitFallbackSplitter
+
+		log(w.ctx).Warnf("%v exceeded %v chunks, switching to %v splitter for the remainder", w.description, w.maxChunks, newSplitter)
+	} else {
+		fallback.Close()
+
+		log(w.ctx).Warnf("%v exceeded %v chunks, continuing with the current splitter", w.description, w.maxChunks)
+	}
+
+	if w.onChunkLimitExceeded != nil {
+		w.onChunkLimitExceeded(len(w.indirectIndex), newSplitter)
+	}
+}
+
 func (w *objectWriter) flushBuffer() error {
 	length := w.buffer.Length()
 
@@ -339,4 +378,17 @@ type WriterOptions struct {
 	Compressor  compression.Name
 	Splitter    string // use particular splitter instead of default
 	AsyncWrites int    // allow up to N content writes to be asynchronous
+
+	// ParallelHashing, when greater than AsyncWrites, allows up to N chunks to be hashed and written
+	// concurrently if the object is split using a fixed-size splitter. Chunks keep their order in the
+	// object, so the resulting object ID is the same as when writing sequentially.
+	ParallelHashing int
+
+	// MaxChunks, when positive, limits the number of chunks produced by the splitter. After the object
+	// has been split into MaxChunks chunks, the remainder is split into large fixed-size chunks instead.
+	MaxChunks int
+
+	// OnChunkLimitExceeded is invoked when MaxChunks has been reached with the number of chunks so far
+	// and the name of the splitter used for the remainder, which is empty if the splitter was not changed.
+	OnChunkLimitExceeded func(chunkCount int, newSplitter string)
 }
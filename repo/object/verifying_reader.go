@@ -0,0 +1,176 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// ErrVerificationNotSupported is returned by OpenVerifying when the content reader can't compute content IDs.
+var ErrVerificationNotSupported = errors.New("content hash verification is not supported by the content reader")
+
+// ContentMismatchError is returned by VerifyingReader when the data of a content does not hash to its content ID.
+type ContentMismatchError struct {
+	ContentID  content.ID
+	ComputedID content.ID
+
+	// Offset is the offset of the content data within the object, -1 for contents of indirect object indexes.
+	Offset int64
+}
+
+func (e *ContentMismatchError) Error() string {
+	return fmt.Sprintf("content %v at offset %v does not match its data, which hashes to %v", e.ContentID, e.Offset, e.ComputedID)
+}
+
+// VerifiedBlock is a block of object data, which has been verified to match its content ID.
+type VerifiedBlock struct {
+	ContentID content.ID
+	Offset    int64
+	Data      []byte
+}
+
+type contentIDComputer interface {
+	ComputeContentID(ctx context.Context, data gather.Bytes, prefix content.IDPrefix) (content.ID, error)
+}
+
+type verifyingContentReader interface {
+	contentReader
+	contentIDComputer
+}
+
+// hashVerifyingContentReader verifies all contents it returns, including contents of indirect object indexes.
+type hashVerifyingContentReader struct {
+	verifyingContentReader
+}
+
+func (r hashVerifyingContentReader) GetContent(ctx context.Context, contentID content.ID) ([]byte, error) {
+	data, err := r.verifyingContentReader.GetContent(ctx, contentID)
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+
+	computed, err := r.ComputeContentID(ctx, gather.FromSlice(data), contentID.Prefix())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to compute content ID of %v", contentID)
+	}
+
+	if computed != contentID {
+		return nil, &ContentMismatchError{ContentID: contentID, ComputedID: computed, Offset: -1}
+	}
+
+	return data, nil
+}
+
+// VerifyingReader streams the data of an object one content at a time, verifying each content against its
+// content ID before returning it, which is meant for tools such as security scanners, which must not see
+// data that was tampered with. Only one content is held in memory at a time.
+type VerifyingReader struct {
+	// VerifyingReader implements io.Reader, but needs context to read from repository
+	ctx context.Context //nolint:containedctx
+
+	cr hashVerifyingContentReader
+
+	// entries which remain to be read, possibly referring to nested indirect objects.
+	pending []IndirectObjectEntry
+
+	current    []byte
+	currentPos int
+}
+
+// NextBlock returns the next verified block of object data or io.EOF at the end of the object.
+// When a content doesn't match its ID, a *ContentMismatchError is returned.
+func (r *VerifyingReader) NextBlock() (VerifiedBlock, error) {
+	for len(r.pending) > 0 {
+		e := r.pending[0]
+		r.pending = r.pending[1:]
+
+		if indexObjectID, ok := e.Object.IndexObjectID(); ok {
+			entries, err := LoadIndexObject(r.ctx, r.cr, indexObjectID)
+			if err != nil {
+				return VerifiedBlock{}, errors.Wrapf(err, "error loading index of %v", e.Object)
+			}
+
+			nested := make([]IndirectObjectEntry, 0, len(entries)+len(r.pending))
+
+			for _, ne := range entries {
+				ne.Start += e.Start
+				nested = append(nested, ne)
+			}
+
+			r.pending = append(nested, r.pending...)
+
+			continue
+		}
+
+		contentID, _, ok := e.Object.ContentID()
+		if !ok {
+			return VerifiedBlock{}, errors.Errorf("unsupported object ID: %v", e.Object)
+		}
+
+		data, err := readRawObject(r.ctx, r.cr, e.Object, -1)
+		if err != nil {
+			var me *ContentMismatchError
+			if errors.As(err, &me) {
+				me.Offset = e.Start
+			}
+
+			return VerifiedBlock{}, err
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		return VerifiedBlock{ContentID: contentID, Offset: e.Start, Data: data}, nil
+	}
+
+	return VerifiedBlock{}, io.EOF
+}
+
+// Read implements io.Reader, returning only data of verified blocks.
+func (r *VerifyingReader) Read(buffer []byte) (int, error) {
+	for r.currentPos >= len(r.current) {
+		b, err := r.NextBlock()
+		if err != nil {
+			return 0, err
+		}
+
+		r.current, r.currentPos = b.Data, 0
+	}
+
+	n := copy(buffer, r.current[r.currentPos:])
+	r.currentPos += n
+
+	return n, nil
+}
+
+// Close releases resources associated with the reader.
+func (r *VerifyingReader) Close() error {
+	r.pending = nil
+	r.current = nil
+
+	return nil
+}
+
+// OpenVerifying returns a VerifyingReader for the provided object. Contents of the object and of its indirect
+// indexes are verified by recomputing their content IDs, which requires the content reader to support
+// ComputeContentID, otherwise ErrVerificationNotSupported is returned. In per-source deduplication scope
+// the context must be associated with the deduplication namespace in which the object was written.
+func OpenVerifying(ctx context.Context, cr contentReader, oid ID) (*VerifyingReader, error) {
+	vcr, ok := cr.(verifyingContentReader)
+	if !ok {
+		return nil, ErrVerificationNotSupported
+	}
+
+	return &VerifyingReader{
+		ctx:     ctx,
+		cr:      hashVerifyingContentReader{vcr},
+		pending: []IndirectObjectEntry{{Object: oid}},
+	}, nil
+}
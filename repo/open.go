@@ -20,10 +20,13 @@
 	"github.com/kopia/kopia/internal/retry"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/beforeop"
+	"github.com/kopia/kopia/repo/blob/checksum"
 	loggingwrapper "github.com/kopia/kopia/repo/blob/logging"
 	"github.com/kopia/kopia/repo/blob/readonly"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/blob/storagemetrics"
 	"github.com/kopia/kopia/repo/blob/throttling"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/format"
 	"github.com/kopia/kopia/repo/logging"
@@ -46,8 +49,18 @@
 var supportedFeatures = []feature.Feature{
 	"index-v1",
 	"index-v2",
+	featureBlobChecksum,
+	featureSplitBlobs,
 }
 
+const (
+	// featureBlobChecksum is required by repositories storing a checksum with each blob.
+	featureBlobChecksum feature.Feature = "blob-checksum"
+
+	// featureSplitBlobs is required by repositories splitting blobs larger than the maximum object size.
+	featureSplitBlobs feature.Feature = "split-blobs"
+)
+
 // throttlingWindow is the duration window during which the throttling token bucket fully replenishes.
 // the maximum number of tokens in the bucket is multiplied by the number of seconds.
 const throttlingWindow = 60 * time.Second
@@ -72,6 +85,17 @@ type Options struct {
 	DoNotWaitForUpgrade bool                       // Disable the exponential forever backoff on an upgrade lock.
 	BeforeFlush         []RepositoryWriterCallback // list of callbacks to invoke before every flush
 
+	VerifyAfterWriteRate float64 // Fraction of newly written pack blobs to read back and verify (0 disables)
+	VerifyAfterWriteSeed int64   // Seed for selecting blobs to verify after write (0 is random)
+
+	DisableBlobChecksumVerification bool // Do not verify blob checksums when blobs are read in their entirety
+
+	ImportedContentIndex []byte // Content index export used instead of the index blobs it was produced from
+
+	CompressionPredictor compression.Predictor // Decides whether to attempt compression of contents, always when nil
+
+	IndexLoading content.IndexLoadingMode // Whether to load indexes when opening (default) or on first access
+
 	OnFatalError func(err error) // function to invoke when repository encounters a fatal error, usually invokes os.Exit
 
 	// test-only flags
@@ -159,6 +183,7 @@ func getContentCacheOrNil(ctx context.Context, si *APIServerInfo, opt *content.C
 		MaxSizeBytes: opt.ContentCacheSizeBytes,
 		LimitBytes:   opt.ContentCacheSizeLimitBytes,
 		MinSweepAge:  opt.MinContentSweepAge.DurationOrDefault(content.DefaultDataCacheSweepAge),
+		TTL:          opt.ContentCacheTTL.DurationOrDefault(0),
 	}, mr, timeNow)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to open persistent cache")
@@ -239,6 +264,11 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		TimeNow:                defaultTime(options.TimeNowFunc),
 		DisableInternalLog:     options.DisableInternalLog,
 		PermissiveCacheLoading: cliOpts.PermissiveCacheLoading,
+		VerifyAfterWriteRate:   options.VerifyAfterWriteRate,
+		VerifyAfterWriteSeed:   options.VerifyAfterWriteSeed,
+		ImportedIndex:          options.ImportedContentIndex,
+		CompressionPredictor:   options.CompressionPredictor,
+		IndexLoading:           options.IndexLoading,
 	}
 
 	mr := metrics.NewRegistry()
@@ -266,7 +296,7 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		limits = *cliOpts.Throttling
 	}
 
-	st, throttler, ferr := addThrottler(st, limits)
+	st, throttler, ferr := addThrottler(st, limits, mr)
 	if ferr != nil {
 		return nil, errors.Wrap(ferr, "unable to add throttler")
 	}
@@ -287,6 +317,24 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		return nil, errors.Wrap(err, "blob configuration")
 	}
 
+	if m := maxObjectSizeFromConnectionInfo(st.ConnectionInfo()); m != blobcfg.MaxObjectSize && m != 0 {
+		log(ctx).Warnf("ignoring maximum object size of the storage (%v), the repository uses %v", m, blobcfg.MaxObjectSize)
+	}
+
+	// splitting is done before checksums are added, so that the checksum covers the entire blob.
+	if blobcfg.MaxObjectSize > 0 {
+		st = splitting.NewWrapper(st, blobcfg.MaxObjectSize)
+	}
+
+	if blobcfg.ChecksumAlgorithm != "" {
+		st, err = checksum.NewWrapperWithOptions(st, blobcfg.ChecksumAlgorithm, checksum.Options{
+			DisableVerifyOnRead: options.DisableBlobChecksumVerification,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to add blob checksum wrapper")
+		}
+	}
+
 	if blobcfg.IsRetentionEnabled() {
 		st = wrapLockingStorage(st, blobcfg)
 	}
@@ -411,8 +459,8 @@ func wrapLockingStorage(st blob.Storage, r format.BlobStorageConfiguration) blob
 	})
 }
 
-func addThrottler(st blob.Storage, limits throttling.Limits) (blob.Storage, throttling.SettableThrottler, error) {
-	throttler, err := throttling.NewThrottler(limits, throttlingWindow, throttleBucketInitialFill)
+func addThrottler(st blob.Storage, limits throttling.Limits, mr *metrics.Registry) (blob.Storage, throttling.SettableThrottler, error) {
+	throttler, err := throttling.NewThrottler(limits, throttlingWindow, throttleBucketInitialFill, mr)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "unable to create throttler")
 	}
@@ -477,6 +525,21 @@ func upgradeLockMonitor(
 	return beforeop.NewUniformWrapper(st, cb)
 }
 
+func maxObjectSizeFromConnectionInfo(ci blob.ConnectionInfo) int64 {
+	v, err := json.Marshal(ci.Config)
+	if err != nil {
+		return 0
+	}
+
+	var l splitting.ObjectSizeLimit
+
+	if err := json.Unmarshal(v, &l); err != nil {
+		return 0
+	}
+
+	return l.MaxObjectSize
+}
+
 func throttlingLimitsFromConnectionInfo(ctx context.Context, ci blob.ConnectionInfo) throttling.Limits {
 	v, err := json.Marshal(ci.Config)
 	if err != nil {
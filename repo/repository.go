@@ -72,6 +72,9 @@ type DirectRepository interface {
 	BlobReader() blob.Reader
 	BlobVolume() blob.Volume
 	ContentReader() content.Reader
+	DescribeObject(ctx context.Context, id object.ID) (*object.Layout, error)
+	FragmentationReport(ctx context.Context, id object.ID) (*object.Fragmentation, error)
+	OpenVerifyingObject(ctx context.Context, id object.ID) (*object.VerifyingReader, error)
 	IndexBlobs(ctx context.Context, includeInactive bool) ([]indexblob.Metadata, error)
 	NewDirectWriter(ctx context.Context, opt WriteSessionOptions) (context.Context, DirectRepositoryWriter, error)
 	AlsoLogToContentLog(ctx context.Context) context.Context
@@ -196,12 +199,35 @@ func (r *directRepository) OpenObject(ctx context.Context, id object.ID) (object
 	return object.Open(ctx, r.cmgr, id)
 }
 
+// OpenVerifyingObject opens a reader for a given object, which verifies each content against its content ID.
+func (r *directRepository) OpenVerifyingObject(ctx context.Context, id object.ID) (*object.VerifyingReader, error) {
+	//nolint:wrapcheck
+	return object.OpenVerifying(ctx, r.cmgr, id)
+}
+
 // VerifyObject verifies that the given object is stored properly in a repository and returns backing content IDs.
 func (r *directRepository) VerifyObject(ctx context.Context, id object.ID) ([]content.ID, error) {
 	//nolint:wrapcheck
 	return object.VerifyObject(ctx, r.cmgr, id)
 }
 
+// DescribeObject returns the layout of contents backing the given object without reading its data.
+func (r *directRepository) DescribeObject(ctx context.Context, id object.ID) (*object.Layout, error) {
+	//nolint:wrapcheck
+	return object.DescribeObject(ctx, r.cmgr, id)
+}
+
+// FragmentationReport returns the fragmentation of contents backing the given object across pack blobs without reading its data.
+func (r *directRepository) FragmentationReport(ctx context.Context, id object.ID) (*object.Fragmentation, error) {
+	mp, err := r.FormatManager().GetMutableParameters(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "mutable parameters")
+	}
+
+	//nolint:wrapcheck
+	return object.FragmentationReport(ctx, r.cmgr, id, mp.PackAlignment)
+}
+
 // GetManifest returns the given manifest data and metadata.
 func (r *directRepository) GetManifest(ctx context.Context, id manifest.ID, data interface{}) (*manifest.EntryMetadata, error) {
 	//nolint:wrapcheck
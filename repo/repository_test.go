@@ -3,6 +3,7 @@
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"io"
 	"math/rand"
 	"runtime/debug"
@@ -26,9 +27,12 @@
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/beforeop"
+	"github.com/kopia/kopia/repo/blob/checksum"
+	"github.com/kopia/kopia/repo/blob/splitting"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/content/indexblob"
 	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/repo/object"
 )
 
@@ -591,6 +595,163 @@ func TestObjectWritesWithRetention(t *testing.T) {
 	}))
 }
 
+func TestOpenVerifyingObject(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	payload := make([]byte, 2<<20+1000)
+	rand.Read(payload)
+
+	writer := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{Compressor: "gzip"})
+	_, err := writer.Write(payload)
+	require.NoError(t, err)
+
+	oid, err := writer.Result()
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	r, err := env.Repository.(repo.DirectRepository).OpenVerifyingObject(ctx, oid)
+	require.NoError(t, err)
+
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, payload, got)
+}
+
+func TestBlobChecksum(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+		NewRepositoryOptions: func(nro *repo.NewRepositoryOptions) {
+			nro.BlobChecksumAlgorithm = checksum.SHA256
+		},
+	})
+
+	blobcfg, err := env.RepositoryWriter.FormatManager().BlobCfgBlob(ctx)
+	require.NoError(t, err)
+	require.Equal(t, checksum.SHA256, blobcfg.ChecksumAlgorithm)
+
+	// the checksum algorithm can't be changed.
+	mp, err := env.RepositoryWriter.FormatManager().GetMutableParameters(ctx)
+	require.NoError(t, err)
+
+	rf, err := env.RepositoryWriter.FormatManager().RequiredFeatures(ctx)
+	require.NoError(t, err)
+	require.Len(t, rf, 1)
+
+	blobcfg2 := blobcfg
+	blobcfg2.ChecksumAlgorithm = checksum.CRC32C
+	require.ErrorContains(t, env.RepositoryWriter.FormatManager().SetParameters(ctx, mp, blobcfg2, rf), "can't be changed")
+
+	oid := writeObject(ctx, t, env.RepositoryWriter, []byte{1, 2, 3}, "test-1")
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	env.MustReopen(t)
+	verify(ctx, t, env.Repository, oid, []byte{1, 2, 3}, "test-1")
+
+	// blobs written by the repository are longer than reported by the repository storage.
+	var packBlobs []blob.Metadata
+
+	require.NoError(t, env.RepositoryWriter.BlobReader().ListBlobs(ctx, content.PackBlobIDPrefixRegular, func(bm blob.Metadata) error {
+		packBlobs = append(packBlobs, bm)
+		return nil
+	}))
+	require.NotEmpty(t, packBlobs)
+
+	for _, bm := range packBlobs {
+		raw, err := env.RootStorage().GetMetadata(ctx, bm.BlobID)
+		require.NoError(t, err)
+		require.Equal(t, bm.Length+sha256.Size, raw.Length)
+	}
+
+	// corruption of index blobs is detected when they are read.
+	var indexBlobs []blob.Metadata
+
+	require.NoError(t, env.RootStorage().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		if strings.HasPrefix(string(bm.BlobID), "x") || strings.HasPrefix(string(bm.BlobID), "q") {
+			indexBlobs = append(indexBlobs, bm)
+		}
+
+		return nil
+	}))
+	require.NotEmpty(t, indexBlobs)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	for _, bm := range indexBlobs {
+		require.NoError(t, env.RootStorage().GetBlob(ctx, bm.BlobID, 0, -1, &tmp))
+
+		data := tmp.ToByteSlice()
+		data[0] ^= 1
+
+		require.NoError(t, env.RootStorage().PutBlob(ctx, bm.BlobID, gather.FromSlice(data), blob.PutOptions{}))
+		require.ErrorIs(t, env.RepositoryWriter.BlobReader().GetBlob(ctx, bm.BlobID, 0, -1, &tmp), checksum.ErrChecksumMismatch)
+	}
+}
+
+func TestSplitBlobs(t *testing.T) {
+	const maxObjectSize = 64 << 10
+
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+		NewRepositoryOptions: func(nro *repo.NewRepositoryOptions) {
+			nro.MaxObjectSize = maxObjectSize
+		},
+	})
+
+	blobcfg, err := env.RepositoryWriter.FormatManager().BlobCfgBlob(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, maxObjectSize, blobcfg.MaxObjectSize)
+
+	mp, err := env.RepositoryWriter.FormatManager().GetMutableParameters(ctx)
+	require.NoError(t, err)
+
+	rf, err := env.RepositoryWriter.FormatManager().RequiredFeatures(ctx)
+	require.NoError(t, err)
+	require.Len(t, rf, 1)
+
+	blobcfg2 := blobcfg
+	blobcfg2.MaxObjectSize *= 2
+	require.ErrorContains(t, env.RepositoryWriter.FormatManager().SetParameters(ctx, mp, blobcfg2, rf), "can't be changed")
+
+	payload := make([]byte, 10*maxObjectSize)
+	rand.Read(payload)
+
+	oid := writeObject(ctx, t, env.RepositoryWriter, payload, "split")
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	countParts := func() int {
+		t.Helper()
+
+		var parts int
+
+		require.NoError(t, env.RootStorage().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+			require.LessOrEqual(t, bm.Length, int64(maxObjectSize))
+
+			if splitting.IsPartID(bm.BlobID) {
+				parts++
+			}
+
+			return nil
+		}))
+
+		return parts
+	}
+
+	parts := countParts()
+	require.NotZero(t, parts)
+
+	// the limit is stored in the repository and applies to all clients.
+	env.MustReopen(t)
+	verify(ctx, t, env.Repository, oid, payload, "split")
+
+	// garbage collection keeps parts of split blobs.
+	_, err = maintenance.DeleteUnreferencedBlobs(ctx, env.RepositoryWriter, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Equal(t, parts, countParts())
+
+	verify(ctx, t, env.Repository, oid, payload, "split")
+}
+
 func TestWriteSessionFlushOnSuccess(t *testing.T) {
 	var beforeFlushCount, afterFlushCount atomic.Int32
 
@@ -3,6 +3,7 @@
 
 import (
 	"sort"
+	"strings"
 )
 
 const (
@@ -85,5 +86,10 @@ func GetFactory(name string) Factory {
 	return splitterFactories[name]
 }
 
+// IsFixedSize returns true if the splitter with the provided name produces fixed-size chunks.
+func IsFixedSize(name string) bool {
+	return strings.HasPrefix(name, "FIXED")
+}
+
 // DefaultAlgorithm is the name of the splitter used by default for new repositories.
 const DefaultAlgorithm = "DYNAMIC-4M-BUZHASH"
@@ -209,3 +209,21 @@ func getSplitPointsRandomSlices(data []byte, s Splitter) (minSplit, maxSplit, co
 
 	return minSplit, maxSplit, count
 }
+
+func TestIsFixedSize(t *testing.T) {
+	cases := map[string]bool{
+		"FIXED":                true,
+		"FIXED-128K":           true,
+		"FIXED-8M":             true,
+		"DYNAMIC":              false,
+		"DYNAMIC-4M-BUZHASH":   false,
+		"DYNAMIC-1M-RABINKARP": false,
+		"":                     false,
+	}
+
+	for name, want := range cases {
+		if got := IsFixedSize(name); got != want {
+			t.Errorf("invalid IsFixedSize(%q): %v, want %v", name, got, want)
+		}
+	}
+}
@@ -5,6 +5,9 @@
 	"encoding/json"
 	"sort"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 
@@ -127,6 +130,23 @@ type DirEntry struct {
 	GroupID     uint32               `json:"gid,omitempty"`
 	ObjectID    object.ID            `json:"obj,omitempty"`
 	DirSummary  *fs.DirectorySummary `json:"summ,omitempty"`
+	FileFlags   fs.FileFlags         `json:"flags,omitempty"`
+
+	// InlineData holds the data of a small file stored in the directory manifest instead of an object,
+	// in which case ObjectID is empty.
+	InlineData []byte `json:"inline,omitempty"`
+
+	// CaseCollision is set when the name of the entry differs only by case from the name of a sibling,
+	// so both can't be restored to a case-insensitive filesystem.
+	CaseCollision bool `json:"caseCollision,omitempty"`
+}
+
+// MaxInlineFileSize is the maximum size of a file which can be stored inline in a directory manifest.
+const MaxInlineFileSize = 64 << 10
+
+// IsInline returns true if the entry is a file whose data is stored inline in the directory manifest.
+func (e *DirEntry) IsInline() bool {
+	return e.Type == EntryTypeFile && e.ObjectID == object.EmptyID
 }
 
 // Clone returns a clone of the entry.
@@ -160,6 +180,10 @@ type DirManifest struct {
 	StreamType string               `json:"stream"` // legacy
 	Entries    []*DirEntry          `json:"entries"`
 	Summary    *fs.DirectorySummary `json:"summary"`
+
+	// Shards holds the IDs of directory manifests storing consecutive ranges of entries of
+	// a directory too large to be stored in a single manifest, in which case Entries is empty.
+	Shards []object.ID `json:"shards,omitempty"`
 }
 
 // RootObjectID returns the ID of a root object.
@@ -217,6 +241,43 @@ type StorageUsageDetails struct {
 	ContentCount int32 `json:"contents"`
 }
 
+// MaxDescriptionLength is the maximum length of a snapshot description in bytes.
+const MaxDescriptionLength = 1024
+
+// ValidateDescription returns an error if the provided snapshot description is too long, is not valid UTF-8
+// or contains control characters. It is meant for descriptions provided by users, since descriptions
+// of existing snapshots may predate these rules and must remain usable.
+func ValidateDescription(desc string) error {
+	if len(desc) > MaxDescriptionLength {
+		return errors.Errorf("description too long (%v bytes, maximum is %v)", len(desc), MaxDescriptionLength)
+	}
+
+	if !utf8.ValidString(desc) {
+		return errors.New("description is not valid UTF-8")
+	}
+
+	if strings.IndexFunc(desc, unicode.IsControl) >= 0 {
+		return errors.New("description must not contain control characters")
+	}
+
+	return nil
+}
+
+// FilterByDescription returns manifests whose description contains the provided text, ignoring case.
+func FilterByDescription(manifests []*Manifest, text string) []*Manifest {
+	text = strings.ToLower(text)
+
+	var result []*Manifest
+
+	for _, m := range manifests {
+		if strings.Contains(strings.ToLower(m.Description), text) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
 // GroupBySource returns a slice of slices, such that each result item contains manifests from a single source.
 func GroupBySource(manifests []*Manifest) [][]*Manifest {
 	resultMap := map[SourceInfo][]*Manifest{}
@@ -1,6 +1,12 @@
 package policy
 
-import "github.com/kopia/kopia/snapshot"
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/snapshot"
+)
 
 // ErrorHandlingPolicy controls error hadnling behavior when taking snapshots.
 type ErrorHandlingPolicy struct {
@@ -12,13 +18,27 @@ type ErrorHandlingPolicy struct {
 
 	// IgnoreUnknownTypes controls whether or not snapshot operation should fail when it encounters a directory entry of an unknown type.
 	IgnoreUnknownTypes *OptionalBool `json:"ignoreUnknownTypes,omitempty"`
+
+	// IgnorePermissionDeniedFiles controls whether files that can't be read due to insufficient permissions should be
+	// skipped (and recorded in the directory summary) instead of failing the snapshot. Defaults to IgnoreFileErrors.
+	IgnorePermissionDeniedFiles *OptionalBool `json:"ignorePermissionDeniedFiles,omitempty"`
+
+	// IgnorePermissionDeniedDirectories controls whether directories that can't be read due to insufficient permissions should be
+	// skipped (and recorded in the directory summary) instead of failing the snapshot. Defaults to IgnoreDirectoryErrors.
+	IgnorePermissionDeniedDirectories *OptionalBool `json:"ignorePermissionDeniedDirectories,omitempty"`
+
+	// ZeroFillDeviceReadErrors controls whether unreadable regions of block devices (such as bad sectors) should be skipped and replaced with zeros.
+	ZeroFillDeviceReadErrors *OptionalBool `json:"zeroFillDeviceReadErrors,omitempty"`
 }
 
 // ErrorHandlingPolicyDefinition specifies which policy definition provided the value of a particular field.
 type ErrorHandlingPolicyDefinition struct {
-	IgnoreFileErrors      snapshot.SourceInfo `json:"ignoreFileErrors,omitempty"`
-	IgnoreDirectoryErrors snapshot.SourceInfo `json:"ignoreDirectoryErrors,omitempty"`
-	IgnoreUnknownTypes    snapshot.SourceInfo `json:"ignoreUnknownTypes,omitempty"`
+	IgnoreFileErrors                  snapshot.SourceInfo `json:"ignoreFileErrors,omitempty"`
+	IgnoreDirectoryErrors             snapshot.SourceInfo `json:"ignoreDirectoryErrors,omitempty"`
+	IgnoreUnknownTypes                snapshot.SourceInfo `json:"ignoreUnknownTypes,omitempty"`
+	IgnorePermissionDeniedFiles       snapshot.SourceInfo `json:"ignorePermissionDeniedFiles,omitempty"`
+	IgnorePermissionDeniedDirectories snapshot.SourceInfo `json:"ignorePermissionDeniedDirectories,omitempty"`
+	ZeroFillDeviceReadErrors          snapshot.SourceInfo `json:"zeroFillDeviceReadErrors,omitempty"`
 }
 
 // Merge applies default values from the provided policy.
@@ -26,4 +46,25 @@ func (p *ErrorHandlingPolicy) Merge(src ErrorHandlingPolicy, def *ErrorHandlingP
 	mergeOptionalBool(&p.IgnoreFileErrors, src.IgnoreFileErrors, &def.IgnoreFileErrors, si)
 	mergeOptionalBool(&p.IgnoreDirectoryErrors, src.IgnoreDirectoryErrors, &def.IgnoreDirectoryErrors, si)
 	mergeOptionalBool(&p.IgnoreUnknownTypes, src.IgnoreUnknownTypes, &def.IgnoreUnknownTypes, si)
+	mergeOptionalBool(&p.IgnorePermissionDeniedFiles, src.IgnorePermissionDeniedFiles, &def.IgnorePermissionDeniedFiles, si)
+	mergeOptionalBool(&p.IgnorePermissionDeniedDirectories, src.IgnorePermissionDeniedDirectories, &def.IgnorePermissionDeniedDirectories, si)
+	mergeOptionalBool(&p.ZeroFillDeviceReadErrors, src.ZeroFillDeviceReadErrors, &def.ZeroFillDeviceReadErrors, si)
+}
+
+// ShouldIgnoreFileError returns true if the provided error encountered while reading a file should be ignored.
+func (p *ErrorHandlingPolicy) ShouldIgnoreFileError(err error) bool {
+	if errors.Is(err, os.ErrPermission) {
+		return p.IgnorePermissionDeniedFiles.OrDefault(p.IgnoreFileErrors.OrDefault(false))
+	}
+
+	return p.IgnoreFileErrors.OrDefault(false)
+}
+
+// ShouldIgnoreDirectoryError returns true if the provided error encountered while reading a directory should be ignored.
+func (p *ErrorHandlingPolicy) ShouldIgnoreDirectoryError(err error) bool {
+	if errors.Is(err, os.ErrPermission) {
+		return p.IgnorePermissionDeniedDirectories.OrDefault(p.IgnoreDirectoryErrors.OrDefault(false))
+	}
+
+	return p.IgnoreDirectoryErrors.OrDefault(false)
 }
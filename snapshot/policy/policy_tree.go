@@ -17,9 +17,10 @@
 
 	// defaultErrorHandlingPolicy is the default error handling policy.
 	defaultErrorHandlingPolicy = ErrorHandlingPolicy{
-		IgnoreFileErrors:      NewOptionalBool(false),
-		IgnoreDirectoryErrors: NewOptionalBool(false),
-		IgnoreUnknownTypes:    NewOptionalBool(true),
+		IgnoreFileErrors:         NewOptionalBool(false),
+		IgnoreDirectoryErrors:    NewOptionalBool(false),
+		IgnoreUnknownTypes:       NewOptionalBool(true),
+		ZeroFillDeviceReadErrors: NewOptionalBool(false),
 	}
 
 	// defaultFilesPolicy is the default file ignore policy.
@@ -52,7 +53,8 @@
 	}
 
 	defaultSchedulingPolicy = SchedulingPolicy{
-		RunMissed: NewOptionalBool(defaultRunMissed),
+		RunMissed:        NewOptionalBool(defaultRunMissed),
+		MaxJitterSeconds: newOptionalInt64(defaultMaxJitterSeconds),
 	}
 
 	defaultOSSnapshotPolicy = OSSnapshotPolicy{
@@ -67,6 +69,8 @@
 
 		// upload large files in chunks of 2 GiB
 		ParallelUploadAboveSize: newOptionalInt64(2 << 30), //nolint:mnd
+
+		MaxParallelChunkHashing: nil, // chunks are hashed sequentially
 	}
 
 	// DefaultPolicy is a default policy returned by policy tree in absence of other policies.
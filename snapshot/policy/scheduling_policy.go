@@ -4,6 +4,7 @@
 	"cmp"
 	"context"
 	"fmt"
+	"hash/fnv"
 	"reflect"
 	"slices"
 	"strings"
@@ -61,25 +62,32 @@ func SortAndDedupeTimesOfDay(tod []TimeOfDay) []TimeOfDay {
 
 // SchedulingPolicy describes policy for scheduling snapshots.
 type SchedulingPolicy struct {
-	IntervalSeconds    int64         `json:"intervalSeconds,omitempty"`
-	TimesOfDay         []TimeOfDay   `json:"timeOfDay,omitempty"`
-	NoParentTimesOfDay bool          `json:"noParentTimeOfDay,omitempty"`
-	Manual             bool          `json:"manual,omitempty"`
-	Cron               []string      `json:"cron,omitempty"`
-	RunMissed          *OptionalBool `json:"runMissed,omitempty"`
+	IntervalSeconds    int64          `json:"intervalSeconds,omitempty"`
+	TimesOfDay         []TimeOfDay    `json:"timeOfDay,omitempty"`
+	NoParentTimesOfDay bool           `json:"noParentTimeOfDay,omitempty"`
+	Manual             bool           `json:"manual,omitempty"`
+	Cron               []string       `json:"cron,omitempty"`
+	RunMissed          *OptionalBool  `json:"runMissed,omitempty"`
+	MaxJitterSeconds   *OptionalInt64 `json:"maxJitterSeconds,omitempty"`
 }
 
 // SchedulingPolicyDefinition specifies which policy definition provided the value of a particular field.
 type SchedulingPolicyDefinition struct {
-	IntervalSeconds snapshot.SourceInfo `json:"intervalSeconds,omitempty"`
-	TimesOfDay      snapshot.SourceInfo `json:"timeOfDay,omitempty"`
-	Cron            snapshot.SourceInfo `json:"cron,omitempty"`
-	Manual          snapshot.SourceInfo `json:"manual,omitempty"`
-	RunMissed       snapshot.SourceInfo `json:"runMissed,omitempty"`
+	IntervalSeconds  snapshot.SourceInfo `json:"intervalSeconds,omitempty"`
+	TimesOfDay       snapshot.SourceInfo `json:"timeOfDay,omitempty"`
+	Cron             snapshot.SourceInfo `json:"cron,omitempty"`
+	Manual           snapshot.SourceInfo `json:"manual,omitempty"`
+	RunMissed        snapshot.SourceInfo `json:"runMissed,omitempty"`
+	MaxJitterSeconds snapshot.SourceInfo `json:"maxJitterSeconds,omitempty"`
 }
 
-// defaultRunMissed is the value for RunMissed.
-const defaultRunMissed = true
+const (
+	// defaultRunMissed is the value for RunMissed.
+	defaultRunMissed = true
+
+	// defaultMaxJitterSeconds is the default value for MaxJitterSeconds.
+	defaultMaxJitterSeconds = 60
+)
 
 // Interval returns the snapshot interval or zero if not specified.
 func (p *SchedulingPolicy) Interval() time.Duration {
@@ -91,9 +99,26 @@ func (p *SchedulingPolicy) SetInterval(d time.Duration) {
 	p.IntervalSeconds = int64(d.Seconds())
 }
 
+// MaxJitter returns the maximum random delay applied to scheduled snapshots.
+func (p *SchedulingPolicy) MaxJitter() time.Duration {
+	return time.Duration(p.MaxJitterSeconds.OrDefault(0)) * time.Second
+}
+
 // NextSnapshotTime computes next snapshot time given previous
 // snapshot time and current wall clock time.
 func (p *SchedulingPolicy) NextSnapshotTime(previousSnapshotTime, now time.Time) (time.Time, bool) {
+	return p.nextSnapshotTime(previousSnapshotTime, now, nil)
+}
+
+// NextSnapshotTimeForSource computes next snapshot time for the provided source given previous
+// snapshot time and current wall clock time. Each scheduled snapshot is delayed by a pseudo-random
+// amount of time up to MaxJitter(), which is stable for a given source and scheduled time, so that
+// sources sharing the same schedule don't all start at the same moment.
+func (p *SchedulingPolicy) NextSnapshotTimeForSource(previousSnapshotTime, now time.Time, si snapshot.SourceInfo) (time.Time, bool) {
+	return p.nextSnapshotTime(previousSnapshotTime, now, &si)
+}
+
+func (p *SchedulingPolicy) nextSnapshotTime(previousSnapshotTime, now time.Time, si *snapshot.SourceInfo) (time.Time, bool) {
 	if p.Manual {
 		return time.Time{}, false
 	}
@@ -101,8 +126,13 @@ func (p *SchedulingPolicy) NextSnapshotTime(previousSnapshotTime, now time.Time)
 	var (
 		nextSnapshotTime time.Time
 		ok               bool
+		maxJitter        time.Duration
 	)
 
+	if si != nil {
+		maxJitter = p.MaxJitter()
+	}
+
 	now = now.Local()
 	previousSnapshotTime = previousSnapshotTime.Local()
 
@@ -111,6 +141,10 @@ func (p *SchedulingPolicy) NextSnapshotTime(previousSnapshotTime, now time.Time)
 		interval := time.Duration(interval) * time.Second
 
 		nt := previousSnapshotTime.Add(interval).Truncate(interval)
+		if maxJitter > 0 {
+			nt = nt.Add(jitterForScheduledTime(*si, nt, nt.Add(interval), maxJitter))
+		}
+
 		nextSnapshotTime = nt
 		ok = true
 
@@ -119,14 +153,21 @@ func (p *SchedulingPolicy) NextSnapshotTime(previousSnapshotTime, now time.Time)
 		}
 	}
 
-	if todSnapshot, todOk := p.getNextTimeOfDaySnapshot(now); todOk && (!ok || todSnapshot.Before(nextSnapshotTime)) {
-		nextSnapshotTime = todSnapshot
-		ok = true
-	}
+	if scheduled, scheduledOk := p.getNextScheduledSnapshot(p.scheduleReferenceTime(previousSnapshotTime, now, maxJitter)); scheduledOk {
+		if maxJitter > 0 {
+			following, _ := p.getNextScheduledSnapshot(scheduled.Add(time.Second))
+			scheduled = scheduled.Add(jitterForScheduledTime(*si, scheduled, following, maxJitter))
 
-	if cronSnapshot, cronOk := p.getNextCronSnapshot(now); cronOk && (!ok || cronSnapshot.Before(nextSnapshotTime)) {
-		nextSnapshotTime = cronSnapshot
-		ok = true
+			// the jittered time has already passed but the snapshot did not run yet.
+			if scheduled.Before(now) {
+				scheduled = now
+			}
+		}
+
+		if !ok || scheduled.Before(nextSnapshotTime) {
+			nextSnapshotTime = scheduled
+			ok = true
+		}
 	}
 
 	if ok && p.checkMissedSnapshot(now, previousSnapshotTime, nextSnapshotTime) {
@@ -138,6 +179,54 @@ func (p *SchedulingPolicy) NextSnapshotTime(previousSnapshotTime, now time.Time)
 	return nextSnapshotTime, ok
 }
 
+// scheduleReferenceTime returns the time after which time-of-day and cron schedules are evaluated.
+// When jitter is used, snapshots scheduled up to maxJitter ago may still be pending, unless
+// the previous snapshot was taken after them.
+func (p *SchedulingPolicy) scheduleReferenceTime(previousSnapshotTime, now time.Time, maxJitter time.Duration) time.Time {
+	if maxJitter <= 0 {
+		return now
+	}
+
+	ref := now.Add(-maxJitter)
+
+	// We add a second to ensure that the next possible snapshot is > the last snapshot
+	if momentAfterSnapshot := previousSnapshotTime.Add(time.Second); momentAfterSnapshot.After(ref) {
+		ref = momentAfterSnapshot
+	}
+
+	return ref
+}
+
+// Get next ToD or Cron snapshot, whichever comes first.
+func (p *SchedulingPolicy) getNextScheduledSnapshot(now time.Time) (time.Time, bool) {
+	nextSnapshotTime, ok := p.getNextTimeOfDaySnapshot(now)
+
+	if cronSnapshot, cronOk := p.getNextCronSnapshot(now); cronOk && (!ok || cronSnapshot.Before(nextSnapshotTime)) {
+		nextSnapshotTime = cronSnapshot
+		ok = true
+	}
+
+	return nextSnapshotTime, ok
+}
+
+// jitterForScheduledTime returns a pseudo-random delay in the range [0, maxJitter) for a snapshot of the provided
+// source scheduled at the given time. To guarantee that jitter never causes a scheduled snapshot to be skipped,
+// the delay is limited to half of the time until the following scheduled snapshot (if any).
+func jitterForScheduledTime(si snapshot.SourceInfo, scheduled, following time.Time, maxJitter time.Duration) time.Duration {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%v@%v", si, scheduled.Unix())
+
+	jitter := time.Duration(h.Sum64() % uint64(maxJitter)) //nolint:gosec
+
+	if !following.IsZero() {
+		if limit := following.Sub(scheduled) / 2; jitter > limit { //nolint:mnd
+			jitter = limit
+		}
+	}
+
+	return jitter.Truncate(time.Second)
+}
+
 // Get next ToD snapshot.
 func (p *SchedulingPolicy) getNextTimeOfDaySnapshot(now time.Time) (time.Time, bool) {
 	const oneDay = 24 * time.Hour
@@ -242,6 +331,7 @@ func (p *SchedulingPolicy) Merge(src SchedulingPolicy, def *SchedulingPolicyDefi
 
 	mergeBool(&p.Manual, src.Manual, &def.Manual, si)
 	mergeOptionalBool(&p.RunMissed, src.RunMissed, &def.RunMissed, si)
+	mergeOptionalInt64(&p.MaxJitterSeconds, src.MaxJitterSeconds, &def.MaxJitterSeconds, si)
 }
 
 // IsManualSnapshot returns the SchedulingPolicy manual value from the given policy tree.
@@ -276,6 +366,10 @@ func ValidateSchedulingPolicy(p SchedulingPolicy) error {
 		return errors.New("invalid scheduling policy: manual cannot be combined with other scheduling policies")
 	}
 
+	if p.MaxJitterSeconds != nil && *p.MaxJitterSeconds < 0 {
+		return errors.New("invalid scheduling policy: max jitter cannot be negative")
+	}
+
 	for _, e := range p.Cron {
 		if e2 := stripCronComment(e); e2 != "" {
 			if _, err := cronexpr.Parse(e2); err != nil {
@@ -7,6 +7,7 @@
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
 )
 
@@ -289,6 +290,78 @@ func TestNextSnapshotTime(t *testing.T) {
 	}
 }
 
+func TestNextSnapshotTimeForSourceJitter(t *testing.T) {
+	pol := policy.SchedulingPolicy{
+		TimesOfDay:       []policy.TimeOfDay{{10, 0}},
+		MaxJitterSeconds: optionalInt64(300),
+	}
+
+	scheduled := time.Date(2020, time.January, 2, 10, 0, 0, 0, time.Local)
+	previousSnapshotTime := time.Date(2020, time.January, 1, 10, 3, 0, 0, time.Local)
+	now := time.Date(2020, time.January, 2, 9, 0, 0, 0, time.Local)
+
+	distinct := map[time.Time]bool{}
+
+	for i := range 20 {
+		si := snapshot.SourceInfo{Host: "host", UserName: "user", Path: fmt.Sprintf("/path%v", i)}
+
+		got, ok := pol.NextSnapshotTimeForSource(previousSnapshotTime, now, si)
+		require.True(t, ok)
+		require.False(t, got.Before(scheduled))
+		require.True(t, got.Before(scheduled.Add(pol.MaxJitter())))
+		distinct[got] = true
+
+		// jitter is stable for the same source and scheduled time.
+		got2, _ := pol.NextSnapshotTimeForSource(previousSnapshotTime, now.Add(30*time.Minute), si)
+		require.Equal(t, got, got2)
+
+		// scheduled time has passed but jittered snapshot did not run yet, it must not be skipped.
+		afterScheduled := scheduled.Add(time.Second)
+		want3 := got
+
+		if want3.Before(afterScheduled) {
+			want3 = afterScheduled
+		}
+
+		got3, _ := pol.NextSnapshotTimeForSource(previousSnapshotTime, afterScheduled, si)
+		require.Equal(t, want3, got3)
+
+		// once the snapshot has run, the next one is scheduled on the following day.
+		got4, _ := pol.NextSnapshotTimeForSource(got, got.Add(time.Second), si)
+		require.False(t, got4.Before(scheduled.Add(24*time.Hour)))
+	}
+
+	require.Greater(t, len(distinct), 1)
+
+	// without source, no jitter is applied.
+	got, _ := pol.NextSnapshotTime(previousSnapshotTime, now)
+	require.Equal(t, scheduled, got)
+}
+
+func TestNextSnapshotTimeForSourceJitterLimitedByInterval(t *testing.T) {
+	pol := policy.SchedulingPolicy{
+		IntervalSeconds:  60,
+		MaxJitterSeconds: optionalInt64(3600),
+	}
+
+	previousSnapshotTime := time.Date(2020, time.January, 1, 11, 50, 0, 0, time.Local)
+
+	for i := range 20 {
+		si := snapshot.SourceInfo{Host: "host", UserName: "user", Path: fmt.Sprintf("/path%v", i)}
+
+		got, ok := pol.NextSnapshotTimeForSource(previousSnapshotTime, previousSnapshotTime, si)
+		require.True(t, ok)
+		require.False(t, got.Before(previousSnapshotTime.Add(time.Minute)))
+		require.False(t, got.After(previousSnapshotTime.Add(90*time.Second)))
+	}
+}
+
+func optionalInt64(v int64) *policy.OptionalInt64 {
+	o := policy.OptionalInt64(v)
+
+	return &o
+}
+
 func TestSortAndDedupeTimesOfDay(t *testing.T) {
 	cases := []struct {
 		input []policy.TimeOfDay
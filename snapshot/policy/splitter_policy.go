@@ -7,12 +7,14 @@
 
 // SplitterPolicy specifies compression policy.
 type SplitterPolicy struct {
-	Algorithm string `json:"algorithm,omitempty"`
+	Algorithm        string       `json:"algorithm,omitempty"`
+	MaxChunksPerFile *OptionalInt `json:"maxChunksPerFile,omitempty"`
 }
 
 // SplitterPolicyDefinition specifies which policy definition provided the value of a particular field.
 type SplitterPolicyDefinition struct {
-	Algorithm snapshot.SourceInfo `json:"algorithm,omitempty"`
+	Algorithm        snapshot.SourceInfo `json:"algorithm,omitempty"`
+	MaxChunksPerFile snapshot.SourceInfo `json:"maxChunksPerFile,omitempty"`
 }
 
 // SplitterForFile returns splitter algorithm.
@@ -20,7 +22,14 @@ func (p *SplitterPolicy) SplitterForFile(_ fs.Entry) string {
 	return p.Algorithm
 }
 
+// MaxChunksForFile returns the maximum number of chunks the file can be split into before the uploader
+// switches to larger chunks for the remainder of the file, 0 means unlimited, which is the default.
+func (p *SplitterPolicy) MaxChunksForFile(_ fs.Entry) int {
+	return max(p.MaxChunksPerFile.OrDefault(0), 0)
+}
+
 // Merge applies default values from the provided policy.
 func (p *SplitterPolicy) Merge(src SplitterPolicy, def *SplitterPolicyDefinition, si snapshot.SourceInfo) {
 	mergeString(&p.Algorithm, src.Algorithm, &def.Algorithm, si)
+	mergeOptionalInt(&p.MaxChunksPerFile, src.MaxChunksPerFile, &def.MaxChunksPerFile, si)
 }
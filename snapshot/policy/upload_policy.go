@@ -11,13 +11,30 @@ type UploadPolicy struct {
 	MaxParallelSnapshots    *OptionalInt   `json:"maxParallelSnapshots,omitempty"`
 	MaxParallelFileReads    *OptionalInt   `json:"maxParallelFileReads,omitempty"`
 	ParallelUploadAboveSize *OptionalInt64 `json:"parallelUploadAboveSize,omitempty"`
+
+	// MaxParallelChunkHashing is the maximum number of chunks of a single file which are hashed and
+	// written concurrently when the file is split using a fixed-size splitter.
+	MaxParallelChunkHashing *OptionalInt `json:"maxParallelChunkHashing,omitempty"`
+
+	// InlineFilesUpToSize causes files up to the specified size to be stored in directory manifests instead
+	// of separate objects, negative values disable inlining. Snapshots with inline files can't be read by
+	// versions of kopia which don't support them.
+	InlineFilesUpToSize *OptionalInt64 `json:"inlineFilesUpToSize,omitempty"`
+
+	// MaxDirectoryManifestEntries causes manifests of directories with more entries to be split into
+	// shards of up to the specified number of entries, zero disables sharding. Snapshots with sharded
+	// directories can't be read by versions of kopia which don't support them.
+	MaxDirectoryManifestEntries *OptionalInt `json:"maxDirectoryManifestEntries,omitempty"`
 }
 
 // UploadPolicyDefinition specifies which policy definition provided the value of a particular field.
 type UploadPolicyDefinition struct {
-	MaxParallelSnapshots    snapshot.SourceInfo `json:"maxParallelSnapshots,omitempty"`
-	MaxParallelFileReads    snapshot.SourceInfo `json:"maxParallelFileReads,omitempty"`
-	ParallelUploadAboveSize snapshot.SourceInfo `json:"parallelUploadAboveSize,omitempty"`
+	MaxParallelSnapshots        snapshot.SourceInfo `json:"maxParallelSnapshots,omitempty"`
+	MaxParallelFileReads        snapshot.SourceInfo `json:"maxParallelFileReads,omitempty"`
+	ParallelUploadAboveSize     snapshot.SourceInfo `json:"parallelUploadAboveSize,omitempty"`
+	MaxParallelChunkHashing     snapshot.SourceInfo `json:"maxParallelChunkHashing,omitempty"`
+	InlineFilesUpToSize         snapshot.SourceInfo `json:"inlineFilesUpToSize,omitempty"`
+	MaxDirectoryManifestEntries snapshot.SourceInfo `json:"maxDirectoryManifestEntries,omitempty"`
 }
 
 // Merge applies default values from the provided policy.
@@ -25,6 +42,9 @@ func (p *UploadPolicy) Merge(src UploadPolicy, def *UploadPolicyDefinition, si s
 	mergeOptionalInt(&p.MaxParallelSnapshots, src.MaxParallelSnapshots, &def.MaxParallelSnapshots, si)
 	mergeOptionalInt(&p.MaxParallelFileReads, src.MaxParallelFileReads, &def.MaxParallelFileReads, si)
 	mergeOptionalInt64(&p.ParallelUploadAboveSize, src.ParallelUploadAboveSize, &def.ParallelUploadAboveSize, si)
+	mergeOptionalInt(&p.MaxParallelChunkHashing, src.MaxParallelChunkHashing, &def.MaxParallelChunkHashing, si)
+	mergeOptionalInt64(&p.InlineFilesUpToSize, src.InlineFilesUpToSize, &def.InlineFilesUpToSize, si)
+	mergeOptionalInt(&p.MaxDirectoryManifestEntries, src.MaxDirectoryManifestEntries, &def.MaxDirectoryManifestEntries, si)
 }
 
 // ValidateUploadPolicy returns an error if manual field is set along with Upload fields.
@@ -33,5 +53,17 @@ func ValidateUploadPolicy(si snapshot.SourceInfo, p UploadPolicy) error {
 		return errors.Errorf("max parallel snapshots cannot be specified for paths, only global, username@hostname or @hostname")
 	}
 
+	if v := p.InlineFilesUpToSize.OrDefault(-1); v > snapshot.MaxInlineFileSize {
+		return errors.Errorf("inline file size limit %v exceeds the maximum of %v", v, snapshot.MaxInlineFileSize)
+	}
+
+	if v := p.MaxParallelChunkHashing.OrDefault(0); v < 0 {
+		return errors.Errorf("max parallel chunk hashing must not be negative")
+	}
+
+	if v := p.MaxDirectoryManifestEntries.OrDefault(0); v < 0 {
+		return errors.Errorf("max directory manifest entries must not be negative")
+	}
+
 	return nil
 }
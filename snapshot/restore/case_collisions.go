@@ -0,0 +1,122 @@
+package restore
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// CaseCollisionPolicy determines how entries whose names differ only by case from a sibling are restored.
+// Such entries are detected and marked when the snapshot is created.
+type CaseCollisionPolicy string
+
+// Supported case collision policies.
+const (
+	// CaseCollisionKeep restores all entries with their original names.
+	CaseCollisionKeep CaseCollisionPolicy = "keep"
+
+	// CaseCollisionRename restores the first of the colliding entries in sort order with its original name
+	// and appends a numeric suffix to the names of the others.
+	CaseCollisionRename CaseCollisionPolicy = "rename"
+
+	// CaseCollisionSkip restores only the first of the colliding entries in sort order.
+	CaseCollisionSkip CaseCollisionPolicy = "skip"
+
+	// CaseCollisionFail fails the restore when colliding entries are found.
+	CaseCollisionFail CaseCollisionPolicy = "fail"
+)
+
+// SupportedCaseCollisionPolicies returns the list of supported case collision policies.
+func SupportedCaseCollisionPolicies() []string {
+	return []string{
+		string(CaseCollisionKeep),
+		string(CaseCollisionRename),
+		string(CaseCollisionSkip),
+		string(CaseCollisionFail),
+	}
+}
+
+// ErrCaseCollision is returned when colliding entries are found and the policy is CaseCollisionFail.
+var ErrCaseCollision = errors.New("entry names differ only by case")
+
+// restoredEntry is an entry of a directory together with the name under which it is restored.
+type restoredEntry struct {
+	fs.Entry
+
+	name string
+}
+
+// resolveCaseCollisions applies the policy to the entries of the directory at the provided path
+// and returns the entries to restore.
+func resolveCaseCollisions(policy CaseCollisionPolicy, dirPath string, entries []fs.Entry) ([]restoredEntry, []fs.Entry, error) {
+	var (
+		result  []restoredEntry
+		skipped []fs.Entry
+	)
+
+	if policy == "" || policy == CaseCollisionKeep {
+		for _, e := range entries {
+			result = append(result, restoredEntry{e, e.Name()})
+		}
+
+		return result, nil, nil
+	}
+
+	// colliding names are ranked in sort order, so that the outcome does not depend on the order of entries.
+	colliding := map[string][]string{}
+
+	for _, e := range entries {
+		if isCaseCollision(e) {
+			key := strings.ToLower(e.Name())
+			colliding[key] = append(colliding[key], e.Name())
+		}
+	}
+
+	for _, names := range colliding {
+		sort.Strings(names)
+	}
+
+	for _, e := range entries {
+		re := restoredEntry{e, e.Name()}
+
+		rank := 0
+		if isCaseCollision(e) {
+			rank = slices.Index(colliding[strings.ToLower(e.Name())], e.Name())
+		}
+
+		if rank == 0 {
+			result = append(result, re)
+			continue
+		}
+
+		switch policy {
+		case CaseCollisionRename:
+			re.name = fmt.Sprintf("%v.case-collision-%v", e.Name(), rank)
+			result = append(result, re)
+
+		case CaseCollisionSkip:
+			skipped = append(skipped, e)
+
+		case CaseCollisionFail:
+			return nil, nil, errors.Wrapf(ErrCaseCollision, "%q", path.Join(dirPath, e.Name()))
+
+		default:
+			return nil, nil, errors.Errorf("unsupported case collision policy: %q", policy)
+		}
+	}
+
+	return result, skipped, nil
+}
+
+func isCaseCollision(e fs.Entry) bool {
+	h, ok := e.(snapshot.HasDirEntry)
+
+	return ok && h.DirEntry().CaseCollision
+}
@@ -0,0 +1,119 @@
+package restore_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRestoreCaseCollisions(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("requires case-sensitive source filesystem")
+	}
+
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "File.txt"), []byte("upper"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "file.txt"), []byte("lower"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "other.txt"), []byte("other"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "Dir"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "dir"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "dir", "nested.txt"), []byte("nested"), 0o644))
+
+	sourceRoot, err := localfs.Directory(sourceDir)
+	require.NoError(t, err)
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: sourceDir})
+	require.NoError(t, err)
+
+	manID, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	// colliding entries are marked when the snapshot is created.
+	entries, err := fs.GetAllEntries(ctx, rootEntry.(fs.Directory))
+	require.NoError(t, err)
+
+	collisions := map[string]bool{}
+	for _, e := range entries {
+		collisions[e.Name()] = e.(snapshot.HasDirEntry).DirEntry().CaseCollision
+	}
+
+	require.Equal(t, map[string]bool{"Dir": true, "dir": true, "File.txt": true, "file.txt": true, "other.txt": false}, collisions)
+
+	restoreWith := func(policy restore.CaseCollisionPolicy, plan *restore.Plan) (string, restore.Stats, error) {
+		targetDir := t.TempDir()
+
+		out := &restore.FilesystemOutput{
+			TargetPath:           targetDir,
+			OverwriteDirectories: true,
+		}
+		require.NoError(t, out.Init(ctx))
+
+		st, err := restore.Entry(ctx, env.Repository, out, rootEntry, restore.Options{
+			RestoreDirEntryAtDepth: math.MaxInt32,
+			CaseCollisions:         policy,
+			Plan:                   plan,
+		})
+
+		return targetDir, st, err
+	}
+
+	targetDir, st, err := restoreWith("", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Dir", "File.txt", "dir", "file.txt", "other.txt"}, dirNames(t, targetDir))
+	require.EqualValues(t, 4, st.RestoredFileCount)
+
+	targetDir, st, err = restoreWith(restore.CaseCollisionSkip, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Dir", "File.txt", "other.txt"}, dirNames(t, targetDir))
+	require.EqualValues(t, 2, st.SkippedCount)
+
+	plan, err := restore.BuildPlan(ctx, manID, rootEntry)
+	require.NoError(t, err)
+
+	for _, p := range []*restore.Plan{nil, plan} {
+		targetDir, _, err = restoreWith(restore.CaseCollisionRename, p)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Dir", "File.txt", "dir.case-collision-1", "file.txt.case-collision-1", "other.txt"}, dirNames(t, targetDir))
+
+		got, err := os.ReadFile(filepath.Join(targetDir, "dir.case-collision-1", "nested.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "nested", string(got))
+	}
+
+	_, _, err = restoreWith(restore.CaseCollisionFail, nil)
+	require.ErrorIs(t, err, restore.ErrCaseCollision)
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -118,6 +118,16 @@ type FilesystemOutput struct {
 	// WriteSparseFiles when set to true, write contents as sparse files, minimizing allocated disk space.
 	WriteSparseFiles bool `json:"writeSparseFiles"`
 
+	// RestoreFileFlags when set to true causes restore to reapply file flags (such as immutable or append-only)
+	// captured in the snapshot. Flags that are not supported by the target are skipped with a warning.
+	RestoreFileFlags bool `json:"restoreFileFlags"`
+
+	// MetadataOnly when set to true causes restore to create the directory tree with files of the correct size
+	// and attributes, but without reading or writing their contents. Files are created as sparse files
+	// containing only zeros, so the result is NOT a usable restore of the data. Existing files are never
+	// overwritten, even when OverwriteFiles is set, since that would replace their contents with zeros.
+	MetadataOnly bool `json:"metadataOnly"`
+
 	// copier is the StreamCopier to use for copying the actual bit stream to output.
 	// It is assigned at runtime based on the target filesystem and restore options.
 	copier streamCopier `json:"-"`
@@ -159,6 +169,10 @@ func (o *FilesystemOutput) FinishDirectory(ctx context.Context, relativePath str
 		return errors.Wrap(err, "error setting attributes")
 	}
 
+	if err := o.maybeSetFileFlags(ctx, path, e); err != nil {
+		return err
+	}
+
 	return SafeRemoveAll(path)
 }
 
@@ -179,6 +193,16 @@ func (o *FilesystemOutput) WriteFile(ctx context.Context, relativePath string, f
 	log(ctx).Debugf("WriteFile %v (%v bytes) %v, %v", filepath.Join(o.TargetPath, relativePath), f.Size(), f.Mode(), f.ModTime())
 	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
 
+	if st, err := os.Stat(path); err == nil && localfs.IsBlockDevice(st.Mode()) {
+		if o.MetadataOnly {
+			log(ctx).Debugf("not writing block device %v when restoring metadata only", path)
+			return nil
+		}
+
+		// restoring onto an existing block device, device attributes are left unchanged.
+		return o.copyBlockDeviceContent(ctx, path, f, progressCb)
+	}
+
 	if err := o.copyFileContent(ctx, path, f, progressCb); err != nil {
 		return errors.Wrap(err, "error creating file")
 	}
@@ -187,6 +211,10 @@ func (o *FilesystemOutput) WriteFile(ctx context.Context, relativePath string, f
 		return errors.Wrap(err, "error setting attributes")
 	}
 
+	if err := o.maybeSetFileFlags(ctx, path, f); err != nil {
+		return err
+	}
+
 	return SafeRemoveAll(path)
 }
 
@@ -318,6 +346,37 @@ func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear
 	return nil
 }
 
+// maybeSetFileFlags applies file flags stored in the snapshot to targetPath. This must be done after
+// the contents and all other attributes have been written, since flags such as immutable prevent
+// further modifications.
+func (o *FilesystemOutput) maybeSetFileFlags(ctx context.Context, targetPath string, e fs.Entry) error {
+	if !o.RestoreFileFlags {
+		return nil
+	}
+
+	hf, ok := e.(fs.HasFileFlags)
+	if !ok {
+		return nil
+	}
+
+	flags, err := hf.FileFlags()
+	if err != nil || flags == 0 {
+		return nil //nolint:nilerr
+	}
+
+	switch err := localfs.SetFileFlags(targetPath, flags); {
+	case err == nil:
+		return nil
+	case errors.Is(err, localfs.ErrFileFlagsNotSupported):
+		log(ctx).Warnf("unable to restore file flags on %v: %v", targetPath, err)
+		return nil
+	case o.IgnorePermissionErrors && errors.Is(err, os.ErrPermission):
+		return nil
+	default:
+		return errors.Wrap(err, "could not set file flags on "+targetPath)
+	}
+}
+
 func isSymlink(e fs.Entry) bool {
 	_, ok := e.(fs.Symlink)
 	return ok
@@ -412,10 +471,31 @@ func write(targetPath string, r fs.Reader, size int64, c streamCopier) error {
 	return nil
 }
 
+// writeSkeletonFile creates a new sparse file of the provided size without writing any data to it,
+// it fails if the file already exists.
+func writeSkeletonFile(targetPath string, size int64) error {
+	f, err := os.OpenFile(targetPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec,mnd
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	if err := f.Truncate(size); err != nil {
+		return errors.Wrapf(err, "unable to set size of %q", targetPath)
+	}
+
+	return f.Close() //nolint:wrapcheck
+}
+
 func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath string, f fs.File, progressCb FileWriteProgress) error {
 	switch _, err := os.Stat(targetPath); {
 	case os.IsNotExist(err): // copy file below
 	case err == nil:
+		if o.MetadataOnly {
+			return errors.Errorf("unable to create %q, it already exists and metadata-only restore never overwrites files", targetPath)
+		}
+
 		if !o.OverwriteFiles {
 			return errors.Errorf("unable to create %q, it already exists", targetPath)
 		}
@@ -425,6 +505,10 @@ func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath strin
 		return errors.Wrap(err, "failed to stat "+targetPath)
 	}
 
+	if o.MetadataOnly {
+		return writeSkeletonFile(atomicfile.MaybePrefixLongFilenameOnWindows(targetPath), f.Size())
+	}
+
 	r, err := f.Open(ctx)
 	if err != nil {
 		return errors.Wrap(err, "unable to open snapshot file for "+targetPath)
@@ -447,6 +531,48 @@ func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath strin
 	return write(targetPath, wr, f.Size(), o.copier)
 }
 
+// copyBlockDeviceContent writes the contents of the provided file to an existing block device, which must be
+// at least as large as the file.
+func (o *FilesystemOutput) copyBlockDeviceContent(ctx context.Context, targetPath string, f fs.File, progressCb FileWriteProgress) error {
+	if !o.OverwriteFiles {
+		return errors.Errorf("unable to restore to block device %q, overwriting files is disabled", targetPath)
+	}
+
+	dev, err := os.OpenFile(targetPath, os.O_WRONLY, 0) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to open block device")
+	}
+
+	defer dev.Close() //nolint:errcheck
+
+	devSize, err := dev.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.Wrap(err, "unable to determine block device size")
+	}
+
+	if devSize < f.Size() {
+		return errors.Errorf("block device %q is too small (%v bytes), need %v bytes", targetPath, devSize, f.Size())
+	}
+
+	if _, err = dev.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "unable to seek block device")
+	}
+
+	r, err := f.Open(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to open snapshot file for "+targetPath)
+	}
+	defer r.Close() //nolint:errcheck
+
+	log(ctx).Debugf("copying contents to block device: %v", targetPath)
+
+	if _, err := iocopy.Copy(dev, &progressReportingReader{r: r, cb: progressCb}); err != nil {
+		return errors.Wrapf(err, "cannot write data to block device %q", targetPath)
+	}
+
+	return errors.Wrap(dev.Sync(), "error syncing block device")
+}
+
 func isEmptyDirectory(name string) (bool, error) {
 	f, err := os.Open(name) //nolint:gosec
 	if err != nil {
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"os"
 	"path"
 	"runtime"
 	"sync/atomic"
@@ -12,6 +13,7 @@
 	"github.com/kopia/kopia/internal/parallelwork"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 )
 
@@ -94,6 +96,24 @@ type Options struct {
 	RestoreDirEntryAtDepth int32 `json:"restoreDirEntryAtDepth"`
 	MinSizeForPlaceholder  int32 `json:"minSizeForPlaceholder"`
 
+	// CheckpointFile, when set, records files that have been completely restored, so that an interrupted
+	// restore can be resumed by running it again with the same checkpoint file. The file must be stored
+	// outside of the restore target and is removed after the restore completes successfully.
+	CheckpointFile string `json:"checkpointFile"`
+
+	// VerifyCheckpointedFiles causes files recorded in the checkpoint to be compared with the snapshot
+	// before being skipped, in addition to comparing their size and modification time. Outputs unable
+	// to read back restored files restore them again instead.
+	VerifyCheckpointedFiles bool `json:"verifyCheckpointedFiles"`
+
+	// Plan, when set, provides the entries to restore instead of reading directories from the repository.
+	// It must have been built for the root entry being restored.
+	Plan *Plan `json:"-"`
+
+	// CaseCollisions determines how entries whose names differ only by case from a sibling are restored,
+	// by default they are restored with their original names.
+	CaseCollisions CaseCollisionPolicy `json:"caseCollisions,omitempty"`
+
 	ProgressCallback ProgressCallback `json:"-"`
 	Cancel           chan struct{}    `json:"-"` // channel that can be externally closed to signal cancellation
 }
@@ -108,8 +128,35 @@ func Entry(ctx context.Context, rep repo.Repository, output Output, rootEntry fs
 		q:                parallelwork.NewQueue(),
 		incremental:      options.Incremental,
 		ignoreErrors:     options.IgnoreErrors,
+		verifyCheckpoint: options.VerifyCheckpointedFiles,
 		cancel:           options.Cancel,
 		progressCallback: options.ProgressCallback,
+		caseCollisions:   options.CaseCollisions,
+	}
+
+	if options.CheckpointFile != "" {
+		if fo, ok := output.(*FilesystemOutput); ok {
+			if err := verifyCheckpointOutsideTarget(options.CheckpointFile, fo.TargetPath); err != nil {
+				return Stats{}, err
+			}
+		}
+
+		cp, err := openRestoreCheckpoint(ctx, options.CheckpointFile)
+		if err != nil {
+			return Stats{}, err
+		}
+
+		defer cp.close() //nolint:errcheck
+
+		c.checkpoint = cp
+	}
+
+	if options.Plan != nil {
+		if err := options.Plan.validateRoot(rootEntry); err != nil {
+			return Stats{}, err
+		}
+
+		c.planChildren = options.Plan.children(rep)
 	}
 
 	c.q.ProgressCallback = func(ctx context.Context, enqueued, active, completed int64) {
@@ -140,6 +187,16 @@ func Entry(ctx context.Context, rep repo.Repository, output Output, rootEntry fs
 		return Stats{}, errors.Wrap(err, "error closing output")
 	}
 
+	if c.checkpoint != nil {
+		if err := c.checkpoint.close(); err != nil {
+			return Stats{}, err
+		}
+
+		if err := os.Remove(options.CheckpointFile); err != nil {
+			return Stats{}, errors.Wrap(err, "unable to remove restore checkpoint")
+		}
+	}
+
 	return c.stats.clone(), nil
 }
 
@@ -152,6 +209,14 @@ type copier struct {
 	ignoreErrors  bool
 	cancel        chan struct{}
 
+	checkpoint       *restoreCheckpoint
+	verifyCheckpoint bool
+
+	// planChildren holds entries of directories keyed by their object IDs when restoring from a plan.
+	planChildren map[object.ID][]fs.Entry
+
+	caseCollisions CaseCollisionPolicy
+
 	progressCallback ProgressCallback
 }
 
@@ -171,6 +236,14 @@ func (c *copier) copyEntry(ctx context.Context, e fs.Entry, targetPath string, c
 		}
 	}
 
+	if f, ok := e.(fs.File); ok && c.isCheckpointed(ctx, targetPath, f) {
+		log(ctx).Debugf("skipping file %v because it has already been restored", targetPath)
+		c.stats.SkippedCount.Add(1)
+		c.stats.SkippedTotalFileSize.Add(f.Size())
+
+		return onCompletion()
+	}
+
 	if c.incremental {
 		// in incremental mode, do not copy if the output already exists
 		switch e := e.(type) {
@@ -208,6 +281,32 @@ func (c *copier) copyEntry(ctx context.Context, e fs.Entry, targetPath string, c
 	return err
 }
 
+// isCheckpointed returns true if the file has been completely restored by a previous (interrupted) restore
+// and it has not changed since.
+func (c *copier) isCheckpointed(ctx context.Context, targetPath string, f fs.File) bool {
+	if c.checkpoint == nil || !c.checkpoint.isCompleted(targetPath, f) || !c.output.FileExists(ctx, targetPath, f) {
+		return false
+	}
+
+	if !c.verifyCheckpoint {
+		return true
+	}
+
+	v, ok := c.output.(restoredFileVerifier)
+	if !ok {
+		// verification was requested but the output can't compare files, restore them again.
+		return false
+	}
+
+	same, err := v.VerifyFile(ctx, targetPath, f)
+	if err != nil {
+		log(ctx).Debugf("unable to verify restored file %v: %v", targetPath, err)
+		return false
+	}
+
+	return same
+}
+
 func (c *copier) copyEntryInternal(ctx context.Context, e fs.Entry, targetPath string, currentdepth, maxdepth int32, onCompletion func() error) error {
 	switch e := e.(type) {
 	case fs.Directory:
@@ -234,6 +333,12 @@ func (c *copier) copyEntryInternal(ctx context.Context, e fs.Entry, targetPath s
 			}
 		}
 
+		if c.checkpoint != nil {
+			if err := c.checkpoint.markCompleted(targetPath, e); err != nil {
+				return err
+			}
+		}
+
 		c.stats.RestoredFileCount.Add(1)
 		c.stats.RestoredTotalFileSize.Add(bytesExpected - bytesWritten)
 
@@ -284,23 +389,37 @@ func (c *copier) copyDirectory(ctx context.Context, d fs.Directory, targetPath s
 }
 
 func (c *copier) copyDirectoryContent(ctx context.Context, d fs.Directory, targetPath string, currentdepth, maxdepth int32, onCompletion parallelwork.CallbackFunc) error {
-	entries, err := fs.GetAllEntries(ctx, d)
+	allEntries, err := c.directoryEntries(ctx, d)
 	if err != nil {
 		return errors.Wrap(err, "error reading directory")
 	}
 
+	entries, skipped, err := resolveCaseCollisions(c.caseCollisions, targetPath, allEntries)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range skipped {
+		log(ctx).Debugf("skipping %v because its name differs only by case from a sibling", path.Join(targetPath, e.Name()))
+		c.stats.SkippedCount.Add(1)
+		c.stats.SkippedTotalFileSize.Add(e.Size())
+	}
+
 	if len(entries) == 0 {
 		return onCompletion()
 	}
 
 	onItemCompletion := parallelwork.OnNthCompletion(len(entries), onCompletion)
 
-	for _, e := range entries {
+	for _, re := range entries {
+		e := re.Entry
+		entryPath := path.Join(targetPath, re.name)
+
 		if e.IsDir() {
 			c.stats.EnqueuedDirCount.Add(1)
 			// enqueue directories first, so that we quickly determine the total number and size of items.
 			c.q.EnqueueFront(ctx, func() error {
-				return c.copyEntry(ctx, e, path.Join(targetPath, e.Name()), currentdepth, maxdepth, onItemCompletion)
+				return c.copyEntry(ctx, e, entryPath, currentdepth, maxdepth, onItemCompletion)
 			})
 		} else {
 			if isSymlink(e) {
@@ -312,10 +431,24 @@ func (c *copier) copyDirectoryContent(ctx context.Context, d fs.Directory, targe
 			c.stats.EnqueuedTotalFileSize.Add(e.Size())
 
 			c.q.EnqueueBack(ctx, func() error {
-				return c.copyEntry(ctx, e, path.Join(targetPath, e.Name()), currentdepth, maxdepth, onItemCompletion)
+				return c.copyEntry(ctx, e, entryPath, currentdepth, maxdepth, onItemCompletion)
 			})
 		}
 	}
 
 	return nil
 }
+
+func (c *copier) directoryEntries(ctx context.Context, d fs.Directory) ([]fs.Entry, error) {
+	if c.planChildren != nil {
+		h, ok := d.(object.HasObjectID)
+		if !ok {
+			return nil, errors.Errorf("directory %q has no object ID", d.Name())
+		}
+
+		return c.planChildren[h.ObjectID()], nil
+	}
+
+	//nolint:wrapcheck
+	return fs.GetAllEntries(ctx, d)
+}
@@ -0,0 +1,200 @@
+package restore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo/object"
+)
+
+// checkpointEntry is a single line of the checkpoint file, recorded after a file has been fully restored.
+type checkpointEntry struct {
+	Path     string    `json:"path"`
+	ObjectID object.ID `json:"oid"`
+}
+
+// restoreCheckpoint keeps track of files that have been completely restored, so that an interrupted restore
+// can be resumed without restoring them again. Only fully-written files are recorded, so files that were
+// being written when the restore got interrupted will be restored again from scratch.
+type restoreCheckpoint struct {
+	mu sync.Mutex
+
+	// +checklocks:mu
+	f *os.File
+
+	completed map[string]object.ID // +checklocksignore - populated before restore starts, read-only afterwards.
+}
+
+func openRestoreCheckpoint(ctx context.Context, filename string) (*restoreCheckpoint, error) {
+	completed, err := readRestoreCheckpoint(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) //nolint:gosec,mnd
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open restore checkpoint")
+	}
+
+	return &restoreCheckpoint{
+		f:         f,
+		completed: completed,
+	}, nil
+}
+
+func readRestoreCheckpoint(ctx context.Context, filename string) (map[string]object.ID, error) {
+	result := map[string]object.ID{}
+
+	f, err := os.Open(filename) //nolint:gosec
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open restore checkpoint")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20) //nolint:mnd
+
+	for s.Scan() {
+		var e checkpointEntry
+
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			// the last line may be incomplete if the restore was interrupted while writing it.
+			log(ctx).Debugf("ignoring invalid restore checkpoint entry: %v", err)
+			continue
+		}
+
+		result[e.Path] = e.ObjectID
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading restore checkpoint")
+	}
+
+	return result, nil
+}
+
+// isCompleted returns true if the file at the provided path has been restored from the provided object.
+func (c *restoreCheckpoint) isCompleted(targetPath string, e fs.File) bool {
+	h, ok := e.(object.HasObjectID)
+	if !ok {
+		return false
+	}
+
+	oid, ok := c.completed[targetPath]
+
+	return ok && oid == h.ObjectID()
+}
+
+// markCompleted records the file at the provided path as completely restored.
+func (c *restoreCheckpoint) markCompleted(targetPath string, e fs.File) error {
+	h, ok := e.(object.HasObjectID)
+	if !ok {
+		return nil
+	}
+
+	line, err := json.Marshal(checkpointEntry{targetPath, h.ObjectID()})
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize checkpoint entry")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.f.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "unable to write restore checkpoint")
+	}
+
+	return nil
+}
+
+func (c *restoreCheckpoint) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return errors.Wrap(c.f.Close(), "unable to close restore checkpoint")
+}
+
+// verifyCheckpointOutsideTarget ensures the checkpoint file is not stored inside the directory being restored.
+func verifyCheckpointOutsideTarget(checkpointFile, targetPath string) error {
+	cp, err := filepath.Abs(checkpointFile)
+	if err != nil {
+		return errors.Wrap(err, "invalid checkpoint file")
+	}
+
+	tp, err := filepath.Abs(targetPath)
+	if err != nil {
+		return errors.Wrap(err, "invalid target path")
+	}
+
+	if rel, err := filepath.Rel(tp, cp); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.Errorf("restore checkpoint %q must be stored outside of the restore target %q", checkpointFile, targetPath)
+	}
+
+	return nil
+}
+
+// restoredFileVerifier is implemented by outputs that are able to compare restored files with the snapshot.
+type restoredFileVerifier interface {
+	VerifyFile(ctx context.Context, relativePath string, e fs.File) (bool, error)
+}
+
+// VerifyFile implements restoredFileVerifier by comparing the contents of the restored file with the snapshot.
+func (o *FilesystemOutput) VerifyFile(ctx context.Context, relativePath string, e fs.File) (bool, error) {
+	local, err := os.Open(filepath.Join(o.TargetPath, filepath.FromSlash(relativePath)))
+	if err != nil {
+		return false, errors.Wrap(err, "unable to open restored file")
+	}
+
+	defer local.Close() //nolint:errcheck
+
+	r, err := e.Open(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to open snapshot file")
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	return readersEqual(local, r)
+}
+
+func readersEqual(r1, r2 io.Reader) (bool, error) {
+	const bufSize = 64 << 10
+
+	b1 := make([]byte, bufSize)
+	b2 := make([]byte, bufSize)
+
+	for {
+		n1, err1 := io.ReadFull(r1, b1)
+		if err1 != nil && !errors.Is(err1, io.EOF) && !errors.Is(err1, io.ErrUnexpectedEOF) {
+			return false, errors.Wrap(err1, "read error")
+		}
+
+		n2, err2 := io.ReadFull(r2, b2)
+		if err2 != nil && !errors.Is(err2, io.EOF) && !errors.Is(err2, io.ErrUnexpectedEOF) {
+			return false, errors.Wrap(err2, "read error")
+		}
+
+		if !bytes.Equal(b1[:n1], b2[:n2]) {
+			return false, nil
+		}
+
+		if n1 < bufSize {
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package restore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+var errSimulatedKill = errors.New("simulated kill")
+
+// interruptingOutput writes the beginning of the file with the given name and then fails,
+// simulating restore process being killed in the middle of writing a large file.
+type interruptingOutput struct {
+	*restore.FilesystemOutput
+
+	interruptAt string
+
+	mu      sync.Mutex
+	written []string
+}
+
+func (o *interruptingOutput) WriteFile(ctx context.Context, relativePath string, f fs.File, progressCb restore.FileWriteProgress) error {
+	o.mu.Lock()
+	o.written = append(o.written, relativePath)
+	o.mu.Unlock()
+
+	if relativePath != o.interruptAt {
+		//nolint:wrapcheck
+		return o.FilesystemOutput.WriteFile(ctx, relativePath, f, progressCb)
+	}
+
+	r, err := f.Open(ctx)
+	if err != nil {
+		return errors.Wrap(err, "open")
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	partial := make([]byte, f.Size()/2)
+	if _, err := io.ReadFull(r, partial); err != nil {
+		return errors.Wrap(err, "read")
+	}
+
+	if err := os.WriteFile(filepath.Join(o.TargetPath, relativePath), partial, 0o600); err != nil {
+		return errors.Wrap(err, "write")
+	}
+
+	return errSimulatedKill
+}
+
+func TestRestoreResumeFromCheckpoint(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	bigContents := bytes.Repeat([]byte("0123456789abcdef"), 100000)
+
+	sourceRoot := mockfs.NewDirectory()
+	sourceRoot.AddFile("a.txt", []byte("aaa"), 0o644)
+	sourceRoot.AddFile("b.txt", []byte("bbbb"), 0o644)
+	sourceRoot.AddFile("z-big", bigContents, 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	man, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	targetDir := t.TempDir()
+	checkpointFile := filepath.Join(t.TempDir(), "restore.checkpoint")
+
+	newOutput := func(interruptAt string) *interruptingOutput {
+		fo := &restore.FilesystemOutput{
+			TargetPath:             targetDir,
+			OverwriteDirectories:   true,
+			OverwriteFiles:         true,
+			IgnorePermissionErrors: true,
+		}
+		require.NoError(t, fo.Init(ctx))
+
+		return &interruptingOutput{FilesystemOutput: fo, interruptAt: interruptAt}
+	}
+
+	opts := restore.Options{
+		Parallel:       1,
+		CheckpointFile: checkpointFile,
+	}
+
+	// first attempt is killed while writing the large file.
+	out := newOutput("z-big")
+	_, err = restore.Entry(ctx, env.Repository, out, rootEntry, opts)
+	require.ErrorIs(t, err, errSimulatedKill)
+	require.ElementsMatch(t, []string{"a.txt", "b.txt", "z-big"}, out.written)
+	require.FileExists(t, checkpointFile)
+
+	// resumed restore only writes the file that was not finished.
+	opts.VerifyCheckpointedFiles = true
+	out = newOutput("")
+	st, err := restore.Entry(ctx, env.Repository, out, rootEntry, opts)
+	require.NoError(t, err)
+	require.Equal(t, []string{"z-big"}, out.written)
+	require.EqualValues(t, 2, st.SkippedCount)
+	require.EqualValues(t, 1, st.RestoredFileCount)
+
+	got, err := os.ReadFile(filepath.Join(targetDir, "z-big"))
+	require.NoError(t, err)
+	require.Equal(t, bigContents, got)
+
+	// checkpoint is removed after successful restore.
+	require.NoFileExists(t, checkpointFile)
+}
+
+func TestRestoreCheckpointInsideTarget(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceRoot := mockfs.NewDirectory()
+	sourceRoot.AddFile("a.txt", []byte("aaa"), 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	man, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	targetDir := t.TempDir()
+
+	_, err = restore.Entry(ctx, env.Repository, &restore.FilesystemOutput{TargetPath: targetDir}, rootEntry, restore.Options{
+		CheckpointFile: filepath.Join(targetDir, "sub", "restore.checkpoint"),
+	})
+	require.ErrorContains(t, err, "must be stored outside of the restore target")
+}
+
+// nonVerifyingOutput hides the ability of the wrapped output to verify restored files.
+type nonVerifyingOutput struct {
+	restore.Output
+}
+
+func TestRestoreResumeFromCheckpoint_OutputWithoutVerifier(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceRoot := mockfs.NewDirectory()
+	sourceRoot.AddFile("a.txt", []byte("aaa"), 0o644)
+	sourceRoot.AddFile("b.txt", []byte("bbbb"), 0o644)
+	sourceRoot.AddFile("z-big", bytes.Repeat([]byte("0123456789abcdef"), 100000), 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	man, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	targetDir := t.TempDir()
+	checkpointFile := filepath.Join(t.TempDir(), "restore.checkpoint")
+
+	newOutput := func(interruptAt string) *interruptingOutput {
+		fo := &restore.FilesystemOutput{
+			TargetPath:             targetDir,
+			OverwriteDirectories:   true,
+			OverwriteFiles:         true,
+			IgnorePermissionErrors: true,
+		}
+		require.NoError(t, fo.Init(ctx))
+
+		return &interruptingOutput{FilesystemOutput: fo, interruptAt: interruptAt}
+	}
+
+	opts := restore.Options{
+		Parallel:       1,
+		CheckpointFile: checkpointFile,
+	}
+
+	out := newOutput("z-big")
+	_, err = restore.Entry(ctx, env.Repository, out, rootEntry, opts)
+	require.ErrorIs(t, err, errSimulatedKill)
+	require.FileExists(t, checkpointFile)
+
+	// checkpointed files can't be verified by the output, so they are restored again.
+	opts.VerifyCheckpointedFiles = true
+	out = newOutput("")
+	st, err := restore.Entry(ctx, env.Repository, nonVerifyingOutput{out}, rootEntry, opts)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a.txt", "b.txt", "z-big"}, out.written)
+	require.EqualValues(t, 0, st.SkippedCount)
+	require.EqualValues(t, 3, st.RestoredFileCount)
+}
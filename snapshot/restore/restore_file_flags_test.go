@@ -0,0 +1,87 @@
+package restore_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRestoreImmutableFileFlag(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceDir := t.TempDir()
+	immutableFile := filepath.Join(sourceDir, "immutable.txt")
+
+	require.NoError(t, os.WriteFile(immutableFile, []byte("can't touch this"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "regular.txt"), []byte("regular"), 0o644))
+
+	if err := localfs.SetFileFlags(immutableFile, fs.FileFlagImmutable); err != nil {
+		t.Skipf("immutable flag not supported: %v", err)
+	}
+
+	// immutable files can't be removed, so flags must be cleared before the directory is cleaned up.
+	t.Cleanup(func() { localfs.SetFileFlags(immutableFile, 0) }) //nolint:errcheck
+
+	sourceRoot, err := localfs.Directory(sourceDir)
+	require.NoError(t, err)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	u.CaptureFileFlags = true
+
+	man, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	targetDir := t.TempDir()
+	restoredFile := filepath.Join(targetDir, "immutable.txt")
+
+	t.Cleanup(func() { localfs.SetFileFlags(restoredFile, 0) }) //nolint:errcheck
+
+	out := &restore.FilesystemOutput{
+		TargetPath:       targetDir,
+		RestoreFileFlags: true,
+	}
+	require.NoError(t, out.Init(ctx))
+
+	_, err = restore.Entry(ctx, env.Repository, out, rootEntry, restore.Options{
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(restoredFile)
+	require.NoError(t, err)
+	require.Equal(t, "can't touch this", string(got))
+
+	requireFileFlags(t, restoredFile, fs.FileFlagImmutable)
+	requireFileFlags(t, filepath.Join(targetDir, "regular.txt"), 0)
+
+	// contents can't be modified after the flag has been restored.
+	require.Error(t, os.WriteFile(restoredFile, []byte("modified"), 0o644))
+}
+
+func requireFileFlags(t *testing.T, path string, want fs.FileFlags) {
+	t.Helper()
+
+	e, err := localfs.NewEntry(path)
+	require.NoError(t, err)
+
+	hf, ok := e.(fs.HasFileFlags)
+	require.True(t, ok)
+
+	flags, err := hf.FileFlags()
+	require.NoError(t, err)
+	require.Equal(t, want, flags)
+}
@@ -0,0 +1,80 @@
+package restore_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRestoreInlineFiles(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceDir := t.TempDir()
+
+	files := map[string]struct {
+		data string
+		perm os.FileMode
+	}{
+		"empty":      {"", 0o640},
+		"tiny.txt":   {"tiny", 0o600},
+		"larger.txt": {"larger than the inline limit", 0o644},
+	}
+
+	for name, f := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, name), []byte(f.data), f.perm))
+		require.NoError(t, os.Chmod(filepath.Join(sourceDir, name), f.perm))
+	}
+
+	sourceRoot, err := localfs.Directory(sourceDir)
+	require.NoError(t, err)
+
+	pol := *policy.DefaultPolicy
+	inlineUpTo := policy.OptionalInt64(10)
+	pol.UploadPolicy.InlineFilesUpToSize = &inlineUpTo
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, sourceRoot, policy.BuildTree(nil, &pol), snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	targetDir := t.TempDir()
+
+	out := &restore.FilesystemOutput{
+		TargetPath:           targetDir,
+		OverwriteDirectories: true,
+	}
+	require.NoError(t, out.Init(ctx))
+
+	st, err := restore.Entry(ctx, env.Repository, out, rootEntry, restore.Options{
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, len(files), st.RestoredFileCount)
+
+	for name, f := range files {
+		p := filepath.Join(targetDir, name)
+
+		got, err := os.ReadFile(p)
+		require.NoError(t, err)
+		require.Equal(t, f.data, string(got), name)
+
+		if runtime.GOOS != "windows" {
+			fi, err := os.Stat(p)
+			require.NoError(t, err)
+			require.Equal(t, f.perm, fi.Mode().Perm(), name)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package restore_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRestoreMetadataOnly(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceDir := t.TempDir()
+	mtime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	files := map[string]struct {
+		data []byte
+		mode os.FileMode
+	}{
+		"small.txt":      {[]byte("hello"), 0o640},
+		"sub/large.bin":  {bytes.Repeat([]byte("kopia"), 300000), 0o600},
+		"sub/empty.txt":  {nil, 0o444},
+		"sub/script.sh":  {[]byte("#!/bin/sh\necho hi\n"), 0o750},
+		"other/data.bin": {bytes.Repeat([]byte{1, 2, 3}, 10000), 0o604},
+	}
+
+	for name, f := range files {
+		p := filepath.Join(sourceDir, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, f.data, 0o600))
+		require.NoError(t, os.Chmod(p, f.mode))
+		require.NoError(t, os.Chtimes(p, mtime, mtime))
+	}
+
+	require.NoError(t, os.Chmod(filepath.Join(sourceDir, "sub"), 0o710))
+	require.NoError(t, os.Chtimes(filepath.Join(sourceDir, "sub"), mtime, mtime))
+
+	sourceRoot, err := localfs.Directory(sourceDir)
+	require.NoError(t, err)
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	// file contents are not needed for metadata-only restore, make sure they're not available.
+	deleteDataPacks(ctx, t, env.RepositoryWriter.BlobStorage())
+	env.MustReopen(t)
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	_, err = restoreToDir(ctx, t, env, rootEntry, t.TempDir(), false)
+	require.Error(t, err, "regular restore unexpectedly succeeded without file contents")
+
+	targetDir := t.TempDir()
+
+	st, err := restoreToDir(ctx, t, env, rootEntry, targetDir, true)
+	require.NoError(t, err)
+	require.EqualValues(t, len(files), st.RestoredFileCount)
+
+	for name, f := range files {
+		p := filepath.Join(targetDir, filepath.FromSlash(name))
+
+		fi, err := os.Stat(p)
+		require.NoError(t, err)
+		require.Equal(t, f.mode, fi.Mode(), name)
+		require.Equal(t, int64(len(f.data)), fi.Size(), name)
+		require.True(t, mtime.Equal(fi.ModTime()), name)
+
+		require.NoError(t, os.Chmod(p, 0o600))
+
+		got, err := os.ReadFile(p)
+		require.NoError(t, err)
+		require.Equal(t, make([]byte, len(f.data)), got, name)
+	}
+
+	fi, err := os.Stat(filepath.Join(targetDir, "sub"))
+	require.NoError(t, err)
+	require.Equal(t, os.ModeDir|0o710, fi.Mode())
+	require.True(t, mtime.Equal(fi.ModTime()))
+
+	// existing files are never replaced with skeletons, even when overwriting files.
+	existingDir := t.TempDir()
+	existingFile := filepath.Join(existingDir, "small.txt")
+	require.NoError(t, os.WriteFile(existingFile, []byte("precious"), 0o600))
+
+	out := &restore.FilesystemOutput{
+		TargetPath:           existingDir,
+		SkipOwners:           true,
+		OverwriteDirectories: true,
+		OverwriteFiles:       true,
+		MetadataOnly:         true,
+	}
+	require.NoError(t, out.Init(ctx))
+
+	_, err = restore.Entry(ctx, env.Repository, out, rootEntry, restore.Options{
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+	require.ErrorContains(t, err, "metadata-only restore never overwrites files")
+
+	got, err := os.ReadFile(existingFile)
+	require.NoError(t, err)
+	require.Equal(t, []byte("precious"), got)
+}
+
+func deleteDataPacks(ctx context.Context, t *testing.T, st blob.Storage) {
+	t.Helper()
+
+	var deleted int
+
+	require.NoError(t, st.ListBlobs(ctx, content.PackBlobIDPrefixRegular, func(bm blob.Metadata) error {
+		deleted++
+		return st.DeleteBlob(ctx, bm.BlobID)
+	}))
+
+	require.NotZero(t, deleted)
+}
+
+func restoreToDir(ctx context.Context, t *testing.T, env *repotesting.Environment, rootEntry fs.Entry, targetDir string, metadataOnly bool) (restore.Stats, error) {
+	t.Helper()
+
+	out := &restore.FilesystemOutput{
+		TargetPath:   targetDir,
+		SkipOwners:   true,
+		MetadataOnly: metadataOnly,
+	}
+	require.NoError(t, out.Init(ctx))
+
+	return restore.Entry(ctx, env.Repository, out, rootEntry, restore.Options{
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+}
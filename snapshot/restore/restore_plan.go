@@ -0,0 +1,188 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+const planFormatVersion = 1
+
+// ErrPlanInvalidated is returned when a restore plan was built for a different snapshot or root entry.
+var ErrPlanInvalidated = errors.New("restore plan does not match the entry being restored")
+
+// Plan is a resolved list of all entries under a restore root, which can be saved and reused to drive
+// subsequent restores of the same snapshot without reading its directories from the repository again.
+type Plan struct {
+	Version      int         `json:"version"`
+	SnapshotID   manifest.ID `json:"snapshotID,omitempty"`
+	RootObjectID object.ID   `json:"rootObjectID"`
+
+	// Entries are listed in restore order, each directory is listed before the entries it contains.
+	Entries []PlanEntry `json:"entries"`
+}
+
+// PlanEntry is a single entry of a restore plan.
+type PlanEntry struct {
+	// Path is the slash-separated path of the entry relative to the restore root.
+	Path  string             `json:"path"`
+	Entry *snapshot.DirEntry `json:"entry"`
+}
+
+// TotalFileSize returns the total size of files in the plan.
+func (p *Plan) TotalFileSize() int64 {
+	var total int64
+
+	for _, e := range p.Entries {
+		if e.Entry.Type == snapshot.EntryTypeFile {
+			total += e.Entry.FileSize
+		}
+	}
+
+	return total
+}
+
+// BuildPlan resolves all entries under the provided root entry of the snapshot with the provided ID.
+func BuildPlan(ctx context.Context, snapshotID manifest.ID, rootEntry fs.Entry) (*Plan, error) {
+	h, ok := rootEntry.(object.HasObjectID)
+	if !ok {
+		return nil, errors.Errorf("root entry %q has no object ID", rootEntry.Name())
+	}
+
+	p := &Plan{
+		Version:      planFormatVersion,
+		SnapshotID:   snapshotID,
+		RootObjectID: h.ObjectID(),
+	}
+
+	if d, ok := rootEntry.(fs.Directory); ok {
+		if err := p.addDirectoryContents(ctx, d, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Plan) addDirectoryContents(ctx context.Context, d fs.Directory, dirPath string) error {
+	entries, err := fs.GetAllEntries(ctx, d)
+	if err != nil {
+		return errors.Wrapf(err, "error reading directory %q", dirPath)
+	}
+
+	for _, e := range entries {
+		entryPath := path.Join(dirPath, e.Name())
+
+		h, ok := e.(snapshot.HasDirEntry)
+		if !ok {
+			return errors.Errorf("entry %q is not a snapshot entry", entryPath)
+		}
+
+		p.Entries = append(p.Entries, PlanEntry{entryPath, h.DirEntry()})
+
+		if sd, ok := e.(fs.Directory); ok {
+			if err := p.addDirectoryContents(ctx, sd, entryPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate returns ErrPlanInvalidated if the plan was not built for the provided root entry of the snapshot
+// with the provided ID.
+func (p *Plan) Validate(snapshotID manifest.ID, rootEntry fs.Entry) error {
+	if p.SnapshotID != snapshotID {
+		return errors.Wrapf(ErrPlanInvalidated, "plan was built for snapshot %q, not %q", p.SnapshotID, snapshotID)
+	}
+
+	return p.validateRoot(rootEntry)
+}
+
+func (p *Plan) validateRoot(rootEntry fs.Entry) error {
+	if p.Version != planFormatVersion {
+		return errors.Wrapf(ErrPlanInvalidated, "unsupported plan version %v", p.Version)
+	}
+
+	h, ok := rootEntry.(object.HasObjectID)
+	if !ok || h.ObjectID() != p.RootObjectID {
+		return errors.Wrapf(ErrPlanInvalidated, "plan was built for root object %v", p.RootObjectID)
+	}
+
+	return nil
+}
+
+// children returns the entries of each directory in the plan, keyed by the object ID of the directory.
+func (p *Plan) children(rep repo.Repository) map[object.ID][]fs.Entry {
+	result := map[object.ID][]fs.Entry{}
+
+	// directories with identical contents share the object ID, so entries are only collected
+	// from the first directory with each object ID.
+	dirOIDs := map[string]object.ID{"": p.RootObjectID}
+	firstPath := map[object.ID]string{p.RootObjectID: ""}
+
+	for _, e := range p.Entries {
+		dir := path.Dir(e.Path)
+		if dir == "." {
+			dir = ""
+		}
+
+		if e.Entry.Type == snapshot.EntryTypeDirectory {
+			dirOIDs[e.Path] = e.Entry.ObjectID
+
+			if _, ok := firstPath[e.Entry.ObjectID]; !ok {
+				firstPath[e.Entry.ObjectID] = e.Path
+			}
+		}
+
+		parentOID := dirOIDs[dir]
+		if firstPath[parentOID] != dir {
+			continue
+		}
+
+		result[parentOID] = append(result[parentOID], snapshotfs.EntryFromDirEntry(rep, e.Entry))
+	}
+
+	return result
+}
+
+// SavePlan atomically writes the plan to the provided file.
+func SavePlan(filename string, p *Plan) error {
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(p); err != nil {
+		return errors.Wrap(err, "unable to serialize restore plan")
+	}
+
+	return errors.Wrap(atomicfile.Write(filename, &buf), "unable to write restore plan")
+}
+
+// LoadPlan reads a plan written by SavePlan.
+func LoadPlan(filename string) (*Plan, error) {
+	b, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		//nolint:wrapcheck
+		return nil, err
+	}
+
+	p := &Plan{}
+
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, errors.Wrap(err, "invalid restore plan")
+	}
+
+	return p, nil
+}
@@ -0,0 +1,116 @@
+package restore_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRestoreFromPlan(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceRoot := mockfs.NewDirectory()
+	sourceRoot.AddFile("a.txt", []byte("aaa"), 0o644)
+	sub := sourceRoot.AddDir("sub", 0o755)
+	sub.AddFile("b.txt", []byte("bbbb"), 0o644)
+	sub.AddDir("empty", 0o755)
+	sub.AddSymlink("link", "b.txt", 0o777)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	man, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"})
+	require.NoError(t, err)
+
+	manID, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	rootEntry, err := snapshotfs.SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	plan, err := restore.BuildPlan(ctx, manID, rootEntry)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, e := range plan.Entries {
+		paths = append(paths, e.Path)
+	}
+
+	require.ElementsMatch(t, []string{"a.txt", "sub", "sub/b.txt", "sub/empty", "sub/link"}, paths)
+	require.EqualValues(t, 7, plan.TotalFileSize())
+
+	// the plan survives a roundtrip through a file.
+	planFile := filepath.Join(t.TempDir(), "restore.plan")
+	require.NoError(t, restore.SavePlan(planFile, plan))
+
+	loaded, err := restore.LoadPlan(planFile)
+	require.NoError(t, err)
+	require.NoError(t, loaded.Validate(manID, rootEntry))
+
+	restoreWithPlan := func(p *restore.Plan) (string, restore.Stats, error) {
+		targetDir := t.TempDir()
+
+		out := &restore.FilesystemOutput{
+			TargetPath:           targetDir,
+			OverwriteDirectories: true,
+		}
+		require.NoError(t, out.Init(ctx))
+
+		st, err := restore.Entry(ctx, env.Repository, out, rootEntry, restore.Options{
+			RestoreDirEntryAtDepth: math.MaxInt32,
+			Plan:                   p,
+		})
+
+		return targetDir, st, err
+	}
+
+	targetDir, st, err := restoreWithPlan(loaded)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, st.RestoredFileCount)
+	require.EqualValues(t, 1, st.RestoredSymlinkCount)
+
+	got, err := os.ReadFile(filepath.Join(targetDir, "sub", "b.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "bbbb", string(got))
+	require.DirExists(t, filepath.Join(targetDir, "sub", "empty"))
+
+	// directories are not read again, so only entries in the plan are restored.
+	loaded.Entries = slices.DeleteFunc(loaded.Entries, func(e restore.PlanEntry) bool {
+		return e.Path != "a.txt"
+	})
+
+	targetDir, st, err = restoreWithPlan(loaded)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, st.RestoredFileCount)
+	require.FileExists(t, filepath.Join(targetDir, "a.txt"))
+	require.NoDirExists(t, filepath.Join(targetDir, "sub"))
+
+	// plans of other snapshots are rejected.
+	sub.AddFile("c.txt", []byte("c"), 0o644)
+
+	man2, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"})
+	require.NoError(t, err)
+
+	man2ID, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man2)
+	require.NoError(t, err)
+
+	rootEntry2, err := snapshotfs.SnapshotRoot(env.Repository, man2)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, plan.Validate(man2ID, rootEntry2), restore.ErrPlanInvalidated)
+	require.ErrorIs(t, plan.Validate(manID, rootEntry2), restore.ErrPlanInvalidated)
+
+	_, err = restore.Entry(ctx, env.Repository, &restore.FilesystemOutput{TargetPath: t.TempDir()}, rootEntry2, restore.Options{
+		Plan: plan,
+	})
+	require.ErrorIs(t, err, restore.ErrPlanInvalidated)
+}
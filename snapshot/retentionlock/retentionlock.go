@@ -0,0 +1,261 @@
+// Package retentionlock manages retention locks, which keep contents of individual files from being
+// garbage-collected until the specified time, regardless of the retention of snapshots containing them.
+//
+// Each lock is stored in a separate manifest holding the object ID of the locked file, so that the lock
+// keeps the file (but not the snapshot or directories it was found in) after all snapshots referencing
+// it have been deleted, including when the entire source is removed. Once the lock expires, the contents
+// of the file are subject to garbage collection like any other unreferenced contents.
+package retentionlock
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// ManifestType is the value of the "type" label for retention lock manifests.
+const ManifestType = "retentionlock"
+
+// ObjectIDLabel is the manifest label holding the object ID of the locked file.
+const ObjectIDLabel = "object"
+
+// ErrLockNotExpired is returned when attempting to delete a lock which has not expired.
+var ErrLockNotExpired = errors.New("retention lock has not expired")
+
+// ErrLockNotFound is returned when a lock is not found.
+var ErrLockNotFound = errors.New("retention lock not found")
+
+// Lock prevents contents of a file from being deleted until RetainUntil.
+type Lock struct {
+	ID manifest.ID `json:"-"`
+
+	ObjectID    object.ID `json:"object"`
+	RetainUntil time.Time `json:"retainUntil"`
+	CreatedTime time.Time `json:"created"`
+	Description string    `json:"description,omitempty"`
+
+	// where the file was found when the lock was created, informational only.
+	Source     snapshot.SourceInfo `json:"source,omitempty"`
+	SnapshotID manifest.ID         `json:"snapshot,omitempty"`
+	Path       string              `json:"path,omitempty"`
+	FileSize   int64               `json:"size,omitempty"`
+}
+
+// Expired returns true if the lock no longer retains the file at the provided time.
+func (l *Lock) Expired(now time.Time) bool {
+	return !now.Before(l.RetainUntil)
+}
+
+// Create saves the provided lock after verifying that the locked object exists.
+func Create(ctx context.Context, rep repo.RepositoryWriter, l *Lock) (manifest.ID, error) {
+	if l.ObjectID == object.EmptyID {
+		return "", errors.New("missing object ID")
+	}
+
+	if l.CreatedTime.IsZero() {
+		l.CreatedTime = rep.Time()
+	}
+
+	if !l.RetainUntil.After(l.CreatedTime) {
+		return "", errors.New("retention time must be in the future")
+	}
+
+	if _, err := rep.VerifyObject(ctx, l.ObjectID); err != nil {
+		return "", errors.Wrapf(err, "unable to verify object %v", l.ObjectID)
+	}
+
+	return save(ctx, rep, l)
+}
+
+// LockFile creates a lock for the file at the provided path within the provided snapshot.
+func LockFile(ctx context.Context, rep repo.RepositoryWriter, m *snapshot.Manifest, filePath string, retainUntil time.Time, description string) (*Lock, error) {
+	root, err := snapshotfs.SnapshotRoot(rep, m)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get snapshot root")
+	}
+
+	e, err := snapshotfs.GetNestedEntry(ctx, root, strings.Split(filePath, "/"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find %q", filePath)
+	}
+
+	if _, ok := e.(fs.File); !ok {
+		return nil, errors.Errorf("%q is not a file", filePath)
+	}
+
+	if h, ok := e.(snapshot.HasDirEntry); ok && h.DirEntry().IsInline() {
+		// inline files are only retained by their directories.
+		return nil, errors.Errorf("%q is stored inline in the directory and can't be locked", filePath)
+	}
+
+	hoid, ok := e.(object.HasObjectID)
+	if !ok {
+		return nil, errors.Errorf("%q does not have ObjectID", filePath)
+	}
+
+	l := &Lock{
+		ObjectID:    hoid.ObjectID(),
+		RetainUntil: retainUntil,
+		Description: description,
+		Source:      m.Source,
+		SnapshotID:  m.ID,
+		Path:        strings.Trim(filePath, "/"),
+		FileSize:    e.Size(),
+	}
+
+	if _, err := Create(ctx, rep, l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Extend moves the expiration of the lock with the provided ID to the provided time.
+// Locks can't be shortened, since that would defeat the purpose of the lock.
+func Extend(ctx context.Context, rep repo.RepositoryWriter, id manifest.ID, retainUntil time.Time) (*Lock, error) {
+	l, err := Load(ctx, rep, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if retainUntil.Before(l.RetainUntil) {
+		return nil, errors.Errorf("lock %v retains %v until %v and can't be shortened", id, l.ObjectID, l.RetainUntil)
+	}
+
+	l.RetainUntil = retainUntil
+
+	if _, err := save(ctx, rep, l); err != nil {
+		return nil, err
+	}
+
+	if err := rep.DeleteManifest(ctx, id); err != nil {
+		return nil, errors.Wrap(err, "error deleting old manifest")
+	}
+
+	return l, nil
+}
+
+// Delete deletes the lock with the provided ID, which must have expired.
+func Delete(ctx context.Context, rep repo.RepositoryWriter, id manifest.ID) error {
+	l, err := Load(ctx, rep, id)
+	if err != nil {
+		return err
+	}
+
+	if !l.Expired(rep.Time()) {
+		return errors.Wrapf(ErrLockNotExpired, "lock %v retains %v until %v", id, l.ObjectID, l.RetainUntil)
+	}
+
+	return errors.Wrap(rep.DeleteManifest(ctx, id), "error deleting manifest")
+}
+
+// Load loads the lock with the provided ID.
+func Load(ctx context.Context, rep repo.Repository, id manifest.ID) (*Lock, error) {
+	l := &Lock{}
+
+	em, err := rep.GetManifest(ctx, id, l)
+	if err != nil {
+		if errors.Is(err, manifest.ErrNotFound) {
+			return nil, ErrLockNotFound
+		}
+
+		return nil, errors.Wrap(err, "unable to get manifest")
+	}
+
+	if em.Labels[manifest.TypeLabelKey] != ManifestType {
+		return nil, errors.Errorf("manifest is not a retention lock")
+	}
+
+	l.ID = id
+
+	return l, nil
+}
+
+// List returns all locks, including expired ones.
+func List(ctx context.Context, rep repo.Repository) ([]*Lock, error) {
+	entries, err := rep.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey: ManifestType,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find retention lock manifests")
+	}
+
+	var result []*Lock
+
+	for _, e := range entries {
+		l, err := Load(ctx, rep, e.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to load retention lock %v", e.ID)
+		}
+
+		result = append(result, l)
+	}
+
+	return result, nil
+}
+
+// ListActive returns locks which have not expired at the provided time.
+func ListActive(ctx context.Context, rep repo.Repository, now time.Time) ([]*Lock, error) {
+	locks, err := List(ctx, rep)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Lock
+
+	for _, l := range locks {
+		if !l.Expired(now) {
+			result = append(result, l)
+		}
+	}
+
+	return result, nil
+}
+
+// WalkLockedContents invokes the provided callback for each content of files locked at the provided time.
+// The callback may be invoked more than once for the same content.
+func WalkLockedContents(ctx context.Context, rep repo.Repository, now time.Time, cb func(ctx context.Context, cid content.ID) error) error {
+	locks, err := ListActive(ctx, rep, now)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range locks {
+		contentIDs, err := rep.VerifyObject(ctx, l.ObjectID)
+		if err != nil {
+			return errors.Wrapf(err, "error verifying object %v locked by %v", l.ObjectID, l.ID)
+		}
+
+		for _, cid := range contentIDs {
+			if err := cb(ctx, cid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func save(ctx context.Context, rep repo.RepositoryWriter, l *Lock) (manifest.ID, error) {
+	id, err := rep.PutManifest(ctx, map[string]string{
+		manifest.TypeLabelKey: ManifestType,
+		ObjectIDLabel:         l.ObjectID.String(),
+	}, l)
+	if err != nil {
+		return "", errors.Wrap(err, "error putting manifest")
+	}
+
+	l.ID = id
+
+	return id, nil
+}
@@ -0,0 +1,98 @@
+package retentionlock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRetentionLock(t *testing.T) {
+	ft := faketime.NewClockTimeWithOffset(0)
+
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ft.NowFunc()
+		},
+	})
+
+	source := mockfs.NewDirectory()
+	source.AddFile("a", []byte("contents of a"), 0o644)
+	source.AddDir("sub", 0o755).AddFile("b", []byte("contents of b"), 0o644)
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, source, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"})
+	require.NoError(t, err)
+
+	_, err = snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	until := ft.NowFunc()().Add(24 * time.Hour)
+
+	l, err := retentionlock.LockFile(ctx, env.RepositoryWriter, man, "sub/b", until, "legal hold")
+	require.NoError(t, err)
+	require.Equal(t, "sub/b", l.Path)
+	require.Equal(t, man.Source, l.Source)
+	require.EqualValues(t, 13, l.FileSize)
+
+	_, err = retentionlock.LockFile(ctx, env.RepositoryWriter, man, "sub", until, "")
+	require.ErrorContains(t, err, "is not a file")
+
+	_, err = retentionlock.LockFile(ctx, env.RepositoryWriter, man, "no-such-file", until, "")
+	require.Error(t, err)
+
+	// locks must expire in the future and reference existing objects.
+	_, err = retentionlock.Create(ctx, env.RepositoryWriter, &retentionlock.Lock{ObjectID: l.ObjectID, RetainUntil: ft.NowFunc()().Add(-time.Hour)})
+	require.Error(t, err)
+
+	_, err = retentionlock.Create(ctx, env.RepositoryWriter, &retentionlock.Lock{ObjectID: object.EmptyID, RetainUntil: until})
+	require.Error(t, err)
+
+	active, err := retentionlock.ListActive(ctx, env.RepositoryWriter, ft.NowFunc()())
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	require.Equal(t, l.ID, active[0].ID)
+	require.Equal(t, "legal hold", active[0].Description)
+
+	// active locks can be extended, but not shortened or deleted.
+	_, err = retentionlock.Extend(ctx, env.RepositoryWriter, l.ID, until.Add(-time.Hour))
+	require.Error(t, err)
+
+	require.ErrorIs(t, retentionlock.Delete(ctx, env.RepositoryWriter, l.ID), retentionlock.ErrLockNotExpired)
+
+	oldID := l.ID
+
+	l, err = retentionlock.Extend(ctx, env.RepositoryWriter, l.ID, until.Add(time.Hour))
+	require.NoError(t, err)
+	require.NotEqual(t, oldID, l.ID)
+
+	_, err = retentionlock.Load(ctx, env.RepositoryWriter, oldID)
+	require.ErrorIs(t, err, retentionlock.ErrLockNotFound)
+
+	ft.Advance(24 * time.Hour)
+	require.ErrorIs(t, retentionlock.Delete(ctx, env.RepositoryWriter, l.ID), retentionlock.ErrLockNotExpired)
+
+	ft.Advance(time.Hour)
+
+	active, err = retentionlock.ListActive(ctx, env.RepositoryWriter, ft.NowFunc()())
+	require.NoError(t, err)
+	require.Empty(t, active)
+
+	all, err := retentionlock.List(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	require.NoError(t, retentionlock.Delete(ctx, env.RepositoryWriter, l.ID))
+
+	all, err = retentionlock.List(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Empty(t, all)
+}
@@ -6,6 +6,7 @@
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -270,3 +271,42 @@ func TestUpdatePins(t *testing.T) {
 	require.False(t, m.UpdatePins([]string{"e", "a"}, []string{"c"}))
 	require.Equal(t, []string{"a", "b", "d", "e"}, m.Pins)
 }
+
+func TestSnapshotDescription(t *testing.T) {
+	require.NoError(t, snapshot.ValidateDescription(""))
+	require.NoError(t, snapshot.ValidateDescription("pre-upgrade, nightly-full ✓"))
+	require.NoError(t, snapshot.ValidateDescription(strings.Repeat("x", snapshot.MaxDescriptionLength)))
+	require.Error(t, snapshot.ValidateDescription(strings.Repeat("x", snapshot.MaxDescriptionLength+1)))
+	require.Error(t, snapshot.ValidateDescription("line1\nline2"))
+	require.Error(t, snapshot.ValidateDescription("bell\a"))
+	require.Error(t, snapshot.ValidateDescription("\xff"))
+
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	src := snapshot.SourceInfo{Host: "host-1", UserName: "user-1", Path: "/some/path"}
+
+	// descriptions are only validated when provided by users, existing ones can always be saved again.
+	legacy := &snapshot.Manifest{Source: src, Description: "legacy\ndescription"}
+	mustSaveSnapshot(t, env.RepositoryWriter, legacy)
+	require.NoError(t, snapshot.UpdateSnapshot(ctx, env.RepositoryWriter, legacy))
+	require.NoError(t, env.RepositoryWriter.DeleteManifest(ctx, legacy.ID))
+
+	mustSaveSnapshot(t, env.RepositoryWriter, &snapshot.Manifest{Source: src, StartTime: 1, Description: "Pre-Upgrade"})
+	mustSaveSnapshot(t, env.RepositoryWriter, &snapshot.Manifest{Source: src, StartTime: 2, Description: "nightly-full"})
+	mustSaveSnapshot(t, env.RepositoryWriter, &snapshot.Manifest{Source: src, StartTime: 3})
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+	env.MustReopen(t)
+
+	manifests, err := snapshot.ListSnapshots(ctx, env.RepositoryWriter, src)
+	require.NoError(t, err)
+	require.Len(t, manifests, 3)
+
+	upgrade := snapshot.FilterByDescription(manifests, "upgrade")
+	require.Len(t, upgrade, 1)
+	require.Equal(t, "Pre-Upgrade", upgrade[0].Description)
+
+	require.Len(t, snapshot.FilterByDescription(manifests, "-"), 2)
+	require.Len(t, snapshot.FilterByDescription(manifests, ""), 3)
+	require.Empty(t, snapshot.FilterByDescription(manifests, "weekly"))
+}
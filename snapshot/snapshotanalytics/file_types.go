@@ -0,0 +1,335 @@
+// Package snapshotanalytics implements read-only reporting over the contents of snapshots.
+package snapshotanalytics
+
+import (
+	"context"
+	"encoding/binary"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// DirectoryFileType is the file type to which storage used by directory listings is attributed.
+const DirectoryFileType = "(directory)"
+
+// FileTypeStats describes storage used by files of a single type.
+//
+// Every file object is counted once, even when it's present in multiple snapshots or under multiple names,
+// in which case it's attributed to the type of the first name encountered.
+type FileTypeStats struct {
+	// FileType is the lowercase extension of the file name including the leading dot, empty for files
+	// without an extension or DirectoryFileType.
+	FileType string `json:"fileType"`
+
+	FileCount int64 `json:"fileCount"`
+
+	// LogicalBytes is the total size of files, zero for directories.
+	LogicalBytes int64 `json:"logicalBytes"`
+
+	// DedupedBytes is the share of the uncompressed length of contents, after deduplication.
+	DedupedBytes int64 `json:"dedupedBytes"`
+
+	// StoredBytes is the share of the length of contents as stored in the repository, after deduplication
+	// and compression.
+	StoredBytes int64 `json:"storedBytes"`
+}
+
+// DedupRatio returns the ratio of logical bytes to bytes remaining after deduplication.
+func (s FileTypeStats) DedupRatio() float64 {
+	return ratio(s.LogicalBytes, s.DedupedBytes)
+}
+
+// CompressionRatio returns the ratio of deduplicated bytes to stored bytes.
+func (s FileTypeStats) CompressionRatio() float64 {
+	return ratio(s.DedupedBytes, s.StoredBytes)
+}
+
+// EffectiveRatio returns the ratio of logical bytes to stored bytes.
+func (s FileTypeStats) EffectiveRatio() float64 {
+	return ratio(s.LogicalBytes, s.StoredBytes)
+}
+
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return float64(a) / float64(b)
+}
+
+// FileTypeBreakdown is a list of per-file-type statistics.
+type FileTypeBreakdown []FileTypeStats
+
+// SortBy sorts the breakdown in descending order of the value returned by the provided function,
+// ties are broken by file type.
+func (b FileTypeBreakdown) SortBy(value func(s FileTypeStats) float64) {
+	sort.SliceStable(b, func(i, j int) bool {
+		if vi, vj := value(b[i]), value(b[j]); vi != vj {
+			return vi > vj
+		}
+
+		return b[i].FileType < b[j].FileType
+	})
+}
+
+// Total returns the statistics of all file types combined.
+func (b FileTypeBreakdown) Total() FileTypeStats {
+	var t FileTypeStats
+
+	for _, s := range b {
+		t.FileCount += s.FileCount
+		t.LogicalBytes += s.LogicalBytes
+		t.DedupedBytes += s.DedupedBytes
+		t.StoredBytes += s.StoredBytes
+	}
+
+	return t
+}
+
+// FileTypeOptions provides options for FileTypeUsage.
+type FileTypeOptions struct {
+	// Manifests are the snapshots to analyze, all snapshots in the repository when empty.
+	Manifests []*snapshot.Manifest
+
+	// Parallelism is the number of concurrent tree walkers.
+	Parallelism int
+}
+
+type fileTypeTotals struct {
+	fileCount    int64
+	logicalBytes int64
+	dedupedBytes float64
+	storedBytes  float64
+}
+
+// FileTypeUsage walks the provided snapshots and returns storage used by each file type, sorted by stored bytes.
+//
+// Contents shared by multiple files, possibly of different types, are apportioned between them in proportion
+// to the number of references, so that stored bytes of all types add up to the size of all referenced contents.
+//
+// Snapshots are walked twice, first to count references to contents and then to attribute them, memory usage
+// is bounded by the number of file types, with reference counts kept in bigmaps.
+func FileTypeUsage(ctx context.Context, rep repo.Repository, opt FileTypeOptions) (FileTypeBreakdown, error) {
+	manifests := opt.Manifests
+
+	if len(manifests) == 0 {
+		ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list snapshot manifest IDs")
+		}
+
+		manifests, err = snapshot.LoadSnapshots(ctx, rep, ids)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load manifest IDs")
+		}
+	}
+
+	seen, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+	defer seen.Close(ctx)
+
+	refCounts, err := newRefCounter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer refCounts.close(ctx)
+
+	var (
+		mu     sync.Mutex
+		totals = map[string]*fileTypeTotals{}
+	)
+
+	if err := walkObjects(ctx, rep, manifests, opt.Parallelism, func(ctx context.Context, _ fs.Entry, contentIDs []content.ID) error {
+		var cidbuf [128]byte
+
+		for _, cid := range contentIDs {
+			if seen.Put(ctx, cid.Append(cidbuf[:0])) {
+				continue
+			}
+
+			refCounts.addDuplicate(ctx, cid)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error counting content references")
+	}
+
+	if err := walkObjects(ctx, rep, manifests, opt.Parallelism, func(ctx context.Context, e fs.Entry, contentIDs []content.ID) error {
+		var deduped, stored float64
+
+		for _, cid := range contentIDs {
+			ci, err := rep.ContentInfo(ctx, cid)
+			if err != nil {
+				return errors.Wrapf(err, "error getting content info for %v", cid)
+			}
+
+			refs := float64(max(refCounts.count(cid), 1))
+
+			deduped += float64(ci.OriginalLength) / refs
+			stored += float64(ci.PackedLength) / refs
+		}
+
+		ft := DirectoryFileType
+		if !e.IsDir() {
+			ft = strings.ToLower(path.Ext(e.Name()))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		t := totals[ft]
+		if t == nil {
+			t = &fileTypeTotals{}
+			totals[ft] = t
+		}
+
+		t.fileCount++
+		t.dedupedBytes += deduped
+		t.storedBytes += stored
+
+		if !e.IsDir() {
+			t.logicalBytes += e.Size()
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error attributing contents")
+	}
+
+	var result FileTypeBreakdown
+
+	for ft, t := range totals {
+		result = append(result, FileTypeStats{
+			FileType:     ft,
+			FileCount:    t.fileCount,
+			LogicalBytes: t.logicalBytes,
+			DedupedBytes: int64(t.dedupedBytes + 0.5), //nolint:mnd
+			StoredBytes:  int64(t.storedBytes + 0.5),  //nolint:mnd
+		})
+	}
+
+	result.SortBy(func(s FileTypeStats) float64 { return float64(s.StoredBytes) })
+
+	return result, nil
+}
+
+// refCounter counts references to contents referenced more than once. Since bigmaps can't be updated,
+// each reference is stored under a separate key made of its ordinal number and the content ID, and the
+// count is determined by searching for the highest ordinal present.
+type refCounter struct {
+	mu   sync.Mutex
+	refs *bigmap.Set
+}
+
+func newRefCounter(ctx context.Context) (*refCounter, error) {
+	refs, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+
+	return &refCounter{refs: refs}, nil
+}
+
+// addDuplicate records a reference to the content that has already been referenced before.
+func (c *refCounter) addDuplicate(ctx context.Context, cid content.ID) {
+	var buf [128]byte
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.count(cid)
+	if n == 0 {
+		// account for the first reference.
+		c.refs.Put(ctx, refCounterKey(buf[:0], cid, 1))
+		n = 1
+	}
+
+	c.refs.Put(ctx, refCounterKey(buf[:0], cid, n+1))
+}
+
+// count returns the number of references to the content or zero if it was not referenced more than once.
+func (c *refCounter) count(cid content.ID) uint32 {
+	var buf [128]byte
+
+	has := func(n uint32) bool {
+		return c.refs.Contains(refCounterKey(buf[:0], cid, n))
+	}
+
+	if !has(1) {
+		return 0
+	}
+
+	// ordinals are contiguous, so find the last one present using exponential and binary search.
+	lo, hi := uint32(1), uint32(2)
+	for has(hi) {
+		lo, hi = hi, hi*2 //nolint:mnd
+	}
+
+	for hi-lo > 1 {
+		if mid := lo + (hi-lo)/2; has(mid) { //nolint:mnd
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo
+}
+
+func (c *refCounter) close(ctx context.Context) {
+	c.refs.Close(ctx)
+}
+
+// refCounterKey returns the key of the n-th reference to the content, the ordinal goes first since
+// bigmap expects keys to start with well-distributed bytes and references to the same content must not collide.
+func refCounterKey(buf []byte, cid content.ID, n uint32) []byte {
+	return cid.Append(binary.BigEndian.AppendUint32(buf, n))
+}
+
+// walkObjects invokes the provided callback, possibly concurrently, once for each unique object referenced
+// by the provided snapshots with the list of its contents.
+func walkObjects(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest, parallelism int, cb func(ctx context.Context, e fs.Entry, contentIDs []content.ID) error) error {
+	w, twerr := snapshotfs.NewTreeWalker(ctx, snapshotfs.TreeWalkerOptions{
+		Parallelism: parallelism,
+		EntryCallback: func(ctx context.Context, e fs.Entry, oid object.ID, _ string) error {
+			contentIDs, verr := rep.VerifyObject(ctx, oid)
+			if verr != nil {
+				return errors.Wrapf(verr, "error verifying %v", oid)
+			}
+
+			return cb(ctx, e, contentIDs)
+		},
+	})
+	if twerr != nil {
+		return errors.Wrap(twerr, "unable to create tree walker")
+	}
+
+	defer w.Close(ctx)
+
+	for _, m := range manifests {
+		root, err := snapshotfs.SnapshotRoot(rep, m)
+		if err != nil {
+			return errors.Wrap(err, "unable to get snapshot root")
+		}
+
+		if err := w.Process(ctx, root, ""); err != nil {
+			return errors.Wrap(err, "error processing snapshot root")
+		}
+	}
+
+	return nil
+}
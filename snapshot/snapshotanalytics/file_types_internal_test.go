@@ -0,0 +1,50 @@
+package snapshotanalytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/hashing"
+)
+
+func TestRefCounter(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	c, err := newRefCounter(ctx)
+	require.NoError(t, err)
+
+	defer c.close(ctx)
+
+	cid1 := mustContentID(t, "a")
+	cid2 := mustContentID(t, "b")
+	cid3 := mustContentID(t, "c")
+
+	require.Zero(t, c.count(cid1))
+
+	c.addDuplicate(ctx, cid1)
+	require.EqualValues(t, 2, c.count(cid1))
+
+	for range 998 {
+		c.addDuplicate(ctx, cid2)
+	}
+
+	require.EqualValues(t, 2, c.count(cid1))
+	require.EqualValues(t, 999, c.count(cid2))
+	require.Zero(t, c.count(cid3))
+}
+
+func mustContentID(t *testing.T, s string) content.ID {
+	t.Helper()
+
+	var h [hashing.MaxHashSize]byte
+
+	copy(h[:], s)
+
+	cid, err := content.IDFromHash("", h[:16])
+	require.NoError(t, err)
+
+	return cid
+}
@@ -0,0 +1,116 @@
+package snapshotanalytics_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotanalytics"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	var result []byte
+
+	for _, p := range parts {
+		result = append(result, p...)
+	}
+
+	return result
+}
+
+func TestFileTypeUsage(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	// the test repository uses the FIXED-1M splitter, so 'shared' is stored once and referenced by
+	// files of two different types.
+	const chunk = 1 << 20
+
+	shared := randomBytes(t, chunk)
+
+	dir := mockfs.NewDirectory()
+	dir.AddFile("a.bin", concat(shared, randomBytes(t, chunk)), 0o644)
+	dir.AddFile("b.DAT", concat(shared, randomBytes(t, chunk)), 0o644)
+	dir.AddFile("c.txt", []byte("hello"), 0o644)
+	dir.AddFile("README", []byte("readme"), 0o644)
+	sub := dir.AddDir("sub", 0o755)
+	sub.AddFile("same-as-c.txt", []byte("hello"), 0o644)
+	sub.AddFile("d.txt", []byte("d"), 0o644)
+
+	src := te.LocalPathSourceInfo("/src")
+
+	man, err := snapshotfs.NewUploader(te.RepositoryWriter).Upload(ctx, dir, nil, src)
+	require.NoError(t, err)
+
+	_, err = snapshot.SaveSnapshot(ctx, te.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	require.NoError(t, te.RepositoryWriter.Flush(ctx))
+
+	breakdown, err := snapshotanalytics.FileTypeUsage(ctx, te.RepositoryWriter, snapshotanalytics.FileTypeOptions{})
+	require.NoError(t, err)
+
+	byType := map[string]snapshotanalytics.FileTypeStats{}
+	for _, s := range breakdown {
+		byType[s.FileType] = s
+	}
+
+	require.Len(t, byType, 5)
+
+	bin, dat := byType[".bin"], byType[".dat"]
+
+	require.EqualValues(t, 1, bin.FileCount)
+	require.EqualValues(t, 2*chunk, bin.LogicalBytes)
+	// the file is indirect, so there's also a small index content.
+	require.InDelta(t, 1.5*chunk, bin.DedupedBytes, 1000)
+	require.InDelta(t, float64(bin.StoredBytes), float64(dat.StoredBytes), 1000)
+	require.Greater(t, bin.StoredBytes, int64(1.5*chunk))
+	require.InDelta(t, 2/1.5, bin.DedupRatio(), 0.01)
+
+	// identical files are counted once.
+	txt := byType[".txt"]
+	require.EqualValues(t, 2, txt.FileCount)
+	require.EqualValues(t, 6, txt.LogicalBytes)
+	require.EqualValues(t, 6, txt.DedupedBytes)
+
+	require.EqualValues(t, 1, byType[""].FileCount)
+	require.EqualValues(t, 2, byType[snapshotanalytics.DirectoryFileType].FileCount)
+	require.Zero(t, byType[snapshotanalytics.DirectoryFileType].LogicalBytes)
+
+	// stored bytes of all types add up to the size of all contents except manifests.
+	var totalPacked int64
+
+	require.NoError(t, te.RepositoryWriter.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		if ci.ContentID.Prefix() != manifest.ContentPrefix {
+			totalPacked += int64(ci.PackedLength)
+		}
+
+		return nil
+	}))
+
+	require.InDelta(t, float64(totalPacked), float64(breakdown.Total().StoredBytes), 5)
+
+	// sorted by stored bytes by default.
+	require.Contains(t, []string{".bin", ".dat"}, breakdown[0].FileType)
+
+	breakdown.SortBy(func(s snapshotanalytics.FileTypeStats) float64 { return float64(s.FileCount) })
+	require.Equal(t, snapshotanalytics.DirectoryFileType, breakdown[0].FileType)
+	require.Equal(t, ".txt", breakdown[1].FileType)
+}
@@ -0,0 +1,242 @@
+package snapshotfs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// AffectedEntry describes a file or directory in a snapshot whose data is stored in a given blob.
+type AffectedEntry struct {
+	Snapshot *snapshot.Manifest `json:"-"`
+	Path     string             `json:"path"`
+	IsDir    bool               `json:"isDir,omitempty"`
+	ObjectID object.ID          `json:"objectID"`
+
+	// ContentIDs are the contents of the object which are stored in the blob.
+	ContentIDs []content.ID `json:"contentIDs,omitempty"`
+
+	// Error is set when the contents of the object could not be determined because the object
+	// index itself is stored in the blob, so the object is treated as affected.
+	Error string `json:"error,omitempty"`
+}
+
+// AffectedSnapshotsReport summarizes snapshots affected by a blob.
+type AffectedSnapshotsReport struct {
+	BlobID blob.ID `json:"blobID"`
+
+	// ContentCount is the number of contents stored in the blob.
+	ContentCount int `json:"contents"`
+
+	AffectedEntryCount int           `json:"affectedEntries"`
+	AffectedSnapshots  []manifest.ID `json:"affectedSnapshots"`
+
+	// IncompleteSnapshots are snapshots which could not be fully traversed, typically because some of their
+	// directories are stored in the blob, so entries below them could not be examined.
+	IncompleteSnapshots []manifest.ID `json:"incompleteSnapshots,omitempty"`
+}
+
+// AffectedSnapshots finds files and directories in the provided snapshots whose contents are stored in the provided
+// blob, which is useful to determine what needs to be backed up again after the blob has been found to be corrupt.
+//
+// Contents currently stored in the blob are determined from the index, without reading the blob. Each snapshot is
+// then walked and the callback is invoked as soon as an affected entry is found. Objects shared by several
+// entries are reported at each of their paths, but their contents are only determined once per snapshot.
+func AffectedSnapshots(ctx context.Context, rep repo.DirectRepository, blobID blob.ID, manifests []*snapshot.Manifest, callback func(e *AffectedEntry) error) (*AffectedSnapshotsReport, error) {
+	inBlob := map[content.ID]struct{}{}
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		if ci.PackBlobID == blobID {
+			inBlob[ci.ContentID] = struct{}{}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	report := &AffectedSnapshotsReport{
+		BlobID:       blobID,
+		ContentCount: len(inBlob),
+	}
+
+	if len(inBlob) == 0 {
+		return report, nil
+	}
+
+	for _, m := range manifests {
+		affected, complete, err := affectedEntriesInSnapshot(ctx, rep, m, inBlob, callback)
+		if err != nil {
+			return nil, err
+		}
+
+		report.AffectedEntryCount += affected
+
+		if affected > 0 {
+			report.AffectedSnapshots = append(report.AffectedSnapshots, m.ID)
+		}
+
+		if !complete {
+			report.IncompleteSnapshots = append(report.IncompleteSnapshots, m.ID)
+		}
+	}
+
+	return report, nil
+}
+
+// affectedEntriesInSnapshot walks the snapshot invoking the callback for entries referencing provided contents
+// and returns the number of such entries and whether all entries could be examined.
+func affectedEntriesInSnapshot(ctx context.Context, rep repo.Repository, m *snapshot.Manifest, inBlob map[content.ID]struct{}, callback func(e *AffectedEntry) error) (int, bool, error) {
+	var (
+		mu       sync.Mutex
+		affected int
+		cbErr    error
+
+		// objects which have been found to reference contents in the blob.
+		affectedObjects = map[object.ID]*AffectedEntry{}
+	)
+
+	// objects which have been found not to reference any contents in the blob.
+	unaffected, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "NewSet")
+	}
+	defer unaffected.Close(ctx)
+
+	report := func(e *AffectedEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cbErr != nil {
+			return cbErr
+		}
+
+		affected++
+
+		cbErr = callback(e)
+
+		return cbErr
+	}
+
+	tw, twerr := NewTreeWalker(ctx, TreeWalkerOptions{
+		// directories stored in the blob can't be read, keep going to find all other affected entries.
+		MaxErrors: -1,
+		// all paths referencing affected objects are reported.
+		VisitAllPaths: true,
+		EntryCallback: func(ctx context.Context, entry fs.Entry, oid object.ID, entryPath string) error {
+			var oidbuf [128]byte
+
+			if unaffected.Contains(oid.Append(oidbuf[:0])) {
+				return nil
+			}
+
+			mu.Lock()
+			prev := affectedObjects[oid]
+			mu.Unlock()
+
+			if prev == nil {
+				var err error
+
+				if prev, err = affectedObject(ctx, rep, oid, inBlob); err != nil {
+					return err
+				}
+
+				if prev == nil {
+					unaffected.Put(ctx, oid.Append(oidbuf[:0]))
+					return nil
+				}
+
+				mu.Lock()
+				affectedObjects[oid] = prev
+				mu.Unlock()
+			}
+
+			return report(&AffectedEntry{
+				Snapshot:   m,
+				Path:       entryPath,
+				IsDir:      entry.IsDir(),
+				ObjectID:   oid,
+				ContentIDs: prev.ContentIDs,
+				Error:      prev.Error,
+			})
+		},
+	})
+	if twerr != nil {
+		return 0, false, errors.Wrap(twerr, "unable to create tree walker")
+	}
+
+	defer tw.Close(ctx)
+
+	root, err := SnapshotRoot(rep, m)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "unable to get snapshot root")
+	}
+
+	walkErr := tw.Process(ctx, root, ".")
+	if err := ctx.Err(); err != nil {
+		return 0, false, err //nolint:wrapcheck
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cbErr != nil {
+		return 0, false, cbErr
+	}
+
+	return affected, walkErr == nil, nil
+}
+
+// affectedObject returns the contents of the object stored in the blob or nil if the object does not reference any.
+// Errors determining the contents only mark the object as affected when the object index itself is stored
+// in the blob, other errors are returned.
+func affectedObject(ctx context.Context, rep repo.Repository, oid object.ID, inBlob map[content.ID]struct{}) (*AffectedEntry, error) {
+	contentIDs, err := rep.VerifyObject(ctx, oid)
+	if err != nil {
+		if _, ok := inBlob[baseContentID(oid)]; ok {
+			return &AffectedEntry{Error: err.Error()}, nil
+		}
+
+		return nil, errors.Wrapf(err, "error verifying %v", oid)
+	}
+
+	var e AffectedEntry
+
+	for _, cid := range contentIDs {
+		if _, ok := inBlob[cid]; ok {
+			e.ContentIDs = append(e.ContentIDs, cid)
+		}
+	}
+
+	if len(e.ContentIDs) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &e, nil
+}
+
+// baseContentID returns the ID of the content holding the object data or, for indirect objects, its index.
+func baseContentID(oid object.ID) content.ID {
+	for {
+		ind, ok := oid.IndexObjectID()
+		if !ok {
+			break
+		}
+
+		oid = ind
+	}
+
+	cid, _, _ := oid.ContentID()
+
+	return cid
+}
@@ -0,0 +1,124 @@
+package snapshotfs_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestAffectedSnapshots(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	source := mockfs.NewDirectory()
+	source.AddFile("shared", bytes.Repeat([]byte{1, 2, 3}, 1000), 0o644)
+	source.AddDir("copy", 0o755).AddFile("shared", bytes.Repeat([]byte{1, 2, 3}, 1000), 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	si := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/source"}
+
+	man1, err := u.Upload(ctx, source, nil, si)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	// contents written in a separate session end up in a different pack.
+	source.AddDir("sub", 0o755).AddFile("new", bytes.Repeat([]byte{4, 5, 6}, 1000), 0o644)
+
+	man2, err := u.Upload(ctx, source, nil, si)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	for _, m := range []*snapshot.Manifest{man1, man2} {
+		_, err = snapshot.SaveSnapshot(ctx, env.RepositoryWriter, m)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	manifests := []*snapshot.Manifest{man1, man2}
+
+	packOf := func(m *snapshot.Manifest, p string) blob.ID {
+		t.Helper()
+
+		root, err := snapshotfs.SnapshotRoot(env.RepositoryWriter, m)
+		require.NoError(t, err)
+
+		e, err := snapshotfs.GetNestedEntry(ctx, root, strings.Split(p, "/"))
+		require.NoError(t, err)
+
+		h, ok := e.(object.HasObjectID)
+		require.True(t, ok)
+
+		cid, _, ok := h.ObjectID().ContentID()
+		require.True(t, ok)
+
+		ci, err := env.RepositoryWriter.ContentInfo(ctx, cid)
+		require.NoError(t, err)
+
+		return ci.PackBlobID
+	}
+
+	collect := func(blobID blob.ID) (*snapshotfs.AffectedSnapshotsReport, map[manifest.ID][]string) {
+		t.Helper()
+
+		paths := map[manifest.ID][]string{}
+
+		r, err := snapshotfs.AffectedSnapshots(ctx, env.RepositoryWriter, blobID, manifests, func(e *snapshotfs.AffectedEntry) error {
+			require.Empty(t, e.Error)
+			require.NotEmpty(t, e.ContentIDs)
+
+			paths[e.Snapshot.ID] = append(paths[e.Snapshot.ID], e.Path)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		return r, paths
+	}
+
+	// the pack with the data of the shared file affects both snapshots, identical files are reported at all paths.
+	r, paths := collect(packOf(man1, "shared"))
+	require.Positive(t, r.ContentCount)
+	require.Equal(t, []manifest.ID{man1.ID, man2.ID}, r.AffectedSnapshots)
+	require.Empty(t, r.IncompleteSnapshots)
+
+	for _, id := range []manifest.ID{man1.ID, man2.ID} {
+		require.ElementsMatch(t, []string{"shared", "copy/shared"}, paths[id])
+	}
+
+	require.Equal(t, 4, r.AffectedEntryCount)
+
+	// the new file is only in the second snapshot.
+	r, paths = collect(packOf(man2, "sub/new"))
+	require.Equal(t, []manifest.ID{man2.ID}, r.AffectedSnapshots)
+	require.Equal(t, map[manifest.ID][]string{man2.ID: {"sub/new"}}, paths)
+
+	// directories are reported as well.
+	r, paths = collect(packOf(man2, "sub"))
+	require.Contains(t, r.AffectedSnapshots, man2.ID)
+	require.Contains(t, paths[man2.ID], "sub")
+
+	// blobs without contents don't affect anything.
+	r, paths = collect("pdeadbeef")
+	require.Zero(t, r.ContentCount)
+	require.Empty(t, r.AffectedSnapshots)
+	require.Empty(t, paths)
+
+	// errors returned by the callback stop the search.
+	errStop := errors.New("stop")
+
+	_, err = snapshotfs.AffectedSnapshots(ctx, env.RepositoryWriter, packOf(man1, "shared"), manifests, func(e *snapshotfs.AffectedEntry) error {
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+}
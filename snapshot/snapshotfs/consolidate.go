@@ -0,0 +1,183 @@
+package snapshotfs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// ConsolidationStrategy determines how the trees of consolidated snapshots are combined.
+type ConsolidationStrategy string
+
+// Supported consolidation strategies.
+const (
+	// ConsolidateUnion merges the trees of all snapshots recursively, so that every path which existed in any
+	// of them is present in the result. When the same path exists in multiple snapshots, the entry from the most
+	// recent snapshot is used, except for directories which are merged.
+	ConsolidateUnion ConsolidationStrategy = "union"
+
+	// ConsolidateLatest uses the tree of the most recent snapshot.
+	ConsolidateLatest ConsolidationStrategy = "latest"
+)
+
+// Consolidate returns a new snapshot manifest combining the provided snapshots of a single source according to
+// the provided strategy. The manifest is not saved, the caller should save it using snapshot.SaveSnapshot() and
+// flush the repository, after which the original snapshots can be deleted.
+//
+// File contents are never rewritten, only directories whose contents differ between the snapshots are written
+// as new objects, so the result is independently restorable.
+//
+// The consolidated snapshot is an ordinary snapshot of the same source which inherits the start and end time
+// of the most recent snapshot, so it's subject to the retention policy of the source like the snapshot it replaces.
+// To prevent it from being expired, pins of all consolidated snapshots are carried over to it.
+func Consolidate(ctx context.Context, rep repo.RepositoryWriter, ids []manifest.ID, strategy ConsolidationStrategy) (*snapshot.Manifest, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("no snapshots to consolidate")
+	}
+
+	if strategy != ConsolidateUnion && strategy != ConsolidateLatest {
+		return nil, errors.Errorf("unsupported consolidation strategy %q", strategy)
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load snapshots")
+	}
+
+	if len(manifests) != len(ids) {
+		return nil, errors.Errorf("some snapshots could not be found")
+	}
+
+	// oldest first
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifests[i].StartTime.Before(manifests[j].StartTime)
+	})
+
+	latest := manifests[len(manifests)-1]
+
+	result := &snapshot.Manifest{
+		Source:      latest.Source,
+		Description: fmt.Sprintf("Consolidated from %v snapshots (%v)", len(manifests), strategy),
+		StartTime:   latest.StartTime,
+		EndTime:     latest.EndTime,
+		RootEntry:   latest.RootEntry,
+		Tags:        latest.Tags,
+	}
+
+	var roots []*snapshot.DirEntry
+
+	for _, m := range manifests {
+		if m.Source != latest.Source {
+			return nil, errors.Errorf("snapshots of different sources cannot be consolidated: %v and %v", m.Source, latest.Source)
+		}
+
+		if m.RootEntry == nil {
+			return nil, errors.Errorf("snapshot %v does not have a root entry", m.ID)
+		}
+
+		if m.IncompleteReason != "" {
+			return nil, errors.Errorf("snapshot %v is incomplete", m.ID)
+		}
+
+		result.UpdatePins(m.Pins, nil)
+
+		roots = append(roots, m.RootEntry)
+	}
+
+	if strategy == ConsolidateUnion {
+		result.RootEntry, err = mergeDirEntries(ctx, rep, ".", roots)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s := result.RootEntry.DirSummary; s != nil {
+		result.Stats.TotalFileCount = int32(s.TotalFileCount) //nolint:gosec
+		result.Stats.TotalFileSize = s.TotalFileSize
+		result.Stats.TotalDirectoryCount = int32(s.TotalDirCount) //nolint:gosec
+	} else {
+		result.Stats = latest.Stats
+	}
+
+	return result, nil
+}
+
+// mergeDirEntries merges the provided versions of the same entry ordered from the oldest to the newest.
+func mergeDirEntries(ctx context.Context, rep repo.RepositoryWriter, dirPath string, versions []*snapshot.DirEntry) (*snapshot.DirEntry, error) {
+	newest := versions[len(versions)-1]
+
+	if newest.Type != snapshot.EntryTypeDirectory {
+		return newest, nil
+	}
+
+	// only directories can be merged, older versions which were not directories are superseded,
+	// consecutive identical versions only need to be read once.
+	var dirs []*snapshot.DirEntry
+
+	for _, v := range versions {
+		if v.Type == snapshot.EntryTypeDirectory && (len(dirs) == 0 || dirs[len(dirs)-1].ObjectID != v.ObjectID) {
+			dirs = append(dirs, v)
+		}
+	}
+
+	if len(dirs) == 1 {
+		return newest, nil
+	}
+
+	var (
+		names      []string
+		children   = map[string][]*snapshot.DirEntry{}
+		maxEntries int
+	)
+
+	for _, d := range dirs {
+		dir, err := readDirManifest(ctx, rep, d.ObjectID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read directory %v", dirPath)
+		}
+
+		// the merged directory is sharded when any of its versions was.
+		if n := dirManifestShardSize(dir); n > 0 && (maxEntries == 0 || n < maxEntries) {
+			maxEntries = n
+		}
+
+		for _, e := range dir.Entries {
+			if _, ok := children[e.Name]; !ok {
+				names = append(names, e.Name)
+			}
+
+			children[e.Name] = append(children[e.Name], e)
+		}
+	}
+
+	var b DirManifestBuilder
+
+	for _, n := range names {
+		merged, err := mergeDirEntries(ctx, rep, path.Join(dirPath, n), children[n])
+		if err != nil {
+			return nil, err
+		}
+
+		b.AddEntry(merged)
+	}
+
+	dm := b.Build(newest.ModTime, "")
+
+	oid, err := writeDirManifest(ctx, rep, dirPath, dm, maxEntries)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to write directory %v", dirPath)
+	}
+
+	result := newest.Clone()
+	result.ObjectID = oid
+	result.DirSummary = dm.Summary
+
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package snapshotfs_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestConsolidate(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	u := snapshotfs.NewUploader(te.RepositoryWriter)
+	src := te.LocalPathSourceInfo("/src")
+
+	dir1 := mockfs.NewDirectory()
+	dir1.AddFile("a", []byte("a1"), 0o644)
+	dir1.AddFile("only1", []byte("only in 1"), 0o644)
+	dir1.AddDir("sub", 0o755).AddFile("x", []byte("x"), 0o644)
+
+	dir2 := mockfs.NewDirectory()
+	dir2.AddFile("a", []byte("a2"), 0o644)
+	dir2.AddDir("sub", 0o755).AddFile("y", []byte("y"), 0o644)
+
+	var ids []manifest.ID
+
+	for i, dir := range []*mockfs.Directory{dir1, dir2} {
+		man, err := u.Upload(ctx, dir, nil, src)
+		require.NoError(t, err)
+
+		man.Pins = []string{[]string{"pin1", "pin2"}[i]}
+
+		id, err := snapshot.SaveSnapshot(ctx, te.RepositoryWriter, man)
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	latest, err := snapshotfs.Consolidate(ctx, te.RepositoryWriter, ids, snapshotfs.ConsolidateLatest)
+	require.NoError(t, err)
+	require.Equal(t, src, latest.Source)
+	require.Equal(t, []string{"pin1", "pin2"}, latest.Pins)
+	requireFiles(ctx, t, te.RepositoryWriter, latest, map[string]string{
+		"a":     "a2",
+		"sub/y": "y",
+	})
+
+	union, err := snapshotfs.Consolidate(ctx, te.RepositoryWriter, ids, snapshotfs.ConsolidateUnion)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pin1", "pin2"}, union.Pins)
+	require.Equal(t, int32(4), union.Stats.TotalFileCount)
+
+	unionID, err := snapshot.SaveSnapshot(ctx, te.RepositoryWriter, union)
+	require.NoError(t, err)
+
+	// the consolidated snapshot does not depend on the original snapshots.
+	for _, id := range ids {
+		require.NoError(t, te.RepositoryWriter.DeleteManifest(ctx, id))
+	}
+
+	require.NoError(t, te.RepositoryWriter.Flush(ctx))
+
+	requireFiles(ctx, t, te.RepositoryWriter, union, map[string]string{
+		"a":     "a2",
+		"only1": "only in 1",
+		"sub/x": "x",
+		"sub/y": "y",
+	})
+
+	results, err := snapshotfs.VerifySnapshots(ctx, te.RepositoryWriter, []manifest.ID{unionID}, snapshotfs.VerifySnapshotsOptions{
+		VerifierOptions: snapshotfs.VerifierOptions{VerifyFilesPercent: 100},
+	})
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+}
+
+func TestConsolidate_Errors(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	u := snapshotfs.NewUploader(te.RepositoryWriter)
+
+	var ids []manifest.ID
+
+	for _, p := range []string{"/a", "/b"} {
+		dir := mockfs.NewDirectory()
+		dir.AddFile("f", []byte(p), 0o644)
+
+		man, err := u.Upload(ctx, dir, nil, te.LocalPathSourceInfo(p))
+		require.NoError(t, err)
+
+		id, err := snapshot.SaveSnapshot(ctx, te.RepositoryWriter, man)
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	_, err := snapshotfs.Consolidate(ctx, te.RepositoryWriter, ids, snapshotfs.ConsolidateUnion)
+	require.ErrorContains(t, err, "different sources")
+
+	_, err = snapshotfs.Consolidate(ctx, te.RepositoryWriter, ids[:1], "no-such-strategy")
+	require.ErrorContains(t, err, "unsupported consolidation strategy")
+
+	_, err = snapshotfs.Consolidate(ctx, te.RepositoryWriter, nil, snapshotfs.ConsolidateUnion)
+	require.Error(t, err)
+}
+
+func requireFiles(ctx context.Context, t *testing.T, rep repo.Repository, man *snapshot.Manifest, want map[string]string) {
+	t.Helper()
+
+	root, err := snapshotfs.SnapshotRoot(rep, man)
+	require.NoError(t, err)
+
+	got := map[string]string{}
+
+	require.NoError(t, readAllFiles(ctx, root.(fs.Directory), "", got))
+	require.Equal(t, want, got)
+}
+
+func readAllFiles(ctx context.Context, dir fs.Directory, prefix string, result map[string]string) error {
+	return fs.IterateEntries(ctx, dir, func(ctx context.Context, e fs.Entry) error {
+		switch e := e.(type) {
+		case fs.Directory:
+			return readAllFiles(ctx, e, prefix+e.Name()+"/", result)
+
+		case fs.File:
+			r, err := e.Open(ctx)
+			if err != nil {
+				return err
+			}
+			defer r.Close() //nolint:errcheck
+
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+
+			result[prefix+e.Name()] = string(b)
+		}
+
+		return nil
+	})
+}
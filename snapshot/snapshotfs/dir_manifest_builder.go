@@ -2,6 +2,7 @@
 
 import (
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/kopia/kopia/fs"
@@ -57,6 +58,8 @@ func (b *DirManifestBuilder) AddEntry(de *snapshot.DirEntry) {
 			b.summary.FatalErrorCount += childSummary.FatalErrorCount
 			b.summary.IgnoredErrorCount += childSummary.IgnoredErrorCount
 			b.summary.FailedEntries = append(b.summary.FailedEntries, childSummary.FailedEntries...)
+			b.summary.PermissionDeniedCount += childSummary.PermissionDeniedCount
+			b.summary.PermissionDeniedEntries = append(b.summary.PermissionDeniedEntries, childSummary.PermissionDeniedEntries...)
 
 			if childSummary.MaxModTime.After(b.summary.MaxModTime) {
 				b.summary.MaxModTime = childSummary.MaxModTime
@@ -82,6 +85,18 @@ func (b *DirManifestBuilder) AddFailedEntry(relPath string, isIgnoredError bool,
 	})
 }
 
+// AddPermissionDeniedEntry records an entry that was skipped due to insufficient permissions.
+func (b *DirManifestBuilder) AddPermissionDeniedEntry(relPath string, isDirectory bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.summary.PermissionDeniedCount++
+	b.summary.PermissionDeniedEntries = append(b.summary.PermissionDeniedEntries, &fs.PermissionDeniedEntry{
+		EntryPath:   relPath,
+		IsDirectory: isDirectory,
+	})
+}
+
 // Build builds the directory manifest.
 func (b *DirManifestBuilder) Build(dirModTime fs.UTCTimestamp, incompleteReason string) *snapshot.DirManifest {
 	b.mu.Lock()
@@ -99,6 +114,7 @@ func (b *DirManifestBuilder) Build(dirModTime fs.UTCTimestamp, incompleteReason
 	s.IncompleteReason = incompleteReason
 
 	b.summary.FailedEntries = sortedTopFailures(b.summary.FailedEntries)
+	s.PermissionDeniedEntries = sortedTopPermissionDenied(s.PermissionDeniedEntries)
 
 	// sort the result, directories first, then non-directories, ordered by name
 	sort.Slice(b.entries, func(i, j int) bool {
@@ -110,6 +126,8 @@ func (b *DirManifestBuilder) Build(dirModTime fs.UTCTimestamp, incompleteReason
 		return entries[i].Name < entries[j].Name
 	})
 
+	markCaseCollisions(entries)
+
 	return &snapshot.DirManifest{
 		StreamType: directoryStreamType,
 		Summary:    &s,
@@ -117,6 +135,27 @@ func (b *DirManifestBuilder) Build(dirModTime fs.UTCTimestamp, incompleteReason
 	}
 }
 
+// markCaseCollisions marks entries whose names differ only by case from the name of a sibling.
+func markCaseCollisions(entries []*snapshot.DirEntry) {
+	counts := map[string]int{}
+
+	for _, e := range entries {
+		counts[strings.ToLower(e.Name)]++
+	}
+
+	for i, e := range entries {
+		collides := counts[strings.ToLower(e.Name)] > 1
+		if e.CaseCollision == collides {
+			continue
+		}
+
+		// entries may be shared with previous directory manifests, so update a copy.
+		e2 := e.Clone()
+		e2.CaseCollision = collides
+		entries[i] = e2
+	}
+}
+
 func sortedTopFailures(entries []*fs.EntryWithError) []*fs.EntryWithError {
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].EntryPath < entries[j].EntryPath
@@ -128,3 +167,15 @@ func sortedTopFailures(entries []*fs.EntryWithError) []*fs.EntryWithError {
 
 	return entries
 }
+
+func sortedTopPermissionDenied(entries []*fs.PermissionDeniedEntry) []*fs.PermissionDeniedEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].EntryPath < entries[j].EntryPath
+	})
+
+	if len(entries) > fs.MaxFailedEntriesPerDirectorySummary {
+		entries = entries[0:fs.MaxFailedEntriesPerDirectorySummary]
+	}
+
+	return entries
+}
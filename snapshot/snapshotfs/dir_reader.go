@@ -1,28 +1,94 @@
 package snapshotfs
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 
 	"github.com/pkg/errors"
 
-	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 )
 
-const directoryStreamType = "kopia:directory"
+const (
+	directoryStreamType = "kopia:directory"
 
-// readDirEntries reads all directory entries from the specified reader.
-func readDirEntries(r io.Reader) ([]*snapshot.DirEntry, *fs.DirectorySummary, error) {
+	// shardedDirectoryStreamType is the stream type of manifests of directories whose entries are
+	// stored in shards, it differs from directoryStreamType so that versions of kopia which
+	// don't support sharding fail instead of reading directories as empty.
+	shardedDirectoryStreamType = "kopia:sharded-directory"
+)
+
+// decodeDirManifest reads the directory manifest from the specified reader.
+func decodeDirManifest(r io.Reader) (*snapshot.DirManifest, error) {
 	var dir snapshot.DirManifest
 
 	if err := json.NewDecoder(r).Decode(&dir); err != nil {
-		return nil, nil, errors.Wrap(err, "unable to parse directory object")
+		return nil, errors.Wrap(err, "unable to parse directory object")
+	}
+
+	if dir.StreamType != directoryStreamType && dir.StreamType != shardedDirectoryStreamType {
+		return nil, errors.Errorf("invalid directory stream type")
+	}
+
+	return &dir, nil
+}
+
+// readDirManifest reads the directory manifest with the provided ID, reassembling the entries
+// of sharded manifests in their original order.
+//
+// The returned manifest has the IDs of shards in Shards, if any, and all entries in Entries.
+func readDirManifest(ctx context.Context, rep repo.Repository, oid object.ID) (*snapshot.DirManifest, error) {
+	dir, err := readDirManifestObject(ctx, rep, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir.StreamType != shardedDirectoryStreamType {
+		return dir, nil
+	}
+
+	for _, shardID := range dir.Shards {
+		shard, err := readDirManifestObject(ctx, rep, shardID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read directory shard %v", shardID)
+		}
+
+		if shard.StreamType != directoryStreamType {
+			return nil, errors.Errorf("invalid stream type of directory shard %v", shardID)
+		}
+
+		dir.Entries = append(dir.Entries, shard.Entries...)
 	}
 
-	if dir.StreamType != directoryStreamType {
-		return nil, nil, errors.Errorf("invalid directory stream type")
+	return dir, nil
+}
+
+func readDirManifestObject(ctx context.Context, rep repo.Repository, oid object.ID) (*snapshot.DirManifest, error) {
+	r, err := rep.OpenObject(ctx, oid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open object: %v", oid)
 	}
+	defer r.Close() //nolint:errcheck
+
+	return decodeDirManifest(r)
+}
+
+// dirManifestShardSize returns the number of entries per shard of the provided manifest
+// returned by readDirManifest or zero if the manifest is not sharded.
+func dirManifestShardSize(dir *snapshot.DirManifest) int {
+	if len(dir.Shards) == 0 {
+		return 0
+	}
+
+	// all shards except for the last one are full.
+	return (len(dir.Entries) + len(dir.Shards) - 1) / len(dir.Shards)
+}
 
-	return dir.Entries, dir.Summary, nil
+// dirManifestShards is implemented by directories whose manifests are sharded.
+type dirManifestShards interface {
+	// manifestShards returns the IDs of shards of the directory manifest which has already been loaded.
+	manifestShards() []object.ID
 }
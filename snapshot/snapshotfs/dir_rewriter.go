@@ -134,21 +134,16 @@ func (rw *DirRewriter) getCachedReplacement(ctx context.Context, parentPath stri
 func (rw *DirRewriter) processDirectory(ctx context.Context, pathFromRoot string, entry *snapshot.DirEntry) (*snapshot.DirEntry, error) {
 	dirRewriterLog(ctx).Debugw("processDirectory", "path", pathFromRoot)
 
-	r, err := rw.rep.OpenObject(ctx, entry.ObjectID)
-	if err != nil {
-		return rw.opts.OnDirectoryReadFailure(ctx, pathFromRoot, entry, errors.Wrapf(err, "unable to open directory object %v", entry.ObjectID))
-	}
-	defer r.Close() //nolint:errcheck
-
-	entries, _, err := readDirEntries(r)
+	dir, err := readDirManifest(ctx, rw.rep, entry.ObjectID)
 	if err != nil {
 		return rw.opts.OnDirectoryReadFailure(ctx, pathFromRoot, entry, errors.Wrap(err, "unable to read directory entries"))
 	}
 
-	return rw.processDirectoryEntries(ctx, pathFromRoot, entry, entries)
+	// rewritten directories are sharded the same way as the original ones.
+	return rw.processDirectoryEntries(ctx, pathFromRoot, entry, dir.Entries, dirManifestShardSize(dir))
 }
 
-func (rw *DirRewriter) processDirectoryEntries(ctx context.Context, parentPath string, entry *snapshot.DirEntry, entries []*snapshot.DirEntry) (*snapshot.DirEntry, error) {
+func (rw *DirRewriter) processDirectoryEntries(ctx context.Context, parentPath string, entry *snapshot.DirEntry, entries []*snapshot.DirEntry, maxEntries int) (*snapshot.DirEntry, error) {
 	var (
 		builder DirManifestBuilder
 		wg      workshare.AsyncGroup[*dirRewriterRequest]
@@ -194,7 +189,7 @@ func (rw *DirRewriter) processDirectoryEntries(ctx context.Context, parentPath s
 
 	dm := builder.Build(entry.ModTime, entry.DirSummary.IncompleteReason)
 
-	oid, err := writeDirManifest(ctx, rw.rep, entry.ObjectID.String(), dm)
+	oid, err := writeDirManifest(ctx, rw.rep, entry.ObjectID.String(), dm, maxEntries)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to write directory manifest")
 	}
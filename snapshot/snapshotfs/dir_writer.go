@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/pkg/errors"
 
@@ -11,9 +12,41 @@
 	"github.com/kopia/kopia/snapshot"
 )
 
-func writeDirManifest(ctx context.Context, rep repo.RepositoryWriter, dirRelativePath string, dirManifest *snapshot.DirManifest) (object.ID, error) {
+// writeDirManifest writes the provided directory manifest and returns its object ID.
+//
+// When maxEntries is positive and the directory has more entries, consecutive ranges of up to maxEntries
+// entries are written as separate shards referenced by the returned manifest.
+func writeDirManifest(ctx context.Context, rep repo.RepositoryWriter, dirRelativePath string, dirManifest *snapshot.DirManifest, maxEntries int) (object.ID, error) {
+	if maxEntries <= 0 || len(dirManifest.Entries) <= maxEntries {
+		return writeDirManifestObject(ctx, rep, "DIR:"+dirRelativePath, dirManifest)
+	}
+
+	sharded := &snapshot.DirManifest{
+		StreamType: shardedDirectoryStreamType,
+		Entries:    []*snapshot.DirEntry{},
+		Summary:    dirManifest.Summary,
+	}
+
+	for start := 0; start < len(dirManifest.Entries); start += maxEntries {
+		shard := &snapshot.DirManifest{
+			StreamType: directoryStreamType,
+			Entries:    dirManifest.Entries[start:min(start+maxEntries, len(dirManifest.Entries))],
+		}
+
+		oid, err := writeDirManifestObject(ctx, rep, fmt.Sprintf("DIR:%v#%v", dirRelativePath, len(sharded.Shards)), shard)
+		if err != nil {
+			return object.EmptyID, errors.Wrap(err, "unable to write directory shard")
+		}
+
+		sharded.Shards = append(sharded.Shards, oid)
+	}
+
+	return writeDirManifestObject(ctx, rep, "DIR:"+dirRelativePath, sharded)
+}
+
+func writeDirManifestObject(ctx context.Context, rep repo.RepositoryWriter, description string, dirManifest *snapshot.DirManifest) (object.ID, error) {
 	writer := rep.NewObjectWriter(ctx, object.WriterOptions{
-		Description: "DIR:" + dirRelativePath,
+		Description: description,
 		Prefix:      objectIDPrefixDirectory,
 	})
 
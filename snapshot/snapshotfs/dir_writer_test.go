@@ -0,0 +1,152 @@
+package snapshotfs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestWriteDirManifest_Sharded(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	numEntries := 5_000_000
+	if testing.Short() || testutil.ShouldReduceTestComplexity() {
+		numEntries = 50_000
+	}
+
+	const numShards = 50
+
+	maxEntries := numEntries / numShards
+
+	var b DirManifestBuilder
+
+	for i := range numEntries {
+		b.AddEntry(&snapshot.DirEntry{
+			Name:     fmt.Sprintf("f%08d", i),
+			Type:     snapshot.EntryTypeFile,
+			FileSize: int64(i),
+		})
+	}
+
+	dm := b.Build(fs.UTCTimestamp(0), "")
+
+	oid, err := writeDirManifest(ctx, env.RepositoryWriter, "big", dm, maxEntries)
+	require.NoError(t, err)
+
+	// the top-level manifest can't be mistaken for an empty directory.
+	top, err := readDirManifestObject(ctx, env.RepositoryWriter, oid)
+	require.NoError(t, err)
+	require.Equal(t, shardedDirectoryStreamType, top.StreamType)
+	require.Empty(t, top.Entries)
+	require.Len(t, top.Shards, numShards)
+
+	got, err := readDirManifest(ctx, env.RepositoryWriter, oid)
+	require.NoError(t, err)
+	require.Equal(t, dm.Summary, got.Summary)
+	require.Len(t, got.Entries, numEntries)
+	require.Equal(t, maxEntries, dirManifestShardSize(got))
+
+	for i, e := range got.Entries {
+		if e.Name != dm.Entries[i].Name {
+			require.Failf(t, "unexpected entry order", "entry %v is %q, want %q", i, e.Name, dm.Entries[i].Name)
+		}
+	}
+
+	// directories within the limit are not sharded.
+	oid2, err := writeDirManifest(ctx, env.RepositoryWriter, "big", dm, numEntries)
+	require.NoError(t, err)
+
+	got, err = readDirManifest(ctx, env.RepositoryWriter, oid2)
+	require.NoError(t, err)
+	require.Equal(t, directoryStreamType, got.StreamType)
+	require.Empty(t, got.Shards)
+	require.Len(t, got.Entries, numEntries)
+}
+
+func TestUpload_ShardedDirectoryManifest(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	source := mockfs.NewDirectory()
+
+	var wantNames []string
+
+	sub := source.AddDir("sub", 0o755)
+	sub.AddFile("nested", []byte{1}, 0o644)
+
+	wantNames = append(wantNames, "sub")
+
+	for i := range 25 {
+		n := fmt.Sprintf("file%02d", i)
+		source.AddFile(n, []byte(n), 0o644)
+		wantNames = append(wantNames, n)
+	}
+
+	maxEntries := policy.OptionalInt(10)
+
+	policyTree := policy.BuildTree(nil, &policy.Policy{
+		UploadPolicy: policy.UploadPolicy{
+			MaxDirectoryManifestEntries: &maxEntries,
+		},
+	})
+
+	man, err := NewUploader(env.RepositoryWriter).Upload(ctx, source, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	root, err := SnapshotRoot(env.Repository, man)
+	require.NoError(t, err)
+
+	entries, err := fs.GetAllEntries(ctx, root.(fs.Directory))
+	require.NoError(t, err)
+
+	var gotNames []string
+	for _, e := range entries {
+		gotNames = append(gotNames, e.Name())
+	}
+
+	require.Equal(t, wantNames, gotNames)
+
+	child, err := root.(fs.Directory).Child(ctx, "file17")
+	require.NoError(t, err)
+	require.EqualValues(t, 6, child.Size())
+
+	shards := root.(dirManifestShards).manifestShards()
+	require.Len(t, shards, 3)
+
+	// shards are reported by the tree walker, so that their contents are not garbage-collected.
+	var (
+		mu      sync.Mutex
+		visited = map[object.ID]bool{}
+	)
+
+	w, err := NewTreeWalker(ctx, TreeWalkerOptions{
+		EntryCallback: func(_ context.Context, _ fs.Entry, oid object.ID, _ string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			visited[oid] = true
+
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	defer w.Close(ctx)
+
+	require.NoError(t, w.Process(ctx, root, ""))
+
+	for _, oid := range shards {
+		require.True(t, visited[oid], "shard %v was not visited", oid)
+	}
+}
@@ -146,7 +146,7 @@ func estimate(ctx context.Context, relativePath string, entry fs.Entry, policyTr
 		progress.Stats(ctx, stats, ib, eb, *ed, false)
 
 		if err != nil {
-			isIgnored := policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreDirectoryErrors.OrDefault(false)
+			isIgnored := policyTree.EffectivePolicy().ErrorHandlingPolicy.ShouldIgnoreDirectoryError(err)
 
 			if isIgnored {
 				atomic.AddInt32(&stats.IgnoredErrorCount, 1)
@@ -0,0 +1,108 @@
+package snapshotfs_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// mustSnapshotWithInlineFiles creates a snapshot in which files up to 10 bytes are stored inline.
+func mustSnapshotWithInlineFiles(t *testing.T, env *repotesting.Environment) *snapshot.Manifest {
+	t.Helper()
+
+	ctx := context.Background()
+
+	sourceRoot := mockfs.NewDirectory()
+	sourceRoot.AddFile("tiny", []byte("tiny"), 0o644)
+	sourceRoot.AddFile("empty", nil, 0o644)
+	sourceRoot.AddFile("large", bytes.Repeat([]byte{1, 2, 3}, 100), 0o644)
+	sourceRoot.AddDir("subdir", 0o755).AddFile("tiny2", []byte("tiny2"), 0o644)
+
+	pol := *policy.DefaultPolicy
+	n := policy.OptionalInt64(10)
+	pol.UploadPolicy.InlineFilesUpToSize = &n
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, sourceRoot, policy.BuildTree(nil, &pol), snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/inline"})
+	require.NoError(t, err)
+
+	_, err = snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	tiny, err := snapshotfs.GetNestedEntry(ctx, mustSnapshotRoot(t, env, man), []string{"tiny"})
+	require.NoError(t, err)
+	require.True(t, tiny.(snapshot.HasDirEntry).DirEntry().IsInline())
+
+	return man
+}
+
+func TestVerifySnapshots_InlineFiles(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	man := mustSnapshotWithInlineFiles(t, env)
+
+	results, err := snapshotfs.VerifySnapshots(ctx, env.Repository, []manifest.ID{man.ID}, snapshotfs.VerifySnapshotsOptions{
+		VerifierOptions: snapshotfs.VerifierOptions{VerifyFilesPercent: 100},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+}
+
+func TestReconcile_InlineFiles(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	mustSnapshotWithInlineFiles(t, env)
+
+	st, err := snapshotfs.Reconcile(ctx, env.RepositoryWriter, snapshotfs.ReconcileOptions{
+		OnDanglingReference: func(ctx context.Context, r snapshotfs.DanglingReference) error {
+			t.Errorf("unexpected dangling reference: %v", r)
+			return nil
+		},
+		OnOrphanContent: func(ctx context.Context, ci content.Info) error {
+			t.Errorf("unexpected orphan content: %v", ci.ContentID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	// root, subdir and the large file are the only objects.
+	require.Equal(t, snapshotfs.ReconcileStats{Snapshots: 1, Objects: 3}, st)
+}
+
+func TestDescribeObject_InlineFiles(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	man := mustSnapshotWithInlineFiles(t, env)
+
+	root := mustSnapshotRoot(t, env, man).(fs.Directory)
+
+	require.NoError(t, fs.IterateEntries(ctx, root, func(ctx context.Context, e fs.Entry) error {
+		oid := e.(object.HasObjectID).ObjectID()
+
+		l, err := env.RepositoryWriter.DescribeObject(ctx, oid)
+		require.NoError(t, err)
+
+		if e.(snapshot.HasDirEntry).DirEntry().IsInline() {
+			require.Nil(t, l.Content)
+			require.Empty(t, l.Entries)
+			require.Equal(t, 0, l.Fragmentation(0).Contents)
+		} else {
+			require.Equal(t, 1, l.Fragmentation(0).Contents)
+		}
+
+		return nil
+	}))
+}
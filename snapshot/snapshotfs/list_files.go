@@ -0,0 +1,101 @@
+package snapshotfs
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// FileListEntry describes a single file or directory in a snapshot.
+type FileListEntry struct {
+	// Path is the slash-separated path of the entry relative to the snapshot root.
+	Path     string             `json:"path"`
+	Type     snapshot.EntryType `json:"type"`
+	Size     int64              `json:"size"`
+	Mode     os.FileMode        `json:"mode"`
+	ModTime  time.Time          `json:"mtime"`
+	ObjectID object.ID          `json:"obj"`
+}
+
+// ListFiles invokes the provided callback for each entry of the snapshot with the provided ID whose path
+// is equal to or located under pathPrefix (all entries are listed when pathPrefix is empty).
+//
+// The tree is walked lazily in depth-first order, so only the directories on the current path are held in memory
+// and directories outside of pathPrefix are never read. Contents of files are not read.
+func ListFiles(ctx context.Context, rep repo.Repository, snapshotID manifest.ID, pathPrefix string, cb func(e *FileListEntry) error) error {
+	m, err := snapshot.LoadSnapshot(ctx, rep, snapshotID)
+	if err != nil {
+		return errors.Wrap(err, "unable to load snapshot")
+	}
+
+	root, err := SnapshotRoot(rep, m)
+	if err != nil {
+		return errors.Wrap(err, "unable to get snapshot root")
+	}
+
+	d, ok := root.(fs.Directory)
+	if !ok {
+		return errors.Errorf("snapshot %v is not a directory", snapshotID)
+	}
+
+	return listFilesInDirectory(ctx, d, "", strings.Trim(pathPrefix, "/"), cb)
+}
+
+func listFilesInDirectory(ctx context.Context, d fs.Directory, dirPath, pathPrefix string, cb func(e *FileListEntry) error) error {
+	//nolint:wrapcheck
+	return fs.IterateEntries(ctx, d, func(ctx context.Context, e fs.Entry) error {
+		entryPath := path.Join(dirPath, e.Name())
+
+		within := isWithinPrefix(entryPath, pathPrefix)
+		if !within && !isWithinPrefix(pathPrefix, entryPath) {
+			// neither the entry nor any of its descendants are included.
+			return nil
+		}
+
+		if within {
+			if err := cb(fileListEntry(entryPath, e)); err != nil {
+				return err
+			}
+		}
+
+		if sd, ok := e.(fs.Directory); ok {
+			return listFilesInDirectory(ctx, sd, entryPath, pathPrefix, cb)
+		}
+
+		return nil
+	})
+}
+
+// isWithinPrefix returns true if p is equal to prefix or located under it.
+func isWithinPrefix(p, prefix string) bool {
+	return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func fileListEntry(entryPath string, e fs.Entry) *FileListEntry {
+	fe := &FileListEntry{
+		Path:    entryPath,
+		Size:    e.Size(),
+		Mode:    e.Mode(),
+		ModTime: e.ModTime(),
+	}
+
+	if h, ok := e.(snapshot.HasDirEntry); ok {
+		fe.Type = h.DirEntry().Type
+	}
+
+	if h, ok := e.(object.HasObjectID); ok {
+		fe.ObjectID = h.ObjectID()
+	}
+
+	return fe
+}
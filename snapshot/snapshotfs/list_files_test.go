@@ -0,0 +1,82 @@
+package snapshotfs_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestListFiles(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	source := mockfs.NewDirectory()
+	source.AddFile("a.txt", []byte("aaa"), 0o644)
+	sub := source.AddDir("sub", 0o755)
+	sub.AddFile("b.txt", []byte("bbbb"), 0o600)
+	sub.AddDir("nested", 0o755).AddFile("c.txt", []byte("c"), 0o644)
+	source.AddDir("subway", 0o755).AddFile("d.txt", []byte("dd"), 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+
+	man, err := u.Upload(ctx, source, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"})
+	require.NoError(t, err)
+
+	manID, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	listFiles := func(prefix string) map[string]*snapshotfs.FileListEntry {
+		t.Helper()
+
+		result := map[string]*snapshotfs.FileListEntry{}
+
+		require.NoError(t, snapshotfs.ListFiles(ctx, env.RepositoryWriter, manID, prefix, func(e *snapshotfs.FileListEntry) error {
+			result[e.Path] = e
+			return nil
+		}))
+
+		return result
+	}
+
+	all := listFiles("")
+	require.Len(t, all, 7)
+
+	b := all["sub/b.txt"]
+	require.Equal(t, snapshot.EntryTypeFile, b.Type)
+	require.EqualValues(t, 4, b.Size)
+	require.EqualValues(t, 0o600, b.Mode.Perm())
+	require.NotEmpty(t, b.ObjectID.String())
+	require.Equal(t, snapshot.EntryTypeDirectory, all["sub/nested"].Type)
+
+	// prefix selects a subtree, not entries with the same leading characters.
+	require.ElementsMatch(t, []string{"sub", "sub/b.txt", "sub/nested", "sub/nested/c.txt"}, keys(listFiles("sub")))
+	require.ElementsMatch(t, []string{"sub/nested", "sub/nested/c.txt"}, keys(listFiles("/sub/nested/")))
+	require.ElementsMatch(t, []string{"sub/b.txt"}, keys(listFiles("sub/b.txt")))
+	require.Empty(t, listFiles("no-such-dir"))
+
+	// errors returned by the callback stop the listing.
+	errStop := errors.New("stop")
+	count := 0
+
+	require.ErrorIs(t, snapshotfs.ListFiles(ctx, env.RepositoryWriter, manID, "", func(_ *snapshotfs.FileListEntry) error {
+		count++
+		return errStop
+	}), errStop)
+	require.Equal(t, 1, count)
+}
+
+func keys[T any](m map[string]T) []string {
+	var result []string
+
+	for k := range m {
+		result = append(result, k)
+	}
+
+	return result
+}
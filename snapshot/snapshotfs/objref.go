@@ -72,6 +72,10 @@ func parseNestedObjectID(ctx context.Context, startingDir fs.Entry, parts []stri
 		return object.EmptyID, errors.Errorf("entry without ObjectID")
 	}
 
+	if h, ok := e.(snapshot.HasDirEntry); ok && h.DirEntry().IsInline() {
+		return object.EmptyID, errors.Errorf("%q is stored inline in the directory and has no ObjectID", e.Name())
+	}
+
 	return hoid.ObjectID(), nil
 }
 
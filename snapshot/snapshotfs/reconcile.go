@@ -0,0 +1,268 @@
+package snapshotfs
+
+import (
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+var reconcileLog = logging.Module("reconcile")
+
+// DanglingReference describes a reference from a snapshot to a content that is not present in the index.
+type DanglingReference struct {
+	Snapshot  *snapshot.Manifest
+	Path      string     // path of the entry within the snapshot
+	ObjectID  object.ID  // object referencing the missing content
+	ContentID content.ID // missing (or deleted) content
+}
+
+// ReconcileOptions provides callbacks receiving results of Reconcile() as soon as they are found.
+type ReconcileOptions struct {
+	OnDanglingReference func(ctx context.Context, r DanglingReference) error
+	OnOrphanContent     func(ctx context.Context, ci content.Info) error
+}
+
+// ReconcileStats contains summary of Reconcile() results.
+type ReconcileStats struct {
+	Snapshots          int `json:"snapshots"`
+	Objects            int `json:"objects"`
+	DanglingReferences int `json:"danglingReferences"`
+	OrphanContents     int `json:"orphanContents"`
+}
+
+type reconciler struct {
+	rep     repo.DirectRepository
+	opts    ReconcileOptions
+	stats   ReconcileStats
+	visited *bigmap.Set // object IDs that have been visited
+	used    *bigmap.Set // content IDs referenced by any snapshot
+}
+
+// reconcileContentReader adapts the repository to the interface required to load index objects.
+type reconcileContentReader struct {
+	repo.DirectRepository
+}
+
+func (r reconcileContentReader) GetContent(ctx context.Context, contentID content.ID) ([]byte, error) {
+	//nolint:wrapcheck
+	return r.ContentReader().GetContent(ctx, contentID)
+}
+
+// Reconcile walks all snapshot manifests and resolves every content they reference against the index,
+// reporting references to contents that are missing or deleted (dangling) and indexed contents
+// that are not referenced by any snapshot (orphans).
+//
+// Results are streamed to the provided callbacks and sets of visited objects and contents are kept in
+// bigmap.Set so memory usage is bounded. Each object is checked only once, so a dangling reference shared
+// by multiple snapshots is reported for the first snapshot and path where it was found.
+func Reconcile(ctx context.Context, rep repo.DirectRepository, opts ReconcileOptions) (ReconcileStats, error) {
+	visited, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return ReconcileStats{}, errors.Wrap(err, "NewSet")
+	}
+
+	defer visited.Close(ctx)
+
+	used, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return ReconcileStats{}, errors.Wrap(err, "NewSet")
+	}
+
+	defer used.Close(ctx)
+
+	r := &reconciler{
+		rep:     rep,
+		opts:    opts,
+		visited: visited,
+		used:    used,
+	}
+
+	if err := r.checkSnapshots(ctx); err != nil {
+		return r.stats, err
+	}
+
+	if err := r.findOrphans(ctx); err != nil {
+		return r.stats, err
+	}
+
+	return r.stats, nil
+}
+
+func (r *reconciler) checkSnapshots(ctx context.Context) error {
+	ids, err := snapshot.ListSnapshotManifests(ctx, r.rep, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshot manifests")
+	}
+
+	for _, id := range ids {
+		// load manifests one at a time to avoid keeping all of them in memory.
+		m, err := snapshot.LoadSnapshot(ctx, r.rep, id)
+		if err != nil {
+			return errors.Wrapf(err, "unable to load snapshot manifest %v", id)
+		}
+
+		reconcileLog(ctx).Debugf("checking snapshot %v of %v", id, m.Source)
+
+		root, err := SnapshotRoot(r.rep, m)
+		if err != nil {
+			return errors.Wrap(err, "unable to get snapshot root")
+		}
+
+		r.stats.Snapshots++
+
+		if err := r.checkEntry(ctx, m, root, "."); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) checkEntry(ctx context.Context, m *snapshot.Manifest, e fs.Entry, entryPath string) error {
+	checked, missing, err := r.checkReferencedObject(ctx, m, oidOf(e), entryPath)
+	if err != nil || !checked {
+		return err
+	}
+
+	dir, ok := e.(fs.Directory)
+	if !ok || len(missing) > 0 {
+		// can't descend into directories that can't be read.
+		return nil
+	}
+
+	if err := r.checkChildren(ctx, m, dir, entryPath); err != nil {
+		return err
+	}
+
+	// shards of the directory manifest are objects referenced by the directory.
+	if sd, ok := dir.(dirManifestShards); ok {
+		for _, oid := range sd.manifestShards() {
+			if _, _, err := r.checkReferencedObject(ctx, m, oid, entryPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkReferencedObject checks the provided object referenced at the given path, unless it has already been
+// checked, and reports dangling references to its contents. It returns whether the object has been checked
+// and the IDs of its missing contents.
+func (r *reconciler) checkReferencedObject(ctx context.Context, m *snapshot.Manifest, oid object.ID, entryPath string) (checked bool, missing []content.ID, err error) {
+	var idbuf [128]byte
+
+	if !r.visited.Put(ctx, oid.Append(idbuf[:0])) {
+		return false, nil, nil
+	}
+
+	r.stats.Objects++
+
+	missing, err = r.checkObject(ctx, oid)
+	if err != nil {
+		return false, nil, errors.Wrapf(err, "error checking %v", entryPath)
+	}
+
+	for _, cid := range missing {
+		r.stats.DanglingReferences++
+
+		if cb := r.opts.OnDanglingReference; cb != nil {
+			if err := cb(ctx, DanglingReference{m, entryPath, oid, cid}); err != nil {
+				return false, nil, err
+			}
+		}
+	}
+
+	return true, missing, nil
+}
+
+func (r *reconciler) checkChildren(ctx context.Context, m *snapshot.Manifest, dir fs.Directory, entryPath string) error {
+	//nolint:wrapcheck
+	return fs.IterateEntries(ctx, dir, func(ctx context.Context, child fs.Entry) error {
+		// entries without object IDs, such as files stored inline, have no backing contents.
+		if oidOf(child) == object.EmptyID {
+			return nil
+		}
+
+		return r.checkEntry(ctx, m, child, path.Join(entryPath, child.Name()))
+	})
+}
+
+// checkObject records contents backing the provided object and returns IDs of contents that are missing.
+func (r *reconciler) checkObject(ctx context.Context, oid object.ID) ([]content.ID, error) {
+	if indexObjectID, ok := oid.IndexObjectID(); ok {
+		missing, err := r.checkObject(ctx, indexObjectID)
+		if err != nil || len(missing) > 0 {
+			return missing, err
+		}
+
+		entries, err := object.LoadIndexObject(ctx, reconcileContentReader{r.rep}, indexObjectID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to load index object %v", indexObjectID)
+		}
+
+		for _, ent := range entries {
+			m, err := r.checkObject(ctx, ent.Object)
+			if err != nil {
+				return nil, err
+			}
+
+			missing = append(missing, m...)
+		}
+
+		return missing, nil
+	}
+
+	contentID, _, ok := oid.ContentID()
+	if !ok {
+		return nil, errors.Errorf("unrecognized object type: %v", oid)
+	}
+
+	var cidbuf [128]byte
+
+	r.used.Put(ctx, contentID.Append(cidbuf[:0]))
+
+	ci, err := r.rep.ContentInfo(ctx, contentID)
+	if errors.Is(err, content.ErrContentNotFound) || err == nil && ci.Deleted {
+		return []content.ID{contentID}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting content info for %v", contentID)
+	}
+
+	return nil, nil
+}
+
+func (r *reconciler) findOrphans(ctx context.Context) error {
+	//nolint:wrapcheck
+	return r.rep.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		if ci.ContentID.Prefix() == manifest.ContentPrefix {
+			return nil
+		}
+
+		var cidbuf [128]byte
+
+		if r.used.Contains(ci.ContentID.Append(cidbuf[:0])) {
+			return nil
+		}
+
+		r.stats.OrphanContents++
+
+		if cb := r.opts.OnOrphanContent; cb != nil {
+			return cb(ctx, ci)
+		}
+
+		return nil
+	})
+}
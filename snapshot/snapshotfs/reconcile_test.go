@@ -0,0 +1,136 @@
+package snapshotfs_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceRoot := mockfs.NewDirectory()
+	dir1 := sourceRoot.AddDir("dir1", 0o755)
+	dir1.AddFile("file1", bytes.Repeat([]byte{1, 2, 3}, 100), 0o644)
+	dir1.AddFile("file2", bytes.Repeat([]byte{4, 5, 6}, 100), 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	man, err := u.Upload(ctx, sourceRoot, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"})
+	require.NoError(t, err)
+
+	_, err = snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	// no problems after a clean snapshot.
+	st, err := snapshotfs.Reconcile(ctx, env.RepositoryWriter, snapshotfs.ReconcileOptions{})
+	require.NoError(t, err)
+	require.Equal(t, snapshotfs.ReconcileStats{Snapshots: 1, Objects: 4}, st)
+
+	// delete content of file2 and write content not referenced by any snapshot.
+	file2, err := snapshotfs.GetNestedEntry(ctx, mustSnapshotRoot(t, env, man), []string{"dir1", "file2"})
+	require.NoError(t, err)
+
+	file2CID, _, ok := file2.(object.HasObjectID).ObjectID().ContentID()
+	require.True(t, ok)
+	require.NoError(t, env.RepositoryWriter.ContentManager().DeleteContent(ctx, file2CID))
+
+	orphanCID, err := env.RepositoryWriter.ContentManager().WriteContent(ctx, gather.FromSlice([]byte("orphan")), "", content.NoCompression)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	var (
+		dangling []snapshotfs.DanglingReference
+		orphans  []content.ID
+	)
+
+	st, err = snapshotfs.Reconcile(ctx, env.RepositoryWriter, snapshotfs.ReconcileOptions{
+		OnDanglingReference: func(ctx context.Context, r snapshotfs.DanglingReference) error {
+			dangling = append(dangling, r)
+			return nil
+		},
+		OnOrphanContent: func(ctx context.Context, ci content.Info) error {
+			orphans = append(orphans, ci.ContentID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, snapshotfs.ReconcileStats{Snapshots: 1, Objects: 4, DanglingReferences: 1, OrphanContents: 1}, st)
+
+	require.Len(t, dangling, 1)
+	require.Equal(t, "dir1/file2", dangling[0].Path)
+	require.Equal(t, file2CID, dangling[0].ContentID)
+	require.Equal(t, man.ID, dangling[0].Snapshot.ID)
+	require.Equal(t, []content.ID{orphanCID}, orphans)
+}
+
+func mustSnapshotRoot(t *testing.T, env *repotesting.Environment, man *snapshot.Manifest) fs.Entry {
+	t.Helper()
+
+	root, err := snapshotfs.SnapshotRoot(env.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	return root
+}
+
+func TestReconcile_ShardedDirectory(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	mustSnapshotWithShardedDirectory(t, env)
+
+	var orphans []content.ID
+
+	st, err := snapshotfs.Reconcile(ctx, env.RepositoryWriter, snapshotfs.ReconcileOptions{
+		OnOrphanContent: func(ctx context.Context, ci content.Info) error {
+			orphans = append(orphans, ci.ContentID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, orphans)
+
+	// the root directory, its 3 manifest shards and 25 files.
+	require.Equal(t, snapshotfs.ReconcileStats{Snapshots: 1, Objects: 29}, st)
+}
+
+// mustSnapshotWithShardedDirectory creates a snapshot whose root directory manifest is split into 3 shards.
+func mustSnapshotWithShardedDirectory(t *testing.T, env *repotesting.Environment) *snapshot.Manifest {
+	t.Helper()
+
+	ctx := context.Background()
+
+	sourceRoot := mockfs.NewDirectory()
+
+	for i := range 25 {
+		sourceRoot.AddFile(fmt.Sprintf("file%02d", i), bytes.Repeat([]byte{byte(i)}, 100), 0o644)
+	}
+
+	maxEntries := policy.OptionalInt(10)
+
+	policyTree := policy.BuildTree(nil, &policy.Policy{
+		UploadPolicy: policy.UploadPolicy{
+			MaxDirectoryManifestEntries: &maxEntries,
+		},
+	})
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, sourceRoot, policyTree, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/sharded"})
+	require.NoError(t, err)
+
+	_, err = snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	return man
+}
@@ -2,6 +2,7 @@
 package snapshotfs
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"os"
@@ -80,6 +81,10 @@ func (e *repositoryEntry) DirEntry() *snapshot.DirEntry {
 	return e.metadata
 }
 
+func (e *repositoryEntry) FileFlags() (fs.FileFlags, error) {
+	return e.metadata.FileFlags, nil
+}
+
 func (e *repositoryEntry) LocalFilesystemPath() string {
 	return ""
 }
@@ -93,6 +98,8 @@ type repositoryDirectory struct {
 	mu         sync.Mutex
 	summary    *fs.DirectorySummary
 	dirEntries map[string]*snapshot.DirEntry
+	ordered    []*snapshot.DirEntry
+	shards     []object.ID
 }
 
 type repositoryFile struct {
@@ -138,9 +145,13 @@ func (rd *repositoryDirectory) Iterate(ctx context.Context) (fs.DirectoryIterato
 		return nil, err
 	}
 
-	var entries []fs.Entry
+	rd.mu.Lock()
+	ordered := rd.ordered
+	rd.mu.Unlock()
+
+	entries := make([]fs.Entry, 0, len(ordered))
 
-	for _, de := range rd.dirEntries {
+	for _, de := range ordered {
 		entries = append(entries, EntryFromDirEntry(rd.repo, de))
 	}
 
@@ -169,18 +180,21 @@ func (rd *repositoryDirectory) ensureSummary(ctx context.Context) error {
 	return rd.loadLocked(ctx)
 }
 
-func (rd *repositoryDirectory) loadLocked(ctx context.Context) error {
-	r, err := rd.repo.OpenObject(ctx, rd.metadata.ObjectID)
-	if err != nil {
-		return errors.Wrapf(err, "unable to open object: %v", rd.metadata.ObjectID)
-	}
-	defer r.Close() //nolint:errcheck
+func (rd *repositoryDirectory) manifestShards() []object.ID {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
 
-	ent, summ, err := readDirEntries(r)
+	return rd.shards
+}
+
+func (rd *repositoryDirectory) loadLocked(ctx context.Context) error {
+	dir, err := readDirManifest(ctx, rd.repo, rd.metadata.ObjectID)
 	if err != nil {
 		return errors.Wrapf(err, "unable to read dir entries for: %v", rd.metadata.ObjectID)
 	}
 
+	ent := dir.Entries
+
 	for _, md := range ent {
 		if md.Type == snapshot.EntryTypeDirectory && md.DirSummary != nil {
 			md.FileSize = md.DirSummary.TotalFileSize
@@ -188,7 +202,9 @@ func (rd *repositoryDirectory) loadLocked(ctx context.Context) error {
 		}
 	}
 
-	rd.summary = summ
+	rd.summary = dir.Summary
+	rd.shards = dir.Shards
+	rd.ordered = ent
 	rd.dirEntries = map[string]*snapshot.DirEntry{}
 
 	for _, e := range ent {
@@ -203,9 +219,14 @@ func (rd *repositoryDirectory) Close() {
 	defer rd.mu.Unlock()
 
 	rd.dirEntries = nil
+	rd.ordered = nil
 }
 
 func (rf *repositoryFile) Open(ctx context.Context) (fs.Reader, error) {
+	if rf.metadata.IsInline() {
+		return withFileInfo(newInlineReader(rf.metadata.InlineData), rf), nil
+	}
+
 	r, err := rf.repo.OpenObject(ctx, rf.metadata.ObjectID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to open object: %v", rf.metadata.ObjectID)
@@ -256,6 +277,23 @@ func EntryFromDirEntry(r repo.Repository, md *snapshot.DirEntry) fs.Entry {
 	}
 }
 
+// inlineReader is an object.Reader over the data of a file stored inline in the directory manifest.
+type inlineReader struct {
+	*bytes.Reader
+}
+
+func (r inlineReader) Close() error {
+	return nil
+}
+
+func (r inlineReader) Length() int64 {
+	return r.Size()
+}
+
+func newInlineReader(data []byte) object.Reader {
+	return inlineReader{bytes.NewReader(data)}
+}
+
 type readCloserWithFileInfo struct {
 	object.Reader
 	e fs.Entry
@@ -351,6 +389,9 @@ func IsDirectoryID(oid object.ID) bool {
 	_ fs.Directory = (*repositoryDirectory)(nil)
 	_ fs.File      = (*repositoryFile)(nil)
 	_ fs.Symlink   = (*repositorySymlink)(nil)
+
+	_ fs.HasFileFlags = (*repositoryDirectory)(nil)
+	_ fs.HasFileFlags = (*repositoryFile)(nil)
 )
 
 var (
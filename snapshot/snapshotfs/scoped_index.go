@@ -0,0 +1,76 @@
+package snapshotfs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// RestrictIndexToSnapshots pre-walks the trees of the provided snapshots and limits the content index
+// of the repository to contents reachable from them, which reduces memory usage of tools that only
+// ever read from a single source (such as restore). The walk itself uses the full index, afterwards
+// index blobs without any reachable contents are closed and are not loaded again when indexes are refreshed.
+//
+// Contents with a prefix (manifests, directories and other metadata) always remain visible, so snapshot
+// manifests can still be listed and loaded, but opening files that are not reachable from the
+// provided snapshots will fail with object.ErrObjectNotFound. Contents written afterwards through
+// the same repository are unaffected.
+func RestrictIndexToSnapshots(ctx context.Context, rep repo.DirectRepositoryWriter, manifests []*snapshot.Manifest) error {
+	var (
+		mu sync.Mutex
+		// reachable is retained by the content manager to re-apply the restriction on index refresh.
+		reachable = map[content.ID]struct{}{}
+	)
+
+	tw, twerr := NewTreeWalker(ctx, TreeWalkerOptions{
+		EntryCallback: func(ctx context.Context, _ fs.Entry, oid object.ID, _ string) error {
+			contentIDs, err := rep.VerifyObject(ctx, oid)
+			if err != nil {
+				return errors.Wrapf(err, "error verifying object %v", oid)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, cid := range contentIDs {
+				reachable[cid] = struct{}{}
+			}
+
+			return nil
+		},
+	})
+	if twerr != nil {
+		return errors.Wrap(twerr, "unable to create tree walker")
+	}
+
+	defer tw.Close(ctx)
+
+	for _, m := range manifests {
+		root, err := SnapshotRoot(rep, m)
+		if err != nil {
+			return errors.Wrap(err, "unable to get snapshot root")
+		}
+
+		if err := tw.Process(ctx, root, ""); err != nil {
+			return errors.Wrap(err, "error processing snapshot root")
+		}
+	}
+
+	//nolint:wrapcheck
+	return rep.ContentManager().RestrictIndex(ctx, func(cid content.ID) bool {
+		if cid.HasPrefix() {
+			return true
+		}
+
+		_, ok := reachable[cid]
+
+		return ok
+	})
+}
@@ -0,0 +1,81 @@
+package snapshotfs_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRestrictIndexToSnapshots(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	data1 := bytes.Repeat([]byte{1, 2, 3}, 1000)
+	data2 := bytes.Repeat([]byte{4, 5, 6}, 1000)
+
+	source1 := mockfs.NewDirectory()
+	source1.AddFile("file1", data1, 0o644)
+
+	source2 := mockfs.NewDirectory()
+	source2.AddFile("file2", data2, 0o644)
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+
+	man1, err := u.Upload(ctx, source1, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/source1"})
+	require.NoError(t, err)
+
+	man2, err := u.Upload(ctx, source2, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/source2"})
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	require.NoError(t, snapshotfs.RestrictIndexToSnapshots(ctx, env.RepositoryWriter, []*snapshot.Manifest{man1}))
+
+	// file reachable from the selected snapshot can be read.
+	require.Equal(t, data1, readSnapshotFile(t, env, man1, "file1"))
+
+	// directories of other snapshots remain visible, but their file data does not.
+	root2, err := snapshotfs.SnapshotRoot(env.RepositoryWriter, man2)
+	require.NoError(t, err)
+
+	e, err := root2.(fs.Directory).Child(ctx, "file2")
+	require.NoError(t, err)
+
+	r, err := e.(fs.File).Open(ctx)
+	if err == nil {
+		_, err = io.ReadAll(r)
+		r.Close()
+	}
+
+	require.ErrorIs(t, err, object.ErrObjectNotFound)
+}
+
+func readSnapshotFile(t *testing.T, env *repotesting.Environment, man *snapshot.Manifest, name string) []byte {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+
+	root, err := snapshotfs.SnapshotRoot(env.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	e, err := root.(fs.Directory).Child(ctx, name)
+	require.NoError(t, err)
+
+	r, err := e.(fs.File).Open(ctx)
+	require.NoError(t, err)
+
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return data
+}
@@ -87,6 +87,10 @@ func (w *TreeWalker) TooManyErrors() bool {
 }
 
 func (w *TreeWalker) alreadyProcessed(ctx context.Context, e fs.Entry) bool {
+	if w.options.VisitAllPaths {
+		return false
+	}
+
 	var idbuf [128]byte
 
 	return !w.enqueued.Put(ctx, oidOf(e).Append(idbuf[:0]))
@@ -127,7 +131,8 @@ func (w *TreeWalker) processDirEntry(ctx context.Context, dir fs.Directory, entr
 			break
 		}
 
-		if !w.alreadyProcessed(ctx, ent2) {
+		// entries without object IDs, such as files stored inline, have no backing contents.
+		if oidOf(ent2) != object.EmptyID && !w.alreadyProcessed(ctx, ent2) {
 			childPath := path.Join(entryPath, ent2.Name())
 
 			if ag.CanShareWork(w.wp) {
@@ -144,6 +149,18 @@ func (w *TreeWalker) processDirEntry(ctx context.Context, dir fs.Directory, entr
 
 	if err != nil {
 		w.ReportError(ctx, entryPath, errors.Wrap(err, "error reading directory"))
+		return
+	}
+
+	// shards of the directory manifest are objects referenced by the directory.
+	if sd, ok := dir.(dirManifestShards); ok {
+		if ec := w.options.EntryCallback; ec != nil {
+			for _, oid := range sd.manifestShards() {
+				if err := ec(ctx, dir, oid, entryPath); err != nil {
+					w.ReportError(ctx, entryPath, err)
+				}
+			}
+		}
 	}
 }
 
@@ -174,6 +191,10 @@ type TreeWalkerOptions struct {
 
 	Parallelism int
 	MaxErrors   int
+
+	// VisitAllPaths causes the callback to be invoked for every path in the tree, including objects
+	// which have already been seen at other paths. By default each object is processed once.
+	VisitAllPaths bool
 }
 
 // NewTreeWalker creates new tree walker.
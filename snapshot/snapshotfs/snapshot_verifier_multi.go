@@ -0,0 +1,258 @@
+package snapshotfs
+
+import (
+	"context"
+	"path"
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// VerifySnapshotsOptions provides options for VerifySnapshots.
+type VerifySnapshotsOptions struct {
+	VerifierOptions
+
+	// Concurrency is the number of snapshots verified at the same time, defaults to the number of CPUs.
+	Concurrency int
+
+	// StopOnError causes verification of remaining snapshots to be abandoned after the first failure.
+	StopOnError bool
+}
+
+// SnapshotVerificationResult is the result of verification of a single snapshot.
+type SnapshotVerificationResult struct {
+	ID     manifest.ID
+	Source snapshot.SourceInfo
+
+	// Skipped is true when the snapshot was not verified because of StopOnError.
+	Skipped bool
+
+	Err error
+}
+
+// VerifySnapshots verifies the provided snapshots using a bounded pool of workers and returns
+// the result for each of them in the same order.
+//
+// Objects shared between snapshots are verified only once and the outcome is reused by all snapshots
+// which reference them. The returned error is only non-nil when verification could not be started or
+// was stopped because of StopOnError, in which case it's the error of the first failed snapshot.
+func VerifySnapshots(ctx context.Context, rep repo.Repository, ids []manifest.ID, opts VerifySnapshotsOptions) ([]SnapshotVerificationResult, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = runtime.NumCPU()
+	}
+
+	verified, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+	defer verified.Close(ctx)
+
+	mv := &multiSnapshotVerifier{
+		v:          NewVerifier(ctx, rep, opts.VerifierOptions),
+		rep:        rep,
+		verified:   verified,
+		inProgress: map[object.ID]chan struct{}{},
+		failed:     map[object.ID]error{},
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		work     = make(chan int)
+		results  = make([]SnapshotVerificationResult, len(ids))
+	)
+
+	for i, id := range ids {
+		results[i] = SnapshotVerificationResult{ID: id, Skipped: true}
+	}
+
+	for range opts.Concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range work {
+				res := &results[i]
+				res.Skipped = false
+				res.Source, res.Err = mv.verifySnapshot(ctx, res.ID)
+
+				if res.Err != nil && opts.StopOnError {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = errors.Wrapf(res.Err, "error verifying snapshot %v", res.ID)
+					}
+					mu.Unlock()
+
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+
+		work <- i
+	}
+
+	close(work)
+	wg.Wait()
+
+	mv.v.ShowFinalStats(ctx)
+
+	return results, firstErr
+}
+
+type multiSnapshotVerifier struct {
+	v   *Verifier
+	rep repo.Repository
+
+	// objects which have been successfully verified.
+	verified *bigmap.Set
+
+	mu sync.Mutex
+	// objects being verified, the channel is closed when done.
+	// +checklocks:mu
+	inProgress map[object.ID]chan struct{}
+	// objects which failed verification.
+	// +checklocks:mu
+	failed map[object.ID]error
+}
+
+func (mv *multiSnapshotVerifier) verifySnapshot(ctx context.Context, id manifest.ID) (snapshot.SourceInfo, error) {
+	man, err := snapshot.LoadSnapshot(ctx, mv.rep, id)
+	if err != nil {
+		return snapshot.SourceInfo{}, errors.Wrap(err, "unable to load snapshot")
+	}
+
+	root, err := SnapshotRoot(mv.rep, man)
+	if err != nil {
+		return man.Source, err
+	}
+
+	return man.Source, mv.verifyEntry(ctx, root, man.Source.Path)
+}
+
+// verifyEntry verifies the provided entry unless it has already been verified, possibly waiting
+// for verification of the same object started by another snapshot.
+func (mv *multiSnapshotVerifier) verifyEntry(ctx context.Context, e fs.Entry, entryPath string) error {
+	oid := oidOf(e)
+
+	var idbuf [128]byte
+
+	key := oid.Append(idbuf[:0])
+
+	for {
+		if mv.verified.Contains(key) {
+			return nil
+		}
+
+		mv.mu.Lock()
+
+		if err, ok := mv.failed[oid]; ok {
+			mv.mu.Unlock()
+			return err
+		}
+
+		if ch, ok := mv.inProgress[oid]; ok {
+			mv.mu.Unlock()
+
+			select {
+			case <-ch:
+				continue
+
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// check again now that we hold the lock, since the object is added to 'verified' before being
+		// removed from 'inProgress'.
+		if mv.verified.Contains(key) {
+			mv.mu.Unlock()
+			return nil
+		}
+
+		ch := make(chan struct{})
+		mv.inProgress[oid] = ch
+		mv.mu.Unlock()
+
+		err := mv.verifyEntryUncached(ctx, e, entryPath)
+		if err == nil {
+			mv.verified.Put(ctx, key)
+		}
+
+		mv.mu.Lock()
+		delete(mv.inProgress, oid)
+
+		if err != nil {
+			mv.failed[oid] = err
+		}
+		mv.mu.Unlock()
+
+		close(ch)
+
+		return err
+	}
+}
+
+func (mv *multiSnapshotVerifier) verifyEntryUncached(ctx context.Context, e fs.Entry, entryPath string) error {
+	dir, ok := e.(fs.Directory)
+	if !ok {
+		return errors.Wrapf(mv.v.VerifyFile(ctx, oidOf(e), entryPath), "error verifying %v", entryPath)
+	}
+
+	mv.v.processed.Add(1)
+
+	iter, err := dir.Iterate(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "error reading directory %v", entryPath)
+	}
+
+	defer iter.Close()
+
+	ent, err := iter.Next(ctx)
+	for ent != nil {
+		// entries without object IDs, such as files stored inline, have no backing contents.
+		if oidOf(ent) == object.EmptyID {
+			ent, err = iter.Next(ctx)
+			continue
+		}
+
+		if verr := mv.verifyEntry(ctx, ent, path.Join(entryPath, ent.Name())); verr != nil {
+			return verr
+		}
+
+		ent, err = iter.Next(ctx)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error reading directory %v", entryPath)
+	}
+
+	// shards of the directory manifest are objects referenced by the directory.
+	if sd, ok := dir.(dirManifestShards); ok {
+		for _, oid := range sd.manifestShards() {
+			if err := mv.v.VerifyFile(ctx, oid, entryPath); err != nil {
+				return errors.Wrapf(err, "error verifying manifest shard %v of %v", oid, entryPath)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package snapshotfs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestVerifySnapshots(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	u := snapshotfs.NewUploader(te.RepositoryWriter)
+
+	var ids []manifest.ID
+
+	for _, p := range []string{"/a", "/b", "/c"} {
+		dir := mockfs.NewDirectory()
+		dir.AddFile("shared", []byte{1, 2, 3}, 0o644)
+		dir.AddFile("unique", []byte(p), 0o644)
+		dir.AddDir("subdir", 0o755).AddFile("shared2", []byte{4, 5, 6}, 0o644)
+
+		man, err := u.Upload(ctx, dir, nil, te.LocalPathSourceInfo(p))
+		require.NoError(t, err)
+
+		id, err := snapshot.SaveSnapshot(ctx, te.RepositoryWriter, man)
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	require.NoError(t, te.RepositoryWriter.Flush(ctx))
+
+	bm, err := blob.ReadBlobMap(ctx, te.RepositoryWriter.BlobReader())
+	require.NoError(t, err)
+
+	results, err := snapshotfs.VerifySnapshots(ctx, te.Repository, ids, snapshotfs.VerifySnapshotsOptions{
+		VerifierOptions: snapshotfs.VerifierOptions{
+			VerifyFilesPercent: 100,
+			BlobMap:            bm,
+		},
+		Concurrency: 3,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, len(ids))
+
+	for i, r := range results {
+		require.Equal(t, ids[i], r.ID)
+		require.False(t, r.Skipped)
+		require.NoError(t, r.Err)
+		require.Equal(t, te.LocalPathSourceInfo([]string{"/a", "/b", "/c"}[i]), r.Source)
+	}
+
+	t.Run("ContinueOnError", func(t *testing.T) {
+		// remove all 'p' blobs from the blob map, so that all snapshots fail.
+		bm2 := map[blob.ID]blob.Metadata{}
+
+		for k, v := range bm {
+			if !strings.HasPrefix(string(k), "p") {
+				bm2[k] = v
+			}
+		}
+
+		results, err := snapshotfs.VerifySnapshots(ctx, te.Repository, ids, snapshotfs.VerifySnapshotsOptions{
+			VerifierOptions: snapshotfs.VerifierOptions{BlobMap: bm2},
+			Concurrency:     2,
+		})
+		require.NoError(t, err)
+
+		for _, r := range results {
+			require.False(t, r.Skipped)
+			require.ErrorContains(t, r.Err, "backed by missing blob")
+		}
+	})
+
+	t.Run("StopOnError", func(t *testing.T) {
+		results, err := snapshotfs.VerifySnapshots(ctx, te.Repository, append([]manifest.ID{"no-such-manifest"}, ids...), snapshotfs.VerifySnapshotsOptions{
+			Concurrency: 1,
+			StopOnError: true,
+		})
+		require.ErrorContains(t, err, "no-such-manifest")
+		require.Error(t, results[0].Err)
+
+		skipped := 0
+
+		for _, r := range results {
+			if r.Skipped {
+				skipped++
+			}
+		}
+
+		require.Positive(t, skipped)
+	})
+}
+
+func TestVerifySnapshots_ShardedDirectory(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	man := mustSnapshotWithShardedDirectory(t, te)
+
+	bm, err := blob.ReadBlobMap(ctx, te.RepositoryWriter.BlobReader())
+	require.NoError(t, err)
+
+	results, err := snapshotfs.VerifySnapshots(ctx, te.Repository, []manifest.ID{man.ID}, snapshotfs.VerifySnapshotsOptions{
+		VerifierOptions: snapshotfs.VerifierOptions{VerifyFilesPercent: 100, BlobMap: bm},
+	})
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+
+	// remove metadata blobs from the blob map, which hold manifest shards.
+	bm2 := map[blob.ID]blob.Metadata{}
+
+	for k, v := range bm {
+		if !strings.HasPrefix(string(k), "q") {
+			bm2[k] = v
+		}
+	}
+
+	results, err = snapshotfs.VerifySnapshots(ctx, te.Repository, []manifest.ID{man.ID}, snapshotfs.VerifySnapshotsOptions{
+		VerifierOptions: snapshotfs.VerifierOptions{BlobMap: bm2},
+	})
+	require.NoError(t, err)
+	require.ErrorContains(t, results[0].Err, "error verifying manifest shard")
+}
@@ -22,11 +22,13 @@
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/ignorefs"
+	"github.com/kopia/kopia/fs/localfs"
 	"github.com/kopia/kopia/internal/iocopy"
 	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/internal/workshare"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/logging"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
@@ -36,6 +38,9 @@
 // DefaultCheckpointInterval is the default frequency of mid-upload checkpointing.
 const DefaultCheckpointInterval = 45 * time.Minute
 
+// reproducibleModTime replaces modification times which are derived from the wall clock in reproducible mode.
+const reproducibleModTime fs.UTCTimestamp = 0
+
 var (
 	uploadLog   = logging.Module("uploader")
 	estimateLog = logging.Module("estimate")
@@ -98,6 +103,14 @@ type Uploader struct {
 	// Labels to apply to every checkpoint made for this snapshot.
 	CheckpointLabels map[string]string
 
+	// When set to true, capture file flags (such as immutable or append-only) of local files and directories.
+	CaptureFileFlags bool
+
+	// When set to true, time-derived metadata which does not come from the source, such as modification times
+	// of streaming files, is replaced with a fixed value, so that snapshots of identical inputs have identical
+	// root object IDs regardless of when they are taken. Start and end times are still recorded in the manifest.
+	Reproducible bool
+
 	repo repo.RepositoryWriter
 
 	// stats must be allocated on heap to enforce 64-bit alignment due to atomic access on ARM.
@@ -151,22 +164,35 @@ func (u *Uploader) uploadFileInternal(ctx context.Context, parentCheckpointRegis
 		case err == nil && de != nil:
 			// We have read sufficient information from the shallow file's extended
 			// attribute to construct DirEntry.
-			_, err := u.repo.VerifyObject(ctx, de.ObjectID)
-			if err != nil {
-				return nil, errors.Wrapf(err, "invalid placeholder for %q contains foreign object.ID", f.Name())
+			if !de.IsInline() {
+				if _, err := u.repo.VerifyObject(ctx, de.ObjectID); err != nil {
+					return nil, errors.Wrapf(err, "invalid placeholder for %q contains foreign object.ID", f.Name())
+				}
 			}
 
 			return de, nil
 		}
 	}
 
+	if inlineMax := min(pol.UploadPolicy.InlineFilesUpToSize.OrDefault(-1), snapshot.MaxInlineFileSize); inlineMax >= 0 && f.Size() <= inlineMax {
+		de, err := u.uploadInlineFile(ctx, f, inlineMax)
+		if de != nil || err != nil {
+			return de, err
+		}
+
+		// the file has grown past the limit since it was listed, upload it as an object.
+	}
+
 	comp := pol.CompressionPolicy.CompressorForFile(f)
 	splitterName := pol.SplitterPolicy.SplitterForFile(f)
+	maxChunks := pol.SplitterPolicy.MaxChunksForFile(f)
+	parallelHashing := pol.UploadPolicy.MaxParallelChunkHashing.OrDefault(0)
+	zeroFill := localfs.IsBlockDevice(f.Mode()) && pol.ErrorHandlingPolicy.ZeroFillDeviceReadErrors.OrDefault(false)
 
 	chunkSize := pol.UploadPolicy.ParallelUploadAboveSize.OrDefault(-1)
 	if chunkSize < 0 || f.Size() <= chunkSize {
 		// all data fits in 1 full chunks, upload directly
-		return u.uploadFileData(ctx, parentCheckpointRegistry, f, f.Name(), 0, -1, comp, splitterName)
+		return u.uploadFileData(ctx, parentCheckpointRegistry, relativePath, f, f.Name(), 0, -1, comp, splitterName, maxChunks, parallelHashing, zeroFill)
 	}
 
 	// we always have N+1 parts, first N are exactly chunkSize, last one has undetermined length
@@ -176,6 +202,11 @@ func (u *Uploader) uploadFileInternal(ctx context.Context, parentCheckpointRegis
 	parts := make([]*snapshot.DirEntry, fullParts+1)
 	partErrors := make([]error, fullParts+1)
 
+	// the limit applies to the entire file, so each part gets an equal share of it.
+	if maxChunks > 0 {
+		maxChunks = max(maxChunks/len(parts), 1)
+	}
+
 	var wg workshare.AsyncGroup[*uploadWorkItem]
 	defer wg.Close()
 
@@ -191,11 +222,11 @@ func (u *Uploader) uploadFileInternal(ctx context.Context, parentCheckpointRegis
 		if wg.CanShareWork(u.workerPool) {
 			// another goroutine is available, delegate to them
 			wg.RunAsync(u.workerPool, func(_ *workshare.Pool[*uploadWorkItem], _ *uploadWorkItem) {
-				parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, f, uuid.NewString(), offset, length, comp, splitterName)
+				parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, relativePath, f, uuid.NewString(), offset, length, comp, splitterName, maxChunks, parallelHashing, zeroFill)
 			}, nil)
 		} else {
 			// just do the work in the current goroutine
-			parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, f, uuid.NewString(), offset, length, comp, splitterName)
+			parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, relativePath, f, uuid.NewString(), offset, length, comp, splitterName, maxChunks, parallelHashing, zeroFill)
 		}
 	}
 
@@ -234,18 +265,35 @@ func concatenateParts(ctx context.Context, rep repo.RepositoryWriter, name strin
 	return de, nil
 }
 
-func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry *checkpointRegistry, f fs.File, fname string, offset, length int64, compressor compression.Name, splitterName string) (*snapshot.DirEntry, error) {
+//nolint:funlen
+func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry *checkpointRegistry, relativePath string, f fs.File, fname string, offset, length int64, compressor compression.Name, splitterName string, maxChunks, parallelHashing int, zeroFillReadErrors bool) (*snapshot.DirEntry, error) {
 	file, err := f.Open(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to open file")
 	}
 	defer file.Close() //nolint:errcheck
 
+	if zeroFillReadErrors {
+		file = &zeroFillingReader{
+			Reader: file,
+			size:   f.Size(),
+			onError: func(offset, length int64, err error) {
+				uploadLog(ctx).Warnw("zero-filled unreadable region", "path", relativePath, "offset", offset, "length", length, "error", err)
+
+				atomic.AddInt32(&u.stats.IgnoredErrorCount, 1)
+				u.Progress.Error(relativePath, err, true)
+			},
+		}
+	}
+
 	writer := u.repo.NewObjectWriter(ctx, object.WriterOptions{
-		Description: "FILE:" + fname,
-		Compressor:  compressor,
-		Splitter:    splitterName,
-		AsyncWrites: 1, // upload chunk in parallel to writing another chunk
+		Description:          "FILE:" + fname,
+		Compressor:           compressor,
+		Splitter:             splitterName,
+		AsyncWrites:          1, // upload chunk in parallel to writing another chunk
+		ParallelHashing:      parallelHashing,
+		MaxChunks:            maxChunks,
+		OnChunkLimitExceeded: u.chunkLimitExceeded(ctx, relativePath),
 	})
 	defer writer.Close() //nolint:errcheck
 
@@ -298,6 +346,52 @@ func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry
 	return de, nil
 }
 
+// uploadInlineFile returns the entry for a file whose data is stored in the directory manifest,
+// or nil if the file turns out to be larger than maxSize.
+func (u *Uploader) uploadInlineFile(ctx context.Context, f fs.File, maxSize int64) (*snapshot.DirEntry, error) {
+	file, err := f.Open(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open file")
+	}
+	defer file.Close() //nolint:errcheck
+
+	var buf bytes.Buffer
+
+	written, err := u.copyWithProgress(&buf, io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if written > maxSize {
+		return nil, nil
+	}
+
+	de, err := newDirEntry(f, f.Name(), object.EmptyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create dir entry")
+	}
+
+	de.FileSize = written
+
+	if written > 0 {
+		de.InlineData = buf.Bytes()
+	}
+
+	atomic.AddInt32(&u.stats.TotalFileCount, 1)
+	atomic.AddInt64(&u.stats.TotalFileSize, de.FileSize)
+
+	return de, nil
+}
+
+// chunkLimitExceeded returns a callback recording files that were split into more chunks than allowed by the policy.
+func (u *Uploader) chunkLimitExceeded(ctx context.Context, relativePath string) func(chunkCount int, newSplitter string) {
+	return func(chunkCount int, newSplitter string) {
+		uploadLog(ctx).Warnw("file exceeded chunk limit", "path", relativePath, "chunks", chunkCount, "newSplitter", newSplitter)
+
+		atomic.AddInt32(&u.stats.ChunkLimitExceededFileCount, 1)
+	}
+}
+
 func (u *Uploader) uploadSymlinkInternal(ctx context.Context, relativePath string, f fs.Symlink) (dirEntry *snapshot.DirEntry, ret error) {
 	u.Progress.HashingFile(relativePath)
 
@@ -355,9 +449,11 @@ func (u *Uploader) uploadStreamingFileInternal(ctx context.Context, relativePath
 	comp := pol.CompressionPolicy.CompressorForFile(f)
 
 	writer := u.repo.NewObjectWriter(ctx, object.WriterOptions{
-		Description: "STREAMFILE:" + f.Name(),
-		Compressor:  comp,
-		Splitter:    pol.SplitterPolicy.SplitterForFile(f),
+		Description:          "STREAMFILE:" + f.Name(),
+		Compressor:           comp,
+		Splitter:             pol.SplitterPolicy.SplitterForFile(f),
+		MaxChunks:            pol.SplitterPolicy.MaxChunksForFile(f),
+		OnChunkLimitExceeded: u.chunkLimitExceeded(ctx, relativePath),
 	})
 
 	defer writer.Close() //nolint:errcheck
@@ -380,6 +476,11 @@ func (u *Uploader) uploadStreamingFileInternal(ctx context.Context, relativePath
 	de.FileSize = written
 	streamSize = written
 
+	if u.Reproducible {
+		// the modification time of a streaming file is the time it was opened.
+		de.ModTime = reproducibleModTime
+	}
+
 	atomic.AddInt32(&u.stats.TotalFileCount, 1)
 	atomic.AddInt64(&u.stats.TotalFileSize, de.FileSize)
 
@@ -482,7 +583,16 @@ func newCachedDirEntry(md, cached fs.Entry, fname string) (*snapshot.DirEntry, e
 		return newDirEntry(cached, fname, hoid.ObjectID())
 	}
 
-	return newDirEntry(md, fname, hoid.ObjectID())
+	de, err := newDirEntry(md, fname, hoid.ObjectID())
+	if err != nil {
+		return nil, err
+	}
+
+	if h, ok := cached.(snapshot.HasDirEntry); ok && h.DirEntry().IsInline() {
+		de.InlineData = h.DirEntry().InlineData
+	}
+
+	return de, nil
 }
 
 // uploadFileWithCheckpointing uploads the specified File to the repository.
@@ -854,6 +964,9 @@ func (u *Uploader) processSingle(
 			u.Progress.CachedFile(entryRelativePath, cachedEntry.Size())
 
 			cachedDirEntry, err := newCachedDirEntry(entry, cachedEntry, entry.Name())
+			if err == nil {
+				u.maybeCaptureFileFlags(ctx, entry, cachedDirEntry)
+			}
 
 			u.Progress.FinishedFile(entryRelativePath, err)
 
@@ -891,12 +1004,17 @@ func (u *Uploader) processSingle(
 			// otherwise a meaningless, empty snapshot is created that can't be restored.
 			var dre dirReadError
 			if errors.As(err, &dre) {
-				isIgnoredError := childTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreDirectoryErrors.OrDefault(false)
+				isIgnoredError := childTree.EffectivePolicy().ErrorHandlingPolicy.ShouldIgnoreDirectoryError(dre.error)
 				u.reportErrorAndMaybeCancel(dre.error, isIgnoredError, parentDirBuilder, entryRelativePath)
+
+				if isIgnoredError && errors.Is(dre.error, os.ErrPermission) {
+					parentDirBuilder.AddPermissionDeniedEntry(entryRelativePath, true)
+				}
 			} else {
 				return errors.Wrapf(err, "unable to process directory %q", entry.Name())
 			}
 		} else {
+			u.maybeCaptureFileFlags(ctx, entry, de)
 			parentDirBuilder.AddEntry(de)
 		}
 
@@ -905,8 +1023,13 @@ func (u *Uploader) processSingle(
 	case fs.Symlink:
 		de, err := u.uploadSymlinkInternal(ctx, entryRelativePath, entry)
 
+		// symlinks kept to break directory cycles are reported as ignored errors, so they show up in the summary.
+		if us, ok := entry.(fs.UnfollowedSymlink); ok && err == nil {
+			u.reportErrorAndMaybeCancel(us.FollowError(), true, parentDirBuilder, entryRelativePath)
+		}
+
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
-			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
+			policyTree.EffectivePolicy().ErrorHandlingPolicy.ShouldIgnoreFileError(err),
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
 			"snapshotted symlink", t0)
 
@@ -914,9 +1037,12 @@ func (u *Uploader) processSingle(
 		atomic.AddInt32(&u.stats.NonCachedFiles, 1)
 
 		de, err := u.uploadFileInternal(ctx, parentCheckpointRegistry, entryRelativePath, entry, policyTree.Child(entry.Name()).EffectivePolicy())
+		if err == nil {
+			u.maybeCaptureFileFlags(ctx, entry, de)
+		}
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
-			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
+			policyTree.EffectivePolicy().ErrorHandlingPolicy.ShouldIgnoreFileError(err),
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
 			"snapshotted file", t0)
 
@@ -930,7 +1056,7 @@ func (u *Uploader) processSingle(
 			isIgnoredError = policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreUnknownTypes.OrDefault(true)
 			prefix = "unknown entry"
 		} else {
-			isIgnoredError = policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false)
+			isIgnoredError = policyTree.EffectivePolicy().ErrorHandlingPolicy.ShouldIgnoreFileError(entry.ErrorInfo())
 			prefix = "error"
 		}
 
@@ -945,7 +1071,7 @@ func (u *Uploader) processSingle(
 		de, err := u.uploadStreamingFileInternal(ctx, entryRelativePath, entry, policyTree.Child(entry.Name()).EffectivePolicy())
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
-			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
+			policyTree.EffectivePolicy().ErrorHandlingPolicy.ShouldIgnoreFileError(err),
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
 			"snapshotted streaming file", t0)
 
@@ -957,6 +1083,10 @@ func (u *Uploader) processSingle(
 func (u *Uploader) processEntryUploadResult(ctx context.Context, de *snapshot.DirEntry, err error, entryRelativePath string, parentDirBuilder *DirManifestBuilder, isIgnored bool, logDetail policy.LogDetail, logMessage string, t0 timetrack.Timer) error {
 	if err != nil {
 		u.reportErrorAndMaybeCancel(err, isIgnored, parentDirBuilder, entryRelativePath)
+
+		if isIgnored && errors.Is(err, os.ErrPermission) {
+			parentDirBuilder.AddPermissionDeniedEntry(entryRelativePath, false)
+		}
 	} else {
 		parentDirBuilder.AddEntry(de)
 	}
@@ -969,6 +1099,27 @@ func (u *Uploader) processEntryUploadResult(ctx context.Context, de *snapshot.Di
 	return nil
 }
 
+// maybeCaptureFileFlags stores the flags of the provided entry in its DirEntry if requested.
+// Failure to read the flags is not fatal, since they are not needed to restore the contents.
+func (u *Uploader) maybeCaptureFileFlags(ctx context.Context, e fs.Entry, de *snapshot.DirEntry) {
+	if !u.CaptureFileFlags {
+		return
+	}
+
+	hf, ok := e.(fs.HasFileFlags)
+	if !ok {
+		return
+	}
+
+	flags, err := hf.FileFlags()
+	if err != nil {
+		uploadLog(ctx).Debugw("unable to get file flags", "path", e.LocalFilesystemPath(), "error", err)
+		return
+	}
+
+	de.FileFlags = flags
+}
+
 func uniqueChildDirectories(ctx context.Context, dirs []fs.Directory, childName string) []fs.Directory {
 	var result []fs.Directory
 
@@ -1143,6 +1294,8 @@ func uploadDirInternal(
 		return de, err
 	}
 
+	maxManifestEntries := policyTree.EffectivePolicy().UploadPolicy.MaxDirectoryManifestEntries.OrDefault(0)
+
 	childCheckpointRegistry := &checkpointRegistry{}
 
 	thisCheckpointRegistry.addCheckpointCallback(directory.Name(), func() (*snapshot.DirEntry, error) {
@@ -1157,7 +1310,7 @@ func uploadDirInternal(
 
 		checkpointManifest := thisCheckpointBuilder.Build(fs.UTCTimestampFromTime(directory.ModTime()), IncompleteReasonCheckpoint)
 
-		oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, checkpointManifest)
+		oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, checkpointManifest, maxManifestEntries)
 		if err != nil {
 			return nil, errors.Wrap(err, "error writing dir manifest")
 		}
@@ -1172,7 +1325,13 @@ func uploadDirInternal(
 
 	dirManifest := thisDirBuilder.Build(fs.UTCTimestampFromTime(directory.ModTime()), u.incompleteReason())
 
-	oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, dirManifest)
+	for _, de := range dirManifest.Entries {
+		if de.CaseCollision {
+			uploadLog(ctx).Warnw("entry name differs only by case from a sibling", "path", path.Join(dirRelativePath, de.Name))
+		}
+	}
+
+	oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, dirManifest, maxManifestEntries)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error writing dir manifest: %v", directory.Name())
 	}
@@ -1247,6 +1406,9 @@ func (u *Uploader) Upload(
 
 	u.traceEnabled = span.IsRecording()
 
+	// in per-source dedup scope, contents are only deduplicated against previous snapshots of the same source.
+	ctx = content.WithDedupNamespace(ctx, sourceInfo.String())
+
 	u.Progress.UploadStarted()
 	defer u.Progress.UploadFinished()
 
@@ -0,0 +1,68 @@
+package snapshotfs
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// blockDeviceSkipSize is the size of the region that will be skipped and replaced with
+// zeros when a read error is encountered on a block device.
+const blockDeviceSkipSize = 4096
+
+// zeroFillingReader wraps a block device reader and replaces regions that can't be read
+// (such as bad sectors) with zeros, reporting each failure to the provided callback.
+type zeroFillingReader struct {
+	fs.Reader
+
+	offset       int64 // offset of the underlying reader
+	pendingZeros int64 // number of zero bytes to emit before reading more data
+	size         int64
+	onError      func(offset, length int64, err error)
+}
+
+func (r *zeroFillingReader) Read(p []byte) (int, error) {
+	if r.pendingZeros > 0 {
+		n := int(min(r.pendingZeros, int64(len(p))))
+		clear(p[0:n])
+		r.pendingZeros -= int64(n)
+
+		return n, nil
+	}
+
+	n, err := r.Reader.Read(p)
+	r.offset += int64(n)
+
+	if err == nil || errors.Is(err, io.EOF) {
+		return n, err //nolint:wrapcheck
+	}
+
+	if r.offset >= r.size {
+		return n, io.EOF
+	}
+
+	// skip to the next skip-size boundary, but not beyond the end of the device.
+	skip := min(blockDeviceSkipSize-r.offset%blockDeviceSkipSize, r.size-r.offset)
+
+	if _, serr := r.Reader.Seek(r.offset+skip, io.SeekStart); serr != nil {
+		return n, errors.Wrapf(err, "unable to skip unreadable region at offset %v: %v", r.offset, serr)
+	}
+
+	r.onError(r.offset, skip, err)
+	r.offset += skip
+	r.pendingZeros = skip
+
+	return n, nil
+}
+
+func (r *zeroFillingReader) Seek(offset int64, whence int) (int64, error) {
+	n, err := r.Reader.Seek(offset, whence)
+	if err == nil {
+		r.offset = n
+		r.pendingZeros = 0
+	}
+
+	return n, err //nolint:wrapcheck
+}
@@ -0,0 +1,115 @@
+package snapshotfs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+)
+
+var errBadSector = errors.New("bad sector")
+
+// badSectorReader simulates a device that fails reads of any region overlapping [badStart, badEnd).
+type badSectorReader struct {
+	data     []byte
+	pos      int64
+	badStart int64
+	badEnd   int64
+}
+
+func (r *badSectorReader) Read(p []byte) (int, error) {
+	if r.pos >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+
+	if r.pos >= r.badStart && r.pos < r.badEnd {
+		return 0, errBadSector
+	}
+
+	end := min(r.pos+int64(len(p)), int64(len(r.data)))
+	if r.pos < r.badStart {
+		end = min(end, r.badStart)
+	}
+
+	n := copy(p, r.data[r.pos:end])
+	r.pos += int64(n)
+
+	return n, nil
+}
+
+func (r *badSectorReader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, errors.New("unsupported")
+	}
+
+	r.pos = offset
+
+	return offset, nil
+}
+
+func (r *badSectorReader) Close() error {
+	return nil
+}
+
+func (r *badSectorReader) Entry() (fs.Entry, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestZeroFillingReader(t *testing.T) {
+	data := bytes.Repeat([]byte{1}, 5*blockDeviceSkipSize+100)
+
+	var failedRegions [][2]int64
+
+	r := &zeroFillingReader{
+		Reader: &badSectorReader{
+			data:     data,
+			badStart: blockDeviceSkipSize + 10,
+			badEnd:   3 * blockDeviceSkipSize,
+		},
+		size: int64(len(data)),
+		onError: func(offset, length int64, err error) {
+			require.ErrorIs(t, err, errBadSector)
+
+			failedRegions = append(failedRegions, [2]int64{offset, length})
+		},
+	}
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Len(t, got, len(data))
+
+	want := bytes.Clone(data)
+	clear(want[blockDeviceSkipSize+10 : 3*blockDeviceSkipSize])
+
+	require.Equal(t, want, got)
+	require.Equal(t, [][2]int64{
+		{blockDeviceSkipSize + 10, blockDeviceSkipSize - 10},
+		{2 * blockDeviceSkipSize, blockDeviceSkipSize},
+	}, failedRegions)
+}
+
+func TestZeroFillingReader_FailureAtEnd(t *testing.T) {
+	data := bytes.Repeat([]byte{1}, blockDeviceSkipSize+100)
+
+	r := &zeroFillingReader{
+		Reader: &badSectorReader{
+			data:     data,
+			badStart: blockDeviceSkipSize,
+			badEnd:   int64(len(data)),
+		},
+		size:    int64(len(data)),
+		onError: func(offset, length int64, err error) {},
+	}
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	want := bytes.Clone(data)
+	clear(want[blockDeviceSkipSize:])
+
+	require.Equal(t, want, got)
+}
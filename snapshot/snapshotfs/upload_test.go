@@ -10,6 +10,7 @@
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"runtime/debug"
 	"sort"
 	"strings"
@@ -473,6 +474,58 @@ func TestUpload_SubDirectoryReadFailureNoFailFast(t *testing.T) {
 	)
 }
 
+func TestUpload_SymlinkCycleIsReported(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not supported on Windows without elevated privileges")
+	}
+
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	tmp := testutil.TempDirectory(t)
+
+	require.NoError(t, os.Mkdir(filepath.Join(tmp, "A"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "A", "fa"), []byte{1}, 0o644))
+	require.NoError(t, os.Symlink("..", filepath.Join(tmp, "A", "loop")))
+
+	source, err := localfs.Directory(tmp)
+	require.NoError(t, err)
+
+	trueValue := policy.OptionalBool(true)
+
+	policyTree := policy.BuildTree(map[string]*policy.Policy{
+		".": {
+			FilesPolicy: policy.FilesPolicy{
+				FollowSymlinks: &trueValue,
+			},
+		},
+	}, policy.DefaultPolicy)
+
+	u := NewUploader(th.repo)
+
+	man, err := u.Upload(ctx, source, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	verifyErrors(t, man, 0, 1,
+		[]*fs.EntryWithError{
+			{EntryPath: "A/loop", Error: fs.ErrSymlinkCycle.Error()},
+		},
+	)
+
+	// the symlink which was not followed is still stored.
+	root, err := SnapshotRoot(th.repo, man)
+	require.NoError(t, err)
+
+	a, err := root.(fs.Directory).Child(ctx, "A")
+	require.NoError(t, err)
+
+	loop, err := a.(fs.Directory).Child(ctx, "loop")
+	require.NoError(t, err)
+	require.Implements(t, (*fs.Symlink)(nil), loop)
+}
+
 func verifyErrors(t *testing.T, man *snapshot.Manifest, wantFatalErrors, wantIgnoredErrors int, wantErrors []*fs.EntryWithError) {
 	t.Helper()
 
@@ -523,6 +576,94 @@ func TestUpload_SubDirectoryReadFailureSomeIgnoredNoFailFast(t *testing.T) {
 	)
 }
 
+func TestUpload_PermissionDenied(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	errPermissionDenied := errors.Wrap(os.ErrPermission, "open failed")
+
+	setup := func(th *uploadTestHarness) {
+		th.sourceDir.Subdir("d1").FailReaddir(errPermissionDenied)
+		th.sourceDir.Subdir("d2").AddFileWithSource("denied", defaultPermissions, func() (mockfs.ReaderSeekerCloser, error) {
+			return nil, errPermissionDenied
+		})
+		th.sourceDir.AddFileWithSource("failed", defaultPermissions, func() (mockfs.ReaderSeekerCloser, error) {
+			return nil, errTest
+		})
+	}
+
+	trueValue := policy.OptionalBool(true)
+	falseValue := policy.OptionalBool(false)
+
+	t.Run("SkipAndRecord", func(t *testing.T) {
+		th := newUploadTestHarness(ctx, t)
+		defer th.cleanup()
+
+		setup(th)
+
+		policyTree := policy.BuildTree(map[string]*policy.Policy{
+			".": {
+				ErrorHandlingPolicy: policy.ErrorHandlingPolicy{
+					IgnorePermissionDeniedFiles:       &trueValue,
+					IgnorePermissionDeniedDirectories: &trueValue,
+				},
+			},
+		}, policy.DefaultPolicy)
+
+		man, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+		require.NoError(t, err)
+
+		// other errors are still fatal.
+		verifyErrors(t, man,
+			1, 2,
+			[]*fs.EntryWithError{
+				{EntryPath: "d1", Error: os.ErrPermission.Error()},
+				{EntryPath: "d2/denied", Error: os.ErrPermission.Error()},
+				{EntryPath: "failed", Error: errTest.Error()},
+			},
+		)
+
+		require.Equal(t, 2, man.RootEntry.DirSummary.PermissionDeniedCount)
+		require.Equal(t, []*fs.PermissionDeniedEntry{
+			{EntryPath: "d1", IsDirectory: true},
+			{EntryPath: "d2/denied"},
+		}, man.RootEntry.DirSummary.PermissionDeniedEntries)
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		th := newUploadTestHarness(ctx, t)
+		defer th.cleanup()
+
+		setup(th)
+
+		// other errors are ignored, but permission denied errors fail the snapshot.
+		policyTree := policy.BuildTree(map[string]*policy.Policy{
+			".": {
+				ErrorHandlingPolicy: policy.ErrorHandlingPolicy{
+					IgnoreFileErrors:                  &trueValue,
+					IgnoreDirectoryErrors:             &trueValue,
+					IgnorePermissionDeniedFiles:       &falseValue,
+					IgnorePermissionDeniedDirectories: &falseValue,
+				},
+			},
+		}, policy.DefaultPolicy)
+
+		man, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+		require.NoError(t, err)
+
+		verifyErrors(t, man,
+			2, 1,
+			[]*fs.EntryWithError{
+				{EntryPath: "d1", Error: os.ErrPermission.Error()},
+				{EntryPath: "d2/denied", Error: os.ErrPermission.Error()},
+				{EntryPath: "failed", Error: errTest.Error()},
+			},
+		)
+
+		require.Zero(t, man.RootEntry.DirSummary.PermissionDeniedCount)
+		require.Empty(t, man.RootEntry.DirSummary.PermissionDeniedEntries)
+	})
+}
+
 type mockProgress struct {
 	UploadProgress
 	finishedFileCheck func(string, error)
@@ -968,6 +1109,196 @@ func TestUpload_VirtualDirectoryWithStreamingFileWithModTime(t *testing.T) {
 	}
 }
 
+func TestUpload_Reproducible(t *testing.T) {
+	content := []byte("Streaming Temporary file content")
+
+	for _, reproducible := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reproducible=%v", reproducible), func(t *testing.T) {
+			ctx := testlogging.Context(t)
+			th := newUploadTestHarness(ctx, t)
+
+			defer th.cleanup()
+
+			policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+			var roots []object.ID
+
+			var startTimes []fs.UTCTimestamp
+
+			for range 2 {
+				// streaming files are timestamped when opened.
+				staticRoot := virtualfs.NewStaticDirectory("rootdir", []fs.Entry{
+					virtualfs.StreamingFileWithModTimeFromReader("stream-file", th.ft.NowFunc()(), io.NopCloser(bytes.NewReader(content))),
+					th.sourceDir,
+				})
+
+				u := NewUploader(th.repo)
+				u.Reproducible = reproducible
+
+				man, err := u.Upload(ctx, staticRoot, policyTree, snapshot.SourceInfo{})
+				require.NoError(t, err)
+
+				roots = append(roots, man.RootObjectID())
+				startTimes = append(startTimes, man.StartTime)
+
+				th.ft.Advance(time.Hour)
+			}
+
+			// the wall-clock time is always recorded.
+			require.NotEqual(t, startTimes[0], startTimes[1])
+
+			if reproducible {
+				require.Equal(t, roots[0], roots[1])
+			} else {
+				require.NotEqual(t, roots[0], roots[1])
+			}
+		})
+	}
+}
+
+func TestUpload_ParallelChunkHashing(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	data := make([]byte, 3<<20+1234)
+	rand.Read(data)
+
+	source := mockfs.NewDirectory()
+	source.AddFile("big", data, defaultPermissions)
+
+	var fileIDs []object.ID
+
+	for _, parallelism := range []int{0, 4} {
+		n := policy.OptionalInt(parallelism)
+
+		policyTree := policy.BuildTree(nil, &policy.Policy{
+			SplitterPolicy: policy.SplitterPolicy{
+				Algorithm: "FIXED-128K",
+			},
+			UploadPolicy: policy.UploadPolicy{
+				MaxParallelChunkHashing: &n,
+			},
+		})
+
+		man, err := NewUploader(env.RepositoryWriter).Upload(ctx, source, policyTree, snapshot.SourceInfo{})
+		require.NoError(t, err)
+
+		root, err := SnapshotRoot(env.RepositoryWriter, man)
+		require.NoError(t, err)
+
+		f, err := root.(fs.Directory).Child(ctx, "big")
+		require.NoError(t, err)
+
+		fileIDs = append(fileIDs, f.(object.HasObjectID).ObjectID())
+	}
+
+	// chunks hashed in parallel produce the same object.
+	require.Equal(t, fileIDs[0], fileIDs[1])
+}
+
+func TestUpload_InlineFiles(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	const numEmptyFiles = 100000
+
+	td := testutil.TempDirectory(t)
+
+	for i := range numEmptyFiles {
+		require.NoError(t, os.WriteFile(filepath.Join(td, fmt.Sprintf("empty-%06d", i)), nil, 0o644))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(td, "tiny"), []byte("tiny"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "large"), bytes.Repeat([]byte("x"), 100), 0o644))
+
+	srcdir, err := localfs.Directory(td)
+	require.NoError(t, err)
+
+	upload := func(inlineUpToSize int64, previous ...*snapshot.Manifest) (*snapshot.Manifest, int64) {
+		t.Helper()
+
+		pol := *policy.DefaultPolicy
+		n := policy.OptionalInt64(inlineUpToSize)
+		pol.UploadPolicy.InlineFilesUpToSize = &n
+
+		man, err := NewUploader(th.repo).Upload(ctx, srcdir, policy.BuildTree(nil, &pol), snapshot.SourceInfo{}, previous...)
+		require.NoError(t, err)
+
+		r, err := th.repo.OpenObject(ctx, man.RootObjectID())
+		require.NoError(t, err)
+
+		defer r.Close()
+
+		return man, r.Length()
+	}
+
+	_, regularSize := upload(-1)
+	man, inlineSize := upload(10)
+
+	t.Logf("directory manifest size: %v regular, %v inline", regularSize, inlineSize)
+	require.Less(t, inlineSize, regularSize*4/5)
+
+	verifyInline := func(man *snapshot.Manifest) {
+		t.Helper()
+
+		root := EntryFromDirEntry(th.repo, man.RootEntry).(fs.Directory)
+
+		for name, want := range map[string]struct {
+			data   []byte
+			perm   os.FileMode
+			inline bool
+		}{
+			"empty-000123": {nil, 0o644, true},
+			"tiny":         {[]byte("tiny"), 0o600, true},
+			"large":        {bytes.Repeat([]byte("x"), 100), 0o644, false},
+		} {
+			e, err := root.Child(ctx, name)
+			require.NoError(t, err)
+			require.Equal(t, want.inline, e.(snapshot.HasDirEntry).DirEntry().IsInline(), name)
+			require.EqualValues(t, len(want.data), e.Size())
+
+			if runtime.GOOS != "windows" {
+				require.Equal(t, want.perm, e.Mode().Perm(), name)
+			}
+
+			r, err := e.(fs.File).Open(ctx)
+			require.NoError(t, err)
+
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, string(want.data), string(got), name)
+		}
+	}
+
+	verifyInline(man)
+
+	// inline files are preserved when reusing cached entries of the previous snapshot.
+	man2, _ := upload(10, man)
+	require.Equal(t, man.RootObjectID(), man2.RootObjectID())
+	verifyInline(man2)
+
+	// entries stored inline have no backing objects to walk.
+	var walked atomic.Int32
+
+	w, err := NewTreeWalker(ctx, TreeWalkerOptions{
+		EntryCallback: func(ctx context.Context, e fs.Entry, oid object.ID, _ string) error {
+			walked.Add(1)
+
+			_, err := th.repo.VerifyObject(ctx, oid)
+
+			return err
+		},
+	})
+	require.NoError(t, err)
+
+	defer w.Close(ctx)
+
+	require.NoError(t, w.Process(ctx, EntryFromDirEntry(th.repo, man.RootEntry), "."))
+	require.EqualValues(t, 2, walked.Load())
+}
+
 func TestUpload_StreamingDirectory(t *testing.T) {
 	ctx := testlogging.Context(t)
 	th := newUploadTestHarness(ctx, t)
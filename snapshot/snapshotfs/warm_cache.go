@@ -0,0 +1,101 @@
+package snapshotfs
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+const warmCacheBatchSize = 100
+
+// errWarmCacheBudgetExceeded stops the walk when the cache budget has been used up.
+var errWarmCacheBudgetExceeded = errors.New("cache budget exceeded")
+
+// WarmCacheOptions provides options for WarmCacheForSnapshot.
+type WarmCacheOptions struct {
+	// PathPrefix limits warming to the entries equal to or located under the provided path.
+	PathPrefix string
+
+	// MaxBytes is the cache budget, warming stops before exceeding the provided total size of files.
+	// Zero means unlimited.
+	MaxBytes int64
+
+	// Hint is the prefetch hint passed to the repository.
+	Hint string
+}
+
+// WarmCacheStats describes the results of WarmCacheForSnapshot.
+type WarmCacheStats struct {
+	Files          int   `json:"files"`
+	Bytes          int64 `json:"bytes"`
+	Contents       int   `json:"contents"`
+	BudgetExceeded bool  `json:"budgetExceeded,omitempty"`
+}
+
+// WarmCacheForSnapshot fetches contents of files in the snapshot with the provided ID into the local cache,
+// so that a subsequent restore reads mostly from the cache.
+//
+// Files are fetched in batches in the order in which they are restored until the cache budget has been used up,
+// since warming more contents than the cache can hold would evict contents fetched earlier.
+func WarmCacheForSnapshot(ctx context.Context, rep repo.Repository, snapshotID manifest.ID, opt WarmCacheOptions) (*WarmCacheStats, error) {
+	var (
+		st    WarmCacheStats
+		batch []object.ID
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		cids, err := rep.PrefetchObjects(ctx, batch, opt.Hint)
+		if err != nil {
+			return errors.Wrap(err, "error prefetching objects")
+		}
+
+		st.Contents += len(cids)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	err := ListFiles(ctx, rep, snapshotID, opt.PathPrefix, func(e *FileListEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		if e.Type != snapshot.EntryTypeFile || e.ObjectID == object.EmptyID {
+			// directories are read during the walk and inline files don't have any contents.
+			return nil
+		}
+
+		if opt.MaxBytes > 0 && st.Bytes+e.Size > opt.MaxBytes {
+			st.BudgetExceeded = true
+			return errWarmCacheBudgetExceeded
+		}
+
+		st.Files++
+		st.Bytes += e.Size
+
+		batch = append(batch, e.ObjectID)
+		if len(batch) < warmCacheBatchSize {
+			return nil
+		}
+
+		return flush()
+	})
+	if err != nil && !errors.Is(err, errWarmCacheBudgetExceeded) {
+		return nil, err
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
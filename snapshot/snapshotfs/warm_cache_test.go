@@ -0,0 +1,53 @@
+package snapshotfs_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestWarmCacheForSnapshot(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	source := mockfs.NewDirectory()
+	source.AddFile("a", bytes.Repeat([]byte{1}, 1000), 0o644)
+	sub := source.AddDir("sub", 0o755)
+	sub.AddFile("b", bytes.Repeat([]byte{2}, 2000), 0o644)
+	sub.AddFile("c", bytes.Repeat([]byte{3}, 3000), 0o644)
+
+	man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, source, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"})
+	require.NoError(t, err)
+
+	manID, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	st, err := snapshotfs.WarmCacheForSnapshot(ctx, env.Repository, manID, snapshotfs.WarmCacheOptions{})
+	require.NoError(t, err)
+	require.Equal(t, snapshotfs.WarmCacheStats{Files: 3, Bytes: 6000, Contents: 3}, *st)
+
+	st, err = snapshotfs.WarmCacheForSnapshot(ctx, env.Repository, manID, snapshotfs.WarmCacheOptions{PathPrefix: "sub"})
+	require.NoError(t, err)
+	require.Equal(t, 2, st.Files)
+	require.EqualValues(t, 5000, st.Bytes)
+
+	// warming stops before exceeding the budget.
+	st, err = snapshotfs.WarmCacheForSnapshot(ctx, env.Repository, manID, snapshotfs.WarmCacheOptions{MaxBytes: 3500})
+	require.NoError(t, err)
+	require.True(t, st.BudgetExceeded)
+	require.LessOrEqual(t, st.Bytes, int64(3500))
+	require.Positive(t, st.Files)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = snapshotfs.WarmCacheForSnapshot(canceledCtx, env.Repository, manID, snapshotfs.WarmCacheOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -18,6 +18,7 @@
 	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
@@ -34,6 +35,27 @@ func findInUseContentIDs(ctx context.Context, rep repo.Repository, used *bigmap.
 		return errors.Wrap(err, "unable to load manifest IDs")
 	}
 
+	markUsed := func(ctx context.Context, cid content.ID) error {
+		var cidbuf [128]byte
+
+		used.Put(ctx, cid.Append(cidbuf[:0]))
+
+		return nil
+	}
+
+	log(ctx).Info("Looking for active contents...")
+
+	if err := walkSnapshotContents(ctx, rep, manifests, markUsed); err != nil {
+		return err
+	}
+
+	// files with retention locks are in use even when no longer referenced by any snapshot.
+	return errors.Wrap(retentionlock.WalkLockedContents(ctx, rep, rep.Time(), markUsed), "unable to find contents of locked files")
+}
+
+// walkSnapshotContents invokes the provided callback, possibly concurrently, for each content
+// referenced by the provided snapshots. The callback may be invoked more than once for the same content.
+func walkSnapshotContents(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest, cb func(ctx context.Context, cid content.ID) error) error {
 	w, twerr := snapshotfs.NewTreeWalker(ctx, snapshotfs.TreeWalkerOptions{
 		EntryCallback: func(ctx context.Context, _ fs.Entry, oid object.ID, _ string) error {
 			contentIDs, verr := rep.VerifyObject(ctx, oid)
@@ -41,23 +63,21 @@ func findInUseContentIDs(ctx context.Context, rep repo.Repository, used *bigmap.
 				return errors.Wrapf(verr, "error verifying %v", oid)
 			}
 
-			var cidbuf [128]byte
-
 			for _, cid := range contentIDs {
-				used.Put(ctx, cid.Append(cidbuf[:0]))
+				if err := cb(ctx, cid); err != nil {
+					return err
+				}
 			}
 
 			return nil
 		},
 	})
 	if twerr != nil {
-		return errors.Wrap(err, "unable to create tree walker")
+		return errors.Wrap(twerr, "unable to create tree walker")
 	}
 
 	defer w.Close(ctx)
 
-	log(ctx).Info("Looking for active contents...")
-
 	for _, m := range manifests {
 		root, err := snapshotfs.SnapshotRoot(rep, m)
 		if err != nil {
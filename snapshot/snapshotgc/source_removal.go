@@ -0,0 +1,174 @@
+package snapshotgc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+)
+
+// SourceRemovalCallbacks receives the results of FindSourceRemovalOrphans as they are found.
+// Callbacks are never invoked concurrently.
+type SourceRemovalCallbacks struct {
+	// Snapshot is invoked for each snapshot of the source which would be deleted.
+	Snapshot func(ctx context.Context, m *snapshot.Manifest) error
+
+	// Content is invoked for each content which would become unreferenced.
+	Content func(ctx context.Context, ci content.Info) error
+}
+
+// SourceRemovalStats summarizes the effects of removing a source.
+type SourceRemovalStats struct {
+	SnapshotCount int `json:"snapshots"`
+
+	// contents that would no longer be referenced by any snapshot.
+	UnreferencedCount int   `json:"unreferencedContents"`
+	UnreferencedBytes int64 `json:"unreferencedBytes"`
+
+	// contents of the source which are also referenced by snapshots of other sources.
+	SharedCount int   `json:"sharedContents"`
+	SharedBytes int64 `json:"sharedBytes"`
+
+	// contents of the source which are not referenced by other sources, but are kept by retention locks
+	// until the locks expire.
+	LockedCount int   `json:"lockedContents"`
+	LockedBytes int64 `json:"lockedBytes"`
+}
+
+// FindSourceRemovalOrphans determines the snapshots owned by the provided source and the contents which would
+// become unreferenced (and thus subject to garbage collection) if those snapshots were deleted, taking into
+// account contents shared with snapshots of other sources and contents of files with retention locks, which
+// are kept after the source is removed. The repository is not modified.
+//
+// Results are passed to the callbacks as they are found, so that large repositories can be handled without
+// holding all of them in memory.
+func FindSourceRemovalOrphans(ctx context.Context, rep repo.Repository, src snapshot.SourceInfo, cb SourceRemovalCallbacks) (SourceRemovalStats, error) {
+	var st SourceRemovalStats
+
+	ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to list snapshot manifest IDs")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to load manifest IDs")
+	}
+
+	var owned, others []*snapshot.Manifest
+
+	for _, m := range manifests {
+		if m.Source == src {
+			owned = append(owned, m)
+		} else {
+			others = append(others, m)
+		}
+	}
+
+	for _, m := range owned {
+		st.SnapshotCount++
+
+		if cb.Snapshot != nil {
+			if err := cb.Snapshot(ctx, m); err != nil {
+				return st, err
+			}
+		}
+	}
+
+	if len(owned) == 0 {
+		return st, nil
+	}
+
+	retained, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to create new set")
+	}
+	defer retained.Close(ctx)
+
+	log(ctx).Info("Looking for contents used by other sources...")
+
+	if err := walkSnapshotContents(ctx, rep, others, func(ctx context.Context, cid content.ID) error {
+		var cidbuf [128]byte
+
+		retained.Put(ctx, cid.Append(cidbuf[:0]))
+
+		return nil
+	}); err != nil {
+		return st, errors.Wrap(err, "unable to find contents used by other sources")
+	}
+
+	locked, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to create new set")
+	}
+	defer locked.Close(ctx)
+
+	if err := retentionlock.WalkLockedContents(ctx, rep, rep.Time(), func(ctx context.Context, cid content.ID) error {
+		var cidbuf [128]byte
+
+		locked.Put(ctx, cid.Append(cidbuf[:0]))
+
+		return nil
+	}); err != nil {
+		return st, errors.Wrap(err, "unable to find contents of locked files")
+	}
+
+	seen, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to create new set")
+	}
+	defer seen.Close(ctx)
+
+	var mu sync.Mutex
+
+	log(ctx).Infof("Looking for contents used by %v...", src)
+
+	err = walkSnapshotContents(ctx, rep, owned, func(ctx context.Context, cid content.ID) error {
+		var cidbuf [128]byte
+
+		key := cid.Append(cidbuf[:0])
+
+		if !seen.Put(ctx, key) {
+			return nil
+		}
+
+		ci, err := rep.ContentInfo(ctx, cid)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get content info for %v", cid)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if retained.Contains(key) {
+			st.SharedCount++
+			st.SharedBytes += int64(ci.PackedLength)
+
+			return nil
+		}
+
+		if locked.Contains(key) {
+			st.LockedCount++
+			st.LockedBytes += int64(ci.PackedLength)
+
+			return nil
+		}
+
+		st.UnreferencedCount++
+		st.UnreferencedBytes += int64(ci.PackedLength)
+
+		if cb.Content != nil {
+			return cb.Content(ctx, ci)
+		}
+
+		return nil
+	})
+
+	return st, errors.Wrap(err, "unable to find contents used by source")
+}
@@ -0,0 +1,124 @@
+package snapshotgc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/snapshot/snapshotgc"
+)
+
+func TestFindSourceRemovalOrphans(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, format.FormatVersion3)
+
+	srcA := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/a"}
+	srcB := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/b"}
+
+	dirA := mockfs.NewDirectory()
+	dirA.AddFile("unique", []byte("only in a"), 0o644)
+	dirA.AddFile("shared", []byte("in both a and b"), 0o644)
+
+	dirB := mockfs.NewDirectory()
+	dirB.AddFile("shared", []byte("in both a and b"), 0o644)
+
+	mustSnapshot(ctx, t, env.RepositoryWriter, dirA, srcA)
+	mustSnapshot(ctx, t, env.RepositoryWriter, dirA, srcA)
+	manB := mustSnapshot(ctx, t, env.RepositoryWriter, dirB, srcB)
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	var (
+		snapshots []*snapshot.Manifest
+		orphaned  []content.ID
+	)
+
+	st, err := snapshotgc.FindSourceRemovalOrphans(ctx, env.Repository, srcA, snapshotgc.SourceRemovalCallbacks{
+		Snapshot: func(ctx context.Context, m *snapshot.Manifest) error {
+			snapshots = append(snapshots, m)
+			return nil
+		},
+		Content: func(ctx context.Context, ci content.Info) error {
+			orphaned = append(orphaned, ci.ContentID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, snapshots, 2)
+	require.Equal(t, 2, st.SnapshotCount)
+
+	for _, m := range snapshots {
+		require.Equal(t, srcA, m.Source)
+	}
+
+	// the root directory of a and the unique file become unreferenced, the shared file does not.
+	require.Equal(t, 1, st.SharedCount)
+	require.Equal(t, 2, st.UnreferencedCount)
+	require.Len(t, orphaned, st.UnreferencedCount)
+	require.Positive(t, st.UnreferencedBytes)
+
+	require.Contains(t, orphaned, mustContentIDOfEntry(ctx, t, env.Repository, snapshots[0], "unique"))
+	require.NotContains(t, orphaned, mustContentIDOfEntry(ctx, t, env.Repository, manB, "shared"))
+
+	// contents of locked files are kept after the source is removed.
+	_, err = retentionlock.LockFile(ctx, env.RepositoryWriter, snapshots[0], "unique", clock.Now().Add(time.Hour), "")
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	st, err = snapshotgc.FindSourceRemovalOrphans(ctx, env.Repository, srcA, snapshotgc.SourceRemovalCallbacks{})
+	require.NoError(t, err)
+	require.Equal(t, 1, st.SharedCount)
+	require.Equal(t, 1, st.LockedCount)
+	require.Equal(t, 1, st.UnreferencedCount)
+
+	// removing a source without snapshots has no effect.
+	st, err = snapshotgc.FindSourceRemovalOrphans(ctx, env.Repository, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/c"}, snapshotgc.SourceRemovalCallbacks{})
+	require.NoError(t, err)
+	require.Equal(t, snapshotgc.SourceRemovalStats{}, st)
+}
+
+func mustSnapshot(ctx context.Context, t *testing.T, rep repo.RepositoryWriter, dir *mockfs.Directory, si snapshot.SourceInfo) *snapshot.Manifest {
+	t.Helper()
+
+	policyTree, err := policy.TreeForSource(ctx, rep, si)
+	require.NoError(t, err)
+
+	man, err := snapshotfs.NewUploader(rep).Upload(ctx, dir, policyTree, si)
+	require.NoError(t, err)
+
+	_, err = snapshot.SaveSnapshot(ctx, rep, man)
+	require.NoError(t, err)
+
+	return man
+}
+
+func mustContentIDOfEntry(ctx context.Context, t *testing.T, rep repo.Repository, man *snapshot.Manifest, name string) content.ID {
+	t.Helper()
+
+	root, err := snapshotfs.SnapshotRoot(rep, man)
+	require.NoError(t, err)
+
+	e, err := snapshotfs.GetNestedEntry(ctx, root, []string{name})
+	require.NoError(t, err)
+
+	hoid, ok := e.(interface{ ObjectID() object.ID })
+	require.True(t, ok)
+
+	cid, _, ok := hoid.ObjectID().ContentID()
+	require.True(t, ok)
+
+	return cid
+}
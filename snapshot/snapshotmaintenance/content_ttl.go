@@ -0,0 +1,182 @@
+package snapshotmaintenance
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// ExpireContentsStats describes the results of ExpireContents.
+type ExpireContentsStats struct {
+	ExpiredContents int   `json:"expiredContents"`
+	ExpiredBytes    int64 `json:"expiredBytes"`
+
+	DeletedSnapshots []manifest.ID `json:"deletedSnapshots"`
+}
+
+// ExpireContents deletes all contents created before the provided time even if they are still referenced,
+// except for manifest contents and contents of files with retention locks, then deletes snapshots referencing
+// any expired content. Pack blobs which no longer hold any live contents are left to be deleted by regular
+// maintenance with the usual safety margins.
+//
+// Unlike snapshot garbage collection this does not wait for other clients to observe the deleted contents,
+// so snapshots being created concurrently may reference contents which are about to be deleted.
+// This is only suitable for repositories used as caches and must never be used for backups.
+func ExpireContents(ctx context.Context, rep repo.DirectRepositoryWriter, expireBefore time.Time) (*ExpireContentsStats, error) {
+	expired, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+	defer expired.Close(ctx)
+
+	var (
+		st     ExpireContentsStats
+		cidbuf [128]byte
+		cm     = rep.ContentManager()
+	)
+
+	locked, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+	defer locked.Close(ctx)
+
+	// contents of files with retention locks never expire.
+	if err := retentionlock.WalkLockedContents(ctx, rep, rep.Time(), func(ctx context.Context, cid content.ID) error {
+		locked.Put(ctx, cid.Append(cidbuf[:0]))
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "unable to find contents of locked files")
+	}
+
+	log(ctx).Infof("Expiring contents created before %v...", expireBefore)
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		if ci.ContentID.Prefix() == manifest.ContentPrefix || !ci.Timestamp().Before(expireBefore) {
+			return nil
+		}
+
+		if locked.Contains(ci.ContentID.Append(cidbuf[:0])) {
+			return nil
+		}
+
+		if err := cm.DeleteContent(ctx, ci.ContentID); err != nil {
+			return errors.Wrapf(err, "error deleting content %v", ci.ContentID)
+		}
+
+		expired.Put(ctx, ci.ContentID.Append(cidbuf[:0]))
+
+		st.ExpiredContents++
+		st.ExpiredBytes += int64(ci.PackedLength)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	if st.ExpiredContents > 0 {
+		if err := deleteSnapshotsReferencingExpiredContents(ctx, rep, expired, &st); err != nil {
+			return nil, err
+		}
+
+		if err := rep.Flush(ctx); err != nil {
+			return nil, errors.Wrap(err, "flush error")
+		}
+	}
+
+	log(ctx).Infof("Expired %v contents (%v) and deleted %v snapshots.",
+		st.ExpiredContents, units.BytesString(st.ExpiredBytes), len(st.DeletedSnapshots))
+
+	return &st, nil
+}
+
+func deleteSnapshotsReferencingExpiredContents(ctx context.Context, rep repo.DirectRepositoryWriter, expired *bigmap.Set, st *ExpireContentsStats) error {
+	ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshot manifest IDs")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		return errors.Wrap(err, "unable to load manifest IDs")
+	}
+
+	for _, m := range manifests {
+		ok, err := snapshotReferencesExpiredContent(ctx, rep, m, expired)
+		if err != nil {
+			return errors.Wrapf(err, "error checking snapshot %v", m.ID)
+		}
+
+		if !ok {
+			continue
+		}
+
+		log(ctx).Debugf("deleting snapshot %v of %v which references expired contents", m.ID, m.Source)
+
+		if err := rep.DeleteManifest(ctx, m.ID); err != nil {
+			return errors.Wrapf(err, "error deleting snapshot %v", m.ID)
+		}
+
+		st.DeletedSnapshots = append(st.DeletedSnapshots, m.ID)
+	}
+
+	return nil
+}
+
+func snapshotReferencesExpiredContent(ctx context.Context, rep repo.Repository, m *snapshot.Manifest, expired *bigmap.Set) (bool, error) {
+	var found atomic.Bool
+
+	// each snapshot uses a separate walker, since walkers skip objects they have already seen.
+	w, err := snapshotfs.NewTreeWalker(ctx, snapshotfs.TreeWalkerOptions{
+		EntryCallback: func(ctx context.Context, _ fs.Entry, oid object.ID, _ string) error {
+			if found.Load() {
+				return nil
+			}
+
+			contentIDs, verr := rep.VerifyObject(ctx, oid)
+			if verr != nil {
+				return errors.Wrapf(verr, "error verifying %v", oid)
+			}
+
+			var cidbuf [128]byte
+
+			for _, cid := range contentIDs {
+				if expired.Contains(cid.Append(cidbuf[:0])) {
+					found.Store(true)
+					return nil
+				}
+			}
+
+			return nil
+		},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to create tree walker")
+	}
+
+	defer w.Close(ctx)
+
+	root, err := snapshotfs.SnapshotRoot(rep, m)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to get snapshot root")
+	}
+
+	if err := w.Process(ctx, root, ""); err != nil {
+		return false, errors.Wrap(err, "error processing snapshot root")
+	}
+
+	return found.Load(), nil
+}
@@ -0,0 +1,124 @@
+package snapshotmaintenance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
+)
+
+func (s *formatSpecificTestSuite) TestContentTTL(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	th.sourceDir.AddFile("old", []byte("old contents"), defaultPermissions)
+
+	oldSource := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/old"}
+	oldSnapshot := mustSnapshot(t, th.RepositoryWriter, th.sourceDir, oldSource)
+	mustFlush(t, th.RepositoryWriter)
+
+	oldInfo := fileContentInfo(t, th, oldSnapshot, "old")
+
+	const ttl = 24 * time.Hour
+
+	th.fakeTime.Advance(ttl + time.Hour)
+
+	newDir := mockfs.NewDirectory()
+	newDir.AddFile("new", []byte("new contents"), defaultPermissions)
+
+	newSource := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/new"}
+	newSnapshot := mustSnapshot(t, th.RepositoryWriter, newDir, newSource)
+	mustFlush(t, th.RepositoryWriter)
+
+	p := maintenance.DefaultParams()
+	p.Owner = th.RepositoryWriter.ClientOptions().UsernameAtHost()
+	p.UnsafeContentTTL = ttl
+	require.NoError(t, maintenance.SetParams(ctx, th.RepositoryWriter, &p))
+	mustFlush(t, th.RepositoryWriter)
+
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+	mustFlush(t, th.RepositoryWriter)
+
+	sched, err := maintenance.GetSchedule(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.NotEmpty(t, sched.Runs[maintenance.TaskExpireContents])
+	require.True(t, sched.Runs[maintenance.TaskExpireContents][0].Success)
+
+	// the snapshot referencing expired contents is deleted, but the pack blob holding them is kept
+	// until regular maintenance deletes it.
+	man, err := snapshot.ListSnapshots(ctx, th.RepositoryWriter, oldSource)
+	require.NoError(t, err)
+	require.Empty(t, man)
+
+	ci, err := th.RepositoryWriter.ContentInfo(ctx, oldInfo.ContentID)
+	require.NoError(t, err)
+	require.True(t, ci.Deleted)
+
+	_, err = th.RepositoryWriter.BlobStorage().GetMetadata(ctx, oldInfo.PackBlobID)
+	require.NoError(t, err)
+
+	// newer snapshots are unaffected.
+	man, err = snapshot.ListSnapshots(ctx, th.RepositoryWriter, newSource)
+	require.NoError(t, err)
+	require.Len(t, man, 1)
+	require.Equal(t, newSnapshot.ID, man[0].ID)
+
+	newInfo := fileContentInfo(t, th, newSnapshot, "new")
+	require.False(t, newInfo.Deleted)
+
+	// subsequent maintenance eventually deletes the orphaned pack blob, without affecting newer contents.
+	for range 4 {
+		th.fakeTime.Advance(ttl / 8)
+		require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+		mustFlush(t, th.RepositoryWriter)
+	}
+
+	_, err = th.RepositoryWriter.BlobStorage().GetMetadata(ctx, oldInfo.PackBlobID)
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+
+	require.False(t, fileContentInfo(t, th, newSnapshot, "new").Deleted)
+}
+
+func (s *formatSpecificTestSuite) TestExpireContentsKeepsRecentContents(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	th.sourceDir.AddFile("f", []byte("contents"), defaultPermissions)
+
+	si := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/foo"}
+	man := mustSnapshot(t, th.RepositoryWriter, th.sourceDir, si)
+	mustFlush(t, th.RepositoryWriter)
+
+	st, err := snapshotmaintenance.ExpireContents(ctx, th.RepositoryWriter, man.StartTime.ToTime().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, st.ExpiredContents)
+	require.Empty(t, st.DeletedSnapshots)
+
+	require.False(t, fileContentInfo(t, th, man, "f").Deleted)
+}
+
+func fileContentInfo(t *testing.T, th *testHarness, man *snapshot.Manifest, name string) content.Info {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+
+	root, err := snapshotfs.SnapshotRoot(th.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	e, err := snapshotfs.GetNestedEntry(ctx, root, []string{name})
+	require.NoError(t, err)
+
+	ci, err := th.RepositoryWriter.ContentInfo(ctx, mustGetContentID(t, e.(snapshot.HasDirEntry).DirEntry().ObjectID))
+	require.NoError(t, err)
+
+	return ci
+}
@@ -0,0 +1,365 @@
+package snapshotmaintenance
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+var log = logging.Module("snapshotmaintenance")
+
+const (
+	defaultMaxPackSpread        = 2
+	defaultRebalanceFlushPacks  = 10
+	rebalanceProgressDirsPeriod = 1000
+)
+
+// RebalanceOptions provides options for RebalanceForLocality.
+type RebalanceOptions struct {
+	// Manifests are the snapshots whose directories are rebalanced, all snapshots when empty.
+	Manifests []*snapshot.Manifest
+
+	// MaxPackSpread is the maximum ratio of the number of pack blobs holding contents of files in a directory
+	// to the minimum number of packs which could hold them, directories exceeding it are rebalanced.
+	MaxPackSpread float64
+
+	// FlushEveryBytes causes rewritten contents to be flushed after the specified number of bytes,
+	// so that the progress of interrupted runs is preserved. Defaults to 10 pack blobs.
+	FlushEveryBytes int64
+
+	DryRun bool
+
+	// Force runs rebalancing even if maintenance is not owned by the local user.
+	Force bool
+}
+
+// LocalityStats describes the locality of contents of files in directories, before and after rebalancing.
+type LocalityStats struct {
+	Directories           int   `json:"directories"`
+	DirectoriesRebalanced int   `json:"directoriesRebalanced"`
+	ContentsRewritten     int   `json:"contentsRewritten"`
+	BytesRewritten        int64 `json:"bytesRewritten"`
+
+	// PacksBefore and PacksAfter are the sums over all directories of the number of distinct pack blobs
+	// holding contents of their files.
+	PacksBefore int64 `json:"packsBefore"`
+	PacksAfter  int64 `json:"packsAfter"`
+
+	// PackSwitchesBefore and PackSwitchesAfter are the numbers of times reading all files in directory order
+	// moves from one pack blob to another. In dry-run mode the values after rebalancing are estimates.
+	PackSwitchesBefore int64 `json:"packSwitchesBefore"`
+	PackSwitchesAfter  int64 `json:"packSwitchesAfter"`
+}
+
+// PacksPerDirectoryBefore returns the average number of pack blobs holding contents of a directory before rebalancing.
+func (s *LocalityStats) PacksPerDirectoryBefore() float64 {
+	return perDirectory(s.PacksBefore, s.Directories)
+}
+
+// PacksPerDirectoryAfter returns the average number of pack blobs holding contents of a directory after rebalancing.
+func (s *LocalityStats) PacksPerDirectoryAfter() float64 {
+	return perDirectory(s.PacksAfter, s.Directories)
+}
+
+func perDirectory(v int64, dirs int) float64 {
+	if dirs == 0 {
+		return 0
+	}
+
+	return float64(v) / float64(dirs)
+}
+
+// placement describes the pack blobs holding a sequence of contents.
+type placement struct {
+	packs      int64
+	switches   int64
+	totalBytes int64
+}
+
+func placementOf(infos []content.Info) placement {
+	var (
+		p     placement
+		packs = map[blob.ID]bool{}
+	)
+
+	for i, ci := range infos {
+		if !packs[ci.PackBlobID] {
+			packs[ci.PackBlobID] = true
+			p.packs++
+		}
+
+		if i > 0 && infos[i-1].PackBlobID != ci.PackBlobID {
+			p.switches++
+		}
+
+		p.totalBytes += int64(ci.PackedLength)
+	}
+
+	return p
+}
+
+type rebalancer struct {
+	rep         repo.DirectRepositoryWriter
+	opt         RebalanceOptions
+	maxPackSize int64
+
+	// directories and contents which have already been processed.
+	seen *bigmap.Set
+
+	unflushedBytes int64
+	stats          LocalityStats
+}
+
+// RebalanceForLocality rewrites contents of files in the same directory of the provided snapshots into
+// shared pack blobs, so that restoring a directory reads a small number of packs sequentially.
+//
+// Content IDs are not changed, only the pack blobs holding the contents, superseded copies are removed by
+// subsequent full maintenance. Directories whose contents are already well placed are not rewritten,
+// so an interrupted run can be resumed by running it again.
+//
+// Rebalancing runs under the maintenance lock and is recorded in the maintenance schedule as a full content
+// rewrite, so that pack blobs orphaned by it are only deleted after the usual safety delay.
+func RebalanceForLocality(ctx context.Context, rep repo.DirectRepositoryWriter, opt RebalanceOptions) (*LocalityStats, error) {
+	var st *LocalityStats
+
+	err := maintenance.RunExclusiveWithoutRescheduling(ctx, rep, maintenance.ModeFull, opt.Force, func(ctx context.Context, _ maintenance.RunParameters) error {
+		run := func() error {
+			var err error
+
+			st, err = rebalanceForLocality(ctx, rep, opt)
+
+			return err
+		}
+
+		if opt.DryRun {
+			return run()
+		}
+
+		//nolint:wrapcheck
+		return maintenance.ReportRun(ctx, rep, maintenance.TaskRewriteContentsFull, nil, run)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error running rebalance")
+	}
+
+	if st == nil {
+		return nil, maintenance.ErrMaintenanceInProgress
+	}
+
+	return st, nil
+}
+
+func rebalanceForLocality(ctx context.Context, rep repo.DirectRepositoryWriter, opt RebalanceOptions) (*LocalityStats, error) {
+	mp, err := rep.ContentReader().ContentFormat().GetMutableParameters(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "mutable parameters")
+	}
+
+	if opt.MaxPackSpread <= 0 {
+		opt.MaxPackSpread = defaultMaxPackSpread
+	}
+
+	if opt.FlushEveryBytes <= 0 {
+		opt.FlushEveryBytes = int64(mp.MaxPackSize) * defaultRebalanceFlushPacks
+	}
+
+	manifests := opt.Manifests
+
+	if len(manifests) == 0 {
+		ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list snapshot manifest IDs")
+		}
+
+		manifests, err = snapshot.LoadSnapshots(ctx, rep, ids)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load manifest IDs")
+		}
+	}
+
+	seen, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+	defer seen.Close(ctx)
+
+	rb := &rebalancer{
+		rep:         rep,
+		opt:         opt,
+		maxPackSize: int64(mp.MaxPackSize),
+		seen:        seen,
+	}
+
+	for _, m := range manifests {
+		root, err := snapshotfs.SnapshotRoot(rep, m)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get snapshot root")
+		}
+
+		dir, ok := root.(fs.Directory)
+		if !ok {
+			continue
+		}
+
+		if err := rb.processDirectory(ctx, dir, "."); err != nil {
+			return nil, errors.Wrapf(err, "error rebalancing snapshot %v", m.ID)
+		}
+	}
+
+	if !opt.DryRun {
+		if err := rep.Flush(ctx); err != nil {
+			return nil, errors.Wrap(err, "flush error")
+		}
+	}
+
+	return &rb.stats, nil
+}
+
+func (rb *rebalancer) markSeen(ctx context.Context, oid object.ID) bool {
+	var buf [128]byte
+
+	return rb.seen.Put(ctx, append(oid.Append(buf[:0]), '/'))
+}
+
+func (rb *rebalancer) processDirectory(ctx context.Context, dir fs.Directory, dirPath string) error {
+	if h, ok := dir.(object.HasObjectID); ok && !rb.markSeen(ctx, h.ObjectID()) {
+		return nil
+	}
+
+	entries, err := fs.GetAllEntries(ctx, dir)
+	if err != nil {
+		return errors.Wrapf(err, "error reading directory %v", dirPath)
+	}
+
+	// read in the same order as restore.
+	fs.Sort(entries)
+
+	var (
+		infos   []content.Info
+		subdirs []fs.Directory
+		cidbuf  [128]byte
+	)
+
+	for _, e := range entries {
+		if sd, ok := e.(fs.Directory); ok {
+			subdirs = append(subdirs, sd)
+			continue
+		}
+
+		h, ok := e.(object.HasObjectID)
+		if !ok || h.ObjectID() == object.EmptyID {
+			continue
+		}
+
+		if _, ok := e.(fs.File); !ok {
+			continue
+		}
+
+		contentIDs, err := rb.rep.VerifyObject(ctx, h.ObjectID())
+		if err != nil {
+			return errors.Wrapf(err, "error verifying %v/%v", dirPath, e.Name())
+		}
+
+		for _, cid := range contentIDs {
+			// contents shared with directories processed earlier stay where they were placed.
+			if !rb.seen.Put(ctx, cid.Append(cidbuf[:0])) {
+				continue
+			}
+
+			ci, err := rb.rep.ContentInfo(ctx, cid)
+			if err != nil {
+				return errors.Wrapf(err, "error getting content info for %v", cid)
+			}
+
+			infos = append(infos, ci)
+		}
+	}
+
+	if len(infos) > 0 {
+		if err := rb.maybeRebalance(ctx, dirPath, infos); err != nil {
+			return err
+		}
+	}
+
+	for _, sd := range subdirs {
+		if err := rb.processDirectory(ctx, sd, dirPath+"/"+sd.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rb *rebalancer) maybeRebalance(ctx context.Context, dirPath string, infos []content.Info) error {
+	before := placementOf(infos)
+	minPacks := max((before.totalBytes+rb.maxPackSize-1)/rb.maxPackSize, 1)
+
+	rb.stats.Directories++
+	rb.stats.PacksBefore += before.packs
+	rb.stats.PackSwitchesBefore += before.switches
+
+	if rb.stats.Directories%rebalanceProgressDirsPeriod == 0 {
+		log(ctx).Infof("Analyzed %v directories, rebalanced %v...", rb.stats.Directories, rb.stats.DirectoriesRebalanced)
+	}
+
+	if float64(before.packs) <= float64(minPacks)*rb.opt.MaxPackSpread {
+		rb.stats.PacksAfter += before.packs
+		rb.stats.PackSwitchesAfter += before.switches
+
+		return nil
+	}
+
+	log(ctx).Debugf("rebalancing %v: %v contents in %v packs (min %v)", dirPath, len(infos), before.packs, minPacks)
+
+	rb.stats.DirectoriesRebalanced++
+	rb.stats.ContentsRewritten += len(infos)
+	rb.stats.BytesRewritten += before.totalBytes
+
+	if rb.opt.DryRun {
+		rb.stats.PacksAfter += minPacks
+		rb.stats.PackSwitchesAfter += minPacks - 1
+
+		return nil
+	}
+
+	after := make([]content.Info, 0, len(infos))
+
+	for _, ci := range infos {
+		if err := rb.rep.ContentManager().RewriteContent(ctx, ci.ContentID); err != nil {
+			return errors.Wrapf(err, "error rewriting content %v", ci.ContentID)
+		}
+
+		ci2, err := rb.rep.ContentInfo(ctx, ci.ContentID)
+		if err != nil {
+			return errors.Wrapf(err, "error getting content info for %v", ci.ContentID)
+		}
+
+		after = append(after, ci2)
+	}
+
+	p := placementOf(after)
+	rb.stats.PacksAfter += p.packs
+	rb.stats.PackSwitchesAfter += p.switches
+
+	rb.unflushedBytes += before.totalBytes
+	if rb.unflushedBytes >= rb.opt.FlushEveryBytes {
+		rb.unflushedBytes = 0
+
+		if err := rb.rep.Flush(ctx); err != nil {
+			return errors.Wrap(err, "flush error")
+		}
+	}
+
+	return nil
+}
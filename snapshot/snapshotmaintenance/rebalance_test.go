@@ -0,0 +1,146 @@
+package snapshotmaintenance_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
+)
+
+func (s *formatSpecificTestSuite) TestRebalanceForLocality(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	si := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/foo"}
+
+	const numRounds = 5
+
+	// files of both directories are added in rounds and each round is flushed to its own pack blob,
+	// so that files of each directory end up spread across all packs.
+	var man *snapshot.Manifest
+
+	for i := range numRounds {
+		for _, d := range []string{"a", "b"} {
+			if i == 0 {
+				th.sourceDir.AddDir(d, defaultPermissions)
+			}
+
+			th.sourceDir.AddFile(fmt.Sprintf("%v/f%v", d, i), []byte(fmt.Sprintf("file %v in %v", i, d)), defaultPermissions)
+		}
+
+		man = mustSnapshot(t, th.RepositoryWriter, th.sourceDir, si)
+		mustFlush(t, th.RepositoryWriter)
+	}
+
+	contentIDsBefore := fileContentIDs(t, th, man)
+
+	opt := snapshotmaintenance.RebalanceOptions{Manifests: []*snapshot.Manifest{man}, Force: true}
+
+	dryRunOpt := opt
+	dryRunOpt.DryRun = true
+
+	st, err := snapshotmaintenance.RebalanceForLocality(ctx, th.RepositoryWriter, dryRunOpt)
+	require.NoError(t, err)
+	require.Equal(t, 2, st.Directories)
+	require.Equal(t, 2, st.DirectoriesRebalanced)
+	require.EqualValues(t, 2*numRounds, st.PacksBefore)
+	require.EqualValues(t, 2, st.PacksAfter)
+	require.InDelta(t, numRounds, st.PacksPerDirectoryBefore(), 0.01)
+
+	// dry run leaves contents in place.
+	st2, err := snapshotmaintenance.RebalanceForLocality(ctx, th.RepositoryWriter, dryRunOpt)
+	require.NoError(t, err)
+	require.Equal(t, st, st2)
+
+	st, err = snapshotmaintenance.RebalanceForLocality(ctx, th.RepositoryWriter, opt)
+	require.NoError(t, err)
+	require.Equal(t, 2, st.DirectoriesRebalanced)
+	require.Equal(t, 2*numRounds, st.ContentsRewritten)
+	require.EqualValues(t, 2*(numRounds-1), st.PackSwitchesBefore)
+	require.LessOrEqual(t, st.PacksAfter, int64(2))
+	require.InDelta(t, 1, st.PacksPerDirectoryAfter(), 0.01)
+
+	// rebalancing is recorded as a content rewrite, which delays deletion of the orphaned packs.
+	sched, err := maintenance.GetSchedule(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, sched.Runs[maintenance.TaskRewriteContentsFull], 1)
+	require.True(t, sched.Runs[maintenance.TaskRewriteContentsFull][0].Success)
+
+	// content IDs and data are unchanged.
+	require.Equal(t, contentIDsBefore, fileContentIDs(t, th, man))
+	verifyFiles(t, th, man, numRounds)
+
+	// running again finds nothing to do.
+	st, err = snapshotmaintenance.RebalanceForLocality(ctx, th.RepositoryWriter, opt)
+	require.NoError(t, err)
+	require.Zero(t, st.DirectoriesRebalanced)
+	require.Zero(t, st.ContentsRewritten)
+	require.EqualValues(t, 2, st.PacksBefore)
+
+	// all snapshots are processed by default and contents already placed are not moved again.
+	st, err = snapshotmaintenance.RebalanceForLocality(ctx, th.RepositoryWriter, snapshotmaintenance.RebalanceOptions{Force: true})
+	require.NoError(t, err)
+	require.Zero(t, st.ContentsRewritten)
+}
+
+func fileContentIDs(t *testing.T, th *testHarness, man *snapshot.Manifest) map[content.ID]bool {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+	result := map[content.ID]bool{}
+
+	root, err := snapshotfs.SnapshotRoot(th.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	for _, d := range []string{"a", "b"} {
+		e, err := snapshotfs.GetNestedEntry(ctx, root, []string{d})
+		require.NoError(t, err)
+
+		entries, err := fs.GetAllEntries(ctx, e.(fs.Directory))
+		require.NoError(t, err)
+
+		for _, fe := range entries {
+			cids, err := th.RepositoryWriter.VerifyObject(ctx, fe.(snapshot.HasDirEntry).DirEntry().ObjectID)
+			require.NoError(t, err)
+
+			for _, cid := range cids {
+				result[cid] = true
+			}
+		}
+	}
+
+	return result
+}
+
+func verifyFiles(t *testing.T, th *testHarness, man *snapshot.Manifest, numRounds int) {
+	t.Helper()
+
+	ctx := testlogging.Context(t)
+
+	root, err := snapshotfs.SnapshotRoot(th.RepositoryWriter, man)
+	require.NoError(t, err)
+
+	for _, d := range []string{"a", "b"} {
+		for i := range numRounds {
+			e, err := snapshotfs.GetNestedEntry(ctx, root, []string{d, fmt.Sprintf("f%v", i)})
+			require.NoError(t, err)
+
+			r, err := e.(fs.File).Open(ctx)
+			require.NoError(t, err)
+
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, fmt.Sprintf("file %v in %v", i, d), string(data))
+		}
+	}
+}
@@ -8,6 +8,7 @@
 
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/snapshotgc"
 )
 
@@ -16,6 +17,16 @@ func Run(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.M
 	//nolint:wrapcheck
 	return maintenance.RunExclusive(ctx, dr, mode, force,
 		func(ctx context.Context, runParams maintenance.RunParameters) error {
+			// expire contents before snapshot GC, which would otherwise undelete them.
+			if ttl := runParams.Params.UnsafeContentTTL; runParams.Mode == maintenance.ModeFull && ttl > 0 {
+				if err := maintenance.ReportRun(ctx, dr, maintenance.TaskExpireContents, nil, func() error {
+					_, err := ExpireContents(ctx, dr, runParams.MaintenanceStartTime.Add(-ttl))
+					return err
+				}); err != nil {
+					return errors.Wrap(err, "content expiration failure")
+				}
+			}
+
 			// run snapshot GC before full maintenance
 			if runParams.Mode == maintenance.ModeFull {
 				if _, err := snapshotgc.Run(ctx, dr, true, safety, runParams.MaintenanceStartTime); err != nil {
@@ -23,7 +34,42 @@ func(ctx context.Context, runParams maintenance.RunParameters) error {
 				}
 			}
 
-			//nolint:wrapcheck
-			return maintenance.Run(ctx, runParams, safety)
+			if err := maintenance.Run(ctx, runParams, safety); err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			// stats are informational, failing to record them does not fail maintenance.
+			if err := recordStats(ctx, dr, runParams); err != nil {
+				log(ctx).Errorf("unable to record repository stats: %v", err)
+			}
+
+			return nil
 		})
 }
+
+// recordStats appends the current repository statistics to the stats history when enabled,
+// which only happens during full maintenance since collecting them lists all blobs and contents.
+func recordStats(ctx context.Context, dr repo.DirectRepositoryWriter, runParams maintenance.RunParameters) error {
+	opt := runParams.Params.StatsHistory.OrDefault()
+	if !opt.Enabled || runParams.Mode != maintenance.ModeFull {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return maintenance.ReportRun(ctx, dr, maintenance.TaskRecordStats, nil, func() error {
+		st, err := maintenance.CollectStats(ctx, dr)
+		if err != nil {
+			return errors.Wrap(err, "unable to collect repository stats")
+		}
+
+		snapshotIDs, err := snapshot.ListSnapshotManifests(ctx, dr, nil, nil)
+		if err != nil {
+			return errors.Wrap(err, "unable to list snapshots")
+		}
+
+		st.Mode = runParams.Mode
+		st.SnapshotCount = len(snapshotIDs)
+
+		return maintenance.RecordStats(ctx, dr, *st, opt)
+	})
+}
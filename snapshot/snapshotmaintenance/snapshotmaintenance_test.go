@@ -21,6 +21,8 @@
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/retentionlock"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
 )
 
@@ -231,6 +233,59 @@ func (s *formatSpecificTestSuite) TestSnapshotGCMinContentAgeSafety(t *testing.T
 	checkContentDeletion(t, th.Repository, cids, false)
 }
 
+func (s *formatSpecificTestSuite) TestSnapshotGCRetentionLock(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	th.sourceDir.AddFile("locked", []byte{1, 2, 3, 4}, defaultPermissions)
+	th.sourceDir.AddFile("unlocked", []byte{5, 6, 7, 8}, defaultPermissions)
+
+	si := snapshot.SourceInfo{
+		Host:     "host",
+		UserName: "user",
+		Path:     "/foo",
+	}
+
+	s1 := mustSnapshot(t, th.RepositoryWriter, th.sourceDir, si)
+
+	const lockDuration = 7 * 24 * time.Hour
+
+	l, err := retentionlock.LockFile(ctx, th.RepositoryWriter, s1, "locked", th.fakeTime.NowFunc()().Add(lockDuration), "")
+	require.NoError(t, err)
+
+	rootEntry, err := snapshotfs.SnapshotRoot(th.RepositoryWriter, s1)
+	require.NoError(t, err)
+
+	unlocked, err := snapshotfs.GetNestedEntry(ctx, rootEntry, []string{"unlocked"})
+	require.NoError(t, err)
+
+	lockedCID := mustGetContentID(t, l.ObjectID)
+	unlockedCID := mustGetContentID(t, unlocked.(object.HasObjectID).ObjectID())
+
+	// the lock keeps the file after the snapshot has been deleted.
+	require.NoError(t, th.RepositoryWriter.DeleteManifest(ctx, s1.ID))
+	mustFlush(t, th.RepositoryWriter)
+
+	th.fakeTime.Advance(maintenance.SafetyFull.MinContentAgeSubjectToGC + time.Hour)
+
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+	mustFlush(t, th.RepositoryWriter)
+
+	checkContentDeletion(t, th.RepositoryWriter, []content.ID{lockedCID}, false)
+	checkContentDeletion(t, th.RepositoryWriter, []content.ID{unlockedCID}, true)
+
+	_, err = th.RepositoryWriter.VerifyObject(ctx, l.ObjectID)
+	require.NoError(t, err)
+
+	// once the lock expires, the file is garbage-collected.
+	th.fakeTime.Advance(lockDuration)
+
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+	mustFlush(t, th.RepositoryWriter)
+
+	checkContentDeletion(t, th.RepositoryWriter, []content.ID{lockedCID}, true)
+}
+
 func newTestHarness(t *testing.T, formatVersion format.Version) *testHarness {
 	t.Helper()
 
@@ -247,6 +302,58 @@ func newTestHarness(t *testing.T, formatVersion format.Version) *testHarness {
 	return th
 }
 
+func (s *formatSpecificTestSuite) TestMaintenanceRecordsStats(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	th.sourceDir.AddFile("f1", []byte{1, 2, 3, 4}, defaultPermissions)
+
+	si := snapshot.SourceInfo{
+		Host:     "host",
+		UserName: "user",
+		Path:     "/foo",
+	}
+
+	mustSnapshot(t, th.RepositoryWriter, th.sourceDir, si)
+	mustSnapshot(t, th.RepositoryWriter, th.sourceDir, si)
+	mustFlush(t, th.RepositoryWriter)
+
+	// stats are not recorded by default.
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+
+	points, err := maintenance.GetStatsHistory(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Empty(t, points)
+
+	p, err := maintenance.GetParams(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+
+	p.StatsHistory.Enabled = true
+	require.NoError(t, maintenance.SetParams(ctx, th.RepositoryWriter, p))
+
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+
+	// quick maintenance does not record stats.
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeQuick, true, maintenance.SafetyFull))
+
+	points, err = maintenance.GetStatsHistory(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	require.Equal(t, maintenance.ModeFull, points[0].Mode)
+	require.Equal(t, 2, points[0].SnapshotCount)
+	require.Positive(t, points[0].ContentCount)
+
+	// recording can be disabled again.
+	p.StatsHistory.Enabled = false
+	require.NoError(t, maintenance.SetParams(ctx, th.RepositoryWriter, p))
+
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+
+	points, err = maintenance.GetStatsHistory(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+}
+
 func (s *formatSpecificTestSuite) TestMaintenanceAutoLiveness(t *testing.T) {
 	ft := faketime.NewClockTimeWithOffset(0)
 
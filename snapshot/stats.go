@@ -34,6 +34,9 @@ type Stats struct {
 	IgnoredErrorCount int32 `json:"ignoredErrorCount"`
 	// +checkatomic
 	ErrorCount int32 `json:"errorCount"`
+
+	// +checkatomic
+	ChunkLimitExceededFileCount int32 `json:"chunkLimitExceededFileCount,omitempty"`
 }
 
 // AddExcluded adds the information about excluded file to the statistics.
@@ -0,0 +1,58 @@
+package endtoend_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestBlobVerify(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--blob-checksum=SHA256")
+	e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+	e.RunAndExpectSuccess(t, "blob", "verify")
+
+	var packFile string
+
+	require.NoError(t, filepath.WalkDir(e.RepoDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(e.RepoDir, path)
+		if err != nil {
+			return err
+		}
+
+		// files are sharded into subdirectories, so look at the relative path.
+		if !d.IsDir() && strings.HasPrefix(rel, "p") && packFile == "" {
+			packFile = path
+		}
+
+		return nil
+	}))
+
+	require.NotEmpty(t, packFile)
+
+	b, err := os.ReadFile(packFile)
+	require.NoError(t, err)
+
+	b[0] ^= 1
+
+	require.NoError(t, os.WriteFile(packFile, b, 0o600))
+
+	e.RunAndExpectFailure(t, "blob", "verify")
+	e.RunAndExpectSuccess(t, "blob", "verify", "--prefix=q")
+}
@@ -4,9 +4,12 @@
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/cli"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
 	"github.com/kopia/kopia/tests/testenv"
 )
 
@@ -74,3 +77,26 @@ func (s *formatSpecificTestSuite) TestFullMaintenance(t *testing.T) {
 		t.Fatalf("maintenance left unwanted blobs: %v, want %v", got, want)
 	}
 }
+
+func TestMaintenanceRebalance(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+
+	var dryRun, st snapshotmaintenance.LocalityStats
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "rebalance", "--dry-run", "--json"), &dryRun)
+	require.Positive(t, dryRun.Directories)
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "rebalance", "--json"), &st)
+	require.Equal(t, dryRun.DirectoriesRebalanced, st.DirectoriesRebalanced)
+	require.LessOrEqual(t, st.PacksAfter, st.PacksBefore)
+
+	e.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+}
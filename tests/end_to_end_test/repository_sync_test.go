@@ -3,6 +3,8 @@
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/clitestutil"
 	"github.com/kopia/kopia/tests/testenv"
@@ -55,3 +57,29 @@ func TestRepositorySync(t *testing.T) {
 	// syncing to the directory should fail because it contains incompatible format blob.
 	e2.RunAndExpectFailure(t, "repo", "sync-to", "filesystem", "--path", dir2)
 }
+
+func TestRepositorySyncWithBlobChecksum(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--blob-checksum=CRC32C")
+	e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+
+	require.Contains(t, e.RunAndExpectSuccess(t, "repo", "status"), "Blob checksum:       CRC32C")
+
+	sources := clitestutil.ListSnapshotsAndExpectSuccess(t, e)
+
+	// synchronized blobs keep their checksums.
+	dir2 := testutil.TempDirectory(t)
+	e.RunAndExpectSuccess(t, "repo", "sync-to", "filesystem", "--path", dir2)
+	e.RunAndExpectSuccess(t, "repo", "sync-to", "filesystem", "--path", dir2)
+
+	e.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", dir2)
+	e.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+
+	require.Len(t, clitestutil.ListSnapshotsAndExpectSuccess(t, e), len(sources))
+}
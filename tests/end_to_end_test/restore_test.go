@@ -12,7 +12,9 @@
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -922,3 +924,57 @@ func TestRestoreByPathWithoutTarget(t *testing.T) {
 	// Defaults to latest snapshot time
 	e.RunAndExpectSuccess(t, "restore", srcdir)
 }
+
+func TestRestoreWithPlanFile(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	sourceDir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("bbb"), 0o644))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", sourceDir)
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "c.txt"), []byte("ccc"), 0o644))
+	e.RunAndExpectSuccess(t, "snapshot", "create", sourceDir)
+
+	si := clitestutil.ListSnapshotsAndExpectSuccess(t, e, sourceDir)
+	require.Len(t, si, 1)
+	require.Len(t, si[0].Snapshots, 2)
+
+	snap1 := si[0].Snapshots[0].SnapshotID
+	snap2 := si[0].Snapshots[1].SnapshotID
+
+	planFile := filepath.Join(testutil.TempDirectory(t), "restore.plan")
+
+	restore := func(snapID string) (string, []string) {
+		restoreDir := testutil.TempDirectory(t)
+		_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "restore", snapID, restoreDir, "--plan-file", planFile)
+
+		return restoreDir, stderr
+	}
+
+	hasLine := func(lines []string, prefix string) bool {
+		return slices.ContainsFunc(lines, func(l string) bool { return strings.HasPrefix(l, prefix) })
+	}
+
+	_, stderr := restore(snap1)
+	require.True(t, hasLine(stderr, "Saved restore plan with 3 entries"), stderr)
+	require.FileExists(t, planFile)
+
+	restoreDir, stderr := restore(snap1)
+	require.True(t, hasLine(stderr, "Using restore plan with 3 entries"), stderr)
+	require.FileExists(t, filepath.Join(restoreDir, "sub", "b.txt"))
+
+	// the plan is rebuilt when restoring a different snapshot.
+	restoreDir, stderr = restore(snap2)
+	require.True(t, hasLine(stderr, "Not using restore plan"), stderr)
+	require.True(t, hasLine(stderr, "Saved restore plan with 4 entries"), stderr)
+	require.FileExists(t, filepath.Join(restoreDir, "c.txt"))
+}